@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/devluispereira/go-package/server"
+	"github.com/gofiber/fiber/v2"
+)
+
+// actorLocal is the fiber local holding the actor authenticated by the admin token.
+const actorLocal = "admin.actor"
+
+// Config holds the configuration of MountWithConfig. It can be loaded with the config package.
+type Config struct {
+	// Tokens maps each actor to its admin token, e.g. ADMIN_TOKENS="maria=<token>,deploy-bot=<token>". The actor
+	// of the token used is recorded in the audit logs.
+	Tokens map[string]string `json:"tokens" env:"ADMIN_TOKENS"`
+}
+
+// Mount creates a route group for admin endpoints, protected by a static token.
+//
+// Parameters:
+//
+//	app: Fiber app (usually server.App).
+//	prefix: Path prefix for the admin routes, e.g. "/admin".
+//	token: Token expected in the "Authorization: Bearer <token>" or "X-Admin-Token" header.
+//	       If empty, every admin request is rejected.
+//
+// Changes are audit logged with the actor "admin"; use MountWithConfig to give each operator a token.
+//
+// Usage:
+//
+//	r := admin.Mount(srv.App, "/admin", os.Getenv("ADMIN_TOKEN"))
+//	admin.RegisterSettings(r, settings.Default)
+func Mount(app *fiber.App, prefix string, token string) fiber.Router {
+	cfg := Config{}
+	if token != "" {
+		cfg.Tokens = map[string]string{"admin": token}
+	}
+	return MountWithConfig(app, prefix, cfg)
+}
+
+// MountWithConfig creates a route group for admin endpoints, protected by a token per actor.
+//
+// Behavior:
+//   - The token is read from the "Authorization: Bearer <token>" or "X-Admin-Token" header and compared in
+//     constant time with every configured token. Without tokens, every admin request is rejected.
+//   - The actor of audit logs is the subject of the claims authenticated by the app (see server.WithClaims), or
+//     the actor of the token.
+//
+// Usage:
+//
+//	var cfg admin.Config
+//	_ = config.Load(&cfg)
+//	r := admin.MountWithConfig(srv.App, "/admin", cfg)
+func MountWithConfig(app *fiber.App, prefix string, cfg Config) fiber.Router {
+	return app.Group(prefix, func(c *fiber.Ctx) error {
+		provided := c.Get("X-Admin-Token")
+		if provided == "" {
+			provided = strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		}
+
+		authenticated := ""
+		for actor, token := range cfg.Tokens {
+			if token != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+				authenticated = actor
+			}
+		}
+
+		if authenticated == "" {
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid admin token")
+		}
+
+		c.Locals(actorLocal, authenticated)
+		return c.Next()
+	})
+}
+
+// actor identifies who performed an admin action, for audit logging: the authenticated subject, or the actor of
+// the admin token.
+func actor(c *fiber.Ctx) string {
+	if claims, ok := server.ClaimsFromContext(c.UserContext()); ok && claims.Subject != "" {
+		return claims.Subject
+	}
+	if a, ok := c.Locals(actorLocal).(string); ok {
+		return a
+	}
+	return c.IP()
+}
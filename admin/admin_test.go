@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/devluispereira/go-package/settings"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSettingsChangesRecordTheTokenActor(t *testing.T) {
+	store := settings.NewStore()
+	var actors []string
+	store.Subscribe(func(change settings.Change) { actors = append(actors, change.Actor) })
+
+	app := fiber.New()
+	r := MountWithConfig(app, "/admin", Config{Tokens: map[string]string{"maria": "maria-token", "joao": "joao-token"}})
+	RegisterSettings(r, store)
+
+	put := func(token, actorHeader string) int {
+		req := httptest.NewRequest(http.MethodPut, "/admin/settings/cache_ttl_override", strings.NewReader(`{"value":"30s"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Admin-Token", token)
+		req.Header.Set("X-Admin-Actor", actorHeader)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := put("wrong", "maria"); status != http.StatusUnauthorized {
+		t.Fatalf("invalid token: status %d, want 401", status)
+	}
+	if status := put("joao-token", "maria"); status >= 300 {
+		t.Fatalf("status %d", status)
+	}
+
+	if len(actors) != 1 || actors[0] != "joao" {
+		t.Fatalf("actors %v, want [joao]", actors)
+	}
+}
+
+func TestMountWithoutTokenRejectsEverything(t *testing.T) {
+	app := fiber.New()
+	r := Mount(app, "/admin", "")
+	RegisterSettings(r, settings.NewStore())
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/settings", nil)
+	req.Header.Set("X-Admin-Token", "")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status %d, want 401", resp.StatusCode)
+	}
+}
@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/devluispereira/go-package/settings"
+	"github.com/gofiber/fiber/v2"
+)
+
+type forceRequest struct {
+	State string `json:"state"`
+}
+
+// RegisterBreakers registers endpoints to inspect and force the httpclient circuit breakers:
+//
+//	GET    /breakers              lists every breaker with its state, counts and last transition time.
+//	POST   /breakers/:name/force  forces a breaker, body {"state": "open"} or {"state": "closed"}.
+//	DELETE /breakers/:name/force  removes the override, returning the breaker to normal operation.
+//
+// Overrides are stored in the settings store under settings.BreakerForcePrefix, so they are audit logged
+// and, with settings.SyncRedis, applied to every replica.
+func RegisterBreakers(r fiber.Router, store *settings.Store) {
+	r.Get("/breakers", func(c *fiber.Ctx) error {
+		return c.JSON(httpclient.Breakers())
+	})
+
+	r.Post("/breakers/:name/force", func(c *fiber.Ctx) error {
+		name := c.Params("name")
+		if !httpclient.HasBreaker(name) {
+			return fiber.NewError(fiber.StatusNotFound, "unknown breaker")
+		}
+
+		var body forceRequest
+		if err := c.BodyParser(&body); err != nil || (body.State != "open" && body.State != "closed") {
+			return fiber.NewError(fiber.StatusBadRequest, `state must be "open" or "closed"`)
+		}
+
+		if err := store.Set(settings.BreakerForcePrefix+name, body.State, actor(c)); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		return c.JSON(httpclient.Breakers())
+	})
+
+	r.Delete("/breakers/:name/force", func(c *fiber.Ctx) error {
+		if err := store.Delete(settings.BreakerForcePrefix+c.Params("name"), actor(c)); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+}
@@ -0,0 +1,151 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterCache registers endpoints to inspect and purge the httpclient caches:
+//
+//	GET    /cache/stats               hit/miss counters, entry sizes and hottest keys of every cache.
+//	GET    /cache/:name?url=...       whether a URL is cached, with TTL remaining and stored headers.
+//	                                  Vary headers are passed as repeated header=Name:Value query parameters.
+//	GET    /cache/:name/key?url=...   the key computed for a URL and headers, with its components.
+//	DELETE /cache/:name/keys/:key     purges a key.
+//	DELETE /cache/:name?url=...       purges the entry of a URL.
+//	DELETE /cache/:name?prefix=...    purges every entry whose URL starts with prefix (requires CacheConfig.Index).
+//	DELETE /cache/:name/tags/:tag     purges every entry with the tag (requires CacheConfig.Index).
+//
+// Purges are audit logged with the authenticated actor (see MountWithConfig).
+func RegisterCache(r fiber.Router, inspectors ...*httpclient.CacheInspector) {
+	byName := make(map[string]*httpclient.CacheInspector, len(inspectors))
+	for _, inspector := range inspectors {
+		byName[inspector.Name()] = inspector
+	}
+
+	find := func(c *fiber.Ctx) (*httpclient.CacheInspector, error) {
+		inspector, ok := byName[c.Params("name")]
+		if !ok {
+			return nil, fiber.NewError(fiber.StatusNotFound, "unknown cache")
+		}
+		return inspector, nil
+	}
+
+	audit := func(c *fiber.Ctx, action, target string, purged int) {
+		logger.Info().
+			Str("actor", actor(c)).
+			Str("cache", c.Params("name")).
+			Str("action", action).
+			Str("target", target).
+			Int("purged", purged).
+			Msg("admin:cache purge")
+	}
+
+	r.Get("/cache/stats", func(c *fiber.Ctx) error {
+		return c.JSON(httpclient.CacheStats())
+	})
+
+	r.Get("/cache/:name", func(c *fiber.Ctx) error {
+		inspector, err := find(c)
+		if err != nil {
+			return err
+		}
+
+		info, err := inspector.Lookup(c.UserContext(), c.Query("url"), queryHeaders(c))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		return c.JSON(info)
+	})
+
+	r.Get("/cache/:name/key", func(c *fiber.Ctx) error {
+		inspector, err := find(c)
+		if err != nil {
+			return err
+		}
+
+		if c.Query("url") == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "url is required")
+		}
+
+		components, err := inspector.KeyComponents(c.Query("url"), queryHeaders(c))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		return c.JSON(components)
+	})
+
+	r.Delete("/cache/:name", func(c *fiber.Ctx) error {
+		inspector, err := find(c)
+		if err != nil {
+			return err
+		}
+
+		if prefix := c.Query("prefix"); prefix != "" {
+			purged, err := inspector.PurgePrefix(c.UserContext(), prefix)
+			if err != nil {
+				return err
+			}
+
+			audit(c, "prefix", prefix, purged)
+			return c.JSON(fiber.Map{"purged": purged})
+		}
+
+		key, err := inspector.Key(c.Query("url"), queryHeaders(c))
+		if err != nil || c.Query("url") == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "url or prefix is required")
+		}
+
+		if err := inspector.PurgeKey(c.UserContext(), key); err != nil {
+			return err
+		}
+
+		audit(c, "url", c.Query("url"), 1)
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	r.Delete("/cache/:name/keys/:key", func(c *fiber.Ctx) error {
+		inspector, err := find(c)
+		if err != nil {
+			return err
+		}
+
+		if err := inspector.PurgeKey(c.UserContext(), c.Params("key")); err != nil {
+			return err
+		}
+
+		audit(c, "key", c.Params("key"), 1)
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	r.Delete("/cache/:name/tags/:tag", func(c *fiber.Ctx) error {
+		inspector, err := find(c)
+		if err != nil {
+			return err
+		}
+
+		purged, err := inspector.PurgeTag(c.UserContext(), c.Params("tag"))
+		if err != nil {
+			return err
+		}
+
+		audit(c, "tag", c.Params("tag"), purged)
+		return c.JSON(fiber.Map{"purged": purged})
+	})
+}
+
+// queryHeaders reads the repeated header=Name:Value query parameters.
+func queryHeaders(c *fiber.Ctx) http.Header {
+	headers := make(http.Header)
+	for _, raw := range c.Context().QueryArgs().PeekMulti("header") {
+		if name, value, ok := strings.Cut(string(raw), ":"); ok {
+			headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+	}
+	return headers
+}
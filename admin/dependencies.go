@@ -0,0 +1,27 @@
+package admin
+
+import (
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterDependencies registers an endpoint rendering the dependency report recorded by
+// httpclient.DependencyRecorder for every service:
+//
+//	GET /dependencies             JSON map of service to the downstream routes it calls.
+//	GET /dependencies?format=dot  Graphviz DOT graph of services and downstream hosts.
+func RegisterDependencies(r fiber.Router, client httpclient.IDependencyRedisClient) {
+	r.Get("/dependencies", func(c *fiber.Ctx) error {
+		graph, err := httpclient.ReadDependencyGraph(c.UserContext(), client)
+		if err != nil {
+			return err
+		}
+
+		if c.Query("format") == "dot" {
+			c.Set(fiber.HeaderContentType, "text/vnd.graphviz")
+			return c.SendString(graph.DOT())
+		}
+
+		return c.JSON(graph)
+	})
+}
@@ -0,0 +1,167 @@
+package admin
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/devluispereira/go-package/buildinfo"
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/devluispereira/go-package/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Diagnostics describes what a pod is actually running: its build, effective configuration, routes and
+// downstream clients.
+type Diagnostics struct {
+	// App is the Fiber app whose routes and middleware chains are reported.
+	App *fiber.App
+	// Config holds the configuration sections, e.g. {"server": serverCfg, "redis": redisCfg}. Secrets are
+	// masked with config.Redact.
+	Config map[string]any
+	// Clients is the registry of the downstream clients, if any.
+	Clients *httpclient.ClientRegistry
+}
+
+// DiagnosticsReport is the output of the diagnostics endpoint.
+type DiagnosticsReport struct {
+	Build   buildinfo.Info `json:"build"`
+	Config  map[string]any `json:"config"`
+	Routes  []RouteInfo    `json:"routes"`
+	Clients map[string]any `json:"clients,omitempty"`
+}
+
+// RouteInfo describes a route and the handlers a request to it goes through, middlewares first.
+type RouteInfo struct {
+	Method   string   `json:"method"`
+	Path     string   `json:"path"`
+	Handlers []string `json:"handlers"`
+}
+
+// Report collects the diagnostics. Routes are read at call time, so routes registered after the admin ones are
+// included.
+func (d Diagnostics) Report() DiagnosticsReport {
+	report := DiagnosticsReport{
+		Build:  buildinfo.Get(),
+		Config: make(map[string]any, len(d.Config)),
+	}
+
+	for name, section := range d.Config {
+		report.Config[name] = config.Redact(section)
+	}
+
+	if d.App != nil {
+		report.Routes = routes(d.App)
+	}
+
+	if d.Clients != nil {
+		report.Clients = make(map[string]any)
+		for name, client := range d.Clients.Configs() {
+			report.Clients[name] = config.Redact(client)
+		}
+	}
+
+	return report
+}
+
+// Log writes the diagnostics as a single startup log block. Its signature matches server.Hook, so it can be
+// registered with srv.OnStart, after every route is registered.
+func (d Diagnostics) Log(_ context.Context) error {
+	report := d.Report()
+
+	logger.Info().
+		Interface("build", report.Build).
+		Interface("config", report.Config).
+		Interface("routes", report.Routes).
+		Interface("clients", report.Clients).
+		Msg("startup diagnostics")
+	return nil
+}
+
+// RegisterDiagnostics registers an endpoint reporting what the pod is running:
+//
+//	GET /diagnostics  build info (version, commit, Go version), effective configuration with secrets masked,
+//	                  routes with their middleware chains and downstream clients.
+//
+// Usage:
+//
+//	diag := admin.Diagnostics{App: srv.App, Config: map[string]any{"app": cfg}, Clients: registry}
+//	admin.RegisterDiagnostics(r, diag)
+//	srv.OnStart(diag.Log)
+func RegisterDiagnostics(r fiber.Router, d Diagnostics) {
+	r.Get("/diagnostics", func(c *fiber.Ctx) error {
+		return c.JSON(d.Report())
+	})
+}
+
+// routes lists the routes of app, without the HEAD routes Fiber adds for GET routes, with the middlewares
+// registered with Use (on the app or a group) that run before each of them.
+func routes(app *fiber.App) []RouteInfo {
+	all := app.GetRoutes()
+	endpoints := app.GetRoutes(true)
+
+	var (
+		result      []RouteInfo
+		middlewares = map[string][]fiber.Route{}
+		next        int
+	)
+
+	for _, route := range all {
+		isEndpoint := next < len(endpoints) && sameRoute(route, endpoints[next])
+		if !isEndpoint {
+			middlewares[route.Method] = append(middlewares[route.Method], route)
+			continue
+		}
+		next++
+
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+
+		var handlers []string
+		for _, middleware := range middlewares[route.Method] {
+			if coversPath(middleware.Path, route.Path) {
+				handlers = append(handlers, handlerNames(middleware.Handlers)...)
+			}
+		}
+		handlers = append(handlers, handlerNames(route.Handlers)...)
+
+		result = append(result, RouteInfo{Method: route.Method, Path: route.Path, Handlers: handlers})
+	}
+
+	return result
+}
+
+func sameRoute(a, b fiber.Route) bool {
+	if a.Method != b.Method || a.Path != b.Path || len(a.Handlers) != len(b.Handlers) {
+		return false
+	}
+	for i := range a.Handlers {
+		if reflect.ValueOf(a.Handlers[i]).Pointer() != reflect.ValueOf(b.Handlers[i]).Pointer() {
+			return false
+		}
+	}
+	return true
+}
+
+// coversPath reports whether a middleware registered with Use on prefix runs for path.
+func coversPath(prefix, path string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+var closureSuffix = regexp.MustCompile(`(\.func\d+)+$`)
+
+// handlerNames returns the package-qualified names of the functions creating the handlers, e.g.
+// "server.ForwardHeadersMiddleware".
+func handlerNames(handlers []fiber.Handler) []string {
+	names := make([]string, 0, len(handlers))
+	for _, handler := range handlers {
+		name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+		name = name[strings.LastIndex(name, "/")+1:]
+		names = append(names, closureSuffix.ReplaceAllString(name, ""))
+	}
+	return names
+}
@@ -0,0 +1,5 @@
+package admin
+
+import "github.com/devluispereira/go-package/logging"
+
+var logger = logging.New("admin")
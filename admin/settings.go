@@ -0,0 +1,54 @@
+package admin
+
+import (
+	"net/url"
+
+	"github.com/devluispereira/go-package/settings"
+	"github.com/gofiber/fiber/v2"
+)
+
+type settingRequest struct {
+	Value string `json:"value"`
+}
+
+// RegisterSettings registers endpoints to inspect and change runtime settings:
+//
+//	GET    /settings       lists every defined setting.
+//	PUT    /settings/:key  sets a setting, body {"value": "..."}.
+//	DELETE /settings/:key  removes a setting, restoring the default behavior.
+//
+// Changes are audit logged with the authenticated actor (see MountWithConfig).
+func RegisterSettings(r fiber.Router, store *settings.Store) {
+	r.Get("/settings", func(c *fiber.Ctx) error {
+		return c.JSON(store.All())
+	})
+
+	r.Put("/settings/:key", func(c *fiber.Ctx) error {
+		var body settingRequest
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+		}
+
+		if err := store.Set(settingKey(c), body.Value, actor(c)); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		return c.JSON(store.All())
+	})
+
+	r.Delete("/settings/:key", func(c *fiber.Ctx) error {
+		if err := store.Delete(settingKey(c), actor(c)); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+}
+
+func settingKey(c *fiber.Ctx) string {
+	key, err := url.PathUnescape(c.Params("key"))
+	if err != nil {
+		return c.Params("key")
+	}
+	return key
+}
@@ -0,0 +1,15 @@
+package admin
+
+import (
+	"github.com/devluispereira/go-package/slo"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterSLO registers an endpoint summarizing the SLO compliance of every tracked downstream:
+//
+//	GET /slo  availability, latency percentiles, burn rate and remaining error budget per downstream.
+func RegisterSLO(r fiber.Router, tracker *slo.Tracker) {
+	r.Get("/slo", func(c *fiber.Ctx) error {
+		return c.JSON(tracker.Summaries())
+	})
+}
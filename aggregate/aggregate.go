@@ -0,0 +1,200 @@
+package aggregate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/devluispereira/go-package/logging"
+)
+
+// ErrRequiredCallFailed is returned by Run when a call marked as Required fails.
+var ErrRequiredCallFailed = errors.New("required call failed")
+
+// Call is a single downstream call of an aggregation.
+type Call struct {
+	// Name is the key of the call in the merged result.
+	Name  string
+	Fetch func(ctx context.Context) (*httpclient.HTTPResponse, error)
+	// Fallback is used as the call result when it fails. If nil, the key is omitted from the data.
+	Fallback any
+	// Required makes Run fail when this call fails, instead of returning a partial result.
+	Required bool
+	// Timeout bounds this call within the shared deadline. Zero uses only the shared deadline.
+	Timeout time.Duration
+}
+
+// Get returns a Call issuing a GET request to path with client.
+func Get(client *httpclient.HTTPClient, name, path string) Call {
+	return Call{
+		Name: name,
+		Fetch: func(ctx context.Context) (*httpclient.HTTPResponse, error) {
+			return client.Get(ctx, path)
+		},
+	}
+}
+
+// WithFallback returns a copy of the call using fallback when it fails.
+func (c Call) WithFallback(fallback any) Call {
+	c.Fallback = fallback
+	return c
+}
+
+// WithTimeout returns a copy of the call bounded by timeout.
+func (c Call) WithTimeout(timeout time.Duration) Call {
+	c.Timeout = timeout
+	return c
+}
+
+// AsRequired returns a copy of the call marked as required.
+func (c Call) AsRequired() Call {
+	c.Required = true
+	return c
+}
+
+// Failure reasons reported in Result.Errors.
+const (
+	ReasonTimeout     = "timeout"
+	ReasonCanceled    = "canceled"
+	ReasonUnavailable = "unavailable"
+)
+
+// Result is the merged result of an aggregation, ready to be returned as JSON.
+type Result struct {
+	// Data holds the body of each successful call (or its fallback) by call name.
+	Data map[string]any `json:"data"`
+	// Errors holds the failure reason of each failed call by call name: ReasonTimeout, ReasonCanceled,
+	// ReasonUnavailable or "status_<code>". The underlying error is only logged, so it never reaches the client.
+	Errors map[string]string `json:"errors,omitempty"`
+	// Partial is true when at least one call failed.
+	Partial bool `json:"partial"`
+}
+
+// Run executes the calls concurrently with a shared deadline and merges their bodies into a single Result.
+//
+// Parameters:
+//
+//	ctx: Request context; forwarded headers and trace are propagated to every call.
+//	timeout: Shared deadline for the whole aggregation. Zero keeps only the ctx deadline.
+//	calls: Calls to execute.
+//
+// Behavior:
+//   - A call fails on transport error, on a non-2xx status or when its deadline expires.
+//   - Failed calls use their Fallback, when set, and are reported in Result.Errors with a stable reason; the full
+//     error, which may carry internal hosts and addresses, is logged instead.
+//   - Run waits for every call, so no goroutine outlives the request.
+//
+// Returns:
+//
+//	The merged Result, and an error wrapping ErrRequiredCallFailed when a required call failed.
+//
+// Usage:
+//
+//	result, err := aggregate.Run(c.UserContext(), 800*time.Millisecond,
+//		aggregate.Get(catalog, "product", "/products/"+id).AsRequired(),
+//		aggregate.Get(reviews, "reviews", "/reviews?product="+id).WithFallback([]any{}),
+//		aggregate.Get(pricing, "price", "/prices/"+id).WithTimeout(300*time.Millisecond),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	return c.JSON(result)
+func Run(ctx context.Context, timeout time.Duration, calls ...Call) (*Result, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type outcome struct {
+		body any
+		err  error
+	}
+
+	outcomes := make([]outcome, len(calls))
+	var wg sync.WaitGroup
+
+	for i, call := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, err := execute(ctx, call)
+			outcomes[i] = outcome{body: body, err: err}
+		}()
+	}
+
+	wg.Wait()
+
+	result := &Result{Data: make(map[string]any, len(calls))}
+	var requiredErrs []error
+
+	for i, call := range calls {
+		out := outcomes[i]
+		if out.err == nil {
+			result.Data[call.Name] = out.body
+			continue
+		}
+
+		logging.FromContext(ctx).Warn().Err(out.err).Str("layer", "aggregate").Str("call", call.Name).Msg("aggregate call failed")
+
+		result.Partial = true
+		if result.Errors == nil {
+			result.Errors = make(map[string]string)
+		}
+		result.Errors[call.Name] = failureReason(out.err)
+
+		if call.Fallback != nil {
+			result.Data[call.Name] = call.Fallback
+		}
+
+		if call.Required {
+			requiredErrs = append(requiredErrs, fmt.Errorf("%w: %s: %w", ErrRequiredCallFailed, call.Name, out.err))
+		}
+	}
+
+	return result, errors.Join(requiredErrs...)
+}
+
+func execute(ctx context.Context, call Call) (body any, err error) {
+	if call.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, call.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	resp, err := call.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &httpclient.HTTPStatusError{Status: resp.StatusCode, Err: errors.New("unexpected status")}
+	}
+
+	return resp.Body, nil
+}
+
+// failureReason maps a call error to a reason that is safe to return to clients.
+func failureReason(err error) string {
+	var statusErr *httpclient.HTTPStatusError
+	switch {
+	case errors.As(err, &statusErr):
+		return "status_" + strconv.Itoa(statusErr.Status)
+	case errors.Is(err, context.DeadlineExceeded):
+		return ReasonTimeout
+	case errors.Is(err, context.Canceled):
+		return ReasonCanceled
+	default:
+		return ReasonUnavailable
+	}
+}
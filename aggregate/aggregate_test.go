@@ -0,0 +1,52 @@
+package aggregate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devluispereira/go-package/clients/httpclient"
+)
+
+func fetchWith(resp *httpclient.HTTPResponse, err error) func(context.Context) (*httpclient.HTTPResponse, error) {
+	return func(context.Context) (*httpclient.HTTPResponse, error) { return resp, err }
+}
+
+func TestRunReportsReasonsWithoutInternalDetails(t *testing.T) {
+	dialErr := errors.New(`Get "http://10.0.3.17:8080/reviews": dial tcp 10.0.3.17:8080: connect: connection refused`)
+
+	result, err := Run(context.Background(), time.Second,
+		Call{Name: "product", Fetch: fetchWith(&httpclient.HTTPResponse{StatusCode: http.StatusOK, Body: "ok"}, nil)},
+		Call{Name: "reviews", Fetch: fetchWith(nil, dialErr)},
+		Call{Name: "price", Fetch: fetchWith(&httpclient.HTTPResponse{StatusCode: http.StatusServiceUnavailable}, nil)},
+		Call{Name: "stock", Fetch: fetchWith(nil, &httpclient.HTTPStatusError{Status: http.StatusTooManyRequests, Err: errors.New("HTTP error")})},
+		Call{Name: "shipping", Timeout: time.Millisecond, Fetch: func(ctx context.Context) (*httpclient.HTTPResponse, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}},
+	)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := map[string]string{
+		"reviews":  ReasonUnavailable,
+		"price":    "status_503",
+		"stock":    "status_429",
+		"shipping": ReasonTimeout,
+	}
+	for name, reason := range want {
+		if got := result.Errors[name]; got != reason {
+			t.Errorf("%s: reason %q, want %q", name, got, reason)
+		}
+	}
+
+	body, _ := json.Marshal(result)
+	if strings.Contains(string(body), "10.0.3.17") {
+		t.Fatalf("result leaks the internal address: %s", body)
+	}
+}
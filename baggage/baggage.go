@@ -0,0 +1,171 @@
+package baggage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	otelbaggage "go.opentelemetry.io/otel/baggage"
+)
+
+// HeaderName is the W3C header carrying the baggage between services.
+const HeaderName = "baggage"
+
+// Value lists the types a Key can carry.
+type Value interface {
+	string | bool | int | int64 | float64
+}
+
+// Key is a typed baggage entry, e.g. the experiment arm or the tenant tier of the request. Declare keys once, as
+// package variables, so every service reads and writes the same name with the same type.
+type Key[T Value] struct {
+	name string
+}
+
+// NewKey creates a Key. The name must be a valid baggage key (an HTTP token, e.g. "experiment.checkout").
+//
+// Usage:
+//
+//	var TenantTier = baggage.NewKey[string]("tenant.tier")
+//	var CheckoutArm = baggage.NewKey[int]("experiment.checkout")
+func NewKey[T Value](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Name returns the name of the key in the baggage header.
+func (k Key[T]) Name() string {
+	return k.name
+}
+
+// Set returns a copy of ctx whose baggage holds value under the key.
+//
+// Usage:
+//
+//	ctx, err := TenantTier.Set(ctx, "gold")
+func (k Key[T]) Set(ctx context.Context, value T) (context.Context, error) {
+	var raw string
+	switch v := any(value).(type) {
+	case string:
+		raw = v
+	case bool:
+		raw = strconv.FormatBool(v)
+	case int:
+		raw = strconv.Itoa(v)
+	case int64:
+		raw = strconv.FormatInt(v, 10)
+	case float64:
+		raw = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return Set(ctx, k.name, raw)
+}
+
+// Get returns the value of the key in the baggage of ctx, reporting false when it is missing or does not parse as
+// the type of the key.
+//
+// Usage:
+//
+//	tier, ok := TenantTier.Get(ctx)
+func (k Key[T]) Get(ctx context.Context) (T, bool) {
+	var value T
+
+	raw, ok := Get(ctx, k.name)
+	if !ok {
+		return value, false
+	}
+
+	var err error
+	switch v := any(&value).(type) {
+	case *string:
+		*v = raw
+	case *bool:
+		*v, err = strconv.ParseBool(raw)
+	case *int:
+		*v, err = strconv.Atoi(raw)
+	case *int64:
+		*v, err = strconv.ParseInt(raw, 10, 64)
+	case *float64:
+		*v, err = strconv.ParseFloat(raw, 64)
+	}
+	if err != nil {
+		var zero T
+		return zero, false
+	}
+	return value, true
+}
+
+// Set returns a copy of ctx whose baggage holds key=value, replacing a previous value of key. The baggage is the
+// OpenTelemetry one, so it is shared with the tracing middlewares and propagators.
+//
+// Returns:
+//   - context.Context: The context carrying the baggage.
+//   - error: When the key is not a valid baggage key or the baggage exceeds the W3C limits; ctx is returned as is.
+func Set(ctx context.Context, key, value string) (context.Context, error) {
+	member, err := otelbaggage.NewMemberRaw(key, value)
+	if err != nil {
+		return ctx, fmt.Errorf("invalid baggage member %q: %w", key, err)
+	}
+
+	b, err := otelbaggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to set baggage member %q: %w", key, err)
+	}
+
+	return otelbaggage.ContextWithBaggage(ctx, b), nil
+}
+
+// Get returns the value of key in the baggage of ctx.
+func Get(ctx context.Context, key string) (string, bool) {
+	member := otelbaggage.FromContext(ctx).Member(key)
+	if member.Key() == "" {
+		return "", false
+	}
+	return member.Value(), true
+}
+
+// Delete returns a copy of ctx whose baggage no longer holds key.
+func Delete(ctx context.Context, key string) context.Context {
+	return otelbaggage.ContextWithBaggage(ctx, otelbaggage.FromContext(ctx).DeleteMember(key))
+}
+
+// All returns the key-values of the baggage of ctx.
+func All(ctx context.Context) map[string]string {
+	members := otelbaggage.FromContext(ctx).Members()
+
+	values := make(map[string]string, len(members))
+	for _, member := range members {
+		values[member.Key()] = member.Value()
+	}
+	return values
+}
+
+// Header serializes the baggage of ctx into the W3C baggage header value, percent-encoding the values. It returns
+// an empty string when the baggage is empty.
+func Header(ctx context.Context) string {
+	return otelbaggage.FromContext(ctx).String()
+}
+
+// FromHeader returns a copy of ctx whose baggage holds the members of a W3C baggage header value. Members already in
+// ctx are kept unless the header replaces them.
+//
+// Returns:
+//   - context.Context: The context carrying the baggage.
+//   - error: When the header is malformed or exceeds the W3C limits; ctx is returned as is.
+func FromHeader(ctx context.Context, header string) (context.Context, error) {
+	if header == "" {
+		return ctx, nil
+	}
+
+	parsed, err := otelbaggage.Parse(header)
+	if err != nil {
+		return ctx, fmt.Errorf("invalid baggage header: %w", err)
+	}
+
+	b := otelbaggage.FromContext(ctx)
+	for _, member := range parsed.Members() {
+		if b, err = b.SetMember(member); err != nil {
+			return ctx, fmt.Errorf("failed to merge baggage header: %w", err)
+		}
+	}
+
+	return otelbaggage.ContextWithBaggage(ctx, b), nil
+}
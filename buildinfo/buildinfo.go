@@ -0,0 +1,93 @@
+package buildinfo
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Set at build time with ldflags, e.g.:
+//
+//	go build -ldflags "-X github.com/devluispereira/go-package/buildinfo.Version=1.4.2 \
+//		-X github.com/devluispereira/go-package/buildinfo.Commit=$(git rev-parse HEAD) \
+//		-X github.com/devluispereira/go-package/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Empty values are read from the APP_VERSION variable (Version only) and the build information embedded by the
+// Go toolchain (see Get).
+var (
+	Version   string
+	Commit    string
+	BuildTime string
+)
+
+// Info identifies the running binary.
+type Info struct {
+	// Version is the release version, e.g. "1.4.2". Without ldflags, it is APP_VERSION or the main module
+	// version, which is "(devel)" for local builds.
+	Version string `json:"version"`
+	// Commit is the VCS revision the binary was built from.
+	Commit string `json:"commit,omitempty"`
+	// BuildTime is the build (ldflags) or commit (VCS) time, in RFC 3339.
+	BuildTime string `json:"build_time,omitempty"`
+	// Modified reports a build from a working tree with uncommitted changes.
+	Modified  bool   `json:"modified,omitempty"`
+	Module    string `json:"module,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+var load = sync.OnceValue(read)
+
+// Get returns the build information of the running binary, from the ldflags variables with a fallback to
+// APP_VERSION and debug.ReadBuildInfo (module version and the vcs.* settings stamped by "go build"). The server
+// X-Origin-App header and /version endpoint, the observability logs, resource and build_info metric all use it.
+//
+// Usage:
+//
+//	info := buildinfo.Get()
+//	logger.Info().Str("version", info.Version).Str("commit", info.Commit).Msg("starting")
+func Get() Info {
+	return load()
+}
+
+// ShortCommit returns the first 12 characters of the commit, for headers and labels.
+func (i Info) ShortCommit() string {
+	if len(i.Commit) > 12 {
+		return i.Commit[:12]
+	}
+	return i.Commit
+}
+
+func read() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+	if info.Version == "" {
+		info.Version = os.Getenv("APP_VERSION")
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.Module = build.Main.Path
+	if info.Version == "" {
+		info.Version = build.Main.Version
+	}
+
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,295 @@
+// Package cache provides a typed read-through/write-through cache on Redis, independent of the HTTP middleware.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/devluispereira/go-package/clock"
+	"github.com/devluispereira/go-package/logging"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/singleflight"
+)
+
+const instrumentationName = "github.com/devluispereira/go-package/cache"
+
+var logger = logging.New("cache")
+
+// ErrNotFound is returned by loaders when the value does not exist. It is cached for the negative TTL, so
+// repeated lookups of missing values don't reach the loader.
+var ErrNotFound = errors.New("cache: not found")
+
+// Store is the key-value storage of a Cache. It is satisfied by redisclient.RedisClient; misses are reported
+// with redis.Nil.
+type Store interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value any, expiration time.Duration) error
+	Del(ctx context.Context, keys ...string) error
+}
+
+// Codec serializes the cached values.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSON is the default Codec.
+var JSON Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// Cache holds the storage and settings shared by GetOrLoad, Set and Delete.
+type Cache struct {
+	store       Store
+	name        string
+	prefix      string
+	codec       Codec
+	negativeTTL time.Duration
+	staleTTL    time.Duration
+	clock       clock.Clock
+	group       singleflight.Group
+	lookups     metric.Int64Counter
+}
+
+// Option customizes a Cache.
+type Option func(*Cache)
+
+// WithName identifies the cache in logs and metrics. Defaults to "default".
+func WithName(name string) Option {
+	return func(c *Cache) {
+		c.name = name
+	}
+}
+
+// WithPrefix prepends prefix to every key.
+func WithPrefix(prefix string) Option {
+	return func(c *Cache) {
+		c.prefix = prefix
+	}
+}
+
+// WithCodec replaces the JSON codec.
+func WithCodec(codec Codec) Option {
+	return func(c *Cache) {
+		c.codec = codec
+	}
+}
+
+// WithNegativeTTL sets how long an ErrNotFound from the loader is cached. Defaults to 30s; 0 disables it.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.negativeTTL = ttl
+	}
+}
+
+// WithStaleIfError keeps values for ttl after they expire, to be served when the loader fails. 0 disables it.
+func WithStaleIfError(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.staleTTL = ttl
+	}
+}
+
+// WithClock sets the Clock used for expirations. Defaults to clock.Real.
+func WithClock(c clock.Clock) Option {
+	return func(cache *Cache) {
+		cache.clock = c
+	}
+}
+
+// New creates a Cache on store.
+//
+// Usage:
+//
+//	products := cache.New(redis, cache.WithName("products"), cache.WithPrefix("products:"), cache.WithStaleIfError(time.Hour))
+func New(store Store, opts ...Option) *Cache {
+	c := &Cache{
+		store:       store,
+		name:        "default",
+		codec:       JSON,
+		negativeTTL: 30 * time.Second,
+		clock:       clock.Real,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.lookups, _ = otel.Meter(instrumentationName).Int64Counter("cache.lookups",
+		metric.WithDescription("Cache lookups by result: hit, miss, negative, stale and error."))
+
+	return c
+}
+
+// envelope is the stored form of a value, with its logical expiration.
+type envelope struct {
+	Value    json.RawMessage `json:"v,omitempty"`
+	Data     []byte          `json:"d,omitempty"`
+	NotFound bool            `json:"nf,omitempty"`
+	Expires  int64           `json:"exp"`
+}
+
+// GetOrLoad returns the cached value of key, calling loader on misses.
+//
+// Parameters:
+//
+//	ctx: Context for the store and the loader.
+//	c: Cache with the store and settings.
+//	key: Cache key, prefixed with the cache prefix.
+//	ttl: How long a loaded value is fresh.
+//	loader: Loads the value on misses. Return ErrNotFound for missing values.
+//
+// Behavior:
+//   - Concurrent calls for the same key share a single loader call (singleflight).
+//   - ErrNotFound from the loader is cached for the negative TTL and returned to the next lookups.
+//   - With WithStaleIfError, an expired value is kept in the store and returned when the loader fails.
+//   - Store errors are logged and the loader is called: the cache never makes a lookup fail by itself.
+//   - Lookups are counted in cache.lookups by cache name and result.
+//
+// Usage:
+//
+//	product, err := cache.GetOrLoad(ctx, products, id, 5*time.Minute, func(ctx context.Context) (Product, error) {
+//		return catalog.Product(ctx, id)
+//	})
+func GetOrLoad[T any](ctx context.Context, c *Cache, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	key = c.prefix + key
+
+	stored, found := c.read(ctx, key)
+	now := c.clock.Now()
+
+	if found && now.UnixMilli() < stored.Expires {
+		if stored.NotFound {
+			c.record(ctx, "negative")
+			return zero, ErrNotFound
+		}
+
+		var value T
+		if err := c.decode(stored, &value); err == nil {
+			c.record(ctx, "hit")
+			return value, nil
+		}
+	}
+
+	result, err, _ := c.group.Do(key, func() (any, error) {
+		value, err := loader(ctx)
+
+		switch {
+		case errors.Is(err, ErrNotFound):
+			if c.negativeTTL > 0 {
+				c.write(ctx, key, envelope{NotFound: true, Expires: now.Add(c.negativeTTL).UnixMilli()}, c.negativeTTL)
+			}
+			return nil, err
+		case err != nil:
+			return nil, err
+		}
+
+		if err := c.set(ctx, key, value, ttl); err != nil {
+			logger.Warn().Err(err).Str("cache", c.name).Str("key", key).Msg("cache: failed to store value")
+		}
+		return value, nil
+	})
+
+	if err == nil {
+		c.record(ctx, "miss")
+		value, _ := result.(T)
+		return value, nil
+	}
+
+	if found && !stored.NotFound && !errors.Is(err, ErrNotFound) {
+		var value T
+		if decodeErr := c.decode(stored, &value); decodeErr == nil {
+			c.record(ctx, "stale")
+			logger.Warn().Err(err).Str("cache", c.name).Str("key", key).Msg("cache: loader failed, serving stale value")
+			return value, nil
+		}
+	}
+
+	c.record(ctx, "error")
+	return zero, err
+}
+
+// Set stores value under key for ttl (write-through), replacing any cached or negative entry.
+func (c *Cache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	return c.set(ctx, c.prefix+key, value, ttl)
+}
+
+// set stores value under the prefixed key.
+func (c *Cache) set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("cache: encode %s: %w", key, err)
+	}
+
+	stored := envelope{Expires: c.clock.Now().Add(ttl).UnixMilli()}
+	if _, isJSON := c.codec.(jsonCodec); isJSON {
+		stored.Value = data
+	} else {
+		stored.Data = data
+	}
+
+	return c.put(ctx, key, stored, ttl)
+}
+
+// Delete removes keys from the cache, e.g. after the underlying data changed.
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	return c.store.Del(ctx, prefixed...)
+}
+
+func (c *Cache) read(ctx context.Context, key string) (envelope, bool) {
+	raw, err := c.store.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Warn().Err(err).Str("cache", c.name).Str("key", key).Msg("cache: failed to read value")
+		}
+		return envelope{}, false
+	}
+
+	var stored envelope
+	if err := json.Unmarshal([]byte(raw), &stored); err != nil {
+		logger.Warn().Err(err).Str("cache", c.name).Str("key", key).Msg("cache: ignoring invalid entry")
+		return envelope{}, false
+	}
+
+	return stored, true
+}
+
+func (c *Cache) write(ctx context.Context, key string, stored envelope, ttl time.Duration) {
+	if err := c.put(ctx, key, stored, ttl); err != nil {
+		logger.Warn().Err(err).Str("cache", c.name).Str("key", key).Msg("cache: failed to store value")
+	}
+}
+
+// put stores the envelope, keeping it for the stale-if-error window after the logical expiration.
+func (c *Cache) put(ctx context.Context, key string, stored envelope, ttl time.Duration) error {
+	raw, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return c.store.Set(ctx, key, raw, ttl+c.staleTTL)
+}
+
+func (c *Cache) decode(stored envelope, v any) error {
+	if stored.Value != nil {
+		return c.codec.Unmarshal(stored.Value, v)
+	}
+	return c.codec.Unmarshal(stored.Data, v)
+}
+
+func (c *Cache) record(ctx context.Context, result string) {
+	c.lookups.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("cache", c.name),
+		attribute.String("result", result),
+	))
+}
@@ -0,0 +1,223 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Adaptive concurrency algorithms accepted by AdaptiveConcurrencyConfig.
+const (
+	AlgorithmGradient = "gradient"
+	AlgorithmAIMD     = "aimd"
+)
+
+// ErrConcurrencyLimit is returned by the adaptive concurrency middleware when the in-flight limit is reached.
+var ErrConcurrencyLimit = errors.New("adaptive concurrency limit reached")
+
+// AdaptiveConcurrencyConfig holds the configuration of the adaptive concurrency limiter.
+// It can be loaded with the config package.
+type AdaptiveConcurrencyConfig struct {
+	// Name identifies the downstream in metrics.
+	Name string `json:"name"`
+	// Algorithm is "gradient" (default) or "aimd".
+	Algorithm string `json:"algorithm" env:"CONCURRENCY_ALGORITHM" default:"gradient"`
+	// InitialLimit, MinLimit and MaxLimit bound the in-flight limit. Defaults to 20, 1 and 200.
+	InitialLimit int `json:"initial_limit" env:"CONCURRENCY_INITIAL_LIMIT" default:"20"`
+	MinLimit     int `json:"min_limit" env:"CONCURRENCY_MIN_LIMIT" default:"1"`
+	MaxLimit     int `json:"max_limit" env:"CONCURRENCY_MAX_LIMIT" default:"200"`
+	// Tolerance is how much the recent latency may exceed the long-term latency before the gradient algorithm
+	// reduces the limit, e.g. 1.5 tolerates 50% more. Defaults to 1.5.
+	Tolerance float64 `json:"tolerance" env:"CONCURRENCY_TOLERANCE" default:"1.5"`
+	// Smoothing is the weight of each new limit computed by the gradient algorithm, from 0 to 1. Defaults to 0.2.
+	Smoothing float64 `json:"smoothing" env:"CONCURRENCY_SMOOTHING" default:"0.2"`
+	// LatencyThreshold is the latency above which the AIMD algorithm treats a request as a drop. Defaults to 1s.
+	LatencyThreshold time.Duration `json:"latency_threshold" env:"CONCURRENCY_LATENCY_THRESHOLD" default:"1s"`
+	// BackoffRatio multiplies the limit on each drop in the AIMD algorithm. Defaults to 0.9.
+	BackoffRatio float64 `json:"backoff_ratio" env:"CONCURRENCY_BACKOFF_RATIO" default:"0.9"`
+}
+
+// NewAdaptiveConcurrencyMiddleware limits in-flight requests to a downstream with a limit adjusted from observed
+// latencies, as a smarter alternative to static bulkheads for downstreams with variable capacity.
+//
+// Parameters:
+//
+//	cfg: Limiter configuration.
+//
+// Behavior:
+//   - Requests beyond the current limit fail immediately with ErrConcurrencyLimit.
+//   - gradient: compares a short-term latency average with a long-term one. While they are close the limit grows
+//     by about sqrt(limit); when recent latency rises above Tolerance times the long-term one, queuing is assumed
+//     and the limit shrinks proportionally (Netflix concurrency-limits Gradient2 style).
+//   - aimd: the limit grows by one per window of successful requests and is multiplied by BackoffRatio on each
+//     drop (transport error, 429, 503 or latency above LatencyThreshold).
+//   - Exposes the http.client.concurrency.limit and http.client.concurrency.in_flight gauges and the
+//     http.client.concurrency.rejected counter, labelled by downstream.
+//
+// Usage:
+//
+//	client := httpclient.NewHTTPClient(baseURL, 2*time.Second,
+//		httpclient.NewAdaptiveConcurrencyMiddleware(httpclient.AdaptiveConcurrencyConfig{Name: "search"}),
+//	)
+func NewAdaptiveConcurrencyMiddleware(cfg AdaptiveConcurrencyConfig) func(next http.RoundTripper) http.RoundTripper {
+	limiter := newConcurrencyLimiter(cfg)
+
+	nameAttr := metric.WithAttributes(attribute.String("peer.service", limiter.cfg.Name))
+	meter := otel.Meter(instrumentationName)
+	rejected, _ := meter.Int64Counter("http.client.concurrency.rejected",
+		metric.WithDescription("Outgoing requests rejected by the adaptive concurrency limit."))
+	limitGauge, _ := meter.Float64ObservableGauge("http.client.concurrency.limit",
+		metric.WithDescription("Current adaptive concurrency limit."))
+	inFlightGauge, _ := meter.Int64ObservableGauge("http.client.concurrency.in_flight",
+		metric.WithDescription("Outgoing requests in flight under the adaptive concurrency limit."))
+	_, _ = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		limit, inFlight := limiter.snapshot()
+		o.ObserveFloat64(limitGauge, limit, nameAttr)
+		o.ObserveInt64(inFlightGauge, int64(inFlight), nameAttr)
+		return nil
+	}, limitGauge, inFlightGauge)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !limiter.acquire() {
+				rejected.Add(req.Context(), 1, nameAttr)
+				return nil, ErrConcurrencyLimit
+			}
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+
+			dropped := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+			limiter.release(time.Since(start), dropped)
+
+			return resp, err
+		})
+	}
+}
+
+type concurrencyLimiter struct {
+	cfg AdaptiveConcurrencyConfig
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	shortRTT float64
+	longRTT  float64
+}
+
+func newConcurrencyLimiter(cfg AdaptiveConcurrencyConfig) *concurrencyLimiter {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = AlgorithmGradient
+	}
+	if cfg.MinLimit <= 0 {
+		cfg.MinLimit = 1
+	}
+	if cfg.MaxLimit <= 0 {
+		cfg.MaxLimit = 200
+	}
+	if cfg.InitialLimit <= 0 {
+		cfg.InitialLimit = 20
+	}
+	if cfg.Tolerance < 1 {
+		cfg.Tolerance = 1.5
+	}
+	if cfg.Smoothing <= 0 || cfg.Smoothing > 1 {
+		cfg.Smoothing = 0.2
+	}
+	if cfg.LatencyThreshold <= 0 {
+		cfg.LatencyThreshold = time.Second
+	}
+	if cfg.BackoffRatio <= 0 || cfg.BackoffRatio >= 1 {
+		cfg.BackoffRatio = 0.9
+	}
+
+	l := &concurrencyLimiter{cfg: cfg}
+	l.limit = l.clamp(float64(cfg.InitialLimit))
+	return l
+}
+
+func (l *concurrencyLimiter) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if float64(l.inFlight) >= l.limit {
+		return false
+	}
+
+	l.inFlight++
+	return true
+}
+
+func (l *concurrencyLimiter) release(rtt time.Duration, dropped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+
+	if l.cfg.Algorithm == AlgorithmAIMD {
+		l.aimd(rtt, dropped)
+		return
+	}
+
+	l.gradient(rtt, dropped)
+}
+
+func (l *concurrencyLimiter) aimd(rtt time.Duration, dropped bool) {
+	if dropped || rtt > l.cfg.LatencyThreshold {
+		l.limit = l.clamp(l.limit * l.cfg.BackoffRatio)
+		return
+	}
+
+	// Grow only when the limit is being used, so idle periods don't inflate it.
+	if float64(l.inFlight)*2 >= l.limit {
+		l.limit = l.clamp(l.limit + 1/l.limit)
+	}
+}
+
+func (l *concurrencyLimiter) gradient(rtt time.Duration, dropped bool) {
+	sample := float64(rtt)
+
+	if l.longRTT == 0 {
+		l.shortRTT, l.longRTT = sample, sample
+		return
+	}
+
+	l.shortRTT = 0.9*l.shortRTT + 0.1*sample
+	l.longRTT = 0.99*l.longRTT + 0.01*sample
+
+	// Recover quickly when the long-term average drifted above the recent one (e.g. after an incident).
+	if l.longRTT/l.shortRTT > 2 {
+		l.longRTT *= 0.95
+	}
+
+	gradient := math.Max(0.5, math.Min(1, l.cfg.Tolerance*l.longRTT/l.shortRTT))
+	if dropped {
+		gradient = 0.5
+	}
+
+	// Grow only when the limit is being used, so idle periods don't inflate it.
+	queue := 0.0
+	if float64(l.inFlight)*2 >= l.limit {
+		queue = math.Sqrt(l.limit)
+	}
+
+	newLimit := l.limit*gradient + queue
+	l.limit = l.clamp(l.limit*(1-l.cfg.Smoothing) + newLimit*l.cfg.Smoothing)
+}
+
+func (l *concurrencyLimiter) clamp(limit float64) float64 {
+	return math.Max(float64(l.cfg.MinLimit), math.Min(float64(l.cfg.MaxLimit), limit))
+}
+
+func (l *concurrencyLimiter) snapshot() (float64, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit, l.inFlight
+}
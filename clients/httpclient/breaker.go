@@ -0,0 +1,168 @@
+package httpclient
+
+import (
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/clock"
+	"github.com/sony/gobreaker"
+)
+
+// circuitBreaker is the state machine of gobreaker.CircuitBreaker timed by a clock.Clock, so the open timeout and
+// the counting interval follow BreakerConfig.Clock. It keeps gobreaker's states, counts and errors, so callers
+// matching gobreaker.ErrOpenState or gobreaker.ErrTooManyRequests are not affected.
+type circuitBreaker struct {
+	cfg          BreakerConfig
+	clock        clock.Clock
+	isSuccessful func(err error) bool
+
+	mu             sync.Mutex
+	state          gobreaker.State
+	generation     uint64
+	counts         gobreaker.Counts
+	expiry         time.Time
+	lastTransition time.Time
+}
+
+func newCircuitBreaker(cfg BreakerConfig, isSuccessful func(err error) bool) *circuitBreaker {
+	cb := &circuitBreaker{
+		cfg:          cfg,
+		clock:        clock.Or(cfg.Clock),
+		isSuccessful: isSuccessful,
+	}
+	cb.newGeneration(cb.clock.Now())
+	return cb
+}
+
+// State returns the current state, moving an open breaker to half-open once its timeout elapsed.
+func (cb *circuitBreaker) State() gobreaker.State {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	state, _ := cb.currentState(cb.clock.Now())
+	return state
+}
+
+// Counts returns the request counts of the current generation.
+func (cb *circuitBreaker) Counts() gobreaker.Counts {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.counts
+}
+
+// LastTransition returns when the state last changed, zero if it never did.
+func (cb *circuitBreaker) LastTransition() time.Time {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.lastTransition
+}
+
+// Execute runs req if the breaker accepts it, recording its outcome. It returns gobreaker.ErrOpenState while open and
+// gobreaker.ErrTooManyRequests when half-open already let MaxRequests through.
+func (cb *circuitBreaker) Execute(req func() (any, error)) (any, error) {
+	generation, err := cb.beforeRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if e := recover(); e != nil {
+			cb.afterRequest(generation, false)
+			panic(e)
+		}
+	}()
+
+	result, err := req()
+	cb.afterRequest(generation, cb.isSuccessful(err))
+	return result, err
+}
+
+func (cb *circuitBreaker) beforeRequest() (uint64, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	state, generation := cb.currentState(cb.clock.Now())
+	switch {
+	case state == gobreaker.StateOpen:
+		return generation, gobreaker.ErrOpenState
+	case state == gobreaker.StateHalfOpen && cb.counts.Requests >= cb.cfg.MaxRequests:
+		return generation, gobreaker.ErrTooManyRequests
+	}
+
+	cb.counts.Requests++
+	return generation, nil
+}
+
+func (cb *circuitBreaker) afterRequest(before uint64, success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.clock.Now()
+	state, generation := cb.currentState(now)
+	if generation != before {
+		return
+	}
+
+	if success {
+		cb.counts.TotalSuccesses++
+		cb.counts.ConsecutiveSuccesses++
+		cb.counts.ConsecutiveFailures = 0
+		if state == gobreaker.StateHalfOpen && cb.counts.ConsecutiveSuccesses >= cb.cfg.MaxRequests {
+			cb.setState(gobreaker.StateClosed, now)
+		}
+		return
+	}
+
+	switch state {
+	case gobreaker.StateClosed:
+		cb.counts.TotalFailures++
+		cb.counts.ConsecutiveFailures++
+		cb.counts.ConsecutiveSuccesses = 0
+		if cb.counts.Requests >= cb.cfg.MinRequests &&
+			float64(cb.counts.TotalFailures)/float64(cb.counts.Requests) >= cb.cfg.FailureRatio {
+			cb.setState(gobreaker.StateOpen, now)
+		}
+	case gobreaker.StateHalfOpen:
+		cb.setState(gobreaker.StateOpen, now)
+	}
+}
+
+func (cb *circuitBreaker) currentState(now time.Time) (gobreaker.State, uint64) {
+	switch cb.state {
+	case gobreaker.StateClosed:
+		if !cb.expiry.IsZero() && cb.expiry.Before(now) {
+			cb.newGeneration(now)
+		}
+	case gobreaker.StateOpen:
+		if cb.expiry.Before(now) {
+			cb.setState(gobreaker.StateHalfOpen, now)
+		}
+	}
+	return cb.state, cb.generation
+}
+
+func (cb *circuitBreaker) setState(state gobreaker.State, now time.Time) {
+	if cb.state == state {
+		return
+	}
+
+	cb.state = state
+	cb.lastTransition = now
+	cb.newGeneration(now)
+}
+
+// newGeneration clears the counts and sets when the current state expires: after Interval when closed, after
+// Timeout when open, and never when half-open.
+func (cb *circuitBreaker) newGeneration(now time.Time) {
+	cb.generation++
+	cb.counts = gobreaker.Counts{}
+
+	switch cb.state {
+	case gobreaker.StateClosed:
+		cb.expiry = now.Add(cb.cfg.Interval)
+	case gobreaker.StateOpen:
+		cb.expiry = now.Add(cb.cfg.Timeout)
+	default:
+		cb.expiry = time.Time{}
+	}
+}
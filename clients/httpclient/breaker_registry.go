@@ -0,0 +1,96 @@
+package httpclient
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/settings"
+)
+
+// BreakerCounts holds the request counts of a breaker in the current interval.
+type BreakerCounts struct {
+	Requests             uint32 `json:"requests"`
+	TotalSuccesses       uint32 `json:"total_successes"`
+	TotalFailures        uint32 `json:"total_failures"`
+	ConsecutiveSuccesses uint32 `json:"consecutive_successes"`
+	ConsecutiveFailures  uint32 `json:"consecutive_failures"`
+}
+
+// BreakerInfo is a snapshot of a registered circuit breaker.
+type BreakerInfo struct {
+	Name   string        `json:"name"`
+	State  string        `json:"state"`
+	Counts BreakerCounts `json:"counts"`
+	// Forced is "open" or "closed" when the breaker is forced through settings.BreakerForcePrefix.
+	Forced         string    `json:"forced,omitempty"`
+	LastTransition time.Time `json:"last_transition,omitempty"`
+}
+
+// breakerRegistry holds one breaker per name, shared by every middleware of that name, so clients built per
+// request or per tenant reuse the breaker of their downstream instead of adding one each.
+var breakerRegistry = struct {
+	mu       sync.RWMutex
+	breakers map[string]*circuitBreaker
+}{breakers: make(map[string]*circuitBreaker)}
+
+// registerBreaker returns the breaker named cfg.Name, creating it from cfg when the name is new. Later
+// configurations of the same name are ignored.
+func registerBreaker(cfg BreakerConfig, isSuccessful func(err error) bool) *circuitBreaker {
+	breakerRegistry.mu.Lock()
+	defer breakerRegistry.mu.Unlock()
+
+	if breaker, ok := breakerRegistry.breakers[cfg.Name]; ok {
+		return breaker
+	}
+
+	breaker := newCircuitBreaker(cfg, isSuccessful)
+	breakerRegistry.breakers[cfg.Name] = breaker
+	return breaker
+}
+
+// forcedState returns "open" or "closed" when the breaker named name is forced through settings.BreakerForcePrefix,
+// or "" otherwise. The middleware and Breakers both use it, so the dashboard shows the state requests get.
+func forcedState(name string) string {
+	forced, _ := settings.Default.Get(settings.BreakerForcePrefix + name)
+	if forced != "open" && forced != "closed" {
+		return ""
+	}
+	return forced
+}
+
+// Breakers returns a snapshot of every circuit breaker created by the circuit breaker middleware, sorted by name.
+func Breakers() []BreakerInfo {
+	breakerRegistry.mu.RLock()
+	defer breakerRegistry.mu.RUnlock()
+
+	infos := make([]BreakerInfo, 0, len(breakerRegistry.breakers))
+	for name, breaker := range breakerRegistry.breakers {
+		counts := breaker.Counts()
+
+		infos = append(infos, BreakerInfo{
+			Name:  name,
+			State: breaker.State().String(),
+			Counts: BreakerCounts{
+				Requests:             counts.Requests,
+				TotalSuccesses:       counts.TotalSuccesses,
+				TotalFailures:        counts.TotalFailures,
+				ConsecutiveSuccesses: counts.ConsecutiveSuccesses,
+				ConsecutiveFailures:  counts.ConsecutiveFailures,
+			},
+			Forced:         forcedState(name),
+			LastTransition: breaker.LastTransition(),
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// HasBreaker reports whether a circuit breaker with the given name was registered.
+func HasBreaker(name string) bool {
+	breakerRegistry.mu.RLock()
+	defer breakerRegistry.mu.RUnlock()
+	_, ok := breakerRegistry.breakers[name]
+	return ok
+}
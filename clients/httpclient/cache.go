@@ -1,18 +1,24 @@
 package httpclient
 
 import (
+	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"github.com/devluispereira/go-package/clock"
+	"github.com/devluispereira/go-package/settings"
+	"github.com/devluispereira/go-package/variants"
+	"github.com/devluispereira/go-package/workers"
 )
 
 // IRedisClient defines the interface for a Redis client used by the cache middleware.
@@ -26,22 +32,84 @@ type IRedisClient interface {
 type cacheKeyHeaders []string
 
 // CacheConfig holds the configuration for the cache middleware, including Redis client, TTL, and headers for cache key.
+// It can be loaded with the config package; RedisClient must be set in code.
 type CacheConfig struct {
-	RedisClient IRedisClient
-	TTL         time.Duration
-	OverrideTTL bool
-	Headers     cacheKeyHeaders
+	// Name identifies the cache in stats, index keys and the admin API. Defaults to "default".
+	Name        string       `json:"name" env:"CACHE_NAME"`
+	RedisClient IRedisClient `json:"-"`
+	// WriteClient runs the background cache writes, e.g. redisclient.RedisClient.Background(), so a burst of writes
+	// cannot starve the cache reads of connections. If nil, RedisClient is used.
+	WriteClient IRedisClient `json:"-"`
+	// Pool runs the asynchronous cache writes. Prefer Writer, which retries failed writes.
+	Pool *workers.Pool `json:"-"`
+	// Writer runs the asynchronous cache writes, with retries and drop metrics. If nil (and Pool is nil),
+	// workers.DefaultWriter is used.
+	Writer      *workers.Writer `json:"-"`
+	TTL         time.Duration   `json:"ttl" env:"CACHE_TTL"`
+	OverrideTTL bool            `json:"override_ttl" env:"CACHE_OVERRIDE_TTL"`
+	Headers     cacheKeyHeaders `json:"headers" env:"CACHE_HEADERS"`
+	// Index records the URL and the Cache-Tag/Surrogate-Key tags of each entry, enabling purge by prefix or tag.
+	// It requires a WriteClient (or RedisClient) implementing SAdd, SRem, SRandMemberN, TTL and Expire (e.g.
+	// redisclient.RedisClient). The index and tag sets expire with their longest-lived entry.
+	Index bool `json:"index" env:"CACHE_INDEX"`
+	// Include restricts caching to requests matching at least one rule. If empty, every GET is cacheable.
+	Include []CacheMatchRule `json:"include"`
+	// Exclude prevents caching requests matching any rule. It takes precedence over Include.
+	Exclude []CacheMatchRule `json:"exclude"`
+	// DebugHeader enables cache key debugging: requests carrying this header (e.g. "X-Cache-Debug") get the
+	// computed key in the X-Cache-Key response header and its parts in X-Cache-Key-Components.
+	DebugHeader string `json:"debug_header" env:"CACHE_DEBUG_HEADER"`
+	// Key configures how URLs are canonicalized into cache keys, so equivalent URLs share an entry.
+	Key CacheKeyConfig `json:"key"`
+	// Tenant namespaces the entries per tenant, with TTLs and enable flags per tenant. Disabled when neither
+	// Tenant.Header nor Tenant.Resolver is set.
+	Tenant CacheTenantConfig `json:"tenant"`
+	// Variants add the device variant of the request (e.g. platform and client version range, from the forwarded
+	// headers) to the key, so mobile and web responses never collide while the number of keys stays bounded.
+	Variants variants.Rules `json:"variants"`
+	// MaxBodySize is the largest body cached, in bytes. Larger responses are streamed to the caller and not cached.
+	// Defaults to 1 MiB.
+	MaxBodySize int64 `json:"max_body_size" env:"CACHE_MAX_BODY_SIZE" default:"1048576"`
+	// Validators stores the ETag and Last-Modified of responses apart from their body, so conditional requests
+	// (If-None-Match, If-Modified-Since) are answered with 304 while the response is fresh, even when its body is
+	// above MaxBodySize or was evicted, and are revalidated with the origin afterwards.
+	Validators bool `json:"validators" env:"CACHE_VALIDATORS"`
+	// ValidatorsTTL is how long the validators are kept, usually much longer than the entries. Defaults to 24h.
+	ValidatorsTTL time.Duration `json:"validators_ttl" env:"CACHE_VALIDATORS_TTL" default:"24h"`
+	// Invalidation purges the cached GET entries related to successful POST, PUT, PATCH and DELETE requests sent
+	// through the client.
+	Invalidation CacheInvalidationConfig `json:"invalidation"`
+	// Clock timestamps entries and detects stale serves. Defaults to clock.Real.
+	Clock clock.Clock `json:"-"`
 }
 
+// defaultCacheMaxBodySize is the MaxBodySize used when none is configured.
+const defaultCacheMaxBodySize = 1 << 20
+
 // SerializableCache represents the structure of a cached HTTP response, ready for (de)serialization.
+// Entries carry the schema Version they were written with; older entries are upgraded on read (see
+// migrateCacheEntry), so changing the envelope does not turn every existing key into a miss after a deploy.
 type SerializableCache struct {
-	Status            string              `json:"status"`
-	StatusCode        int                 `json:"status_code"`
-	Proto             string              `json:"proto"`
-	ResponseHeaders   map[string][]string `json:"header"`
-	Body              string              `json:"body"`
-	CacheControlValue int                 `json:"cacheControlValue"`
-	Policy            CachePolicy         `json:"policy"`
+	Version         int                 `json:"v,omitempty"`
+	URL             string              `json:"url,omitempty"`
+	Status          string              `json:"status"`
+	StatusCode      int                 `json:"status_code"`
+	Proto           string              `json:"proto"`
+	ResponseHeaders map[string][]string `json:"header"`
+	Body            string              `json:"body"`
+	// BodyEncoding is "base64" when Body holds binary content (images, compressed payloads), which a JSON string
+	// cannot carry faithfully.
+	BodyEncoding string `json:"body_encoding,omitempty"`
+	// ContentType, ContentLength, TransferEncoding and Uncompressed keep the framing of the original response,
+	// restored on hits and used to validate the cached body.
+	ContentType      string   `json:"content_type,omitempty"`
+	ContentLength    int64    `json:"content_length,omitempty"`
+	TransferEncoding []string `json:"transfer_encoding,omitempty"`
+	Uncompressed     bool     `json:"uncompressed,omitempty"`
+	// StoredAt is the Unix time the entry was written, used to detect stale serves.
+	StoredAt          int64       `json:"stored_at,omitempty"`
+	CacheControlValue int         `json:"cacheControlValue"`
+	Policy            CachePolicy `json:"policy"`
 }
 
 // CachePolicy defines cache control policy for a cached response, including max-age and headers used.
@@ -55,109 +123,215 @@ type CachePolicy struct {
 // It checks if the cache is enabled and a Redis client is configured. For each GET request, it attempts to retrieve
 // a cached response from Redis using a generated cache key. If a valid cached response is found, it is deserialized
 // and returned immediately, setting the "X-Cache" header to "HIT". If not found, the request proceeds to the next
-// RoundTripper, and the response is cached asynchronously if the status code is 2xx. The cache TTL can be overridden
-// by configuration, and the middleware also updates the "Cache-Control" header accordingly.
+// RoundTripper, and the response is cached asynchronously if the status code is 2xx. Responses with a Vary header
+// are cached per variant of the listed request headers ("Vary: *" is not cached); lookups fetch the candidate
+// variant keys in a single MGET when the Redis client supports it. The body is streamed to the
+// caller as it arrives and accumulated up to MaxBodySize; the entry is written once the caller has read it entirely. The cache TTL can be overridden
+// by configuration or at runtime through the settings.CacheTTLOverride setting, and the middleware also updates
+// the "Cache-Control" header accordingly. Operators can bypass the cache at runtime, e.g. during an incident, with
+// the "disable:cache:<Name>" setting (see settings.DisablePrefix).
 //
 // Parameters:
 //
 //	cfg *CacheConfig: Cache configuration struct.
 //	  - RedisClient: Redis client used to store and retrieve cached data.
+//	  - WriteClient: Optional Redis client (e.g. on a separate pool) used for the background writes.
 //	  - TTL: Default expiration time (Time To Live) for cache entries.
 //	  - OverrideTTL: If true, overrides the TTL from the Cache-Control header with the configured TTL.
 //	  - Headers: HTTP headers that will be considered when generating the cache key.
+//	  - Include/Exclude: Host globs, path prefixes and path regexes selecting which requests are cached,
+//	    so one client with mixed downstreams only caches the safe read APIs.
+//	  - DebugHeader: Request header enabling the X-Cache-Key and X-Cache-Key-Components response headers.
+//	  - Key: URL canonicalization for the cache key (ignored params, case-insensitive names, trailing slash).
+//	  - Writer: Background writer running the asynchronous cache writes (default workers.DefaultWriter).
+//	  - Pool: Legacy alternative to Writer, without retries.
+//	  - Name: Identifies the cache in CacheStats and in the admin API (see NewCacheInspector).
+//	  - Index: Records URLs and tags of the entries, enabling purge by prefix or tag.
+//	  - MaxBodySize: Largest body cached, in bytes (default 1 MiB). Larger responses are not cached.
+//	  - Tenant: Tenant isolation; keys are prefixed with "tenant:<id>:", requests without tenant bypass the cache
+//	    and TTLs and enable flags can differ per tenant.
+//	  - Variants: Declarative device variants (see variants.Rules) added to the key.
+//	  - Validators: Stores ETag and Last-Modified apart from the body, answering conditional requests with 304
+//	    while fresh and revalidating them with the origin afterwards ("X-Cache: REVALIDATED" on origin 304s).
+//	  - Invalidation: Purges the entries of the same path (or Related URLs and Tags) after 2xx writes through the
+//	    client, keeping the cache coherent with the writes. Purging every query, tenant and variant of a path
+//	    requires Index.
 //
 // Returns:
 //
 //	A function that wraps an http.RoundTripper with caching logic.
 func NewCacheMiddleware(cfg *CacheConfig) func(next http.RoundTripper) http.RoundTripper {
+	stats := cacheStatsFor(cfg.cacheName())
+	cacheable := cfg.cacheMatcher()
+	sets := cacheVarySetsFor(cfg.cacheName())
+
 	return func(next http.RoundTripper) http.RoundTripper {
 		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
-			if cfg.RedisClient == nil {
+			if cfg.RedisClient == nil || settings.Default.Disabled("cache", cfg.cacheName()) {
+				return next.RoundTrip(req)
+			}
+
+			if cfg.invalidates(req) && cacheable(req) {
+				resp, err := next.RoundTrip(req)
+				if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+					purged, err := cfg.invalidate(req, resp)
+					stats.invalidated.Add(int64(purged))
+					if err != nil {
+						stats.errors.Add(1)
+						logger.Error().Err(err).Str("url", req.URL.String()).Msg("Error invalidating cached responses")
+					}
+				}
+				return resp, err
+			}
+
+			if req.Method != "GET" || !cacheable(req) {
 				return next.RoundTrip(req)
 			}
 
-			if req.Method != "GET" {
+			components := cacheKeyComponents(req, cfg)
+			if cfg.Tenant.bypass(components.Tenant) {
 				return next.RoundTrip(req)
 			}
 
-			cacheKey := getCacheKey(req, cfg.Headers)
+			debug := cfg.DebugHeader != "" && req.Header.Get(cfg.DebugHeader) != ""
 
-			value, err := cfg.RedisClient.Get(req.Context(), cacheKey)
+			value, hitComponents, err := cfg.lookup(req.Context(), req, components, sets, "")
 
 			if err == nil && value != "" {
 				responseSerialized, err := parseCachedResponseFromString(value)
 
 				if err != nil {
+					stats.errors.Add(1)
 					logger.Error().Msg("Error deserializing cached response")
 					return next.RoundTrip(req)
 				}
 
-				resp := &http.Response{
-					StatusCode:    responseSerialized.StatusCode,
-					Status:        responseSerialized.Status,
-					Proto:         responseSerialized.Proto,
-					ProtoMajor:    1,
-					ProtoMinor:    1,
-					Body:          io.NopCloser(strings.NewReader(responseSerialized.Body)),
-					Header:        make(http.Header),
-					ContentLength: int64(len(responseSerialized.Body)),
-					Request:       req,
-				}
+				resp, err := responseSerialized.toResponse(req)
 
-				for k, v := range responseSerialized.ResponseHeaders {
-					for _, vv := range v {
-						resp.Header.Add(k, vv)
-					}
+				if err != nil {
+					stats.errors.Add(1)
+					logger.Error().Err(err).Str("url", req.URL.String()).Msg("Discarding invalid cached response")
+					return next.RoundTrip(req)
 				}
 
+				stats.hit(hitComponents.Key, responseSerialized.URL, responseSerialized.stale(clock.Or(cfg.Clock).Now()))
+
 				newCacheControl := fmt.Sprintf("max-age=%v, public", responseSerialized.CacheControlValue)
 				resp.Header.Set("Cache-Control", newCacheControl)
 				resp.Header.Set("X-Cache", "HIT")
 
+				if debug {
+					setCacheKeyHeaders(resp, hitComponents)
+				}
+
 				return resp, nil
 			}
 
+			now := clock.Or(cfg.Clock).Now()
+
+			var validators cacheValidators
+			revalidating := false
+
+			if cfg.Validators && isConditional(req) {
+				var validatorsComponents CacheKeyComponents
+				validators, validatorsComponents, revalidating = cfg.lookupValidators(req.Context(), req, components, sets)
+				revalidating = revalidating && validators.matches(req)
+
+				if remaining := validators.remaining(now); revalidating && remaining > 0 {
+					stats.hit(validatorsComponents.Key, req.URL.String(), false)
+					resp := validators.notModified(req, remaining)
+					if debug {
+						setCacheKeyHeaders(resp, validatorsComponents)
+					}
+					return resp, nil
+				}
+
+				components = validatorsComponents
+			}
+
+			stats.misses.Add(1)
 			resp, err := next.RoundTrip(req)
 
 			if err != nil {
 				return resp, fmt.Errorf("error executing request: %w", err)
 			}
 
-			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-
-				responseCacheControl := getCacheControlHeaderValue(resp)
+			if revalidating && resp.StatusCode == http.StatusNotModified {
+				cfg.scheduleValidators(components.Key, validators.refreshed(resp, cfg.entryTTL(resp, components.Tenant), now))
+				resp.Header.Set("X-Cache", "REVALIDATED")
+				if debug {
+					setCacheKeyHeaders(resp, components)
+				}
+				return resp, nil
+			}
 
-				var ttl time.Duration = time.Second * time.Duration(responseCacheControl)
+			varyHeaders, varyCacheable := responseVary(resp, cfg.Headers, cfg.Variants.Headers())
 
-				if cfg.OverrideTTL {
-					ttl = cfg.TTL
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 && varyCacheable {
+				if len(varyHeaders) > len(cfg.Headers) {
+					if !sets.add(varyHeaders) {
+						resp.Header.Set("X-Cache", "MISS")
+						if debug {
+							setCacheKeyHeaders(resp, components)
+						}
+						return resp, nil
+					}
+					components = cacheKeyComponentsFor(req, cfg, varyHeaders)
 				}
 
+				responseCacheControl := getCacheControlHeaderValue(resp)
+				ttl := cfg.entryTTL(resp, components.Tenant)
+
 				newCacheControl := fmt.Sprintf("max-age=%v, public", ttl.Seconds())
 				resp.Header.Set("Cache-Control", newCacheControl)
 
 				policy := CachePolicy{
 					MaxAge:  responseCacheControl,
-					Headers: cfg.Headers,
+					Headers: varyHeaders,
 				}
 
-				cachedValue, err := responseToJSON(resp, policy)
+				if cfg.Validators {
+					if v, ok := newCacheValidators(resp, ttl, now); ok {
+						v.Headers = varyHeaders
+						cfg.scheduleValidators(components.Key, v)
+					}
+				}
 
-				resp.Header.Set("X-Cache", "MISS")
+				maxBodySize := cfg.maxBodySize()
 
-				if err != nil {
-					logger.Err(err).Msg("Error serializing response for cache")
-					return resp, fmt.Errorf("error serializing response for cache: %w", err)
+				if resp.ContentLength > maxBodySize {
+					resp.Header.Set("X-Cache", "MISS")
+					if debug {
+						setCacheKeyHeaders(resp, components)
+					}
+					return resp, nil
 				}
 
-				go func() {
-					setErr := cfg.RedisClient.Set(req.Context(), cacheKey, cachedValue, ttl)
+				entry := newSerializableCache(req.URL.String(), resp, policy)
+				tags := responseCacheTags(resp)
+				url := req.URL.String()
 
-					if setErr != nil {
-						logger.Error().Err(setErr).Msg("Error saving to cache")
-					}
-				}()
+				resp.Body = &cacheTeeBody{
+					body: resp.Body,
+					max:  maxBodySize,
+					onComplete: func(body []byte) {
+						cachedValue, err := entry.withBody(body, clock.Or(cfg.Clock).Now())
+
+						if err != nil {
+							logger.Err(err).Msg("Error serializing response for cache")
+							return
+						}
+
+						stats.written(len(cachedValue))
+						cfg.schedule(components.Key, url, tags, cachedValue, ttl)
+					},
+				}
 
+				resp.Header.Set("X-Cache", "MISS")
+			}
+
+			if debug {
+				setCacheKeyHeaders(resp, components)
 			}
 
 			return resp, nil
@@ -165,76 +339,246 @@ func NewCacheMiddleware(cfg *CacheConfig) func(next http.RoundTripper) http.Roun
 	}
 }
 
-func getCacheKey(req *http.Request, headers cacheKeyHeaders) string {
-	keyParts := []string{
-		buildURLPart(req),
-		buildQueryPart(req),
-		buildVaryHeadersPart(req, headers),
+// entryTTL returns the TTL of the entry of resp: its max-age, replaced by TTL with OverrideTTL, by the TTL of
+// tenant and by the settings.CacheTTLOverride setting, in that order.
+func (cfg *CacheConfig) entryTTL(resp *http.Response, tenant string) time.Duration {
+	ttl := time.Second * time.Duration(getCacheControlHeaderValue(resp))
+
+	if cfg.OverrideTTL {
+		ttl = cfg.TTL
 	}
 
-	base := strings.Join(keyParts, "|")
-	hash := sha256.Sum256([]byte(base))
-	return hex.EncodeToString(hash[:])
+	if tenantTTL, ok := cfg.Tenant.ttl(tenant); ok {
+		ttl = tenantTTL
+	}
+
+	if override, ok := settings.Default.Duration(settings.CacheTTLOverride); ok {
+		ttl = override
+	}
+
+	return ttl
+}
+
+// setCacheKeyHeaders exposes the cache key of a response, for requests carrying CacheConfig.DebugHeader.
+func setCacheKeyHeaders(resp *http.Response, components CacheKeyComponents) {
+	resp.Header.Set("X-Cache-Key", components.Key)
+	resp.Header.Set("X-Cache-Key-Components", components.String())
 }
 
-func buildURLPart(req *http.Request) string {
-	return req.URL.String()
+// schedule writes a cache entry in the background, on the configured Pool or Writer (workers.DefaultWriter when
+// neither is set). Writes run detached from the request context, so they complete after the handler returns.
+func (cfg *CacheConfig) schedule(key, url string, tags []string, value []byte, ttl time.Duration) {
+	cfg.scheduleWrite(func(ctx context.Context) error {
+		return cfg.store(ctx, key, url, tags, value, ttl)
+	})
 }
 
-func buildQueryPart(req *http.Request) string {
-	query := req.URL.Query()
-	var queryParts []string
+// scheduleWrite runs write in the background, on the configured Pool or Writer.
+func (cfg *CacheConfig) scheduleWrite(write func(ctx context.Context) error) {
+	if cfg.Pool != nil {
+		if submitErr := cfg.Pool.TrySubmit(write); submitErr != nil {
+			logger.Error().Err(submitErr).Msg("Error scheduling cache write")
+		}
+		return
+	}
 
-	for k, v := range query {
-		sort.Strings(v)
-		queryParts = append(queryParts, k+"="+strings.Join(v, ","))
+	writer := cfg.Writer
+	if writer == nil {
+		writer = workers.DefaultWriter()
 	}
 
-	sort.Strings(queryParts)
-	return strings.Join(queryParts, "&")
+	writer.Write(write)
 }
 
-func buildVaryHeadersPart(req *http.Request, headers cacheKeyHeaders) string {
-	var headersParts []string
+// writeClient returns the client of the background cache writes.
+func (cfg *CacheConfig) writeClient() IRedisClient {
+	if cfg.WriteClient != nil {
+		return cfg.WriteClient
+	}
+	return cfg.RedisClient
+}
 
-	for _, key := range headers {
-		if req.Header.Get(key) != "" {
-			headersParts = append(headersParts, key+":"+req.Header.Get(key))
-		}
+func (cfg *CacheConfig) maxBodySize() int64 {
+	if cfg.MaxBodySize <= 0 {
+		return defaultCacheMaxBodySize
 	}
+	return cfg.MaxBodySize
+}
 
-	fmt.Println("Vary Headers:", headersParts)
-	return strings.Join(headersParts, "|")
+// cacheTeeBody passes the response body through to the caller while accumulating up to max bytes.
+// When the caller reads it to the end without exceeding max, onComplete receives the full body.
+// Larger bodies are streamed as usual and not cached, so they are never buffered twice in memory.
+type cacheTeeBody struct {
+	body       io.ReadCloser
+	buf        bytes.Buffer
+	max        int64
+	overflow   bool
+	done       bool
+	onComplete func(body []byte)
 }
 
-func responseToJSON(resp *http.Response, policy CachePolicy) ([]byte, error) {
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+func (t *cacheTeeBody) Read(p []byte) (int, error) {
+	n, err := t.body.Read(p)
+
+	if n > 0 && !t.overflow {
+		if int64(t.buf.Len()+n) > t.max {
+			t.overflow = true
+			t.buf = bytes.Buffer{}
+		} else {
+			t.buf.Write(p[:n])
+		}
+	}
+
+	if err == io.EOF && !t.overflow && !t.done {
+		t.done = true
+		t.onComplete(t.buf.Bytes())
 	}
 
-	resp.Body = io.NopCloser(strings.NewReader(string(bodyBytes)))
+	return n, err
+}
+
+func (t *cacheTeeBody) Close() error {
+	return t.body.Close()
+}
 
-	sr := SerializableCache{
+// newSerializableCache captures the status and headers of a response to be cached. The body is added with
+// withBody once it has been read, so headers set afterwards (e.g. X-Cache) are not stored.
+func newSerializableCache(url string, resp *http.Response, policy CachePolicy) *SerializableCache {
+	return &SerializableCache{
+		Version:           cacheSchemaVersion,
+		URL:               url,
 		Status:            resp.Status,
 		StatusCode:        resp.StatusCode,
 		Proto:             resp.Proto,
-		ResponseHeaders:   resp.Header,
+		ResponseHeaders:   resp.Header.Clone(),
 		Policy:            policy,
 		CacheControlValue: getCacheControlHeaderValue(resp),
-		Body:              string(bodyBytes),
+		ContentType:       resp.Header.Get("Content-Type"),
+		TransferEncoding:  resp.TransferEncoding,
+		Uncompressed:      resp.Uncompressed,
+	}
+}
+
+// withBody serializes the entry with body. Binary content is stored as base64.
+func (sc SerializableCache) withBody(body []byte, now time.Time) ([]byte, error) {
+	sc.StoredAt = now.Unix()
+	sc.Body = string(body)
+	sc.ContentLength = int64(len(body))
+
+	if !isIdentityEncoding(sc.contentEncoding()) || !utf8.Valid(body) {
+		sc.Body = base64.StdEncoding.EncodeToString(body)
+		sc.BodyEncoding = "base64"
 	}
 
-	return json.Marshal(sr)
+	return json.Marshal(sc)
+}
+
+// stale reports whether the entry is older, at now, than the max-age given by the origin.
+func (sc *SerializableCache) stale(now time.Time) bool {
+	if sc.StoredAt == 0 {
+		return false
+	}
+	return now.Sub(time.Unix(sc.StoredAt, 0)) > time.Duration(sc.Policy.MaxAge)*time.Second
+}
+
+// contentEncoding returns the Content-Encoding of the cached response.
+func (sc *SerializableCache) contentEncoding() string {
+	return http.Header(sc.ResponseHeaders).Get("Content-Encoding")
+}
+
+// toResponse rebuilds the cached response, restoring its framing headers and validating the body against them.
+// It fails when the body does not match the recorded length or is not valid for the original content type,
+// so the caller can discard the entry and go to the origin instead of serving a corrupted response.
+func (sc *SerializableCache) toResponse(req *http.Request) (*http.Response, error) {
+	body := []byte(sc.Body)
+
+	if sc.BodyEncoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(sc.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode cached body: %w", err)
+		}
+		body = decoded
+	}
+
+	resp := &http.Response{
+		StatusCode:       sc.StatusCode,
+		Status:           sc.Status,
+		Proto:            sc.Proto,
+		ProtoMajor:       1,
+		ProtoMinor:       1,
+		Body:             io.NopCloser(bytes.NewReader(body)),
+		Header:           make(http.Header),
+		ContentLength:    int64(len(body)),
+		TransferEncoding: sc.TransferEncoding,
+		Uncompressed:     sc.Uncompressed,
+		Request:          req,
+	}
+
+	for k, v := range sc.ResponseHeaders {
+		for _, vv := range v {
+			resp.Header.Add(k, vv)
+		}
+	}
+
+	if resp.Header.Get("Content-Length") != "" {
+		resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+
+	if err := sc.validate(resp.Header, body); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// validate checks a reconstructed body against the recorded length and content type. Entries written before
+// the framing fields existed have no ContentLength and skip the length check.
+func (sc *SerializableCache) validate(header http.Header, body []byte) error {
+	if sc.ContentLength > 0 && sc.ContentLength != int64(len(body)) {
+		return fmt.Errorf("cached body has %d bytes, expected %d", len(body), sc.ContentLength)
+	}
+
+	if sc.ContentType != "" && header.Get("Content-Type") != sc.ContentType {
+		return fmt.Errorf("cached content type %q does not match %q", header.Get("Content-Type"), sc.ContentType)
+	}
+
+	if !isIdentityEncoding(header.Get("Content-Encoding")) {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if len(body) > 0 && !json.Valid(body) {
+			return fmt.Errorf("cached body is not valid %s", mediaType)
+		}
+
+	case strings.HasPrefix(mediaType, "text/"):
+		if !utf8.Valid(body) {
+			return fmt.Errorf("cached body is not valid %s", mediaType)
+		}
+	}
+
+	return nil
+}
+
+func isIdentityEncoding(encoding string) bool {
+	return encoding == "" || strings.EqualFold(encoding, "identity")
 }
 
 func parseCachedResponseFromString(jsonStr string) (*SerializableCache, error) {
+	entry, version, err := migrateCacheEntry([]byte(jsonStr))
+	if err != nil {
+		return nil, err
+	}
+
 	var sc SerializableCache
 
-	err := json.Unmarshal([]byte(jsonStr), &sc)
+	err = json.Unmarshal(entry, &sc)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal cached response: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal cached response (schema version %d): %w", version, err)
 	}
 
 	return &sc, nil
@@ -249,7 +593,7 @@ func getCacheControlHeaderValue(res *http.Response) int {
 		age, err := strconv.Atoi(matches[1])
 
 		if err != nil {
-			fmt.Println("error on convert to int", err)
+			logger.Error().Err(err).Msg("error converting max-age to int")
 			return 0
 		}
 
@@ -0,0 +1,329 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// cacheIndexClient is implemented by Redis clients able to maintain the cache index (see CacheConfig.Index).
+type cacheIndexClient interface {
+	SAdd(ctx context.Context, key string, members ...any) error
+	SRem(ctx context.Context, key string, members ...any) error
+	SRandMemberN(ctx context.Context, key string, count int64) ([]string, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Expire(ctx context.Context, key string, expiration time.Duration) error
+}
+
+// ICacheAdminRedisClient defines the Redis operations used by CacheInspector. It is satisfied by redisclient.RedisClient.
+type ICacheAdminRedisClient interface {
+	IRedisClient
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Del(ctx context.Context, keys ...string) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SRem(ctx context.Context, key string, members ...any) error
+}
+
+// missingKeyTTL is the TTL Redis reports for a key that does not exist.
+const missingKeyTTL time.Duration = -2
+
+// indexPruneSample is the number of random index members checked on every indexed write. Members of expired
+// entries are removed, so the index follows the live entries instead of every key ever written.
+const indexPruneSample = 3
+
+func (cfg *CacheConfig) cacheName() string {
+	if cfg.Name == "" {
+		return "default"
+	}
+	return cfg.Name
+}
+
+func (cfg *CacheConfig) indexKey() string {
+	return "httpcache:" + cfg.cacheName() + ":index"
+}
+
+func (cfg *CacheConfig) tagKey(tag string) string {
+	return "httpcache:" + cfg.cacheName() + ":tag:" + tag
+}
+
+// urlKey returns the set of the entries of rawURL, any query, tenant or variant.
+func (cfg *CacheConfig) urlKey(rawURL string) string {
+	return "httpcache:" + cfg.cacheName() + ":url:" + withoutQuery(rawURL)
+}
+
+// store writes a cache entry and, when indexing is enabled, records its URL and tags.
+//
+// The index, URL and tag sets expire with the longest-lived entry they list, and a sample of the index is pruned
+// on every write.
+func (cfg *CacheConfig) store(ctx context.Context, key, url string, tags []string, value []byte, ttl time.Duration) error {
+	client := cfg.writeClient()
+	if err := client.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	indexer, ok := client.(cacheIndexClient)
+	if !cfg.Index || !ok {
+		return nil
+	}
+
+	if err := indexSet(ctx, indexer, cfg.indexKey(), key+" "+url, ttl); err != nil {
+		return fmt.Errorf("failed to index cache entry: %w", err)
+	}
+	if err := indexSet(ctx, indexer, cfg.urlKey(url), key, ttl); err != nil {
+		return fmt.Errorf("failed to index cache entry: %w", err)
+	}
+
+	for _, tag := range tags {
+		if err := indexSet(ctx, indexer, cfg.tagKey(tag), key, ttl); err != nil {
+			return fmt.Errorf("failed to tag cache entry: %w", err)
+		}
+	}
+
+	if err := cfg.pruneIndex(ctx, indexer); err != nil {
+		return fmt.Errorf("failed to prune cache index: %w", err)
+	}
+
+	return nil
+}
+
+// indexSet adds member to set, extending the expiration of set to ttl when it would expire earlier. Entries
+// stored before with a longer TTL keep their set.
+func indexSet(ctx context.Context, client cacheIndexClient, set string, member any, ttl time.Duration) error {
+	if err := client.SAdd(ctx, set, member); err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	current, err := client.TTL(ctx, set)
+	if err != nil {
+		return err
+	}
+	if current >= ttl {
+		return nil
+	}
+	return client.Expire(ctx, set, ttl)
+}
+
+// pruneIndex removes the members of expired entries among a random sample of the index.
+func (cfg *CacheConfig) pruneIndex(ctx context.Context, client cacheIndexClient) error {
+	members, err := client.SRandMemberN(ctx, cfg.indexKey(), indexPruneSample)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		key, _, _ := strings.Cut(member, " ")
+		if ttl, err := client.TTL(ctx, key); err != nil || ttl != missingKeyTTL {
+			continue
+		}
+		if err := client.SRem(ctx, cfg.indexKey(), member); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteKeys deletes keys one by one, since a multi-key DEL fails with CROSSSLOT on Redis Cluster when the keys
+// hash to different slots. It returns the number of keys deleted before an error.
+func deleteKeys(ctx context.Context, client cacheDeleteClient, keys []string) (int, error) {
+	for i, key := range keys {
+		if err := client.Del(ctx, key); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
+
+// responseCacheTags returns the tags of a response, from the Cache-Tag (comma-separated) or
+// Surrogate-Key (space-separated) headers.
+func responseCacheTags(resp *http.Response) []string {
+	var tags []string
+
+	for _, tag := range strings.Split(resp.Header.Get("Cache-Tag"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	tags = append(tags, strings.Fields(resp.Header.Get("Surrogate-Key"))...)
+	return tags
+}
+
+// CacheEntryInfo describes a cache lookup.
+type CacheEntryInfo struct {
+	Key        string              `json:"key"`
+	URL        string              `json:"url"`
+	Cached     bool                `json:"cached"`
+	TTL        float64             `json:"ttl_seconds,omitempty"`
+	StatusCode int                 `json:"status_code,omitempty"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+}
+
+// CacheInspector inspects and purges the entries of a cache middleware, for debugging stale-content incidents.
+type CacheInspector struct {
+	cfg    *CacheConfig
+	client ICacheAdminRedisClient
+}
+
+// NewCacheInspector creates an inspector for the cache configured by cfg.
+// The RedisClient of cfg must implement ICacheAdminRedisClient.
+//
+// Usage:
+//
+//	inspector, err := httpclient.NewCacheInspector(cacheCfg)
+//	admin.RegisterCache(r, inspector)
+func NewCacheInspector(cfg *CacheConfig) (*CacheInspector, error) {
+	client, ok := cfg.RedisClient.(ICacheAdminRedisClient)
+	if !ok {
+		return nil, errors.New("cache redis client does not support inspection")
+	}
+
+	return &CacheInspector{cfg: cfg, client: client}, nil
+}
+
+// Name returns the cache name.
+func (i *CacheInspector) Name() string {
+	return i.cfg.cacheName()
+}
+
+// Stats returns the hit/miss counters of the cache.
+func (i *CacheInspector) Stats() CacheStatsSnapshot {
+	return cacheStatsFor(i.Name()).snapshot(i.Name())
+}
+
+// Key returns the cache key of a GET request to rawURL with the given headers.
+func (i *CacheInspector) Key(rawURL string, headers http.Header) (string, error) {
+	components, err := i.KeyComponents(rawURL, headers)
+	if err != nil {
+		return "", err
+	}
+
+	return components.Key, nil
+}
+
+// KeyComponents returns the cache key of a GET request to rawURL with the given headers, with the canonical URL,
+// query and vary headers it was computed from.
+func (i *CacheInspector) KeyComponents(rawURL string, headers http.Header) (CacheKeyComponents, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return CacheKeyComponents{}, fmt.Errorf("invalid url: %w", err)
+	}
+
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+
+	return cacheKeyComponents(req, i.cfg), nil
+}
+
+// Lookup reports whether a GET request to rawURL with the given headers is cached, with its remaining TTL
+// and stored response headers.
+func (i *CacheInspector) Lookup(ctx context.Context, rawURL string, headers http.Header) (*CacheEntryInfo, error) {
+	key, err := i.Key(rawURL, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &CacheEntryInfo{Key: key, URL: rawURL}
+
+	value, err := i.client.Get(ctx, key)
+	if errors.Is(err, redis.Nil) || (err == nil && value == "") {
+		return info, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache entry: %w", err)
+	}
+
+	cached, err := parseCachedResponseFromString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	info.Cached = true
+	info.StatusCode = cached.StatusCode
+	info.Headers = cached.ResponseHeaders
+
+	if ttl, err := i.client.TTL(ctx, key); err == nil && ttl > 0 {
+		info.TTL = ttl.Seconds()
+	}
+
+	return info, nil
+}
+
+// PurgeKey deletes a cache entry by key.
+func (i *CacheInspector) PurgeKey(ctx context.Context, key string) error {
+	if err := i.client.Del(ctx, key); err != nil {
+		return fmt.Errorf("failed to purge cache key: %w", err)
+	}
+	return nil
+}
+
+// PurgePrefix deletes every indexed entry whose URL starts with prefix, returning the number of entries purged.
+// It requires CacheConfig.Index. Index members of expired entries are pruned along the way.
+func (i *CacheInspector) PurgePrefix(ctx context.Context, prefix string) (int, error) {
+	return i.purgeMatching(ctx, func(url string) bool { return strings.HasPrefix(url, prefix) })
+}
+
+// purgeMatching deletes every indexed entry whose URL matches, pruning the index members of expired entries.
+// It reads the whole index, so it is meant for operators, not for every request.
+func (i *CacheInspector) purgeMatching(ctx context.Context, match func(url string) bool) (int, error) {
+	members, err := i.client.SMembers(ctx, i.cfg.indexKey())
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache index: %w", err)
+	}
+
+	purged := 0
+	for _, member := range members {
+		key, url, _ := strings.Cut(member, " ")
+
+		if match(url) {
+			if err := i.client.Del(ctx, key); err != nil {
+				return purged, fmt.Errorf("failed to purge cache key: %w", err)
+			}
+			purged++
+		} else if ttl, err := i.client.TTL(ctx, key); err != nil || ttl != missingKeyTTL {
+			continue
+		}
+
+		if err := i.client.SRem(ctx, i.cfg.indexKey(), member); err != nil {
+			return purged, fmt.Errorf("failed to update cache index: %w", err)
+		}
+	}
+
+	return purged, nil
+}
+
+// PurgeTag deletes every entry tagged with tag (Cache-Tag or Surrogate-Key response headers), returning the
+// number of entries purged. It requires CacheConfig.Index.
+func (i *CacheInspector) PurgeTag(ctx context.Context, tag string) (int, error) {
+	purged, err := i.purgeSet(ctx, i.cfg.tagKey(tag))
+	if err != nil {
+		return purged, fmt.Errorf("failed to purge cache tag: %w", err)
+	}
+	return purged, nil
+}
+
+// purgeSet deletes every entry listed in set, then set itself.
+func (i *CacheInspector) purgeSet(ctx context.Context, set string) (int, error) {
+	keys, err := i.client.SMembers(ctx, set)
+	if err != nil {
+		return 0, err
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	purged, err := deleteKeys(ctx, i.client, keys)
+	if err != nil {
+		return purged, err
+	}
+	return purged, i.client.Del(ctx, set)
+}
@@ -0,0 +1,259 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// indexRedis is an in-memory ICacheAdminRedisClient with sets and TTLs, recording the keys of every DEL.
+type indexRedis struct {
+	*memoryRedis
+
+	mu       sync.Mutex
+	sets     map[string]map[string]bool
+	ttls     map[string]time.Duration
+	dels     [][]string
+	smembers map[string]int
+}
+
+func newIndexRedis() *indexRedis {
+	return &indexRedis{
+		memoryRedis: newMemoryRedis(),
+		sets:        map[string]map[string]bool{},
+		ttls:        map[string]time.Duration{},
+		smembers:    map[string]int{},
+	}
+}
+
+func (r *indexRedis) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	r.mu.Lock()
+	r.ttls[key] = ttl
+	r.mu.Unlock()
+	return r.memoryRedis.Set(ctx, key, value, ttl)
+}
+
+func (r *indexRedis) Del(_ context.Context, keys ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dels = append(r.dels, keys)
+
+	r.memoryRedis.mu.Lock()
+	defer r.memoryRedis.mu.Unlock()
+	for _, key := range keys {
+		delete(r.data, key)
+		delete(r.sets, key)
+		delete(r.ttls, key)
+	}
+	return nil
+}
+
+func (r *indexRedis) TTL(_ context.Context, key string) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.memoryRedis.mu.Lock()
+	_, found := r.data[key]
+	r.memoryRedis.mu.Unlock()
+	if _, ok := r.sets[key]; !ok && !found {
+		return missingKeyTTL, nil
+	}
+	if ttl, ok := r.ttls[key]; ok && ttl > 0 {
+		return ttl, nil
+	}
+	return -1, nil
+}
+
+func (r *indexRedis) Expire(_ context.Context, key string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ttls[key] = ttl
+	return nil
+}
+
+func (r *indexRedis) SAdd(_ context.Context, key string, members ...any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sets[key] == nil {
+		r.sets[key] = map[string]bool{}
+	}
+	for _, member := range members {
+		r.sets[key][member.(string)] = true
+	}
+	return nil
+}
+
+func (r *indexRedis) SRem(_ context.Context, key string, members ...any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, member := range members {
+		delete(r.sets[key], member.(string))
+	}
+	return nil
+}
+
+func (r *indexRedis) SMembers(_ context.Context, key string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.smembers[key]++
+	return r.members(key), nil
+}
+
+// SRandMemberN returns the first members in order, so pruning is deterministic.
+func (r *indexRedis) SRandMemberN(_ context.Context, key string, count int64) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	members := r.members(key)
+	return members[:min(int(count), len(members))], nil
+}
+
+func (r *indexRedis) members(key string) []string {
+	members := make([]string, 0, len(r.sets[key]))
+	for member := range r.sets[key] {
+		members = append(members, member)
+	}
+	slices.Sort(members)
+	return members
+}
+
+// waitIndexed polls until key is listed in set, since cache writes run in the background.
+func (r *indexRedis) waitIndexed(t *testing.T, set, key string) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		found := r.sets[set][key]
+		r.mu.Unlock()
+		if found {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("key %q never indexed in %q", key, set)
+}
+
+func (r *indexRedis) setTTL(key string) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ttls[key]
+}
+
+func TestCacheStoreExpiresIndexSets(t *testing.T) {
+	redis := newIndexRedis()
+	cfg := &CacheConfig{Name: t.Name(), RedisClient: redis, Index: true}
+	ctx := context.Background()
+
+	if err := cfg.store(ctx, "k1", "http://api/products/1", []string{"product"}, []byte("{}"), time.Hour); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	// A shorter-lived entry must not shorten the sets listing the first one.
+	if err := cfg.store(ctx, "k2", "http://api/products/1?page=2", []string{"product"}, []byte("{}"), time.Minute); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	for _, set := range []string{cfg.indexKey(), cfg.tagKey("product"), cfg.urlKey("http://api/products/1")} {
+		if ttl := redis.setTTL(set); ttl != time.Hour {
+			t.Errorf("%s expires in %v, want 1h", set, ttl)
+		}
+	}
+}
+
+func TestCacheStorePrunesExpiredIndexMembers(t *testing.T) {
+	redis := newIndexRedis()
+	cfg := &CacheConfig{Name: t.Name(), RedisClient: redis, Index: true}
+	ctx := context.Background()
+
+	_ = cfg.store(ctx, "k0", "http://api/a", nil, []byte("{}"), time.Minute)
+	// k0 expires.
+	_ = redis.Del(ctx, "k0")
+	_ = cfg.store(ctx, "k1", "http://api/b", nil, []byte("{}"), time.Minute)
+
+	if members := redis.members(cfg.indexKey()); !slices.Equal(members, []string{"k1 http://api/b"}) {
+		t.Fatalf("index = %v, want only the live entry", members)
+	}
+}
+
+func TestPurgeTagDeletesKeysOneByOne(t *testing.T) {
+	redis := newIndexRedis()
+	cfg := &CacheConfig{Name: t.Name(), RedisClient: redis, Index: true}
+	ctx := context.Background()
+
+	for _, key := range []string{"k1", "k2", "k3"} {
+		_ = cfg.store(ctx, key, "http://api/"+key, []string{"product"}, []byte("{}"), time.Minute)
+	}
+
+	inspector, err := NewCacheInspector(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	purged, err := inspector.PurgeTag(ctx, "product")
+	if err != nil || purged != 3 {
+		t.Fatalf("PurgeTag = %d, %v, want 3", purged, err)
+	}
+
+	for _, keys := range redis.dels {
+		if len(keys) != 1 {
+			t.Errorf("DEL with %d keys %v fails with CROSSSLOT on Redis Cluster", len(keys), keys)
+		}
+	}
+	if value, _ := redis.Get(ctx, "k1"); value != "" {
+		t.Error("tagged entry not purged")
+	}
+	if members := redis.members(cfg.tagKey("product")); len(members) != 0 {
+		t.Errorf("tag set not deleted: %v", members)
+	}
+}
+
+func TestCacheInvalidationDoesNotScanIndex(t *testing.T) {
+	var calls atomic.Int32
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer origin.Close()
+
+	redis := newIndexRedis()
+	cfg := &CacheConfig{
+		Name:         t.Name(),
+		RedisClient:  redis,
+		Index:        true,
+		Invalidation: CacheInvalidationConfig{Enabled: true},
+	}
+	client := NewHTTPClient("", 2*time.Second, NewCacheMiddleware(cfg))
+	ctx := context.Background()
+
+	for _, path := range []string{"/products/1?page=1", "/products/1?page=2", "/products/2"} {
+		if _, err := client.Get(ctx, origin.URL+path); err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		req, _ := http.NewRequest(http.MethodGet, origin.URL+path, nil)
+		redis.waitIndexed(t, cfg.urlKey(req.URL.String()), cacheKeyComponents(req, cfg).Key)
+	}
+
+	if _, err := client.Post(ctx, origin.URL+"/products/1", strings.NewReader(`{}`)); err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+
+	redis.mu.Lock()
+	scans := redis.smembers[cfg.indexKey()]
+	redis.mu.Unlock()
+	if scans != 0 {
+		t.Errorf("index read %d times by a write", scans)
+	}
+
+	for path, cached := range map[string]bool{"/products/1?page=1": false, "/products/1?page=2": false, "/products/2": true} {
+		req, _ := http.NewRequest(http.MethodGet, origin.URL+path, nil)
+		if value, _ := redis.Get(ctx, cacheKeyComponents(req, cfg).Key); (value != "") != cached {
+			t.Errorf("%s cached = %v, want %v", path, value != "", cached)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CacheInvalidationConfig configures the purge of cached GET entries after writes through the same client, so a
+// read following a write does not return the entry cached before it.
+type CacheInvalidationConfig struct {
+	// Enabled purges the related entries after every 2xx POST, PUT, PATCH or DELETE response.
+	Enabled bool `json:"enabled" env:"CACHE_INVALIDATION_ENABLED"`
+	// Related returns the URLs whose entries are purged after a write. An entry matches when its URL, without
+	// the query, equals one of them, so every query of the path is purged; a URL ending with "*" matches as a
+	// prefix, e.g. "https://api/products/*". Defaults to the URL of the write, without its query.
+	Related func(req *http.Request) []string `json:"-"`
+	// Tags returns the tags (Cache-Tag/Surrogate-Key) whose entries are purged after a write. Defaults to the
+	// Cache-Tag and Surrogate-Key headers of the write response.
+	Tags func(req *http.Request, resp *http.Response) []string `json:"-"`
+}
+
+// cacheDeleteClient is implemented by Redis clients able to delete entries, required by cache invalidation.
+type cacheDeleteClient interface {
+	Del(ctx context.Context, keys ...string) error
+}
+
+// invalidates reports whether a response to req must purge the related entries.
+func (cfg *CacheConfig) invalidates(req *http.Request) bool {
+	if !cfg.Invalidation.Enabled {
+		return false
+	}
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// invalidate purges the entries related to a successful write.
+//
+// With Index, every entry of the related URLs (any query, tenant or variant) and of the tags is purged; without
+// it, only the entry stored under the key of a GET of each related URL, with the key headers of the write request.
+func (cfg *CacheConfig) invalidate(req *http.Request, resp *http.Response) (int, error) {
+	client, ok := cfg.RedisClient.(cacheDeleteClient)
+	if !ok {
+		return 0, errors.New("cache redis client does not support deletes")
+	}
+	ctx := context.WithoutCancel(req.Context())
+
+	related := cfg.relatedURLs(req)
+	tags := responseCacheTags(resp)
+	if cfg.Invalidation.Tags != nil {
+		tags = cfg.Invalidation.Tags(req, resp)
+	}
+
+	if admin, ok := cfg.RedisClient.(ICacheAdminRedisClient); ok && cfg.Index {
+		return cfg.purgeIndexed(ctx, &CacheInspector{cfg: cfg, client: admin}, related, tags)
+	}
+
+	var keys []string
+	for _, rawURL := range related {
+		if strings.HasSuffix(rawURL, "*") {
+			continue
+		}
+		get, err := http.NewRequestWithContext(req.Context(), http.MethodGet, rawURL, nil)
+		if err != nil {
+			continue
+		}
+		get.Header = req.Header
+		keys = append(keys, cacheKeyComponents(get, cfg).Key)
+	}
+	purged, err := deleteKeys(ctx, client, keys)
+	if err != nil {
+		return purged, fmt.Errorf("failed to purge cache keys: %w", err)
+	}
+	return purged, nil
+}
+
+// purgeIndexed purges the indexed entries of the related URLs and of the tags.
+//
+// Exact URLs are purged from their own URL set; only related URLs ending with "*" read the whole index.
+func (cfg *CacheConfig) purgeIndexed(ctx context.Context, inspector *CacheInspector, related, tags []string) (int, error) {
+	purged := 0
+	var prefixes []string
+	for _, rawURL := range related {
+		if strings.HasSuffix(rawURL, "*") {
+			prefixes = append(prefixes, rawURL)
+			continue
+		}
+
+		n, err := inspector.purgeSet(ctx, cfg.urlKey(rawURL))
+		purged += n
+		if err != nil {
+			return purged, fmt.Errorf("failed to purge cache url: %w", err)
+		}
+	}
+
+	if len(prefixes) > 0 {
+		n, err := inspector.purgeMatching(ctx, func(entryURL string) bool {
+			return matchesRelated(entryURL, prefixes)
+		})
+		purged += n
+		if err != nil {
+			return purged, err
+		}
+	}
+
+	for _, tag := range tags {
+		n, err := inspector.PurgeTag(ctx, tag)
+		purged += n
+		if err != nil {
+			return purged, err
+		}
+	}
+
+	return purged, nil
+}
+
+// relatedURLs returns the URLs purged after req.
+func (cfg *CacheConfig) relatedURLs(req *http.Request) []string {
+	if cfg.Invalidation.Related != nil {
+		return cfg.Invalidation.Related(req)
+	}
+
+	u := *req.URL
+	u.RawQuery, u.Fragment = "", ""
+	return []string{u.String()}
+}
+
+// matchesRelated reports whether entryURL, without its query, equals one of related or starts with one of the
+// related URLs ending with "*".
+func matchesRelated(entryURL string, related []string) bool {
+	path := withoutQuery(entryURL)
+
+	for _, rawURL := range related {
+		if prefix, ok := strings.CutSuffix(rawURL, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == rawURL {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutQuery returns rawURL without its query and fragment.
+func withoutQuery(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.RawQuery, u.Fragment = "", ""
+	return u.String()
+}
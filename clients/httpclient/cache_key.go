@@ -0,0 +1,160 @@
+package httpclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// CacheKeyConfig configures the canonicalization of request URLs into cache keys. It can be loaded with the
+// config package as part of CacheConfig.
+//
+// With every option disabled (the default), keys are computed from the full URL as before, so existing entries
+// remain valid. Enabling any option switches to the canonical form, where the query is hashed only through its
+// sorted, canonicalized representation.
+type CacheKeyConfig struct {
+	// IgnoreParams lists query parameters left out of the key, e.g. "utm_*" or "fbclid". A trailing "*"
+	// matches by prefix.
+	IgnoreParams []string `json:"ignore_params" env:"CACHE_KEY_IGNORE_PARAMS"`
+	// CaseInsensitiveParams lowercases query parameter names, so "?Page=2" and "?page=2" share an entry.
+	CaseInsensitiveParams bool `json:"case_insensitive_params" env:"CACHE_KEY_CASE_INSENSITIVE_PARAMS"`
+	// TrimTrailingSlash removes the trailing slash of the path, so "/products/" and "/products" share an entry.
+	TrimTrailingSlash bool `json:"trim_trailing_slash" env:"CACHE_KEY_TRIM_TRAILING_SLASH"`
+}
+
+func (k CacheKeyConfig) canonical() bool {
+	return len(k.IgnoreParams) > 0 || k.CaseInsensitiveParams || k.TrimTrailingSlash
+}
+
+func (k CacheKeyConfig) ignored(param string) bool {
+	for _, pattern := range k.IgnoreParams {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(strings.ToLower(param), strings.ToLower(prefix)) {
+				return true
+			}
+		} else if strings.EqualFold(param, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// CacheKeyComponents are the parts hashed into a cache key, exposed for debugging (see CacheConfig.DebugHeader
+// and CacheInspector.KeyComponents).
+type CacheKeyComponents struct {
+	Key string `json:"key"`
+	// Tenant is the tenant namespacing the key, with CacheConfig.Tenant enabled.
+	Tenant string `json:"tenant,omitempty"`
+	URL    string `json:"url"`
+	Query  string `json:"query"`
+	Vary   string `json:"vary"`
+	// Variant is the device variant, with CacheConfig.Variants set.
+	Variant string `json:"variant,omitempty"`
+}
+
+// String formats the components for the X-Cache-Key-Components header.
+func (c CacheKeyComponents) String() string {
+	s := fmt.Sprintf("url=%q; query=%q; vary=%q", c.URL, c.Query, c.Vary)
+	if c.Tenant != "" {
+		s = fmt.Sprintf("tenant=%q; ", c.Tenant) + s
+	}
+	if c.Variant != "" {
+		s += fmt.Sprintf("; variant=%q", c.Variant)
+	}
+	return s
+}
+
+func getCacheKey(req *http.Request, cfg *CacheConfig) string {
+	return cacheKeyComponents(req, cfg).Key
+}
+
+func cacheKeyComponents(req *http.Request, cfg *CacheConfig) CacheKeyComponents {
+	return cacheKeyComponentsFor(req, cfg, cfg.Headers)
+}
+
+// cacheKeyComponentsFor computes the key varying on headers, which may extend CacheConfig.Headers with the
+// headers listed in the Vary of a response. With tenant isolation, the key is prefixed with the tenant namespace
+// ("tenant:<id>:"), so tenants never share an entry; with Variants, the device variant is part of the key.
+func cacheKeyComponentsFor(req *http.Request, cfg *CacheConfig, headers cacheKeyHeaders) CacheKeyComponents {
+	components := CacheKeyComponents{
+		Tenant: cfg.Tenant.resolve(req),
+		URL:    buildURLPart(req, cfg.Key),
+		Query:  buildQueryPart(req, cfg.Key),
+		Vary:   buildVaryHeadersPart(req, headers),
+	}
+
+	parts := []string{components.URL, components.Query, components.Vary}
+	if len(cfg.Variants) > 0 {
+		components.Variant = cfg.Variants.Variant(func(header string) string { return forwardedHeader(req, header) })
+		parts = append(parts, components.Variant)
+	}
+
+	base := strings.Join(parts, "|")
+	hash := sha256.Sum256([]byte(base))
+	components.Key = hex.EncodeToString(hash[:])
+
+	if components.Tenant != "" {
+		components.Key = "tenant:" + components.Tenant + ":" + components.Key
+	}
+
+	return components
+}
+
+// buildURLPart returns the full URL or, in canonical mode, the lowercased scheme and host with the cleaned path.
+func buildURLPart(req *http.Request, key CacheKeyConfig) string {
+	if !key.canonical() {
+		return req.URL.String()
+	}
+
+	urlPath := req.URL.EscapedPath()
+	if urlPath == "" {
+		urlPath = "/"
+	}
+	urlPath = path.Clean(urlPath)
+	if !key.TrimTrailingSlash && strings.HasSuffix(req.URL.EscapedPath(), "/") && urlPath != "/" {
+		urlPath += "/"
+	}
+
+	return fmt.Sprintf("%s://%s%s", strings.ToLower(req.URL.Scheme), strings.ToLower(req.URL.Host), urlPath)
+}
+
+// buildQueryPart returns the query parameters sorted by name, with sorted values, leaving out the ignored ones.
+func buildQueryPart(req *http.Request, key CacheKeyConfig) string {
+	query := make(map[string][]string)
+
+	for k, v := range req.URL.Query() {
+		if key.ignored(k) {
+			continue
+		}
+		if key.CaseInsensitiveParams {
+			k = strings.ToLower(k)
+		}
+		query[k] = append(query[k], v...)
+	}
+
+	var queryParts []string
+
+	for k, v := range query {
+		sort.Strings(v)
+		queryParts = append(queryParts, k+"="+strings.Join(v, ","))
+	}
+
+	sort.Strings(queryParts)
+	return strings.Join(queryParts, "&")
+}
+
+func buildVaryHeadersPart(req *http.Request, headers cacheKeyHeaders) string {
+	var headersParts []string
+
+	for _, key := range headers {
+		if req.Header.Get(key) != "" {
+			headersParts = append(headersParts, key+":"+req.Header.Get(key))
+		}
+	}
+
+	return strings.Join(headersParts, "|")
+}
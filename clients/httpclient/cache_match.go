@@ -0,0 +1,93 @@
+package httpclient
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// CacheMatchRule selects requests by host and path. A rule matches when every field set matches.
+type CacheMatchRule struct {
+	// Host is a glob matched against the request host without port, e.g. "api.example.com" or "*.internal".
+	Host string `json:"host"`
+	// PathPrefix matches paths starting with the prefix, e.g. "/v1/catalog/".
+	PathPrefix string `json:"path_prefix"`
+	// PathRegex matches paths against a regular expression, e.g. "^/v1/products/[0-9]+$".
+	PathRegex string `json:"path_regex"`
+}
+
+type compiledCacheMatchRule struct {
+	rule  CacheMatchRule
+	regex *regexp.Regexp
+}
+
+func compileCacheMatchRules(rules []CacheMatchRule) []compiledCacheMatchRule {
+	compiled := make([]compiledCacheMatchRule, 0, len(rules))
+
+	for _, rule := range rules {
+		c := compiledCacheMatchRule{rule: rule}
+
+		if rule.PathRegex != "" {
+			regex, err := regexp.Compile(rule.PathRegex)
+			if err != nil {
+				logger.Error().Err(err).Str("path_regex", rule.PathRegex).Msg("Ignoring invalid cache match rule")
+				continue
+			}
+			c.regex = regex
+		}
+
+		compiled = append(compiled, c)
+	}
+
+	return compiled
+}
+
+func (c compiledCacheMatchRule) matches(req *http.Request) bool {
+	if c.rule.Host != "" {
+		ok, _ := path.Match(strings.ToLower(c.rule.Host), strings.ToLower(req.URL.Hostname()))
+		if !ok {
+			return false
+		}
+	}
+
+	if c.rule.PathPrefix != "" && !strings.HasPrefix(req.URL.Path, c.rule.PathPrefix) {
+		return false
+	}
+
+	if c.regex != nil && !c.regex.MatchString(req.URL.Path) {
+		return false
+	}
+
+	return true
+}
+
+// cacheMatcher reports whether a request may be cached according to CacheConfig.Include and Exclude.
+func (cfg *CacheConfig) cacheMatcher() func(req *http.Request) bool {
+	include := compileCacheMatchRules(cfg.Include)
+	exclude := compileCacheMatchRules(cfg.Exclude)
+
+	if len(cfg.Include) == 0 && len(cfg.Exclude) == 0 {
+		return func(*http.Request) bool { return true }
+	}
+
+	return func(req *http.Request) bool {
+		for _, rule := range exclude {
+			if rule.matches(req) {
+				return false
+			}
+		}
+
+		if len(cfg.Include) == 0 {
+			return true
+		}
+
+		for _, rule := range include {
+			if rule.matches(req) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
@@ -0,0 +1,77 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// cacheSchemaVersion is the version of the SerializableCache envelope written by this code.
+// Bump it when the envelope changes incompatibly and register the upgrade from the previous version
+// in cacheMigrations.
+const cacheSchemaVersion = 1
+
+// cacheEntry is the raw JSON object of a cache entry, as seen by migrations.
+type cacheEntry map[string]json.RawMessage
+
+// cacheMigrations upgrade an entry from the version of its index to the next one.
+//
+//	0 -> 1: entries written before versioning, without the framing fields (content_type, content_length).
+//	        content_type is recovered from the stored headers; the length check is skipped when absent.
+var cacheMigrations = []func(entry cacheEntry) error{
+	func(entry cacheEntry) error {
+		if _, ok := entry["content_type"]; ok {
+			return nil
+		}
+
+		var headers http.Header
+		if raw, ok := entry["header"]; ok {
+			if err := json.Unmarshal(raw, &headers); err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+		}
+
+		if contentType := headers.Get("Content-Type"); contentType != "" {
+			raw, _ := json.Marshal(contentType)
+			entry["content_type"] = raw
+		}
+
+		return nil
+	},
+}
+
+// migrateCacheEntry upgrades a serialized entry to cacheSchemaVersion, returning it with the version it was
+// written with. Entries written by a newer version (during a rolling deploy) are returned unchanged and decoded
+// best effort, since unknown fields are ignored.
+func migrateCacheEntry(raw []byte) ([]byte, int, error) {
+	var entry cacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal cached response: %w", err)
+	}
+
+	version := 0
+	if rawVersion, ok := entry["v"]; ok {
+		if err := json.Unmarshal(rawVersion, &version); err != nil {
+			return nil, 0, fmt.Errorf("invalid cache schema version: %w", err)
+		}
+	}
+
+	if version >= cacheSchemaVersion {
+		return raw, version, nil
+	}
+
+	for v := version; v < cacheSchemaVersion; v++ {
+		if err := cacheMigrations[v](entry); err != nil {
+			return nil, version, fmt.Errorf("failed to migrate cached response from schema version %d: %w", v, err)
+		}
+	}
+
+	entry["v"], _ = json.Marshal(cacheSchemaVersion)
+
+	migrated, err := json.Marshal(entry)
+	if err != nil {
+		return nil, version, fmt.Errorf("failed to marshal migrated cached response: %w", err)
+	}
+
+	return migrated, version, nil
+}
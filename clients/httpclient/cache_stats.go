@@ -0,0 +1,188 @@
+package httpclient
+
+import (
+	"expvar"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// hotKeysTracked is the number of hottest keys reported by CacheStats.
+const hotKeysTracked = 10
+
+// Count-min sketch dimensions used to estimate key hit counts in constant memory.
+const (
+	sketchDepth = 4
+	sketchWidth = 2048
+)
+
+func init() {
+	expvar.Publish("httpcache", expvar.Func(func() any { return CacheStats() }))
+}
+
+type cacheCounters struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+	stale  atomic.Int64
+	errors atomic.Int64
+
+	// invalidated counts the entries purged by writes (see CacheInvalidationConfig).
+	invalidated atomic.Int64
+
+	writes       atomic.Int64
+	writtenBytes atomic.Int64
+
+	hot hotKeys
+}
+
+var cacheCountersByName sync.Map
+
+func cacheStatsFor(name string) *cacheCounters {
+	counters, _ := cacheCountersByName.LoadOrStore(name, &cacheCounters{})
+	return counters.(*cacheCounters)
+}
+
+// hit records a cache hit on key. Stale hits are entries served past the max-age given by the origin,
+// kept longer by OverrideTTL or the settings.CacheTTLOverride setting.
+func (c *cacheCounters) hit(key, url string, stale bool) {
+	c.hits.Add(1)
+	if stale {
+		c.stale.Add(1)
+	}
+	c.hot.add(key, url)
+}
+
+// written records a cache write of size bytes.
+func (c *cacheCounters) written(size int) {
+	c.writes.Add(1)
+	c.writtenBytes.Add(int64(size))
+}
+
+// CacheStatsSnapshot holds the counters of a cache since the process started.
+type CacheStatsSnapshot struct {
+	Name   string `json:"name"`
+	Hits   int64  `json:"hits"`
+	Misses int64  `json:"misses"`
+	// Stale counts hits served past the max-age given by the origin (see OverrideTTL).
+	Stale    int64   `json:"stale"`
+	Errors   int64   `json:"errors"`
+	HitRatio float64 `json:"hit_ratio"`
+	// Invalidated counts the entries purged after writes through the client (see CacheConfig.Invalidation).
+	Invalidated int64 `json:"invalidated"`
+	// AvgEntrySize is the average size of the entries written, in bytes.
+	AvgEntrySize int64 `json:"avg_entry_size"`
+	// HotKeys are the most hit keys, with approximate hit counts.
+	HotKeys []HotCacheKey `json:"hot_keys,omitempty"`
+}
+
+// HotCacheKey is a frequently hit cache key. Hits is an estimate that may overcount, never undercount.
+type HotCacheKey struct {
+	Key  string `json:"key"`
+	URL  string `json:"url,omitempty"`
+	Hits uint64 `json:"hits"`
+}
+
+func (c *cacheCounters) snapshot(name string) CacheStatsSnapshot {
+	stats := CacheStatsSnapshot{
+		Name:        name,
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Stale:       c.stale.Load(),
+		Errors:      c.errors.Load(),
+		Invalidated: c.invalidated.Load(),
+		HotKeys:     c.hot.top(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	if writes := c.writes.Load(); writes > 0 {
+		stats.AvgEntrySize = c.writtenBytes.Load() / writes
+	}
+	return stats
+}
+
+// CacheStats returns the counters of every cache middleware, sorted by cache name. They are also published
+// with expvar as "httpcache", available on /debug/vars when the expvar handler is served.
+func CacheStats() []CacheStatsSnapshot {
+	var all []CacheStatsSnapshot
+	cacheCountersByName.Range(func(name, counters any) bool {
+		all = append(all, counters.(*cacheCounters).snapshot(name.(string)))
+		return true
+	})
+
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// hotKeys tracks the hottest keys approximately: a count-min sketch estimates the hits of every key, and a small
+// candidate set keeps the keys with the highest estimates.
+type hotKeys struct {
+	mu         sync.Mutex
+	sketch     [sketchDepth][sketchWidth]uint64
+	candidates map[string]*HotCacheKey
+}
+
+func (h *hotKeys) add(key, url string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	estimate := uint64(0)
+	for row := range sketchDepth {
+		cell := &h.sketch[row][sketchIndex(key, row)]
+		*cell++
+		if row == 0 || *cell < estimate {
+			estimate = *cell
+		}
+	}
+
+	if h.candidates == nil {
+		h.candidates = make(map[string]*HotCacheKey)
+	}
+
+	if candidate, ok := h.candidates[key]; ok {
+		candidate.Hits = estimate
+		return
+	}
+
+	if len(h.candidates) < hotKeysTracked*2 {
+		h.candidates[key] = &HotCacheKey{Key: key, URL: url, Hits: estimate}
+		return
+	}
+
+	var coldest *HotCacheKey
+	for _, candidate := range h.candidates {
+		if coldest == nil || candidate.Hits < coldest.Hits {
+			coldest = candidate
+		}
+	}
+
+	if estimate > coldest.Hits {
+		delete(h.candidates, coldest.Key)
+		h.candidates[key] = &HotCacheKey{Key: key, URL: url, Hits: estimate}
+	}
+}
+
+func (h *hotKeys) top() []HotCacheKey {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	top := make([]HotCacheKey, 0, len(h.candidates))
+	for _, candidate := range h.candidates {
+		top = append(top, *candidate)
+	}
+
+	sort.Slice(top, func(i, j int) bool { return top[i].Hits > top[j].Hits })
+	if len(top) > hotKeysTracked {
+		top = top[:hotKeysTracked]
+	}
+
+	return top
+}
+
+func sketchIndex(key string, row int) int {
+	hash := fnv.New64a()
+	hash.Write([]byte{byte(row)})
+	hash.Write([]byte(key))
+	return int(hash.Sum64() % sketchWidth)
+}
@@ -0,0 +1,67 @@
+package httpclient
+
+import (
+	"net/http"
+	"slices"
+	"time"
+)
+
+// CacheTenantConfig isolates the cache entries of each tenant of a multi-tenant BFF and lets the cache differ per
+// tenant. It can be loaded with the config package as part of CacheConfig.
+type CacheTenantConfig struct {
+	// Header is the forwarded header carrying the tenant ID, e.g. "x-tenant-id" (see server.TenantMiddleware).
+	// Setting it, or Resolver, enables tenant isolation.
+	Header string `json:"header" env:"CACHE_TENANT_HEADER"`
+	// Resolver returns the tenant ID of a request, instead of Header.
+	Resolver func(req *http.Request) string `json:"-"`
+	// AllowShared caches the requests without tenant in a shared namespace. By default they bypass the cache, so a
+	// request missing its tenant never reads an entry stored for another one.
+	AllowShared bool `json:"allow_shared" env:"CACHE_TENANT_ALLOW_SHARED"`
+	// TTL overrides the TTL of the entries stored for each tenant, e.g. {"acme": "5m"}.
+	TTL map[string]time.Duration `json:"ttl" env:"CACHE_TENANT_TTL"`
+	// Disabled lists the tenants whose requests bypass the cache.
+	Disabled []string `json:"disabled" env:"CACHE_TENANT_DISABLED"`
+}
+
+func (t CacheTenantConfig) enabled() bool {
+	return t.Header != "" || t.Resolver != nil
+}
+
+// resolve returns the tenant of req: from Resolver or, with Header, from the forwarded headers of the request
+// context or the request header itself.
+func (t CacheTenantConfig) resolve(req *http.Request) string {
+	if t.Resolver != nil {
+		return t.Resolver(req)
+	}
+	if t.Header == "" {
+		return ""
+	}
+	return forwardedHeader(req, t.Header)
+}
+
+// forwardedHeader returns the header of req, looking first at the forwarded headers of the request context.
+func forwardedHeader(req *http.Request, name string) string {
+	for k, v := range getForwardedHeaders(req.Context()) {
+		if http.CanonicalHeaderKey(k) == http.CanonicalHeaderKey(name) && v != "" {
+			return v
+		}
+	}
+	return req.Header.Get(name)
+}
+
+// bypass reports whether requests of tenant must skip the cache.
+func (t CacheTenantConfig) bypass(tenant string) bool {
+	if !t.enabled() {
+		return false
+	}
+	if tenant == "" {
+		return !t.AllowShared
+	}
+	return slices.Contains(t.Disabled, tenant)
+}
+
+// ttl returns the TTL configured for tenant, if any.
+func (t CacheTenantConfig) ttl(tenant string) (time.Duration, bool) {
+	ttl, ok := t.TTL[tenant]
+	return ttl, ok && tenant != ""
+}
@@ -0,0 +1,148 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// validatorsKeySuffix is appended to the key of an entry to form the key of its validators.
+const validatorsKeySuffix = ":validators"
+
+// defaultCacheValidatorsTTL is the ValidatorsTTL used when none is configured.
+const defaultCacheValidatorsTTL = 24 * time.Hour
+
+// cacheValidators are the ETag and Last-Modified of a cached response, stored apart from its body. They are a few
+// bytes, so they outlive bodies that were never stored (above MaxBodySize) or were evicted by Redis.
+type cacheValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// StoredAt is the Unix time the response was validated by the origin, and MaxAge its freshness in seconds.
+	StoredAt int64 `json:"stored_at"`
+	MaxAge   int64 `json:"max_age"`
+	// Headers are the headers the key of the validators was built from: CacheConfig.Headers and the Vary of the
+	// response.
+	Headers []string `json:"headers,omitempty"`
+}
+
+// newCacheValidators returns the validators of resp, reporting false when it has none.
+func newCacheValidators(resp *http.Response, ttl time.Duration, now time.Time) (cacheValidators, bool) {
+	v := cacheValidators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		StoredAt:     now.Unix(),
+		MaxAge:       int64(ttl.Seconds()),
+	}
+	return v, v.ETag != "" || v.LastModified != ""
+}
+
+// refreshed returns the validators revalidated by a 304 response of the origin, which may update them.
+func (v cacheValidators) refreshed(resp *http.Response, ttl time.Duration, now time.Time) cacheValidators {
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		v.ETag = etag
+	}
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		v.LastModified = lastModified
+	}
+	v.StoredAt = now.Unix()
+	v.MaxAge = int64(ttl.Seconds())
+	return v
+}
+
+// remaining returns how long the validated response stays fresh at now.
+func (v cacheValidators) remaining(now time.Time) time.Duration {
+	return time.Unix(v.StoredAt, 0).Add(time.Duration(v.MaxAge) * time.Second).Sub(now)
+}
+
+// matches reports whether the conditional headers of req match the validators: If-None-Match is compared weakly
+// with the ETag and takes precedence over If-Modified-Since, compared with Last-Modified.
+func (v cacheValidators) matches(req *http.Request) bool {
+	if noneMatch := req.Header.Get("If-None-Match"); noneMatch != "" {
+		if v.ETag == "" {
+			return false
+		}
+		for _, candidate := range strings.Split(noneMatch, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(v.ETag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	modifiedSince, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lastModified, err := http.ParseTime(v.LastModified)
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(modifiedSince)
+}
+
+// notModified builds the 304 response answering req from the validators, fresh for another remaining.
+func (v cacheValidators) notModified(req *http.Request, remaining time.Duration) *http.Response {
+	header := make(http.Header)
+	if v.ETag != "" {
+		header.Set("ETag", v.ETag)
+	}
+	if v.LastModified != "" {
+		header.Set("Last-Modified", v.LastModified)
+	}
+	header.Set("Cache-Control", fmt.Sprintf("max-age=%v, public", int(remaining.Seconds())))
+	header.Set("X-Cache", "HIT")
+
+	return &http.Response{
+		StatusCode: http.StatusNotModified,
+		Status:     "304 Not Modified",
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
+
+// isConditional reports whether req carries validators of a copy the caller already holds.
+func isConditional(req *http.Request) bool {
+	return req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+}
+
+// lookupValidators fetches the validators stored for req, under the same candidate keys as its entry.
+func (cfg *CacheConfig) lookupValidators(ctx context.Context, req *http.Request, base CacheKeyComponents, sets *varySets) (cacheValidators, CacheKeyComponents, bool) {
+	value, components, err := cfg.lookup(ctx, req, base, sets, validatorsKeySuffix)
+	if err != nil || value == "" {
+		return cacheValidators{}, base, false
+	}
+
+	var v cacheValidators
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		logger.Error().Err(err).Str("url", req.URL.String()).Msg("Discarding invalid cached validators")
+		return cacheValidators{}, base, false
+	}
+
+	return v, components, true
+}
+
+// scheduleValidators stores the validators of the entry with key in the background, for ValidatorsTTL.
+func (cfg *CacheConfig) scheduleValidators(key string, v cacheValidators) {
+	value, err := json.Marshal(v)
+	if err != nil {
+		logger.Err(err).Msg("Error serializing validators for cache")
+		return
+	}
+
+	ttl := cfg.ValidatorsTTL
+	if ttl <= 0 {
+		ttl = defaultCacheValidatorsTTL
+	}
+
+	cfg.scheduleWrite(func(ctx context.Context) error {
+		return cfg.writeClient().Set(ctx, key+validatorsKeySuffix, value, ttl)
+	})
+}
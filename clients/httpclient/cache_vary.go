@@ -0,0 +1,171 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxVarySets bounds the distinct header sets learned from Vary, and so the candidate keys of each lookup.
+const maxVarySets = 8
+
+// cacheMultiGetClient is implemented by Redis clients able to fetch several keys in one round trip
+// (e.g. redisclient.RedisClient).
+type cacheMultiGetClient interface {
+	MGet(ctx context.Context, keys ...string) ([]any, error)
+}
+
+// varySets holds the header sets a cache has seen in the Vary of responses, combined with CacheConfig.Headers.
+// They are learned per process: each instance discovers them on its first misses.
+type varySets struct {
+	mu   sync.RWMutex
+	sets []cacheKeyHeaders
+}
+
+var varySetsByName sync.Map
+
+func cacheVarySetsFor(name string) *varySets {
+	sets, _ := varySetsByName.LoadOrStore(name, &varySets{})
+	return sets.(*varySets)
+}
+
+// add learns a header set, reporting false when there are already maxVarySets sets and the variant must not be
+// cached, since lookups would never find it.
+func (v *varySets) add(headers cacheKeyHeaders) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, set := range v.sets {
+		if slices.Equal(set, headers) {
+			return true
+		}
+	}
+
+	if len(v.sets) >= maxVarySets {
+		logger.Warn().Strs("headers", headers).Msg("Too many distinct Vary header sets, not caching variant")
+		return false
+	}
+
+	v.sets = append(v.sets, headers)
+	sort.SliceStable(v.sets, func(i, j int) bool { return len(v.sets[i]) > len(v.sets[j]) })
+	return true
+}
+
+// list returns a copy of the sets, since add reorders them in place.
+func (v *varySets) list() []cacheKeyHeaders {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return slices.Clone(v.sets)
+}
+
+// responseVary returns CacheConfig.Headers extended with the headers listed in the Vary of the response, except
+// the ones in covered (the CacheConfig.Variants headers, already in the key as a bounded variant).
+// It reports false when the response varies on "*" and must not be cached.
+func responseVary(resp *http.Response, base cacheKeyHeaders, covered []string) (cacheKeyHeaders, bool) {
+	headers := slices.Clone(base)
+	var extra []string
+
+	for _, value := range resp.Header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+
+			switch {
+			case name == "*":
+				return nil, false
+			case name == "" || name == "Accept-Encoding":
+				// Accept-Encoding is negotiated by the transport and never part of the key.
+			case slices.ContainsFunc(covered, func(h string) bool { return strings.EqualFold(h, name) }):
+				// Variant headers are keyed by their bucket, not their raw value.
+			case !slices.ContainsFunc(headers, func(h string) bool { return strings.EqualFold(h, name) }) &&
+				!slices.Contains(extra, name):
+				extra = append(extra, name)
+			}
+		}
+	}
+
+	sort.Strings(extra)
+	return append(headers, extra...), true
+}
+
+// storedKeyHeaders is the part of cached entries (policy.headers) and validators (headers) naming the headers their
+// key was built from.
+type storedKeyHeaders struct {
+	Policy  CachePolicy `json:"policy"`
+	Headers []string    `json:"headers"`
+}
+
+// storedFor reports whether value, found under the key of c, was stored for the values req has for the headers
+// the record varies on. A record found under a candidate key it was not built from, e.g. one written before the
+// response started to vary, is not served.
+func (cfg *CacheConfig) storedFor(req *http.Request, c CacheKeyComponents, value string) bool {
+	var stored storedKeyHeaders
+	if err := json.Unmarshal([]byte(value), &stored); err != nil {
+		// Invalid records are reported and discarded by the caller.
+		return true
+	}
+
+	headers := stored.Policy.Headers
+	if headers == nil {
+		headers = stored.Headers
+	}
+	return cacheKeyComponentsFor(req, cfg, headers).Key == c.Key
+}
+
+// lookup fetches the cached entry of req, or the record stored next to it when suffix is not empty. When responses
+// were seen varying on extra headers, every candidate key (most specific first, then base) is fetched in a single
+// MGET when the client supports it (redisclient.RedisClient pipelines it per key on Redis Cluster), and the first
+// variant present whose stored Vary headers match the request wins.
+func (cfg *CacheConfig) lookup(ctx context.Context, req *http.Request, base CacheKeyComponents, sets *varySets, suffix string) (string, CacheKeyComponents, error) {
+	candidates := make([]CacheKeyComponents, 0, 1)
+
+	for _, headers := range sets.list() {
+		components := cacheKeyComponentsFor(req, cfg, headers)
+		if components.Key != base.Key && !slices.ContainsFunc(candidates, func(c CacheKeyComponents) bool { return c.Key == components.Key }) {
+			candidates = append(candidates, components)
+		}
+	}
+	candidates = append(candidates, base)
+
+	if len(candidates) == 1 {
+		value, err := cfg.RedisClient.Get(ctx, base.Key+suffix)
+		if err == nil && value != "" && !cfg.storedFor(req, base, value) {
+			return "", base, nil
+		}
+		return value, base, err
+	}
+
+	if multi, ok := cfg.RedisClient.(cacheMultiGetClient); ok {
+		keys := make([]string, len(candidates))
+		for i, c := range candidates {
+			keys[i] = c.Key + suffix
+		}
+
+		values, err := multi.MGet(ctx, keys...)
+		if err != nil {
+			return "", base, err
+		}
+
+		for i, value := range values {
+			if s, ok := value.(string); ok && s != "" && cfg.storedFor(req, candidates[i], s) {
+				return s, candidates[i], nil
+			}
+		}
+
+		return "", base, nil
+	}
+
+	var lastErr error
+	for _, c := range candidates {
+		value, err := cfg.RedisClient.Get(ctx, c.Key+suffix)
+		if err == nil && value != "" && cfg.storedFor(req, c, value) {
+			return value, c, nil
+		}
+		lastErr = err
+	}
+
+	return "", base, lastErr
+}
@@ -0,0 +1,180 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryRedis is an in-memory IRedisClient, with MGet, ignoring expirations.
+type memoryRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newMemoryRedis() *memoryRedis {
+	return &memoryRedis{data: map[string]string{}}
+}
+
+func (m *memoryRedis) Get(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data[key], nil
+}
+
+func (m *memoryRedis) Set(_ context.Context, key string, value any, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch v := value.(type) {
+	case []byte:
+		m.data[key] = string(v)
+	default:
+		m.data[key] = v.(string)
+	}
+	return nil
+}
+
+func (m *memoryRedis) MGet(ctx context.Context, keys ...string) ([]any, error) {
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		if value, _ := m.Get(ctx, key); value != "" {
+			values[i] = value
+		}
+	}
+	return values, nil
+}
+
+// waitFor polls until key is stored, since cache writes run in the background.
+func (m *memoryRedis) waitFor(t *testing.T, key string) string {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if value, _ := m.Get(context.Background(), key); value != "" {
+			return value
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("key %q never stored", key)
+	return ""
+}
+
+// newVaryOrigin returns a server answering with the Authorization of the request, varying on it.
+func newVaryOrigin(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Authorization")
+		_, _ = w.Write([]byte(`{"user":"` + r.Header.Get("Authorization") + `"}`))
+	}))
+	t.Cleanup(origin.Close)
+	return origin
+}
+
+func getWithAuthorization(t *testing.T, client *HTTPClient, url, authorization string) string {
+	t.Helper()
+
+	ctx := context.Background()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req.Header.Set("Authorization", authorization)
+	resp, err := client.client.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	return string(body)
+}
+
+func TestCacheVaryServesEachUserTheirVariant(t *testing.T) {
+	var calls atomic.Int32
+	origin := newVaryOrigin(t, &calls)
+	redis := newMemoryRedis()
+	cfg := &CacheConfig{Name: t.Name(), RedisClient: redis}
+	client := NewHTTPClient("", 2*time.Second, NewCacheMiddleware(cfg))
+
+	url := origin.URL + "/me"
+	if body := getWithAuthorization(t, client, url, "alice"); !strings.Contains(body, "alice") {
+		t.Fatalf("got %s, want alice", body)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Authorization", "alice")
+	redis.waitFor(t, cacheKeyComponentsFor(req, cfg, cacheKeyHeaders{"Authorization"}).Key)
+
+	if body := getWithAuthorization(t, client, url, "bob"); !strings.Contains(body, "bob") {
+		t.Fatalf("bob got %s", body)
+	}
+	if body := getWithAuthorization(t, client, url, "alice"); !strings.Contains(body, "alice") {
+		t.Fatalf("alice got %s", body)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("origin called %d times, want 2", n)
+	}
+}
+
+func TestCacheVaryDoesNotServeMismatchedBaseEntry(t *testing.T) {
+	var calls atomic.Int32
+	origin := newVaryOrigin(t, &calls)
+	redis := newMemoryRedis()
+	cfg := &CacheConfig{Name: t.Name(), RedisClient: redis}
+	client := NewHTTPClient("", 2*time.Second, NewCacheMiddleware(cfg))
+
+	url := origin.URL + "/me"
+	getWithAuthorization(t, client, url, "alice")
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Authorization", "alice")
+	aliceEntry := redis.waitFor(t, cacheKeyComponentsFor(req, cfg, cacheKeyHeaders{"Authorization"}).Key)
+
+	// An entry varying on Authorization found under the base key, e.g. written by another version, must not be
+	// served to other users.
+	_ = redis.Set(context.Background(), cacheKeyComponents(req, cfg).Key, aliceEntry, 0)
+
+	if body := getWithAuthorization(t, client, url, "bob"); !strings.Contains(body, "bob") {
+		t.Fatalf("bob got %s", body)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("origin called %d times, want 2", n)
+	}
+}
+
+func TestVarySetsListReturnsCopy(t *testing.T) {
+	sets := &varySets{}
+	sets.add(cacheKeyHeaders{"A"})
+	listed := sets.list()
+
+	sets.add(cacheKeyHeaders{"A", "B"})
+	if !slices.Equal(listed[0], cacheKeyHeaders{"A"}) {
+		t.Fatalf("listed sets changed by add: %v", listed)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			sets.add(cacheKeyHeaders{"A", "B", string(rune('C' + i))})
+		}()
+		go func() {
+			defer wg.Done()
+			for range sets.list() {
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,100 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/observability"
+)
+
+// maxRecordedCalls bounds the calls kept by a CallRecorder.
+const maxRecordedCalls = 100
+
+// CallTiming describes a downstream call made by an HTTPClient.
+type CallTiming struct {
+	Method string `json:"method"`
+	Host   string `json:"host"`
+	// Route is the path of the call, with ID-like segments replaced by ":id".
+	Route  string `json:"route"`
+	Status int    `json:"status,omitempty"`
+	// Cache is the X-Cache header of the response (HIT, MISS), when the cache middleware is installed.
+	Cache string `json:"cache,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Offset is the time between the creation of the recorder and the start of the call.
+	Offset   time.Duration `json:"offset"`
+	Duration time.Duration `json:"duration"`
+}
+
+// CallRecorderKeyType is the context key of the CallRecorder set by WithCallRecorder.
+type CallRecorderKeyType struct{}
+
+// CallRecorder collects the downstream calls made with a context, e.g. to explain a slow server request.
+type CallRecorder struct {
+	start time.Time
+
+	mu      sync.Mutex
+	calls   []CallTiming
+	dropped int
+}
+
+// WithCallRecorder returns a context whose HTTPClient calls are recorded by the returned CallRecorder. Only the
+// first 100 calls are kept.
+//
+// Usage:
+//
+//	ctx, calls := httpclient.WithCallRecorder(c.UserContext())
+//	c.SetUserContext(ctx)
+//	...
+//	for _, call := range calls.Calls() { ... }
+func WithCallRecorder(ctx context.Context) (context.Context, *CallRecorder) {
+	recorder := &CallRecorder{start: time.Now()}
+	return context.WithValue(ctx, CallRecorderKeyType{}, recorder), recorder
+}
+
+// Calls returns the recorded calls, in the order they finished.
+func (r *CallRecorder) Calls() []CallTiming {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CallTiming(nil), r.calls...)
+}
+
+// Dropped returns the number of calls not kept after the first 100.
+func (r *CallRecorder) Dropped() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
+
+// recordCall records the call of req, started at start, in the CallRecorder of ctx, if any.
+func recordCall(ctx context.Context, req *http.Request, start time.Time, resp *http.Response, err error) {
+	recorder, ok := ctx.Value(CallRecorderKeyType{}).(*CallRecorder)
+	if !ok {
+		return
+	}
+
+	call := CallTiming{
+		Method:   req.Method,
+		Host:     req.URL.Host,
+		Route:    observability.TemplatePath(req.URL.Path),
+		Offset:   start.Sub(recorder.start),
+		Duration: time.Since(start),
+	}
+	if resp != nil {
+		call.Status = resp.StatusCode
+		call.Cache = resp.Header.Get("X-Cache")
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	if len(recorder.calls) >= maxRecordedCalls {
+		recorder.dropped++
+		return
+	}
+	recorder.calls = append(recorder.calls, call)
+}
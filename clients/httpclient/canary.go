@@ -0,0 +1,165 @@
+package httpclient
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	variantPrimary = "primary"
+	variantCanary  = "canary"
+)
+
+// CanaryConfig holds the configuration of the canary routing middleware. It can be loaded with the config package.
+type CanaryConfig struct {
+	// Name identifies the downstream in metrics and logs.
+	Name string `json:"name"`
+	// BaseURL is the alternate base URL. Its scheme and host replace the ones of the request and its path,
+	// if any, is prepended to the request path.
+	BaseURL string `json:"base_url" env:"CANARY_BASE_URL"`
+	// Percent is the percentage (0-100) of requests sent to BaseURL.
+	Percent float64 `json:"percent" env:"CANARY_PERCENT"`
+	// Headers always send the requests that have them to BaseURL. An empty value matches any value of the header.
+	Headers map[string]string `json:"headers"`
+}
+
+// NewCanaryMiddleware sends part of the traffic to an alternate base URL and compares both variants, to migrate
+// downstreams or validate a new provider.
+//
+// Parameters:
+//
+//	cfg: Alternate base URL, percentage and headers that select the canary.
+//
+// Behavior:
+//   - A request goes to the canary when it has one of cfg.Headers or, otherwise, with probability cfg.Percent.
+//   - The http.client.canary.duration histogram records the latency of both variants, labelled by variant and
+//     status class, so status and latency can be compared side by side.
+//   - The http.client.canary.error_rate_divergence and http.client.canary.latency_divergence gauges report the
+//     canary error rate minus the primary one and the canary average latency over the primary one.
+//   - An invalid BaseURL is logged and disables the canary.
+//
+// Usage:
+//
+//	client := httpclient.NewHTTPClient("https://catalog.internal", 2*time.Second,
+//		httpclient.NewCanaryMiddleware(httpclient.CanaryConfig{
+//			Name:    "catalog",
+//			BaseURL: "https://catalog-v2.internal",
+//			Percent: 5,
+//			Headers: map[string]string{"x-canary": ""},
+//		}),
+//	)
+func NewCanaryMiddleware(cfg CanaryConfig) func(next http.RoundTripper) http.RoundTripper {
+	target, err := url.Parse(cfg.BaseURL)
+	if err != nil || target.Host == "" {
+		logger.Error().Err(err).Str("name", cfg.Name).Str("base_url", cfg.BaseURL).Msg("Invalid canary base URL, canary disabled")
+		return func(next http.RoundTripper) http.RoundTripper { return next }
+	}
+
+	primary, canary := &variantStats{}, &variantStats{}
+
+	nameAttr := attribute.String("peer.service", cfg.Name)
+	meter := otel.Meter(instrumentationName)
+	duration, _ := meter.Float64Histogram("http.client.canary.duration",
+		metric.WithDescription("Duration of outgoing requests by canary variant."),
+		metric.WithUnit("s"))
+	errorDivergence, _ := meter.Float64ObservableGauge("http.client.canary.error_rate_divergence",
+		metric.WithDescription("Canary error rate minus primary error rate."))
+	latencyDivergence, _ := meter.Float64ObservableGauge("http.client.canary.latency_divergence",
+		metric.WithDescription("Canary average latency over primary average latency."))
+	_, _ = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		primaryErrors, primaryLatency := primary.snapshot()
+		canaryErrors, canaryLatency := canary.snapshot()
+
+		o.ObserveFloat64(errorDivergence, canaryErrors-primaryErrors, metric.WithAttributes(nameAttr))
+		if primaryLatency > 0 && canaryLatency > 0 {
+			o.ObserveFloat64(latencyDivergence, float64(canaryLatency)/float64(primaryLatency), metric.WithAttributes(nameAttr))
+		}
+		return nil
+	}, errorDivergence, latencyDivergence)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			variant, stats := variantPrimary, primary
+			if cfg.selects(req) {
+				variant, stats = variantCanary, canary
+
+				req = req.Clone(req.Context())
+				req.URL.Scheme = target.Scheme
+				req.URL.Host = target.Host
+				req.Host = ""
+				if prefix := strings.TrimSuffix(target.Path, "/"); prefix != "" {
+					req.URL.Path = prefix + req.URL.Path
+					req.URL.RawPath = ""
+				}
+			}
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			elapsed := time.Since(start)
+
+			failed := err != nil || resp.StatusCode >= 500
+			stats.observe(elapsed, failed)
+
+			status := "error"
+			if err == nil {
+				status = strconv.Itoa(resp.StatusCode/100) + "xx"
+			}
+
+			duration.Record(req.Context(), elapsed.Seconds(), metric.WithAttributes(
+				nameAttr,
+				attribute.String("variant", variant),
+				attribute.String("status_class", status),
+			))
+
+			return resp, err
+		})
+	}
+}
+
+func (cfg CanaryConfig) selects(req *http.Request) bool {
+	for name, value := range cfg.Headers {
+		if got := req.Header.Get(name); got != "" && (value == "" || got == value) {
+			return true
+		}
+	}
+
+	return cfg.Percent > 0 && rand.Float64()*100 < cfg.Percent
+}
+
+// variantStats keeps moving averages of the error rate and latency of a canary variant.
+type variantStats struct {
+	mu        sync.Mutex
+	errorRate float64
+	latency   ewma
+}
+
+func (s *variantStats) observe(d time.Duration, failed bool) {
+	s.latency.observe(d, time.Now())
+
+	sample := 0.0
+	if failed {
+		sample = 1
+	}
+
+	s.mu.Lock()
+	s.errorRate = 0.95*s.errorRate + 0.05*sample
+	s.mu.Unlock()
+}
+
+func (s *variantStats) snapshot() (float64, time.Duration) {
+	s.mu.Lock()
+	errorRate := s.errorRate
+	s.mu.Unlock()
+
+	return errorRate, s.latency.value(time.Now())
+}
@@ -1,58 +1,104 @@
 package httpclient
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/devluispereira/go-package/clock"
+	"github.com/devluispereira/go-package/settings"
 	"github.com/sony/gobreaker"
 )
 
-// NewCircuitBreaker wraps an http.RoundTripper with a circuit breaker using gobreaker.
+// BreakerConfig holds the circuit breaker settings. It can be loaded with the config package.
+type BreakerConfig struct {
+	Name string `json:"name" env:"BREAKER_NAME"`
+	// MaxRequests is the number of requests allowed while half-open.
+	MaxRequests uint32 `json:"max_requests" env:"BREAKER_MAX_REQUESTS" default:"10"`
+	// Interval is the cyclic period of the closed state used to clear the counts.
+	Interval time.Duration `json:"interval" env:"BREAKER_INTERVAL" default:"10s"`
+	// Timeout is how long the breaker stays open before becoming half-open.
+	Timeout time.Duration `json:"timeout" env:"BREAKER_TIMEOUT" default:"60s"`
+	// MinRequests is the minimum number of requests in the interval before the breaker may trip.
+	MinRequests uint32 `json:"min_requests" env:"BREAKER_MIN_REQUESTS" default:"20"`
+	// FailureRatio is the failure ratio (0-1) that trips the breaker.
+	FailureRatio float64 `json:"failure_ratio" env:"BREAKER_FAILURE_RATIO" default:"0.5"`
+	// Clock times the open timeout and the counting interval, and timestamps the state transitions listed by
+	// Breakers. Defaults to clock.Real.
+	Clock clock.Clock `json:"-"`
+}
+
+// ErrBreakerForcedOpen is returned when a breaker was forced open through the settings.BreakerForcePrefix setting.
+var ErrBreakerForcedOpen = errors.New("circuit breaker forced open")
+
+// DefaultBreakerConfig returns the default circuit breaker settings for the given name.
+func DefaultBreakerConfig(name string) BreakerConfig {
+	return BreakerConfig{
+		Name:         name,
+		MaxRequests:  10,
+		Interval:     10 * time.Second,
+		Timeout:      60 * time.Second,
+		MinRequests:  20,
+		FailureRatio: 0.5,
+	}
+}
+
+// NewCircuitBreaker wraps an http.RoundTripper with a circuit breaker following gobreaker's state machine.
 //
 // The circuit breaker monitors HTTP requests and opens the circuit when the error rate
 // reaches a threshold (default: 50% errors out of at least 20 requests, considering status >= 500 or 429 as errors).
 // While open, requests will fail fast without calling the underlying transport. After a short interval,
 // a limited number of requests are allowed to test recovery. If successful, the circuit closes again.
+// Operators can force the breaker "open" or "closed" at runtime through the settings.BreakerForcePrefix setting,
+// or bypass it entirely, without counting requests, with the "disable:breaker:<name>" setting.
+// Middlewares of the same name share one breaker, created with the settings of the first one, so clients built
+// per request or per tenant don't add a breaker each. Every breaker is listed by Breakers (see
+// admin.RegisterBreakers).
 //
 // Parameters:
 //
-//	cfg: Configuration for the circuit breaker.
-//	     - cfg.Enabled: activates/deactivates the breaker.
-//	     - cfg.Name: identifies the breaker instance (useful for logging/metrics).
-//	next: The next http.RoundTripper to be wrapped. This is usually http.DefaultTransport or a custom transport.
+//	name: identifies the breaker, usually the downstream it protects (useful for logging/metrics).
 //
 // Returns:
 //
 //	An http.RoundTripper that applies circuit breaker logic to all requests.
 func NewCircuitBreakerMiddleware(name string) func(next http.RoundTripper) http.RoundTripper {
-	return func(next http.RoundTripper) http.RoundTripper {
-		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+	return NewCircuitBreakerMiddlewareWithConfig(DefaultBreakerConfig(name))
+}
 
-			breaker := gobreaker.NewCircuitBreaker(gobreaker.Settings{
-				Name:        name,
-				MaxRequests: 10,
-				Interval:    time.Second * 10,
+// NewCircuitBreakerMiddlewareWithConfig behaves like NewCircuitBreakerMiddleware using the given settings.
+// Zero values fall back to the defaults.
+//
+// Usage:
+//
+//	cfg := httpclient.DefaultBreakerConfig("catalog")
+//	_ = config.Load(&cfg)
+//	client := httpclient.NewHTTPClient(baseURL, 5*time.Second, httpclient.NewCircuitBreakerMiddlewareWithConfig(cfg))
+func NewCircuitBreakerMiddlewareWithConfig(cfg BreakerConfig) func(next http.RoundTripper) http.RoundTripper {
+	cfg = withBreakerDefaults(cfg)
+	name := cfg.Name
 
-				ReadyToTrip: func(counts gobreaker.Counts) bool {
-					total := counts.Requests
-					failures := counts.TotalFailures
-					return total >= 20 && failures*100/total >= 50
-				},
+	return func(next http.RoundTripper) http.RoundTripper {
+		breaker := registerBreaker(cfg, breakerSuccess)
 
-				IsSuccessful: func(err error) bool {
-					if err == nil {
-						return true
-					}
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if settings.Default.Disabled("breaker", name) {
+				return next.RoundTrip(req)
+			}
 
-					if httpErr, ok := err.(*HTTPStatusError); ok {
-						return httpErr.Status < 500 && httpErr.Status != 429
-					}
+			exec := executionFrom(req.Context())
 
-					return false
-				},
-			})
+			switch forcedState(name) {
+			case "open":
+				exec.breakerState("forced-open")
+				return nil, ErrBreakerForcedOpen
+			case "closed":
+				exec.breakerState("forced-closed")
+				return next.RoundTrip(req)
+			}
 
+			exec.breakerState(breaker.State().String())
 			logState(name, breaker, req)
 
 			result, err := breaker.Execute(func() (any, error) {
@@ -77,6 +123,42 @@ func NewCircuitBreakerMiddleware(name string) func(next http.RoundTripper) http.
 	}
 }
 
+// breakerSuccess reports whether a request outcome counts as a success: 5xx and 429 responses and transport errors
+// are failures.
+func breakerSuccess(err error) bool {
+	if err == nil {
+		return true
+	}
+
+	if httpErr, ok := err.(*HTTPStatusError); ok {
+		return httpErr.Status < 500 && httpErr.Status != 429
+	}
+
+	return false
+}
+
+func withBreakerDefaults(cfg BreakerConfig) BreakerConfig {
+	defaults := DefaultBreakerConfig(cfg.Name)
+
+	if cfg.MaxRequests == 0 {
+		cfg.MaxRequests = defaults.MaxRequests
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.MinRequests == 0 {
+		cfg.MinRequests = defaults.MinRequests
+	}
+	if cfg.FailureRatio <= 0 {
+		cfg.FailureRatio = defaults.FailureRatio
+	}
+
+	return cfg
+}
+
 type HTTPStatusError struct {
 	Status int
 	Err    error
@@ -86,7 +168,7 @@ func (e *HTTPStatusError) Error() string {
 	return fmt.Sprintf("HTTP status %d: %v", e.Status, e.Err)
 }
 
-func logState(name string, breaker *gobreaker.CircuitBreaker, req *http.Request) {
+func logState(name string, breaker *circuitBreaker, req *http.Request) {
 	state := breaker.State()
 	if state != gobreaker.StateClosed {
 		var stateStr string
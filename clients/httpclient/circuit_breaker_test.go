@@ -0,0 +1,153 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devluispereira/go-package/clock/clocktest"
+	"github.com/devluispereira/go-package/settings"
+	"github.com/sony/gobreaker"
+)
+
+func TestCircuitBreakerTimeoutFollowsClock(t *testing.T) {
+	clk := clocktest.NewFake(time.Time{})
+	cfg := DefaultBreakerConfig(t.Name())
+	cfg.MinRequests = 2
+	cfg.MaxRequests = 1
+	cfg.Timeout = time.Minute
+	cfg.Clock = clk
+
+	var calls atomic.Int32
+	rt := NewCircuitBreakerMiddlewareWithConfig(cfg)(respondWith(&calls, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK))
+	roundTrip := func() error {
+		_, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://catalog/", nil))
+		return err
+	}
+
+	_ = roundTrip()
+	_ = roundTrip()
+	if err := roundTrip(); !errors.Is(err, gobreaker.ErrOpenState) {
+		t.Fatalf("got %v, want ErrOpenState", err)
+	}
+
+	// Only the fake time reopens the breaker to a probe.
+	clk.Advance(59 * time.Second)
+	if err := roundTrip(); !errors.Is(err, gobreaker.ErrOpenState) {
+		t.Fatalf("got %v before the timeout, want ErrOpenState", err)
+	}
+	clk.Advance(2 * time.Second)
+	if err := roundTrip(); err != nil {
+		t.Fatalf("half-open probe failed: %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("transport called %d times, want 3", n)
+	}
+
+	info := breakerInfo(t, cfg.Name)
+	if info.State != "closed" || !info.LastTransition.Equal(clk.Now()) {
+		t.Fatalf("got %+v, want closed at %v", info, clk.Now())
+	}
+}
+
+func TestCircuitBreakerIntervalFollowsClock(t *testing.T) {
+	clk := clocktest.NewFake(time.Time{})
+	cfg := DefaultBreakerConfig(t.Name())
+	cfg.MinRequests = 2
+	cfg.Interval = 10 * time.Second
+	cfg.Clock = clk
+
+	var calls atomic.Int32
+	rt := NewCircuitBreakerMiddlewareWithConfig(cfg)(respondWith(&calls, http.StatusServiceUnavailable))
+	req := httptest.NewRequest(http.MethodGet, "http://catalog/", nil)
+
+	_, _ = rt.RoundTrip(req)
+	clk.Advance(11 * time.Second)
+	// The first failure was cleared with the interval, so this one alone doesn't trip the breaker.
+	_, _ = rt.RoundTrip(req)
+	if info := breakerInfo(t, cfg.Name); info.State != "closed" || info.Counts.Requests != 1 {
+		t.Fatalf("got %+v, want closed with 1 request", info)
+	}
+}
+
+func TestCircuitBreakerCountsFailuresAcrossRequests(t *testing.T) {
+	cfg := DefaultBreakerConfig(t.Name())
+
+	// A breaker built per request never reached MinRequests, so it never tripped.
+	var calls atomic.Int32
+	rt := NewCircuitBreakerMiddlewareWithConfig(cfg)(respondWith(&calls, http.StatusServiceUnavailable))
+	for range cfg.MinRequests {
+		_, _ = rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://catalog/", nil))
+	}
+
+	if _, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://catalog/", nil)); !errors.Is(err, gobreaker.ErrOpenState) {
+		t.Fatalf("got %v, want ErrOpenState", err)
+	}
+	if n := calls.Load(); n != int32(cfg.MinRequests) {
+		t.Fatalf("transport called %d times, want %d", n, cfg.MinRequests)
+	}
+}
+
+func breakerInfo(t *testing.T, name string) BreakerInfo {
+	t.Helper()
+
+	for _, info := range Breakers() {
+		if info.Name == name {
+			return info
+		}
+	}
+	t.Fatalf("breaker %q not registered", name)
+	return BreakerInfo{}
+}
+
+func TestCircuitBreakersSharingANameShareOneBreaker(t *testing.T) {
+	cfg := DefaultBreakerConfig(t.Name())
+	cfg.MinRequests = 1
+
+	var failingCalls, otherCalls atomic.Int32
+	failing := NewCircuitBreakerMiddlewareWithConfig(cfg)(respondWith(&failingCalls, http.StatusServiceUnavailable))
+	req := httptest.NewRequest(http.MethodGet, "http://catalog/", nil)
+	_, _ = failing.RoundTrip(req)
+
+	// Clients built per request reuse the breaker of their name instead of registering one each.
+	for range 10 {
+		other := NewCircuitBreakerMiddlewareWithConfig(cfg)(respondWith(&otherCalls, http.StatusOK))
+		if _, err := other.RoundTrip(req); !errors.Is(err, gobreaker.ErrOpenState) {
+			t.Fatalf("got %v, want the shared breaker open", err)
+		}
+	}
+	if n := otherCalls.Load(); n != 0 {
+		t.Fatalf("downstream called %d times through an open breaker", n)
+	}
+
+	var listed int
+	for _, info := range Breakers() {
+		if info.Name == cfg.Name {
+			listed++
+		}
+	}
+	if listed != 1 {
+		t.Fatalf("breaker listed %d times, want 1", listed)
+	}
+}
+
+func TestCircuitBreakerForcedStateIsListed(t *testing.T) {
+	cfg := DefaultBreakerConfig(t.Name())
+	var calls atomic.Int32
+	rt := NewCircuitBreakerMiddlewareWithConfig(cfg)(respondWith(&calls, http.StatusOK))
+
+	if err := settings.Default.Set(settings.BreakerForcePrefix+cfg.Name, "open", "test"); err != nil {
+		t.Fatal(err)
+	}
+	defer settings.Default.Delete(settings.BreakerForcePrefix+cfg.Name, "test")
+
+	if _, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://catalog/", nil)); !errors.Is(err, ErrBreakerForcedOpen) {
+		t.Fatalf("got %v, want ErrBreakerForcedOpen", err)
+	}
+	if info := breakerInfo(t, cfg.Name); info.Forced != "open" {
+		t.Fatalf("got %+v, want forced open", info)
+	}
+}
@@ -0,0 +1,47 @@
+package httpclient
+
+import (
+	"net/http"
+	"strings"
+)
+
+// When applies mw only to the requests matching pred; other requests skip it.
+//
+// Usage:
+//
+//	httpclient.When(func(req *http.Request) bool { return req.Method == http.MethodGet }, httpclient.NewCacheMiddleware(cfg))
+func When(pred func(req *http.Request) bool, mw RoundTripperMiddleware) RoundTripperMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		wrapped := mw(next)
+
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if pred(req) {
+				return wrapped.RoundTrip(req)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// ForHost applies mw only to requests sent to host (case-insensitive, port ignored).
+//
+// Usage:
+//
+//	httpclient.ForHost("api.example.com", httpclient.NewHeaderMiddleware(map[string]string{"Authorization": token}))
+func ForHost(host string, mw RoundTripperMiddleware) RoundTripperMiddleware {
+	return When(func(req *http.Request) bool {
+		return strings.EqualFold(req.URL.Hostname(), host)
+	}, mw)
+}
+
+// ForMethods applies mw only to requests using one of the given methods.
+func ForMethods(methods []string, mw RoundTripperMiddleware) RoundTripperMiddleware {
+	return When(func(req *http.Request) bool {
+		for _, method := range methods {
+			if strings.EqualFold(req.Method, method) {
+				return true
+			}
+		}
+		return false
+	}, mw)
+}
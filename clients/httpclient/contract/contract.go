@@ -0,0 +1,396 @@
+// Package contract records the interactions of an httpclient with its providers as Pact-style contract files,
+// replays them as a mock transport, and matches provider responses against them, enabling consumer-driven
+// contract tests between services using this package.
+package contract
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/devluispereira/go-package/clients/httpclient"
+)
+
+// ErrNoInteraction is returned by the Replay middleware when no recorded interaction matches a request.
+var ErrNoInteraction = errors.New("no recorded interaction matches the request")
+
+// Pact is a contract between a consumer and a provider, in the Pact specification v2 format.
+type Pact struct {
+	Consumer     Participant   `json:"consumer"`
+	Provider     Participant   `json:"provider"`
+	Interactions []Interaction `json:"interactions"`
+	Metadata     Metadata      `json:"metadata"`
+}
+
+// Participant names a side of the contract.
+type Participant struct {
+	Name string `json:"name"`
+}
+
+// Metadata describes the Pact specification version of the file.
+type Metadata struct {
+	PactSpecification struct {
+		Version string `json:"version"`
+	} `json:"pactSpecification"`
+}
+
+// Interaction is a request/response pair expected by the consumer.
+type Interaction struct {
+	Description   string   `json:"description"`
+	ProviderState string   `json:"providerState,omitempty"`
+	Request       Request  `json:"request"`
+	Response      Response `json:"response"`
+}
+
+// Request is the request side of an interaction.
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Query   string            `json:"query,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    any               `json:"body,omitempty"`
+}
+
+// Response is the response side of an interaction. Body is matched as a subset: the provider may return
+// additional fields.
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    any               `json:"body,omitempty"`
+}
+
+type descriptionKeyType struct{}
+type providerStateKeyType struct{}
+
+// WithDescription sets the description of the interactions recorded with ctx. Defaults to "<METHOD> <path>".
+func WithDescription(ctx context.Context, description string) context.Context {
+	return context.WithValue(ctx, descriptionKeyType{}, description)
+}
+
+// WithProviderState sets the provider state of the interactions recorded with ctx, e.g. "product 1 exists".
+func WithProviderState(ctx context.Context, state string) context.Context {
+	return context.WithValue(ctx, providerStateKeyType{}, state)
+}
+
+// Recorder records the interactions of an httpclient with a provider.
+type Recorder struct {
+	consumer string
+	provider string
+	headers  []string
+
+	mu           sync.Mutex
+	interactions map[string]Interaction
+}
+
+// NewRecorder creates a recorder for the contract between consumer and provider.
+//
+// Parameters:
+//
+//	consumer: The name of the service making the calls.
+//	provider: The name of the downstream service.
+//	headers: Request headers that are part of the contract (e.g. "x-tenant-id"). Other headers are not recorded.
+//
+// Usage:
+//
+//	recorder := contract.NewRecorder("catalog-bff", "catalog", "x-tenant-id")
+//	client := httpclient.NewHTTPClient(catalogURL, 2*time.Second, recorder.Middleware())
+//	// ... exercise the client against a real or staging provider
+//	err := recorder.WriteFile("pacts")
+func NewRecorder(consumer, provider string, headers ...string) *Recorder {
+	return &Recorder{
+		consumer:     consumer,
+		provider:     provider,
+		headers:      headers,
+		interactions: make(map[string]Interaction),
+	}
+}
+
+// Middleware returns an httpclient middleware recording each request and response. Interactions with the same
+// method, path, query and provider state are recorded once, keeping the last response.
+func (r *Recorder) Middleware() httpclient.RoundTripperMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			requestBody, err := readRequestBody(req)
+			if err != nil {
+				return nil, err
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return resp, err
+			}
+
+			responseBody, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read response body: %w", err)
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+			r.record(req, requestBody, resp, responseBody)
+			return resp, nil
+		})
+	}
+}
+
+func (r *Recorder) record(req *http.Request, requestBody []byte, resp *http.Response, responseBody []byte) {
+	interaction := Interaction{
+		Description: req.Method + " " + req.URL.Path,
+		Request: Request{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  req.URL.RawQuery,
+			Body:   decodeBody(requestBody),
+		},
+		Response: Response{
+			Status: resp.StatusCode,
+			Body:   decodeBody(responseBody),
+		},
+	}
+
+	if description, ok := req.Context().Value(descriptionKeyType{}).(string); ok {
+		interaction.Description = description
+	}
+	if state, ok := req.Context().Value(providerStateKeyType{}).(string); ok {
+		interaction.ProviderState = state
+	}
+
+	for _, name := range r.headers {
+		if value := req.Header.Get(name); value != "" {
+			if interaction.Request.Headers == nil {
+				interaction.Request.Headers = make(map[string]string)
+			}
+			interaction.Request.Headers[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		interaction.Response.Headers = map[string]string{"Content-Type": contentType}
+	}
+
+	key := strings.Join([]string{interaction.ProviderState, req.Method, req.URL.Path, req.URL.RawQuery}, " ")
+
+	r.mu.Lock()
+	r.interactions[key] = interaction
+	r.mu.Unlock()
+}
+
+// Pact returns the contract recorded so far, with interactions sorted by description.
+func (r *Recorder) Pact() *Pact {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pact := &Pact{
+		Consumer:     Participant{Name: r.consumer},
+		Provider:     Participant{Name: r.provider},
+		Interactions: make([]Interaction, 0, len(r.interactions)),
+	}
+	pact.Metadata.PactSpecification.Version = "2.0.0"
+
+	for _, interaction := range r.interactions {
+		pact.Interactions = append(pact.Interactions, interaction)
+	}
+
+	sort.Slice(pact.Interactions, func(i, j int) bool {
+		a, b := pact.Interactions[i], pact.Interactions[j]
+		if a.Description != b.Description {
+			return a.Description < b.Description
+		}
+		return a.ProviderState < b.ProviderState
+	})
+
+	return pact
+}
+
+// WriteFile writes the recorded contract to dir as "<consumer>-<provider>.json", creating dir when needed.
+// It returns the path of the written file.
+func (r *Recorder) WriteFile(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create pact directory: %w", err)
+	}
+
+	raw, err := json.MarshalIndent(r.Pact(), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal pact: %w", err)
+	}
+
+	path := filepath.Join(dir, r.consumer+"-"+r.provider+".json")
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write pact: %w", err)
+	}
+
+	return path, nil
+}
+
+// Load reads a contract file written by Recorder.WriteFile (or any Pact v2 file).
+func Load(path string) (*Pact, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pact: %w", err)
+	}
+
+	var pact Pact
+	if err := json.Unmarshal(raw, &pact); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pact: %w", err)
+	}
+
+	return &pact, nil
+}
+
+// Replay returns an httpclient middleware answering requests from the recorded interactions of pact,
+// without calling the provider. It is the mock side of the contract, for consumer tests.
+//
+// Behavior:
+//   - A request matches an interaction with the same method, path and query, whose recorded request
+//     headers are present with the same values. When several match, the first one wins.
+//   - Unmatched requests fail with ErrNoInteraction.
+//
+// Usage:
+//
+//	pact, _ := contract.Load("pacts/catalog-bff-catalog.json")
+//	client := httpclient.NewHTTPClient("http://catalog", time.Second, contract.Replay(pact))
+func Replay(pact *Pact) httpclient.RoundTripperMiddleware {
+	return func(_ http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			for _, interaction := range pact.Interactions {
+				if !interaction.Request.matches(req) {
+					continue
+				}
+
+				return interaction.Response.httpResponse(req)
+			}
+
+			return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL.RequestURI())
+		})
+	}
+}
+
+func (r Request) matches(req *http.Request) bool {
+	if !strings.EqualFold(r.Method, req.Method) || r.Path != req.URL.Path || r.Query != req.URL.RawQuery {
+		return false
+	}
+
+	for name, value := range r.Headers {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r Response) httpResponse(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if r.Body != nil {
+		if raw, ok := r.Body.(string); ok {
+			body = []byte(raw)
+		} else {
+			encoded, err := json.Marshal(r.Body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal recorded body: %w", err)
+			}
+			body = encoded
+		}
+	}
+
+	header := make(http.Header)
+	for name, value := range r.Headers {
+		header.Set(name, value)
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(r.Status),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Match reports whether actual satisfies expected, following the Pact matching rules for bodies:
+// objects match when every expected key matches (extra keys are allowed), arrays must have the same length
+// and match item by item, and other values must be equal. The returned error describes the first mismatch.
+func Match(expected, actual any) error {
+	return match("$", expected, actual)
+}
+
+func match(path string, expected, actual any) error {
+	switch want := expected.(type) {
+	case map[string]any:
+		got, ok := actual.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %v", path, actual)
+		}
+
+		for key, value := range want {
+			if err := match(path+"."+key, value, got[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case []any:
+		got, ok := actual.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an array, got %v", path, actual)
+		}
+		if len(got) != len(want) {
+			return fmt.Errorf("%s: expected %d items, got %d", path, len(want), len(got))
+		}
+
+		for i := range want {
+			if err := match(fmt.Sprintf("%s[%d]", path, i), want[i], got[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		if expected != actual {
+			return fmt.Errorf("%s: expected %v, got %v", path, expected, actual)
+		}
+		return nil
+	}
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// decodeBody decodes a JSON body, falling back to the raw string for other content.
+func decodeBody(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var decoded any
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return string(body)
+	}
+
+	return decoded
+}
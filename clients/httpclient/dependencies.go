@@ -0,0 +1,299 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/observability"
+)
+
+const dependencyServicesKey = "deps:services"
+
+// IDependencyRedisClient defines the Redis operations used to store the dependency report.
+// It is satisfied by redisclient.RedisClient.
+type IDependencyRedisClient interface {
+	HIncrBy(ctx context.Context, key, field string, incr int64) error
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	SAdd(ctx context.Context, key string, members ...any) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+}
+
+// Dependency is an edge of the dependency report: calls from a service to a downstream route.
+type Dependency struct {
+	Method        string  `json:"method"`
+	Host          string  `json:"host"`
+	Route         string  `json:"route"`
+	Calls         int64   `json:"calls"`
+	Errors        int64   `json:"errors"`
+	AvgDurationMs float64 `json:"avg_duration_ms"`
+}
+
+// DependencyGraph maps each service to the downstream routes it calls.
+type DependencyGraph map[string][]Dependency
+
+type dependencyStats struct {
+	calls      int64
+	errors     int64
+	durationMs int64
+}
+
+// DependencyRecorder records which hosts and routes a service calls, with counts and latencies,
+// and periodically flushes the aggregated counters to Redis, where every service of the platform reports.
+type DependencyRecorder struct {
+	client   IDependencyRedisClient
+	service  string
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*dependencyStats
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewDependencyRecorder creates a recorder for service, flushing to Redis every interval (default 30s).
+//
+// Usage:
+//
+//	deps := httpclient.NewDependencyRecorder(redis, "catalog-bff", 30*time.Second)
+//	srv.OnStart(deps.Start)
+//	srv.OnStop(deps.Stop)
+//	client := httpclient.NewHTTPClient(baseURL, 5*time.Second, deps.Middleware())
+func NewDependencyRecorder(client IDependencyRedisClient, service string, interval time.Duration) *DependencyRecorder {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	return &DependencyRecorder{
+		client:   client,
+		service:  service,
+		interval: interval,
+		pending:  make(map[string]*dependencyStats),
+	}
+}
+
+// Middleware returns an HTTP middleware recording every outgoing request. Route paths are normalized,
+// replacing numeric, UUID and hash-like segments with ":id" to keep the report bounded.
+func (d *DependencyRecorder) Middleware() RoundTripperMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+
+			failed := err != nil || resp.StatusCode >= 500
+			d.record(req.Method+" "+req.URL.Host+" "+observability.TemplatePath(req.URL.Path), time.Since(start), failed)
+
+			return resp, err
+		})
+	}
+}
+
+func (d *DependencyRecorder) record(field string, duration time.Duration, failed bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats, ok := d.pending[field]
+	if !ok {
+		stats = &dependencyStats{}
+		d.pending[field] = stats
+	}
+
+	stats.calls++
+	stats.durationMs += duration.Milliseconds()
+	if failed {
+		stats.errors++
+	}
+}
+
+// Start starts the periodic flush. Its signature matches server.Hook.
+func (d *DependencyRecorder) Start(_ context.Context) error {
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := d.Flush(context.Background()); err != nil {
+					logger.Error().Err(err).Msg("dependencies:flush failed")
+				}
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops the periodic flush and flushes the pending counters. Its signature matches server.Hook.
+func (d *DependencyRecorder) Stop(ctx context.Context) error {
+	if d.stop != nil {
+		close(d.stop)
+		<-d.done
+	}
+
+	return d.Flush(ctx)
+}
+
+// Flush writes the pending counters to Redis. On a Redis error, the counters not yet written are merged back into
+// the pending ones, so the next flush retries them without counting the written ones twice.
+func (d *DependencyRecorder) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	pending := d.pending
+	d.pending = make(map[string]*dependencyStats)
+	d.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if err := d.client.SAdd(ctx, dependencyServicesKey, d.service); err != nil {
+		d.requeue(pending)
+		return fmt.Errorf("failed to register service: %w", err)
+	}
+
+	key := dependencyKey(d.service)
+	for field, stats := range pending {
+		for suffix, value := range map[string]*int64{"calls": &stats.calls, "errors": &stats.errors, "duration_ms": &stats.durationMs} {
+			if *value == 0 {
+				continue
+			}
+			if err := d.client.HIncrBy(ctx, key, field+"|"+suffix, *value); err != nil {
+				d.requeue(pending)
+				return fmt.Errorf("failed to record dependency: %w", err)
+			}
+			*value = 0
+		}
+	}
+
+	return nil
+}
+
+// requeue adds the unwritten counters of a failed flush to the ones recorded since.
+func (d *DependencyRecorder) requeue(unwritten map[string]*dependencyStats) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for field, stats := range unwritten {
+		if stats.calls == 0 && stats.errors == 0 && stats.durationMs == 0 {
+			continue
+		}
+
+		current, ok := d.pending[field]
+		if !ok {
+			d.pending[field] = stats
+			continue
+		}
+		current.calls += stats.calls
+		current.errors += stats.errors
+		current.durationMs += stats.durationMs
+	}
+}
+
+// ReadDependencyGraph reads the dependency report of every service from Redis.
+func ReadDependencyGraph(ctx context.Context, client IDependencyRedisClient) (DependencyGraph, error) {
+	services, err := client.SMembers(ctx, dependencyServicesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read services: %w", err)
+	}
+
+	graph := make(DependencyGraph, len(services))
+	for _, service := range services {
+		fields, err := client.HGetAll(ctx, dependencyKey(service))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dependencies of %s: %w", service, err)
+		}
+
+		edges := make(map[string]*Dependency)
+		durations := make(map[string]int64)
+
+		for field, raw := range fields {
+			edge, suffix, _ := strings.Cut(field, "|")
+			value, _ := strconv.ParseInt(raw, 10, 64)
+
+			dep, ok := edges[edge]
+			if !ok {
+				parts := strings.SplitN(edge, " ", 3)
+				if len(parts) != 3 {
+					continue
+				}
+				dep = &Dependency{Method: parts[0], Host: parts[1], Route: parts[2]}
+				edges[edge] = dep
+			}
+
+			switch suffix {
+			case "calls":
+				dep.Calls = value
+			case "errors":
+				dep.Errors = value
+			case "duration_ms":
+				durations[edge] = value
+			}
+		}
+
+		deps := make([]Dependency, 0, len(edges))
+		for edge, dep := range edges {
+			if dep.Calls > 0 {
+				dep.AvgDurationMs = float64(durations[edge]) / float64(dep.Calls)
+			}
+			deps = append(deps, *dep)
+		}
+
+		sort.Slice(deps, func(i, j int) bool {
+			if deps[i].Host != deps[j].Host {
+				return deps[i].Host < deps[j].Host
+			}
+			return deps[i].Method+deps[i].Route < deps[j].Method+deps[j].Route
+		})
+
+		graph[service] = deps
+	}
+
+	return graph, nil
+}
+
+// DOT renders the graph in Graphviz DOT format, one edge per service and downstream host.
+func (g DependencyGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+
+	services := make([]string, 0, len(g))
+	for service := range g {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	for _, service := range services {
+		calls := make(map[string]int64)
+		var hosts []string
+		for _, dep := range g[service] {
+			if _, ok := calls[dep.Host]; !ok {
+				hosts = append(hosts, dep.Host)
+			}
+			calls[dep.Host] += dep.Calls
+		}
+
+		for _, host := range hosts {
+			fmt.Fprintf(&b, "  %q -> %q [label=\"%d\"];\n", service, host, calls[host])
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dependencyKey(service string) string {
+	return "deps:service:" + service
+}
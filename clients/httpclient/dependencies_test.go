@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyDependencyRedis is an in-memory IDependencyRedisClient whose HIncrBy fails after failAfter calls.
+type flakyDependencyRedis struct {
+	mu        sync.Mutex
+	hashes    map[string]map[string]int64
+	services  map[string]bool
+	failAfter int
+}
+
+func newFlakyDependencyRedis(failAfter int) *flakyDependencyRedis {
+	return &flakyDependencyRedis{hashes: map[string]map[string]int64{}, services: map[string]bool{}, failAfter: failAfter}
+}
+
+func (r *flakyDependencyRedis) HIncrBy(_ context.Context, key, field string, incr int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.failAfter == 0 {
+		return errors.New("connection refused")
+	}
+	r.failAfter--
+
+	if r.hashes[key] == nil {
+		r.hashes[key] = map[string]int64{}
+	}
+	r.hashes[key][field] += incr
+	return nil
+}
+
+func (r *flakyDependencyRedis) HGetAll(_ context.Context, key string) (map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fields := make(map[string]string, len(r.hashes[key]))
+	for field, value := range r.hashes[key] {
+		fields[field] = strconv.FormatInt(value, 10)
+	}
+	return fields, nil
+}
+
+func (r *flakyDependencyRedis) SAdd(_ context.Context, _ string, members ...any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, member := range members {
+		r.services[member.(string)] = true
+	}
+	return nil
+}
+
+func (r *flakyDependencyRedis) SMembers(_ context.Context, _ string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	services := make([]string, 0, len(r.services))
+	for service := range r.services {
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+func TestDependencyRecorderFlushRequeuesUnwrittenCounters(t *testing.T) {
+	redis := newFlakyDependencyRedis(2)
+	deps := NewDependencyRecorder(redis, "bff", time.Minute)
+
+	for range 3 {
+		deps.record("GET catalog /products/:id", 10*time.Millisecond, true)
+	}
+	deps.record("POST cart /items", 20*time.Millisecond, false)
+
+	if err := deps.Flush(context.Background()); err == nil {
+		t.Fatal("Flush succeeded, want the Redis error")
+	}
+
+	// Recorded while Redis was down.
+	deps.record("GET catalog /products/:id", 10*time.Millisecond, false)
+
+	redis.failAfter = -1
+	if err := deps.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	graph, err := ReadDependencyGraph(context.Background(), redis)
+	if err != nil {
+		t.Fatalf("ReadDependencyGraph: %v", err)
+	}
+
+	want := map[string]Dependency{
+		"/products/:id": {Method: "GET", Host: "catalog", Route: "/products/:id", Calls: 4, Errors: 3, AvgDurationMs: 10},
+		"/items":        {Method: "POST", Host: "cart", Route: "/items", Calls: 1, AvgDurationMs: 20},
+	}
+	if len(graph["bff"]) != len(want) {
+		t.Fatalf("got %+v, want %d edges", graph["bff"], len(want))
+	}
+	for _, dep := range graph["bff"] {
+		if dep != want[dep.Route] {
+			t.Errorf("got %+v, want %+v", dep, want[dep.Route])
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Envelope is the standard response body shape written by server.OK, server.Created and the server.Envelope
+// transform: the payload under data, metadata under meta and partial failures under errors.
+type Envelope struct {
+	Data   json.RawMessage `json:"data"`
+	Meta   *ResponseMeta   `json:"meta,omitempty"`
+	Errors ResponseErrors  `json:"errors,omitempty"`
+}
+
+// ResponseMeta is the metadata of an Envelope.
+type ResponseMeta struct {
+	RequestID  string      `json:"request_id,omitempty"`
+	DurationMs int64       `json:"duration_ms,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination describes the page of a list response: Page, PerPage and Total for offset pagination, NextCursor and
+// PrevCursor for cursor pagination.
+type Pagination struct {
+	Page       int    `json:"page,omitempty"`
+	PerPage    int    `json:"per_page,omitempty"`
+	Total      int64  `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// ResponseError is an error of an Envelope, in the problem+json shape of the server package.
+type ResponseError struct {
+	Type      string `json:"type,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Status    int    `json:"status,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	Code      string `json:"code,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ResponseErrors are the errors of an Envelope. Decode returns them when the envelope carries no data.
+type ResponseErrors []ResponseError
+
+func (e ResponseErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, respErr := range e {
+		messages[i] = respErr.Code
+		if respErr.Detail != "" {
+			messages[i] += ": " + respErr.Detail
+		}
+	}
+	return "response errors: " + strings.Join(messages, "; ")
+}
+
+// envelopeFields are the only top-level fields of an Envelope.
+var envelopeFields = map[string]bool{"data": true, "meta": true, "errors": true}
+
+// Envelope returns the body as an Envelope, reporting false when it is not one: a JSON object with a data or errors
+// field and no fields besides data, meta and errors.
+func (r *HTTPResponse) Envelope() (*Envelope, bool) {
+	fields, ok := r.bodyFields()
+	if !ok || (!fields["data"] && !fields["errors"]) {
+		return nil, false
+	}
+	for field := range fields {
+		if !envelopeFields[field] {
+			return nil, false
+		}
+	}
+
+	raw, err := r.rawBody()
+	if err != nil {
+		return nil, false
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, false
+	}
+	return &envelope, true
+}
+
+// Meta returns the metadata of an Envelope body, or nil when the body is not an Envelope or has no meta.
+func (r *HTTPResponse) Meta() *ResponseMeta {
+	envelope, ok := r.Envelope()
+	if !ok {
+		return nil
+	}
+	return envelope.Meta
+}
+
+// Decode unmarshals the response body into v, unwrapping it when it is an Envelope.
+//
+// Behavior:
+//   - Envelope bodies decode their data field; other bodies decode as a whole, so callers work with services that
+//     adopted the envelope and services that did not.
+//   - An Envelope with errors and no data returns its ResponseErrors.
+//
+// Usage:
+//
+//	resp, err := client.Get(ctx, "/products?page=2")
+//	var products []Product
+//	if err := resp.Decode(&products); err != nil { ... }
+//	if meta := resp.Meta(); meta != nil && meta.Pagination != nil && meta.Pagination.HasMore { ... }
+func (r *HTTPResponse) Decode(v any) error {
+	if envelope, ok := r.Envelope(); ok {
+		if len(envelope.Errors) > 0 && (len(envelope.Data) == 0 || string(envelope.Data) == "null") {
+			return envelope.Errors
+		}
+		if err := json.Unmarshal(envelope.Data, v); err != nil {
+			return fmt.Errorf("failed to decode response data: %w", err)
+		}
+		return nil
+	}
+
+	raw, err := r.rawBody()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("failed to decode response body: %w", err)
+	}
+	return nil
+}
+
+// bodyFields returns the top-level fields of an object body. Typed calls leave Body nil, so their fields are read
+// from the raw body.
+func (r *HTTPResponse) bodyFields() (map[string]bool, bool) {
+	fields := make(map[string]bool)
+	if body, ok := r.Body.(map[string]any); ok {
+		for field := range body {
+			fields[field] = true
+		}
+		return fields, true
+	}
+	if r.Body != nil || len(r.raw) == 0 {
+		return nil, false
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(r.raw, &body); err != nil {
+		return nil, false
+	}
+	for field := range body {
+		fields[field] = true
+	}
+	return fields, true
+}
+
+// rawBody returns the body as received, or Body encoded again for responses built without it.
+func (r *HTTPResponse) rawBody() ([]byte, error) {
+	if r.raw != nil {
+		return r.raw, nil
+	}
+
+	raw, err := json.Marshal(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response body: %w", err)
+	}
+	return raw, nil
+}
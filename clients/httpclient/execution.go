@@ -0,0 +1,145 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// ExecutionInfo describes how a call was executed, to explain why it was slow or failed.
+type ExecutionInfo struct {
+	// Attempts is the number of attempts, 1 when the call was not retried.
+	Attempts int `json:"attempts"`
+	// RetryDelay is the total time spent waiting between attempts.
+	RetryDelay time.Duration `json:"retry_delay,omitempty"`
+	// BreakerState is the state of the circuit breaker when the last attempt started: "closed", "half-open",
+	// "open", "forced-open" or "forced-closed". Empty without the circuit breaker middleware.
+	BreakerState string `json:"breaker_state,omitempty"`
+	// Cache is the X-Cache header of the response (HIT, MISS, REVALIDATED), when the cache middleware
+	// is installed.
+	Cache string `json:"cache,omitempty"`
+	// UpstreamAddr is the remote address of the connection of the last attempt, e.g. "10.0.3.17:443". Empty
+	// when no connection was used, e.g. on cache hits.
+	UpstreamAddr string `json:"upstream_addr,omitempty"`
+	// Duration is the time of the whole call, including retries.
+	Duration time.Duration `json:"duration"`
+}
+
+// ExecutionRecorderKeyType is the context key of the ExecutionRecorder set by WithExecutionRecorder.
+type ExecutionRecorderKeyType struct{}
+
+// executionKeyType is the context key of the execution of the call in progress.
+type executionKeyType struct{}
+
+// ExecutionRecorder holds the ExecutionInfo of the last call made with a context.
+type ExecutionRecorder struct {
+	mu   sync.Mutex
+	info ExecutionInfo
+}
+
+// WithExecutionRecorder returns a context whose HTTPClient calls record their ExecutionInfo in the returned
+// ExecutionRecorder. It is the way to get the ExecutionInfo of failed calls; successful ones also return it in
+// HTTPResponse.Execution.
+//
+// Usage:
+//
+//	ctx, execution := httpclient.WithExecutionRecorder(c.UserContext())
+//	_, err := catalog.Get(ctx, "/products/42")
+//	if err != nil {
+//		info := execution.Info()
+//		log.Warn().Err(err).Int("attempts", info.Attempts).Str("breaker", info.BreakerState).Msg("catalog failed")
+//	}
+func WithExecutionRecorder(ctx context.Context) (context.Context, *ExecutionRecorder) {
+	recorder := &ExecutionRecorder{}
+	return context.WithValue(ctx, ExecutionRecorderKeyType{}, recorder), recorder
+}
+
+// Info returns the ExecutionInfo of the last call finished with the context of the recorder.
+func (r *ExecutionRecorder) Info() ExecutionInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.info
+}
+
+// execution collects the ExecutionInfo of a call while its middlewares run.
+type execution struct {
+	mu    sync.Mutex
+	info  ExecutionInfo
+	start time.Time
+	// responded stops recording connections once the attempt got its response, so requests cloned from it
+	// afterwards (e.g. shadow traffic) don't overwrite the upstream address.
+	responded bool
+}
+
+// withExecution returns a context collecting the ExecutionInfo of a call, with the connection trace hooks.
+func withExecution(ctx context.Context) (context.Context, *execution) {
+	e := &execution{start: time.Now()}
+	ctx = context.WithValue(ctx, executionKeyType{}, e)
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			if !e.responded && info.Conn != nil {
+				e.info.UpstreamAddr = info.Conn.RemoteAddr().String()
+			}
+		},
+		GotFirstResponseByte: func() {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			e.responded = true
+		},
+	})
+	return ctx, e
+}
+
+// executionFrom returns the execution of the call of ctx, nil outside HTTPClient calls.
+func executionFrom(ctx context.Context) *execution {
+	e, _ := ctx.Value(executionKeyType{}).(*execution)
+	return e
+}
+
+// attempt records the start of an attempt, after waiting delay.
+func (e *execution) attempt(n int, delay time.Duration) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.info.Attempts = n
+	e.info.RetryDelay += delay
+	e.responded = false
+}
+
+// breakerState records the state of the circuit breaker of the attempt.
+func (e *execution) breakerState(state string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.info.BreakerState = state
+}
+
+// finish completes the ExecutionInfo of the call with its response, publishing it to the ExecutionRecorder of ctx.
+func (e *execution) finish(ctx context.Context, resp *http.Response) ExecutionInfo {
+	e.mu.Lock()
+	e.responded = true
+	if e.info.Attempts == 0 {
+		e.info.Attempts = 1
+	}
+	if resp != nil {
+		e.info.Cache = resp.Header.Get("X-Cache")
+	}
+	e.info.Duration = time.Since(e.start)
+	info := e.info
+	e.mu.Unlock()
+
+	if recorder, ok := ctx.Value(ExecutionRecorderKeyType{}).(*ExecutionRecorder); ok {
+		recorder.mu.Lock()
+		recorder.info = info
+		recorder.mu.Unlock()
+	}
+	return info
+}
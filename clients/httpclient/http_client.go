@@ -6,20 +6,32 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/devluispereira/go-package/baggage"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 type HTTPClient struct {
-	client  *http.Client
-	baseURL string
-	headers map[string]string
+	client    *http.Client
+	baseURL   string
+	headers   map[string]string
+	transport http.RoundTripper
 }
 
 type HTTPResponse struct {
 	Body       any
 	StatusCode int
 	Headers    http.Header
+	// Execution tells how the call was executed: attempts, retry delay, breaker state, cache status and upstream
+	// address. See WithExecutionRecorder for failed calls.
+	Execution ExecutionInfo
+
+	// raw is the response body as received, decoded by Decode.
+	raw []byte
 }
 
 // NewHTTPClient creates a new HTTPClient instance.
@@ -37,26 +49,43 @@ type HTTPResponse struct {
 //     1. NewLoggingMiddleware;
 //     (Should be outermost to log all requests and responses, including cache hits and circuit breaker events)
 //
-//     2. NewHeaderMiddleware;
+//     2. NewTracingMiddleware and NewMetricsMiddleware;
+//     (Span and duration cover the whole call, including cache hits, retries and backoff)
+//
+//     3. NewHeaderMiddleware;
 //     (Sets custom headers before cache and circuit logic, ensuring cache keys and backend requests use the correct headers)
 //
-//     3. CacheMiddleware;
-//     (Checks/sets cache after headers are set, and before circuit breaker, for maximum cache efficiency)
+//     4. CacheMiddleware;
+//     (Checks/sets cache after headers are set, and before retry and circuit breaker, for maximum cache efficiency)
+//
+//     5. NewRetryMiddleware;
+//     (Retries only requests missing the cache, with each attempt going through the circuit breaker)
 //
-//     4. CircuitBreakerMiddleware.
-//     (Protects backend only for requests that reach it, after cache and header logic)
+//     6. CircuitBreakerMiddleware.
+//     (Protects backend only for requests that reach it, counting every attempt)
 //
 // Returns: Configured HTTP client.
 func NewHTTPClient(
 	baseUrl string,
 	timeout time.Duration,
 	middlewares ...RoundTripperMiddleware) *HTTPClient {
+	return NewHTTPClientWithTransport(baseUrl, timeout, http.DefaultTransport, middlewares...)
+}
+
+// NewHTTPClientWithTransport behaves like NewHTTPClient, sending the requests through transport instead of
+// http.DefaultTransport, e.g. to share a connection pool among clients.
+func NewHTTPClientWithTransport(
+	baseUrl string,
+	timeout time.Duration,
+	transport http.RoundTripper,
+	middlewares ...RoundTripperMiddleware) *HTTPClient {
 	return &HTTPClient{
 		client: &http.Client{
 			Timeout:   timeout,
-			Transport: configMiddlewares(middlewares),
+			Transport: configMiddlewares(newGuardedTransport(transport), middlewares),
 		},
-		baseURL: baseUrl,
+		baseURL:   baseUrl,
+		transport: transport,
 	}
 }
 
@@ -142,12 +171,22 @@ func (c *HTTPClient) Head(ctx context.Context, path string) (*HTTPResponse, erro
 }
 
 func (c *HTTPClient) doRequest(ctx context.Context, method, path string, body io.Reader) (*HTTPResponse, error) {
-	url := path
-	if !strings.HasPrefix(path, "http") {
-		url = strings.TrimSuffix(c.baseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err := json.Unmarshal(resp.raw, &resp.Body); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return resp, nil
+}
+
+// send sends a request and reads the response body, without decoding it.
+func (c *HTTPClient) send(ctx context.Context, method, path string, body io.Reader) (*HTTPResponse, error) {
+	ctx, exec := withExecution(ctx)
+
+	req, err := http.NewRequestWithContext(ctx, method, c.resolveURL(path), body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -158,36 +197,73 @@ func (c *HTTPClient) doRequest(ctx context.Context, method, path string, body io
 		req.Header.Set(k, value)
 	}
 
+	// The span of ctx, when there is one, replaces the forwarded traceparent, so downstream spans are its children.
+	forwardedBaggage := req.Header.Get(baggage.HeaderName)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	if header := mergeBaggage(ctx, forwardedBaggage); header != "" {
+		req.Header.Set(baggage.HeaderName, header)
+	}
+
 	for key, value := range c.headers {
 		req.Header.Set(key, value)
 	}
 
+	if deadline, ok := ctx.Deadline(); ok && req.Header.Get("X-Request-Timeout") == "" {
+		if remaining := time.Until(deadline).Milliseconds(); remaining > 0 {
+			req.Header.Set("X-Request-Timeout", strconv.FormatInt(remaining, 10))
+		}
+	}
+
 	if method == "POST" && req.Header.Get("Content-Type") == "" {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
+	start := time.Now()
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request execution failed: %w", err)
+		recordCall(ctx, req, start, nil, err)
+		exec.finish(ctx, nil)
+		return nil, fmt.Errorf("request execution failed: %w", ClassifyError(err))
 	}
 
 	defer resp.Body.Close()
-	var jsonBody any
 	bodyBytes, err := io.ReadAll(resp.Body)
+	recordCall(ctx, req, start, resp, err)
+	info := exec.finish(ctx, resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
-	if err := json.Unmarshal(bodyBytes, &jsonBody); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response body: %w", err)
-	}
 
 	return &HTTPResponse{
-		Body:       jsonBody,
 		StatusCode: resp.StatusCode,
 		Headers:    resp.Header,
+		Execution:  info,
+		raw:        bodyBytes,
 	}, nil
 }
 
+// mergeBaggage returns the baggage header sent downstream: the members of the forwarded header, replaced by those of
+// the baggage of ctx. An invalid forwarded header is dropped.
+func mergeBaggage(ctx context.Context, forwarded string) string {
+	merged, err := baggage.FromHeader(context.Background(), forwarded)
+	if err != nil {
+		return baggage.Header(ctx)
+	}
+	if merged, err = baggage.FromHeader(merged, baggage.Header(ctx)); err != nil {
+		return baggage.Header(ctx)
+	}
+	return baggage.Header(merged)
+}
+
+// resolveURL returns path itself when it is a full URL, or path joined to the base URL.
+func (c *HTTPClient) resolveURL(path string) string {
+	if strings.HasPrefix(path, "http") {
+		return path
+	}
+	return strings.TrimSuffix(c.baseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
 func getForwardedHeaders(ctx context.Context) map[string]string {
 	headers, _ := ctx.Value("forwardedHeaders").(map[string]string)
 	return headers
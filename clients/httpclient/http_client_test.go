@@ -0,0 +1,66 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devluispereira/go-package/baggage"
+	"github.com/devluispereira/go-package/slo"
+)
+
+func TestSendMergesForwardedBaggageOnce(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Values(baggage.HeaderName)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	ctx := context.WithValue(context.Background(), "forwardedHeaders", map[string]string{baggage.HeaderName: "tier=free,region=br"})
+	ctx, err := baggage.Set(ctx, "tier", "gold")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewHTTPClient(server.URL, 2*time.Second).Get(ctx, "/"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(received) != 1 {
+		t.Fatalf("got baggage headers %q, want one", received)
+	}
+
+	members := strings.Split(received[0], ",")
+	if len(members) != 2 || !strings.Contains(received[0], "tier=gold") || !strings.Contains(received[0], "region=br") {
+		t.Fatalf("got baggage %q, want tier=gold and region=br", received[0])
+	}
+}
+
+func TestMergeBaggageDropsInvalidForwardedHeader(t *testing.T) {
+	ctx, _ := baggage.Set(context.Background(), "tier", "gold")
+	if got := mergeBaggage(ctx, "not a baggage;;"); got != "tier=gold" {
+		t.Fatalf("got %q, want tier=gold", got)
+	}
+}
+
+func TestMetricsMiddlewareFeedsConfiguredSLOTracker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	tracker := slo.NewTracker()
+	if err := tracker.SetObjective(slo.Objective{Name: "catalog"}); err != nil {
+		t.Fatalf("SetObjective: %v", err)
+	}
+	client := NewHTTPClient(server.URL, 2*time.Second, NewMetricsMiddlewareWithConfig("catalog", MetricsConfig{SLO: tracker}))
+	_, _ = client.Get(context.Background(), "/")
+
+	summaries := tracker.Summaries()
+	if len(summaries) != 1 || summaries[0].Requests != 1 || summaries[0].Errors != 1 {
+		t.Fatalf("got %+v, want one failed request", summaries)
+	}
+}
@@ -0,0 +1,222 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"unicode/utf8"
+)
+
+// maxSchemaViolations caps the violations reported for a document.
+const maxSchemaViolations = 10
+
+// jsonSchema is the subset of JSON Schema used to validate downstream responses: type, enum, const, properties,
+// required, additionalProperties, items, minItems, maxItems, minLength, maxLength, pattern, minimum, maximum,
+// allOf and anyOf. Other keywords are ignored.
+type jsonSchema struct {
+	Type                 schemaTypes            `json:"type"`
+	Enum                 []any                  `json:"enum"`
+	Const                *any                   `json:"const"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Pattern              string                 `json:"pattern"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	AllOf                []*jsonSchema          `json:"allOf"`
+	AnyOf                []*jsonSchema          `json:"anyOf"`
+
+	// never is set by the boolean schema false, which rejects every value.
+	never   bool
+	pattern *regexp.Regexp
+}
+
+// schemaTypes accepts both "type": "string" and "type": ["string", "null"].
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("invalid type: %s", data)
+	}
+	*t = multiple
+	return nil
+}
+
+func (s *jsonSchema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = jsonSchema{}
+		return nil
+	case "false":
+		*s = jsonSchema{never: true}
+		return nil
+	}
+
+	type plain jsonSchema
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = pattern
+	}
+
+	return nil
+}
+
+// parseJSONSchema parses a JSON Schema document.
+func parseJSONSchema(data []byte) (*jsonSchema, error) {
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("parse json schema: %w", err)
+	}
+	return &schema, nil
+}
+
+// validate returns the violations of value, decoded with encoding/json, against the schema.
+func (s *jsonSchema) validate(value any) []string {
+	var violations []string
+	s.check(value, "$", &violations)
+	return violations
+}
+
+func (s *jsonSchema) check(value any, path string, violations *[]string) {
+	if len(*violations) >= maxSchemaViolations {
+		return
+	}
+
+	report := func(format string, args ...any) {
+		if len(*violations) < maxSchemaViolations {
+			*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+		}
+	}
+
+	if s.never {
+		report("not allowed")
+		return
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return jsonTypeMatches(t, value) }) {
+		report("expected %v, got %s", []string(s.Type), jsonTypeOf(value))
+		return
+	}
+
+	if s.Enum != nil && !slices.ContainsFunc(s.Enum, func(v any) bool { return reflect.DeepEqual(v, value) }) {
+		report("value not in enum")
+	}
+	if s.Const != nil && !reflect.DeepEqual(*s.Const, value) {
+		report("value differs from const")
+	}
+
+	for _, sub := range s.AllOf {
+		sub.check(value, path, violations)
+	}
+
+	if len(s.AnyOf) > 0 && !slices.ContainsFunc(s.AnyOf, func(sub *jsonSchema) bool { return len(sub.validate(value)) == 0 }) {
+		report("matches none of anyOf")
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				report("missing required property %q", name)
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if sub, ok := s.Properties[name]; ok {
+				sub.check(v[name], path+"."+name, violations)
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.check(v[name], path+"."+name, violations)
+			}
+		}
+
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			report("expected at least %d items, got %d", *s.MinItems, len(v))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			report("expected at most %d items, got %d", *s.MaxItems, len(v))
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.check(item, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			report("expected at least %d characters, got %d", *s.MinLength, length)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			report("expected at most %d characters, got %d", *s.MaxLength, length)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			report("does not match pattern %q", s.Pattern)
+		}
+
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			report("expected >= %v, got %v", *s.Minimum, v)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			report("expected <= %v, got %v", *s.Maximum, v)
+		}
+	}
+}
+
+func jsonTypeMatches(name string, value any) bool {
+	if name == "integer" {
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	}
+	return name == jsonTypeOf(value)
+}
+
+func jsonTypeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -1,8 +1,16 @@
 package httpclient
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"sync"
 	"time"
+
+	"github.com/devluispereira/go-package/errorreport"
+	"github.com/devluispereira/go-package/logging"
+	"github.com/devluispereira/go-package/settings"
+	"github.com/rs/zerolog"
 )
 
 // NewLoggingMiddleware returns an HTTP middleware that logs all outgoing requests and responses.
@@ -13,14 +21,23 @@ import (
 // Returns:
 //   A function that wraps an http.RoundTripper and logs request and response details, including method, URL, status, duration, cache status, and errors.
 //   Logs at INFO level for successful requests and ERROR level for failed requests.
+//   The "disable:logging" settings (see settings.DisablePrefix) silence the successful requests at runtime;
+//   failures are still logged.
+//   Log lines carry the request-id, tenant and trace IDs of the request context (see logging.FromContext).
+//   A burst of failures (10 responses with status 5xx or transport errors within a minute) is reported once to the
+//   errorreport Reporter, with the context of the request that completed the burst.
 
 func NewLoggingMiddleware(name string) func(next http.RoundTripper) http.RoundTripper {
+	bursts := &burstDetector{}
+
 	return func(next http.RoundTripper) http.RoundTripper {
 		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
 			start := time.Now()
 			resp, err := next.RoundTrip(req)
 			duration := time.Since(start)
 
+			logger := requestLogger(req.Context())
+
 			if err != nil {
 				logger.Error().
 					Str("service", name).
@@ -30,6 +47,11 @@ func NewLoggingMiddleware(name string) func(next http.RoundTripper) http.RoundTr
 					Int64("duration_ms", duration.Milliseconds()).
 					Msg(err.Error())
 
+				bursts.record(req, name, 0, err)
+				return resp, err
+			}
+
+			if settings.Default.Disabled("logging", name) && resp.StatusCode < 500 {
 				return resp, err
 			}
 
@@ -42,7 +64,58 @@ func NewLoggingMiddleware(name string) func(next http.RoundTripper) http.RoundTr
 				Str("cache", resp.Header.Get("X-Cache")).
 				Msg(resp.Status)
 
+			if resp.StatusCode >= 500 {
+				bursts.record(req, name, resp.StatusCode, nil)
+			}
 			return resp, err
 		})
 	}
 }
+
+// requestLogger returns the request-scoped logger of ctx, identified as the http-client layer.
+func requestLogger(ctx context.Context) zerolog.Logger {
+	return *logging.FromContextFor(ctx, "http-client")
+}
+
+// Failures within burstWindow reaching burstThreshold make a burst.
+const (
+	burstThreshold = 10
+	burstWindow    = time.Minute
+)
+
+// burstDetector counts the failures of a client in fixed windows, reporting the window that reaches
+// burstThreshold.
+type burstDetector struct {
+	mu    sync.Mutex
+	start time.Time
+	count int
+}
+
+func (b *burstDetector) record(req *http.Request, name string, status int, err error) {
+	now := time.Now()
+
+	b.mu.Lock()
+	if now.Sub(b.start) >= burstWindow {
+		b.start = now
+		b.count = 0
+	}
+	b.count++
+	burst := b.count == burstThreshold
+	b.mu.Unlock()
+
+	if !burst {
+		return
+	}
+
+	cause := err
+	if cause == nil {
+		cause = fmt.Errorf("%s responded with status %d", req.URL.Host, status)
+	}
+
+	errorreport.Capture(req.Context(), errorreport.Event{
+		Err:     cause,
+		Message: fmt.Sprintf("%s: %d failed requests within %s", name, burstThreshold, burstWindow),
+		Tags:    map[string]string{"component": "http-client", "service": name, "host": req.URL.Host},
+		Extra:   map[string]any{"last_status": status, "last_url": req.URL.String(), "last_method": req.Method},
+	})
+}
@@ -1,14 +1,7 @@
 package httpclient
 
 import (
-	"os"
-
-	"github.com/rs/zerolog"
+	"github.com/devluispereira/go-package/logging"
 )
 
-var logger zerolog.Logger
-
-func init() {
-	logger = zerolog.New(os.Stdout).
-		With().Str("layer", "http-client").Logger()
-}
+var logger = logging.New("http-client")
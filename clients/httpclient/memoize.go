@@ -0,0 +1,136 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+type MemoKeyType struct{}
+
+// memoStore holds the responses of a single incoming request.
+type memoStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoEntry
+}
+
+type memoEntry struct {
+	done   chan struct{}
+	status string
+	code   int
+	proto  string
+	header http.Header
+	body   []byte
+	err    error
+}
+
+// WithMemoization returns a context whose GET and HEAD requests are memoized by NewMemoizationMiddleware, and a
+// function that clears the memoized responses. Use one context per incoming request, and call clear when the
+// request ends (server.MemoizeMiddleware does both).
+func WithMemoization(ctx context.Context) (context.Context, func()) {
+	store := &memoStore{entries: make(map[string]*memoEntry)}
+
+	return context.WithValue(ctx, MemoKeyType{}, store), func() {
+		store.mu.Lock()
+		store.entries = make(map[string]*memoEntry)
+		store.mu.Unlock()
+	}
+}
+
+// NewMemoizationMiddleware makes a single call per URL within a request scope, so a handler that calls the same
+// downstream URL several times while building a response only reaches it once.
+//
+// Behavior:
+//   - Only GET and HEAD requests made with a context from WithMemoization are memoized; others pass through.
+//   - Concurrent calls for the same method and URL wait for the first one and share its response.
+//   - Each caller gets its own copy of the response, with the body fully read in memory.
+//   - Errors are shared with the calls waiting for them but not memoized, so a later call tries again.
+//   - Place it before the cache middleware, so memoized calls don't touch the cache either.
+//
+// Usage:
+//
+//	client := httpclient.NewHTTPClient(baseURL, 2*time.Second,
+//		httpclient.NewMemoizationMiddleware(),
+//		httpclient.NewCacheMiddleware(cacheCfg),
+//	)
+//	app.Use(server.MemoizeMiddleware())
+func NewMemoizationMiddleware() func(next http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			store, ok := req.Context().Value(MemoKeyType{}).(*memoStore)
+			if !ok || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+				return next.RoundTrip(req)
+			}
+
+			key := req.Method + " " + req.URL.String()
+
+			store.mu.Lock()
+			entry, found := store.entries[key]
+			if !found {
+				entry = &memoEntry{done: make(chan struct{})}
+				store.entries[key] = entry
+			}
+			store.mu.Unlock()
+
+			if found {
+				select {
+				case <-entry.done:
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				}
+				if entry.err != nil {
+					return nil, entry.err
+				}
+				return entry.response(req), nil
+			}
+
+			entry.fill(next.RoundTrip(req))
+			close(entry.done)
+
+			if entry.err != nil {
+				store.mu.Lock()
+				if store.entries[key] == entry {
+					delete(store.entries, key)
+				}
+				store.mu.Unlock()
+				return nil, entry.err
+			}
+
+			return entry.response(req), nil
+		})
+	}
+}
+
+func (e *memoEntry) fill(resp *http.Response, err error) {
+	if err != nil {
+		e.err = err
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		e.err = err
+		return
+	}
+
+	e.status = resp.Status
+	e.code = resp.StatusCode
+	e.proto = resp.Proto
+	e.header = resp.Header
+	e.body = body
+}
+
+func (e *memoEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        e.status,
+		StatusCode:    e.code,
+		Proto:         e.proto,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
@@ -16,9 +16,9 @@ func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 }
 
 // configMiddlewares composes a slice of RoundTripperMiddleware into a single http.RoundTripper chain.
-// The first middleware in the slice will be the outermost (executed first).
-func configMiddlewares(middlewares []RoundTripperMiddleware) http.RoundTripper {
-	composed := http.DefaultTransport
+// The first middleware in the slice will be the outermost (executed first); base is the innermost transport.
+func configMiddlewares(base http.RoundTripper, middlewares []RoundTripperMiddleware) http.RoundTripper {
+	composed := base
 
 	for i := len(middlewares) - 1; i >= 0; i-- {
 		composed = middlewares[i](composed)
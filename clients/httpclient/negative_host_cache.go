@@ -0,0 +1,129 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrHostUnavailable is wrapped by HostUnavailableError.
+var ErrHostUnavailable = errors.New("host recently unreachable")
+
+// HostUnavailableError is returned without sending the request when the host failed to resolve or connect
+// within the negative cache TTL.
+type HostUnavailableError struct {
+	Host string
+	// Until is when the host is tried again.
+	Until time.Time
+	// Cause is the classified error of the failed attempt, e.g. matching ErrDNS.
+	Cause error
+}
+
+func (e *HostUnavailableError) Error() string {
+	return fmt.Sprintf("%s: %s until %s: %s", e.Host, ErrHostUnavailable, e.Until.Format(time.RFC3339), e.Cause)
+}
+
+func (e *HostUnavailableError) Unwrap() []error {
+	return []error{ErrHostUnavailable, e.Cause}
+}
+
+// NegativeHostCacheConfig holds the configuration of the negative host cache. It can be loaded with the config
+// package.
+type NegativeHostCacheConfig struct {
+	// TTL is how long a host that failed to resolve or connect fails fast. Defaults to 5s.
+	TTL time.Duration `json:"ttl" env:"NEGATIVE_HOST_CACHE_TTL" default:"5s"`
+}
+
+// NewNegativeHostCacheMiddleware fails requests fast for hosts that recently failed DNS resolution or connection,
+// instead of spending the whole dial timeout on every request during a downstream DNS outage.
+//
+// Parameters:
+//
+//	cfg: How long failed hosts fail fast.
+//
+// Behavior:
+//   - A request failing with ErrDNS, ErrConnectTimeout or ErrConnectionRefused marks its host (host and port)
+//     as unavailable for cfg.TTL. Other errors and any response, whatever its status, don't.
+//   - Requests to an unavailable host are not sent and fail with a *HostUnavailableError, which matches
+//     ErrHostUnavailable and the category of the original failure with errors.Is. The retry middleware does not
+//     retry them.
+//   - Once the TTL expires the next request is sent; a new failure marks the host again.
+//   - The http.client.negative_host_cache.rejected counter reports fast failures, labelled by server.address.
+//
+// Usage:
+//
+//	client := httpclient.NewHTTPClient(baseURL, 2*time.Second,
+//		httpclient.NewRetryMiddleware(httpclient.DefaultRetryConfig()),
+//		httpclient.NewNegativeHostCacheMiddleware(httpclient.NegativeHostCacheConfig{TTL: 5 * time.Second}),
+//	)
+//
+//	_, err := client.Get(ctx, "/products")
+//	if errors.Is(err, httpclient.ErrHostUnavailable) { ... }
+func NewNegativeHostCacheMiddleware(cfg NegativeHostCacheConfig) func(next http.RoundTripper) http.RoundTripper {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 5 * time.Second
+	}
+
+	var (
+		mu     sync.Mutex
+		failed = map[string]*HostUnavailableError{}
+	)
+
+	rejected, _ := otel.Meter(instrumentationName).Int64Counter("http.client.negative_host_cache.rejected",
+		metric.WithDescription("Requests failed fast because their host recently failed to resolve or connect."))
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			host := req.URL.Host
+
+			mu.Lock()
+			entry, ok := failed[host]
+			if ok && !time.Now().Before(entry.Until) {
+				delete(failed, host)
+				ok = false
+			}
+			mu.Unlock()
+
+			if ok {
+				rejected.Add(req.Context(), 1, metric.WithAttributes(attribute.String("server.address", host)))
+				return nil, entry
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err == nil {
+				return resp, nil
+			}
+
+			classified := ClassifyError(err)
+			if !errors.Is(classified, ErrDNS) && !errors.Is(classified, ErrConnectTimeout) &&
+				!errors.Is(classified, ErrConnectionRefused) {
+				return resp, err
+			}
+
+			now := time.Now()
+			mu.Lock()
+			for h, e := range failed {
+				if !now.Before(e.Until) {
+					delete(failed, h)
+				}
+			}
+			failed[host] = &HostUnavailableError{Host: host, Until: now.Add(cfg.TTL), Cause: classified}
+			mu.Unlock()
+
+			log := requestLogger(req.Context())
+			log.Warn().
+				Err(err).
+				Str("host", host).
+				Dur("ttl", cfg.TTL).
+				Msg("host unreachable, failing fast")
+
+			return resp, err
+		})
+	}
+}
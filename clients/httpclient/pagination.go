@@ -0,0 +1,96 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrStopPaging can be returned by the callback of EachPage to stop paging without an error.
+var ErrStopPaging = errors.New("stop paging")
+
+// EachPage calls fn for each page of a cursor paginated list, starting at path, until the last page.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout.
+//   - path: Request path or full URL of the first page, e.g. "/products?limit=50".
+//   - fn: Called with each page; decode it with resp.Decode. Returning ErrStopPaging stops without an error, other
+//     errors stop and are returned.
+//
+// Behavior:
+//   - The next page is the rel="next" URL of the Link header, or path with the cursor query parameter set to the
+//     next_cursor of the Envelope meta (see server.Paginator).
+//   - Paging stops when the response has neither, or has_more is false.
+//   - Non-2xx responses are handed to fn and end the paging.
+//
+// Usage:
+//
+//	err := client.EachPage(ctx, "/products?limit=100", func(ctx context.Context, resp *httpclient.HTTPResponse) error {
+//		var products []Product
+//		if err := resp.Decode(&products); err != nil {
+//			return err
+//		}
+//		all = append(all, products...)
+//		return nil
+//	})
+func (c *HTTPClient) EachPage(ctx context.Context, path string, fn func(ctx context.Context, resp *HTTPResponse) error) error {
+	for path != "" {
+		resp, err := c.Get(ctx, path)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(ctx, resp); err != nil {
+			if errors.Is(err, ErrStopPaging) {
+				return nil
+			}
+			return err
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil
+		}
+		path = nextPage(path, resp)
+	}
+	return nil
+}
+
+// nextPage returns the path of the page after resp, or an empty string on the last page.
+func nextPage(path string, resp *HTTPResponse) string {
+	if next := linkRel(resp.Headers.Get("Link"), "next"); next != "" {
+		return next
+	}
+
+	meta := resp.Meta()
+	if meta == nil || meta.Pagination == nil || meta.Pagination.NextCursor == "" || !meta.Pagination.HasMore {
+		return ""
+	}
+
+	u, err := url.Parse(path)
+	if err != nil {
+		return ""
+	}
+	query := u.Query()
+	query.Set("cursor", meta.Pagination.NextCursor)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// linkRel returns the URL of the rel link of a Link header (RFC 8288), e.g. `<https://api/p?cursor=x>; rel="next"`.
+func linkRel(header, rel string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "rel") && strings.EqualFold(strings.Trim(value, `"`), rel) {
+				return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+			}
+		}
+	}
+	return ""
+}
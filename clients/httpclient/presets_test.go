@@ -0,0 +1,32 @@
+package httpclient
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResilientClientRejectsUnknownProfile(t *testing.T) {
+	cfg := ResilientClientConfig{Name: "catalog", Profile: "resilliant"}
+
+	if _, err := NewResilientJSONClient(cfg); !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("NewResilientJSONClient: got %v, want ErrUnknownProfile", err)
+	}
+
+	registry := NewClientRegistry(ClientRegistryConfig{Clients: map[string]ResilientClientConfig{"catalog": cfg}})
+	if _, err := registry.Client("catalog"); !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("ClientRegistry.Client: got %v, want ErrUnknownProfile", err)
+	}
+}
+
+func TestClientRegistryUsesSharedTransport(t *testing.T) {
+	registry := NewClientRegistry(ClientRegistryConfig{Clients: map[string]ResilientClientConfig{
+		"catalog": {Profile: ProfileBasic},
+		"search":  {},
+	}})
+
+	catalog := registry.MustClient("catalog")
+	search := registry.MustClient("search")
+	if catalog.transport != registry.transport || search.transport != registry.transport {
+		t.Fatal("registry clients don't share the registry transport")
+	}
+}
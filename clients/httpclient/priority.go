@@ -0,0 +1,213 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/devluispereira/go-package/clock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Priority is the importance of a request, used to decide what to shed during overload.
+type Priority int
+
+const (
+	// PriorityLow marks sheddable traffic: prefetches, recommendations, analytics.
+	PriorityLow Priority = iota + 1
+	// PriorityNormal is the priority of requests without an explicit one.
+	PriorityNormal
+	// PriorityCritical marks core traffic, which is never shed (e.g. playback, checkout).
+	PriorityCritical
+)
+
+// PriorityHeader carries the priority of a request between services.
+const PriorityHeader = "X-Priority"
+
+// ErrLoadShed is returned by the load shedding middleware when a request is rejected to protect the downstream.
+var ErrLoadShed = errors.New("request shed due to overload")
+
+// String returns the name of the priority, as sent in PriorityHeader.
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityCritical:
+		return "critical"
+	default:
+		return "normal"
+	}
+}
+
+// ParsePriority parses a priority name ("low", "normal", "critical"). Unknown names are PriorityNormal.
+func ParsePriority(name string) Priority {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "low":
+		return PriorityLow
+	case "critical":
+		return PriorityCritical
+	default:
+		return PriorityNormal
+	}
+}
+
+type PriorityKeyType struct{}
+
+// WithPriority sets the priority of the requests made with ctx.
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, PriorityKeyType{}, priority)
+}
+
+// PriorityFromContext returns the priority set with WithPriority, or PriorityNormal.
+func PriorityFromContext(ctx context.Context) Priority {
+	if priority, ok := ctx.Value(PriorityKeyType{}).(Priority); ok {
+		return priority
+	}
+	return PriorityNormal
+}
+
+// LoadSheddingConfig holds the configuration of the client load shedding middleware.
+// It can be loaded with the config package.
+type LoadSheddingConfig struct {
+	// Name identifies the downstream in metrics.
+	Name string `json:"name"`
+	// MaxInFlight is the number of concurrent requests above which normal priority requests are shed.
+	// Defaults to 100.
+	MaxInFlight int `json:"max_in_flight" env:"SHED_MAX_IN_FLIGHT" default:"100"`
+	// LowPriorityRatio is the fraction of MaxInFlight above which low priority requests are shed. Defaults to 0.5.
+	LowPriorityRatio float64 `json:"low_priority_ratio" env:"SHED_LOW_PRIORITY_RATIO" default:"0.5"`
+	// LatencyThreshold sheds low priority requests while the average downstream latency is above it.
+	// Zero disables latency based shedding.
+	LatencyThreshold time.Duration `json:"latency_threshold" env:"SHED_LATENCY_THRESHOLD"`
+	// LatencyHalfLife is the time in which the average latency halves while no request completes, so a caller
+	// sending only low priority requests is not shed forever once the threshold is crossed. Defaults to 5s.
+	LatencyHalfLife time.Duration `json:"latency_half_life" env:"SHED_LATENCY_HALF_LIFE" default:"5s"`
+	// Clock measures latencies and their decay. Defaults to clock.Real.
+	Clock clock.Clock `json:"-"`
+}
+
+// NewLoadSheddingMiddleware tags requests with their priority and sheds the less important ones when the
+// downstream is overloaded, protecting core traffic.
+//
+// Parameters:
+//
+//	cfg: Load shedding configuration.
+//
+// Behavior:
+//   - The priority comes from the request context (see WithPriority) and is sent in the X-Priority header,
+//     so the downstream can shed by priority as well (see server.LoadShedMiddleware).
+//   - Low priority requests are shed above LowPriorityRatio * MaxInFlight in-flight requests, or while the
+//     moving average latency is above LatencyThreshold. The average decays while no request completes, so low
+//     priority requests get through again once it falls below the threshold.
+//   - Normal priority requests are shed above MaxInFlight. Critical requests are never shed.
+//   - Shed requests fail with ErrLoadShed and are counted in http.client.shed, by downstream, priority and reason.
+//
+// Usage:
+//
+//	client := httpclient.NewHTTPClient(baseURL, 2*time.Second,
+//		httpclient.NewLoadSheddingMiddleware(httpclient.LoadSheddingConfig{Name: "recommendations", MaxInFlight: 50}),
+//	)
+//	resp, err := client.Get(httpclient.WithPriority(ctx, httpclient.PriorityLow), "/recommendations")
+func NewLoadSheddingMiddleware(cfg LoadSheddingConfig) func(next http.RoundTripper) http.RoundTripper {
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = 100
+	}
+	if cfg.LowPriorityRatio <= 0 || cfg.LowPriorityRatio > 1 {
+		cfg.LowPriorityRatio = 0.5
+	}
+
+	if cfg.LatencyHalfLife <= 0 {
+		cfg.LatencyHalfLife = 5 * time.Second
+	}
+
+	lowLimit := int64(math.Max(1, float64(cfg.MaxInFlight)*cfg.LowPriorityRatio))
+	clk := clock.Or(cfg.Clock)
+
+	var inFlight atomic.Int64
+	latency := &ewma{halfLife: cfg.LatencyHalfLife}
+
+	shed, _ := otel.Meter(instrumentationName).Int64Counter("http.client.shed",
+		metric.WithDescription("Outgoing requests shed due to overload."))
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			priority := PriorityFromContext(req.Context())
+
+			reason := ""
+			current := inFlight.Load()
+			switch priority {
+			case PriorityLow:
+				if current >= lowLimit {
+					reason = "in_flight"
+				} else if cfg.LatencyThreshold > 0 && latency.value(clk.Now()) > cfg.LatencyThreshold {
+					reason = "latency"
+				}
+			case PriorityNormal:
+				if current >= int64(cfg.MaxInFlight) {
+					reason = "in_flight"
+				}
+			}
+
+			if reason != "" {
+				shed.Add(req.Context(), 1, metric.WithAttributes(
+					attribute.String("peer.service", cfg.Name),
+					attribute.String("priority", priority.String()),
+					attribute.String("reason", reason),
+				))
+				return nil, ErrLoadShed
+			}
+
+			req = req.Clone(req.Context())
+			req.Header.Set(PriorityHeader, priority.String())
+
+			inFlight.Add(1)
+			start := clk.Now()
+			resp, err := next.RoundTrip(req)
+			latency.observe(clk.Since(start), clk.Now())
+			inFlight.Add(-1)
+
+			return resp, err
+		})
+	}
+}
+
+// ewma is an exponentially weighted moving average of latencies, halving every halfLife without observations.
+type ewma struct {
+	mu       sync.Mutex
+	halfLife time.Duration
+	average  time.Duration
+	updated  time.Time
+}
+
+func (e *ewma) observe(d time.Duration, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	average := e.decayed(now)
+	if average == 0 {
+		e.average, e.updated = d, now
+		return
+	}
+	e.average, e.updated = time.Duration(0.9*float64(average)+0.1*float64(d)), now
+}
+
+func (e *ewma) value(now time.Time) time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.decayed(now)
+}
+
+func (e *ewma) decayed(now time.Time) time.Duration {
+	elapsed := now.Sub(e.updated)
+	if e.average == 0 || elapsed <= 0 || e.halfLife <= 0 {
+		return e.average
+	}
+	return time.Duration(float64(e.average) * math.Exp2(-float64(elapsed)/float64(e.halfLife)))
+}
@@ -0,0 +1,47 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/devluispereira/go-package/clock/clocktest"
+)
+
+func TestLoadSheddingLatencyDecays(t *testing.T) {
+	clk := clocktest.NewFake(time.Now())
+	slow := true
+	shedding := NewLoadSheddingMiddleware(LoadSheddingConfig{
+		LatencyThreshold: 100 * time.Millisecond,
+		LatencyHalfLife:  time.Second,
+		Clock:            clk,
+	})
+	transport := shedding(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if slow {
+			clk.Advance(time.Second)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	}))
+
+	do := func(priority Priority) error {
+		req, _ := http.NewRequestWithContext(WithPriority(context.Background(), priority), http.MethodGet, "http://api/", nil)
+		_, err := transport.RoundTrip(req)
+		return err
+	}
+
+	if err := do(PriorityLow); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if err := do(PriorityLow); !errors.Is(err, ErrLoadShed) {
+		t.Fatalf("got %v, want ErrLoadShed while the downstream is slow", err)
+	}
+
+	// Only low priority traffic: no request updates the average, which must decay on its own.
+	slow = false
+	clk.Advance(5 * time.Second)
+	if err := do(PriorityLow); err != nil {
+		t.Fatalf("got %v after the average decayed", err)
+	}
+}
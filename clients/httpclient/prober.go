@@ -0,0 +1,212 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/health"
+)
+
+// ProbeTarget is a downstream periodically probed by a Prober.
+type ProbeTarget struct {
+	Name   string
+	Client *HTTPClient
+	// Method defaults to HEAD.
+	Method string
+	// Path defaults to "/".
+	Path string
+	// Interval between probes, jittered by ±20%. Defaults to 30s.
+	Interval time.Duration
+	// Timeout of each probe. Defaults to 2s.
+	Timeout time.Duration
+	// Critical registers the target as a readiness check (see Prober.RegisterHealth).
+	Critical bool
+}
+
+// ProbeResult is the outcome of the last probe of a target.
+type ProbeResult struct {
+	Name       string    `json:"name"`
+	Healthy    bool      `json:"healthy"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	LatencyMs  int64     `json:"latency_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+	err        error
+}
+
+// Prober periodically issues lightweight synthetic requests to downstream clients. Probes go through the
+// client middlewares, so they also pre-warm DNS, connections and circuit breakers before real traffic arrives.
+type Prober struct {
+	targets []ProbeTarget
+
+	mu      sync.RWMutex
+	results map[string]ProbeResult
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewProber creates a prober for the given targets.
+//
+// Usage:
+//
+//	prober := httpclient.NewProber(
+//		httpclient.ProbeTarget{Name: "catalog", Client: catalog, Path: "/status", Critical: true},
+//		httpclient.ProbeTarget{Name: "reviews", Client: reviews},
+//	)
+//	prober.RegisterHealth(health.Default)
+//	srv.OnStart(prober.Start)
+//	srv.OnStop(prober.Stop)
+func NewProber(targets ...ProbeTarget) *Prober {
+	for i := range targets {
+		if targets[i].Method == "" {
+			targets[i].Method = http.MethodHead
+		}
+		if targets[i].Path == "" {
+			targets[i].Path = "/"
+		}
+		if targets[i].Interval <= 0 {
+			targets[i].Interval = 30 * time.Second
+		}
+		if targets[i].Timeout <= 0 {
+			targets[i].Timeout = 2 * time.Second
+		}
+	}
+
+	return &Prober{targets: targets, results: make(map[string]ProbeResult)}
+}
+
+// Start probes every target in background, all at once and then at their intervals, without blocking the startup.
+// Until its first probe completes, a critical target fails its readiness check, so traffic arrives after the
+// warm-up. Its signature matches server.Hook.
+func (p *Prober) Start(_ context.Context) error {
+	runCtx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	for _, target := range p.targets {
+		p.wg.Add(1)
+		go p.loop(runCtx, target)
+	}
+
+	return nil
+}
+
+// Stop stops the background probes. Its signature matches server.Hook.
+func (p *Prober) Stop(_ context.Context) error {
+	if p.cancel != nil {
+		p.cancel()
+		p.wg.Wait()
+	}
+	return nil
+}
+
+// Results returns the last result of every target, sorted by name.
+func (p *Prober) Results() []ProbeResult {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	results := make([]ProbeResult, 0, len(p.results))
+	for _, result := range p.results {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	return results
+}
+
+// Checker returns a health checker reporting the last probe of the named target.
+func (p *Prober) Checker(name string) health.Checker {
+	return health.CheckerFunc(func(_ context.Context) error {
+		p.mu.RLock()
+		result, ok := p.results[name]
+		p.mu.RUnlock()
+
+		if !ok {
+			return errors.New("not probed yet")
+		}
+		return result.err
+	})
+}
+
+// RegisterHealth registers the critical targets in registry as "probe:<name>" readiness checks.
+func (p *Prober) RegisterHealth(registry *health.Registry) {
+	for _, target := range p.targets {
+		if target.Critical {
+			registry.Register("probe:"+target.Name, p.Checker(target.Name))
+		}
+	}
+}
+
+func (p *Prober) loop(ctx context.Context, target ProbeTarget) {
+	defer p.wg.Done()
+
+	p.probe(ctx, target)
+	for {
+		jitter := time.Duration((rand.Float64()*0.4 - 0.2) * float64(target.Interval))
+		timer := time.NewTimer(target.Interval + jitter)
+
+		select {
+		case <-timer.C:
+			p.probe(ctx, target)
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (p *Prober) probe(ctx context.Context, target ProbeTarget) {
+	ctx, cancel := context.WithTimeout(ctx, target.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	status, err := target.Client.probe(ctx, target.Method, target.Path)
+	if err == nil && status >= 500 {
+		err = fmt.Errorf("unhealthy status: %d", status)
+	}
+
+	result := ProbeResult{
+		Name:       target.Name,
+		Healthy:    err == nil,
+		StatusCode: status,
+		LatencyMs:  time.Since(start).Milliseconds(),
+		CheckedAt:  time.Now(),
+		err:        err,
+	}
+	if err != nil {
+		result.Error = err.Error()
+		logger.Warn().Err(err).Str("target", target.Name).Msg("probe:failed")
+	}
+
+	p.mu.Lock()
+	p.results[target.Name] = result
+	p.mu.Unlock()
+}
+
+// probe sends a request without decoding the body, returning the response status.
+func (c *HTTPClient) probe(ctx context.Context, method, path string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.resolveURL(path), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
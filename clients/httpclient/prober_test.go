@@ -0,0 +1,56 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProberStartDoesNotWaitForProbes(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	client := NewHTTPClient(slow.URL, 5*time.Second)
+	prober := NewProber(
+		ProbeTarget{Name: "catalog", Client: client, Timeout: 5 * time.Second, Critical: true},
+		ProbeTarget{Name: "reviews", Client: client, Timeout: 5 * time.Second},
+	)
+
+	start := time.Now()
+	if err := prober.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer prober.Stop(context.Background())
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Start took %v, want it not to wait for the probes", elapsed)
+	}
+	if err := prober.Checker("catalog").Check(context.Background()); err == nil {
+		t.Fatal("critical target ready before its first probe")
+	}
+}
+
+func TestProberFirstRoundReportsResults(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer healthy.Close()
+
+	prober := NewProber(ProbeTarget{Name: "catalog", Client: NewHTTPClient(healthy.URL, time.Second)})
+	if err := prober.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer prober.Stop(context.Background())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for prober.Checker("catalog").Check(context.Background()) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("first probe never completed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,188 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrUnknownClient is returned by ClientRegistry.Client when no downstream was declared with the given name.
+var ErrUnknownClient = errors.New("unknown client")
+
+// ClientRegistryConfig declares the downstreams of a service. It can be loaded with the config package from a
+// JSON/YAML file, keyed by client name.
+type ClientRegistryConfig struct {
+	Clients map[string]ResilientClientConfig `json:"clients"`
+}
+
+// ClientRegistry builds the declared downstream clients lazily, on first use, and shares a single
+// transport (connection pool) among them. Circuit breakers are registered process-wide, so they appear in the
+// breaker dashboard (see Breakers).
+type ClientRegistry struct {
+	mu        sync.Mutex
+	configs   map[string]ResilientClientConfig
+	clients   map[string]*HTTPClient
+	transport http.RoundTripper
+	redis     IRedisClient
+}
+
+// RegistryOption customizes a ClientRegistry.
+type RegistryOption func(*ClientRegistry)
+
+// WithRegistryRedis sets the Redis client used by the cache of the clients with the "cached" profile.
+func WithRegistryRedis(client IRedisClient) RegistryOption {
+	return func(r *ClientRegistry) {
+		r.redis = client
+	}
+}
+
+// WithRegistryTransport replaces the shared transport.
+func WithRegistryTransport(transport http.RoundTripper) RegistryOption {
+	return func(r *ClientRegistry) {
+		r.transport = transport
+	}
+}
+
+// NewClientRegistry creates a registry from the declared downstreams.
+//
+// Usage:
+//
+//	var cfg httpclient.ClientRegistryConfig
+//	_ = config.Load(&cfg, config.WithFile("clients.yaml"))
+//	registry := httpclient.NewClientRegistry(cfg, httpclient.WithRegistryRedis(redis))
+//
+//	catalog, err := registry.Client("catalog")
+//
+//	# clients.yaml
+//	clients:
+//	  catalog:
+//	    base_url: https://catalog.internal
+//	    timeout: 2s
+//	    profile: cached
+//	  search:
+//	    base_url: https://search.internal
+//	    retry:
+//	      max_attempts: 2
+func NewClientRegistry(cfg ClientRegistryConfig, opts ...RegistryOption) *ClientRegistry {
+	r := &ClientRegistry{
+		configs:   make(map[string]ResilientClientConfig, len(cfg.Clients)),
+		clients:   make(map[string]*HTTPClient),
+		transport: newSharedTransport(),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	for name, clientCfg := range cfg.Clients {
+		r.configs[name] = clientCfg
+	}
+
+	return r
+}
+
+// Register declares a downstream in code. It replaces a previous declaration that was not built yet.
+func (r *ClientRegistry) Register(name string, cfg ResilientClientConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.configs[name] = cfg
+}
+
+// Client returns the client of the named downstream, building it on first use. It returns ErrUnknownClient for an
+// undeclared name and ErrUnknownProfile for a declaration with an unknown Profile.
+func (r *ClientRegistry) Client(name string) (*HTTPClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if client, ok := r.clients[name]; ok {
+		return client, nil
+	}
+
+	cfg, ok := r.configs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownClient, name)
+	}
+
+	if cfg.Name == "" {
+		cfg.Name = name
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.Profile == ProfileCached && cfg.Cache.RedisClient == nil {
+		cfg.Cache.RedisClient = r.redis
+	}
+
+	middlewares, err := ResilientMiddlewares(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewHTTPClientWithTransport(cfg.BaseURL, cfg.Timeout, r.transport, middlewares...)
+	r.clients[name] = client
+	return client, nil
+}
+
+// MustClient behaves like Client but panics for unknown downstreams. Use it during startup wiring.
+func (r *ClientRegistry) MustClient(name string) *HTTPClient {
+	client, err := r.Client(name)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// Names returns the declared downstream names, sorted.
+func (r *ClientRegistry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.configs))
+	for name := range r.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Configs returns the declaration of every downstream, keyed by name, e.g. for diagnostics. Built reports whether
+// the client was already built.
+func (r *ClientRegistry) Configs() map[string]RegisteredClient {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	configs := make(map[string]RegisteredClient, len(r.configs))
+	for name, cfg := range r.configs {
+		_, built := r.clients[name]
+		configs[name] = RegisteredClient{Config: cfg, Built: built}
+	}
+
+	return configs
+}
+
+// RegisteredClient is a downstream declared in a ClientRegistry.
+type RegisteredClient struct {
+	Config ResilientClientConfig `json:"config"`
+	Built  bool                  `json:"built"`
+}
+
+func newSharedTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          200,
+		MaxIdleConnsPerHost:   50,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
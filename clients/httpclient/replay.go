@@ -0,0 +1,344 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayEntry is a request recorded in an access log or a fixture, replayed by HTTPClient.Replay.
+type ReplayEntry struct {
+	Time    time.Time         `json:"time"`
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// Status and Duration are the recorded response status and latency, compared with the replayed ones.
+	Status   int           `json:"status"`
+	Duration time.Duration `json:"duration"`
+}
+
+// ReadAccessLog reads the requests of a structured access log: one JSON object per line, as written by the logging
+// package. Lines without a method and a path, such as other log messages, are skipped.
+//
+// Behavior:
+//   - The path is read from "path", "url" or "uri", with "query" appended when present.
+//   - The latency is read from "duration" (milliseconds, as logged by zerolog Dur, or a duration string such as
+//     "120ms"), "duration_ms" or "latency_ms".
+//   - "headers" (an object of strings) and "body" (a string) are replayed when present; fixtures written with
+//     json.Marshal of ReplayEntry have the same fields.
+//
+// Usage:
+//
+//	file, _ := os.Open("access.log")
+//	entries, err := httpclient.ReadAccessLog(file)
+func ReadAccessLog(r io.Reader) ([]ReplayEntry, error) {
+	var entries []ReplayEntry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(text, "{") {
+			continue
+		}
+
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(text), &fields); err != nil {
+			return nil, fmt.Errorf("invalid access log line %d: %w", line, err)
+		}
+		if entry, ok := replayEntryFromLog(fields); ok {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read access log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// replayEntryFromLog returns the request of a log line, if it has one.
+func replayEntryFromLog(fields map[string]any) (ReplayEntry, bool) {
+	str := func(names ...string) string {
+		for _, name := range names {
+			if value, ok := fields[name].(string); ok && value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	entry := ReplayEntry{
+		Method: strings.ToUpper(str("method")),
+		Path:   str("path", "url", "uri"),
+		Body:   str("body"),
+	}
+	if entry.Method == "" || entry.Path == "" {
+		return entry, false
+	}
+	if query := str("query"); query != "" && !strings.Contains(entry.Path, "?") {
+		entry.Path += "?" + strings.TrimPrefix(query, "?")
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, str("time")); err == nil {
+		entry.Time = t
+	}
+	if status, ok := fields["status"].(float64); ok {
+		entry.Status = int(status)
+	}
+
+	switch duration := fields["duration"].(type) {
+	case float64:
+		entry.Duration = time.Duration(duration * float64(time.Millisecond))
+	case string:
+		entry.Duration, _ = time.ParseDuration(duration)
+	default:
+		for _, name := range []string{"duration_ms", "latency_ms"} {
+			if ms, ok := fields[name].(float64); ok {
+				entry.Duration = time.Duration(ms * float64(time.Millisecond))
+				break
+			}
+		}
+	}
+
+	if headers, ok := fields["headers"].(map[string]any); ok {
+		entry.Headers = make(map[string]string, len(headers))
+		for name, value := range headers {
+			if s, ok := value.(string); ok {
+				entry.Headers[name] = s
+			}
+		}
+	}
+
+	return entry, true
+}
+
+// ReplayConfig holds the settings of HTTPClient.Replay.
+type ReplayConfig struct {
+	// Speed scales the recorded pace: 1 replays at the recorded pace, 2 twice as fast. 0 sends the requests as fast
+	// as Concurrency allows, as do entries without a time.
+	Speed float64 `json:"speed" env:"REPLAY_SPEED" default:"1"`
+	// Concurrency caps the replayed requests in flight. Defaults to 10.
+	Concurrency int `json:"concurrency" env:"REPLAY_CONCURRENCY" default:"10"`
+	// LatencyTolerance is how much slower than recorded a replayed request can be before it is reported.
+	// Defaults to 100ms.
+	LatencyTolerance time.Duration `json:"latency_tolerance" env:"REPLAY_LATENCY_TOLERANCE" default:"100ms"`
+	// Headers are set on every replayed request, e.g. a test Authorization replacing the redacted one.
+	Headers map[string]string `json:"headers"`
+}
+
+// ReplayResult is the outcome of a replayed request.
+type ReplayResult struct {
+	Entry    ReplayEntry   `json:"entry"`
+	Status   int           `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// ReplayReport summarizes a replay. Entries without a recorded status or latency are not compared on it.
+type ReplayReport struct {
+	Total  int `json:"total"`
+	Errors int `json:"errors"`
+	// StatusDiffs are the requests answered with a status other than the recorded one, or failed.
+	StatusDiffs []ReplayResult `json:"status_diffs"`
+	// LatencyDiffs are the requests slower than recorded by more than LatencyTolerance.
+	LatencyDiffs []ReplayResult `json:"latency_diffs"`
+	// Recorded and Replayed are the latency percentiles of the recorded and replayed requests.
+	Recorded LatencyPercentiles `json:"recorded"`
+	Replayed LatencyPercentiles `json:"replayed"`
+	Elapsed  time.Duration      `json:"elapsed"`
+}
+
+// LatencyPercentiles are the percentiles of a set of latencies.
+type LatencyPercentiles struct {
+	P50 time.Duration `json:"p50"`
+	P95 time.Duration `json:"p95"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+// Replay sends recorded requests to the base URL of the client and compares the responses with the recorded ones,
+// to validate refactors and to load test with realistic traffic patterns.
+//
+// Parameters:
+//
+//	ctx: Context for cancellation; cancelling it stops the replay.
+//	entries: Recorded requests, usually read with ReadAccessLog, in the order they were recorded.
+//	cfg: Pace, concurrency and latency tolerance.
+//
+// Behavior:
+//   - Requests go through the middlewares of the client, with the recorded headers and body. Responses are
+//     compared by status only, so any body (JSON or not) is accepted.
+//   - Requests are sent at their recorded offsets from the first entry, divided by Speed. When Concurrency
+//     requests are in flight, the next ones wait, falling behind the recorded pace.
+//   - Run it against staging or a local instance: writes are replayed too.
+//
+// Usage:
+//
+//	target := httpclient.NewHTTPClient("http://localhost:8080", 5*time.Second)
+//	report, err := target.Replay(ctx, entries, httpclient.ReplayConfig{Speed: 2, Concurrency: 20})
+//	for _, diff := range report.StatusDiffs {
+//		fmt.Println(diff.Entry.Method, diff.Entry.Path, diff.Entry.Status, "->", diff.Status, diff.Error)
+//	}
+//
+// Returns:
+//   - *ReplayReport: Differences and latency percentiles of the replayed requests.
+//   - error: The context error, when the replay was cancelled; the report covers the requests sent before it.
+func (c *HTTPClient) Replay(ctx context.Context, entries []ReplayEntry, cfg ReplayConfig) (*ReplayReport, error) {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 10
+	}
+	if cfg.LatencyTolerance <= 0 {
+		cfg.LatencyTolerance = 100 * time.Millisecond
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results []ReplayResult
+	)
+	sem := make(chan struct{}, cfg.Concurrency)
+	start := time.Now()
+
+	var first time.Time
+	for _, entry := range entries {
+		if !entry.Time.IsZero() {
+			first = entry.Time
+			break
+		}
+	}
+
+	var err error
+	for _, entry := range entries {
+		if cfg.Speed > 0 && !entry.Time.IsZero() && !first.IsZero() {
+			offset := time.Duration(float64(entry.Time.Sub(first)) / cfg.Speed)
+			if wait := time.Until(start.Add(offset)); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+				}
+			}
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err = ctx.Err(); err != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := c.replayEntry(ctx, entry, cfg.Headers)
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	report := newReplayReport(results, cfg.LatencyTolerance)
+	report.Elapsed = time.Since(start)
+
+	logger.Info().
+		Int("total", report.Total).
+		Int("errors", report.Errors).
+		Int("status_diffs", len(report.StatusDiffs)).
+		Int("latency_diffs", len(report.LatencyDiffs)).
+		Int64("elapsed_ms", report.Elapsed.Milliseconds()).
+		Msg("Traffic replay finished")
+
+	return report, err
+}
+
+// replayEntry sends a recorded request and returns its outcome.
+func (c *HTTPClient) replayEntry(ctx context.Context, entry ReplayEntry, headers map[string]string) ReplayResult {
+	result := ReplayResult{Entry: entry}
+
+	var body io.Reader
+	if entry.Body != "" {
+		body = strings.NewReader(entry.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, entry.Method, c.resolveURL(entry.Path), body)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	for name, value := range entry.Headers {
+		req.Header.Set(name, value)
+	}
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		result.Duration = time.Since(start)
+		result.Error = ClassifyError(err).Error()
+		return result
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	result.Duration = time.Since(start)
+	result.Status = resp.StatusCode
+	return result
+}
+
+// newReplayReport compares the results with the recorded entries.
+func newReplayReport(results []ReplayResult, tolerance time.Duration) *ReplayReport {
+	report := &ReplayReport{Total: len(results)}
+
+	var recorded, replayed []time.Duration
+	for _, result := range results {
+		if result.Error != "" {
+			report.Errors++
+		}
+		if result.Error != "" || (result.Entry.Status != 0 && result.Status != result.Entry.Status) {
+			report.StatusDiffs = append(report.StatusDiffs, result)
+		}
+		if result.Error == "" {
+			replayed = append(replayed, result.Duration)
+		}
+		if result.Entry.Duration > 0 {
+			recorded = append(recorded, result.Entry.Duration)
+			if result.Error == "" && result.Duration-result.Entry.Duration > tolerance {
+				report.LatencyDiffs = append(report.LatencyDiffs, result)
+			}
+		}
+	}
+
+	report.Recorded = latencyPercentiles(recorded)
+	report.Replayed = latencyPercentiles(replayed)
+	return report
+}
+
+func latencyPercentiles(durations []time.Duration) LatencyPercentiles {
+	if len(durations) == 0 {
+		return LatencyPercentiles{}
+	}
+	slices.Sort(durations)
+
+	// Nearest-rank percentile.
+	at := func(p float64) time.Duration {
+		return durations[int(math.Ceil(p*float64(len(durations))))-1]
+	}
+	return LatencyPercentiles{P50: at(0.50), P95: at(0.95), P99: at(0.99), Max: durations[len(durations)-1]}
+}
@@ -0,0 +1,203 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/devluispereira/go-package/clock"
+	"github.com/devluispereira/go-package/settings"
+	"github.com/sony/gobreaker"
+)
+
+// RetryConfig holds the retry settings. It can be loaded with the config package.
+type RetryConfig struct {
+	// Name identifies the client in the "disable:retry:<Name>" setting (see settings.DisablePrefix).
+	Name string `json:"name" env:"RETRY_NAME"`
+	// MaxAttempts is the total number of attempts, including the first one. 1 disables retries.
+	MaxAttempts int `json:"max_attempts" env:"RETRY_MAX_ATTEMPTS" default:"3"`
+	// InitialBackoff is the base delay, doubled at each attempt with full jitter.
+	InitialBackoff time.Duration `json:"initial_backoff" env:"RETRY_INITIAL_BACKOFF" default:"100ms"`
+	// MaxBackoff caps the delay between attempts, including the one requested by Retry-After.
+	MaxBackoff time.Duration `json:"max_backoff" env:"RETRY_MAX_BACKOFF" default:"2s"`
+	// Statuses are the response statuses retried.
+	Statuses []int `json:"statuses" env:"RETRY_STATUSES" default:"429,502,503,504"`
+	// Methods are the retried methods. Defaults to the idempotent ones.
+	Methods []string `json:"methods" env:"RETRY_METHODS" default:"GET,HEAD,OPTIONS,PUT,DELETE"`
+	// Budget caps retries as a fraction of requests. If nil, each middleware gets its own budget, named after Name,
+	// so a failing downstream can't exhaust the retries of the other clients. Set DefaultRetryBudget to share one.
+	Budget *RetryBudget `json:"-"`
+	// Clock times the backoff between attempts. Defaults to clock.Real.
+	Clock clock.Clock `json:"-"`
+}
+
+// DefaultRetryConfig returns the default retry settings.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Statuses:       []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		Methods:        []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete},
+	}
+}
+
+// NewRetryMiddleware retries failed requests with exponential backoff and full jitter.
+//
+// Parameters:
+//
+//	cfg: Retry settings. Zero values fall back to DefaultRetryConfig.
+//
+// Behavior:
+//   - Retries transport errors and the configured statuses, only for the configured methods.
+//   - Honors the Retry-After header (seconds), capped by MaxBackoff.
+//   - Does not retry when the circuit breaker is open or the request context is done.
+//   - Request bodies are replayed with req.GetBody; requests whose body can't be replayed are not retried.
+//   - Each retry is withdrawn from the retry budget (cfg.Budget, or one per middleware). When it is exhausted the
+//     last response is returned as is, and a transport error is wrapped with ErrRetryBudgetExhausted.
+//   - Place it before the circuit breaker, so each attempt is counted by the breaker.
+//   - The "disable:retry" settings (see settings.DisablePrefix) turn retries off at runtime.
+//
+// Usage:
+//
+//	client := httpclient.NewHTTPClient(baseURL, 5*time.Second,
+//		httpclient.NewRetryMiddleware(httpclient.DefaultRetryConfig()),
+//		httpclient.NewCircuitBreakerMiddleware("catalog"),
+//	)
+func NewRetryMiddleware(cfg RetryConfig) func(next http.RoundTripper) http.RoundTripper {
+	cfg = withRetryDefaults(cfg)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if settings.Default.Disabled("retry", cfg.Name) {
+				return next.RoundTrip(req)
+			}
+
+			cfg.Budget.recordRequest()
+
+			if !slices.Contains(cfg.Methods, req.Method) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+				return next.RoundTrip(req)
+			}
+
+			exec := executionFrom(req.Context())
+
+			var delay time.Duration
+			for attempt := 1; ; attempt++ {
+				exec.attempt(attempt, delay)
+				resp, err := next.RoundTrip(req)
+
+				if attempt >= cfg.MaxAttempts || !cfg.shouldRetry(req.Context(), resp, err) {
+					return resp, err
+				}
+
+				if !cfg.Budget.tryRetry(req.Context()) {
+					log := requestLogger(req.Context())
+					log.Warn().
+						Str("method", req.Method).
+						Str("url", req.URL.String()).
+						Int("attempt", attempt).
+						Msg("retry budget exhausted, not retrying")
+
+					if err != nil {
+						return nil, fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, err)
+					}
+					return resp, nil
+				}
+
+				delay = cfg.backoff(attempt, resp)
+				if resp != nil {
+					_, _ = io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+				}
+
+				log := requestLogger(req.Context())
+				log.Warn().
+					Str("method", req.Method).
+					Str("url", req.URL.String()).
+					Int("attempt", attempt).
+					Int64("backoff_ms", delay.Milliseconds()).
+					Msg("retrying request")
+
+				timer := cfg.Clock.NewTimer(delay)
+				select {
+				case <-timer.C():
+				case <-req.Context().Done():
+					timer.Stop()
+					return nil, req.Context().Err()
+				}
+
+				// The caller's request is never modified: each retry sends a clone with a fresh body.
+				req = req.Clone(req.Context())
+				if req.GetBody != nil {
+					body, bodyErr := req.GetBody()
+					if bodyErr != nil {
+						return nil, bodyErr
+					}
+					req.Body = body
+				}
+			}
+		})
+	}
+}
+
+func (cfg RetryConfig) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		return !errors.Is(err, gobreaker.ErrOpenState) &&
+			!errors.Is(err, gobreaker.ErrTooManyRequests) &&
+			!errors.Is(err, ErrBreakerForcedOpen) &&
+			!errors.Is(err, ErrHostUnavailable)
+	}
+
+	return slices.Contains(cfg.Statuses, resp.StatusCode)
+}
+
+func (cfg RetryConfig) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, cfg.MaxBackoff)
+		}
+	}
+
+	// Clamped before shifting, since InitialBackoff<<(attempt-1) overflows for large attempts.
+	ceiling := cfg.MaxBackoff
+	if shift := attempt - 1; shift < 63 && cfg.InitialBackoff <= cfg.MaxBackoff>>shift {
+		ceiling = cfg.InitialBackoff << shift
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
+
+func withRetryDefaults(cfg RetryConfig) RetryConfig {
+	defaults := DefaultRetryConfig()
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaults.MaxAttempts
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = defaults.InitialBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaults.MaxBackoff
+	}
+	if len(cfg.Statuses) == 0 {
+		cfg.Statuses = defaults.Statuses
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = defaults.Methods
+	}
+	cfg.Clock = clock.Or(cfg.Clock)
+	if cfg.Budget == nil {
+		cfg.Budget = NewRetryBudget(RetryBudgetConfig{Name: cfg.Name, Clock: cfg.Clock})
+	}
+
+	return cfg
+}
@@ -0,0 +1,151 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/clock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrRetryBudgetExhausted is wrapped in the error returned by the retry middleware when a failed request was not
+// retried because the retry budget is exhausted.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudgetConfig holds the configuration of a RetryBudget. It can be loaded with the config package.
+type RetryBudgetConfig struct {
+	// Name identifies the budget in metrics. Defaults to "default".
+	Name string `json:"name" default:"default"`
+	// Ratio is the maximum number of retries per request over the window, e.g. 0.1 allows retries to add at most
+	// 10% of load. Defaults to 0.1.
+	Ratio float64 `json:"ratio" env:"RETRY_BUDGET_RATIO" default:"0.1"`
+	// MinRetriesPerSecond lets low traffic clients retry even when Ratio would allow none. Defaults to 10.
+	MinRetriesPerSecond int `json:"min_retries_per_second" env:"RETRY_BUDGET_MIN_RETRIES_PER_SECOND" default:"10"`
+	// Window is the sliding window over which requests and retries are counted. Defaults to 10s.
+	Window time.Duration `json:"window" env:"RETRY_BUDGET_WINDOW" default:"10s"`
+	// Clock places requests and retries in the window. Defaults to clock.Real.
+	Clock clock.Clock `json:"-"`
+}
+
+// RetryBudget caps retries as a fraction of requests over a sliding window, so the retry middleware cannot amplify
+// an outage into a retry storm. Each retry middleware has its own budget unless RetryConfig.Budget is set; share a
+// budget between the clients of the same downstream, not across downstreams.
+type RetryBudget struct {
+	cfg     RetryBudgetConfig
+	mu      sync.Mutex
+	buckets []retryBudgetBucket
+
+	exhausted metric.Int64Counter
+}
+
+type retryBudgetBucket struct {
+	second   int64
+	requests int64
+	retries  int64
+}
+
+// DefaultRetryBudget is a process-wide budget, for retry middlewares that opt into sharing one through
+// RetryConfig.Budget.
+var DefaultRetryBudget = NewRetryBudget(RetryBudgetConfig{})
+
+// NewRetryBudget creates a retry budget and registers its metrics: the http.client.retry.budget_exhausted counter
+// and the http.client.retry.budget_remaining gauge, labelled by budget name.
+//
+// Usage:
+//
+//	budget := httpclient.NewRetryBudget(httpclient.RetryBudgetConfig{Name: "catalog", Ratio: 0.2})
+//	retry := httpclient.DefaultRetryConfig()
+//	retry.Budget = budget
+func NewRetryBudget(cfg RetryBudgetConfig) *RetryBudget {
+	if cfg.Name == "" {
+		cfg.Name = "default"
+	}
+	if cfg.Ratio <= 0 {
+		cfg.Ratio = 0.1
+	}
+	if cfg.MinRetriesPerSecond < 0 {
+		cfg.MinRetriesPerSecond = 0
+	} else if cfg.MinRetriesPerSecond == 0 {
+		cfg.MinRetriesPerSecond = 10
+	}
+	if cfg.Window < time.Second {
+		cfg.Window = 10 * time.Second
+	}
+	cfg.Clock = clock.Or(cfg.Clock)
+
+	b := &RetryBudget{cfg: cfg, buckets: make([]retryBudgetBucket, int(cfg.Window/time.Second))}
+
+	nameAttr := metric.WithAttributes(attribute.String("budget", cfg.Name))
+	meter := otel.Meter(instrumentationName)
+	b.exhausted, _ = meter.Int64Counter("http.client.retry.budget_exhausted",
+		metric.WithDescription("Retries skipped because the retry budget was exhausted."))
+	remaining, _ := meter.Int64ObservableGauge("http.client.retry.budget_remaining",
+		metric.WithDescription("Retries still allowed by the retry budget in the current window."))
+	_, _ = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(remaining, b.Remaining(), nameAttr)
+		return nil
+	}, remaining)
+
+	return b
+}
+
+// Remaining returns the number of retries still allowed in the current window.
+func (b *RetryBudget) Remaining() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	requests, retries := b.totals(b.cfg.Clock.Now().Unix())
+	return max(0, b.allowed(requests)-retries)
+}
+
+// recordRequest counts a request made through a retry middleware.
+func (b *RetryBudget) recordRequest() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bucket(b.cfg.Clock.Now().Unix()).requests++
+}
+
+// tryRetry withdraws a retry from the budget, reporting false when none is left.
+func (b *RetryBudget) tryRetry(ctx context.Context) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.cfg.Clock.Now().Unix()
+	requests, retries := b.totals(now)
+
+	if retries >= b.allowed(requests) {
+		b.exhausted.Add(ctx, 1, metric.WithAttributes(attribute.String("budget", b.cfg.Name)))
+		return false
+	}
+
+	b.bucket(now).retries++
+	return true
+}
+
+func (b *RetryBudget) allowed(requests int64) int64 {
+	return int64(b.cfg.Ratio*float64(requests)) + int64(b.cfg.MinRetriesPerSecond)*int64(len(b.buckets))
+}
+
+func (b *RetryBudget) bucket(second int64) *retryBudgetBucket {
+	bucket := &b.buckets[second%int64(len(b.buckets))]
+	if bucket.second != second {
+		*bucket = retryBudgetBucket{second: second}
+	}
+	return bucket
+}
+
+func (b *RetryBudget) totals(now int64) (requests, retries int64) {
+	oldest := now - int64(len(b.buckets)) + 1
+	for _, bucket := range b.buckets {
+		if bucket.second >= oldest {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+	return requests, retries
+}
@@ -0,0 +1,96 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// respondWith returns a transport answering with the statuses in order, repeating the last one.
+func respondWith(calls *atomic.Int32, statuses ...int) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		n := int(calls.Add(1)) - 1
+		status := statuses[min(n, len(statuses)-1)]
+		return &http.Response{StatusCode: status, Body: http.NoBody, Header: http.Header{}, Request: req}, nil
+	})
+}
+
+func fastRetryConfig(name string) RetryConfig {
+	cfg := DefaultRetryConfig()
+	cfg.Name = name
+	cfg.MaxAttempts = 2
+	cfg.InitialBackoff = time.Nanosecond
+	cfg.MaxBackoff = time.Nanosecond
+	return cfg
+}
+
+func TestRetryBudgetIsPerMiddlewareByDefault(t *testing.T) {
+	var failingCalls atomic.Int32
+	failing := NewRetryMiddleware(fastRetryConfig("failing"))(respondWith(&failingCalls, http.StatusServiceUnavailable))
+	for range 300 {
+		req, _ := http.NewRequest(http.MethodGet, "http://failing/", nil)
+		if _, err := failing.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := failingCalls.Load(); n >= 600 {
+		t.Fatalf("failing downstream called %d times, want the budget to stop retries", n)
+	}
+
+	var healthyCalls atomic.Int32
+	healthy := NewRetryMiddleware(fastRetryConfig("healthy"))(respondWith(&healthyCalls, http.StatusServiceUnavailable, http.StatusOK))
+	req, _ := http.NewRequest(http.MethodGet, "http://healthy/", nil)
+	resp, err := healthy.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("got %v, %v: the failing downstream exhausted the retries of another client", resp, err)
+	}
+}
+
+func TestRetryBudgetCanBeShared(t *testing.T) {
+	budget := NewRetryBudget(RetryBudgetConfig{Name: t.Name(), MinRetriesPerSecond: -1})
+
+	cfg := fastRetryConfig("shared")
+	cfg.Budget = budget
+	var calls atomic.Int32
+	transport := NewRetryMiddleware(cfg)(respondWith(&calls, http.StatusServiceUnavailable))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://shared/", nil)
+	_, _ = transport.RoundTrip(req)
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("called %d times, want no retry from an empty shared budget", n)
+	}
+}
+
+func TestRetryBackoffClampsLargeAttempts(t *testing.T) {
+	cfg := withRetryDefaults(RetryConfig{MaxAttempts: 100})
+	for _, attempt := range []int{1, 30, 40, 64, 65, 100} {
+		if delay := cfg.backoff(attempt, nil); delay < 0 || delay > cfg.MaxBackoff {
+			t.Errorf("attempt %d: backoff %v, want within [0, %v]", attempt, delay, cfg.MaxBackoff)
+		}
+	}
+}
+
+func TestRetryDoesNotReplaceCallerBody(t *testing.T) {
+	var bodies []string
+	var calls atomic.Int32
+	next := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		return respondWith(&calls, http.StatusServiceUnavailable, http.StatusOK).RoundTrip(req)
+	})
+
+	req, _ := http.NewRequest(http.MethodPut, "http://catalog/items/1", strings.NewReader(`{"name":"item"}`))
+	original := req.Body
+	if _, err := NewRetryMiddleware(fastRetryConfig(t.Name()))(next).RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Body != original {
+		t.Fatal("retry replaced the body of the caller's request")
+	}
+	if len(bodies) != 2 || bodies[1] != `{"name":"item"}` {
+		t.Fatalf("got bodies %q, want the body replayed", bodies)
+	}
+}
@@ -0,0 +1,223 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrSchemaViolation is wrapped by SchemaViolationError.
+var ErrSchemaViolation = errors.New("response violates schema")
+
+// SchemaViolationError is returned in SchemaModeFail when a response doesn't match its schema.
+type SchemaViolationError struct {
+	// Method and URL identify the request.
+	Method string
+	URL    string
+	// Route is the Path of the rule that matched.
+	Route string
+	// Violations are the first violations found, as "<json path>: <reason>".
+	Violations []string
+}
+
+func (e *SchemaViolationError) Error() string {
+	return fmt.Sprintf("%s %s: %s: %s", e.Method, e.URL, ErrSchemaViolation, strings.Join(e.Violations, "; "))
+}
+
+func (e *SchemaViolationError) Unwrap() error {
+	return ErrSchemaViolation
+}
+
+const (
+	// SchemaModeLog logs violations and returns the response unchanged.
+	SchemaModeLog = "log"
+	// SchemaModeFail returns a *SchemaViolationError instead of the response.
+	SchemaModeFail = "fail"
+)
+
+// SchemaRule associates a JSON Schema with the responses of a route.
+type SchemaRule struct {
+	// Method restricts the rule to a request method. Empty matches every method.
+	Method string `json:"method"`
+	// Host is a glob matched against the request host without port. Empty matches every host.
+	Host string `json:"host"`
+	// Path is a glob matched against the request path, e.g. "/v1/products/*".
+	Path string `json:"path"`
+	// Schema is the JSON Schema document of the response body.
+	Schema json.RawMessage `json:"schema"`
+}
+
+// SchemaValidationConfig holds the configuration of the response schema validation middleware.
+// It can be loaded with the config package.
+type SchemaValidationConfig struct {
+	// Name identifies the downstream in logs and metrics.
+	Name string `json:"name"`
+	// Rules are checked in order; the first matching rule validates the response.
+	Rules []SchemaRule `json:"rules"`
+	// Mode is SchemaModeLog (default) or SchemaModeFail.
+	Mode string `json:"mode" env:"SCHEMA_VALIDATION_MODE" default:"log"`
+	// MaxBodySize skips the validation of larger bodies. Defaults to 1 MiB.
+	MaxBodySize int64 `json:"max_body_size" env:"SCHEMA_VALIDATION_MAX_BODY_SIZE" default:"1048576"`
+}
+
+type compiledSchemaRule struct {
+	rule   SchemaRule
+	schema *jsonSchema
+}
+
+func (c compiledSchemaRule) matches(req *http.Request) bool {
+	if c.rule.Method != "" && !strings.EqualFold(c.rule.Method, req.Method) {
+		return false
+	}
+
+	if c.rule.Host != "" {
+		if ok, _ := path.Match(strings.ToLower(c.rule.Host), strings.ToLower(req.URL.Hostname())); !ok {
+			return false
+		}
+	}
+
+	ok, _ := path.Match(c.rule.Path, req.URL.Path)
+	return ok
+}
+
+// NewSchemaValidationMiddleware validates JSON responses of a downstream against a JSON Schema per route, catching
+// breaking upstream changes before they corrupt application state.
+//
+// Parameters:
+//
+//	cfg: Rules and violation mode.
+//
+// Behavior:
+//   - Only 2xx responses with a JSON content type are validated, against the first rule matching the request.
+//   - Supports the JSON Schema keywords type, enum, const, properties, required, additionalProperties, items,
+//     minItems, maxItems, minLength, maxLength, pattern, minimum, maximum, allOf and anyOf; others are ignored.
+//   - Rules with an invalid schema are logged and ignored.
+//   - Violations are counted in http.client.schema.violations and logged with "event": "contract.schema_violation".
+//   - In SchemaModeFail, the response is discarded and a *SchemaViolationError (wrapping ErrSchemaViolation)
+//     is returned, so the caller never sees the unexpected payload.
+//   - Bodies larger than MaxBodySize are passed through without validation.
+//
+// Usage:
+//
+//	client := httpclient.NewHTTPClient(baseURL, 2*time.Second,
+//		httpclient.NewSchemaValidationMiddleware(httpclient.SchemaValidationConfig{
+//			Name: "catalog",
+//			Rules: []httpclient.SchemaRule{
+//				{Path: "/v1/products/*", Schema: json.RawMessage(productSchema)},
+//			},
+//		}),
+//	)
+func NewSchemaValidationMiddleware(cfg SchemaValidationConfig) func(next http.RoundTripper) http.RoundTripper {
+	if cfg.Mode == "" {
+		cfg.Mode = SchemaModeLog
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = 1 << 20
+	}
+
+	rules := make([]compiledSchemaRule, 0, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		schema, err := parseJSONSchema(rule.Schema)
+		if err != nil {
+			logger.Error().Err(err).Str("name", cfg.Name).Str("path", rule.Path).Msg("Ignoring invalid schema rule")
+			continue
+		}
+		rules = append(rules, compiledSchemaRule{rule: rule, schema: schema})
+	}
+
+	violationsCounter, _ := otel.Meter(instrumentationName).Int64Counter("http.client.schema.violations",
+		metric.WithDescription("Downstream responses that violate their JSON Schema."))
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 || !isJSONResponse(resp) {
+				return resp, err
+			}
+
+			var rule *compiledSchemaRule
+			for i := range rules {
+				if rules[i].matches(req) {
+					rule = &rules[i]
+					break
+				}
+			}
+			if rule == nil {
+				return resp, nil
+			}
+
+			body, err := io.ReadAll(io.LimitReader(resp.Body, cfg.MaxBodySize+1))
+			if err != nil {
+				resp.Body.Close()
+				return nil, fmt.Errorf("read response body: %w", err)
+			}
+
+			if int64(len(body)) > cfg.MaxBodySize {
+				resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+				return resp, nil
+			}
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+
+			var value any
+			var violations []string
+			if err := json.Unmarshal(body, &value); err != nil {
+				violations = []string{"$: invalid json: " + err.Error()}
+			} else {
+				violations = rule.schema.validate(value)
+			}
+
+			if len(violations) == 0 {
+				return resp, nil
+			}
+
+			violationsCounter.Add(req.Context(), 1, metric.WithAttributes(
+				attribute.String("peer.service", cfg.Name),
+				attribute.String("route", rule.rule.Path),
+				attribute.String("mode", cfg.Mode),
+			))
+
+			log := requestLogger(req.Context())
+			log.Warn().
+				Str("event", "contract.schema_violation").
+				Str("name", cfg.Name).
+				Str("method", req.Method).
+				Str("url", req.URL.String()).
+				Str("route", rule.rule.Path).
+				Strs("violations", violations).
+				Msg("response violates schema")
+
+			if cfg.Mode == SchemaModeFail {
+				return nil, &SchemaViolationError{
+					Method:     req.Method,
+					URL:        req.URL.String(),
+					Route:      rule.rule.Path,
+					Violations: violations,
+				}
+			}
+
+			return resp, nil
+		})
+	}
+}
+
+func isJSONResponse(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// readCloser reads from a reader and closes the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
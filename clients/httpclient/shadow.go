@@ -0,0 +1,231 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var errShadowBodyNotReplayable = errors.New("request body can't be replayed")
+
+// ShadowConfig holds the configuration of the shadow traffic middleware. It can be loaded with the config package.
+type ShadowConfig struct {
+	// Name identifies the downstream in metrics.
+	Name string `json:"name"`
+	// BaseURL is the secondary endpoint. Its scheme and host replace the ones of the request and its path,
+	// if any, is prepended to the request path.
+	BaseURL string `json:"base_url" env:"SHADOW_BASE_URL"`
+	// Percent is the percentage (0-100) of requests mirrored.
+	Percent float64 `json:"percent" env:"SHADOW_PERCENT"`
+	// Methods are the mirrored methods. Defaults to GET and HEAD: mirroring writes repeats their side effects
+	// on the shadow target, so add them only when it is isolated.
+	Methods []string `json:"methods" env:"SHADOW_METHODS" default:"GET,HEAD"`
+	// MaxConcurrent caps the mirrored requests in flight; requests above it are not mirrored. Defaults to 50.
+	MaxConcurrent int `json:"max_concurrent" env:"SHADOW_MAX_CONCURRENT" default:"50"`
+	// Timeout bounds each mirrored request. Defaults to 5s.
+	Timeout time.Duration `json:"timeout" env:"SHADOW_TIMEOUT" default:"5s"`
+	// RedactHeaders are removed from mirrored requests. Defaults to Authorization and Cookie.
+	RedactHeaders []string `json:"redact_headers" env:"SHADOW_REDACT_HEADERS" default:"Authorization,Cookie"`
+	// RedactQueryParams are removed from the query of mirrored requests.
+	RedactQueryParams []string `json:"redact_query_params" env:"SHADOW_REDACT_QUERY_PARAMS"`
+	// RedactFields are JSON body fields, at any depth, whose values are replaced by "[REDACTED]".
+	RedactFields []string `json:"redact_fields" env:"SHADOW_REDACT_FIELDS"`
+	// Transport sends the mirrored requests. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper `json:"-"`
+}
+
+// NewShadowMiddleware asynchronously mirrors a sample of the requests to a secondary endpoint, enabling load
+// tests of new services with real traffic shapes.
+//
+// Parameters:
+//
+//	cfg: Secondary endpoint, sample percentage and redaction rules.
+//
+// Behavior:
+//   - Only GET and HEAD requests are mirrored, unless cfg.Methods lists writes.
+//   - Mirrored requests run in background after the original one is sent; their responses are discarded and
+//     never affect the caller.
+//   - Requests with a body that can't be replayed (no req.GetBody) are not mirrored.
+//   - Redacted headers and query parameters are removed; redacted JSON fields are replaced by "[REDACTED]".
+//   - Mirrored requests don't carry the caller cancellation, and are bounded by cfg.Timeout.
+//   - Requests above MaxConcurrent mirrors in flight are skipped.
+//   - The http.client.shadow.requests counter reports the outcome (sent, skipped, error) and status class.
+//
+// Usage:
+//
+//	client := httpclient.NewHTTPClient(baseURL, 2*time.Second,
+//		httpclient.NewShadowMiddleware(httpclient.ShadowConfig{
+//			Name:    "search-v2",
+//			BaseURL: "https://search-v2.internal",
+//			Percent: 10,
+//		}),
+//	)
+func NewShadowMiddleware(cfg ShadowConfig) func(next http.RoundTripper) http.RoundTripper {
+	target, err := url.Parse(cfg.BaseURL)
+	if err != nil || target.Host == "" {
+		logger.Error().Err(err).Str("name", cfg.Name).Str("base_url", cfg.BaseURL).Msg("Invalid shadow base URL, mirroring disabled")
+		return func(next http.RoundTripper) http.RoundTripper { return next }
+	}
+
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 50
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = []string{http.MethodGet, http.MethodHead}
+	}
+	if cfg.RedactHeaders == nil {
+		cfg.RedactHeaders = []string{"Authorization", "Cookie"}
+	}
+	if cfg.Transport == nil {
+		cfg.Transport = http.DefaultTransport
+	}
+
+	slots := make(chan struct{}, cfg.MaxConcurrent)
+
+	counter, _ := otel.Meter(instrumentationName).Int64Counter("http.client.shadow.requests",
+		metric.WithDescription("Requests mirrored to a shadow endpoint, by outcome."))
+	record := func(outcome, status string) {
+		counter.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String("peer.service", cfg.Name),
+			attribute.String("outcome", outcome),
+			attribute.String("status_class", status),
+		))
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !slices.Contains(cfg.Methods, req.Method) || cfg.Percent <= 0 || rand.Float64()*100 >= cfg.Percent {
+				return next.RoundTrip(req)
+			}
+
+			mirror, err := cfg.mirror(req, target)
+			if err != nil {
+				record("skipped", "")
+				return next.RoundTrip(req)
+			}
+
+			select {
+			case slots <- struct{}{}:
+			default:
+				record("skipped", "")
+				return next.RoundTrip(req)
+			}
+
+			go func() {
+				defer func() { <-slots }()
+
+				ctx, cancel := context.WithTimeout(mirror.Context(), cfg.Timeout)
+				defer cancel()
+
+				resp, err := cfg.Transport.RoundTrip(mirror.WithContext(ctx))
+				if err != nil {
+					record("error", "")
+					return
+				}
+				_, _ = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+
+				record("sent", strconv.Itoa(resp.StatusCode/100)+"xx")
+			}()
+
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// mirror builds the redacted copy of req sent to the shadow endpoint.
+func (cfg ShadowConfig) mirror(req *http.Request, target *url.URL) (*http.Request, error) {
+	mirror := req.Clone(context.WithoutCancel(req.Context()))
+	mirror.URL.Scheme = target.Scheme
+	mirror.URL.Host = target.Host
+	mirror.Host = ""
+	if prefix := strings.TrimSuffix(target.Path, "/"); prefix != "" {
+		mirror.URL.Path = prefix + mirror.URL.Path
+		mirror.URL.RawPath = ""
+	}
+
+	for _, name := range cfg.RedactHeaders {
+		mirror.Header.Del(name)
+	}
+
+	if len(cfg.RedactQueryParams) > 0 {
+		query := mirror.URL.Query()
+		for _, name := range cfg.RedactQueryParams {
+			query.Del(name)
+		}
+		mirror.URL.RawQuery = query.Encode()
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return mirror, nil
+	}
+	if req.GetBody == nil {
+		return nil, errShadowBodyNotReplayable
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.RedactFields) > 0 {
+		var value any
+		if json.Unmarshal(data, &value) == nil {
+			redactFields(value, cfg.RedactFields)
+			if redacted, err := json.Marshal(value); err == nil {
+				data = redacted
+			}
+		}
+	}
+
+	mirror.Body = io.NopCloser(bytes.NewReader(data))
+	mirror.ContentLength = int64(len(data))
+	mirror.GetBody = nil
+
+	return mirror, nil
+}
+
+// redactFields replaces, in place, the values of the named fields at any depth.
+func redactFields(value any, fields []string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for name, child := range v {
+			redacted := false
+			for _, field := range fields {
+				if strings.EqualFold(name, field) {
+					v[name] = "[REDACTED]"
+					redacted = true
+					break
+				}
+			}
+			if !redacted {
+				redactFields(child, fields)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			redactFields(item, fields)
+		}
+	}
+}
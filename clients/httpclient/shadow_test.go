@@ -0,0 +1,58 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShadowMirrorsOnlySafeMethodsByDefault(t *testing.T) {
+	var mu sync.Mutex
+	var mirrored []string
+	shadow := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		mirrored = append(mirrored, r.Method)
+		mu.Unlock()
+	}))
+	defer shadow.Close()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer origin.Close()
+
+	client := NewHTTPClient(origin.URL, 2*time.Second, NewShadowMiddleware(ShadowConfig{
+		BaseURL: shadow.URL,
+		Percent: 100,
+	}))
+
+	ctx := t.Context()
+	if _, err := client.Post(ctx, "/orders", strings.NewReader(`{}`)); err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	if _, err := client.Get(ctx, "/orders"); err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(mirrored)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	// Give a mirrored POST the time to arrive after the GET.
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(mirrored) != 1 || mirrored[0] != http.MethodGet {
+		t.Fatalf("mirrored %v, want only the GET", mirrored)
+	}
+}
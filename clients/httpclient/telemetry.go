@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/devluispereira/go-package/observability"
+	"github.com/devluispereira/go-package/slo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/devluispereira/go-package/clients/httpclient"
+
+// NewTracingMiddleware returns an HTTP middleware that creates a client span for each outgoing request
+// and injects the trace context into the request headers.
+//
+// Parameters:
+//
+//	name: The name of the downstream service (used as span attribute).
+//
+// Returns:
+//
+//	A function that wraps an http.RoundTripper with tracing. Uses the global TracerProvider set by observability.Init.
+func NewTracingMiddleware(name string) func(next http.RoundTripper) http.RoundTripper {
+	tracer := otel.Tracer(instrumentationName)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, span := tracer.Start(req.Context(), req.Method+" "+name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("peer.service", name),
+					attribute.String("http.request.method", req.Method),
+					attribute.String("url.full", req.URL.String()),
+					attribute.String("server.address", req.URL.Host),
+				),
+			)
+			defer span.End()
+
+			req = req.WithContext(ctx)
+			otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetAttributes(attribute.String("error.type", ErrorType(err)))
+				span.SetStatus(codes.Error, err.Error())
+				return resp, err
+			}
+
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+			if resp.StatusCode >= 500 {
+				span.SetStatus(codes.Error, resp.Status)
+			}
+
+			return resp, nil
+		})
+	}
+}
+
+// Metric labels recorded by NewMetricsMiddleware.
+const (
+	LabelPeerService   = "peer.service"
+	LabelMethod        = "http.request.method"
+	LabelServerAddress = "server.address"
+	LabelURLTemplate   = "url.template"
+	LabelStatus        = "http.response.status_code"
+	LabelCache         = "cache"
+	LabelErrorType     = "error.type"
+)
+
+// MetricsConfig holds the configuration of NewMetricsMiddlewareWithConfig. It can be loaded with the config package.
+type MetricsConfig struct {
+	// Labels is the allowlist of recorded labels, among the Label constants. If empty, records all of them.
+	Labels []string `json:"labels" env:"METRICS_LABELS"`
+	// CardinalityLimit is the maximum number of distinct values per label; new values beyond it are recorded as
+	// "other". If zero, uses 100.
+	CardinalityLimit int `json:"cardinality_limit" env:"METRICS_CARDINALITY_LIMIT" default:"100"`
+	// Histogram configures the buckets, or the exponential aggregation, of the duration histogram. If empty, uses
+	// observability.DefaultLatencyBuckets.
+	Histogram observability.HistogramConfig `json:"histogram" envPrefix:"METRICS_"`
+	// SLO receives every request, to track the downstreams with an objective. If nil, uses slo.Default().
+	SLO *slo.Tracker `json:"-"`
+}
+
+type URLTemplateKeyType struct{}
+
+// WithURLTemplate sets the path template ("/products/{id}") recorded in the url.template label of the requests
+// made with ctx, instead of the one derived from the request path.
+func WithURLTemplate(ctx context.Context, template string) context.Context {
+	return context.WithValue(ctx, URLTemplateKeyType{}, template)
+}
+
+// NewMetricsMiddleware returns an HTTP middleware that records the duration of each outgoing request
+// in the "http.client.request.duration" histogram. See NewMetricsMiddlewareWithConfig.
+//
+// Parameters:
+//
+//	name: The name of the downstream service (used as metric label).
+//
+// Returns:
+//
+//	A function that wraps an http.RoundTripper with metrics. Uses the global MeterProvider set by observability.Init.
+//	Requests are also fed to slo.Default(), which tracks the downstreams with an objective (see slo.Tracker).
+func NewMetricsMiddleware(name string) func(next http.RoundTripper) http.RoundTripper {
+	return NewMetricsMiddlewareWithConfig(name, MetricsConfig{})
+}
+
+// NewMetricsMiddlewareWithConfig returns an HTTP middleware that records the duration of each outgoing request
+// in the "http.client.request.duration" histogram, keeping the number of series bounded.
+//
+// Parameters:
+//
+//	name: The name of the downstream service (used as metric label).
+//	cfg: Allowlist of labels, cardinality limit and histogram buckets.
+//
+// Behavior:
+//   - The url.template label is the template set with WithURLTemplate or, without one, the request path with its
+//     identifier-like segments replaced by ":id" (see observability.TemplatePath).
+//   - Non-standard methods are labeled "_OTHER" and label values are sanitized (see observability.SanitizeLabel).
+//   - Each label keeps at most CardinalityLimit distinct values, aggregating the overflow into "other".
+//   - Transport errors are labeled with their category in error.type (see ClassifyError), e.g. "canceled" when
+//     the caller gave up and "dns" or "connect_timeout" when the downstream is unreachable.
+//
+// Returns:
+//
+//	A function that wraps an http.RoundTripper with metrics. Requests are also fed to cfg.SLO.
+func NewMetricsMiddlewareWithConfig(name string, cfg MetricsConfig) func(next http.RoundTripper) http.RoundTripper {
+	duration, _ := observability.LatencyHistogram(instrumentationName, "http.client.request.duration",
+		"Duration of outgoing HTTP requests.", cfg.Histogram)
+
+	allowed := make(map[string]bool, len(cfg.Labels))
+	for _, label := range cfg.Labels {
+		allowed[label] = true
+	}
+	limiter := observability.NewLabelLimiter(cfg.CardinalityLimit)
+	tracker := cfg.SLO
+	if tracker == nil {
+		tracker = slo.Default()
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+
+			template, ok := req.Context().Value(URLTemplateKeyType{}).(string)
+			if !ok {
+				template = observability.TemplatePath(req.URL.Path)
+			}
+
+			labels := [][2]string{
+				{LabelPeerService, name},
+				{LabelMethod, observability.MethodLabel(req.Method)},
+				{LabelServerAddress, req.URL.Host},
+				{LabelURLTemplate, template},
+			}
+			if err != nil {
+				labels = append(labels, [2]string{LabelErrorType, ErrorType(err)})
+			} else {
+				labels = append(labels,
+					[2]string{LabelStatus, strconv.Itoa(resp.StatusCode)},
+					[2]string{LabelCache, resp.Header.Get("X-Cache")},
+				)
+			}
+
+			attrs := make([]attribute.KeyValue, 0, len(labels))
+			for _, label := range labels {
+				if len(allowed) == 0 || allowed[label[0]] {
+					attrs = append(attrs, attribute.String(label[0], limiter.Value(label[0], label[1])))
+				}
+			}
+
+			elapsed := time.Since(start)
+			duration.Record(req.Context(), elapsed.Seconds(), metric.WithAttributes(attrs...))
+			tracker.Observe(name, elapsed, err != nil || resp.StatusCode >= 500)
+			return resp, err
+		})
+	}
+}
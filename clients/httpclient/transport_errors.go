@@ -0,0 +1,139 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"syscall"
+)
+
+// Categories of transport errors. ClassifyError wraps transport errors so they match one of them with errors.Is,
+// keeping the original error in the chain: errors.Is(err, context.Canceled) still holds.
+var (
+	// ErrCanceled means the caller gave up: its context was canceled.
+	ErrCanceled = errors.New("canceled")
+	// ErrDeadlineExceeded means the deadline of the context or the client timeout expired.
+	ErrDeadlineExceeded = errors.New("deadline exceeded")
+	// ErrDNS means the host could not be resolved.
+	ErrDNS = errors.New("dns failure")
+	// ErrConnectTimeout means the connection could not be established in time.
+	ErrConnectTimeout = errors.New("connect timeout")
+	// ErrConnectionRefused means nothing was listening on the downstream address.
+	ErrConnectionRefused = errors.New("connection refused")
+	// ErrTLS means the TLS handshake failed, e.g. an invalid certificate.
+	ErrTLS = errors.New("tls failure")
+	// ErrConnectionReset means the downstream reset or closed the connection.
+	ErrConnectionReset = errors.New("connection reset by peer")
+	// ErrTimeout means a read or write on an established connection timed out.
+	ErrTimeout = errors.New("timeout")
+	// ErrTransport is any other transport failure.
+	ErrTransport = errors.New("transport failure")
+)
+
+// errorTypes are the error.type metric labels of the categories.
+var errorTypes = map[error]string{
+	ErrCanceled:          "canceled",
+	ErrDeadlineExceeded:  "deadline_exceeded",
+	ErrDNS:               "dns",
+	ErrConnectTimeout:    "connect_timeout",
+	ErrConnectionRefused: "connection_refused",
+	ErrTLS:               "tls",
+	ErrConnectionReset:   "connection_reset",
+	ErrTimeout:           "timeout",
+	ErrTransport:         "transport",
+}
+
+// TransportError is a transport error classified into a category (ErrDNS, ErrCanceled, ...).
+type TransportError struct {
+	Category error
+	Err      error
+}
+
+func (e *TransportError) Error() string {
+	return e.Category.Error() + ": " + e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() []error {
+	return []error{e.Category, e.Err}
+}
+
+// Type returns the category as a metric label, e.g. "dns" or "deadline_exceeded".
+func (e *TransportError) Type() string {
+	return errorTypes[e.Category]
+}
+
+// ClassifyError wraps a transport error into a TransportError, so dashboards and callers can separate "caller
+// gave up" (ErrCanceled, ErrDeadlineExceeded) from "downstream is broken" (the other categories).
+//
+// Behavior:
+//   - Returns nil for a nil error, and err itself when it is already classified.
+//   - Context errors win over the network errors they caused: a dial aborted by a canceled context is ErrCanceled.
+//
+// Usage:
+//
+//	_, err := client.Get(ctx, "/products")
+//	switch {
+//	case errors.Is(err, httpclient.ErrCanceled):
+//		// the caller went away, nothing to report
+//	case errors.Is(err, httpclient.ErrDNS), errors.Is(err, httpclient.ErrConnectionRefused):
+//		// the downstream is unreachable
+//	}
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var classified *TransportError
+	if errors.As(err, &classified) {
+		return err
+	}
+
+	return &TransportError{Category: errorCategory(err), Err: err}
+}
+
+// ErrorType returns the error.type metric label of a transport error, classifying it if needed.
+func ErrorType(err error) string {
+	var classified *TransportError
+	if !errors.As(ClassifyError(err), &classified) {
+		return ""
+	}
+	return classified.Type()
+}
+
+func errorCategory(err error) error {
+	var (
+		dnsErr       *net.DNSError
+		opErr        *net.OpError
+		netErr       net.Error
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrDeadlineExceeded
+	case errors.As(err, &dnsErr):
+		return ErrDNS
+	case errors.As(err, &recordErr), errors.As(err, &alertErr), errors.As(err, &verifyErr),
+		errors.As(err, &authorityErr), errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return ErrTLS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrConnectionRefused
+	case errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout():
+		return ErrConnectTimeout
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return ErrConnectionReset
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrTimeout
+	default:
+		return ErrTransport
+	}
+}
@@ -0,0 +1,121 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrDecodeResponse is wrapped by the typed request functions (GetAs, PostAs, ...) when the response body can't be
+// decoded into the requested type.
+var ErrDecodeResponse = errors.New("failed to decode response")
+
+type EnvelopeKeyType struct{}
+
+// WithEnvelope marks the typed requests made with ctx as answered with an Envelope (server.OK, server.Created), so
+// GetAs and the other typed functions decode its data field instead of the whole body.
+func WithEnvelope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, EnvelopeKeyType{}, true)
+}
+
+func expectsEnvelope(ctx context.Context) bool {
+	envelope, _ := ctx.Value(EnvelopeKeyType{}).(bool)
+	return envelope
+}
+
+// GetAs sends a GET request and decodes the JSON response body into a T, sparing callers the type assertions on
+// HTTPResponse.Body.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout.
+//   - client: The client sending the request.
+//   - path: Request path or full URL.
+//
+// Behavior:
+//   - The body is decoded once, straight into T. With a ctx from WithEnvelope, the data field of the Envelope is
+//     decoded instead, and an envelope with errors and no data returns its ResponseErrors.
+//   - An empty body, e.g. of a 204 response, decodes to the zero T.
+//   - The body is decoded whatever the status; check HTTPResponse.StatusCode before using the value of error
+//     responses.
+//   - resp.Body is left nil; resp.Meta and resp.Envelope read the body when called, e.g. to read the pagination of
+//     list responses.
+//
+// Usage:
+//
+//	product, resp, err := httpclient.GetAs[Product](ctx, catalog, "/products/42")
+//	if err != nil {
+//		return err
+//	}
+//	if resp.StatusCode == http.StatusNotFound { ... }
+//
+// Returns:
+//   - T: The decoded body.
+//   - *HTTPResponse: The response, also returned when decoding fails so its status and headers can be inspected.
+//   - error: Any error sending the request, or an error wrapping ErrDecodeResponse and the decoding error.
+func GetAs[T any](ctx context.Context, client *HTTPClient, path string) (T, *HTTPResponse, error) {
+	return requestAs[T](ctx, client, http.MethodGet, path, nil)
+}
+
+// PostAs sends a POST request with a body and decodes the JSON response body into a T. See GetAs.
+//
+// Usage:
+//
+//	order, resp, err := httpclient.PostAs[Order](ctx, orders, "/orders", bytes.NewReader(payload))
+func PostAs[T any](ctx context.Context, client *HTTPClient, path string, body io.Reader) (T, *HTTPResponse, error) {
+	return requestAs[T](ctx, client, http.MethodPost, path, body)
+}
+
+// PutAs sends a PUT request with a body and decodes the JSON response body into a T. See GetAs.
+func PutAs[T any](ctx context.Context, client *HTTPClient, path string, body io.Reader) (T, *HTTPResponse, error) {
+	return requestAs[T](ctx, client, http.MethodPut, path, body)
+}
+
+// PatchAs sends a PATCH request with a body and decodes the JSON response body into a T. See GetAs.
+func PatchAs[T any](ctx context.Context, client *HTTPClient, path string, body io.Reader) (T, *HTTPResponse, error) {
+	return requestAs[T](ctx, client, http.MethodPatch, path, body)
+}
+
+// DeleteAs sends a DELETE request and decodes the JSON response body into a T. See GetAs.
+func DeleteAs[T any](ctx context.Context, client *HTTPClient, path string) (T, *HTTPResponse, error) {
+	return requestAs[T](ctx, client, http.MethodDelete, path, nil)
+}
+
+func requestAs[T any](ctx context.Context, client *HTTPClient, method, path string, body io.Reader) (T, *HTTPResponse, error) {
+	var value T
+
+	resp, err := client.send(ctx, method, path, body)
+	if err != nil {
+		return value, nil, err
+	}
+	if len(resp.raw) == 0 {
+		return value, resp, nil
+	}
+
+	data := resp.raw
+	if expectsEnvelope(ctx) {
+		var envelope Envelope
+		if err := json.Unmarshal(resp.raw, &envelope); err != nil {
+			return value, resp, decodeError(method, path, resp, value, err)
+		}
+		if len(envelope.Errors) > 0 && (len(envelope.Data) == 0 || string(envelope.Data) == "null") {
+			return value, resp, envelope.Errors
+		}
+		if len(envelope.Data) == 0 {
+			return value, resp, nil
+		}
+		data = envelope.Data
+	}
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, resp, decodeError(method, path, resp, value, err)
+	}
+
+	return value, resp, nil
+}
+
+func decodeError(method, path string, resp *HTTPResponse, value any, err error) error {
+	return fmt.Errorf("%w: %s %s (status %d) into %T: %w", ErrDecodeResponse, method, path, resp.StatusCode, value, err)
+}
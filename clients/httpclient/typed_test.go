@@ -0,0 +1,83 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newJSONServer returns a client for a server answering every request with body.
+func newJSONServer(t *testing.T, body string) *HTTPClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewHTTPClient(server.URL, 2*time.Second)
+}
+
+type graphQLResponse struct {
+	Data struct {
+		Product struct {
+			ID string `json:"id"`
+		} `json:"product"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func TestGetAsDecodesWholeBodyByDefault(t *testing.T) {
+	client := newJSONServer(t, `{"data":{"product":{"id":"42"}},"errors":[{"message":"partial"}]}`)
+
+	value, resp, err := GetAs[graphQLResponse](context.Background(), client, "/graphql")
+	if err != nil {
+		t.Fatalf("GetAs: %v", err)
+	}
+	if value.Data.Product.ID != "42" || len(value.Errors) != 1 || value.Errors[0].Message != "partial" {
+		t.Fatalf("got %+v, want the whole body", value)
+	}
+	if resp.Body != nil {
+		t.Fatalf("Body %v, want nil for typed calls", resp.Body)
+	}
+}
+
+func TestGetAsWithEnvelope(t *testing.T) {
+	client := newJSONServer(t, `{"data":{"id":"42"},"meta":{"request_id":"abc"}}`)
+
+	value, resp, err := GetAs[struct {
+		ID string `json:"id"`
+	}](WithEnvelope(context.Background()), client, "/products/42")
+	if err != nil {
+		t.Fatalf("GetAs: %v", err)
+	}
+	if value.ID != "42" {
+		t.Fatalf("got %+v, want the data field", value)
+	}
+	if meta := resp.Meta(); meta == nil || meta.RequestID != "abc" {
+		t.Fatalf("Meta() = %+v, want request_id abc", meta)
+	}
+}
+
+func TestGetAsWithEnvelopeReturnsErrors(t *testing.T) {
+	client := newJSONServer(t, `{"errors":[{"code":"not_found"}]}`)
+
+	_, _, err := GetAs[map[string]any](WithEnvelope(context.Background()), client, "/products/42")
+	var respErrs ResponseErrors
+	if !errors.As(err, &respErrs) || respErrs[0].Code != "not_found" {
+		t.Fatalf("got %v, want ResponseErrors", err)
+	}
+}
+
+func TestGetAsInvalidBody(t *testing.T) {
+	client := newJSONServer(t, `<html>Not Found</html>`)
+
+	if _, resp, err := GetAs[map[string]any](context.Background(), client, "/"); !errors.Is(err, ErrDecodeResponse) || resp == nil {
+		t.Fatalf("got %v, %v, want ErrDecodeResponse with the response", resp, err)
+	}
+}
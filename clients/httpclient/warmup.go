@@ -0,0 +1,147 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// WarmUpConfig holds the connection warm-up settings. It can be loaded with the config package.
+type WarmUpConfig struct {
+	// Connections is the number of connections opened to the base URL. 0 disables the warm-up.
+	Connections int `json:"connections" env:"WARMUP_CONNECTIONS"`
+	// Path is requested with HEAD on each connection. Defaults to "/"; any response status counts as warm.
+	Path string `json:"path" env:"WARMUP_PATH" default:"/"`
+	// Timeout bounds the whole warm-up. Defaults to 5s.
+	Timeout time.Duration `json:"timeout" env:"WARMUP_TIMEOUT" default:"5s"`
+}
+
+// WarmUp resolves the base URL host and opens connections to it, completing the TLS handshakes, so the first
+// requests after a deploy don't pay for them.
+//
+// Parameters:
+//
+//	ctx: Context for cancellation.
+//	cfg: Number of connections, path and timeout.
+//
+// Behavior:
+//   - Sends cfg.Connections concurrent HEAD requests straight to the transport, skipping the middlewares
+//     (no cache, metrics, retries or breaker counts).
+//   - The connections stay idle in the transport pool, up to its MaxIdleConnsPerHost (2 for http.DefaultTransport;
+//     NewResilientJSONClient and ClientRegistry use a pool that keeps 50 per host).
+//   - Over HTTP/2 the requests are multiplexed on a single connection, which is what needs warming.
+//
+// Usage:
+//
+//	srv.OnStart(func(ctx context.Context) error {
+//		return catalog.WarmUp(ctx, httpclient.WarmUpConfig{Connections: 4, Path: "/healthcheck"})
+//	})
+//
+// Returns:
+//   - error: The DNS resolution error, or the errors of the requests that failed.
+func (c *HTTPClient) WarmUp(ctx context.Context, cfg WarmUpConfig) error {
+	if cfg.Connections <= 0 {
+		return nil
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+
+	base, err := url.Parse(c.baseURL)
+	if err != nil || base.Host == "" {
+		return fmt.Errorf("warm up: invalid base url %q", c.baseURL)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	if _, err := net.DefaultResolver.LookupHost(ctx, base.Hostname()); err != nil {
+		return fmt.Errorf("warm up %s: %w", base.Host, err)
+	}
+
+	transport := c.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for range cfg.Connections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.resolveURL(cfg.Path), nil)
+			if err == nil {
+				var resp *http.Response
+				if resp, err = transport.RoundTrip(req); err == nil {
+					_, _ = io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+				}
+			}
+
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	logger.Info().
+		Str("host", base.Host).
+		Int("connections", cfg.Connections-len(errs)).
+		Int("failed", len(errs)).
+		Int64("duration_ms", time.Since(start).Milliseconds()).
+		Msg("Client connections warmed up")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("warm up %s: %w", base.Host, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// WarmUp builds every declared client and warms up the ones with WarmUp.Connections set. It can be registered
+// as a server start hook.
+//
+// Usage:
+//
+//	srv.OnStart(registry.WarmUp)
+func (r *ClientRegistry) WarmUp(ctx context.Context) error {
+	var errs []error
+
+	for _, name := range r.Names() {
+		client, err := r.Client(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		r.mu.Lock()
+		cfg := r.configs[name].WarmUp
+		r.mu.Unlock()
+
+		if err := client.WarmUp(ctx, cfg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,197 @@
+package kafkaclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Handler processes a consumed message. The context carries the forwarded headers and trace of the producer.
+type Handler func(ctx context.Context, record *kgo.Record) error
+
+// ConsumerConfig holds the consumer group configuration. It can be loaded with the config package.
+type ConsumerConfig struct {
+	Brokers []string `json:"brokers" env:"KAFKA_BROKERS"`
+	Group   string   `json:"group" env:"KAFKA_CONSUMER_GROUP"`
+	Topics  []string `json:"topics" env:"KAFKA_CONSUMER_TOPICS"`
+	// MaxRetries is the number of retries per message before sending it to the DLQ. Defaults to 3.
+	MaxRetries int `json:"max_retries" env:"KAFKA_CONSUMER_MAX_RETRIES" default:"3"`
+	// RetryBackoff is the initial delay between retries, doubled at each attempt. Defaults to 200ms.
+	RetryBackoff time.Duration `json:"retry_backoff" env:"KAFKA_CONSUMER_RETRY_BACKOFF" default:"200ms"`
+	// DLQSuffix is appended to the topic name to build the dead-letter topic. Empty disables the DLQ.
+	DLQSuffix string `json:"dlq_suffix" env:"KAFKA_CONSUMER_DLQ_SUFFIX" default:".dlq"`
+	// ForwardHeaders are the record headers forwarded to the HTTP calls of the handler. If empty, uses
+	// server.DefaultForwardHeaders.
+	ForwardHeaders []string `json:"forward_headers" env:"KAFKA_CONSUMER_FORWARD_HEADERS"`
+}
+
+// Consumer wraps a consumer group, committing offsets only after each message is handled or dead-lettered.
+// Messages interrupted by the shutdown are neither dead-lettered nor committed, so they are consumed again.
+type Consumer struct {
+	cfg      ConsumerConfig
+	client   *kgo.Client
+	handler  Handler
+	tracer   trace.Tracer
+	consumed metric.Int64Counter
+}
+
+// NewConsumer creates a consumer group member for the configured topics.
+//
+// Usage:
+//
+//	consumer, err := kafkaclient.NewConsumer(kafkaclient.ConsumerConfig{
+//		Brokers: []string{"localhost:9092"},
+//		Group:   "billing",
+//		Topics:  []string{"orders"},
+//	}, handleOrder)
+//	srv.OnStart(func(ctx context.Context) error { go consumer.Run(context.Background()); return nil })
+//	srv.OnStop(consumer.Close)
+func NewConsumer(cfg ConsumerConfig, handler Handler) (*Consumer, error) {
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 200 * time.Millisecond
+	}
+
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(cfg.Brokers...),
+		kgo.ConsumerGroup(cfg.Group),
+		kgo.ConsumeTopics(cfg.Topics...),
+		kgo.DisableAutoCommit(),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka consumer: %w", err)
+	}
+
+	consumed, _ := otel.Meter(instrumentationName).Int64Counter("messaging.client.consumed.messages", metric.WithDescription("Messages consumed by outcome."))
+
+	return &Consumer{
+		cfg:      cfg,
+		client:   client,
+		handler:  handler,
+		tracer:   otel.Tracer(instrumentationName),
+		consumed: consumed,
+	}, nil
+}
+
+// Run polls and handles messages until ctx is done or the consumer is closed.
+func (c *Consumer) Run(ctx context.Context) error {
+	for {
+		fetches := c.client.PollFetches(ctx)
+		if fetches.IsClientClosed() || ctx.Err() != nil {
+			return nil
+		}
+
+		fetches.EachError(func(topic string, partition int32, err error) {
+			logger.Error().Err(err).Str("topic", topic).Int32("partition", partition).Msg("kafka:fetch failed")
+		})
+
+		var handled []*kgo.Record
+		fetches.EachRecord(func(record *kgo.Record) {
+			if ctx.Err() != nil {
+				return
+			}
+			if c.handle(ctx, record) {
+				handled = append(handled, record)
+			}
+		})
+
+		if len(handled) > 0 {
+			if err := c.client.CommitRecords(context.WithoutCancel(ctx), handled...); err != nil {
+				logger.Error().Err(err).Msg("kafka:commit failed")
+			}
+		}
+	}
+}
+
+// handle processes record, retrying and dead-lettering it on failure. It returns false when ctx is done before the
+// message is handled, so its offset is not committed.
+func (c *Consumer) handle(parent context.Context, record *kgo.Record) bool {
+	ctx := extractHeaders(parent, record, c.cfg.ForwardHeaders)
+	ctx, span := c.tracer.Start(ctx, "process "+record.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", record.Topic),
+			attribute.String("messaging.consumer.group.name", c.cfg.Group),
+		),
+	)
+	defer span.End()
+
+	err := c.handler(ctx, record)
+	backoff := c.cfg.RetryBackoff
+
+retries:
+	for attempt := 1; err != nil && attempt <= c.cfg.MaxRetries; attempt++ {
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			break retries
+		}
+
+		err = c.handler(ctx, record)
+	}
+
+	if err != nil && parent.Err() != nil {
+		span.SetStatus(codes.Error, "interrupted by shutdown")
+		c.consumed.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("topic", record.Topic),
+			attribute.String("outcome", "interrupted"),
+		))
+		return false
+	}
+
+	outcome := "ok"
+	if err != nil {
+		outcome = "dead_lettered"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		if dlqErr := c.deadLetter(ctx, record, err); dlqErr != nil {
+			outcome = "dropped"
+			logger.Error().Err(dlqErr).Str("topic", record.Topic).Msg("kafka:dead-letter failed")
+		}
+	}
+
+	c.consumed.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("topic", record.Topic),
+		attribute.String("outcome", outcome),
+	))
+	return true
+}
+
+func (c *Consumer) deadLetter(ctx context.Context, record *kgo.Record, cause error) error {
+	if c.cfg.DLQSuffix == "" {
+		return fmt.Errorf("message dropped after retries: %w", cause)
+	}
+
+	dlq := &kgo.Record{
+		Topic: record.Topic + c.cfg.DLQSuffix,
+		Key:   record.Key,
+		Value: record.Value,
+		Headers: append(withoutDLQHeaders(record.Headers),
+			kgo.RecordHeader{Key: "x-dlq-error", Value: []byte(cause.Error())},
+			kgo.RecordHeader{Key: "x-dlq-original-topic", Value: []byte(record.Topic)},
+			kgo.RecordHeader{Key: "x-dlq-original-offset", Value: []byte(strconv.FormatInt(record.Offset, 10))},
+		),
+	}
+
+	return c.client.ProduceSync(context.WithoutCancel(ctx), dlq).FirstErr()
+}
+
+// Close leaves the consumer group and closes the client. Its signature matches server.Hook.
+func (c *Consumer) Close(_ context.Context) error {
+	c.client.Close()
+	return nil
+}
@@ -0,0 +1,86 @@
+package kafkaclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel"
+)
+
+// newTestConsumer returns a consumer without a Kafka client: dead-lettering a message panics.
+func newTestConsumer(handler Handler) *Consumer {
+	consumed, _ := otel.Meter(instrumentationName).Int64Counter("test.consumed")
+	return &Consumer{
+		cfg:      ConsumerConfig{MaxRetries: 3, RetryBackoff: time.Hour, DLQSuffix: ".dlq"},
+		handler:  handler,
+		tracer:   otel.Tracer(instrumentationName),
+		consumed: consumed,
+	}
+}
+
+func TestHandleInterruptedByShutdownIsNotCommitted(t *testing.T) {
+	consumer := newTestConsumer(func(context.Context, *kgo.Record) error {
+		return errors.New("downstream unavailable")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	done := make(chan bool, 1)
+	go func() { done <- consumer.handle(ctx, &kgo.Record{Topic: "orders"}) }()
+
+	select {
+	case handled := <-done:
+		if handled {
+			t.Fatal("message interrupted during the retry backoff reported as handled")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("handle did not return on shutdown")
+	}
+}
+
+func TestExtractHeadersDropsDLQHeaders(t *testing.T) {
+	record := &kgo.Record{Headers: []kgo.RecordHeader{
+		{Key: "x-request-id", Value: []byte("abc")},
+		{Key: "x-dlq-error", Value: []byte("boom")},
+		{Key: "X-DLQ-Original-Topic", Value: []byte("orders")},
+	}}
+
+	forward := []string{"x-request-id", "x-dlq-error"}
+	headers := extractHeaders(context.Background(), record, forward).Value("forwardedHeaders").(map[string]string)
+	if len(headers) != 1 || headers["x-request-id"] != "abc" {
+		t.Fatalf("forwarded headers = %v, want only x-request-id", headers)
+	}
+}
+
+func TestExtractHeadersForwardsOnlyAllowedHeaders(t *testing.T) {
+	record := &kgo.Record{Headers: []kgo.RecordHeader{
+		{Key: "X-Tenant-Id", Value: []byte("acme")},
+		{Key: "Authorization", Value: []byte("Bearer stolen")},
+		{Key: "x-internal-flag", Value: []byte("admin")},
+		{Key: "traceparent", Value: []byte("not-a-traceparent")},
+	}}
+
+	headers := extractHeaders(context.Background(), record, nil).Value("forwardedHeaders").(map[string]string)
+	if len(headers) != 1 || headers["x-tenant-id"] != "acme" {
+		t.Fatalf("forwarded headers = %v, want only x-tenant-id", headers)
+	}
+
+	headers = extractHeaders(context.Background(), record, []string{"x-internal-flag"}).Value("forwardedHeaders").(map[string]string)
+	if len(headers) != 1 || headers["x-internal-flag"] != "admin" {
+		t.Fatalf("forwarded headers = %v, want only x-internal-flag", headers)
+	}
+}
+
+func TestWithoutDLQHeaders(t *testing.T) {
+	headers := withoutDLQHeaders([]kgo.RecordHeader{
+		{Key: "x-dlq-error", Value: []byte("first failure")},
+		{Key: "x-tenant-id", Value: []byte("acme")},
+	})
+	if len(headers) != 1 || headers[0].Key != "x-tenant-id" {
+		t.Fatalf("headers = %v, want only x-tenant-id", headers)
+	}
+}
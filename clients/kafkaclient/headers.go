@@ -0,0 +1,92 @@
+package kafkaclient
+
+import (
+	"context"
+	"strings"
+
+	"github.com/devluispereira/go-package/server"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel"
+)
+
+// recordCarrier adapts Kafka record headers to an OpenTelemetry TextMapCarrier.
+type recordCarrier struct {
+	record *kgo.Record
+}
+
+func (c recordCarrier) Get(key string) string {
+	for _, h := range c.record.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c recordCarrier) Set(key, value string) {
+	for i, h := range c.record.Headers {
+		if h.Key == key {
+			c.record.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.record.Headers = append(c.record.Headers, kgo.RecordHeader{Key: key, Value: []byte(value)})
+}
+
+func (c recordCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.record.Headers))
+	for _, h := range c.record.Headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
+
+// injectHeaders copies the forwarded headers and the trace context of ctx into the record headers.
+func injectHeaders(ctx context.Context, record *kgo.Record) {
+	carrier := recordCarrier{record: record}
+
+	if headers, ok := ctx.Value("forwardedHeaders").(map[string]string); ok {
+		for k, v := range headers {
+			carrier.Set(k, v)
+		}
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// dlqHeaderPrefix prefixes the headers describing why a message was dead-lettered.
+const dlqHeaderPrefix = "x-dlq-"
+
+func isDLQHeader(key string) bool {
+	return strings.HasPrefix(strings.ToLower(key), dlqHeaderPrefix)
+}
+
+// withoutDLQHeaders returns a copy of headers without the dead-letter headers, so a replayed message that fails
+// again carries only the headers of its last failure.
+func withoutDLQHeaders(headers []kgo.RecordHeader) []kgo.RecordHeader {
+	kept := make([]kgo.RecordHeader, 0, len(headers))
+	for _, h := range headers {
+		if !isDLQHeader(h.Key) {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
+
+// extractHeaders returns a context carrying the record headers listed in forward (or server.DefaultForwardHeaders
+// when empty) as forwarded headers and its trace context, so httpclient calls made by the handler propagate them
+// downstream. Other headers are not forwarded, so a producer cannot set arbitrary headers (e.g. Authorization) on
+// those calls; dead-letter headers are never forwarded.
+func extractHeaders(ctx context.Context, record *kgo.Record, forward []string) context.Context {
+	headers := server.CollectForwardedHeaders(forward, func(name string) string {
+		for _, h := range record.Headers {
+			if strings.EqualFold(h.Key, name) && !isDLQHeader(h.Key) {
+				return string(h.Value)
+			}
+		}
+		return ""
+	})
+
+	ctx = context.WithValue(ctx, "forwardedHeaders", headers)
+	return otel.GetTextMapPropagator().Extract(ctx, recordCarrier{record: record})
+}
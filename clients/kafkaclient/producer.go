@@ -0,0 +1,134 @@
+package kafkaclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/devluispereira/go-package/logging"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/devluispereira/go-package/clients/kafkaclient"
+
+var logger = logging.New("kafka-client")
+
+// ProducerConfig holds the producer configuration. It can be loaded with the config package.
+type ProducerConfig struct {
+	Brokers  []string `json:"brokers" env:"KAFKA_BROKERS"`
+	ClientID string   `json:"client_id" env:"KAFKA_CLIENT_ID"`
+	// Linger is how long records wait to be batched together. Defaults to 5ms.
+	Linger time.Duration `json:"linger" env:"KAFKA_PRODUCER_LINGER" default:"5ms"`
+	// MaxBatchBytes limits the size of each batch. Defaults to 1MB.
+	MaxBatchBytes int32 `json:"max_batch_bytes" env:"KAFKA_PRODUCER_MAX_BATCH_BYTES" default:"1000000"`
+}
+
+// Producer publishes messages with idempotent, batched writes.
+type Producer struct {
+	client   *kgo.Client
+	tracer   trace.Tracer
+	produced metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+// NewProducer creates an idempotent Producer (acks from all in-sync replicas).
+//
+// Usage:
+//
+//	producer, err := kafkaclient.NewProducer(kafkaclient.ProducerConfig{Brokers: []string{"localhost:9092"}})
+//	srv.OnStop(producer.Close)
+//
+//	err = producer.Publish(c.UserContext(), "orders", []byte(order.ID), payload)
+func NewProducer(cfg ProducerConfig) (*Producer, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(cfg.Brokers...),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+	}
+	if cfg.ClientID != "" {
+		opts = append(opts, kgo.ClientID(cfg.ClientID))
+	}
+	if cfg.Linger > 0 {
+		opts = append(opts, kgo.ProducerLinger(cfg.Linger))
+	}
+	if cfg.MaxBatchBytes > 0 {
+		opts = append(opts, kgo.ProducerBatchMaxBytes(cfg.MaxBatchBytes))
+	}
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka producer: %w", err)
+	}
+
+	meter := otel.Meter(instrumentationName)
+	produced, _ := meter.Int64Counter("messaging.client.sent.messages", metric.WithDescription("Messages published."))
+	duration, _ := meter.Float64Histogram("messaging.client.operation.duration", metric.WithUnit("s"), metric.WithDescription("Duration of publish operations."))
+
+	return &Producer{
+		client:   client,
+		tracer:   otel.Tracer(instrumentationName),
+		produced: produced,
+		duration: duration,
+	}, nil
+}
+
+// Publish sends a message and waits for the broker acknowledgement.
+// Forwarded headers and the trace context of ctx are propagated as message headers.
+func (p *Producer) Publish(ctx context.Context, topic string, key, value []byte, headers ...kgo.RecordHeader) error {
+	record := &kgo.Record{Topic: topic, Key: key, Value: value, Headers: headers}
+	return p.PublishRecords(ctx, record)
+}
+
+// PublishRecords sends a batch of records and waits for all acknowledgements.
+func (p *Producer) PublishRecords(ctx context.Context, records ...*kgo.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	ctx, span := p.tracer.Start(ctx, "publish "+records[0].Topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", records[0].Topic),
+			attribute.Int("messaging.batch.message_count", len(records)),
+		),
+	)
+	defer span.End()
+
+	for _, record := range records {
+		injectHeaders(ctx, record)
+	}
+
+	start := time.Now()
+	err := p.client.ProduceSync(ctx, records...).FirstErr()
+	elapsed := time.Since(start)
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		logging.FromContext(ctx).Error().Err(err).Str("layer", "kafka-client").Str("topic", records[0].Topic).Msg("kafka:publish failed")
+	}
+
+	attrs := metric.WithAttributes(attribute.String("topic", records[0].Topic), attribute.String("status", status))
+	p.produced.Add(ctx, int64(len(records)), attrs)
+	p.duration.Record(ctx, elapsed.Seconds(), attrs)
+
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", records[0].Topic, err)
+	}
+
+	return nil
+}
+
+// Close flushes pending records and closes the producer. Its signature matches server.Hook.
+func (p *Producer) Close(ctx context.Context) error {
+	err := p.client.Flush(ctx)
+	p.client.Close()
+	return err
+}
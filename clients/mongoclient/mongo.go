@@ -0,0 +1,229 @@
+package mongoclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/devluispereira/go-package/health"
+	"github.com/devluispereira/go-package/logging"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+)
+
+var logger = logging.New("mongo-client")
+
+// Config holds the MongoDB client configuration. It can be loaded with the config package.
+type Config struct {
+	// Name identifies the client in logs, metrics and the health check ("mongo:<name>"). Defaults to Database.
+	Name     string `json:"name" env:"MONGO_NAME"`
+	URL      string `json:"url" env:"MONGO_URL"`
+	Database string `json:"database" env:"MONGO_DATABASE"`
+
+	MaxPoolSize    uint64        `json:"max_pool_size" env:"MONGO_MAX_POOL_SIZE" default:"100"`
+	MinPoolSize    uint64        `json:"min_pool_size" env:"MONGO_MIN_POOL_SIZE"`
+	ConnectTimeout time.Duration `json:"connect_timeout" env:"MONGO_CONNECT_TIMEOUT" default:"10s"`
+
+	// OperationTimeout bounds every helper call whose context has no earlier deadline. Defaults to 5s.
+	OperationTimeout time.Duration `json:"operation_timeout" env:"MONGO_OPERATION_TIMEOUT" default:"5s"`
+	// SlowOperationThreshold logs commands slower than this at WARN level. Zero disables slow command logs.
+	SlowOperationThreshold time.Duration `json:"slow_operation_threshold" env:"MONGO_SLOW_OPERATION_THRESHOLD" default:"200ms"`
+}
+
+// MongoClient wraps a mongo.Client bound to a database, with deadlines, logs, metrics and traces.
+type MongoClient struct {
+	client   *mongo.Client
+	database *mongo.Database
+	cfg      Config
+}
+
+// NewMongoClient connects to MongoDB and registers the "mongo:<name>" health checker.
+//
+// Parameters:
+//
+//	cfg: Client configuration. URL and Database are required.
+//
+// Behavior:
+//   - The connection is verified with a ping bounded by ConnectTimeout.
+//   - Every command is traced, measured (db.client.operation.duration) and logged with the request context.
+//   - The helpers (FindOne, Find, InsertOne, ...) run with the OperationTimeout deadline unless ctx has an earlier one.
+//
+// Usage:
+//
+//	mongo, err := mongoclient.NewMongoClient(mongoclient.Config{URL: "mongodb://localhost:27017", Database: "catalog"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	srv.OnStop(mongo.Close)
+func NewMongoClient(cfg Config) (*MongoClient, error) {
+	if cfg.Name == "" {
+		cfg.Name = cfg.Database
+	}
+	if cfg.ConnectTimeout <= 0 {
+		cfg.ConnectTimeout = 10 * time.Second
+	}
+	if cfg.OperationTimeout <= 0 {
+		cfg.OperationTimeout = 5 * time.Second
+	}
+
+	opts := options.Client().
+		ApplyURI(cfg.URL).
+		SetConnectTimeout(cfg.ConnectTimeout).
+		SetMonitor(newCommandMonitor(cfg))
+	if cfg.MaxPoolSize > 0 {
+		opts.SetMaxPoolSize(cfg.MaxPoolSize)
+	}
+	if cfg.MinPoolSize > 0 {
+		opts.SetMinPoolSize(cfg.MinPoolSize)
+	}
+
+	client, err := mongo.Connect(opts)
+	if err != nil {
+		logger.Error().Err(err).Str("db", cfg.Name).Msg("failed to create mongo client")
+		return nil, fmt.Errorf("failed to create mongo client: %w", err)
+	}
+
+	m := &MongoClient{client: client, database: client.Database(cfg.Database), cfg: cfg}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+
+	if err := m.Ping(ctx); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, fmt.Errorf("failed to connect to mongo: %w", err)
+	}
+
+	health.Register("mongo:"+cfg.Name, health.CheckerFunc(m.Ping))
+
+	return m, nil
+}
+
+// Ping verifies the connection to the primary.
+func (m *MongoClient) Ping(ctx context.Context) error {
+	return m.client.Ping(ctx, readpref.Primary())
+}
+
+// Collection returns a handle to the named collection of the configured database.
+func (m *MongoClient) Collection(name string) *mongo.Collection {
+	return m.database.Collection(name)
+}
+
+// Database returns the configured database.
+func (m *MongoClient) Database() *mongo.Database {
+	return m.database
+}
+
+// Client returns the underlying mongo.Client, for operations not covered by the helpers.
+func (m *MongoClient) Client() *mongo.Client {
+	return m.client
+}
+
+// InsertOne inserts a document and returns its _id.
+func (m *MongoClient) InsertOne(ctx context.Context, collection string, document any) (any, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	result, err := m.Collection(collection).InsertOne(ctx, document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert into %s: %w", collection, err)
+	}
+
+	return result.InsertedID, nil
+}
+
+// UpdateOne applies update to the first document matching filter. With upsert, the document is created when missing.
+func (m *MongoClient) UpdateOne(ctx context.Context, collection string, filter, update any, upsert bool) (*mongo.UpdateResult, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	result, err := m.Collection(collection).UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(upsert))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", collection, err)
+	}
+
+	return result, nil
+}
+
+// DeleteOne deletes the first document matching filter, reporting whether a document was deleted.
+func (m *MongoClient) DeleteOne(ctx context.Context, collection string, filter any) (bool, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	result, err := m.Collection(collection).DeleteOne(ctx, filter)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete from %s: %w", collection, err)
+	}
+
+	return result.DeletedCount > 0, nil
+}
+
+// Count returns the number of documents matching filter.
+func (m *MongoClient) Count(ctx context.Context, collection string, filter any) (int64, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	count, err := m.Collection(collection).CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", collection, err)
+	}
+
+	return count, nil
+}
+
+// FindOne decodes the first document matching filter into a T. It returns mongo.ErrNoDocuments when nothing matches.
+//
+// Usage:
+//
+//	product, err := mongoclient.FindOne[Product](ctx, mongo, "products", bson.M{"_id": id})
+//	if errors.Is(err, mongo.ErrNoDocuments) {
+//		return fiber.ErrNotFound
+//	}
+func FindOne[T any](ctx context.Context, m *MongoClient, collection string, filter any) (*T, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var result T
+	err := m.Collection(collection).FindOne(ctx, filter).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find in %s: %w", collection, err)
+	}
+
+	return &result, nil
+}
+
+// Find decodes every document matching filter into a []T. Use options.Find() to set limit, sort and projection.
+func Find[T any](ctx context.Context, m *MongoClient, collection string, filter any, opts ...options.Lister[options.FindOptions]) ([]T, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	cursor, err := m.Collection(collection).Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find in %s: %w", collection, err)
+	}
+
+	var results []T
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", collection, err)
+	}
+
+	return results, nil
+}
+
+// Close unregisters the health checker and disconnects the client.
+func (m *MongoClient) Close(ctx context.Context) error {
+	health.Default.Unregister("mongo:" + m.cfg.Name)
+	return m.client.Disconnect(ctx)
+}
+
+func (m *MongoClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= m.cfg.OperationTimeout {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, m.cfg.OperationTimeout)
+}
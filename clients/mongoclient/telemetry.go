@@ -0,0 +1,82 @@
+package mongoclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/devluispereira/go-package/logging"
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/devluispereira/go-package/clients/mongoclient"
+
+// newCommandMonitor returns a command monitor that creates spans, records the duration of every command and
+// logs failed and slow commands, using the global providers set by observability.Init.
+func newCommandMonitor(cfg Config) *event.CommandMonitor {
+	tracer := otel.Tracer(instrumentationName)
+	duration, _ := otel.Meter(instrumentationName).Float64Histogram(
+		"db.client.operation.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of MongoDB commands."),
+	)
+
+	// spans are keyed by request ID, since the finished events don't carry the started context.
+	var spans sync.Map
+
+	finish := func(ctx context.Context, e event.CommandFinishedEvent, err error) {
+		status := "ok"
+		if err != nil {
+			status = "error"
+		}
+
+		duration.Record(ctx, e.Duration.Seconds(), metric.WithAttributes(
+			attribute.String("db.namespace", cfg.Name),
+			attribute.String("db.operation.name", e.CommandName),
+			attribute.String("status", status),
+		))
+
+		if value, ok := spans.LoadAndDelete(e.RequestID); ok {
+			span := value.(trace.Span)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}
+
+		log := logging.FromContextFor(ctx, "mongo-client").With().Str("db", cfg.Name).Logger()
+		switch {
+		case err != nil:
+			log.Error().Err(err).Str("operation", e.CommandName).
+				Int64("duration_ms", e.Duration.Milliseconds()).Msg("mongo command failed")
+		case cfg.SlowOperationThreshold > 0 && e.Duration >= cfg.SlowOperationThreshold:
+			log.Warn().Str("operation", e.CommandName).
+				Int64("duration_ms", e.Duration.Milliseconds()).Msg("slow mongo command")
+		}
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			_, span := tracer.Start(ctx, "mongo."+e.CommandName,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("db.system", "mongodb"),
+					attribute.String("db.namespace", e.DatabaseName),
+					attribute.String("db.operation.name", e.CommandName),
+				),
+			)
+			spans.Store(e.RequestID, span)
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			finish(ctx, e.CommandFinishedEvent, nil)
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			finish(ctx, e.CommandFinishedEvent, e.Failure)
+		},
+	}
+}
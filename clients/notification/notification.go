@@ -0,0 +1,353 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/devluispereira/go-package/logging"
+	"github.com/devluispereira/go-package/workers"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "github.com/devluispereira/go-package/clients/notification"
+
+var logger = logging.New("notification")
+
+var (
+	// ErrSuppressed is returned when every recipient of a message is in the suppression list.
+	ErrSuppressed = errors.New("all recipients are suppressed")
+	// ErrNoRecipients is returned for messages without recipients.
+	ErrNoRecipients = errors.New("message has no recipients")
+)
+
+// Message is a transactional message. Either set Subject, Text and HTML directly or set Template and Data to render
+// them with the Templates of the Sender.
+type Message struct {
+	// From defaults to Config.From.
+	From string `json:"from,omitempty"`
+	// To lists the recipients: email addresses for the SMTP and SES providers, any address the webhook receiver
+	// understands (phone numbers, device tokens) for the webhook provider.
+	To      []string          `json:"to"`
+	ReplyTo string            `json:"reply_to,omitempty"`
+	Subject string            `json:"subject,omitempty"`
+	Text    string            `json:"text,omitempty"`
+	HTML    string            `json:"html,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Template is the name of the template rendering Subject, Text and HTML.
+	Template string `json:"template,omitempty"`
+	// Data is the template data.
+	Data any `json:"-"`
+	// Tags are forwarded to providers that support them, e.g. for analytics.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// Provider delivers messages. SMTPProvider, SESProvider and WebhookProvider are the built-in implementations.
+type Provider interface {
+	// Name identifies the provider in logs and metrics, e.g. "smtp".
+	Name() string
+	// Send delivers a rendered message. Errors matching IsPermanent are not retried.
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Error is an error answered by a provider.
+type Error struct {
+	Provider string
+	// Code is the SMTP reply code or HTTP status code.
+	Code    int
+	Message string
+	// Permanent reports that retrying will not help, e.g. a rejected recipient or invalid credentials.
+	Permanent bool
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s error %d: %s", e.Provider, e.Code, e.Message)
+}
+
+// IsPermanent reports whether err is a permanent provider failure, which the Sender does not retry.
+func IsPermanent(err error) bool {
+	var providerErr *Error
+	return errors.As(err, &providerErr) && providerErr.Permanent
+}
+
+// Config holds the configuration of a Sender. It can be loaded with the config package.
+type Config struct {
+	// Name identifies the sender in logs and metrics. Defaults to the provider name.
+	Name string `json:"name" env:"NOTIFICATION_NAME"`
+	// From is the default sender address, e.g. "Shop <no-reply@shop.com>".
+	From string `json:"from" env:"NOTIFICATION_FROM"`
+	// Workers is the number of messages sent concurrently. Defaults to 4.
+	Workers int `json:"workers" env:"NOTIFICATION_WORKERS" default:"4"`
+	// QueueSize is the number of messages waiting for a worker before Send blocks. Defaults to 1000.
+	QueueSize int `json:"queue_size" env:"NOTIFICATION_QUEUE_SIZE" default:"1000"`
+	// MaxAttempts is the number of attempts per message, including the first one. Defaults to 5.
+	MaxAttempts int `json:"max_attempts" env:"NOTIFICATION_MAX_ATTEMPTS" default:"5"`
+	// InitialBackoff and MaxBackoff bound the jittered exponential backoff between attempts.
+	InitialBackoff time.Duration `json:"initial_backoff" env:"NOTIFICATION_INITIAL_BACKOFF" default:"1s"`
+	MaxBackoff     time.Duration `json:"max_backoff" env:"NOTIFICATION_MAX_BACKOFF" default:"1m"`
+	// AttemptTimeout bounds each attempt. Defaults to 30s.
+	AttemptTimeout time.Duration `json:"attempt_timeout" env:"NOTIFICATION_ATTEMPT_TIMEOUT" default:"30s"`
+
+	// Provider delivers the messages. Required.
+	Provider Provider `json:"-"`
+	// Templates renders messages with a Template. Optional.
+	Templates *Templates `json:"-"`
+	// Suppressions filters out recipients that bounced, complained or unsubscribed. Optional.
+	Suppressions SuppressionList `json:"-"`
+	// OnFailure is called when a queued message is dropped after its last attempt or a permanent failure. If nil,
+	// failures are logged.
+	OnFailure func(msg *Message, err error) `json:"-"`
+}
+
+// Sender renders, filters and delivers messages through a Provider, in the background with retries.
+type Sender struct {
+	cfg      Config
+	pool     *workers.Pool
+	sent     metric.Int64Counter
+	retries  metric.Int64Counter
+	provider attribute.KeyValue
+}
+
+// NewSender creates a Sender and starts its workers.
+//
+// Parameters:
+//
+//	cfg: Sender configuration.
+//	  - Provider: Delivers the messages (SMTP, SES, webhook or a custom Provider).
+//	  - Templates: Renders messages with a Template.
+//	  - Suppressions: Recipients never sent to.
+//
+// Behavior:
+//   - Messages are queued in a workers.Pool and sent by Workers goroutines, detached from the request context.
+//   - Failed attempts are retried up to MaxAttempts with jittered exponential backoff, except permanent failures
+//     (see IsPermanent).
+//   - Exposes the notification.messages counter by outcome (sent, failed, suppressed) and the
+//     notification.retries counter, labelled by sender name and provider.
+//
+// Usage:
+//
+//	sender, err := notification.NewSender(notification.Config{
+//		From:         "Shop <no-reply@shop.com>",
+//		Provider:     notification.NewSMTPProvider(notification.SMTPConfig{Host: "smtp.mailgun.org", Port: 587}),
+//		Templates:    templates,
+//		Suppressions: notification.NewMemorySuppressionList(),
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	srv.OnStop(sender.Stop)
+//
+//	err = sender.Send(c.UserContext(), &notification.Message{
+//		To:       []string{user.Email},
+//		Template: "welcome",
+//		Data:     user,
+//	})
+func NewSender(cfg Config) (*Sender, error) {
+	if cfg.Provider == nil {
+		return nil, errors.New("notification provider is required")
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.Provider.Name()
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Minute
+	}
+	if cfg.AttemptTimeout <= 0 {
+		cfg.AttemptTimeout = 30 * time.Second
+	}
+
+	meter := otel.Meter(instrumentationName)
+	sent, _ := meter.Int64Counter("notification.messages",
+		metric.WithDescription("Notification messages by outcome (sent, failed, suppressed)."))
+	retries, _ := meter.Int64Counter("notification.retries",
+		metric.WithDescription("Notification send attempts retried after a failure."))
+
+	s := &Sender{
+		cfg:      cfg,
+		sent:     sent,
+		retries:  retries,
+		provider: attribute.String("provider", cfg.Provider.Name()),
+	}
+	s.pool = workers.NewPool(workers.Config{
+		Name:      "notification:" + cfg.Name,
+		Size:      cfg.Workers,
+		QueueSize: cfg.QueueSize,
+	})
+
+	return s, nil
+}
+
+// Send renders msg, removes its suppressed recipients and queues it for delivery, blocking while the queue is full
+// until ctx is done.
+//
+// Returns:
+//   - error: ErrNoRecipients, ErrSuppressed, a template error or workers.ErrPoolClosed. Delivery failures are
+//     reported to OnFailure, since the message is sent in the background.
+func (s *Sender) Send(ctx context.Context, msg *Message) error {
+	msg, err := s.prepare(ctx, msg)
+	if err != nil {
+		return err
+	}
+
+	return s.pool.Submit(ctx, func(ctx context.Context) error {
+		if err := s.deliver(ctx, msg); err != nil {
+			s.failed(msg, err)
+		}
+		return nil
+	})
+}
+
+// SendNow renders msg, removes its suppressed recipients and delivers it synchronously, with the retries of Send.
+// Use it when the caller must know the message was accepted by the provider, e.g. one-time passwords.
+func (s *Sender) SendNow(ctx context.Context, msg *Message) error {
+	msg, err := s.prepare(ctx, msg)
+	if err != nil {
+		return err
+	}
+	return s.deliver(ctx, msg)
+}
+
+// Suppress adds address to the suppression list, e.g. from the bounce and complaint webhooks of the provider.
+func (s *Sender) Suppress(ctx context.Context, address, reason string) error {
+	if s.cfg.Suppressions == nil {
+		return errors.New("notification sender has no suppression list")
+	}
+	return s.cfg.Suppressions.Add(ctx, normalizeAddress(address), reason)
+}
+
+// Stop stops accepting messages and waits for the queued ones to be sent, like workers.Pool.Stop.
+// Its signature matches server.Hook, so it can be registered with srv.OnStop.
+func (s *Sender) Stop(ctx context.Context) error {
+	return s.pool.Stop(ctx)
+}
+
+// prepare returns a rendered copy of msg without its suppressed recipients.
+func (s *Sender) prepare(ctx context.Context, msg *Message) (*Message, error) {
+	prepared := *msg
+	if prepared.From == "" {
+		prepared.From = s.cfg.From
+	}
+
+	if prepared.Template != "" {
+		if s.cfg.Templates == nil {
+			return nil, fmt.Errorf("failed to render template %s: notification sender has no templates", prepared.Template)
+		}
+		if err := s.cfg.Templates.Render(&prepared); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(prepared.To) == 0 {
+		return nil, ErrNoRecipients
+	}
+	if s.cfg.Suppressions == nil {
+		return &prepared, nil
+	}
+
+	prepared.To = make([]string, 0, len(msg.To))
+	for _, to := range msg.To {
+		suppressed, err := s.cfg.Suppressions.Contains(ctx, normalizeAddress(to))
+		if err != nil {
+			return nil, fmt.Errorf("failed to check suppression list: %w", err)
+		}
+		if suppressed {
+			logging.FromContextFor(ctx, "notification").Debug().Str("sender", s.cfg.Name).Str("to", to).Msg("skipped suppressed recipient")
+			continue
+		}
+		prepared.To = append(prepared.To, to)
+	}
+
+	if len(prepared.To) == 0 {
+		s.count(ctx, "suppressed")
+		return nil, ErrSuppressed
+	}
+	return &prepared, nil
+}
+
+// deliver sends msg through the provider, retrying temporary failures.
+func (s *Sender) deliver(ctx context.Context, msg *Message) error {
+	var err error
+
+	for attempt := 1; attempt <= s.cfg.MaxAttempts; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, s.cfg.AttemptTimeout)
+		err = s.cfg.Provider.Send(attemptCtx, msg)
+		cancel()
+
+		if err == nil {
+			s.count(ctx, "sent")
+			return nil
+		}
+		if IsPermanent(err) || attempt == s.cfg.MaxAttempts {
+			break
+		}
+
+		s.retries.Add(ctx, 1, metric.WithAttributes(attribute.String("sender", s.cfg.Name), s.provider))
+
+		select {
+		case <-time.After(s.backoff(attempt)):
+		case <-ctx.Done():
+			s.count(ctx, "failed")
+			return fmt.Errorf("failed to send notification: %w", err)
+		}
+	}
+
+	s.count(ctx, "failed")
+	return fmt.Errorf("failed to send notification: %w", err)
+}
+
+// backoff returns a jittered exponential delay for the given attempt.
+func (s *Sender) backoff(attempt int) time.Duration {
+	delay := s.cfg.InitialBackoff << (attempt - 1)
+	if delay <= 0 || delay > s.cfg.MaxBackoff {
+		delay = s.cfg.MaxBackoff
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func (s *Sender) failed(msg *Message, err error) {
+	if s.cfg.OnFailure != nil {
+		s.cfg.OnFailure(msg, err)
+		return
+	}
+	logger.Error().Err(err).
+		Str("sender", s.cfg.Name).
+		Strs("to", msg.To).
+		Str("template", msg.Template).
+		Msg("notification dropped")
+}
+
+func (s *Sender) count(ctx context.Context, outcome string) {
+	s.sent.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("sender", s.cfg.Name),
+		s.provider,
+		attribute.String("outcome", outcome),
+	))
+}
+
+// normalizeAddress returns the lowercase address of an email address such as "Ana <Ana@Shop.com>", or the trimmed
+// input when it is not an email address.
+func normalizeAddress(address string) string {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		return strings.ToLower(parsed.Address)
+	}
+	return strings.ToLower(strings.TrimSpace(address))
+}
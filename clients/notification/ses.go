@@ -0,0 +1,176 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// SESConfig holds the configuration of an SESProvider. It can be loaded with the config package.
+type SESConfig struct {
+	Region string `json:"region" env:"SES_REGION" default:"us-east-1"`
+	// Endpoint defaults to the SES endpoint of Region, e.g. "https://email.us-east-1.amazonaws.com".
+	Endpoint string `json:"endpoint" env:"SES_ENDPOINT"`
+	// ConfigurationSet is the SES configuration set of the messages, for event publishing.
+	ConfigurationSet string `json:"configuration_set" env:"SES_CONFIGURATION_SET"`
+
+	// AccessKeyID, SecretAccessKey and SessionToken are static credentials. Ignored when Credentials is set.
+	AccessKeyID     string `json:"access_key_id" env:"SES_ACCESS_KEY_ID"`
+	SecretAccessKey string `json:"secret_access_key" env:"SES_SECRET_ACCESS_KEY"`
+	SessionToken    string `json:"session_token" env:"SES_SESSION_TOKEN"`
+	// Credentials provides the credentials, e.g. awsCfg.Credentials from awsconfig.LoadDefaultConfig for IAM roles.
+	Credentials aws.CredentialsProvider `json:"-"`
+
+	// Timeout bounds each request when the context has no deadline. Defaults to 10s.
+	Timeout time.Duration `json:"timeout" env:"SES_TIMEOUT" default:"10s"`
+}
+
+// SESProvider sends email with the Amazon SES v2 API, signing requests with AWS Signature Version 4.
+type SESProvider struct {
+	cfg         SESConfig
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	http        *http.Client
+}
+
+// NewSESProvider creates an SESProvider.
+//
+// Usage:
+//
+//	provider, err := notification.NewSESProvider(notification.SESConfig{
+//		Region:      "sa-east-1",
+//		Credentials: awsCfg.Credentials,
+//	})
+func NewSESProvider(cfg SESConfig) (*SESProvider, error) {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://email." + cfg.Region + ".amazonaws.com"
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	credentials := cfg.Credentials
+	if credentials == nil {
+		if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+			return nil, errors.New("ses credentials are required")
+		}
+		static := aws.Credentials{
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+			SessionToken:    cfg.SessionToken,
+			Source:          "notification.SESConfig",
+		}
+		credentials = aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return static, nil
+		})
+	}
+
+	return &SESProvider{
+		cfg:         cfg,
+		credentials: aws.NewCredentialsCache(credentials),
+		signer:      v4.NewSigner(),
+		http:        newHTTPClient("ses", cfg.Timeout),
+	}, nil
+}
+
+// Name returns "ses".
+func (p *SESProvider) Name() string {
+	return "ses"
+}
+
+// sesContent is the content of an SES message.
+type sesContent struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset"`
+}
+
+type sesNameValue struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+}
+
+// Send delivers msg with the SendEmail operation. 4xx responses other than throttling, such as rejected messages
+// or unverified senders, are permanent errors.
+func (p *SESProvider) Send(ctx context.Context, msg *Message) error {
+	body := map[string]any{}
+	simple := map[string]any{"Subject": sesContent{Data: msg.Subject, Charset: "UTF-8"}}
+
+	content := map[string]sesContent{}
+	if msg.Text != "" {
+		content["Text"] = sesContent{Data: msg.Text, Charset: "UTF-8"}
+	}
+	if msg.HTML != "" {
+		content["Html"] = sesContent{Data: msg.HTML, Charset: "UTF-8"}
+	}
+	simple["Body"] = content
+	if len(msg.Headers) > 0 {
+		simple["Headers"] = nameValues(msg.Headers)
+	}
+
+	body["FromEmailAddress"] = msg.From
+	body["Destination"] = map[string][]string{"ToAddresses": msg.To}
+	body["Content"] = map[string]any{"Simple": simple}
+	if msg.ReplyTo != "" {
+		body["ReplyToAddresses"] = []string{msg.ReplyTo}
+	}
+	if len(msg.Tags) > 0 {
+		body["EmailTags"] = nameValues(msg.Tags)
+	}
+	if p.cfg.ConfigurationSet != "" {
+		body["ConfigurationSetName"] = p.cfg.ConfigurationSet
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode ses message: %w", err)
+	}
+
+	credentials, err := p.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve ses credentials: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.Endpoint+"/v2/email/outbound-emails", bytes.NewReader(payload))
+	if err != nil {
+		return &Error{Provider: p.Name(), Message: err.Error(), Permanent: true}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	hash := sha256.Sum256(payload)
+	if err := p.signer.SignHTTP(ctx, credentials, req, hex.EncodeToString(hash[:]), "ses", p.cfg.Region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign ses request: %w", err)
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("ses request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return responseError(p.Name(), resp)
+}
+
+// nameValues returns values as SES name/value pairs, sorted by name.
+func nameValues(values map[string]string) []sesNameValue {
+	pairs := make([]sesNameValue, 0, len(values))
+	for name, value := range values {
+		pairs = append(pairs, sesNameValue{Name: name, Value: value})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
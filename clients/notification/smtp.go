@@ -0,0 +1,290 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig holds the configuration of an SMTPProvider. It can be loaded with the config package.
+type SMTPConfig struct {
+	Host string `json:"host" env:"SMTP_HOST"`
+	// Port defaults to 587. Port 465 uses implicit TLS; other ports upgrade with STARTTLS when the server offers it.
+	Port     int    `json:"port" env:"SMTP_PORT" default:"587"`
+	Username string `json:"username" env:"SMTP_USERNAME"`
+	Password string `json:"password" env:"SMTP_PASSWORD"`
+	// LocalName is the host name sent in the HELO/EHLO command. Defaults to "localhost".
+	LocalName string `json:"local_name" env:"SMTP_LOCAL_NAME"`
+	// RequireTLS fails instead of sending in plain text when the server does not offer STARTTLS.
+	RequireTLS bool `json:"require_tls" env:"SMTP_REQUIRE_TLS"`
+	// Timeout bounds the connection when the context has no deadline. Defaults to 10s.
+	Timeout time.Duration `json:"timeout" env:"SMTP_TIMEOUT" default:"10s"`
+	// TLSConfig overrides the TLS configuration, e.g. for private certificate authorities.
+	TLSConfig *tls.Config `json:"-"`
+}
+
+// SMTPProvider sends email through an SMTP server, one connection per message.
+type SMTPProvider struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPProvider creates an SMTPProvider.
+func NewSMTPProvider(cfg SMTPConfig) *SMTPProvider {
+	if cfg.Port <= 0 {
+		cfg.Port = 587
+	}
+	if cfg.LocalName == "" {
+		cfg.LocalName = "localhost"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.TLSConfig == nil {
+		cfg.TLSConfig = &tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12}
+	}
+	return &SMTPProvider{cfg: cfg}
+}
+
+// Name returns "smtp".
+func (p *SMTPProvider) Name() string {
+	return "smtp"
+}
+
+// Send delivers msg. SMTP 5xx replies, such as unknown recipients or rejected credentials, are permanent errors.
+func (p *SMTPProvider) Send(ctx context.Context, msg *Message) error {
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return &Error{Provider: p.Name(), Message: fmt.Sprintf("invalid sender %q", msg.From), Permanent: true}
+	}
+	recipients := make([]string, 0, len(msg.To))
+	for _, to := range msg.To {
+		address, err := mail.ParseAddress(to)
+		if err != nil {
+			return &Error{Provider: p.Name(), Message: fmt.Sprintf("invalid recipient %q", to), Permanent: true}
+		}
+		recipients = append(recipients, address.Address)
+	}
+
+	body, err := buildMIME(msg)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.cfg.Timeout)
+		defer cancel()
+	}
+
+	client, err := p.dial(ctx)
+	if err != nil {
+		return p.error(err)
+	}
+	defer client.Close()
+
+	if err := p.send(client, from.Address, recipients, body); err != nil {
+		return p.error(err)
+	}
+	return nil
+}
+
+// dial connects and authenticates to the server. The deadline of ctx bounds the whole session.
+func (p *SMTPProvider) dial(ctx context.Context) (*smtp.Client, error) {
+	address := net.JoinHostPort(p.cfg.Host, strconv.Itoa(p.cfg.Port))
+
+	var conn net.Conn
+	var err error
+	if p.cfg.Port == 465 {
+		dialer := &tls.Dialer{Config: p.cfg.TLSConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, p.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := client.Hello(p.cfg.LocalName); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	if _, isTLS := conn.(*tls.Conn); !isTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(p.cfg.TLSConfig); err != nil {
+				client.Close()
+				return nil, err
+			}
+		} else if p.cfg.RequireTLS {
+			client.Close()
+			return nil, &Error{Provider: p.Name(), Message: "server does not support STARTTLS", Permanent: true}
+		}
+	}
+
+	if p.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", p.cfg.Username, p.cfg.Password, p.cfg.Host)); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+func (p *SMTPProvider) send(client *smtp.Client, from string, recipients []string, body []byte) error {
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, to := range recipients {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// error turns SMTP replies into *Error, permanent for 5xx codes.
+func (p *SMTPProvider) error(err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		return &Error{Provider: p.Name(), Code: reply.Code, Message: reply.Msg, Permanent: reply.Code >= 500}
+	}
+	return err
+}
+
+// buildMIME returns the RFC 5322 message of msg, multipart/alternative when it has both text and HTML.
+func buildMIME(msg *Message) ([]byte, error) {
+	var b bytes.Buffer
+
+	header := textproto.MIMEHeader{}
+	header.Set("From", msg.From)
+	header.Set("To", strings.Join(msg.To, ", "))
+	if msg.ReplyTo != "" {
+		header.Set("Reply-To", msg.ReplyTo)
+	}
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Message-Id", messageID(msg.From))
+	header.Set("MIME-Version", "1.0")
+	for name, value := range msg.Headers {
+		header.Set(name, value)
+	}
+
+	var parts []textproto.MIMEHeader
+	var contents []string
+	if msg.Text != "" || msg.HTML == "" {
+		parts = append(parts, textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+		contents = append(contents, msg.Text)
+	}
+	if msg.HTML != "" {
+		parts = append(parts, textproto.MIMEHeader{"Content-Type": {"text/html; charset=utf-8"}})
+		contents = append(contents, msg.HTML)
+	}
+
+	if len(parts) == 1 {
+		header.Set("Content-Type", parts[0].Get("Content-Type"))
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		writeHeader(&b, header)
+		if err := writeQuotedPrintable(&b, contents[0]); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for i, part := range parts {
+		part.Set("Content-Transfer-Encoding", "quoted-printable")
+		w, err := writer.CreatePart(part)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, contents[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	header.Set("Content-Type", "multipart/alternative; boundary="+writer.Boundary())
+	writeHeader(&b, header)
+	b.Write(body.Bytes())
+	return b.Bytes(), nil
+}
+
+// writeHeader writes header in a stable order, followed by the blank line that ends it.
+func writeHeader(b *bytes.Buffer, header textproto.MIMEHeader) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			// Strip line breaks so header values can't inject other headers.
+			value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+			fmt.Fprintf(b, "%s: %s\r\n", name, value)
+		}
+	}
+	b.WriteString("\r\n")
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// messageID returns a unique Message-Id in the domain of from.
+func messageID(from string) string {
+	domain := "localhost"
+	if address, err := mail.ParseAddress(from); err == nil {
+		if _, host, ok := strings.Cut(address.Address, "@"); ok {
+			domain = host
+		}
+	}
+
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
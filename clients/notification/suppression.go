@@ -0,0 +1,75 @@
+package notification
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SuppressionList holds the addresses messages are never sent to: hard bounces, spam complaints and unsubscribes.
+// Addresses are normalized to lowercase before every call. Implement it with a database table or Redis set to
+// share the list between instances.
+type SuppressionList interface {
+	// Contains reports whether address is suppressed.
+	Contains(ctx context.Context, address string) (bool, error)
+	// Add suppresses address, e.g. with reason "bounce", "complaint" or "unsubscribe".
+	Add(ctx context.Context, address, reason string) error
+	// Remove lifts the suppression of address.
+	Remove(ctx context.Context, address string) error
+}
+
+// Suppression is an entry of a MemorySuppressionList.
+type Suppression struct {
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+}
+
+// MemorySuppressionList is an in-memory SuppressionList, for tests and single-instance services.
+type MemorySuppressionList struct {
+	mu      sync.RWMutex
+	entries map[string]Suppression
+}
+
+// NewMemorySuppressionList creates an empty MemorySuppressionList.
+func NewMemorySuppressionList() *MemorySuppressionList {
+	return &MemorySuppressionList{entries: map[string]Suppression{}}
+}
+
+// Contains reports whether address is suppressed.
+func (l *MemorySuppressionList) Contains(_ context.Context, address string) (bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	_, ok := l.entries[address]
+	return ok, nil
+}
+
+// Add suppresses address.
+func (l *MemorySuppressionList) Add(_ context.Context, address, reason string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[address] = Suppression{Reason: reason, Since: time.Now()}
+	return nil
+}
+
+// Remove lifts the suppression of address.
+func (l *MemorySuppressionList) Remove(_ context.Context, address string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, address)
+	return nil
+}
+
+// Entries returns a copy of the suppressed addresses.
+func (l *MemorySuppressionList) Entries() map[string]Suppression {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entries := make(map[string]Suppression, len(l.entries))
+	for address, entry := range l.entries {
+		entries[address] = entry
+	}
+	return entries
+}
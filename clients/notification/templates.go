@@ -0,0 +1,169 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template is the source of a message template. Subject and Text are text/template sources; HTML is an
+// html/template source, so the data is escaped. Any of them can be empty.
+type Template struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Templates renders the Subject, Text and HTML of messages from named templates.
+type Templates struct {
+	templates map[string]*parsedTemplate
+}
+
+type parsedTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// NewTemplates parses templates, keyed by name.
+//
+// Usage:
+//
+//	templates, err := notification.NewTemplates(map[string]notification.Template{
+//		"welcome": {
+//			Subject: "Welcome, {{.Name}}",
+//			Text:    "Hi {{.Name}}, your account is ready.",
+//			HTML:    "<p>Hi <b>{{.Name}}</b>, your account is ready.</p>",
+//		},
+//	})
+func NewTemplates(templates map[string]Template) (*Templates, error) {
+	t := &Templates{templates: make(map[string]*parsedTemplate, len(templates))}
+	for name, source := range templates {
+		parsed, err := parseTemplate(name, source)
+		if err != nil {
+			return nil, err
+		}
+		t.templates[name] = parsed
+	}
+	return t, nil
+}
+
+// NewTemplatesFS parses the templates of fsys, usually an embed.FS. Each file is a part of the template named after
+// the file: "<name>.subject.tmpl", "<name>.txt.tmpl" and "<name>.html.tmpl". Files are read from every directory,
+// and other files are ignored.
+//
+// Usage:
+//
+//	//go:embed templates
+//	var templateFiles embed.FS
+//
+//	templates, err := notification.NewTemplatesFS(templateFiles)
+func NewTemplatesFS(fsys fs.FS) (*Templates, error) {
+	sources := map[string]Template{}
+
+	err := fs.WalkDir(fsys, ".", func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		base, ok := strings.CutSuffix(path.Base(file), ".tmpl")
+		if !ok {
+			return nil
+		}
+		name, part, ok := cutLast(base, ".")
+		if !ok {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		source := sources[name]
+		switch part {
+		case "subject":
+			source.Subject = string(content)
+		case "txt":
+			source.Text = string(content)
+		case "html":
+			source.HTML = string(content)
+		default:
+			return nil
+		}
+		sources[name] = source
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification templates: %w", err)
+	}
+
+	return NewTemplates(sources)
+}
+
+// Render sets the Subject, Text and HTML of msg from its Template and Data. Parts the template does not define are
+// kept.
+func (t *Templates) Render(msg *Message) error {
+	parsed, ok := t.templates[msg.Template]
+	if !ok {
+		return fmt.Errorf("notification template %s not found", msg.Template)
+	}
+
+	var b bytes.Buffer
+	if parsed.subject != nil {
+		if err := parsed.subject.Execute(&b, msg.Data); err != nil {
+			return fmt.Errorf("failed to render subject of template %s: %w", msg.Template, err)
+		}
+		// Headers can't span lines.
+		msg.Subject = strings.Join(strings.Fields(b.String()), " ")
+		b.Reset()
+	}
+	if parsed.text != nil {
+		if err := parsed.text.Execute(&b, msg.Data); err != nil {
+			return fmt.Errorf("failed to render text of template %s: %w", msg.Template, err)
+		}
+		msg.Text = b.String()
+		b.Reset()
+	}
+	if parsed.html != nil {
+		if err := parsed.html.Execute(&b, msg.Data); err != nil {
+			return fmt.Errorf("failed to render html of template %s: %w", msg.Template, err)
+		}
+		msg.HTML = b.String()
+	}
+	return nil
+}
+
+func parseTemplate(name string, source Template) (*parsedTemplate, error) {
+	parsed := &parsedTemplate{}
+
+	var err error
+	if source.Subject != "" {
+		if parsed.subject, err = texttemplate.New(name + ".subject").Option("missingkey=error").Parse(source.Subject); err != nil {
+			return nil, fmt.Errorf("failed to parse subject of template %s: %w", name, err)
+		}
+	}
+	if source.Text != "" {
+		if parsed.text, err = texttemplate.New(name + ".txt").Option("missingkey=error").Parse(source.Text); err != nil {
+			return nil, fmt.Errorf("failed to parse text of template %s: %w", name, err)
+		}
+	}
+	if source.HTML != "" {
+		if parsed.html, err = htmltemplate.New(name + ".html").Option("missingkey=error").Parse(source.HTML); err != nil {
+			return nil, fmt.Errorf("failed to parse html of template %s: %w", name, err)
+		}
+	}
+	return parsed, nil
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,139 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devluispereira/go-package/clients/httpclient"
+)
+
+// WebhookConfig holds the configuration of a WebhookProvider. It can be loaded with the config package.
+type WebhookConfig struct {
+	// Name identifies the provider in logs and metrics, e.g. "sms-gateway". Defaults to "webhook".
+	Name string `json:"name" env:"NOTIFICATION_WEBHOOK_NAME"`
+	// URL receives a POST with the JSON message for each notification.
+	URL string `json:"url" env:"NOTIFICATION_WEBHOOK_URL"`
+	// Headers are sent with every request, e.g. an Authorization header.
+	Headers map[string]string `json:"headers"`
+	// Secret, when set, signs each request body with HMAC-SHA256 in the X-Signature header ("sha256=<hex>"), with
+	// the Unix time of the request in X-Signature-Timestamp included in the signed content ("<timestamp>.<body>").
+	Secret string `json:"secret" env:"NOTIFICATION_WEBHOOK_SECRET"`
+	// Timeout bounds each request when the context has no deadline. Defaults to 10s.
+	Timeout time.Duration `json:"timeout" env:"NOTIFICATION_WEBHOOK_TIMEOUT" default:"10s"`
+}
+
+// WebhookProvider delivers messages by posting them as JSON to an HTTP endpoint, for SMS gateways, push services or
+// providers with an HTTP API (SendGrid, Mailgun, Postmark) behind a small adapter.
+type WebhookProvider struct {
+	cfg  WebhookConfig
+	http *http.Client
+}
+
+// NewWebhookProvider creates a WebhookProvider. Requests go through the httpclient tracing and metrics middlewares,
+// labelled with Name; retries are left to the Sender.
+func NewWebhookProvider(cfg WebhookConfig) *WebhookProvider {
+	if cfg.Name == "" {
+		cfg.Name = "webhook"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &WebhookProvider{cfg: cfg, http: newHTTPClient(cfg.Name, cfg.Timeout)}
+}
+
+// Name returns the configured name, "webhook" by default.
+func (p *WebhookProvider) Name() string {
+	return p.cfg.Name
+}
+
+// Send posts msg as JSON. 4xx responses other than 408 and 429 are permanent errors.
+func (p *WebhookProvider) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return &Error{Provider: p.Name(), Message: err.Error(), Permanent: true}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range p.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	if p.cfg.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("X-Signature-Timestamp", timestamp)
+		req.Header.Set("X-Signature", "sha256="+sign(p.cfg.Secret, timestamp, body))
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return responseError(p.Name(), resp)
+}
+
+// sign returns the hex HMAC-SHA256 of "<timestamp>.<body>".
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newHTTPClient returns the traced and measured client of an HTTP provider.
+func newHTTPClient(name string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: httpclient.NewTracingMiddleware(name)(
+			httpclient.NewMetricsMiddleware(name)(http.DefaultTransport),
+		),
+	}
+}
+
+// responseError returns nil for 2xx responses and an *Error otherwise, permanent for 4xx statuses other than 408
+// and 429.
+func responseError(provider string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil
+	}
+
+	message := resp.Status
+	if raw, err := io.ReadAll(io.LimitReader(resp.Body, 4<<10)); err == nil && len(bytes.TrimSpace(raw)) > 0 {
+		var body struct {
+			Message string `json:"message"`
+			Error   string `json:"error"`
+		}
+		switch {
+		case json.Unmarshal(raw, &body) == nil && body.Message != "":
+			message = body.Message
+		case body.Error != "":
+			message = body.Error
+		default:
+			message = strings.TrimSpace(string(raw))
+		}
+	}
+
+	return &Error{
+		Provider: provider,
+		Code:     resp.StatusCode,
+		Message:  message,
+		Permanent: resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests,
+	}
+}
@@ -0,0 +1,208 @@
+package objectstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Put stores body under key. It implements server.ObjectStore.
+//
+// Parameters:
+//   - ctx: Context for cancellation.
+//   - key: Object key, e.g. "avatars/42.png".
+//   - body: Object content, read once.
+//   - size: Content size, or -1 when unknown (e.g. a streamed upload).
+//   - contentType: Content type stored with the object. Defaults to "application/octet-stream".
+//
+// Behavior:
+//   - Objects up to PartSize are sent with a single request; larger or unknown-size objects are streamed with a
+//     multipart upload, one PartSize part at a time, so memory use is bounded by PartSize.
+//   - Each request is retried with its buffered part; a failed multipart upload is aborted.
+func (c *Client) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// A known size up to PartSize gets one spare byte, so the read reaches the end of body.
+	bufferSize := c.cfg.PartSize
+	if size >= 0 && size <= bufferSize {
+		bufferSize = size + 1
+	}
+
+	part, last, err := readPart(body, make([]byte, bufferSize))
+	if err != nil {
+		return fmt.Errorf("failed to read object %s: %w", key, err)
+	}
+	if last {
+		return c.putObject(ctx, key, part, contentType)
+	}
+
+	return c.putMultipart(ctx, key, body, part, contentType)
+}
+
+func (c *Client) putObject(ctx context.Context, key string, body []byte, contentType string) error {
+	resp, err := c.do(ctx, request{
+		method:   http.MethodPut,
+		key:      key,
+		header:   http.Header{"Content-Type": {contentType}},
+		body:     body,
+		template: "/{bucket}/{key}",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put object %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// putMultipart uploads first and the rest of body as the parts of a multipart upload.
+func (c *Client) putMultipart(ctx context.Context, key string, body io.Reader, first []byte, contentType string) error {
+	uploadID, err := c.CreateMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		return err
+	}
+
+	parts, err := c.uploadParts(ctx, key, uploadID, body, first)
+	if err == nil {
+		err = c.CompleteMultipartUpload(ctx, key, uploadID, parts)
+	}
+	if err != nil {
+		if abortErr := c.AbortMultipartUpload(context.WithoutCancel(ctx), key, uploadID); abortErr != nil {
+			logger.Warn().Err(abortErr).Str("storage", c.cfg.Name).Str("key", key).Msg("failed to abort multipart upload")
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Client) uploadParts(ctx context.Context, key, uploadID string, body io.Reader, first []byte) ([]CompletedPart, error) {
+	buffer := first
+	part := first
+
+	var parts []CompletedPart
+	for number := 1; ; number++ {
+		etag, err := c.UploadPart(ctx, key, uploadID, number, part)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, CompletedPart{PartNumber: number, ETag: etag})
+
+		var last bool
+		part, last, err = readPart(body, buffer[:cap(buffer)])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read object %s: %w", key, err)
+		}
+		if len(part) == 0 {
+			return parts, nil
+		}
+		if last {
+			etag, err := c.UploadPart(ctx, key, uploadID, number+1, part)
+			if err != nil {
+				return nil, err
+			}
+			return append(parts, CompletedPart{PartNumber: number + 1, ETag: etag}), nil
+		}
+	}
+}
+
+// readPart fills buffer from body, reporting whether body ended.
+func readPart(body io.Reader, buffer []byte) ([]byte, bool, error) {
+	n, err := io.ReadFull(body, buffer)
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return buffer[:n], true, nil
+	case err != nil:
+		return nil, false, err
+	default:
+		return buffer[:n], false, nil
+	}
+}
+
+// Get returns the content of the object under key, to be closed by the caller, and its metadata. It returns an
+// error matching ErrNotFound when the object does not exist.
+//
+// Usage:
+//
+//	body, info, err := storage.Get(ctx, "reports/2024-05.csv")
+//	if errors.Is(err, objectstorage.ErrNotFound) { ... }
+//	defer body.Close()
+func (c *Client) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	resp, err := c.do(ctx, request{method: http.MethodGet, key: key, template: "/{bucket}/{key}"})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get object %s: %w", key, err)
+	}
+	return resp.Body, objectInfo(key, resp), nil
+}
+
+// Head returns the metadata of the object under key. It returns an error matching ErrNotFound when the object does
+// not exist.
+func (c *Client) Head(ctx context.Context, key string) (*ObjectInfo, error) {
+	resp, err := c.do(ctx, request{method: http.MethodHead, key: key, template: "/{bucket}/{key}"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to head object %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return objectInfo(key, resp), nil
+}
+
+// Delete removes the object under key. Deleting a missing object is not an error. It implements
+// server.ObjectStore.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, request{method: http.MethodDelete, key: key, template: "/{bucket}/{key}"})
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Presign returns a URL granting method (GET or PUT) on the object under key for expires, so clients download or
+// upload it directly, without going through the service. S3 accepts up to 7 days.
+//
+// Usage:
+//
+//	uploadURL, err := storage.Presign(ctx, http.MethodPut, "avatars/42.png", 15*time.Minute)
+func (c *Client) Presign(ctx context.Context, method, key string, expires time.Duration) (string, error) {
+	credentials, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve object storage credentials: %w", err)
+	}
+
+	u := c.objectURL(key)
+	u.RawQuery = url.Values{"X-Amz-Expires": {strconv.FormatInt(int64(expires/time.Second), 10)}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create presigned request: %w", err)
+	}
+	req.URL = u
+	req.Host = u.Host
+
+	signed, _, err := c.signer.PresignHTTP(ctx, credentials, req, "UNSIGNED-PAYLOAD", "s3", c.cfg.Region, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("failed to presign object %s: %w", key, err)
+	}
+	return signed, nil
+}
+
+func objectInfo(key string, resp *http.Response) *ObjectInfo {
+	info := &ObjectInfo{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        resp.Header.Get("ETag"),
+	}
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = lastModified
+	}
+	return info
+}
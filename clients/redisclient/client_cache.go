@@ -0,0 +1,426 @@
+package redisclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrClientCacheUnsupported is returned by EnableClientCache for cluster clients.
+var ErrClientCacheUnsupported = errors.New("client-side caching requires a standalone or sentinel client")
+
+// invalidationChannel is the channel on which Redis sends the invalidation messages of redirected tracking.
+const invalidationChannel = "__redis__:invalidate"
+
+// ClientCacheConfig holds the client-side caching settings. It can be loaded with the config package.
+type ClientCacheConfig struct {
+	// Prefixes are the key prefixes cached in process, e.g. "httpcache:". Empty caches every key read with Get
+	// and MGet, which is rarely what you want: keep it to the hottest, read-mostly keys.
+	Prefixes []string `json:"prefixes" env:"REDIS_CLIENT_CACHE_PREFIXES"`
+	// MaxEntries bounds the number of keys kept in process. Defaults to 10000.
+	MaxEntries int `json:"max_entries" env:"REDIS_CLIENT_CACHE_MAX_ENTRIES" default:"10000"`
+	// TTL bounds how long a key is kept without being invalidated, as a safety net. Defaults to 1m.
+	TTL time.Duration `json:"ttl" env:"REDIS_CLIENT_CACHE_TTL" default:"1m"`
+}
+
+// EnableClientCache caches the keys matching cfg.Prefixes in process, using Redis server-assisted client-side
+// caching (CLIENT TRACKING, Redis 6+) so they are invalidated as soon as any client changes them.
+//
+// Parameters:
+//
+//	ctx: Context for the initial subscription and tracking commands.
+//	cfg: Tracked prefixes and local cache bounds.
+//
+// Behavior:
+//   - Get and MGet serve tracked keys from memory after the first read, including missing keys.
+//   - Tracking uses broadcasting mode with redirection: a dedicated connection receives the invalidations of
+//     every change under the prefixes on the __redis__:invalidate channel, which works with RESP2 and RESP3
+//     servers (go-redis does not deliver RESP3 push invalidations on pooled connections).
+//   - Whenever the invalidation or tracking connection is re-established, the local cache is cleared, since
+//     invalidations may have been missed in between.
+//   - Exposes the redis.client_cache.hits and redis.client_cache.misses counters.
+//   - On views created with WithPrefix, the prefixes are relative to the view (its whole prefix if empty), and
+//     the cache is shared with the root client.
+//   - Not supported by cluster clients (ErrClientCacheUnsupported). Call it before the client is shared.
+//
+// Usage:
+//
+//	redis, _ := redisclient.NewRedisClient(cfg)
+//	if err := redis.EnableClientCache(ctx, redisclient.ClientCacheConfig{Prefixes: []string{"httpcache:"}}); err != nil {
+//		log.Fatal(err)
+//	}
+func (r *RedisClient) EnableClientCache(ctx context.Context, cfg ClientCacheConfig) error {
+	client, ok := r.client.(*redis.Client)
+	if !ok {
+		return ErrClientCacheUnsupported
+	}
+
+	if r.prefix != "" {
+		prefixes := []string{r.prefix}
+		if len(cfg.Prefixes) > 0 {
+			prefixes = r.keys(cfg.Prefixes)
+		}
+		cfg.Prefixes = prefixes
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 10000
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = time.Minute
+	}
+
+	cache, err := newClientCache(ctx, client.Options(), cfg)
+	if err != nil {
+		return err
+	}
+
+	r.cache.Store(cache)
+	return nil
+}
+
+// clientCache keeps tracked keys in memory and drops them when Redis reports they changed.
+type clientCache struct {
+	cfg ClientCacheConfig
+
+	mu      sync.Mutex
+	entries map[string]*clientCacheEntry
+
+	redirectID atomic.Int64
+	subscriber *redis.Client
+	pubsub     *redis.PubSub
+	tracker    *redis.Client
+	stop       chan struct{}
+
+	hits   metric.Int64Counter
+	misses metric.Int64Counter
+}
+
+// clientCacheEntry is a cached value. An entry without loaded is a read in flight: if it is invalidated before
+// the read completes, the value read is not stored.
+type clientCacheEntry struct {
+	value   string
+	found   bool
+	loaded  bool
+	expires time.Time
+}
+
+func newClientCache(ctx context.Context, base *redis.Options, cfg ClientCacheConfig) (*clientCache, error) {
+	c := &clientCache{
+		cfg:     cfg,
+		entries: make(map[string]*clientCacheEntry),
+		stop:    make(chan struct{}),
+	}
+
+	meter := otel.Meter(instrumentationName)
+	c.hits, _ = meter.Int64Counter("redis.client_cache.hits", metric.WithDescription("Reads served by the Redis client-side cache."))
+	c.misses, _ = meter.Int64Counter("redis.client_cache.misses", metric.WithDescription("Tracked reads that went to Redis."))
+
+	// The subscriber speaks RESP2, so invalidations arrive as regular Pub/Sub messages.
+	subscriberOpts := *base
+	subscriberOpts.Protocol = 2
+	subscriberOpts.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+		id, err := cn.ClientID(ctx).Result()
+		if err != nil {
+			return err
+		}
+		if c.redirectID.Swap(id) != 0 {
+			c.clear()
+			go c.retrack()
+		}
+		return nil
+	}
+	c.subscriber = redis.NewClient(&subscriberOpts)
+
+	c.pubsub = c.subscriber.Subscribe(ctx, invalidationChannel)
+	if _, err := c.pubsub.Receive(ctx); err != nil {
+		c.close()
+		return nil, fmt.Errorf("subscribe to invalidations: %w", err)
+	}
+
+	// The tracker holds the connection with tracking enabled. Tracking is enabled again on every new connection.
+	trackerOpts := *base
+	trackerOpts.PoolSize = 1
+	trackerOpts.MinIdleConns = 0
+	trackerOpts.ConnMaxIdleTime = -1
+	trackerOpts.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+		c.clear()
+		return cn.Do(ctx, c.trackingArgs()...).Err()
+	}
+	c.tracker = redis.NewClient(&trackerOpts)
+
+	if err := c.tracker.Ping(ctx).Err(); err != nil {
+		c.close()
+		return nil, fmt.Errorf("enable client tracking: %w", err)
+	}
+
+	go c.listen()
+	go c.keepAlive()
+
+	return c, nil
+}
+
+func (c *clientCache) trackingArgs() []any {
+	args := []any{"client", "tracking", "on", "redirect", c.redirectID.Load(), "bcast"}
+	for _, prefix := range c.cfg.Prefixes {
+		args = append(args, "prefix", prefix)
+	}
+	return args
+}
+
+// retrack redirects the tracking to a new subscriber connection.
+func (c *clientCache) retrack() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn := c.tracker.Conn()
+	defer conn.Close()
+
+	err := conn.Do(ctx, "client", "tracking", "off").Err()
+	if err == nil {
+		err = conn.Do(ctx, c.trackingArgs()...).Err()
+	}
+	if err != nil {
+		logger.Warn().Err(err).Msg("redis client cache: failed to redirect tracking")
+	}
+}
+
+// listen drops the keys reported by the invalidation messages. A nil payload (FLUSHALL) or any receive error
+// clears the whole cache.
+func (c *clientCache) listen() {
+	for {
+		msg, err := c.pubsub.ReceiveMessage(context.Background())
+		if err != nil {
+			select {
+			case <-c.stop:
+				return
+			default:
+			}
+			c.clear()
+			if errors.Is(err, redis.ErrClosed) {
+				return
+			}
+
+			select {
+			case <-c.stop:
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+			continue
+		}
+
+		keys := msg.PayloadSlice
+		if keys == nil && msg.Payload != "" {
+			keys = []string{msg.Payload}
+		}
+
+		c.invalidate(keys...)
+	}
+}
+
+// keepAlive pings the tracking connection, so a dropped connection is replaced (and tracking enabled again)
+// without waiting for the next read.
+func (c *clientCache) keepAlive() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			if err := c.tracker.Ping(ctx).Err(); err != nil {
+				c.clear()
+			}
+			cancel()
+		}
+	}
+}
+
+func (c *clientCache) tracks(key string) bool {
+	if len(c.cfg.Prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range c.cfg.Prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the cached value of key. When the key is not cached, it registers a pending read and returns
+// a token to pass to store.
+func (c *clientCache) lookup(ctx context.Context, key string) (entry clientCacheEntry, ok bool, token *clientCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, exists := c.entries[key]; exists && cached.loaded && time.Now().Before(cached.expires) {
+		c.hits.Add(ctx, 1)
+		return *cached, true, nil
+	}
+
+	c.misses.Add(ctx, 1)
+
+	if len(c.entries) >= c.cfg.MaxEntries {
+		for evicted := range c.entries {
+			delete(c.entries, evicted)
+			break
+		}
+	}
+
+	token = &clientCacheEntry{}
+	c.entries[key] = token
+	return clientCacheEntry{}, false, token
+}
+
+// store saves a value read from Redis, unless the key was invalidated since lookup.
+func (c *clientCache) store(key string, token *clientCacheEntry, value string, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries[key] != token {
+		return
+	}
+
+	token.value = value
+	token.found = found
+	token.loaded = true
+	token.expires = time.Now().Add(c.cfg.TTL)
+}
+
+// abort drops a pending read that failed.
+func (c *clientCache) abort(key string, token *clientCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries[key] == token {
+		delete(c.entries, key)
+	}
+}
+
+// invalidate drops keys written through this client, without waiting for the invalidation message of Redis, so a
+// read following a write in the same process never returns the previous value.
+func (c *clientCache) invalidate(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+}
+
+func (c *clientCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*clientCacheEntry)
+}
+
+func (c *clientCache) close() error {
+	select {
+	case <-c.stop:
+		return nil
+	default:
+		close(c.stop)
+	}
+
+	var errs []error
+	if c.pubsub != nil {
+		errs = append(errs, c.pubsub.Close())
+	}
+	if c.tracker != nil {
+		errs = append(errs, c.tracker.Close())
+	}
+	errs = append(errs, c.subscriber.Close())
+
+	return errors.Join(errs...)
+}
+
+// invalidate drops keys, already prefixed, from the client-side cache, if enabled. Writes defer it, so it also runs
+// when the write fails, after possibly reaching Redis.
+func (r *RedisClient) invalidate(keys ...string) {
+	if cache := r.cache.Load(); cache != nil {
+		cache.invalidate(keys...)
+	}
+}
+
+// cachedGet implements Get for tracked keys.
+func (r *RedisClient) cachedGet(ctx context.Context, cache *clientCache, key string) (string, error) {
+	entry, ok, token := cache.lookup(ctx, key)
+	if ok {
+		if !entry.found {
+			return "", redis.Nil
+		}
+		return entry.value, nil
+	}
+
+	value, err := r.client.Get(ctx, key).Result()
+	switch {
+	case errors.Is(err, redis.Nil):
+		cache.store(key, token, "", false)
+	case err != nil:
+		cache.abort(key, token)
+	default:
+		cache.store(key, token, value, true)
+	}
+
+	return value, err
+}
+
+// cachedMGet implements MGet, serving tracked keys from memory and fetching the others in a single MGET (see mget).
+func (r *RedisClient) cachedMGet(ctx context.Context, cache *clientCache, keys []string) ([]any, error) {
+	values := make([]any, len(keys))
+	tokens := make(map[int]*clientCacheEntry)
+	var missing []string
+	var missingIdx []int
+
+	for i, key := range keys {
+		if !cache.tracks(key) {
+			missing = append(missing, key)
+			missingIdx = append(missingIdx, i)
+			continue
+		}
+
+		entry, ok, token := cache.lookup(ctx, key)
+		if ok {
+			if entry.found {
+				values[i] = entry.value
+			}
+			continue
+		}
+
+		tokens[i] = token
+		missing = append(missing, key)
+		missingIdx = append(missingIdx, i)
+	}
+
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	fetched, err := r.mget(ctx, missing)
+	if err != nil {
+		for i, token := range tokens {
+			cache.abort(keys[i], token)
+		}
+		return nil, err
+	}
+
+	for j, i := range missingIdx {
+		values[i] = fetched[j]
+
+		if token, ok := tokens[i]; ok {
+			value, found := fetched[j].(string)
+			cache.store(keys[i], token, value, found)
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,100 @@
+package redisclient
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+)
+
+// memoryStore is a go-redis hook answering GET, SET and DEL in memory, so no Redis is needed.
+type memoryStore struct {
+	mu   sync.Mutex
+	data map[string]string
+	gets int
+}
+
+func (m *memoryStore) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (m *memoryStore) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (m *memoryStore) ProcessHook(redis.ProcessHook) redis.ProcessHook {
+	return func(_ context.Context, cmd redis.Cmder) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		args := cmd.Args()
+		switch c := cmd.(type) {
+		case *redis.StringCmd:
+			m.gets++
+			value, ok := m.data[args[1].(string)]
+			if !ok {
+				c.SetErr(redis.Nil)
+				return redis.Nil
+			}
+			c.SetVal(value)
+		case *redis.StatusCmd:
+			m.data[args[1].(string)] = args[2].(string)
+			c.SetVal("OK")
+		case *redis.IntCmd:
+			for _, key := range args[1:] {
+				delete(m.data, key.(string))
+			}
+		default:
+			return errors.New("unsupported command " + strings.Join(strings.Fields(cmd.String()), " "))
+		}
+		return nil
+	}
+}
+
+func newCachedMemoryClient(t *testing.T) (*RedisClient, *memoryStore) {
+	t.Helper()
+
+	store := &memoryStore{data: map[string]string{}}
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	client.AddHook(store)
+	t.Cleanup(func() { _ = client.Close() })
+
+	cache := &clientCache{
+		cfg:     ClientCacheConfig{MaxEntries: 10, TTL: time.Minute},
+		entries: make(map[string]*clientCacheEntry),
+		stop:    make(chan struct{}),
+	}
+	cache.hits, _ = otel.Meter(instrumentationName).Int64Counter("test.hits")
+	cache.misses, _ = otel.Meter(instrumentationName).Int64Counter("test.misses")
+
+	r := newRedisClient(client, nil)
+	r.cache.Store(cache)
+	return r, store
+}
+
+func TestClientCacheDropsKeysWrittenByTheClient(t *testing.T) {
+	r, store := newCachedMemoryClient(t)
+	ctx := context.Background()
+
+	_ = r.Set(ctx, "settings", "v1", 0)
+	if value, _ := r.Get(ctx, "settings"); value != "v1" {
+		t.Fatalf("got %q, want v1", value)
+	}
+	_, _ = r.Get(ctx, "settings")
+	if store.gets != 1 {
+		t.Fatalf("Redis read %d times, want the second Get served from memory", store.gets)
+	}
+
+	_ = r.Set(ctx, "settings", "v2", 0)
+	if value, _ := r.Get(ctx, "settings"); value != "v2" {
+		t.Fatalf("got %q after Set, want v2", value)
+	}
+
+	_ = r.Del(ctx, "settings")
+	if _, err := r.Get(ctx, "settings"); !errors.Is(err, redis.Nil) {
+		t.Fatalf("got %v after Del, want redis.Nil", err)
+	}
+}
@@ -0,0 +1,69 @@
+package redisclient
+
+import "strings"
+
+// WithPrefix returns a view of the client that prepends prefix to every key, so components sharing one Redis
+// connection cannot collide.
+//
+// Parameters:
+//
+//	prefix: Key prefix, e.g. "myapp:cache:". Views of views concatenate their prefixes.
+//
+// Behavior:
+//   - Keys of every method are prefixed, including MGet, Del and Eval KEYS; ScanKeys and DeleteByPattern match
+//     only the keys under the prefix, and ScanKeys hands them to fn without it.
+//   - Pub/Sub channels and commands run through Client() are not prefixed.
+//   - The view shares the connections, client-side cache and topology callbacks of the client. Close on a view
+//     does nothing.
+//
+// Usage:
+//
+//	sessions := redis.WithPrefix("myapp:sessions:")
+//	_ = sessions.Set(ctx, id, data, time.Hour) // stored as "myapp:sessions:<id>"
+func (r *RedisClient) WithPrefix(prefix string) *RedisClient {
+	return &RedisClient{
+		client:     r.client,
+		background: r.background,
+		prefix:     r.prefix + prefix,
+		cache:      r.cache,
+		topology:   r.topology,
+	}
+}
+
+// Prefix returns the prefix prepended to the keys by the client. It is empty for clients not created with
+// WithPrefix.
+func (r *RedisClient) Prefix() string {
+	return r.prefix
+}
+
+func (r *RedisClient) key(key string) string {
+	return r.prefix + key
+}
+
+func (r *RedisClient) keys(keys []string) []string {
+	if r.prefix == "" {
+		return keys
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = r.prefix + key
+	}
+	return prefixed
+}
+
+// pattern prefixes a SCAN pattern, escaping the glob characters of the prefix.
+func (r *RedisClient) pattern(pattern string) string {
+	if r.prefix == "" {
+		return pattern
+	}
+
+	var escaped strings.Builder
+	for _, c := range r.prefix {
+		if strings.ContainsRune(`*?[]\`, c) {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteRune(c)
+	}
+	return escaped.String() + pattern
+}
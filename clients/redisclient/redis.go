@@ -2,29 +2,91 @@ package redisclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/url"
-	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/devluispereira/go-package/logging"
 	"github.com/redis/go-redis/v9"
 )
 
+var logger = logging.New("redis-client")
+
 type RedisClient struct {
 	client redis.UniversalClient
+	// background is the pool of the views created with Background, nil when Config.BackgroundPoolSize is zero.
+	background redis.UniversalClient
+	// prefix is prepended to every key by the views created with WithPrefix.
+	prefix string
+	// root is false for views, which share the connections, client cache and topology of their root client.
+	root     bool
+	cache    *atomic.Pointer[clientCache]
+	topology *topology
+}
+
+// defaultPoolSize is the size of the connection pool of the clients.
+const defaultPoolSize = 20
+
+func newRedisClient(client redis.UniversalClient, top *topology) *RedisClient {
+	return &RedisClient{
+		client:   client,
+		root:     true,
+		cache:    new(atomic.Pointer[clientCache]),
+		topology: top,
+	}
+}
+
+// Config holds the Redis client configuration. It can be loaded with the config package.
+type Config struct {
+	URL string `json:"url" env:"REDIS_URL"`
+	// BackgroundPoolSize creates a separate pool of connections of this size for the views returned by Background,
+	// used for background writes (cache writes, audit streams), so a burst of them cannot starve the
+	// latency-critical reads of connections. If zero, Background shares the main pool.
+	BackgroundPoolSize int `json:"background_pool_size" env:"REDIS_BACKGROUND_POOL_SIZE"`
+}
+
+// NewRedisClient creates a RedisClient from a Config. See NewRedisClientFromURL for the supported URL schemes.
+func NewRedisClient(cfg Config) (*RedisClient, error) {
+	client, err := NewRedisClientFromURL(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.BackgroundPoolSize > 0 {
+		background, err := dial(cfg.URL, cfg.BackgroundPoolSize, nil)
+		if err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+		client.background = background
+	}
+
+	return client, nil
 }
 
 func NewRedisClientFromURL(rawURL string) (*RedisClient, error) {
-	logger := log.New(os.Stdout, "redis-client", 1)
+	top := new(topology)
+
+	client, err := dial(rawURL, defaultPoolSize, top)
+	if err != nil {
+		return nil, err
+	}
 
+	return newRedisClient(client, top), nil
+}
+
+// dial connects to the Redis of rawURL with a pool of poolSize connections. Topology changes are watched when top
+// is not nil.
+func dial(rawURL string, poolSize int, top *topology) (redis.UniversalClient, error) {
 	cleanURL := cleanRedisURL(rawURL)
 
 	parsed, err := parseURL(cleanURL)
 
 	if err != nil {
-		logger.Print("Parsed error to : %w", err)
+		logger.Error().Err(err).Msg("failed to parse redis url")
 		return nil, fmt.Errorf("parsed error to : %w", err)
 	}
 
@@ -33,33 +95,209 @@ func NewRedisClientFromURL(rawURL string) (*RedisClient, error) {
 
 	switch parsed.Scheme {
 	case "redis":
-		return createRedisClient(addrs, password, 0), nil
+		return createRedisClient(addrs, password, 0, poolSize), nil
 
 	case "redis+sentinel", "sentinel":
-		logger.Println("connect into redis sentinel mode")
-		return createSentinelClient(rawURL, parsed, password), nil
+		logger.Info().Msg("connect into redis sentinel mode")
+		return createSentinelClient(rawURL, parsed, password, poolSize, top), nil
 
 	case "redis+cluster", "cluster":
-		logger.Println("connect into redis cluster")
-		return createClusterClient(rawURL, password), nil
+		logger.Info().Msg("connect into redis cluster")
+		return createClusterClient(rawURL, password, poolSize, top), nil
 
 	default:
 		if len(addrs) == 0 {
 			return nil, fmt.Errorf("invalid redis URL: %s", rawURL)
 		}
 
-		return createRedisClient(addrs, password, 0), nil
+		return createRedisClient(addrs, password, 0, poolSize), nil
+	}
+}
+
+// Background returns a view of the client running its commands on the background pool (see
+// Config.BackgroundPoolSize), for writes that must not compete with the latency-critical reads for connections.
+//
+// Behavior:
+//   - Without a background pool, it returns the client itself.
+//   - The view keeps the prefix, client-side cache and topology callbacks of the client; Client() returns the
+//     go-redis client of the background pool. Close on the view does nothing.
+//
+// Usage:
+//
+//	cache := &httpclient.CacheConfig{RedisClient: redis, WriteClient: redis.Background()}
+//	broker := redisstream.New(redis.Background().Client(), redisstream.Config{})
+func (r *RedisClient) Background() *RedisClient {
+	if r.background == nil {
+		return r
+	}
+
+	return &RedisClient{
+		client:     r.background,
+		background: r.background,
+		prefix:     r.prefix,
+		cache:      r.cache,
+		topology:   r.topology,
 	}
 }
 
 func (r *RedisClient) Set(ctx context.Context, key string, value any, expiration time.Duration) error {
+	key = r.key(key)
+	defer r.invalidate(key)
 	return r.client.Set(ctx, key, value, expiration).Err()
 }
 
 func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
+	key = r.key(key)
+	if cache := r.cache.Load(); cache != nil && cache.tracks(key) {
+		return r.cachedGet(ctx, cache, key)
+	}
 	return r.client.Get(ctx, key).Result()
 }
 
+// MGet returns the values of the given keys in a single round trip. Missing keys are returned as nil. On Redis
+// Cluster the keys may hash to different slots, which MGET rejects with CROSSSLOT, so they are fetched with a
+// pipeline of GETs instead, still one round trip per node.
+func (r *RedisClient) MGet(ctx context.Context, keys ...string) ([]any, error) {
+	keys = r.keys(keys)
+	if cache := r.cache.Load(); cache != nil {
+		return r.cachedMGet(ctx, cache, keys)
+	}
+	return r.mget(ctx, keys)
+}
+
+// mget runs MGET, or a pipeline of GETs on Redis Cluster.
+func (r *RedisClient) mget(ctx context.Context, keys []string) ([]any, error) {
+	if _, ok := r.client.(*redis.ClusterClient); !ok || len(keys) < 2 {
+		return r.client.MGet(ctx, keys...).Result()
+	}
+
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	values := make([]any, len(keys))
+	for i, cmd := range cmds {
+		if value, err := cmd.Result(); err == nil {
+			values[i] = value
+		}
+	}
+	return values, nil
+}
+
+// SetNX sets the key only if it does not exist, reporting whether it was set.
+func (r *RedisClient) SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	key = r.key(key)
+	defer r.invalidate(key)
+	return r.client.SetNX(ctx, key, value, expiration).Result()
+}
+
+// Del deletes the given keys.
+func (r *RedisClient) Del(ctx context.Context, keys ...string) error {
+	keys = r.keys(keys)
+	defer r.invalidate(keys...)
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// TTL returns the remaining time to live of a key. It is negative when the key has no expiration or does not exist.
+func (r *RedisClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return r.client.TTL(ctx, r.key(key)).Result()
+}
+
+// Expire sets the expiration of a key.
+func (r *RedisClient) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	key = r.key(key)
+	defer r.invalidate(key)
+	return r.client.Expire(ctx, key, expiration).Err()
+}
+
+// SAdd adds members to a set.
+func (r *RedisClient) SAdd(ctx context.Context, key string, members ...any) error {
+	return r.client.SAdd(ctx, r.key(key), members...).Err()
+}
+
+// SMembers returns every member of a set.
+func (r *RedisClient) SMembers(ctx context.Context, key string) ([]string, error) {
+	return r.client.SMembers(ctx, r.key(key)).Result()
+}
+
+// SRandMemberN returns up to count distinct random members of a set.
+func (r *RedisClient) SRandMemberN(ctx context.Context, key string, count int64) ([]string, error) {
+	return r.client.SRandMemberN(ctx, r.key(key), count).Result()
+}
+
+// SRem removes members from a set.
+func (r *RedisClient) SRem(ctx context.Context, key string, members ...any) error {
+	return r.client.SRem(ctx, r.key(key), members...).Err()
+}
+
+// HIncrBy increments the integer value of a hash field.
+func (r *RedisClient) HIncrBy(ctx context.Context, key, field string, incr int64) error {
+	return r.client.HIncrBy(ctx, r.key(key), field, incr).Err()
+}
+
+// HGetAll returns every field and value of a hash.
+func (r *RedisClient) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return r.client.HGetAll(ctx, r.key(key)).Result()
+}
+
+// HSet sets fields of a hash, given as field and value pairs.
+func (r *RedisClient) HSet(ctx context.Context, key string, values ...any) error {
+	return r.client.HSet(ctx, r.key(key), values...).Err()
+}
+
+// HDel removes fields of a hash.
+func (r *RedisClient) HDel(ctx context.Context, key string, fields ...string) error {
+	return r.client.HDel(ctx, r.key(key), fields...).Err()
+}
+
+// Eval runs a Lua script. The keys it receives are dropped from the client-side cache, as it may change them.
+func (r *RedisClient) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	keys = r.keys(keys)
+	defer r.invalidate(keys...)
+	return r.client.Eval(ctx, script, keys, args...).Result()
+}
+
+// Publish posts a message to the given channel.
+func (r *RedisClient) Publish(ctx context.Context, channel string, message any) error {
+	return r.client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe subscribes to the given channels. The caller must close the returned PubSub.
+func (r *RedisClient) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return r.client.Subscribe(ctx, channels...)
+}
+
+// Client returns the underlying go-redis client, for commands not wrapped by RedisClient. Keys passed to it are
+// not prefixed, even on views created with WithPrefix.
+func (r *RedisClient) Client() redis.UniversalClient {
+	return r.client
+}
+
+// Close closes the underlying connections. Register it as a server stop hook to release them on shutdown.
+// Closing a view created with WithPrefix does nothing; close the root client instead.
+func (r *RedisClient) Close() error {
+	if !r.root {
+		return nil
+	}
+	if cache := r.cache.Swap(nil); cache != nil {
+		_ = cache.close()
+	}
+	if r.topology.stop != nil {
+		r.topology.stop()
+	}
+	if r.background != nil {
+		_ = r.background.Close()
+	}
+	return r.client.Close()
+}
+
 func cleanRedisURL(rawURL string) string {
 	if strings.HasPrefix(rawURL, "http://") {
 		return strings.TrimPrefix(rawURL, "http://")
@@ -85,22 +323,24 @@ func extractAddrs(parsed *url.URL) []string {
 	return strings.Split(parsed.Host, ",")
 }
 
-func createRedisClient(addrs []string, password string, db int) *RedisClient {
+func createRedisClient(addrs []string, password string, db int, poolSize int) redis.UniversalClient {
 	client := redis.NewClient(&redis.Options{
 		Addr:         addrs[0],
 		Password:     password,
 		DB:           db,
-		PoolSize:     20,
-		MinIdleConns: 5,
+		PoolSize:     poolSize,
+		MinIdleConns: min(5, poolSize),
 		DialTimeout:  2 * time.Second,
 		ReadTimeout:  1 * time.Second,
 		WriteTimeout: 1 * time.Second,
 	})
 
-	return &RedisClient{client: client}
+	client.AddHook(newTelemetryHook())
+
+	return client
 }
 
-func createSentinelClient(rawURL string, parsed *url.URL, password string) *RedisClient {
+func createSentinelClient(rawURL string, parsed *url.URL, password string, poolSize int, top *topology) redis.UniversalClient {
 	hosts := strings.Split(strings.Split(strings.Split(rawURL, "//")[1], "/")[0], ",")
 
 	path := strings.TrimPrefix(parsed.Path, "/")
@@ -111,33 +351,45 @@ func createSentinelClient(rawURL string, parsed *url.URL, password string) *Redi
 		masterName = strings.Split(path, "service_name:")[1]
 	}
 
-	client := redis.NewFailoverClient(&redis.FailoverOptions{
+	failover := redis.NewFailoverClient(&redis.FailoverOptions{
 		MasterName:    masterName,
 		SentinelAddrs: hosts,
 		Password:      password,
 		DB:            0,
-		PoolSize:      20,
-		MinIdleConns:  5,
+		PoolSize:      poolSize,
+		MinIdleConns:  min(5, poolSize),
 		DialTimeout:   2 * time.Second,
 		ReadTimeout:   1 * time.Second,
 		WriteTimeout:  1 * time.Second,
 	})
 
-	return &RedisClient{client: client}
+	failover.AddHook(newTelemetryHook())
+
+	if top != nil {
+		top.watchSentinel(hosts, masterName)
+	}
+
+	return failover
 }
 
-func createClusterClient(rawURL string, password string) *RedisClient {
+func createClusterClient(rawURL string, password string, poolSize int, top *topology) redis.UniversalClient {
 	hosts := strings.Split(strings.Split(strings.Split(rawURL, "//")[1], "/")[0], ",")
 
-	client := redis.NewClusterClient(&redis.ClusterOptions{
+	cluster := redis.NewClusterClient(&redis.ClusterOptions{
 		Addrs:        hosts,
 		Password:     password,
-		PoolSize:     20,
-		MinIdleConns: 5,
+		PoolSize:     poolSize,
+		MinIdleConns: min(5, poolSize),
 		DialTimeout:  2 * time.Second,
 		ReadTimeout:  1 * time.Second,
 		WriteTimeout: 1 * time.Second,
 	})
 
-	return &RedisClient{client: client}
+	cluster.AddHook(newTelemetryHook())
+
+	if top != nil {
+		top.watchCluster(cluster)
+	}
+
+	return cluster
 }
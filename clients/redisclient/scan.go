@@ -0,0 +1,198 @@
+package redisclient
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// scanOptions holds the settings of ScanKeys and DeleteByPattern.
+type scanOptions struct {
+	count int64
+	rate  int
+}
+
+// ScanOption customizes ScanKeys and DeleteByPattern.
+type ScanOption func(*scanOptions)
+
+// WithScanCount sets the COUNT hint of each SCAN call, i.e. roughly how many keys each round trip examines.
+// Defaults to 500.
+func WithScanCount(count int64) ScanOption {
+	return func(o *scanOptions) {
+		o.count = count
+	}
+}
+
+// WithScanRate limits how many keys per second are visited (or deleted), to keep cleanups from hurting Redis
+// latency. 0 disables the limit.
+func WithScanRate(keysPerSecond int) ScanOption {
+	return func(o *scanOptions) {
+		o.rate = keysPerSecond
+	}
+}
+
+// ScanKeys calls fn for every key matching pattern, using cursor-based SCAN instead of KEYS so Redis is never
+// blocked.
+//
+// Parameters:
+//
+//	ctx: Context for cancellation.
+//	pattern: Glob-style pattern, e.g. "httpcache:catalog:*".
+//	fn: Called for each key. Returning an error stops the scan.
+//	opts: WithScanCount and WithScanRate. Unlimited rate by default.
+//
+// Behavior:
+//   - On cluster clients, every master is scanned, one after the other.
+//   - On views created with WithPrefix, only keys under the prefix are visited and fn gets them without it.
+//   - SCAN guarantees that keys present during the whole scan are returned, but a key may be returned twice.
+//
+// Usage:
+//
+//	err := redis.ScanKeys(ctx, "session:*", func(ctx context.Context, key string) error {
+//		count++
+//		return nil
+//	})
+func (r *RedisClient) ScanKeys(ctx context.Context, pattern string, fn func(ctx context.Context, key string) error, opts ...ScanOption) error {
+	o := newScanOptions(opts)
+
+	return r.forEachNode(ctx, func(ctx context.Context, node redis.UniversalClient) error {
+		return scanNode(ctx, node, r.pattern(pattern), o, func(ctx context.Context, keys []string) error {
+			for _, key := range keys {
+				if err := fn(ctx, strings.TrimPrefix(key, r.prefix)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// DeleteByPattern deletes every key matching pattern with SCAN and UNLINK, returning the number of keys deleted.
+//
+// Parameters:
+//
+//	ctx: Context for cancellation.
+//	pattern: Glob-style pattern, e.g. "tmp:import:*".
+//	opts: WithScanCount and WithScanRate. Limited to 5000 keys per second by default.
+//
+// Behavior:
+//   - Each SCAN page is deleted in a single pipeline of UNLINK commands, so keys of different cluster slots
+//     can be deleted together. UNLINK reclaims memory in background on the Redis side.
+//   - On cluster clients, every master is cleaned, one after the other.
+//   - On error, the keys deleted so far are returned with it.
+//
+// Usage:
+//
+//	deleted, err := redis.DeleteByPattern(ctx, "tmp:import:*", redisclient.WithScanRate(1000))
+func (r *RedisClient) DeleteByPattern(ctx context.Context, pattern string, opts ...ScanOption) (int64, error) {
+	o := newScanOptions(append([]ScanOption{WithScanRate(5000)}, opts...))
+
+	var deleted int64
+	err := r.forEachNode(ctx, func(ctx context.Context, node redis.UniversalClient) error {
+		return scanNode(ctx, node, r.pattern(pattern), o, func(ctx context.Context, keys []string) error {
+			defer r.invalidate(keys...)
+
+			pipe := node.Pipeline()
+			cmds := make([]*redis.IntCmd, len(keys))
+			for i, key := range keys {
+				cmds[i] = pipe.Unlink(ctx, key)
+			}
+
+			_, err := pipe.Exec(ctx)
+			for _, cmd := range cmds {
+				deleted += cmd.Val()
+			}
+			return err
+		})
+	})
+
+	logger.Info().Str("pattern", pattern).Int64("deleted", deleted).Err(err).Msg("redis keys deleted by pattern")
+
+	return deleted, err
+}
+
+func newScanOptions(opts []ScanOption) scanOptions {
+	o := scanOptions{count: 500}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.count <= 0 {
+		o.count = 500
+	}
+	return o
+}
+
+// forEachNode runs fn on every master of a cluster client, or on the client itself.
+func (r *RedisClient) forEachNode(ctx context.Context, fn func(ctx context.Context, node redis.UniversalClient) error) error {
+	cluster, ok := r.client.(*redis.ClusterClient)
+	if !ok {
+		return fn(ctx, r.client)
+	}
+
+	// ForEachMaster calls back concurrently, one goroutine per master; collecting the masters under a lock and
+	// scanning them in sequence keeps the rate limit global.
+	var (
+		mu      sync.Mutex
+		masters []*redis.Client
+	)
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		masters = append(masters, master)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, master := range masters {
+		if err := fn(ctx, master); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanNode scans a single node, calling page with each page of keys and pacing the pages to o.rate.
+func scanNode(ctx context.Context, node redis.UniversalClient, pattern string, o scanOptions, page func(ctx context.Context, keys []string) error) error {
+	var (
+		cursor  uint64
+		visited int
+		start   = time.Now()
+	)
+
+	for {
+		keys, next, err := node.Scan(ctx, cursor, pattern, o.count).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			if err := page(ctx, keys); err != nil {
+				return err
+			}
+			visited += len(keys)
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+
+		if o.rate > 0 {
+			wait := time.Duration(float64(visited)/float64(o.rate)*float64(time.Second)) - time.Since(start)
+			if wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
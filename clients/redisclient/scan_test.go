@@ -0,0 +1,47 @@
+package redisclient
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newFakeCluster returns a cluster client of masters owning fixed slot ranges, without connecting to them.
+func newFakeCluster(t *testing.T, masters int) *redis.ClusterClient {
+	t.Helper()
+
+	slots := make([]redis.ClusterSlot, masters)
+	per := 16384 / masters
+	for i := range slots {
+		slots[i] = redis.ClusterSlot{
+			Start: i * per,
+			End:   (i+1)*per - 1,
+			Nodes: []redis.ClusterNode{{Addr: fmt.Sprintf("127.0.0.1:%d", 17000+i)}},
+		}
+	}
+
+	cluster := redis.NewClusterClient(&redis.ClusterOptions{
+		ClusterSlots: func(context.Context) ([]redis.ClusterSlot, error) { return slots, nil },
+	})
+	t.Cleanup(func() { _ = cluster.Close() })
+	return cluster
+}
+
+func TestForEachNodeVisitsEveryClusterMaster(t *testing.T) {
+	const masters = 64
+	client := newRedisClient(newFakeCluster(t, masters), nil)
+
+	visited := map[string]bool{}
+	err := client.forEachNode(context.Background(), func(_ context.Context, node redis.UniversalClient) error {
+		visited[node.(*redis.Client).Options().Addr] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("forEachNode: %v", err)
+	}
+	if len(visited) != masters {
+		t.Fatalf("visited %d masters, want %d", len(visited), masters)
+	}
+}
@@ -0,0 +1,93 @@
+package redisclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/devluispereira/go-package/clients/redisclient"
+
+// telemetryHook is a go-redis hook that creates spans and records the duration of every command,
+// using the global providers set by observability.Init.
+type telemetryHook struct {
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+}
+
+func newTelemetryHook() *telemetryHook {
+	duration, _ := otel.Meter(instrumentationName).Float64Histogram(
+		"db.client.operation.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of Redis commands."),
+	)
+
+	return &telemetryHook{
+		tracer:   otel.Tracer(instrumentationName),
+		duration: duration,
+	}
+}
+
+func (h *telemetryHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *telemetryHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		ctx, span := h.tracer.Start(ctx, "redis."+cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "redis"),
+				attribute.String("db.operation.name", cmd.Name()),
+			),
+		)
+		defer span.End()
+
+		err := next(ctx, cmd)
+		h.record(ctx, span, cmd.Name(), start, err)
+		return err
+	}
+}
+
+func (h *telemetryHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		ctx, span := h.tracer.Start(ctx, "redis.pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "redis"),
+				attribute.Int("db.operation.batch.size", len(cmds)),
+			),
+		)
+		defer span.End()
+
+		err := next(ctx, cmds)
+		h.record(ctx, span, "pipeline", start, err)
+		return err
+	}
+}
+
+func (h *telemetryHook) record(ctx context.Context, span trace.Span, operation string, start time.Time, err error) {
+	status := "ok"
+	if err != nil && !errors.Is(err, redis.Nil) {
+		status = "error"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	h.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+		attribute.String("db.operation.name", operation),
+		attribute.String("status", status),
+	))
+}
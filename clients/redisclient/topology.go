@@ -0,0 +1,218 @@
+package redisclient
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Topology event types.
+const (
+	// TopologyFailover is a sentinel master switch (+switch-master).
+	TopologyFailover = "failover"
+	// TopologyNewNode is a cluster node the client connected to for the first time.
+	TopologyNewNode = "new_node"
+	// TopologySlotMoved is a MOVED redirection: the slot now lives on another node.
+	TopologySlotMoved = "slot_moved"
+	// TopologySlotMigrating is an ASK redirection: the slot is being migrated to another node.
+	TopologySlotMigrating = "slot_migrating"
+)
+
+// TopologyEvent describes a change in the Redis topology seen by the client.
+type TopologyEvent struct {
+	Type string `json:"type"`
+	// Master is the sentinel master name, for failovers.
+	Master string `json:"master,omitempty"`
+	// Addr is the new master, the new node or the node now serving the slot.
+	Addr string `json:"addr"`
+	// PreviousAddr is the previous master, for failovers.
+	PreviousAddr string `json:"previous_addr,omitempty"`
+	// Slot is the cluster slot, for redirections.
+	Slot int       `json:"slot,omitempty"`
+	Time time.Time `json:"time"`
+}
+
+// topology dispatches topology events to logs, metrics and the registered callbacks.
+type topology struct {
+	mu        sync.RWMutex
+	listeners map[string][]func(TopologyEvent)
+	stop      func()
+}
+
+var topologyEvents, _ = otel.Meter(instrumentationName).Int64Counter("redis.topology.events",
+	metric.WithDescription("Redis topology changes seen by the client: failovers, new nodes and slot redirections."))
+
+// OnFailover registers a callback for sentinel master switches. Only sentinel clients emit it.
+func (r *RedisClient) OnFailover(fn func(TopologyEvent)) {
+	r.topology.on(TopologyFailover, fn)
+}
+
+// OnNewNode registers a callback for cluster nodes the client connects to for the first time. Only cluster
+// clients emit it.
+func (r *RedisClient) OnNewNode(fn func(TopologyEvent)) {
+	r.topology.on(TopologyNewNode, fn)
+}
+
+// OnSlotMigration registers a callback for MOVED and ASK redirections, which happen while slots are migrated
+// between cluster nodes. Only cluster clients emit it.
+func (r *RedisClient) OnSlotMigration(fn func(TopologyEvent)) {
+	r.topology.on(TopologySlotMoved, fn)
+	r.topology.on(TopologySlotMigrating, fn)
+}
+
+func (t *topology) on(eventType string, fn func(TopologyEvent)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.listeners == nil {
+		t.listeners = make(map[string][]func(TopologyEvent))
+	}
+	t.listeners[eventType] = append(t.listeners[eventType], fn)
+}
+
+func (t *topology) emit(ctx context.Context, event TopologyEvent) {
+	event.Time = time.Now()
+
+	topologyEvents.Add(ctx, 1, metric.WithAttributes(attribute.String("type", event.Type)))
+
+	log := logger.Info()
+	switch event.Type {
+	case TopologyFailover:
+		log = logger.Warn()
+	case TopologySlotMoved, TopologySlotMigrating:
+		log = logger.Debug()
+	}
+	log.Str("type", event.Type).
+		Str("master", event.Master).
+		Str("addr", event.Addr).
+		Str("previous_addr", event.PreviousAddr).
+		Int("slot", event.Slot).
+		Msg("redis topology changed")
+
+	t.mu.RLock()
+	listeners := t.listeners[event.Type]
+	t.mu.RUnlock()
+
+	for _, fn := range listeners {
+		fn(event)
+	}
+}
+
+// watchSentinel listens to +switch-master on the sentinels until the returned function is called. go-redis
+// switches the master by itself; this only reports it.
+func (t *topology) watchSentinel(sentinelAddrs []string, masterName string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.stop = cancel
+
+	go func() {
+		for i := 0; ctx.Err() == nil; i++ {
+			sentinel := redis.NewSentinelClient(&redis.Options{
+				Addr:        sentinelAddrs[i%len(sentinelAddrs)],
+				DialTimeout: 2 * time.Second,
+			})
+
+			pubsub := sentinel.Subscribe(ctx, "+switch-master")
+			if _, err := pubsub.Receive(ctx); err == nil {
+				t.listenSentinel(ctx, pubsub, masterName)
+			}
+
+			_ = pubsub.Close()
+			_ = sentinel.Close()
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+}
+
+func (t *topology) listenSentinel(ctx context.Context, pubsub *redis.PubSub, masterName string) {
+	for {
+		msg, err := pubsub.ReceiveMessage(ctx)
+		if err != nil {
+			return
+		}
+
+		// <master name> <old ip> <old port> <new ip> <new port>
+		parts := strings.Fields(msg.Payload)
+		if len(parts) != 5 || parts[0] != masterName {
+			continue
+		}
+
+		t.emit(ctx, TopologyEvent{
+			Type:         TopologyFailover,
+			Master:       parts[0],
+			PreviousAddr: net.JoinHostPort(parts[1], parts[2]),
+			Addr:         net.JoinHostPort(parts[3], parts[4]),
+		})
+	}
+}
+
+// watchCluster reports new nodes and adds to each of them a hook reporting slot redirections.
+func (t *topology) watchCluster(client *redis.ClusterClient) {
+	client.OnNewNode(func(node *redis.Client) {
+		t.emit(context.Background(), TopologyEvent{Type: TopologyNewNode, Addr: node.Options().Addr})
+		node.AddHook(redirectHook{topology: t})
+	})
+}
+
+// redirectHook reports the MOVED and ASK errors returned by a cluster node, before go-redis follows them.
+type redirectHook struct {
+	topology *topology
+}
+
+func (h redirectHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h redirectHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		h.check(ctx, err)
+		return err
+	}
+}
+
+func (h redirectHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			h.check(ctx, cmd.Err())
+		}
+		return err
+	}
+}
+
+func (h redirectHook) check(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+
+	// "MOVED 3999 127.0.0.1:6381" or "ASK 3999 127.0.0.1:6381"
+	parts := strings.Fields(err.Error())
+	if len(parts) != 3 {
+		return
+	}
+
+	eventType := ""
+	switch parts[0] {
+	case "MOVED":
+		eventType = TopologySlotMoved
+	case "ASK":
+		eventType = TopologySlotMigrating
+	default:
+		return
+	}
+
+	slot, _ := strconv.Atoi(parts[1])
+	h.topology.emit(ctx, TopologyEvent{Type: eventType, Addr: parts[2], Slot: slot})
+}
@@ -0,0 +1,228 @@
+package sqlclient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/devluispereira/go-package/health"
+	"github.com/devluispereira/go-package/logging"
+)
+
+var logger = logging.New("sql-client")
+
+// Config holds the SQL client configuration. It can be loaded with the config package.
+// The driver must be registered by the application, e.g. with a blank import of github.com/jackc/pgx/v5/stdlib.
+type Config struct {
+	// Name identifies the database in logs, metrics and the health check ("sql:<name>"). Defaults to Driver.
+	Name   string `json:"name" env:"SQL_NAME"`
+	Driver string `json:"driver" env:"SQL_DRIVER"`
+	DSN    string `json:"dsn" env:"SQL_DSN"`
+
+	// The pool settings fall back to their defaults when zero or negative, also for a Config literal, so the pool is
+	// never unlimited.
+	MaxOpenConns    int           `json:"max_open_conns" env:"SQL_MAX_OPEN_CONNS" default:"10"`
+	MaxIdleConns    int           `json:"max_idle_conns" env:"SQL_MAX_IDLE_CONNS" default:"5"`
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime" env:"SQL_CONN_MAX_LIFETIME" default:"30m"`
+	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time" env:"SQL_CONN_MAX_IDLE_TIME" default:"5m"`
+
+	// QueryTimeout bounds every operation whose context has no earlier deadline. Defaults to 5s.
+	QueryTimeout time.Duration `json:"query_timeout" env:"SQL_QUERY_TIMEOUT" default:"5s"`
+	// SlowQueryThreshold logs operations slower than this at WARN level. Zero disables slow query logs.
+	SlowQueryThreshold time.Duration `json:"slow_query_threshold" env:"SQL_SLOW_QUERY_THRESHOLD" default:"200ms"`
+}
+
+// SQLClient wraps a *sql.DB with pool configuration, deadlines, logs, metrics and traces.
+type SQLClient struct {
+	db        *sql.DB
+	cfg       Config
+	telemetry *telemetry
+}
+
+// NewSQLClient opens the database, applies the pool configuration and registers the "sql:<name>" health checker.
+//
+// Parameters:
+//
+//	cfg: Client configuration. Driver and DSN are required.
+//
+// Behavior:
+//   - The connection is verified with a ping bounded by QueryTimeout.
+//   - Every operation runs with the QueryTimeout deadline unless the context already has an earlier one.
+//   - Operations are traced, measured (db.client.operation.duration) and logged with the request context.
+//
+// Usage:
+//
+//	import _ "github.com/jackc/pgx/v5/stdlib"
+//
+//	db, err := sqlclient.NewSQLClient(sqlclient.Config{Name: "orders", Driver: "pgx", DSN: os.Getenv("DATABASE_URL")})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	srv.OnStop(db.Close)
+func NewSQLClient(cfg Config) (*SQLClient, error) {
+	if cfg.Name == "" {
+		cfg.Name = cfg.Driver
+	}
+	if cfg.MaxOpenConns <= 0 {
+		cfg.MaxOpenConns = 10
+	}
+	if cfg.MaxIdleConns <= 0 {
+		cfg.MaxIdleConns = min(5, cfg.MaxOpenConns)
+	}
+	if cfg.ConnMaxLifetime <= 0 {
+		cfg.ConnMaxLifetime = 30 * time.Minute
+	}
+	if cfg.ConnMaxIdleTime <= 0 {
+		cfg.ConnMaxIdleTime = 5 * time.Minute
+	}
+	if cfg.QueryTimeout <= 0 {
+		cfg.QueryTimeout = 5 * time.Second
+	}
+
+	db, err := sql.Open(cfg.Driver, cfg.DSN)
+	if err != nil {
+		logger.Error().Err(err).Str("db", cfg.Name).Msg("failed to open database")
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	client := &SQLClient{db: db, cfg: cfg, telemetry: newTelemetry(cfg.Name, db)}
+
+	if err := client.Ping(context.Background()); err != nil {
+		_ = client.telemetry.close()
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	health.Register("sql:"+cfg.Name, health.CheckerFunc(client.Ping))
+
+	return client, nil
+}
+
+// Ping verifies the connection to the database.
+func (s *SQLClient) Ping(ctx context.Context) error {
+	return s.observe(ctx, "ping", "", func(ctx context.Context) error {
+		return s.db.PingContext(ctx)
+	})
+}
+
+// Exec executes a statement that returns no rows.
+func (s *SQLClient) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	err := s.observe(ctx, "exec", query, func(ctx context.Context) error {
+		var err error
+		result, err = s.db.ExecContext(ctx, query, args...)
+		return err
+	})
+
+	return result, err
+}
+
+// Query executes a query and calls scan for every returned row, within the operation deadline.
+//
+// Usage:
+//
+//	var ids []string
+//	err := db.Query(ctx, "SELECT id FROM orders WHERE tenant = $1", func(rows *sql.Rows) error {
+//		var id string
+//		if err := rows.Scan(&id); err != nil {
+//			return err
+//		}
+//		ids = append(ids, id)
+//		return nil
+//	}, tenantID)
+func (s *SQLClient) Query(ctx context.Context, query string, scan func(rows *sql.Rows) error, args ...any) error {
+	return s.observe(ctx, "query", query, func(ctx context.Context) error {
+		rows, err := s.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			if err := scan(rows); err != nil {
+				return err
+			}
+		}
+
+		return rows.Err()
+	})
+}
+
+// QueryRow executes a query expected to return at most one row and scans it into dest.
+// It returns sql.ErrNoRows when the query returns no rows.
+func (s *SQLClient) QueryRow(ctx context.Context, query string, dest []any, args ...any) error {
+	return s.observe(ctx, "query_row", query, func(ctx context.Context) error {
+		return s.db.QueryRowContext(ctx, query, args...).Scan(dest...)
+	})
+}
+
+// Transaction runs fn inside a transaction, committing when fn returns nil and rolling back otherwise.
+// The whole transaction is bounded by a single operation deadline.
+func (s *SQLClient) Transaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return s.observe(ctx, "transaction", "", func(ctx context.Context) error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+
+		if err := fn(ctx, tx); err != nil {
+			return errors.Join(err, tx.Rollback())
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// DB returns the underlying *sql.DB, for integrations that need it directly (e.g. migration tools).
+func (s *SQLClient) DB() *sql.DB {
+	return s.db
+}
+
+// Close unregisters the health checker and the pool metrics, and closes the database.
+func (s *SQLClient) Close(_ context.Context) error {
+	health.Default.Unregister("sql:" + s.cfg.Name)
+	return errors.Join(s.telemetry.close(), s.db.Close())
+}
+
+// observe runs op with the operation deadline, tracing, metrics and logging.
+func (s *SQLClient) observe(ctx context.Context, operation, query string, op func(ctx context.Context) error) error {
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > s.cfg.QueryTimeout {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.QueryTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	ctx, span := s.telemetry.start(ctx, operation, query)
+	defer span.End()
+
+	err := op(ctx)
+	duration := time.Since(start)
+	s.telemetry.record(ctx, span, operation, duration, err)
+
+	log := logging.FromContextFor(ctx, "sql-client").With().Str("db", s.cfg.Name).Logger()
+	switch {
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		log.Error().Err(err).Str("operation", operation).Str("query", query).
+			Int64("duration_ms", duration.Milliseconds()).Msg("sql operation failed")
+	case s.cfg.SlowQueryThreshold > 0 && duration >= s.cfg.SlowQueryThreshold:
+		log.Warn().Str("operation", operation).Str("query", query).
+			Int64("duration_ms", duration.Milliseconds()).Msg("slow sql operation")
+	default:
+		log.Debug().Str("operation", operation).Str("query", query).
+			Int64("duration_ms", duration.Milliseconds()).Msg("sql operation")
+	}
+
+	return err
+}
@@ -0,0 +1,79 @@
+package sqlclient
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// fakeDriver opens connections that only answer pings.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func init() {
+	sql.Register("sqlclient-fake", fakeDriver{})
+}
+
+func TestNewSQLClientAppliesPoolDefaults(t *testing.T) {
+	client, err := NewSQLClient(Config{Name: t.Name(), Driver: "sqlclient-fake"})
+	if err != nil {
+		t.Fatalf("NewSQLClient: %v", err)
+	}
+	defer client.Close(context.Background())
+
+	if open := client.DB().Stats().MaxOpenConnections; open != 10 {
+		t.Fatalf("MaxOpenConnections = %d, want 10", open)
+	}
+}
+
+func TestCloseUnregistersPoolMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(previous)
+
+	client, err := NewSQLClient(Config{Name: t.Name(), Driver: "sqlclient-fake"})
+	if err != nil {
+		t.Fatalf("NewSQLClient: %v", err)
+	}
+	if !hasMetric(t, reader, "db.client.connection.count") {
+		t.Fatal("pool metrics not reported")
+	}
+
+	if err := client.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if hasMetric(t, reader, "db.client.connection.count") {
+		t.Fatal("pool metrics still reported after Close")
+	}
+}
+
+func hasMetric(t *testing.T, reader sdkmetric.Reader, name string) bool {
+	t.Helper()
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
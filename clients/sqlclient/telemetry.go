@@ -0,0 +1,96 @@
+package sqlclient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/devluispereira/go-package/clients/sqlclient"
+
+// telemetry creates spans and records operation durations and pool usage, using the global providers
+// set by observability.Init.
+type telemetry struct {
+	name     string
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	// pool is the callback observing the pool, unregistered on close.
+	pool metric.Registration
+}
+
+func newTelemetry(name string, db *sql.DB) *telemetry {
+	meter := otel.Meter(instrumentationName)
+
+	duration, _ := meter.Float64Histogram(
+		"db.client.operation.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of SQL operations."),
+	)
+
+	connections, _ := meter.Int64ObservableGauge(
+		"db.client.connection.count",
+		metric.WithDescription("Number of connections in the pool by state."),
+	)
+	pool, _ := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		stats := db.Stats()
+		o.ObserveInt64(connections, int64(stats.InUse), metric.WithAttributes(
+			attribute.String("db.client.connection.pool.name", name),
+			attribute.String("db.client.connection.state", "used"),
+		))
+		o.ObserveInt64(connections, int64(stats.Idle), metric.WithAttributes(
+			attribute.String("db.client.connection.pool.name", name),
+			attribute.String("db.client.connection.state", "idle"),
+		))
+		return nil
+	}, connections)
+
+	return &telemetry{
+		name:     name,
+		tracer:   otel.Tracer(instrumentationName),
+		duration: duration,
+		pool:     pool,
+	}
+}
+
+// close stops observing the pool, so a closed client is no longer reported nor kept alive by the meter.
+func (t *telemetry) close() error {
+	if t.pool == nil {
+		return nil
+	}
+	return t.pool.Unregister()
+}
+
+func (t *telemetry) start(ctx context.Context, operation, query string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "sql"),
+		attribute.String("db.namespace", t.name),
+		attribute.String("db.operation.name", operation),
+	}
+	if query != "" {
+		attrs = append(attrs, attribute.String("db.query.text", query))
+	}
+
+	return t.tracer.Start(ctx, "sql."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func (t *telemetry) record(ctx context.Context, span trace.Span, operation string, duration time.Duration, err error) {
+	status := "ok"
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		status = "error"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	t.duration.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("db.namespace", t.name),
+		attribute.String("db.operation.name", operation),
+		attribute.String("status", status),
+	))
+}
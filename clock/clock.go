@@ -0,0 +1,50 @@
+// Package clock abstracts the passage of time, so time-dependent behavior (cache TTLs, retry backoff, retry
+// budgets, schedulers and throttling) can be tested deterministically with clocktest.Fake.
+package clock
+
+import "time"
+
+// Clock tells the time and creates timers.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Since returns the time elapsed since t.
+	Since(t time.Time) time.Duration
+	// NewTimer creates a Timer that fires once after d.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is the Clock equivalent of time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered when the timer fires.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, reporting false if it already fired or was stopped.
+	Stop() bool
+	// Reset changes the timer to fire after d, reporting whether it was active.
+	Reset(d time.Duration) bool
+}
+
+// Real is the Clock backed by the time package.
+var Real Clock = realClock{}
+
+// Or returns c, or Real when c is nil. Components use it to default their optional Clock field.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+func (realClock) NewTimer(d time.Duration) Timer  { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time        { return t.timer.C }
+func (t realTimer) Stop() bool                 { return t.timer.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.timer.Reset(d) }
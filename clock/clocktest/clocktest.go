@@ -0,0 +1,175 @@
+// Package clocktest provides a fake clock.Clock whose time only moves when the test advances it.
+package clocktest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/clock"
+)
+
+// Fake is a clock.Clock controlled by the test. Timers fire when Advance or Set moves the time past them.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	added  chan struct{}
+}
+
+// NewFake creates a fake clock set to start. A zero start uses 2024-01-01 00:00:00 UTC.
+//
+// Usage:
+//
+//	clk := clocktest.NewFake(time.Time{})
+//	retry := httpclient.DefaultRetryConfig()
+//	retry.Clock = clk
+//	...
+//	clk.WaitForTimers(ctx, 1)
+//	clk.Advance(time.Second)
+func NewFake(start time.Time) *Fake {
+	if start.IsZero() {
+		start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return &Fake{now: start, added: make(chan struct{}, 1)}
+}
+
+// Now returns the fake time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t.
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// NewTimer creates a timer that fires when the fake time reaches now + d.
+func (f *Fake) NewTimer(d time.Duration) clock.Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1)}
+	f.schedule(t, d)
+	return t
+}
+
+// Advance moves the time forward by d, firing the timers due on the way.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the time to t, firing the timers due. Setting a past time doesn't fire anything.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = t
+
+	sort.Slice(f.timers, func(i, j int) bool { return f.timers[i].deadline.Before(f.timers[j].deadline) })
+
+	pending := f.timers[:0]
+	for _, timer := range f.timers {
+		if timer.deadline.After(t) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.active = false
+		select {
+		case timer.c <- t:
+		default:
+		}
+	}
+	f.timers = pending
+}
+
+// Timers returns the number of active timers.
+func (f *Fake) Timers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// WaitForTimers blocks until at least n timers are active or ctx is done, so a test can advance the time only
+// after the code under test started waiting.
+func (f *Fake) WaitForTimers(ctx context.Context, n int) error {
+	for {
+		if f.Timers() >= n {
+			return nil
+		}
+
+		select {
+		case <-f.added:
+		case <-time.After(time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// schedule activates t at now + d. It must be called with f.mu held.
+func (f *Fake) schedule(t *fakeTimer, d time.Duration) {
+	t.deadline = f.now.Add(d)
+
+	if d <= 0 {
+		t.active = false
+		select {
+		case t.c <- f.now:
+		default:
+		}
+		return
+	}
+
+	t.active = true
+	f.timers = append(f.timers, t)
+
+	select {
+	case f.added <- struct{}{}:
+	default:
+	}
+}
+
+// unschedule deactivates t, reporting whether it was active. It must be called with f.mu held.
+func (f *Fake) unschedule(t *fakeTimer) bool {
+	if !t.active {
+		return false
+	}
+
+	t.active = false
+	for i, timer := range f.timers {
+		if timer == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+type fakeTimer struct {
+	clock    *Fake
+	c        chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.unschedule(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.clock.unschedule(t)
+	t.clock.schedule(t, d)
+	return active
+}
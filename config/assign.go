@@ -0,0 +1,247 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func isDuration(v reflect.Value) bool {
+	return v.Type() == durationType
+}
+
+// assign sets v from a generic value decoded from JSON or YAML.
+func assign(v reflect.Value, raw any) error {
+	if raw == nil {
+		return nil
+	}
+
+	if isDuration(v) {
+		switch r := raw.(type) {
+		case string:
+			d, err := time.ParseDuration(r)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+		case int:
+			v.SetInt(int64(time.Duration(r) * time.Second))
+		case float64:
+			v.SetInt(int64(r * float64(time.Second)))
+		case json.Number:
+			f, err := r.Float64()
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(f * float64(time.Second)))
+		default:
+			return fmt.Errorf("invalid duration: %v", raw)
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("expected object, got %T", raw)
+		}
+
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := fieldName(field)
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+
+			value, ok := lookupKey(m, name)
+			if !ok {
+				continue
+			}
+
+			if err := assign(v.Field(i), value); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+
+	case reflect.Slice:
+		items, ok := raw.([]any)
+		if !ok {
+			if s, isString := raw.(string); isString {
+				return setFromString(v, s)
+			}
+			return fmt.Errorf("expected list, got %T", raw)
+		}
+
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := assign(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+
+	case reflect.Map:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("expected object, got %T", raw)
+		}
+
+		result := reflect.MakeMapWithSize(v.Type(), len(m))
+		for key, item := range m {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := assign(elem, item); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			result.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+		v.Set(result)
+
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := assign(elem.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(elem)
+
+	default:
+		return setFromString(v, scalarString(raw))
+	}
+
+	return nil
+}
+
+// scalarString formats a decoded scalar for setFromString. Floats are formatted without exponent, so a YAML
+// 1e6 still sets an integer field.
+func scalarString(raw any) string {
+	switch r := raw.(type) {
+	case float64:
+		return strconv.FormatFloat(r, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(r), 'f', -1, 32)
+	default:
+		return fmt.Sprint(raw)
+	}
+}
+
+// decodeJSON decodes a JSON document keeping numbers as json.Number, so integers above 2^53 keep their precision.
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var raw any
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the document")
+	}
+	return raw, nil
+}
+
+func lookupKey(m map[string]any, name string) (any, bool) {
+	if value, ok := m[name]; ok {
+		return value, true
+	}
+
+	for key, value := range m {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// setFromString sets v from its textual representation, as found in env vars and `default` tags.
+func setFromString(v reflect.Value, s string) error {
+	if isDuration(v) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	case reflect.Slice:
+		parts := splitList(s)
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setFromString(slice.Index(i), part); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+
+	case reflect.Map:
+		result := reflect.MakeMap(v.Type())
+		for _, pair := range splitList(s) {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("invalid map entry: %s", pair)
+			}
+
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setFromString(elem, strings.TrimSpace(value)); err != nil {
+				return err
+			}
+			result.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)).Convert(v.Type().Key()), elem)
+		}
+		v.Set(result)
+
+	default:
+		return fmt.Errorf("unsupported field type: %s", v.Type())
+	}
+
+	return nil
+}
+
+func splitList(s string) []string {
+	var parts []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type numbersConfig struct {
+	MaxBodySize int64         `json:"max_body_size"`
+	PoolSize    uint32        `json:"pool_size"`
+	Ratio       float64       `json:"ratio"`
+	Timeout     time.Duration `json:"timeout"`
+	Limits      []int         `json:"limits"`
+}
+
+func loadFile(t *testing.T, name, content string) numbersConfig {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg numbersConfig
+	if err := Load(&cfg, WithFile(path)); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return cfg
+}
+
+func TestLoadLargeNumbersFromJSON(t *testing.T) {
+	cfg := loadFile(t, "config.json", `{
+		"max_body_size": 9007199254740993,
+		"pool_size": 1000000,
+		"ratio": 0.25,
+		"timeout": 1.5,
+		"limits": [10000000, 20000000]
+	}`)
+
+	if cfg.MaxBodySize != 9007199254740993 {
+		t.Errorf("max_body_size = %d", cfg.MaxBodySize)
+	}
+	if cfg.PoolSize != 1000000 {
+		t.Errorf("pool_size = %d", cfg.PoolSize)
+	}
+	if cfg.Ratio != 0.25 {
+		t.Errorf("ratio = %v", cfg.Ratio)
+	}
+	if cfg.Timeout != 1500*time.Millisecond {
+		t.Errorf("timeout = %v", cfg.Timeout)
+	}
+	if len(cfg.Limits) != 2 || cfg.Limits[1] != 20000000 {
+		t.Errorf("limits = %v", cfg.Limits)
+	}
+}
+
+func TestLoadExponentNumbersFromYAML(t *testing.T) {
+	cfg := loadFile(t, "config.yaml", "max_body_size: 1e6\npool_size: 2.0e3\n")
+
+	if cfg.MaxBodySize != 1000000 {
+		t.Errorf("max_body_size = %d", cfg.MaxBodySize)
+	}
+	if cfg.PoolSize != 2000 {
+		t.Errorf("pool_size = %d", cfg.PoolSize)
+	}
+}
+
+func TestDynamicApplyLargeNumbers(t *testing.T) {
+	dynamic := NewDynamic(numbersConfig{})
+	if err := dynamic.Apply(`{"max_body_size": 20000000, "timeout": "2s"}`); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if got := dynamic.Get(); got.MaxBodySize != 20000000 || got.Timeout != 2*time.Second {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loader holds the options used by Load.
+type loader struct {
+	files     []string
+	envPrefix string
+}
+
+// Option customizes how Load reads configuration.
+type Option func(*loader)
+
+// WithFile adds a JSON or YAML file (chosen by extension) as a configuration source.
+// Missing files are ignored, so the same binary can run with or without them.
+func WithFile(path string) Option {
+	return func(l *loader) {
+		l.files = append(l.files, path)
+	}
+}
+
+// WithEnvPrefix prepends prefix to every environment variable name, e.g. "MYAPP_".
+func WithEnvPrefix(prefix string) Option {
+	return func(l *loader) {
+		l.envPrefix = prefix
+	}
+}
+
+// Load populates dst, a pointer to a struct, from the configured sources.
+//
+// Sources are applied in increasing precedence:
+//  1. `default` struct tags;
+//  2. files added with WithFile, in order (keys match the `json` tag or the field name);
+//  3. environment variables named by the `env` tag.
+//
+// Nested structs are loaded recursively; the `envPrefix` tag on a struct field prefixes the env names
+// of its fields. Supported field types are strings, booleans, numbers, time.Duration (e.g. "30s"),
+// slices (comma-separated in env) and string maps ("k=v,k2=v2" in env). Fields tagged `json:"-"` are skipped.
+//
+// Usage:
+//
+//	type AppConfig struct {
+//		Server  server.ServerConfig        `json:"server"`
+//		Cache   httpclient.CacheConfig     `json:"cache"`
+//		Breaker httpclient.BreakerConfig   `json:"breaker"`
+//		Redis   redisclient.Config         `json:"redis"`
+//	}
+//
+//	var cfg AppConfig
+//	err := config.Load(&cfg, config.WithFile("config.yaml"))
+func Load(dst any, opts ...Option) error {
+	l := &loader{}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return errors.New("config: destination must be a pointer to a struct")
+	}
+
+	if err := applyDefaults(v.Elem()); err != nil {
+		return err
+	}
+
+	for _, path := range l.files {
+		raw, err := readFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := assign(v.Elem(), raw); err != nil {
+			return fmt.Errorf("config: failed to apply %s: %w", path, err)
+		}
+	}
+
+	return applyEnv(v.Elem(), l.envPrefix)
+}
+
+func readFile(path string) (any, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &raw)
+	case ".json":
+		raw, err = decodeJSON(content)
+	default:
+		return nil, fmt.Errorf("config: unsupported file extension: %s", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("config: failed to parse %s: %w", path, err)
+	}
+
+	return raw, nil
+}
+
+func applyDefaults(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || fieldName(field) == "-" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && !isDuration(fv) {
+			if err := applyDefaults(fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if err := setFromString(fv, def); err != nil {
+				return fmt.Errorf("config: invalid default for %s: %w", field.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func applyEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || fieldName(field) == "-" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && !isDuration(fv) {
+			if err := applyEnv(fv, prefix+field.Tag.Get("envPrefix")); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name, ok := field.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+
+		value, ok := os.LookupEnv(prefix + name)
+		if !ok {
+			continue
+		}
+
+		if err := setFromString(fv, value); err != nil {
+			return fmt.Errorf("config: invalid value for %s: %w", prefix+name, err)
+		}
+	}
+
+	return nil
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
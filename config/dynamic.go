@@ -0,0 +1,129 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultWatchInterval is the polling interval of WatchRedis when the given interval is not positive.
+const defaultWatchInterval = 10 * time.Second
+
+// IRedisClient defines the Redis operations used to read dynamic overrides.
+type IRedisClient interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// Dynamic holds a configuration value of type T that can be overridden at runtime,
+// notifying subscribers whenever the effective value changes.
+type Dynamic[T any] struct {
+	mu        sync.RWMutex
+	base      T
+	current   T
+	raw       string
+	listeners []func(old, new T)
+}
+
+// NewDynamic creates a Dynamic configuration whose base value is usually the result of Load.
+func NewDynamic[T any](base T) *Dynamic[T] {
+	return &Dynamic[T]{base: base, current: base}
+}
+
+// Get returns the effective configuration value.
+func (d *Dynamic[T]) Get() T {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.current
+}
+
+// OnChange registers a function called with the previous and the new value after every change.
+func (d *Dynamic[T]) OnChange(fn func(old, new T)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.listeners = append(d.listeners, fn)
+}
+
+// Apply replaces the current overrides with raw, a JSON document merged over the base value.
+// An empty document restores the base value. Listeners are only called when the overrides change.
+func (d *Dynamic[T]) Apply(raw string) error {
+	d.mu.Lock()
+
+	if raw == d.raw {
+		d.mu.Unlock()
+		return nil
+	}
+
+	next := d.base
+	if raw != "" {
+		overrides, err := decodeJSON([]byte(raw))
+		if err != nil {
+			d.mu.Unlock()
+			return fmt.Errorf("config: failed to parse overrides: %w", err)
+		}
+
+		if err := assign(reflect.ValueOf(&next).Elem(), overrides); err != nil {
+			d.mu.Unlock()
+			return fmt.Errorf("config: failed to apply overrides: %w", err)
+		}
+	}
+
+	old := d.current
+	d.current = next
+	d.raw = raw
+	listeners := append([]func(old, new T){}, d.listeners...)
+	d.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(old, next)
+	}
+
+	return nil
+}
+
+// WatchRedis polls key every interval and applies its content as JSON overrides until ctx is done.
+// A missing key restores the base value. Errors are reported to onError, when provided.
+// An interval <= 0 polls every 10 seconds.
+//
+// Usage:
+//
+//	dyn := config.NewDynamic(cfg)
+//	dyn.OnChange(func(old, new AppConfig) { ... })
+//	go dyn.WatchRedis(ctx, redis, "myapp:config", 10*time.Second, nil)
+func (d *Dynamic[T]) WatchRedis(ctx context.Context, client IRedisClient, key string, interval time.Duration, onError func(error)) {
+	poll := func() {
+		value, err := client.Get(ctx, key)
+		if err != nil && !errors.Is(err, redis.Nil) {
+			if onError != nil {
+				onError(fmt.Errorf("config: failed to read overrides: %w", err))
+			}
+			return
+		}
+
+		if err := d.Apply(value); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	poll()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			poll()
+		}
+	}
+}
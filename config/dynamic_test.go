@@ -0,0 +1,31 @@
+package config
+
+import (
+	"context"
+	"testing"
+)
+
+// cancelingRedis returns the overrides and cancels the watch on the first read.
+type cancelingRedis struct {
+	cancel context.CancelFunc
+}
+
+func (r cancelingRedis) Get(context.Context, string) (string, error) {
+	r.cancel()
+	return `{"pool_size": 5}`, nil
+}
+
+func TestWatchRedisDefaultsTheInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dynamic := NewDynamic(numbersConfig{})
+	// A zero interval used to panic in time.NewTicker.
+	dynamic.WatchRedis(ctx, cancelingRedis{cancel: cancel}, "app:config", 0, func(err error) {
+		t.Errorf("onError: %v", err)
+	})
+
+	if got := dynamic.Get(); got.PoolSize != 5 {
+		t.Fatalf("pool_size = %d, want 5", got.PoolSize)
+	}
+}
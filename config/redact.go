@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Redacted replaces secret values in the output of Redact.
+const Redacted = "[REDACTED]"
+
+// secretNames are the field and map key fragments identifying secrets, compared without case, "-" and "_".
+var secretNames = []string{"password", "passwd", "secret", "token", "apikey", "authorization", "credential", "privatekey", "dsn"}
+
+// Redact returns a JSON-friendly copy of cfg, usually a struct loaded with Load, with its secrets masked, for
+// config dumps and startup logs.
+//
+// Behavior:
+//   - Structs become maps keyed like Load reads them (`json` tag or field name); fields tagged `json:"-"` are
+//     skipped, as are functions and channels.
+//   - Fields tagged `secret:"true"`, and fields or map keys named like a secret ("password", "token",
+//     "authorization", "dsn", ...), are replaced with Redacted when not empty.
+//   - Passwords embedded in URLs, e.g. "redis://:pass@host:6379", are masked.
+//   - Durations are rendered as strings, e.g. "5s".
+//
+// Usage:
+//
+//	logger.Info().Interface("config", config.Redact(cfg)).Msg("effective configuration")
+func Redact(cfg any) any {
+	return redactValue(reflect.ValueOf(cfg))
+}
+
+func redactValue(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if isDuration(v) {
+		return time.Duration(v.Int()).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem())
+	case reflect.Struct:
+		return redactStruct(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if isSecretName(key) && !iter.Value().IsZero() {
+				out[key] = Redacted
+				continue
+			}
+			out[key] = redactValue(iter.Value())
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = redactValue(v.Index(i))
+		}
+		return out
+	case reflect.String:
+		return redactURL(v.String())
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+	default:
+		return v.Interface()
+	}
+}
+
+func redactStruct(v reflect.Value) map[string]any {
+	t := v.Type()
+	out := make(map[string]any, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := fieldName(field)
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+
+		fv := v.Field(i)
+		switch fv.Kind() {
+		case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+			continue
+		}
+
+		if (field.Tag.Get("secret") == "true" || isSecretName(field.Name)) && !fv.IsZero() {
+			out[name] = Redacted
+			continue
+		}
+
+		out[name] = redactValue(fv)
+	}
+
+	return out
+}
+
+func isSecretName(name string) bool {
+	normalized := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name))
+	for _, secret := range secretNames {
+		if strings.Contains(normalized, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL masks the password of a URL with credentials, leaving other strings untouched.
+func redactURL(s string) string {
+	if !strings.Contains(s, "://") || !strings.Contains(s, "@") {
+		return s
+	}
+
+	parsed, err := url.Parse(s)
+	if err != nil || parsed.User == nil {
+		return s
+	}
+	if _, hasPassword := parsed.User.Password(); !hasPassword {
+		return s
+	}
+
+	parsed.User = url.UserPassword(parsed.User.Username(), "xxxxx")
+	return parsed.String()
+}
@@ -0,0 +1,164 @@
+package errorreport
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/devluispereira/go-package/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var logger = logging.New("errorreport")
+
+// Levels of an Event.
+const (
+	LevelWarning = "warning"
+	LevelError   = "error"
+	LevelFatal   = "fatal"
+)
+
+// Request describes the HTTP request being served when the event happened.
+type Request struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+}
+
+// Event is an error or panic reported to a Reporter.
+type Event struct {
+	Err     error
+	Message string
+	// Level is LevelError when empty.
+	Level string
+	// Panic marks events recovered from a panic; Stack holds the goroutine stack at the recovery point.
+	Panic bool
+	Stack []byte
+	// Tags are indexed, low-cardinality values (component, route, pool); Extra holds any other detail.
+	Tags  map[string]string
+	Extra map[string]any
+	// Request is set by the server recovery middleware.
+	Request *Request
+	// Fields filled by Capture.
+	Time        time.Time
+	TraceID     string
+	SpanID      string
+	Release     string
+	Environment string
+	// Forwarded holds the forwarded headers of the request context (x-request-id, x-tenant-id, ...).
+	Forwarded map[string]string
+}
+
+// Reporter sends events to an error tracking service. Report must not block the caller for long.
+type Reporter interface {
+	Report(ctx context.Context, event Event)
+}
+
+type release struct {
+	version     string
+	environment string
+}
+
+var (
+	reporter atomic.Pointer[Reporter]
+	current  atomic.Pointer[release]
+)
+
+// SetReporter sets the Reporter receiving every captured event. Until it is called, events are discarded.
+//
+// Usage:
+//
+//	sentry, err := errorreport.NewSentry(errorreport.SentryConfig{DSN: os.Getenv("SENTRY_DSN")})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	errorreport.SetReporter(sentry)
+//	srv.OnStop(sentry.Flush)
+func SetReporter(r Reporter) {
+	if r == nil {
+		reporter.Store(nil)
+		return
+	}
+	reporter.Store(&r)
+}
+
+// SetRelease sets the release (e.g. "catalog@1.4.2") and environment attached to every event. observability.Init
+// sets them from the ServiceInfo.
+func SetRelease(version, environment string) {
+	current.Store(&release{version: version, environment: environment})
+}
+
+// Enabled reports whether a Reporter is set.
+func Enabled() bool {
+	return reporter.Load() != nil
+}
+
+// Capture reports an event, attaching the time, release, trace IDs and forwarded headers of ctx. It is a no-op
+// without a Reporter.
+func Capture(ctx context.Context, event Event) {
+	r := reporter.Load()
+	if r == nil {
+		return
+	}
+
+	if event.Level == "" {
+		event.Level = LevelError
+	}
+	if event.Message == "" && event.Err != nil {
+		event.Message = event.Err.Error()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if rel := current.Load(); rel != nil {
+		event.Release = rel.version
+		event.Environment = rel.environment
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		event.TraceID = span.TraceID().String()
+		event.SpanID = span.SpanID().String()
+	}
+	if headers, ok := ctx.Value("forwardedHeaders").(map[string]string); ok {
+		event.Forwarded = maps.Clone(headers)
+	}
+
+	(*r).Report(ctx, event)
+}
+
+// CaptureError reports err with the given tags. See Capture.
+//
+// Usage:
+//
+//	errorreport.CaptureError(ctx, err, map[string]string{"component": "billing"})
+func CaptureError(ctx context.Context, err error, tags map[string]string) {
+	Capture(ctx, Event{Err: err, Tags: tags})
+}
+
+// CapturePanic reports a value recovered from a panic, with the current stack. Call it from the deferred
+// function that recovered it.
+//
+// Usage:
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			errorreport.CapturePanic(ctx, r, map[string]string{"component": "consumer"})
+//		}
+//	}()
+func CapturePanic(ctx context.Context, recovered any, tags map[string]string) {
+	err, ok := recovered.(error)
+	if !ok {
+		err = fmt.Errorf("%v", recovered)
+	}
+
+	Capture(ctx, Event{
+		Err:     err,
+		Message: fmt.Sprintf("panic: %v", recovered),
+		Level:   LevelFatal,
+		Panic:   true,
+		Stack:   debug.Stack(),
+		Tags:    tags,
+	})
+}
@@ -0,0 +1,292 @@
+package errorreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SentryConfig holds the configuration of the Sentry reporter. It can be loaded with the config package.
+type SentryConfig struct {
+	// DSN is the project DSN, e.g. "https://<key>@o1.ingest.sentry.io/<project>".
+	DSN string `json:"dsn" env:"SENTRY_DSN"`
+	// Timeout bounds each delivery. Defaults to 5 seconds.
+	Timeout time.Duration `json:"timeout" env:"SENTRY_TIMEOUT" default:"5s"`
+	// MaxInFlight is the number of events being delivered at once; events over it are dropped. Defaults to 32.
+	MaxInFlight int `json:"max_in_flight" env:"SENTRY_MAX_IN_FLIGHT" default:"32"`
+	// ServerName identifies the instance. If empty, uses the hostname.
+	ServerName string `json:"server_name" env:"SENTRY_SERVER_NAME"`
+	// Transport sends the events. If nil, uses http.DefaultTransport.
+	Transport http.RoundTripper `json:"-"`
+}
+
+// Sentry is a Reporter delivering events to Sentry, or any service implementing its envelope endpoint
+// (GlitchTip, self-hosted Sentry), without blocking the caller.
+type Sentry struct {
+	cfg      SentryConfig
+	client   *http.Client
+	endpoint string
+	auth     string
+	inFlight chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewSentry creates a Sentry reporter from its DSN.
+//
+// Usage:
+//
+//	sentry, err := errorreport.NewSentry(errorreport.SentryConfig{DSN: os.Getenv("SENTRY_DSN")})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	errorreport.SetReporter(sentry)
+//	srv.OnStop(sentry.Flush)
+func NewSentry(cfg SentryConfig) (*Sentry, error) {
+	dsn, err := url.Parse(cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry dsn: %w", err)
+	}
+	if dsn.User == nil || dsn.User.Username() == "" {
+		return nil, errors.New("invalid sentry dsn: missing public key")
+	}
+
+	path := strings.TrimSuffix(dsn.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project := path[slash+1:]
+	if slash < 0 || project == "" {
+		return nil, errors.New("invalid sentry dsn: missing project id")
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = 32
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName, _ = os.Hostname()
+	}
+	if cfg.Transport == nil {
+		cfg.Transport = http.DefaultTransport
+	}
+
+	return &Sentry{
+		cfg:      cfg,
+		client:   &http.Client{Transport: cfg.Transport, Timeout: cfg.Timeout},
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/envelope/", dsn.Scheme, dsn.Host, path[:slash], project),
+		auth:     "Sentry sentry_version=7, sentry_client=go-package/1.0, sentry_key=" + dsn.User.Username(),
+		inFlight: make(chan struct{}, cfg.MaxInFlight),
+	}, nil
+}
+
+// Report delivers the event in background. Events are dropped, and logged, when MaxInFlight deliveries are
+// already running.
+func (s *Sentry) Report(ctx context.Context, event Event) {
+	select {
+	case s.inFlight <- struct{}{}:
+	default:
+		logger.Warn().Str("message", event.Message).Msg("errorreport: too many events in flight, dropping event")
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer func() {
+			<-s.inFlight
+			s.wg.Done()
+		}()
+
+		if err := s.send(context.WithoutCancel(ctx), event); err != nil {
+			logger.Error().Err(err).Str("message", event.Message).Msg("errorreport: failed to deliver event")
+		}
+	}()
+}
+
+// Flush waits for the events being delivered, until ctx is done. Its signature matches server.Hook, so it can
+// be registered with srv.OnStop.
+func (s *Sentry) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Sentry) send(ctx context.Context, event Event) error {
+	payload := s.payload(event)
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	var envelope bytes.Buffer
+	header, _ := json.Marshal(map[string]string{"event_id": payload.EventID, "sent_at": time.Now().UTC().Format(time.RFC3339)})
+	envelope.Write(header)
+	envelope.WriteString("\n")
+	envelope.WriteString(`{"type":"event","length":` + strconv.Itoa(len(body)) + "}\n")
+	envelope.Write(body)
+	envelope.WriteString("\n")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, &envelope)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sentryEvent is the Sentry event payload.
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Exception   *sentryExceptions `json:"exception,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	Request     *sentryRequest    `json:"request,omitempty"`
+	Contexts    map[string]any    `json:"contexts,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Mechanism  map[string]any    `json:"mechanism"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+}
+
+type sentryRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+func (s *Sentry) payload(event Event) sentryEvent {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	payload := sentryEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   event.Time.Format(time.RFC3339Nano),
+		Level:       event.Level,
+		Platform:    "go",
+		Release:     event.Release,
+		Environment: event.Environment,
+		ServerName:  s.cfg.ServerName,
+		Message:     event.Message,
+		Tags:        map[string]string{},
+		Extra:       event.Extra,
+	}
+
+	for k, v := range event.Forwarded {
+		payload.Tags[k] = v
+	}
+	for k, v := range event.Tags {
+		payload.Tags[k] = v
+	}
+
+	if event.Err != nil {
+		exception := sentryException{
+			Type:      fmt.Sprintf("%T", event.Err),
+			Value:     event.Err.Error(),
+			Mechanism: map[string]any{"type": "generic", "handled": !event.Panic},
+		}
+		if event.Panic {
+			exception.Type = "panic"
+			exception.Mechanism["type"] = "panic"
+		}
+		if frames := parseStack(event.Stack); len(frames) > 0 {
+			exception.Stacktrace = &sentryStacktrace{Frames: frames}
+		}
+		payload.Exception = &sentryExceptions{Values: []sentryException{exception}}
+	}
+
+	if event.Request != nil {
+		payload.Request = &sentryRequest{Method: event.Request.Method, URL: event.Request.URL, Headers: event.Request.Headers}
+	}
+
+	if event.TraceID != "" {
+		payload.Contexts = map[string]any{"trace": map[string]string{"trace_id": event.TraceID, "span_id": event.SpanID}}
+	}
+
+	return payload
+}
+
+// parseStack converts a debug.Stack output to Sentry frames, outermost caller first.
+func parseStack(stack []byte) []sentryFrame {
+	lines := strings.Split(strings.TrimSpace(string(stack)), "\n")
+	if len(lines) < 3 {
+		return nil
+	}
+
+	var frames []sentryFrame
+	for i := 1; i+1 < len(lines); i += 2 {
+		function := lines[i]
+		if creator, ok := strings.CutPrefix(function, "created by "); ok {
+			function, _, _ = strings.Cut(creator, " in goroutine")
+		} else if paren := strings.LastIndex(function, "("); paren > 0 {
+			function = function[:paren]
+		}
+
+		location := strings.TrimSpace(lines[i+1])
+		if space := strings.Index(location, " "); space > 0 {
+			location = location[:space]
+		}
+		colon := strings.LastIndex(location, ":")
+		if colon < 0 {
+			continue
+		}
+		line, _ := strconv.Atoi(location[colon+1:])
+
+		frames = append([]sentryFrame{{Function: function, AbsPath: location[:colon], Lineno: line}}, frames...)
+	}
+	return frames
+}
@@ -0,0 +1,124 @@
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// ErrFlagNotFound is returned by a Provider when the flag does not exist.
+var ErrFlagNotFound = errors.New("flag not found")
+
+// Flag represents a feature flag definition.
+type Flag struct {
+	Key     string `json:"key"`
+	Enabled bool   `json:"enabled"`
+	// Percentage of units (devices/users) that receive the flag, from 0 to 100. Nil (omitted in JSON) means all
+	// units and 0 means none, so a rollout can be paused without disabling the flag.
+	Percentage *int `json:"percentage,omitempty"`
+}
+
+// Provider defines the source of feature flag definitions.
+type Provider interface {
+	GetFlag(ctx context.Context, key string) (*Flag, error)
+}
+
+// Client evaluates feature flags using a Provider, caching definitions in memory for the refresh interval.
+type Client struct {
+	provider Provider
+	refresh  time.Duration
+	mu       sync.RWMutex
+	flags    map[string]cachedFlag
+}
+
+type cachedFlag struct {
+	flag      *Flag
+	expiresAt time.Time
+}
+
+// NewClient creates a new feature flag Client.
+//
+// Parameters:
+//
+//	provider: Source of flag definitions (RedisProvider, HTTPProvider or a custom implementation).
+//	refresh: How long a flag definition is cached in memory before being fetched again.
+//
+// Usage:
+//
+//	flags := featureflags.NewClient(&featureflags.RedisProvider{Client: redis, KeyPrefix: "flags:"}, 30*time.Second)
+//	if flags.IsEnabled(ctx, "new-home", deviceID) { ... }
+func NewClient(provider Provider, refresh time.Duration) *Client {
+	return &Client{
+		provider: provider,
+		refresh:  refresh,
+		flags:    make(map[string]cachedFlag),
+	}
+}
+
+// IsEnabled reports whether the flag is enabled for the given unit (device ID, user ID, etc).
+// Unknown flags and provider errors evaluate to false.
+func (c *Client) IsEnabled(ctx context.Context, key string, unit string) bool {
+	flag, err := c.getFlag(ctx, key)
+	if err != nil {
+		return false
+	}
+
+	return Evaluate(flag, unit)
+}
+
+func (c *Client) getFlag(ctx context.Context, key string) (*Flag, error) {
+	c.mu.RLock()
+	cached, ok := c.flags[key]
+	c.mu.RUnlock()
+
+	if ok && time.Now().Before(cached.expiresAt) {
+		if cached.flag == nil {
+			return nil, ErrFlagNotFound
+		}
+		return cached.flag, nil
+	}
+
+	flag, err := c.provider.GetFlag(ctx, key)
+	if err != nil && !errors.Is(err, ErrFlagNotFound) {
+		if ok && cached.flag != nil {
+			return cached.flag, nil
+		}
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.flags[key] = cachedFlag{flag: flag, expiresAt: time.Now().Add(c.refresh)}
+	c.mu.Unlock()
+
+	if flag == nil {
+		return nil, ErrFlagNotFound
+	}
+
+	return flag, nil
+}
+
+// Evaluate reports whether the flag is enabled for the given unit.
+// Percentage rollouts are deterministic: the same unit always gets the same result for the same flag.
+func Evaluate(flag *Flag, unit string) bool {
+	if flag == nil || !flag.Enabled {
+		return false
+	}
+
+	if flag.Percentage == nil || *flag.Percentage >= 100 {
+		return true
+	}
+
+	if *flag.Percentage <= 0 || unit == "" {
+		return false
+	}
+
+	return bucket(flag.Key, unit) < uint32(*flag.Percentage)
+}
+
+func bucket(key, unit string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key + ":" + unit))
+	return h.Sum32() % 100
+}
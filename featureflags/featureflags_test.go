@@ -0,0 +1,192 @@
+package featureflags
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/devluispereira/go-package/clock/clocktest"
+)
+
+func percentage(p int) *int {
+	return &p
+}
+
+func TestEvaluatePercentage(t *testing.T) {
+	for _, tc := range []struct {
+		percentage *int
+		want       int
+	}{
+		{nil, 1000},
+		{percentage(100), 1000},
+		{percentage(0), 0},
+		{percentage(-1), 0},
+	} {
+		flag := &Flag{Key: "new-home", Enabled: true, Percentage: tc.percentage}
+		enabled := 0
+		for i := range 1000 {
+			if Evaluate(flag, strconv.Itoa(i)) {
+				enabled++
+			}
+		}
+		if enabled != tc.want {
+			t.Errorf("percentage %v: enabled for %d units, want %d", tc.percentage, enabled, tc.want)
+		}
+	}
+
+	flag := &Flag{Key: "new-home", Enabled: true, Percentage: percentage(30)}
+	enabled := 0
+	for i := range 1000 {
+		if Evaluate(flag, strconv.Itoa(i)) {
+			enabled++
+		}
+	}
+	if enabled < 200 || enabled > 400 {
+		t.Errorf("percentage 30: enabled for %d of 1000 units", enabled)
+	}
+}
+
+func TestHTTPProviderBacksOffAfterFailures(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"flags": {"new-home": {"enabled": true}}}`))
+	}))
+	defer server.Close()
+
+	clk := clocktest.NewFake(time.Time{})
+	provider := &HTTPProvider{
+		Client:  httpclient.NewHTTPClient(server.URL, time.Second),
+		Path:    "/flags",
+		Refresh: time.Minute,
+		Clock:   clk,
+	}
+	ctx := context.Background()
+
+	for range 5 {
+		if _, err := provider.GetFlag(ctx, "new-home"); err == nil {
+			t.Fatal("got no error from a failing document")
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("fetched %d times, want 1 until the retry delay elapses", n)
+	}
+
+	healthy.Store(true)
+	clk.Advance(minFetchRetry)
+	flag, err := provider.GetFlag(ctx, "new-home")
+	if err != nil || !flag.Enabled {
+		t.Fatalf("got %+v, %v, want the flag after the retry", flag, err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("fetched %d times, want 2", n)
+	}
+}
+
+func TestHTTPProviderServesStaleFlagsDuringRefresh(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) > 1 {
+			<-release
+		}
+		_, _ = w.Write([]byte(`{"flags": {"new-home": {"enabled": true}}}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	clk := clocktest.NewFake(time.Time{})
+	provider := &HTTPProvider{
+		Client:  httpclient.NewHTTPClient(server.URL, 5*time.Second),
+		Path:    "/flags",
+		Refresh: time.Minute,
+		Clock:   clk,
+	}
+	ctx := context.Background()
+
+	if _, err := provider.GetFlag(ctx, "new-home"); err != nil {
+		t.Fatalf("GetFlag: %v", err)
+	}
+	clk.Advance(time.Minute)
+
+	// The first caller refreshes, blocked by the server; the others read the previous document.
+	go func() { _, _ = provider.GetFlag(ctx, "new-home") }()
+	for calls.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := provider.GetFlag(ctx, "new-home")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("GetFlag during refresh: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("GetFlag blocked by the refresh")
+	}
+}
+
+func TestHTTPProviderRefreshesInTheBackground(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			_, _ = w.Write([]byte(`{"flags": {"new-home": {"enabled": false}}}`))
+			return
+		}
+		<-release
+		_, _ = w.Write([]byte(`{"flags": {"new-home": {"enabled": true}}}`))
+	}))
+	defer server.Close()
+
+	clk := clocktest.NewFake(time.Time{})
+	provider := &HTTPProvider{
+		Client:  httpclient.NewHTTPClient(server.URL, 5*time.Second),
+		Path:    "/flags",
+		Refresh: time.Minute,
+		Clock:   clk,
+	}
+
+	if _, err := provider.GetFlag(context.Background(), "new-home"); err != nil {
+		t.Fatalf("GetFlag: %v", err)
+	}
+	clk.Advance(time.Minute)
+
+	// The triggering request doesn't wait for the refresh, and its cancellation doesn't fail it.
+	ctx, cancel := context.WithCancel(context.Background())
+	flag, err := provider.GetFlag(ctx, "new-home")
+	if err != nil || flag.Enabled {
+		t.Fatalf("got %+v, %v, want the current flags", flag, err)
+	}
+	cancel()
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		flag, err := provider.GetFlag(context.Background(), "new-home")
+		if err == nil && flag.Enabled {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %+v, %v, want the refreshed flags", flag, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("fetched %d times, want 2", n)
+	}
+}
@@ -0,0 +1,77 @@
+package featureflags
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultUnitHeaders defines the headers used, in order, to identify the rollout unit of a request.
+var defaultUnitHeaders = []string{"x-device-id", "x-hsid"}
+
+type EvaluatorKeyType struct{}
+
+// Evaluator evaluates flags for the unit of the current request.
+type Evaluator struct {
+	client *Client
+	unit   string
+}
+
+// Enabled reports whether the flag is enabled for the current request.
+func (e *Evaluator) Enabled(ctx context.Context, key string) bool {
+	return e.client.IsEnabled(ctx, key, e.unit)
+}
+
+// Unit returns the rollout unit identified for the current request.
+func (e *Evaluator) Unit() string {
+	return e.unit
+}
+
+// Middleware injects an Evaluator into the request context, keyed on the request's rollout unit.
+//
+// Parameters:
+//
+//	client: Feature flag client.
+//	unitHeaders: Headers used to identify the rollout unit. If empty, uses x-device-id and x-hsid.
+//
+// Usage:
+//
+//	app.Use(featureflags.Middleware(flags))
+//
+//	// In a handler:
+//	if featureflags.Enabled(c.UserContext(), "new-home") { ... }
+func Middleware(client *Client, unitHeaders ...string) fiber.Handler {
+	if len(unitHeaders) == 0 {
+		unitHeaders = defaultUnitHeaders
+	}
+
+	return func(c *fiber.Ctx) error {
+		var unit string
+		for _, h := range unitHeaders {
+			if unit = c.Get(h); unit != "" {
+				break
+			}
+		}
+
+		evaluator := &Evaluator{client: client, unit: unit}
+		c.SetUserContext(context.WithValue(c.UserContext(), EvaluatorKeyType{}, evaluator))
+		return c.Next()
+	}
+}
+
+// FromContext returns the Evaluator injected by Middleware, if any.
+func FromContext(ctx context.Context) (*Evaluator, bool) {
+	evaluator, ok := ctx.Value(EvaluatorKeyType{}).(*Evaluator)
+	return evaluator, ok
+}
+
+// Enabled reports whether the flag is enabled for the request carried by ctx.
+// Returns false when Middleware was not applied.
+func Enabled(ctx context.Context, key string) bool {
+	evaluator, ok := FromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	return evaluator.Enabled(ctx, key)
+}
@@ -0,0 +1,184 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/devluispereira/go-package/clock"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// IRedisClient defines the Redis operations used by RedisProvider.
+type IRedisClient interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// RedisProvider reads flags stored in Redis as JSON under KeyPrefix + flag key.
+type RedisProvider struct {
+	Client    IRedisClient
+	KeyPrefix string
+}
+
+func (p *RedisProvider) GetFlag(ctx context.Context, key string) (*Flag, error) {
+	value, err := p.Client.Get(ctx, p.KeyPrefix+key)
+	if errors.Is(err, redis.Nil) || (err == nil && value == "") {
+		return nil, ErrFlagNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flag from redis: %w", err)
+	}
+
+	var flag Flag
+	if err := json.Unmarshal([]byte(value), &flag); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal flag: %w", err)
+	}
+
+	flag.Key = key
+	return &flag, nil
+}
+
+// HTTPProvider reads flags from a remote JSON document (ConfigCat-style), with the format:
+//
+//	{"flags": {"new-home": {"enabled": true, "percentage": 10}}}
+//
+// The whole document is fetched at most once per Refresh interval. Only the first fetch is waited for; later
+// refreshes run in the background, so every caller, including the one that triggered the refresh, keeps reading
+// the previous document. Fetches don't follow the cancellation of the caller's request, and are bounded by Timeout
+// instead. After a failed fetch the previous document is kept, and the next fetch waits from 1s, doubling after
+// each consecutive failure, up to Refresh (or 1m).
+type HTTPProvider struct {
+	Client  *httpclient.HTTPClient
+	Path    string
+	Refresh time.Duration
+	// Timeout bounds each fetch of the document. Defaults to 10s.
+	Timeout time.Duration
+	// Clock times the refresh and the retries. Defaults to clock.Real.
+	Clock clock.Clock
+
+	group singleflight.Group
+
+	mu        sync.Mutex
+	flags     map[string]Flag
+	nextFetch time.Time
+	fetching  bool
+	failures  int
+	lastErr   error
+}
+
+// Delays between fetches after consecutive failures.
+const (
+	minFetchRetry = time.Second
+	maxFetchRetry = time.Minute
+)
+
+// defaultFetchTimeout bounds a fetch when HTTPProvider.Timeout is not set.
+const defaultFetchTimeout = 10 * time.Second
+
+type flagsDocument struct {
+	Flags map[string]Flag `json:"flags"`
+}
+
+func (p *HTTPProvider) GetFlag(ctx context.Context, key string) (*Flag, error) {
+	flags, err := p.document(ctx)
+	if flags == nil {
+		return nil, err
+	}
+
+	flag, ok := flags[key]
+	if !ok {
+		return nil, ErrFlagNotFound
+	}
+
+	flag.Key = key
+	return &flag, nil
+}
+
+// document returns the current flags, fetching them when due. Only the first fetch is waited for by every caller;
+// later ones run in the background.
+func (p *HTTPProvider) document(ctx context.Context) (map[string]Flag, error) {
+	p.mu.Lock()
+	flags := p.flags
+	due := !clock.Or(p.Clock).Now().Before(p.nextFetch)
+
+	switch {
+	case flags == nil && !due:
+		err := p.lastErr
+		p.mu.Unlock()
+		return nil, err
+
+	case flags == nil:
+		p.mu.Unlock()
+		v, err, _ := p.group.Do("flags", func() (any, error) {
+			return p.fetch(ctx)
+		})
+		flags, _ := v.(map[string]Flag)
+		return flags, err
+
+	case !due || p.fetching:
+		p.mu.Unlock()
+		return flags, nil
+	}
+
+	p.fetching = true
+	p.mu.Unlock()
+
+	go func() { _, _ = p.fetch(ctx) }()
+	return flags, nil
+}
+
+// fetch fetches the document without holding the mutex, then records the flags or the failure. It keeps the
+// values of ctx, e.g. the trace, but not its cancellation, so a canceled request doesn't fail the fetch shared by
+// other callers.
+func (p *HTTPProvider) fetch(ctx context.Context) (map[string]Flag, error) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultFetchTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	doc, resp, err := httpclient.GetAs[flagsDocument](ctx, p.Client, p.Path)
+	switch {
+	case resp != nil && resp.StatusCode >= 300:
+		err = fmt.Errorf("unexpected status fetching flags: %d", resp.StatusCode)
+	case err != nil:
+		err = fmt.Errorf("failed to fetch flags: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.fetching = false
+	now := clock.Or(p.Clock).Now()
+
+	if err != nil {
+		p.failures++
+		p.lastErr = err
+		p.nextFetch = now.Add(p.retryDelay())
+		return p.flags, err
+	}
+
+	p.flags = doc.Flags
+	if p.flags == nil {
+		p.flags = make(map[string]Flag)
+	}
+	p.failures = 0
+	p.lastErr = nil
+	p.nextFetch = now.Add(p.Refresh)
+	return p.flags, nil
+}
+
+func (p *HTTPProvider) retryDelay() time.Duration {
+	ceiling := maxFetchRetry
+	if p.Refresh > 0 {
+		ceiling = max(p.Refresh, minFetchRetry)
+	}
+	return min(minFetchRetry<<min(p.failures-1, 6), ceiling)
+}
@@ -0,0 +1,250 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/sync/singleflight"
+)
+
+// Status is the result of a check or of the whole report.
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Checker verifies a dependency (database, cache, downstream API). It returns nil when healthy.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the Checker interface.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// CheckResult is the outcome of a single checker.
+type CheckResult struct {
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// LatencyMs is how long the check took, in milliseconds.
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// Report aggregates the results of every registered checker. Status is down when any check fails.
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+	// CheckedAt is when the checks ran; reports are cached for the registry cache TTL.
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Registry holds the named checkers used for readiness.
+type Registry struct {
+	mu       sync.RWMutex
+	checkers map[string]Checker
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	group  singleflight.Group
+	cached *Report
+}
+
+// Option customizes a Registry.
+type Option func(*Registry)
+
+// WithTimeout sets the timeout of each check. Defaults to 2s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(r *Registry) {
+		r.timeout = timeout
+	}
+}
+
+// WithCacheTTL sets how long a report is reused before the checks run again. Defaults to 1s; 0 disables caching.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(r *Registry) {
+		r.cacheTTL = ttl
+	}
+}
+
+// Default is the registry used by the toolkit clients to register their checkers.
+var Default = NewRegistry()
+
+// NewRegistry creates an empty registry.
+func NewRegistry(opts ...Option) *Registry {
+	r := &Registry{
+		checkers: make(map[string]Checker),
+		timeout:  2 * time.Second,
+		cacheTTL: time.Second,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Configure applies options to an existing registry, e.g. the Default one.
+//
+// Usage:
+//
+//	health.Default.Configure(health.WithTimeout(500*time.Millisecond), health.WithCacheTTL(2*time.Second))
+func (r *Registry) Configure(opts ...Option) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.cached = nil
+}
+
+// Register adds or replaces the checker with the given name.
+func (r *Registry) Register(name string, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers[name] = checker
+	r.cached = nil
+}
+
+// Unregister removes the checker with the given name.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checkers, name)
+	r.cached = nil
+}
+
+// Names returns the registered checker names, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.checkers))
+	for name := range r.checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Run executes every registered checker and aggregates the results.
+//
+// Behavior:
+//   - Checks run in parallel, each with the registry timeout, so a slow dependency can't delay the report
+//     beyond it. A check still running at the timeout is reported down, even if it ignores its context.
+//   - The report is cached for the registry cache TTL, and concurrent calls share a single run.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	cached, ttl := r.cached, r.cacheTTL
+	r.mu.RUnlock()
+
+	if cached != nil && time.Since(cached.CheckedAt) < ttl {
+		return *cached
+	}
+
+	report, _, _ := r.group.Do("run", func() (any, error) {
+		report := r.run(context.WithoutCancel(ctx))
+
+		r.mu.Lock()
+		r.cached = &report
+		r.mu.Unlock()
+
+		return report, nil
+	})
+
+	return report.(Report)
+}
+
+func (r *Registry) run(ctx context.Context) Report {
+	r.mu.RLock()
+	checkers := make(map[string]Checker, len(r.checkers))
+	for name, checker := range r.checkers {
+		checkers[name] = checker
+	}
+	timeout := r.timeout
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checkers)), CheckedAt: time.Now()}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := runCheck(ctx, checker, timeout)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func runCheck(ctx context.Context, checker Checker, timeout time.Duration) CheckResult {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %s", timeout)
+	}
+
+	result := CheckResult{Status: StatusUp, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Register adds a checker to the Default registry.
+func Register(name string, checker Checker) {
+	Default.Register(name, checker)
+}
+
+// Handler returns a Fiber handler reporting the registry as JSON, with 200 when every check is up and 503 otherwise.
+//
+// Usage:
+//
+//	srv.App.Get("/readyz", health.Handler(health.Default))
+func Handler(registry *Registry) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report := registry.Run(c.UserContext())
+
+		status := fiber.StatusOK
+		if report.Status != StatusUp {
+			status = fiber.StatusServiceUnavailable
+		}
+
+		return c.Status(status).JSON(report)
+	}
+}
@@ -0,0 +1,236 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/rs/zerolog"
+)
+
+// levels holds the global and per-component minimum levels.
+type levels struct {
+	global     zerolog.Level
+	components map[string]zerolog.Level
+}
+
+var (
+	// levelsMu serializes updates; active is read on every event, so it is swapped as a whole.
+	levelsMu sync.Mutex
+	active   = func() *atomic.Pointer[levels] {
+		p := new(atomic.Pointer[levels])
+		p.Store(&levels{global: zerolog.DebugLevel, components: map[string]zerolog.Level{}})
+		return p
+	}()
+	// configured holds the levels read from LOG_LEVEL_FILE or LOG_LEVEL at boot or on SIGHUP, restored by
+	// ResetLevel and ResetComponentLevel. Guarded by levelsMu.
+	configured = levels{global: zerolog.DebugLevel, components: map[string]zerolog.Level{}}
+)
+
+// levelHook discards the events below the level of the logger component.
+type levelHook struct {
+	component string
+}
+
+func (h levelHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	l := active.Load()
+
+	threshold, ok := l.components[h.component]
+	if !ok {
+		threshold = l.global
+	}
+
+	if level != zerolog.NoLevel && level < threshold {
+		e.Discard()
+	}
+}
+
+// SetLevel sets the minimum level of every component without a level of its own.
+func SetLevel(level string) error {
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	update(func(l *levels) { l.global = parsed })
+	return nil
+}
+
+// SetComponentLevel sets the minimum level of a component (the name given to New, e.g. "http-client"), which
+// may be more or less verbose than the global level. An empty level removes the override.
+func SetComponentLevel(component, level string) error {
+	if level == "" {
+		update(func(l *levels) { delete(l.components, component) })
+		return nil
+	}
+
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	update(func(l *levels) { l.components[component] = parsed })
+	return nil
+}
+
+// ResetLevel restores the global level the process was configured with: the one read from LOG_LEVEL_FILE or
+// LOG_LEVEL, or debug without them. It undoes SetLevel, e.g. when a runtime override is removed.
+func ResetLevel() {
+	levelsMu.Lock()
+	global := configured.global
+	levelsMu.Unlock()
+
+	update(func(l *levels) { l.global = global })
+}
+
+// ResetComponentLevel restores the level the component was configured with in LOG_LEVEL_FILE or LOG_LEVEL,
+// removing its override when it had none.
+func ResetComponentLevel(component string) {
+	levelsMu.Lock()
+	level, ok := configured.components[component]
+	levelsMu.Unlock()
+
+	update(func(l *levels) {
+		if ok {
+			l.components[component] = level
+		} else {
+			delete(l.components, component)
+		}
+	})
+}
+
+// SetLevels applies a level spec: a global level optionally followed by component levels, e.g.
+// "info,http-client=debug,redis-client=warn". Component overrides not in the spec are removed, and the global
+// level defaults to debug.
+func SetLevels(spec string) error {
+	next := levels{global: zerolog.DebugLevel, components: map[string]zerolog.Level{}}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		component, level, isComponent := strings.Cut(part, "=")
+		if !isComponent {
+			level = component
+		}
+
+		parsed, err := parseLevel(strings.TrimSpace(level))
+		if err != nil {
+			return err
+		}
+
+		if isComponent {
+			next.components[strings.TrimSpace(component)] = parsed
+		} else {
+			next.global = parsed
+		}
+	}
+
+	update(func(l *levels) { *l = next })
+	return nil
+}
+
+// Levels returns the current levels: "global" and one entry per component override.
+func Levels() map[string]string {
+	l := active.Load()
+
+	result := map[string]string{"global": l.global.String()}
+	for component, level := range l.components {
+		result[component] = level.String()
+	}
+	return result
+}
+
+// WatchSIGHUP reloads the level spec when the process receives SIGHUP, until ctx is done. The spec is read from
+// the file named by LOG_LEVEL_FILE (e.g. a mounted ConfigMap) or, without it, from LOG_LEVEL.
+//
+// Usage:
+//
+//	logging.WatchSIGHUP(ctx)
+//	// kill -HUP <pid> after updating the file
+func WatchSIGHUP(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(signals)
+
+		logger := New("logging")
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				if err := loadLevelSpec(); err != nil {
+					logger.Error().Err(err).Msg("failed to reload log levels")
+					continue
+				}
+				logger.Info().Interface("levels", Levels()).Msg("log levels reloaded")
+			}
+		}
+	}()
+}
+
+// loadLevelSpec applies the level spec from LOG_LEVEL_FILE or LOG_LEVEL, keeping it as the configured levels.
+func loadLevelSpec() error {
+	spec, err := levelSpec()
+	if err == nil {
+		err = SetLevels(spec)
+	}
+	if err != nil {
+		return err
+	}
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	l := active.Load()
+	configured = levels{global: l.global, components: maps.Clone(l.components)}
+	return nil
+}
+
+// levelSpec reads the level spec from LOG_LEVEL_FILE or LOG_LEVEL.
+func levelSpec() (string, error) {
+	if path := os.Getenv("LOG_LEVEL_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read log level file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	return os.Getenv("LOG_LEVEL"), nil
+}
+
+func parseLevel(level string) (zerolog.Level, error) {
+	parsed, err := zerolog.ParseLevel(strings.ToLower(level))
+	if err != nil || level == "" {
+		return 0, fmt.Errorf("invalid log level %q", level)
+	}
+	return parsed, nil
+}
+
+// update applies fn to a copy of the levels and publishes it, lowering the zerolog global level to the most
+// verbose configured level so the events reach levelHook.
+func update(fn func(l *levels)) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	previous := active.Load()
+	next := levels{global: previous.global, components: maps.Clone(previous.components)}
+	fn(&next)
+	active.Store(&next)
+
+	lowest := next.global
+	for _, level := range next.components {
+		lowest = min(lowest, level)
+	}
+	zerolog.SetGlobalLevel(lowest)
+}
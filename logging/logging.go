@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Output formats accepted by LOG_FORMAT.
+const (
+	FormatJSON   = "json"
+	FormatPretty = "pretty"
+)
+
+type loggerKeyType struct{}
+
+var (
+	root   zerolog.Logger
+	fields atomic.Pointer[map[string]string]
+)
+
+func init() {
+	root = newRoot(os.Stdout, os.Getenv("LOG_FORMAT"))
+
+	if err := loadLevelSpec(); err != nil {
+		root.Warn().Err(err).Msg("ignoring invalid LOG_LEVEL")
+	}
+}
+
+// newRoot creates the logger shared by every component, without level filtering (see levelHook).
+//
+// Parameters:
+//
+//	format: "json" (default) or "pretty" for human-readable local output, from LOG_FORMAT.
+func newRoot(out io.Writer, format string) zerolog.Logger {
+	if strings.EqualFold(format, FormatPretty) {
+		out = zerolog.ConsoleWriter{Out: out, TimeFormat: time.RFC3339}
+	}
+
+	return zerolog.New(out).With().Timestamp().Logger().Hook(globalFieldsHook{})
+}
+
+// globalFieldsHook adds the fields set with SetFields to every event, including loggers created before the call.
+type globalFieldsHook struct{}
+
+func (globalFieldsHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if current := fields.Load(); current != nil {
+		for k, v := range *current {
+			e.Str(k, v)
+		}
+	}
+}
+
+// SetFields sets fields added to every log line of every component, such as service name and version.
+func SetFields(values map[string]string) {
+	copied := make(map[string]string, len(values))
+	for k, v := range values {
+		copied[k] = v
+	}
+	fields.Store(&copied)
+}
+
+// New returns a logger for a toolkit component, identified by the "layer" field. Its level can be changed at
+// runtime with SetComponentLevel.
+//
+// Usage:
+//
+//	var logger = logging.New("http-client")
+func New(component string) zerolog.Logger {
+	return root.With().Str("layer", component).Logger().Hook(levelHook{component: component})
+}
+
+// WithContext returns a copy of ctx carrying logger, retrievable with FromContext.
+func WithContext(ctx context.Context, logger zerolog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKeyType{}, logger)
+}
+
+// FromContext returns the request-scoped logger stored in ctx, enriched with the current trace and span IDs.
+// When no logger was stored, it returns the base logger enriched with the forwarded x-request-id, if any.
+// It logs at the global level; use FromContextFor in toolkit components.
+//
+// Usage:
+//
+//	logging.FromContext(c.UserContext()).Info().Msg("processing order")
+func FromContext(ctx context.Context) *zerolog.Logger {
+	return fromContext(ctx, stored(ctx).Hook(levelHook{}))
+}
+
+// FromContextFor returns the request-scoped logger of ctx for a toolkit component, identified by the "layer"
+// field and logging at the level of the component (see SetComponentLevel).
+//
+// Usage:
+//
+//	log := logging.FromContextFor(ctx, "http-client")
+func FromContextFor(ctx context.Context, component string) *zerolog.Logger {
+	logger := stored(ctx).With().Str("layer", component).Logger().Hook(levelHook{component: component})
+	return fromContext(ctx, logger)
+}
+
+func fromContext(ctx context.Context, logger zerolog.Logger) *zerolog.Logger {
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		logger = logger.With().
+			Str("trace_id", spanCtx.TraceID().String()).
+			Str("span_id", spanCtx.SpanID().String()).
+			Logger()
+	}
+
+	return &logger
+}
+
+// With adds a string field to the request-scoped logger stored in ctx.
+//
+// Usage:
+//
+//	ctx = logging.With(ctx, "tenant", tenant.ID)
+func With(ctx context.Context, key, value string) context.Context {
+	return WithContext(ctx, stored(ctx).With().Str(key, value).Logger())
+}
+
+func stored(ctx context.Context) zerolog.Logger {
+	if logger, ok := ctx.Value(loggerKeyType{}).(zerolog.Logger); ok {
+		return logger
+	}
+
+	if headers, ok := ctx.Value("forwardedHeaders").(map[string]string); ok && headers["x-request-id"] != "" {
+		return root.With().Str("request_id", headers["x-request-id"]).Logger()
+	}
+
+	return root
+}
@@ -0,0 +1,73 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// contentTypeHeader is the message header carrying the codec content type.
+const contentTypeHeader = "content-type"
+
+// Codec serializes message payloads.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	ContentType() string
+}
+
+// JSONCodec encodes payloads as JSON.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (JSONCodec) ContentType() string                { return "application/json" }
+
+// ProtoCodec encodes payloads as protobuf. Values must implement proto.Message.
+type ProtoCodec struct{}
+
+func (ProtoCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("messaging: %T does not implement proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (ProtoCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("messaging: %T does not implement proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+func (ProtoCodec) ContentType() string { return "application/x-protobuf" }
+
+// NewMessage encodes v with codec into a Message for topic.
+//
+// Usage:
+//
+//	msg, err := messaging.NewMessage(messaging.JSONCodec{}, "orders", order)
+//	err = publisher.Publish(ctx, msg)
+func NewMessage(codec Codec, topic string, v any) (*Message, error) {
+	payload, err := codec.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("messaging: failed to encode message: %w", err)
+	}
+
+	return &Message{
+		Topic:   topic,
+		Payload: payload,
+		Headers: map[string]string{contentTypeHeader: codec.ContentType()},
+	}, nil
+}
+
+// Decode decodes the message payload into v with codec.
+func Decode(codec Codec, msg *Message, v any) error {
+	if err := codec.Unmarshal(msg.Payload, v); err != nil {
+		return fmt.Errorf("messaging: failed to decode message: %w", err)
+	}
+	return nil
+}
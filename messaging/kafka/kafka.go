@@ -0,0 +1,89 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/devluispereira/go-package/clients/kafkaclient"
+	"github.com/devluispereira/go-package/messaging"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Publisher implements messaging.Publisher on top of a kafkaclient.Producer.
+type Publisher struct {
+	producer *kafkaclient.Producer
+}
+
+// NewPublisher wraps a kafkaclient.Producer. Closing the publisher closes the producer.
+func NewPublisher(producer *kafkaclient.Producer) *Publisher {
+	return &Publisher{producer: producer}
+}
+
+func (p *Publisher) Publish(ctx context.Context, msg *messaging.Message) error {
+	record := &kgo.Record{Topic: msg.Topic, Value: msg.Payload}
+	if msg.Key != "" {
+		record.Key = []byte(msg.Key)
+	}
+	for k, v := range msg.Headers {
+		record.Headers = append(record.Headers, kgo.RecordHeader{Key: k, Value: []byte(v)})
+	}
+
+	return p.producer.PublishRecords(ctx, record)
+}
+
+func (p *Publisher) Close(ctx context.Context) error {
+	return p.producer.Close(ctx)
+}
+
+// Subscriber implements messaging.Subscriber by creating a kafkaclient.Consumer per subscribed topic.
+// Retries and dead-lettering follow the kafkaclient.ConsumerConfig.
+type Subscriber struct {
+	cfg kafkaclient.ConsumerConfig
+}
+
+// NewSubscriber creates a subscriber; cfg.Topics is ignored and replaced by the topic passed to Subscribe.
+//
+// Usage:
+//
+//	sub := kafka.NewSubscriber(kafkaclient.ConsumerConfig{Brokers: brokers, Group: "billing"})
+//	go sub.Subscribe(ctx, "orders", handler)
+func NewSubscriber(cfg kafkaclient.ConsumerConfig) *Subscriber {
+	return &Subscriber{cfg: cfg}
+}
+
+func (s *Subscriber) Subscribe(ctx context.Context, topic string, handler messaging.Handler) error {
+	cfg := s.cfg
+	cfg.Topics = []string{topic}
+
+	consumer, err := kafkaclient.NewConsumer(cfg, func(ctx context.Context, record *kgo.Record) error {
+		return handler(ctx, toMessage(record))
+	})
+	if err != nil {
+		return err
+	}
+	defer consumer.Close(context.Background())
+
+	return consumer.Run(ctx)
+}
+
+// Close is a no-op; each Subscribe call closes its own consumer when ctx is done.
+func (s *Subscriber) Close(_ context.Context) error {
+	return nil
+}
+
+func toMessage(record *kgo.Record) *messaging.Message {
+	msg := &messaging.Message{
+		ID:      record.Topic + "/" + strconv.Itoa(int(record.Partition)) + "/" + strconv.FormatInt(record.Offset, 10),
+		Topic:   record.Topic,
+		Key:     string(record.Key),
+		Payload: record.Value,
+		Headers: make(map[string]string, len(record.Headers)),
+		Attempt: 1,
+	}
+
+	for _, h := range record.Headers {
+		msg.Headers[h.Key] = string(h.Value)
+	}
+
+	return msg
+}
@@ -0,0 +1,76 @@
+package messaging
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Message is a broker-agnostic message.
+type Message struct {
+	// ID is assigned by the broker when the message is received.
+	ID      string
+	Topic   string
+	Key     string
+	Payload []byte
+	Headers map[string]string
+	// Attempt is the delivery attempt, starting at 1, when the broker reports it.
+	Attempt int
+}
+
+// Handler processes a received message. Returning an error makes the backend redeliver or dead-letter it.
+type Handler func(ctx context.Context, msg *Message) error
+
+// Publisher publishes messages to a topic (stream, queue or Kafka topic, depending on the backend).
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+	Close(ctx context.Context) error
+}
+
+// Subscriber delivers messages of a topic to a handler, blocking until ctx is done.
+type Subscriber interface {
+	Subscribe(ctx context.Context, topic string, handler Handler) error
+	Close(ctx context.Context) error
+}
+
+// InjectContext copies the forwarded headers and the trace context of ctx into the message headers.
+// Backends call it on Publish.
+func InjectContext(ctx context.Context, msg *Message) {
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+
+	if headers, ok := ctx.Value("forwardedHeaders").(map[string]string); ok {
+		for k, v := range headers {
+			if _, exists := msg.Headers[k]; !exists {
+				msg.Headers[k] = v
+			}
+		}
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(msg.Headers))
+}
+
+// Header returns the value of the named header, matched case-insensitively, or "" when absent.
+func (m *Message) Header(name string) string {
+	if value, ok := m.Headers[name]; ok {
+		return value
+	}
+	for k, v := range m.Headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// ExtractContext returns a context carrying forwarded as the forwarded headers and the producer's trace.
+// Backends call it before invoking the handler, with the headers of their ForwardHeaders config collected by
+// server.CollectForwardedHeaders, so a producer cannot set arbitrary headers (e.g. Authorization) on the HTTP
+// calls of the handler.
+func ExtractContext(ctx context.Context, msg *Message, forwarded map[string]string) context.Context {
+	ctx = context.WithValue(ctx, "forwardedHeaders", forwarded)
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Headers))
+}
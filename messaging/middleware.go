@@ -0,0 +1,110 @@
+package messaging
+
+import (
+	"context"
+	"time"
+
+	"github.com/devluispereira/go-package/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/devluispereira/go-package/messaging"
+
+// Middleware wraps a Handler with additional delivery behavior.
+type Middleware func(next Handler) Handler
+
+// Chain applies the middlewares to handler. The first middleware is the outermost.
+//
+// Usage:
+//
+//	handler := messaging.Chain(handleOrder,
+//		messaging.WithLogging("billing"),
+//		messaging.WithTracing(),
+//		messaging.WithRetry(3, 200*time.Millisecond),
+//	)
+func Chain(handler Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// WithLogging logs each delivery with its topic, duration and outcome.
+func WithLogging(name string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+
+			log := logging.FromContext(ctx)
+			event := log.Info()
+			if err != nil {
+				event = log.Error().Err(err)
+			}
+
+			event.
+				Str("layer", "messaging").
+				Str("service", name).
+				Str("topic", msg.Topic).
+				Str("message_id", msg.ID).
+				Int("attempt", msg.Attempt).
+				Int64("duration_ms", time.Since(start).Milliseconds()).
+				Msg("messaging:delivery")
+
+			return err
+		}
+	}
+}
+
+// WithTracing creates a consumer span for each delivery, continuing the producer's trace.
+func WithTracing() Middleware {
+	tracer := otel.Tracer(instrumentationName)
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			ctx, span := tracer.Start(ctx, "process "+msg.Topic,
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(
+					attribute.String("messaging.destination.name", msg.Topic),
+					attribute.String("messaging.message.id", msg.ID),
+				),
+			)
+			defer span.End()
+
+			err := next(ctx, msg)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			return err
+		}
+	}
+}
+
+// WithRetry retries a failed delivery in-process up to maxRetries times, doubling backoff at each attempt,
+// before returning the error to the backend (which redelivers or dead-letters it).
+func WithRetry(maxRetries int, backoff time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			err := next(ctx, msg)
+			delay := backoff
+
+			for attempt := 1; err != nil && attempt <= maxRetries; attempt++ {
+				select {
+				case <-time.After(delay):
+					delay *= 2
+				case <-ctx.Done():
+					return err
+				}
+
+				err = next(ctx, msg)
+			}
+
+			return err
+		}
+	}
+}
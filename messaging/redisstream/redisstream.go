@@ -0,0 +1,220 @@
+package redisstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/devluispereira/go-package/logging"
+	"github.com/devluispereira/go-package/messaging"
+	"github.com/devluispereira/go-package/server"
+	"github.com/redis/go-redis/v9"
+)
+
+var logger = logging.New("messaging-redisstream")
+
+// Config holds the Redis Streams backend configuration. It can be loaded with the config package.
+type Config struct {
+	// Group is the consumer group name.
+	Group string `json:"group" env:"REDISSTREAM_GROUP"`
+	// Consumer identifies this replica in the group. Defaults to the hostname.
+	Consumer string `json:"consumer" env:"REDISSTREAM_CONSUMER"`
+	// Count is the maximum number of messages read per call. Defaults to 10.
+	Count int64 `json:"count" env:"REDISSTREAM_COUNT" default:"10"`
+	// Block is how long a read waits for new messages. Defaults to 5s.
+	Block time.Duration `json:"block" env:"REDISSTREAM_BLOCK" default:"5s"`
+	// ClaimIdle is how long a failed message stays pending before being redelivered. Defaults to 30s.
+	ClaimIdle time.Duration `json:"claim_idle" env:"REDISSTREAM_CLAIM_IDLE" default:"30s"`
+	// MaxDeliveries is the number of deliveries before a message is moved to "<stream>:dlq". Defaults to 5.
+	MaxDeliveries int64 `json:"max_deliveries" env:"REDISSTREAM_MAX_DELIVERIES" default:"5"`
+	// MaxLen caps the stream length on publish (approximate trimming). Zero disables trimming.
+	MaxLen int64 `json:"max_len" env:"REDISSTREAM_MAX_LEN"`
+	// ForwardHeaders are the message headers forwarded to the HTTP calls of the handler. If empty, uses
+	// server.DefaultForwardHeaders.
+	ForwardHeaders []string `json:"forward_headers" env:"REDISSTREAM_FORWARD_HEADERS"`
+}
+
+// Broker implements messaging.Publisher and messaging.Subscriber on Redis Streams.
+type Broker struct {
+	client redis.UniversalClient
+	cfg    Config
+}
+
+// New creates a Redis Streams broker. Use redisclient.RedisClient.Client() to obtain the go-redis client.
+//
+// Usage:
+//
+//	broker := redisstream.New(redis.Client(), redisstream.Config{Group: "billing"})
+//	err := broker.Publish(ctx, msg)
+//	go broker.Subscribe(ctx, "orders", handler)
+func New(client redis.UniversalClient, cfg Config) *Broker {
+	if cfg.Consumer == "" {
+		cfg.Consumer, _ = os.Hostname()
+	}
+	if cfg.Count <= 0 {
+		cfg.Count = 10
+	}
+	if cfg.Block <= 0 {
+		cfg.Block = 5 * time.Second
+	}
+	if cfg.ClaimIdle <= 0 {
+		cfg.ClaimIdle = 30 * time.Second
+	}
+	if cfg.MaxDeliveries <= 0 {
+		cfg.MaxDeliveries = 5
+	}
+
+	return &Broker{client: client, cfg: cfg}
+}
+
+func (b *Broker) Publish(ctx context.Context, msg *messaging.Message) error {
+	messaging.InjectContext(ctx, msg)
+
+	headers, err := json.Marshal(msg.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode headers: %w", err)
+	}
+
+	args := &redis.XAddArgs{
+		Stream: msg.Topic,
+		Values: map[string]any{"key": msg.Key, "payload": msg.Payload, "headers": headers},
+	}
+	if b.cfg.MaxLen > 0 {
+		args.MaxLen = b.cfg.MaxLen
+		args.Approx = true
+	}
+
+	if err := b.client.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to publish to stream %s: %w", msg.Topic, err)
+	}
+
+	return nil
+}
+
+// Subscribe reads the stream with the consumer group until ctx is done. Messages are acknowledged when the
+// handler succeeds; failed messages are redelivered after ClaimIdle and dead-lettered after MaxDeliveries.
+func (b *Broker) Subscribe(ctx context.Context, topic string, handler messaging.Handler) error {
+	err := b.client.XGroupCreateMkStream(ctx, topic, b.cfg.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	lastClaim := time.Now()
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= b.cfg.ClaimIdle {
+			b.reclaim(ctx, topic, handler)
+			lastClaim = time.Now()
+		}
+
+		streams, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    b.cfg.Group,
+			Consumer: b.cfg.Consumer,
+			Streams:  []string{topic, ">"},
+			Count:    b.cfg.Count,
+			Block:    b.cfg.Block,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Error().Err(err).Str("stream", topic).Msg("redisstream:read failed")
+			time.Sleep(time.Second)
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, entry := range stream.Messages {
+				b.deliver(ctx, topic, entry, 1, handler)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *Broker) reclaim(ctx context.Context, topic string, handler messaging.Handler) {
+	pending, err := b.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: topic,
+		Group:  b.cfg.Group,
+		Idle:   b.cfg.ClaimIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  b.cfg.Count,
+	}).Result()
+	if err != nil {
+		logger.Error().Err(err).Str("stream", topic).Msg("redisstream:pending failed")
+		return
+	}
+
+	for _, p := range pending {
+		claimed, err := b.client.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   topic,
+			Group:    b.cfg.Group,
+			Consumer: b.cfg.Consumer,
+			MinIdle:  b.cfg.ClaimIdle,
+			Messages: []string{p.ID},
+		}).Result()
+		if err != nil || len(claimed) == 0 {
+			continue
+		}
+
+		if p.RetryCount >= b.cfg.MaxDeliveries {
+			b.deadLetter(ctx, topic, claimed[0])
+			continue
+		}
+
+		b.deliver(ctx, topic, claimed[0], int(p.RetryCount)+1, handler)
+	}
+}
+
+func (b *Broker) deliver(ctx context.Context, topic string, entry redis.XMessage, attempt int, handler messaging.Handler) {
+	msg := toMessage(topic, entry, attempt)
+
+	forwarded := server.CollectForwardedHeaders(b.cfg.ForwardHeaders, msg.Header)
+	if err := handler(messaging.ExtractContext(ctx, msg, forwarded), msg); err != nil {
+		return
+	}
+
+	if err := b.client.XAck(ctx, topic, b.cfg.Group, entry.ID).Err(); err != nil {
+		logger.Error().Err(err).Str("stream", topic).Str("id", entry.ID).Msg("redisstream:ack failed")
+	}
+}
+
+func (b *Broker) deadLetter(ctx context.Context, topic string, entry redis.XMessage) {
+	err := b.client.XAdd(ctx, &redis.XAddArgs{Stream: topic + ":dlq", Values: entry.Values}).Err()
+	if err == nil {
+		err = b.client.XAck(ctx, topic, b.cfg.Group, entry.ID).Err()
+	}
+
+	if err != nil {
+		logger.Error().Err(err).Str("stream", topic).Str("id", entry.ID).Msg("redisstream:dead-letter failed")
+	}
+}
+
+func toMessage(topic string, entry redis.XMessage, attempt int) *messaging.Message {
+	msg := &messaging.Message{ID: entry.ID, Topic: topic, Attempt: attempt, Headers: map[string]string{}}
+
+	if key, ok := entry.Values["key"].(string); ok {
+		msg.Key = key
+	}
+	if payload, ok := entry.Values["payload"].(string); ok {
+		msg.Payload = []byte(payload)
+	}
+	if headers, ok := entry.Values["headers"].(string); ok {
+		_ = json.Unmarshal([]byte(headers), &msg.Headers)
+	}
+
+	return msg
+}
+
+// Close is a no-op; the Redis client lifecycle is owned by the caller.
+func (b *Broker) Close(_ context.Context) error {
+	return nil
+}
@@ -0,0 +1,281 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/devluispereira/go-package/logging"
+	"github.com/devluispereira/go-package/messaging"
+	"github.com/devluispereira/go-package/server"
+)
+
+var logger = logging.New("messaging-sqs")
+
+// maxAttributes is the number of message attributes SQS accepts per message.
+const maxAttributes = 10
+
+// headersAttribute carries every header as a JSON object when they don't fit in maxAttributes attributes.
+const headersAttribute = "messaging-headers"
+
+// API defines the SQS operations used by the broker. It is satisfied by *sqs.Client.
+type API interface {
+	GetQueueUrl(ctx context.Context, params *awssqs.GetQueueUrlInput, optFns ...func(*awssqs.Options)) (*awssqs.GetQueueUrlOutput, error)
+	SendMessage(ctx context.Context, params *awssqs.SendMessageInput, optFns ...func(*awssqs.Options)) (*awssqs.SendMessageOutput, error)
+	ReceiveMessage(ctx context.Context, params *awssqs.ReceiveMessageInput, optFns ...func(*awssqs.Options)) (*awssqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *awssqs.DeleteMessageInput, optFns ...func(*awssqs.Options)) (*awssqs.DeleteMessageOutput, error)
+}
+
+// Config holds the SQS backend configuration. It can be loaded with the config package.
+type Config struct {
+	// MaxMessages is the maximum number of messages per receive, from 1 to 10. Defaults to 10.
+	MaxMessages int32 `json:"max_messages" env:"SQS_MAX_MESSAGES" default:"10"`
+	// WaitTime is the long polling duration, up to 20s. Defaults to 20s.
+	WaitTime time.Duration `json:"wait_time" env:"SQS_WAIT_TIME" default:"20s"`
+	// VisibilityTimeout overrides the queue visibility timeout for received messages. Zero keeps the queue setting.
+	VisibilityTimeout time.Duration `json:"visibility_timeout" env:"SQS_VISIBILITY_TIMEOUT"`
+	// GroupID is the message group ID of messages without a Key published to FIFO queues. Defaults to "default",
+	// so they are delivered in publish order.
+	GroupID string `json:"group_id" env:"SQS_GROUP_ID" default:"default"`
+	// ForwardHeaders are the message attributes forwarded to the HTTP calls of the handler. If empty, uses
+	// server.DefaultForwardHeaders.
+	ForwardHeaders []string `json:"forward_headers" env:"SQS_FORWARD_HEADERS"`
+}
+
+// Broker implements messaging.Publisher and messaging.Subscriber on Amazon SQS.
+// Topics are queue names; their URLs are resolved once and cached.
+// Retries rely on the visibility timeout and dead-lettering on the queue redrive policy.
+type Broker struct {
+	client API
+	cfg    Config
+
+	mu   sync.RWMutex
+	urls map[string]string
+}
+
+// New creates an SQS broker.
+//
+// Usage:
+//
+//	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+//	broker := sqs.New(awssqs.NewFromConfig(awsCfg), sqs.Config{})
+//	err = broker.Publish(ctx, msg)
+//	go broker.Subscribe(ctx, "orders", handler)
+func New(client API, cfg Config) *Broker {
+	if cfg.MaxMessages <= 0 || cfg.MaxMessages > 10 {
+		cfg.MaxMessages = 10
+	}
+	if cfg.WaitTime <= 0 || cfg.WaitTime > 20*time.Second {
+		cfg.WaitTime = 20 * time.Second
+	}
+	if cfg.GroupID == "" {
+		cfg.GroupID = "default"
+	}
+
+	return &Broker{client: client, cfg: cfg, urls: make(map[string]string)}
+}
+
+// Publish sends the message to the queue named by msg.Topic. Headers are sent as message attributes; when there
+// are more than SQS accepts (10), or a name is not a valid attribute name, they are packed into a single JSON
+// attribute, unpacked by Subscribe. For FIFO queues (".fifo" suffix), msg.Key is used as the message group ID,
+// or Config.GroupID when empty; the queue must have content-based deduplication enabled.
+func (b *Broker) Publish(ctx context.Context, msg *messaging.Message) error {
+	messaging.InjectContext(ctx, msg)
+
+	queueURL, err := b.queueURL(ctx, msg.Topic)
+	if err != nil {
+		return err
+	}
+
+	attributes, err := messageAttributes(msg.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode headers of message to %s: %w", msg.Topic, err)
+	}
+
+	input := &awssqs.SendMessageInput{
+		QueueUrl:          aws.String(queueURL),
+		MessageBody:       aws.String(string(msg.Payload)),
+		MessageAttributes: attributes,
+	}
+	if strings.HasSuffix(msg.Topic, ".fifo") {
+		input.MessageGroupId = aws.String(b.cfg.GroupID)
+		if msg.Key != "" {
+			input.MessageGroupId = aws.String(msg.Key)
+		}
+	}
+
+	if _, err := b.client.SendMessage(ctx, input); err != nil {
+		return fmt.Errorf("failed to send message to %s: %w", msg.Topic, err)
+	}
+
+	return nil
+}
+
+// Subscribe long-polls the queue until ctx is done. Messages are deleted when the handler succeeds;
+// failed messages become visible again after the visibility timeout.
+func (b *Broker) Subscribe(ctx context.Context, topic string, handler messaging.Handler) error {
+	queueURL, err := b.queueURL(ctx, topic)
+	if err != nil {
+		return err
+	}
+
+	input := &awssqs.ReceiveMessageInput{
+		QueueUrl:              aws.String(queueURL),
+		MaxNumberOfMessages:   b.cfg.MaxMessages,
+		WaitTimeSeconds:       int32(b.cfg.WaitTime / time.Second),
+		MessageAttributeNames: []string{"All"},
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameApproximateReceiveCount,
+			types.MessageSystemAttributeNameMessageGroupId,
+		},
+	}
+	if b.cfg.VisibilityTimeout > 0 {
+		input.VisibilityTimeout = int32(b.cfg.VisibilityTimeout / time.Second)
+	}
+
+	for ctx.Err() == nil {
+		out, err := b.client.ReceiveMessage(ctx, input)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Error().Err(err).Str("queue", topic).Msg("sqs:receive failed")
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return nil
+			}
+			continue
+		}
+
+		for _, m := range out.Messages {
+			msg := toMessage(topic, m)
+			forwarded := server.CollectForwardedHeaders(b.cfg.ForwardHeaders, msg.Header)
+			if err := handler(messaging.ExtractContext(ctx, msg, forwarded), msg); err != nil {
+				continue
+			}
+
+			_, err := b.client.DeleteMessage(ctx, &awssqs.DeleteMessageInput{
+				QueueUrl:      aws.String(queueURL),
+				ReceiptHandle: m.ReceiptHandle,
+			})
+			if err != nil {
+				logger.Error().Err(err).Str("queue", topic).Str("id", msg.ID).Msg("sqs:delete failed")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *Broker) queueURL(ctx context.Context, name string) (string, error) {
+	b.mu.RLock()
+	url, ok := b.urls[name]
+	b.mu.RUnlock()
+	if ok {
+		return url, nil
+	}
+
+	out, err := b.client.GetQueueUrl(ctx, &awssqs.GetQueueUrlInput{QueueName: aws.String(name)})
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve queue url for %s: %w", name, err)
+	}
+
+	url = aws.ToString(out.QueueUrl)
+	b.mu.Lock()
+	b.urls[name] = url
+	b.mu.Unlock()
+
+	return url, nil
+}
+
+// messageAttributes returns the message attributes carrying headers: one per header, or a single headersAttribute
+// when they don't fit.
+func messageAttributes(headers map[string]string) (map[string]types.MessageAttributeValue, error) {
+	packed := len(headers) > maxAttributes
+	for k := range headers {
+		packed = packed || !validAttributeName(k)
+	}
+
+	if packed {
+		encoded, err := json.Marshal(headers)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]types.MessageAttributeValue{
+			headersAttribute: {DataType: aws.String("String"), StringValue: aws.String(string(encoded))},
+		}, nil
+	}
+
+	attributes := make(map[string]types.MessageAttributeValue, len(headers))
+	for k, v := range headers {
+		attributes[k] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(v)}
+	}
+	return attributes, nil
+}
+
+// validAttributeName reports whether name is accepted by SQS as a message attribute name: up to 256 letters,
+// digits, "_", "-" and ".", without leading, trailing or consecutive dots, nor the reserved "AWS." and "Amazon."
+// prefixes.
+func validAttributeName(name string) bool {
+	if name == "" || len(name) > 256 || name == headersAttribute {
+		return false
+	}
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") || strings.Contains(name, "..") {
+		return false
+	}
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, "aws.") || strings.HasPrefix(lower, "amazon.") {
+		return false
+	}
+
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func toMessage(topic string, m types.Message) *messaging.Message {
+	msg := &messaging.Message{
+		ID:      aws.ToString(m.MessageId),
+		Topic:   topic,
+		Payload: []byte(aws.ToString(m.Body)),
+		Headers: make(map[string]string, len(m.MessageAttributes)),
+		Attempt: 1,
+	}
+
+	for k, v := range m.MessageAttributes {
+		if k == headersAttribute {
+			if err := json.Unmarshal([]byte(aws.ToString(v.StringValue)), &msg.Headers); err != nil {
+				logger.Error().Err(err).Str("queue", topic).Msg("sqs:invalid packed headers")
+			}
+			continue
+		}
+		msg.Headers[k] = aws.ToString(v.StringValue)
+	}
+
+	if count, err := strconv.Atoi(m.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)]); err == nil {
+		msg.Attempt = count
+	}
+	if group, ok := m.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]; ok {
+		msg.Key = group
+	}
+
+	return msg
+}
+
+// Close is a no-op; the SQS client holds no connections that need closing.
+func (b *Broker) Close(_ context.Context) error {
+	return nil
+}
@@ -0,0 +1,185 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/devluispereira/go-package/messaging"
+)
+
+// fakeAPI records the sent messages and answers receives with receive.
+type fakeAPI struct {
+	API
+	sent    []*awssqs.SendMessageInput
+	receive func(ctx context.Context, params *awssqs.ReceiveMessageInput) (*awssqs.ReceiveMessageOutput, error)
+}
+
+func (f *fakeAPI) ReceiveMessage(ctx context.Context, params *awssqs.ReceiveMessageInput, _ ...func(*awssqs.Options)) (*awssqs.ReceiveMessageOutput, error) {
+	return f.receive(ctx, params)
+}
+
+func (f *fakeAPI) DeleteMessage(_ context.Context, _ *awssqs.DeleteMessageInput, _ ...func(*awssqs.Options)) (*awssqs.DeleteMessageOutput, error) {
+	return &awssqs.DeleteMessageOutput{}, nil
+}
+
+func (f *fakeAPI) GetQueueUrl(_ context.Context, params *awssqs.GetQueueUrlInput, _ ...func(*awssqs.Options)) (*awssqs.GetQueueUrlOutput, error) {
+	return &awssqs.GetQueueUrlOutput{QueueUrl: aws.String("https://sqs/" + aws.ToString(params.QueueName))}, nil
+}
+
+func (f *fakeAPI) SendMessage(_ context.Context, params *awssqs.SendMessageInput, _ ...func(*awssqs.Options)) (*awssqs.SendMessageOutput, error) {
+	f.sent = append(f.sent, params)
+	return &awssqs.SendMessageOutput{}, nil
+}
+
+func TestPublishPacksHeadersAboveAttributeLimit(t *testing.T) {
+	api := &fakeAPI{}
+	broker := New(api, Config{})
+
+	headers := map[string]string{}
+	for i := range 12 {
+		headers["x-header-"+strconv.Itoa(i)] = strconv.Itoa(i)
+	}
+	if err := broker.Publish(context.Background(), &messaging.Message{Topic: "orders", Headers: headers}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	attributes := api.sent[0].MessageAttributes
+	if len(attributes) > maxAttributes {
+		t.Fatalf("sent %d attributes, SQS accepts %d", len(attributes), maxAttributes)
+	}
+
+	received := toMessage("orders", types.Message{MessageAttributes: attributes})
+	for k, v := range headers {
+		if received.Headers[k] != v {
+			t.Errorf("header %s = %q, want %q", k, received.Headers[k], v)
+		}
+	}
+}
+
+func TestPublishKeepsFewHeadersAsAttributes(t *testing.T) {
+	api := &fakeAPI{}
+	broker := New(api, Config{})
+
+	if err := broker.Publish(context.Background(), &messaging.Message{
+		Topic:   "orders",
+		Headers: map[string]string{"x-request-id": "abc"},
+	}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if value := api.sent[0].MessageAttributes["x-request-id"]; aws.ToString(value.StringValue) != "abc" {
+		t.Fatalf("attributes = %v", api.sent[0].MessageAttributes)
+	}
+}
+
+func TestPublishDefaultsFIFOGroupID(t *testing.T) {
+	api := &fakeAPI{}
+	broker := New(api, Config{})
+	ctx := context.Background()
+
+	_ = broker.Publish(ctx, &messaging.Message{Topic: "orders.fifo"})
+	_ = broker.Publish(ctx, &messaging.Message{Topic: "orders.fifo", Key: "customer-1"})
+	_ = broker.Publish(ctx, &messaging.Message{Topic: "orders"})
+
+	if got := aws.ToString(api.sent[0].MessageGroupId); got != "default" {
+		t.Errorf("group ID without key = %q, want default", got)
+	}
+	if got := aws.ToString(api.sent[1].MessageGroupId); got != "customer-1" {
+		t.Errorf("group ID = %q, want customer-1", got)
+	}
+	if api.sent[2].MessageGroupId != nil {
+		t.Error("group ID set on a standard queue")
+	}
+}
+
+func TestValidAttributeName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"x-request-id":   true,
+		"traceparent":    true,
+		"AWS.TraceID":    false,
+		"x:custom":       false,
+		"a..b":           false,
+		headersAttribute: false,
+	} {
+		if got := validAttributeName(name); got != want {
+			t.Errorf("validAttributeName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestSubscribeForwardsAllowedHeadersAndGroupID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	api := &fakeAPI{}
+	api.receive = func(_ context.Context, params *awssqs.ReceiveMessageInput) (*awssqs.ReceiveMessageOutput, error) {
+		if !slices.Contains(params.MessageSystemAttributeNames, types.MessageSystemAttributeNameMessageGroupId) {
+			t.Errorf("MessageGroupId not requested: %v", params.MessageSystemAttributeNames)
+		}
+		return &awssqs.ReceiveMessageOutput{Messages: []types.Message{{
+			MessageId: aws.String("1"),
+			Attributes: map[string]string{
+				string(types.MessageSystemAttributeNameMessageGroupId): "customer-1",
+			},
+			MessageAttributes: map[string]types.MessageAttributeValue{
+				"x-tenant-id":   {DataType: aws.String("String"), StringValue: aws.String("acme")},
+				"Authorization": {DataType: aws.String("String"), StringValue: aws.String("Bearer stolen")},
+			},
+		}}}, nil
+	}
+
+	var (
+		key       string
+		forwarded map[string]string
+	)
+	err := New(api, Config{}).Subscribe(ctx, "orders.fifo", func(ctx context.Context, msg *messaging.Message) error {
+		key = msg.Key
+		forwarded, _ = ctx.Value("forwardedHeaders").(map[string]string)
+		cancel()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if key != "customer-1" {
+		t.Errorf("key = %q, want customer-1", key)
+	}
+	if len(forwarded) != 1 || forwarded["x-tenant-id"] != "acme" {
+		t.Errorf("forwarded headers = %v, want only x-tenant-id", forwarded)
+	}
+}
+
+func TestSubscribeStopsDuringReceiveBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	received := make(chan struct{}, 1)
+	api := &fakeAPI{}
+	api.receive = func(context.Context, *awssqs.ReceiveMessageInput) (*awssqs.ReceiveMessageOutput, error) {
+		received <- struct{}{}
+		return nil, errors.New("throttled")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- New(api, Config{}).Subscribe(ctx, "orders", func(context.Context, *messaging.Message) error { return nil })
+	}()
+	<-received
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Subscribe did not return on cancellation during the receive backoff")
+	}
+}
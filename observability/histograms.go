@@ -0,0 +1,113 @@
+package observability
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// Scope attributes identifying the aggregation requested by HistogramConfig.
+const (
+	histogramAttribute        = "histogram"
+	histogramBucketsAttribute = "histogram.buckets"
+	histogramMaxSizeAttribute = "histogram.max_size"
+)
+
+// DefaultLatencyBuckets are the bucket boundaries, in seconds, of the latency histograms without configured
+// buckets: from 5ms to 10s, as recommended by the OpenTelemetry HTTP semantic conventions.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+
+// HistogramConfig configures the aggregation of a latency histogram. It can be loaded with the config package.
+type HistogramConfig struct {
+	// Buckets are the bucket boundaries, in seconds, e.g. [0.0005, 0.001, 0.005] for cache hits or [1, 5, 15, 60]
+	// for report endpoints. If empty, uses DefaultLatencyBuckets.
+	Buckets []float64 `json:"buckets" env:"HISTOGRAM_BUCKETS"`
+	// Exponential records a base-2 exponential histogram, which adapts its resolution to the recorded values, instead
+	// of explicit buckets. It is applied by the MeterProvider created by Init and requires the OTLP exporter: the
+	// Prometheus exporter drops exponential histograms.
+	Exponential bool `json:"exponential" env:"HISTOGRAM_EXPONENTIAL"`
+	// MaxSize is the maximum number of buckets of an exponential histogram. If zero, uses 160.
+	MaxSize int32 `json:"max_size" env:"HISTOGRAM_MAX_SIZE"`
+}
+
+// LatencyHistogram creates a latency histogram, in seconds, aggregated as configured by cfg.
+//
+// Parameters:
+//
+//	scope: The instrumentation scope, usually the package import path.
+//	name: The metric name, e.g. "http.server.request.duration".
+//	description: The metric description.
+//	cfg: Buckets or exponential aggregation.
+//
+// Behavior:
+//   - Buckets are passed as advice to the MeterProvider, so they apply with any OpenTelemetry SDK.
+//   - A configured histogram is created in its own meter, identified by scope attributes, so several middlewares
+//     may record the same metric with different buckets; the exponential aggregation is selected by the view Init
+//     installs for these attributes.
+//
+// Usage:
+//
+//	duration, _ := observability.LatencyHistogram(instrumentationName, "jobs.duration", "Duration of jobs.",
+//		observability.HistogramConfig{Buckets: []float64{1, 5, 15, 60, 300}})
+func LatencyHistogram(scope, name, description string, cfg HistogramConfig) (metric.Float64Histogram, error) {
+	buckets := cfg.Buckets
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	var attrs []attribute.KeyValue
+	switch {
+	case cfg.Exponential:
+		maxSize := cfg.MaxSize
+		if maxSize <= 0 {
+			maxSize = 160
+		}
+		attrs = []attribute.KeyValue{
+			attribute.String(histogramAttribute, "exponential"),
+			attribute.Int(histogramMaxSizeAttribute, int(maxSize)),
+		}
+	case len(cfg.Buckets) > 0:
+		attrs = []attribute.KeyValue{
+			attribute.String(histogramAttribute, "explicit"),
+			attribute.Float64Slice(histogramBucketsAttribute, cfg.Buckets),
+		}
+	}
+
+	var opts []metric.MeterOption
+	if len(attrs) > 0 {
+		opts = append(opts, metric.WithInstrumentationAttributes(attrs...))
+	}
+
+	return otel.Meter(scope, opts...).Float64Histogram(
+		name,
+		metric.WithUnit("s"),
+		metric.WithDescription(description),
+		metric.WithExplicitBucketBoundaries(buckets...),
+	)
+}
+
+// histogramView aggregates the histograms created by LatencyHistogram with Exponential as base-2 exponential
+// histograms.
+func histogramView(i sdkmetric.Instrument) (sdkmetric.Stream, bool) {
+	if i.Kind != sdkmetric.InstrumentKindHistogram {
+		return sdkmetric.Stream{}, false
+	}
+
+	kind, ok := i.Scope.Attributes.Value(histogramAttribute)
+	if !ok || kind.AsString() != "exponential" {
+		return sdkmetric.Stream{}, false
+	}
+
+	maxSize, _ := i.Scope.Attributes.Value(histogramMaxSizeAttribute)
+
+	return sdkmetric.Stream{
+		Name:        i.Name,
+		Description: i.Description,
+		Unit:        i.Unit,
+		Aggregation: sdkmetric.AggregationBase2ExponentialHistogram{
+			MaxSize:  int32(maxSize.AsInt64()),
+			MaxScale: 20,
+		},
+	}, true
+}
@@ -0,0 +1,132 @@
+package observability
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// OverflowLabel replaces label values beyond the cardinality limit of a LabelLimiter.
+const OverflowLabel = "other"
+
+// maxLabelLength bounds the length of sanitized label values.
+const maxLabelLength = 128
+
+// LabelLimiter caps the number of distinct values recorded per metric label, protecting the metrics backend from
+// series explosions (IDs in URLs, arbitrary hosts or status codes sent by clients). It is safe for concurrent use.
+type LabelLimiter struct {
+	limit int
+	mu    sync.RWMutex
+	seen  map[string]map[string]struct{}
+}
+
+// NewLabelLimiter creates a LabelLimiter allowing limit distinct values per label. If limit is zero or negative,
+// uses 100.
+func NewLabelLimiter(limit int) *LabelLimiter {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	return &LabelLimiter{limit: limit, seen: make(map[string]map[string]struct{})}
+}
+
+// Value returns the sanitized value for the label key, or OverflowLabel once the label already has limit distinct
+// values and value is a new one. Values seen before the limit was reached keep being returned as they are.
+func (l *LabelLimiter) Value(key, value string) string {
+	value = SanitizeLabel(value)
+
+	l.mu.RLock()
+	_, ok := l.seen[key][value]
+	l.mu.RUnlock()
+	if ok {
+		return value
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	values, ok := l.seen[key]
+	if !ok {
+		values = make(map[string]struct{})
+		l.seen[key] = values
+	}
+	if _, ok := values[value]; ok {
+		return value
+	}
+	if len(values) >= l.limit {
+		return OverflowLabel
+	}
+
+	// Values may alias reused buffers (e.g. Fiber request data).
+	value = strings.Clone(value)
+	values[value] = struct{}{}
+	return value
+}
+
+// SanitizeLabel makes value safe to use as a metric label: invalid UTF-8 is replaced, control characters are
+// removed and the value is truncated to 128 bytes.
+func SanitizeLabel(value string) string {
+	value = strings.ToValidUTF8(value, "�")
+	value = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+
+	if len(value) <= maxLabelLength {
+		return value
+	}
+
+	cut := maxLabelLength
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut]
+}
+
+// MethodLabel returns the HTTP method as a label value: the standard methods in upper case and "_OTHER" for the
+// rest, as in the OpenTelemetry semantic conventions.
+func MethodLabel(method string) string {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		return http.MethodGet
+	case http.MethodHead:
+		return http.MethodHead
+	case http.MethodPost:
+		return http.MethodPost
+	case http.MethodPut:
+		return http.MethodPut
+	case http.MethodPatch:
+		return http.MethodPatch
+	case http.MethodDelete:
+		return http.MethodDelete
+	case http.MethodConnect:
+		return http.MethodConnect
+	case http.MethodOptions:
+		return http.MethodOptions
+	case http.MethodTrace:
+		return http.MethodTrace
+	}
+	return "_OTHER"
+}
+
+var idSegment = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F-]{32,36}|[0-9a-fA-F]{24})$`)
+
+// TemplatePath replaces the identifier-like segments of a URL path (numbers, UUIDs, hashes and ObjectIDs) with
+// ":id", so "/products/123/reviews" and "/products/456/reviews" share the "/products/:id/reviews" label.
+func TemplatePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if idSegment.MatchString(segment) {
+			segments[i] = ":id"
+		}
+	}
+
+	if template := strings.Join(segments, "/"); template != "" {
+		return template
+	}
+	return "/"
+}
@@ -0,0 +1,223 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/devluispereira/go-package/buildinfo"
+	"github.com/devluispereira/go-package/errorreport"
+	"github.com/devluispereira/go-package/logging"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// Exporter names accepted by ServiceInfo.
+const (
+	ExporterOTLP       = "otlp"
+	ExporterPrometheus = "prometheus"
+	ExporterNone       = "none"
+)
+
+// ServiceInfo describes the service and how its telemetry is exported. It can be loaded with the config package.
+// OTLP endpoints and headers are read by the exporters from the standard OTEL_EXPORTER_OTLP_* variables.
+type ServiceInfo struct {
+	Name        string `json:"name" env:"APP_NAME"`
+	Version     string `json:"version" env:"APP_VERSION"`
+	Environment string `json:"environment" env:"APP_ENV"`
+	// MetricsExporter is "prometheus" (default), "otlp" or "none".
+	MetricsExporter string `json:"metrics_exporter" env:"OTEL_METRICS_EXPORTER" default:"prometheus"`
+	// TracesExporter is "otlp" (default) or "none".
+	TracesExporter string `json:"traces_exporter" env:"OTEL_TRACES_EXPORTER" default:"otlp"`
+	// SampleRatio is the fraction of root traces sampled, from 0 to 1. Parent decisions are respected.
+	SampleRatio float64 `json:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG" default:"0.1"`
+}
+
+// Shutdown flushes and stops the telemetry providers.
+type Shutdown func(ctx context.Context) error
+
+// Init wires logging, metrics and tracing with consistent resource attributes for the whole toolkit.
+//
+// The version defaults to the buildinfo version. The service attributes and commit are added to every toolkit
+// logger through logging.SetFields, the release ("<name>@<version>") and environment to every error report
+// through errorreport.SetRelease, and the build to the build_info metric.
+// The returned providers are also registered globally (otel.SetMeterProvider/SetTracerProvider), together with
+// W3C trace-context and baggage propagators, so the server middlewares, httpclient and redisclient pick them up.
+//
+// Parameters:
+//
+//	info: Service information and exporter selection.
+//
+// Returns:
+//
+//	A zerolog.Logger enriched with service attributes, the MeterProvider, the TracerProvider,
+//	a Shutdown function that must be called on exit (e.g. as a server stop hook) and any setup error.
+//
+// Usage:
+//
+//	logger, _, _, shutdown, err := observability.Init(observability.ServiceInfo{Name: "my-app", Version: "1.0.0"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	srv.OnStop(shutdown)
+func Init(info ServiceInfo) (zerolog.Logger, metric.MeterProvider, trace.TracerProvider, Shutdown, error) {
+	ctx := context.Background()
+
+	build := buildinfo.Get()
+	if info.Version == "" {
+		info.Version = build.Version
+	}
+
+	logging.SetFields(map[string]string{
+		"service": info.Name,
+		"version": info.Version,
+		"commit":  build.ShortCommit(),
+		"env":     info.Environment,
+	})
+	logger := logging.New("app")
+	errorreport.SetRelease(info.Name+"@"+info.Version, info.Environment)
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(info.Name),
+		semconv.ServiceVersion(info.Version),
+		semconv.DeploymentEnvironment(info.Environment),
+		attribute.String("service.commit", build.Commit),
+	))
+	if err != nil {
+		return logger, nil, nil, nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	var shutdowns []Shutdown
+
+	meterProvider, metricsShutdown, err := newMeterProvider(ctx, info, res)
+	if err != nil {
+		return logger, nil, nil, nil, err
+	}
+	if metricsShutdown != nil {
+		shutdowns = append(shutdowns, metricsShutdown)
+	}
+
+	tracerProvider, tracesShutdown, err := newTracerProvider(ctx, info, res)
+	if err != nil {
+		return logger, nil, nil, nil, err
+	}
+	if tracesShutdown != nil {
+		shutdowns = append(shutdowns, tracesShutdown)
+	}
+
+	otel.SetMeterProvider(meterProvider)
+	if err := registerBuildInfo(meterProvider, info.Version, build); err != nil {
+		return logger, nil, nil, nil, err
+	}
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	shutdown := func(ctx context.Context) error {
+		var errs []error
+		for _, fn := range shutdowns {
+			errs = append(errs, fn(ctx))
+		}
+		return errors.Join(errs...)
+	}
+
+	return logger, meterProvider, tracerProvider, shutdown, nil
+}
+
+// MetricsHandler returns the HTTP handler exposing the Prometheus metrics registered by Init.
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
+
+// registerBuildInfo exports the build_info gauge, always 1, whose labels identify the running build, so dashboards
+// can join any metric with the version and commit of the pod.
+func registerBuildInfo(provider metric.MeterProvider, version string, build buildinfo.Info) error {
+	_, err := provider.Meter("github.com/devluispereira/go-package/observability").Int64ObservableGauge("build_info",
+		metric.WithDescription("Build of the running service, always 1."),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(1, metric.WithAttributes(
+				attribute.String("version", version),
+				attribute.String("commit", build.ShortCommit()),
+				attribute.String("go_version", build.GoVersion),
+			))
+			return nil
+		}))
+	if err != nil {
+		return fmt.Errorf("failed to register build_info metric: %w", err)
+	}
+	return nil
+}
+
+func newMeterProvider(ctx context.Context, info ServiceInfo, res *resource.Resource) (metric.MeterProvider, Shutdown, error) {
+	switch info.MetricsExporter {
+	case ExporterNone:
+		return noop.NewMeterProvider(), nil, nil
+
+	case ExporterOTLP:
+		exporter, err := otlpmetrichttp.New(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create otlp metric exporter: %w", err)
+		}
+
+		provider := sdkmetric.NewMeterProvider(
+			sdkmetric.WithResource(res),
+			sdkmetric.WithView(histogramView),
+			sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+		)
+		return provider, provider.Shutdown, nil
+
+	case ExporterPrometheus, "":
+		exporter, err := prometheus.New()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create prometheus exporter: %w", err)
+		}
+
+		provider := sdkmetric.NewMeterProvider(
+			sdkmetric.WithResource(res),
+			sdkmetric.WithView(histogramView),
+			sdkmetric.WithReader(exporter),
+		)
+		return provider, provider.Shutdown, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown metrics exporter: %s", info.MetricsExporter)
+	}
+}
+
+func newTracerProvider(ctx context.Context, info ServiceInfo, res *resource.Resource) (trace.TracerProvider, Shutdown, error) {
+	switch info.TracesExporter {
+	case ExporterNone:
+		return tracenoop.NewTracerProvider(), nil, nil
+
+	case ExporterOTLP, "":
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create otlp trace exporter: %w", err)
+		}
+
+		provider := sdktrace.NewTracerProvider(
+			sdktrace.WithResource(res),
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(info.SampleRatio))),
+		)
+		return provider, provider.Shutdown, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown traces exporter: %s", info.TracesExporter)
+	}
+}
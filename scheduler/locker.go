@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Locker coordinates singleton jobs between replicas.
+type Locker interface {
+	// TryLock tries to acquire the lock identified by key for ttl, reporting whether it was acquired.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// IRedisClient defines the Redis operations used by RedisLocker.
+type IRedisClient interface {
+	SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error)
+}
+
+// RedisLocker implements Locker with SET NX locks in Redis, so each execution runs on exactly one replica.
+type RedisLocker struct {
+	client IRedisClient
+	prefix string
+	owner  string
+}
+
+// NewRedisLocker creates a RedisLocker. Locks are stored under "<namespace>:scheduler:<key>".
+func NewRedisLocker(client IRedisClient, namespace string) *RedisLocker {
+	hostname, _ := os.Hostname()
+	return &RedisLocker{client: client, prefix: namespace + ":scheduler:", owner: hostname}
+}
+
+func (l *RedisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	acquired, err := l.client.SetNX(ctx, l.prefix+key, l.owner, ttl)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	return acquired, nil
+}
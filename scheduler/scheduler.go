@@ -0,0 +1,257 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/clock"
+	"github.com/devluispereira/go-package/logging"
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "github.com/devluispereira/go-package/scheduler"
+
+var logger = logging.New("scheduler")
+
+// defaultJobTimeout is used when Job.Timeout is not set.
+const defaultJobTimeout = time.Minute
+
+// Job describes a scheduled task.
+type Job struct {
+	// Name identifies the job in logs, metrics and singleton locks.
+	Name string
+	// Schedule is a standard 5-field cron expression ("*/5 * * * *") or a descriptor ("@hourly", "@every 30s").
+	// "@every" intervals are aligned to the wall clock ("@every 5m" runs at :00, :05, ...), so every replica
+	// schedules the same ticks.
+	Schedule string
+	// Timeout bounds each execution. Defaults to 1 minute.
+	Timeout time.Duration
+	// Singleton ensures that only one replica runs each execution. Requires a Locker.
+	Singleton bool
+	// Run executes the job. The context is canceled when Timeout expires or the scheduler stops.
+	Run func(ctx context.Context) error
+}
+
+// Scheduler runs jobs on cron schedules or fixed intervals.
+type Scheduler struct {
+	locker   Locker
+	clock    clock.Clock
+	jobs     []*scheduledJob
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	runs     metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+type scheduledJob struct {
+	job      Job
+	schedule cron.Schedule
+}
+
+// Option customizes a Scheduler.
+type Option func(*Scheduler)
+
+// WithLocker sets the Locker used by singleton jobs, usually a RedisLocker.
+func WithLocker(locker Locker) Option {
+	return func(s *Scheduler) {
+		s.locker = locker
+	}
+}
+
+// WithClock sets the Clock that times the schedules, so tests can drive them with clocktest.Fake.
+func WithClock(c clock.Clock) Option {
+	return func(s *Scheduler) {
+		s.clock = c
+	}
+}
+
+// New creates a Scheduler.
+//
+// Usage:
+//
+//	sched := scheduler.New(scheduler.WithLocker(scheduler.NewRedisLocker(redis, "my-app")))
+//	sched.Add(scheduler.Job{Name: "warm-cache", Schedule: "@every 5m", Singleton: true, Run: warmer.Warm})
+//	srv.OnStart(sched.Start)
+//	srv.OnStop(sched.Stop)
+func New(opts ...Option) *Scheduler {
+	meter := otel.Meter(instrumentationName)
+	runs, _ := meter.Int64Counter("scheduler.job.runs", metric.WithDescription("Job executions by status."))
+	duration, _ := meter.Float64Histogram("scheduler.job.duration", metric.WithUnit("s"), metric.WithDescription("Duration of job executions."))
+
+	s := &Scheduler{runs: runs, duration: duration, clock: clock.Real}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add registers a job. It must be called before Start.
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return errors.New("scheduler: job name and run function are required")
+	}
+
+	if job.Singleton && s.locker == nil {
+		return fmt.Errorf("scheduler: singleton job %s requires a locker", job.Name)
+	}
+
+	schedule, err := cron.ParseStandard(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("scheduler: invalid schedule for %s: %w", job.Name, err)
+	}
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		schedule = alignedSchedule{delay: every.Delay}
+	}
+
+	if job.Timeout <= 0 {
+		job.Timeout = defaultJobTimeout
+	}
+
+	s.jobs = append(s.jobs, &scheduledJob{job: job, schedule: schedule})
+	return nil
+}
+
+// Every registers a job executed at a fixed interval.
+func (s *Scheduler) Every(name string, interval time.Duration, run func(ctx context.Context) error) error {
+	return s.Add(Job{Name: name, Schedule: "@every " + interval.String(), Run: run})
+}
+
+// Start starts every registered job in the background. Its signature matches server.Hook.
+func (s *Scheduler) Start(_ context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, sj := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, sj)
+	}
+
+	return nil
+}
+
+// Stop stops scheduling and waits for running executions to finish, or for ctx to be done.
+// Its signature matches server.Hook.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, sj *scheduledJob) {
+	defer s.wg.Done()
+
+	for {
+		now := s.clock.Now()
+		next := sj.schedule.Next(now)
+		timer := s.clock.NewTimer(next.Sub(now))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+			s.execute(ctx, sj.job, next, sj.schedule.Next(next).Sub(next))
+		}
+	}
+}
+
+// execute runs the execution of job scheduled at tick; period is the time until the following tick.
+func (s *Scheduler) execute(parent context.Context, job Job, tick time.Time, period time.Duration) {
+	ctx, cancel := context.WithTimeout(parent, job.Timeout)
+	defer cancel()
+
+	if job.Singleton {
+		// The lock is scoped to the scheduled tick, which every replica computes alike (cron expressions and
+		// aligned intervals), and held until the next tick, so replicas whose timers fire later, e.g. with a
+		// skewed clock, never run the same tick again, even if the job finished quickly.
+		acquired, err := s.locker.TryLock(ctx, fmt.Sprintf("%s:%d", job.Name, tick.Unix()), max(job.Timeout, period))
+		if err != nil {
+			logger.Error().Err(err).Str("job", job.Name).Msg("scheduler:lock failed")
+			s.record(ctx, job.Name, "error", 0)
+			return
+		}
+		if !acquired {
+			s.record(ctx, job.Name, "skipped", 0)
+			return
+		}
+	}
+
+	start := s.clock.Now()
+	err := runSafely(ctx, job.Run)
+	elapsed := s.clock.Since(start)
+
+	status := "ok"
+	var panicErr *PanicError
+	switch {
+	case errors.As(err, &panicErr):
+		status = "panic"
+		logger.Error().Err(err).Str("job", job.Name).Str("stack", string(panicErr.Stack)).Msg("scheduler:job panicked")
+	case err != nil:
+		status = "error"
+		logger.Error().Err(err).Str("job", job.Name).Dur("duration", elapsed).Msg("scheduler:job failed")
+	default:
+		logger.Debug().Str("job", job.Name).Dur("duration", elapsed).Msg("scheduler:job finished")
+	}
+
+	s.record(ctx, job.Name, status, elapsed)
+}
+
+// alignedSchedule runs every delay at multiples of delay since the zero time, so replicas started at different
+// moments compute the same ticks, and so the same singleton lock keys. The "@every" schedule of cron counts from
+// the start of the process instead.
+type alignedSchedule struct {
+	delay time.Duration
+}
+
+func (s alignedSchedule) Next(t time.Time) time.Time {
+	return t.Truncate(s.delay).Add(s.delay)
+}
+
+func (s *Scheduler) record(ctx context.Context, name, status string, elapsed time.Duration) {
+	attrs := metric.WithAttributes(attribute.String("job", name), attribute.String("status", status))
+	s.runs.Add(context.WithoutCancel(ctx), 1, attrs)
+	if status != "skipped" {
+		s.duration.Record(context.WithoutCancel(ctx), elapsed.Seconds(), attrs)
+	}
+}
+
+// PanicError is returned when a job panics.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+func runSafely(ctx context.Context, run func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	return run(ctx)
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devluispereira/go-package/clock/clocktest"
+)
+
+// memoryLocker is a Locker shared by the schedulers of a test, as Redis is shared by replicas.
+type memoryLocker struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+func (l *memoryLocker) TryLock(_ context.Context, key string, _ time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.keys[key] {
+		return false, nil
+	}
+	l.keys[key] = true
+	return true, nil
+}
+
+func TestEveryIsAlignedAcrossReplicas(t *testing.T) {
+	s := New()
+	if err := s.Add(Job{Name: "job", Schedule: "@every 5m", Run: func(context.Context) error { return nil }}); err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	schedule := s.jobs[0].schedule
+	a := schedule.Next(base.Add(7 * time.Second))
+	b := schedule.Next(base.Add(3*time.Minute + 41*time.Second))
+	if !a.Equal(b) || !a.Equal(base.Add(5*time.Minute)) {
+		t.Fatalf("replicas scheduled %v and %v, want %v", a, b, base.Add(5*time.Minute))
+	}
+}
+
+func TestSingletonRunsOncePerTickAcrossReplicas(t *testing.T) {
+	locker := &memoryLocker{keys: map[string]bool{}}
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var runs atomic.Int32
+	ran := make(chan struct{}, 2)
+	job := Job{Name: "report", Schedule: "@every 1m", Singleton: true, Run: func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}}
+
+	// Replicas started at different moments, with their own clocks.
+	var clocks []*clocktest.Fake
+	for _, offset := range []time.Duration{3 * time.Second, 42 * time.Second} {
+		clk := clocktest.NewFake(base.Add(offset))
+		s := New(WithLocker(locker), WithClock(clk))
+		counted := job
+		counted.Run = func(ctx context.Context) error {
+			defer func() { ran <- struct{}{} }()
+			return job.Run(ctx)
+		}
+		if err := s.Add(counted); err != nil {
+			t.Fatal(err)
+		}
+		_ = s.Start(context.Background())
+		t.Cleanup(func() { _ = s.Stop(context.Background()) })
+		clocks = append(clocks, clk)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, clk := range clocks {
+		if err := clk.WaitForTimers(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+		clk.Set(base.Add(time.Minute + time.Second))
+	}
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("job never ran")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("tick ran %d times, want 1", n)
+	}
+}
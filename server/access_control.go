@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/devluispereira/go-package/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Claims identifies the caller of a request. Authentication middlewares (JWT, API key) store it in the user
+// context with WithClaims.
+type Claims struct {
+	Subject string
+	Roles   []string
+	// Scopes are granted permissions, e.g. "catalog:read". A "catalog:*" scope grants every catalog scope.
+	Scopes []string
+	// Extra holds the remaining claims, for callback policies.
+	Extra map[string]any
+}
+
+type ClaimsKeyType struct{}
+
+// WithClaims returns a copy of ctx carrying the claims of the caller.
+//
+// Usage:
+//
+//	// In an authentication middleware:
+//	c.SetUserContext(server.WithClaims(c.UserContext(), &server.Claims{Subject: token.Subject, Scopes: token.Scopes}))
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, ClaimsKeyType{}, claims)
+}
+
+// ClaimsFromContext returns the claims stored by the authentication middleware, if any.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(ClaimsKeyType{}).(*Claims)
+	return claims, ok && claims != nil
+}
+
+// HasScope reports whether the claims grant scope, directly or through a "<resource>:*" wildcard.
+func (c *Claims) HasScope(scope string) bool {
+	for _, granted := range c.Scopes {
+		if granted == scope {
+			return true
+		}
+		if resource, ok := strings.CutSuffix(granted, ":*"); ok && strings.HasPrefix(scope, resource+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole reports whether the claims include role.
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// AccessPolicy decides whether the caller may access the route.
+type AccessPolicy func(c *fiber.Ctx, claims *Claims) bool
+
+// RequireScopes allows the request only when the caller has every scope. See RequirePolicy.
+//
+// Usage:
+//
+//	app.Get("/products", server.RequireScopes("catalog:read"), handler)
+func RequireScopes(scopes ...string) fiber.Handler {
+	return RequirePolicy("scopes:"+strings.Join(scopes, ","), func(_ *fiber.Ctx, claims *Claims) bool {
+		for _, scope := range scopes {
+			if !claims.HasScope(scope) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// RequireRole allows the request only when the caller has any of the roles. See RequirePolicy.
+//
+// Usage:
+//
+//	admin := app.Group("/admin", server.RequireRole("admin"))
+func RequireRole(roles ...string) fiber.Handler {
+	return RequirePolicy("roles:"+strings.Join(roles, ","), func(_ *fiber.Ctx, claims *Claims) bool {
+		return slices.ContainsFunc(roles, claims.HasRole)
+	})
+}
+
+// RequirePolicy allows the request only when policy accepts the claims of the caller, applied per route or group.
+//
+// Parameters:
+//
+//	name: Identifies the policy in logs.
+//	policy: Decides on the claims stored by the authentication middleware (see WithClaims).
+//
+// Behavior:
+//   - Requests without claims are rejected with 401 (CodeUnauthenticated).
+//   - Requests the policy denies are rejected with 403 (CodePermissionDenied) and logged as a security event
+//     ("event": "security.access_denied"). Both are answered as problem+json by ErrorHandler.
+//   - Register it after the authentication middleware.
+//
+// Usage:
+//
+//	app.Put("/products/:id", server.RequirePolicy("product-owner", func(c *fiber.Ctx, claims *server.Claims) bool {
+//		return claims.HasRole("admin") || claims.Subject == owners[c.Params("id")]
+//	}), handler)
+func RequirePolicy(name string, policy AccessPolicy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := ClaimsFromContext(c.UserContext())
+		if !ok {
+			return Errorf(CodeUnauthenticated, "authentication required")
+		}
+
+		if !policy(c, claims) {
+			log := logging.FromContext(c.UserContext())
+			log.Warn().
+				Str("event", "security.access_denied").
+				Str("policy", name).
+				Str("subject", claims.Subject).
+				Str("method", c.Method()).
+				Str("path", c.Path()).
+				Msg("access denied")
+
+			return Errorf(CodePermissionDenied, "access denied")
+		}
+
+		return c.Next()
+	}
+}
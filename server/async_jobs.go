@@ -0,0 +1,280 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/logging"
+	"github.com/devluispereira/go-package/workers"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// IJobRedisClient defines the Redis operations used by AsyncJobs. It is satisfied by redisclient.RedisClient.
+type IJobRedisClient interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value any, expiration time.Duration) error
+}
+
+// Job states.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job is the state of an async job, served by the status endpoint.
+type Job struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	// Progress is the completion percentage, from 0 to 100, reported by the work with JobHandle.Progress.
+	Progress int    `json:"progress"`
+	Message  string `json:"message,omitempty"`
+	// Result is the JSON value returned by the work, once it succeeded.
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// JobFunc is the slow part of a handler, executed in background. The returned value becomes the job result.
+type JobFunc func(ctx context.Context, job *JobHandle) (any, error)
+
+// AsyncJobsConfig holds the configuration of AsyncJobs.
+type AsyncJobsConfig struct {
+	Client IJobRedisClient
+	// Pool executes the jobs. If nil, jobs run in their own goroutine.
+	Pool *workers.Pool
+	// StatusPath is the path of the status endpoint registered with Register, prefixing the job IDs in the
+	// Location header. If empty, uses "/jobs".
+	StatusPath string
+	// KeyPrefix namespaces the job keys in Redis. If empty, uses "job:".
+	KeyPrefix string
+	// TTL is how long finished jobs remain queryable before Redis removes them. If zero, uses 1 hour.
+	TTL time.Duration
+	// MaxDuration bounds pending and running jobs, whose keys expire after it even if the process dies while
+	// running them. If zero, uses 24 hours.
+	MaxDuration time.Duration
+}
+
+// AsyncJobs turns slow handlers into async jobs: the request is answered with 202 and a Location to a status
+// endpoint, and the work runs in background with its progress stored in Redis.
+type AsyncJobs struct {
+	cfg AsyncJobsConfig
+}
+
+// NewAsyncJobs creates the async job component. Register its status endpoint with Register.
+//
+// Usage:
+//
+//	jobs := server.NewAsyncJobs(server.AsyncJobsConfig{Client: redis, Pool: pool, StatusPath: "/api/jobs"})
+//	jobs.Register(app)
+//
+//	app.Post("/api/reports", jobs.Handler(func(c *fiber.Ctx) (server.JobFunc, error) {
+//		var req ReportRequest
+//		if err := c.BodyParser(&req); err != nil {
+//			return nil, server.Errorf(server.CodeInvalidArgument, "invalid body")
+//		}
+//		return func(ctx context.Context, job *server.JobHandle) (any, error) {
+//			return reports.Build(ctx, req, job.Progress)
+//		}, nil
+//	}))
+func NewAsyncJobs(cfg AsyncJobsConfig) *AsyncJobs {
+	if cfg.StatusPath == "" {
+		cfg.StatusPath = "/jobs"
+	}
+	cfg.StatusPath = strings.TrimSuffix(cfg.StatusPath, "/")
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = "job:"
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = time.Hour
+	}
+	if cfg.MaxDuration <= 0 {
+		cfg.MaxDuration = 24 * time.Hour
+	}
+
+	return &AsyncJobs{cfg: cfg}
+}
+
+// Handler creates an async job per request.
+//
+// Parameters:
+//
+//	prepare: Reads what the work needs from the request (the Fiber context can't be used after the response)
+//	  and returns the work. Its errors are answered like handler errors.
+//
+// Behavior:
+//   - The job is stored as pending and the request is answered with 202, a Location header pointing to the
+//     status endpoint and the job in the body.
+//   - The work runs on the Pool with the request context values (trace, forwarded headers, tenant), but not its
+//     cancellation. Jobs the Pool can't accept right away are rejected with 503.
+//   - The job ends as succeeded, with the result, or failed, with the error message, and remains queryable for TTL.
+func (j *AsyncJobs) Handler(prepare func(c *fiber.Ctx) (JobFunc, error)) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		work, err := prepare(c)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		created := Job{ID: newJobID(), Status: JobPending, CreatedAt: now, UpdatedAt: now}
+		handle := &JobHandle{jobs: j, job: created}
+
+		if err := j.save(c.UserContext(), created); err != nil {
+			return Errorf(CodeUnavailable, "failed to create job: %w", err)
+		}
+
+		values := context.WithoutCancel(c.UserContext())
+		task := func(ctx context.Context) error {
+			handle.run(jobContext{Context: ctx, values: values}, work)
+			return nil
+		}
+
+		if j.cfg.Pool == nil {
+			go func() { _ = task(context.Background()) }()
+		} else if err := j.cfg.Pool.TrySubmit(task); err != nil {
+			handle.finish(values, nil, err)
+			return Errorf(CodeUnavailable, "job queue unavailable: %w", err)
+		}
+
+		c.Location(j.cfg.StatusPath + "/" + created.ID)
+		return c.Status(fiber.StatusAccepted).JSON(created)
+	}
+}
+
+// Register registers the status endpoint:
+//
+//	GET <StatusPath>/:id  the job, or 404 when it is unknown or was already removed.
+//
+// Mount it behind the same authentication as the routes creating the jobs.
+func (j *AsyncJobs) Register(router fiber.Router) {
+	router.Get(j.cfg.StatusPath+"/:id", func(c *fiber.Ctx) error {
+		job, err := j.Get(c.UserContext(), c.Params("id"))
+		if errors.Is(err, redis.Nil) {
+			return Errorf(CodeNotFound, "job %s not found", c.Params("id"))
+		}
+		if err != nil {
+			return Errorf(CodeUnavailable, "failed to read job: %w", err)
+		}
+
+		return c.JSON(job)
+	})
+}
+
+// Get returns the job with the given ID. It returns redis.Nil for unknown or removed jobs.
+func (j *AsyncJobs) Get(ctx context.Context, id string) (Job, error) {
+	raw, err := j.cfg.Client.Get(ctx, j.cfg.KeyPrefix+id)
+	if err != nil {
+		return Job{}, err
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		return Job{}, fmt.Errorf("failed to decode job %s: %w", id, err)
+	}
+	return job, nil
+}
+
+func (j *AsyncJobs) save(ctx context.Context, job Job) error {
+	ttl := j.cfg.MaxDuration
+	if job.Status == JobSucceeded || job.Status == JobFailed {
+		ttl = j.cfg.TTL
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return j.cfg.Client.Set(ctx, j.cfg.KeyPrefix+job.ID, data, ttl)
+}
+
+// JobHandle lets the work of a job report its progress. It is safe for concurrent use.
+type JobHandle struct {
+	jobs *AsyncJobs
+	mu   sync.Mutex
+	job  Job
+}
+
+// ID returns the job ID.
+func (h *JobHandle) ID() string {
+	return h.job.ID
+}
+
+// Progress stores the completion percentage (0 to 100) and an optional message, served by the status endpoint.
+func (h *JobHandle) Progress(ctx context.Context, percent int, message string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.job.Progress = min(max(percent, 0), 100)
+	h.job.Message = message
+	h.job.UpdatedAt = time.Now().UTC()
+	return h.jobs.save(ctx, h.job)
+}
+
+func (h *JobHandle) run(ctx context.Context, work JobFunc) {
+	h.mu.Lock()
+	h.job.Status = JobRunning
+	h.job.UpdatedAt = time.Now().UTC()
+	err := h.jobs.save(ctx, h.job)
+	h.mu.Unlock()
+
+	if err != nil {
+		h.finish(ctx, nil, err)
+		return
+	}
+
+	result, err := work(ctx, h)
+	h.finish(ctx, result, err)
+}
+
+func (h *JobHandle) finish(ctx context.Context, result any, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.job.UpdatedAt = time.Now().UTC()
+
+	if err == nil {
+		h.job.Result, err = json.Marshal(result)
+	}
+
+	if err != nil {
+		h.job.Status = JobFailed
+		h.job.Error = err.Error()
+	} else {
+		h.job.Status = JobSucceeded
+		h.job.Progress = 100
+	}
+
+	if saveErr := h.jobs.save(context.WithoutCancel(ctx), h.job); saveErr != nil {
+		log := logging.FromContext(ctx)
+		log.Error().Err(saveErr).Str("job_id", h.job.ID).Str("status", h.job.Status).Msg("failed to save job")
+	}
+}
+
+// jobContext has the cancellation of the worker context and the values of the request context.
+type jobContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c jobContext) Value(key any) any {
+	if v := c.Context.Value(key); v != nil {
+		return v
+	}
+	return c.values.Value(key)
+}
+
+func newJobID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/logging"
+	"github.com/devluispereira/go-package/messaging"
+	"github.com/devluispereira/go-package/workers"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditRecord describes who did what, when, and the outcome of a request to a sensitive route.
+type AuditRecord struct {
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor"`
+	Tenant     string    `json:"tenant,omitempty"`
+	Method     string    `json:"method"`
+	Route      string    `json:"route"`
+	Path       string    `json:"path"`
+	ResourceID string    `json:"resource_id,omitempty"`
+	Status     int       `json:"status"`
+	// Outcome is "success" for statuses below 400 and "failure" otherwise.
+	Outcome   string `json:"outcome"`
+	RequestID string `json:"request_id"`
+	IP        string `json:"ip"`
+	// PrevHash and Hash chain the records of an AuditMiddleware: Hash covers the record and PrevHash, so a
+	// removed or changed record breaks the chain (see VerifyAuditChain).
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// AuditSink stores audit records.
+type AuditSink interface {
+	WriteAudit(ctx context.Context, record AuditRecord) error
+}
+
+// LogAuditSink writes the audit records to the "audit" logger.
+type LogAuditSink struct{}
+
+var auditLogger = logging.New("audit")
+
+func (LogAuditSink) WriteAudit(_ context.Context, record AuditRecord) error {
+	auditLogger.Info().
+		Str("event", "audit").
+		Time("at", record.Time).
+		Str("actor", record.Actor).
+		Str("tenant", record.Tenant).
+		Str("method", record.Method).
+		Str("route", record.Route).
+		Str("path", record.Path).
+		Str("resource_id", record.ResourceID).
+		Int("status", record.Status).
+		Str("outcome", record.Outcome).
+		Str("request_id", record.RequestID).
+		Str("ip", record.IP).
+		Str("prev_hash", record.PrevHash).
+		Str("hash", record.Hash).
+		Msg("audit record")
+	return nil
+}
+
+// PublisherAuditSink publishes the audit records as JSON messages, e.g. to a Redis Stream with
+// redisstream.Broker.
+type PublisherAuditSink struct {
+	Publisher messaging.Publisher
+	// Topic is the stream or topic receiving the records. If empty, uses "audit".
+	Topic string
+}
+
+func (s PublisherAuditSink) WriteAudit(ctx context.Context, record AuditRecord) error {
+	topic := s.Topic
+	if topic == "" {
+		topic = "audit"
+	}
+
+	msg, err := messaging.NewMessage(messaging.JSONCodec{}, topic, record)
+	if err != nil {
+		return err
+	}
+	msg.Key = record.Actor
+
+	return s.Publisher.Publish(ctx, msg)
+}
+
+// AuditConfig holds the configuration for the audit middleware.
+type AuditConfig struct {
+	// Sink stores the records. If nil, uses LogAuditSink.
+	Sink AuditSink
+	// ResourceID extracts the ID of the resource acted upon. If nil, uses the "id" route parameter.
+	ResourceID func(c *fiber.Ctx) string
+	// Key makes the chain hashes HMAC-SHA256, so they can't be recomputed without it. If empty, uses SHA-256.
+	Key []byte
+	// Writer writes the records in background. If nil, uses workers.DefaultWriter().
+	Writer *workers.Writer
+}
+
+// AuditMiddleware records who did what, when, and the outcome of each request, for compliance on admin and
+// other sensitive routes.
+//
+// Parameters:
+//
+//	cfg: Audit configuration (sink, resource ID extractor and chain key).
+//
+// Behavior:
+//   - The actor is the subject of the authenticated claims (see WithClaims), falling back to the client IP; headers
+//     sent by the client are never trusted as the actor. The tenant comes from TenantMiddleware.
+//   - Records are chained: each Hash covers the record and the previous Hash, making removed or altered records
+//     detectable with VerifyAuditChain.
+//   - Records are written in background by cfg.Writer, retried on failure, without delaying the response.
+//     Register the writer Stop as a stop hook to flush them on shutdown.
+//
+// Usage:
+//
+//	broker := redisstream.New(redis.Background().Client(), redisstream.Config{})
+//	admin := app.Group("/admin", server.AuditMiddleware(server.AuditConfig{
+//		Sink: server.PublisherAuditSink{Publisher: broker, Topic: "audit"},
+//		Key:  auditKey,
+//	}))
+func AuditMiddleware(cfg AuditConfig) fiber.Handler {
+	if cfg.Sink == nil {
+		cfg.Sink = LogAuditSink{}
+	}
+	if cfg.ResourceID == nil {
+		cfg.ResourceID = func(c *fiber.Ctx) string { return c.Params("id") }
+	}
+	if cfg.Writer == nil {
+		cfg.Writer = workers.DefaultWriter()
+	}
+
+	chain := &auditChain{key: cfg.Key}
+
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		status := responseStatus(c, err)
+
+		record := AuditRecord{
+			Time:       time.Now().UTC(),
+			Actor:      auditActor(c),
+			Method:     c.Method(),
+			Route:      c.Route().Path,
+			Path:       c.Path(),
+			ResourceID: cfg.ResourceID(c),
+			Status:     status,
+			Outcome:    "success",
+			RequestID:  RequestID(c),
+			IP:         c.IP(),
+		}
+		if status >= 400 {
+			record.Outcome = "failure"
+		}
+		if tenant, ok := TenantFromContext(c.UserContext()); ok {
+			record.Tenant = tenant.ID
+		}
+
+		record = chain.link(record)
+
+		if !cfg.Writer.Write(func(ctx context.Context) error { return cfg.Sink.WriteAudit(ctx, record) }) {
+			log := logging.FromContext(c.UserContext())
+			log.Error().Str("request_id", record.RequestID).Str("hash", record.Hash).Msg("audit record dropped")
+		}
+
+		return err
+	}
+}
+
+func auditActor(c *fiber.Ctx) string {
+	if claims, ok := ClaimsFromContext(c.UserContext()); ok && claims.Subject != "" {
+		return claims.Subject
+	}
+	return c.IP()
+}
+
+// auditChain links the records of a middleware in a hash chain.
+type auditChain struct {
+	key  []byte
+	mu   sync.Mutex
+	last string
+}
+
+func (a *auditChain) link(record AuditRecord) AuditRecord {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	record.PrevHash = a.last
+	record.Hash = auditHash(a.key, record)
+	a.last = record.Hash
+	return record
+}
+
+// auditHash hashes the record, including PrevHash and excluding Hash.
+func auditHash(key []byte, record AuditRecord) string {
+	record.Hash = ""
+	payload, _ := json.Marshal(record)
+
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write(payload)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyAuditChain checks that records, in the order they were produced, form an unbroken hash chain. It returns
+// an error naming the first record that was altered or whose predecessor is missing.
+func VerifyAuditChain(records []AuditRecord, key []byte) error {
+	for i, record := range records {
+		if auditHash(key, record) != record.Hash {
+			return fmt.Errorf("audit record %d (%s) was altered", i, record.RequestID)
+		}
+		if i > 0 && record.PrevHash != records[i-1].Hash {
+			return fmt.Errorf("audit chain broken before record %d (%s)", i, record.RequestID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/devluispereira/go-package/workers"
+	"github.com/gofiber/fiber/v2"
+)
+
+type memoryAuditSink struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+func (s *memoryAuditSink) WriteAudit(_ context.Context, record AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+func TestAuditActorIgnoresClientHeader(t *testing.T) {
+	sink := &memoryAuditSink{}
+	writer := workers.NewWriter(workers.WriterConfig{})
+
+	app := fiber.New()
+	app.Use(AuditMiddleware(AuditConfig{Sink: sink, Writer: writer}))
+	app.Post("/admin/purge", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+	app.Post("/admin/authenticated", func(c *fiber.Ctx) error {
+		c.SetUserContext(WithClaims(c.UserContext(), &Claims{Subject: "maria"}))
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	for _, path := range []string{"/admin/purge", "/admin/authenticated"} {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("X-Admin-Actor", "someone-else")
+		doStatus(t, app, req)
+	}
+	if err := writer.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.records) != 2 {
+		t.Fatalf("got %d records, want 2", len(sink.records))
+	}
+	actors := map[string]bool{}
+	for _, record := range sink.records {
+		if record.Actor == "someone-else" {
+			t.Fatalf("actor taken from the X-Admin-Actor header: %+v", record)
+		}
+		actors[record.Actor] = true
+	}
+	if !actors["maria"] {
+		t.Fatalf("authenticated subject not recorded: %+v", sink.records)
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"github.com/devluispereira/go-package/baggage"
+	"github.com/devluispereira/go-package/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+// BaggageMiddleware parses the W3C baggage header of the request into the user context, so handlers read the
+// cross-service context with the baggage package and httpclient calls made with c.UserContext() propagate it.
+//
+// Behavior:
+//   - Members set on the context by earlier middlewares (e.g. TracingMiddleware) are kept unless the header
+//     replaces them.
+//   - A malformed or oversized header is ignored and logged at debug level; the request is served without it.
+//
+// Usage:
+//
+//	var TenantTier = baggage.NewKey[string]("tenant.tier")
+//
+//	app.Use(server.BaggageMiddleware())
+//	app.Get("/offers", func(c *fiber.Ctx) error {
+//		tier, _ := TenantTier.Get(c.UserContext())
+//		...
+//	})
+func BaggageMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(baggage.HeaderName)
+		if header == "" {
+			return c.Next()
+		}
+
+		ctx, err := baggage.FromHeader(c.UserContext(), header)
+		if err != nil {
+			logging.FromContext(ctx).Debug().Err(err).Msg("Ignoring invalid baggage header")
+			return c.Next()
+		}
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// BatchItem is a sub-request of a batch.
+type BatchItem struct {
+	// ID identifies the item in the response. If empty, uses the item index.
+	ID      string            `json:"id"`
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchResult is the response of a sub-request. Body is the JSON response body, or a JSON string for other bodies.
+type BatchResult struct {
+	ID     string          `json:"id"`
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// BatchTarget executes the sub-requests of a path prefix, e.g. against a downstream service.
+type BatchTarget func(ctx context.Context, item BatchItem) BatchResult
+
+// BatchConfig holds the configuration of the batch endpoint.
+type BatchConfig struct {
+	// App executes the sub-requests without a Target in process, through its routes and middlewares.
+	App *fiber.App
+	// Targets execute the sub-requests whose path starts with the key, e.g. "/catalog" with BatchClient.
+	Targets map[string]BatchTarget
+	// MaxItems is the maximum number of sub-requests per batch. If zero, uses 20.
+	MaxItems int
+	// Concurrency is the number of sub-requests executed at once. If zero, uses 8.
+	Concurrency int
+	// Timeout is the deadline of the whole batch. If zero, uses 10 seconds.
+	Timeout time.Duration
+	// Headers are copied from the batch request to the in-process sub-requests, along with its forwarded headers
+	// and before the item headers. If empty, uses Authorization, Cookie and Accept-Language.
+	Headers []string
+}
+
+type batchRequest struct {
+	Requests []BatchItem `json:"requests"`
+}
+
+type batchResponse struct {
+	Responses []BatchResult `json:"responses"`
+}
+
+// BatchHandler serves a batch endpoint: a POST with N sub-requests, executed concurrently, answered with the
+// status and body of each one, so a client (e.g. a BFF screen) loads several resources in one round trip.
+//
+// Parameters:
+//
+//	cfg: App or Targets executing the sub-requests, limits and deadline.
+//
+// Behavior:
+//   - The body is {"requests": [{"id": "p1", "method": "GET", "path": "/api/products/1"}, ...]} and the response
+//     {"responses": [{"id": "p1", "status": 200, "body": {...}}, ...]}, in the request order.
+//   - Sub-requests go to the Target with the longest matching path prefix or, without one, to App in process,
+//     with the configured Headers of the batch request and the remaining deadline in X-Request-Timeout.
+//   - Every sub-request gets a status: items still running at the deadline get 504, and failures don't fail the
+//     batch, which is answered with 200 (partial results).
+//   - Batches over MaxItems, and sub-requests to the batch endpoint itself, are rejected with 400.
+//
+// Usage:
+//
+//	app.Post("/batch", server.BatchHandler(server.BatchConfig{
+//		App:     app,
+//		Targets: map[string]server.BatchTarget{"/catalog": server.BatchClient(catalog, "/catalog")},
+//		Timeout: 3 * time.Second,
+//	}))
+func BatchHandler(cfg BatchConfig) fiber.Handler {
+	if cfg.MaxItems <= 0 {
+		cfg.MaxItems = 20
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 8
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if len(cfg.Headers) == 0 {
+		cfg.Headers = []string{fiber.HeaderAuthorization, fiber.HeaderCookie, fiber.HeaderAcceptLanguage}
+	}
+
+	return func(c *fiber.Ctx) error {
+		var batch batchRequest
+		if err := json.Unmarshal(c.Body(), &batch); err != nil {
+			return Errorf(CodeInvalidArgument, "invalid batch body")
+		}
+		if len(batch.Requests) > cfg.MaxItems {
+			return Errorf(CodeInvalidArgument, "batch has %d requests, the maximum is %d", len(batch.Requests), cfg.MaxItems)
+		}
+
+		headers := make(map[string]string, len(cfg.Headers))
+		for _, name := range cfg.Headers {
+			if value := c.Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		if forwarded, ok := c.UserContext().Value("forwardedHeaders").(map[string]string); ok {
+			for name, value := range forwarded {
+				headers[name] = value
+			}
+		}
+
+		for i := range batch.Requests {
+			item := &batch.Requests[i]
+			if item.ID == "" {
+				item.ID = strconv.Itoa(i)
+			}
+			if item.Method == "" {
+				item.Method = fiber.MethodGet
+			}
+			item.Method = strings.ToUpper(item.Method)
+			if strings.SplitN(item.Path, "?", 2)[0] == c.Path() {
+				return Errorf(CodeInvalidArgument, "batch request %s targets the batch endpoint", item.ID)
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), cfg.Timeout)
+		defer cancel()
+
+		var (
+			mu       sync.Mutex
+			wg       sync.WaitGroup
+			finished bool
+		)
+		results := make([]BatchResult, len(batch.Requests))
+		slots := make(chan struct{}, cfg.Concurrency)
+
+		for i, item := range batch.Requests {
+			results[i] = batchError(fiber.StatusGatewayTimeout, "batch deadline exceeded")
+			results[i].ID = item.ID
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				select {
+				case slots <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				defer func() { <-slots }()
+
+				result := cfg.execute(ctx, item, headers)
+				result.ID = item.ID
+
+				mu.Lock()
+				defer mu.Unlock()
+				if !finished {
+					results[i] = result
+				}
+			}()
+		}
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+
+		// Items still running keep their 504: their results are discarded once the response is built.
+		mu.Lock()
+		finished = true
+		responses := slices.Clone(results)
+		mu.Unlock()
+
+		return c.JSON(batchResponse{Responses: responses})
+	}
+}
+
+func (cfg BatchConfig) execute(ctx context.Context, item BatchItem, headers map[string]string) BatchResult {
+	var (
+		target BatchTarget
+		prefix string
+	)
+	for p, t := range cfg.Targets {
+		if strings.HasPrefix(item.Path, p) && len(p) > len(prefix) {
+			prefix, target = p, t
+		}
+	}
+
+	if target != nil {
+		return target(ctx, item)
+	}
+	if cfg.App == nil {
+		return batchError(fiber.StatusNotFound, "no target for "+item.Path)
+	}
+
+	return cfg.executeInProcess(ctx, item, headers)
+}
+
+// executeInProcess runs the sub-request through the routes and middlewares of App.
+func (cfg BatchConfig) executeInProcess(ctx context.Context, item BatchItem, headers map[string]string) BatchResult {
+	var fctx fasthttp.RequestCtx
+	req := &fctx.Request
+	req.Header.SetMethod(item.Method)
+	req.SetRequestURI(item.Path)
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range item.Headers {
+		req.Header.Set(k, v)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Header.Set("X-Request-Timeout", strconv.FormatInt(time.Until(deadline).Milliseconds(), 10))
+	}
+	if len(item.Body) > 0 {
+		req.SetBody(item.Body)
+		if len(req.Header.ContentType()) == 0 {
+			req.Header.SetContentType(fiber.MIMEApplicationJSON)
+		}
+	}
+
+	cfg.App.Handler()(&fctx)
+
+	return batchBody(fctx.Response.StatusCode(), fctx.Response.Body())
+}
+
+// BatchClient returns a BatchTarget executing sub-requests with a downstream client, with stripPrefix removed
+// from the path. The forwarded headers of the batch request and its remaining deadline are propagated by the
+// client.
+func BatchClient(client *httpclient.HTTPClient, stripPrefix string) BatchTarget {
+	return func(ctx context.Context, item BatchItem) BatchResult {
+		path := strings.TrimPrefix(item.Path, stripPrefix)
+
+		var (
+			resp *httpclient.HTTPResponse
+			err  error
+		)
+		switch item.Method {
+		case fiber.MethodGet:
+			resp, err = client.Get(ctx, path)
+		case fiber.MethodPost:
+			resp, err = client.Post(ctx, path, bytes.NewReader(item.Body))
+		case fiber.MethodPut:
+			resp, err = client.Put(ctx, path, bytes.NewReader(item.Body))
+		case fiber.MethodPatch:
+			resp, err = client.Patch(ctx, path, bytes.NewReader(item.Body))
+		case fiber.MethodDelete:
+			resp, err = client.Delete(ctx, path)
+		default:
+			return batchError(fiber.StatusMethodNotAllowed, "unsupported method "+item.Method)
+		}
+
+		if ctx.Err() != nil {
+			return batchError(fiber.StatusGatewayTimeout, "batch deadline exceeded")
+		}
+		if err != nil {
+			return batchError(fiber.StatusBadGateway, err.Error())
+		}
+
+		body, _ := json.Marshal(resp.Body)
+		return BatchResult{Status: resp.StatusCode, Body: body}
+	}
+}
+
+func batchBody(status int, body []byte) BatchResult {
+	if len(body) == 0 {
+		return BatchResult{Status: status}
+	}
+	if json.Valid(body) {
+		return BatchResult{Status: status, Body: bytes.Clone(body)}
+	}
+
+	encoded, _ := json.Marshal(string(body))
+	return BatchResult{Status: status, Body: encoded}
+}
+
+func batchError(status int, message string) BatchResult {
+	body, _ := json.Marshal(ErrorBody{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: message,
+		Code:   codeForStatus(status).Name,
+	})
+	return BatchResult{Status: status, Body: body}
+}
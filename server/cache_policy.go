@@ -0,0 +1,184 @@
+package server
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devluispereira/go-package/variants"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CacheRule is a Cache-Control policy for the routes matching Route, optionally restricted to a tenant or device.
+type CacheRule struct {
+	// Route is a path pattern: "*" matches one segment and a trailing "/**" matches any suffix, e.g. "/api/products/**".
+	Route string `json:"route"`
+	// Tenant restricts the rule to a tenant ID (see TenantMiddleware).
+	Tenant string `json:"tenant"`
+	// Device restricts the rule to a device type, read from CachePolicyConfig.DeviceHeader.
+	Device string        `json:"device"`
+	Type   CacheType     `json:"type"`
+	MaxAge time.Duration `json:"max_age"`
+	// SMaxAge is the shared caches (CDN) max-age.
+	SMaxAge              time.Duration `json:"s_maxage"`
+	StaleWhileRevalidate time.Duration `json:"stale_while_revalidate"`
+	StaleIfError         time.Duration `json:"stale_if_error"`
+	Immutable            bool          `json:"immutable"`
+	// SurrogateMaxAge sets the Surrogate-Control header, consumed and stripped by the CDN.
+	SurrogateMaxAge time.Duration `json:"surrogate_max_age"`
+	// SurrogateKeys are sent in the Surrogate-Key header, enabling purge by key at the CDN.
+	SurrogateKeys []string `json:"surrogate_keys"`
+}
+
+// CachePolicyConfig holds the Cache-Control rules. It can be loaded with the config package from a JSON/YAML file.
+type CachePolicyConfig struct {
+	Rules []CacheRule `json:"rules"`
+	// DeviceHeader is the request header carrying the device type. Defaults to x-platform-id.
+	DeviceHeader string `json:"device_header" env:"CACHE_POLICY_DEVICE_HEADER" default:"x-platform-id"`
+	// Variants declare the device variants of the responses (e.g. platform and client version ranges): responses
+	// with a policy list their headers in Vary and carry the bounded variant in X-Variant.
+	Variants variants.Rules `json:"variants"`
+}
+
+// CachePolicyMiddleware sets Cache-Control (and CDN headers) from configured rules, instead of hard-coding
+// SetCacheControlMiddleware on every route.
+//
+// Parameters:
+//
+//	cfg: Policy rules, usually loaded with config.Load.
+//
+// Behavior:
+//   - The most specific matching rule wins: a rule with Tenant and Device beats one with only one of them,
+//     which beats a route-only rule. Ties are resolved by declaration order.
+//   - Rules match both the route pattern registered in Fiber and the request path.
+//   - Only responses with status < 400 get the policy; a Cache-Control set by the handler is kept.
+//   - Rules failing CacheControl.Validate (e.g. s_maxage on a private rule) are ignored.
+//   - With Variants, responses with a policy get Vary with the variant headers and the X-Variant header, e.g.
+//     "x-platform-id=ios;x-client-version=>=4.2", so caches keep mobile and web variants apart. CDNs should key
+//     on the same buckets (or on X-Variant) rather than on the raw version header.
+//
+// Usage:
+//
+//	var policy server.CachePolicyConfig
+//	_ = config.Load(&policy, config.WithFile("cache-policy.yaml"))
+//	app.Use(server.CachePolicyMiddleware(policy))
+//
+//	# cache-policy.yaml
+//	rules:
+//	  - route: /api/products/**
+//	    type: public
+//	    max_age: 60s
+//	    s_maxage: 5m
+//	    stale_while_revalidate: 30s
+//	  - route: /api/products/**
+//	    tenant: acme
+//	    type: private
+//	    max_age: 10s
+func CachePolicyMiddleware(cfg CachePolicyConfig) fiber.Handler {
+	if cfg.DeviceHeader == "" {
+		cfg.DeviceHeader = "x-platform-id"
+	}
+
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		if c.Response().StatusCode() >= 400 || len(c.Response().Header.Peek(fiber.HeaderCacheControl)) > 0 {
+			return nil
+		}
+
+		tenant := ""
+		if t, ok := TenantFromContext(c.UserContext()); ok {
+			tenant = t.ID
+		}
+
+		rule, ok := matchCacheRule(cfg.Rules, c.Route().Path, c.Path(), tenant, c.Get(cfg.DeviceHeader))
+		if !ok {
+			return nil
+		}
+
+		cc := rule.cacheControl()
+		if cc.Validate() != nil {
+			return nil
+		}
+
+		cc.apply(c)
+		if len(cfg.Variants) > 0 && rule.Type != CacheNoStore {
+			c.Vary(cfg.Variants.Headers()...)
+			c.Set("X-Variant", requestVariant(c, cfg.Variants))
+		}
+
+		return nil
+	}
+}
+
+func matchCacheRule(rules []CacheRule, routePath, requestPath, tenant, device string) (CacheRule, bool) {
+	best, bestScore := CacheRule{}, -1
+
+	for _, rule := range rules {
+		if !matchRoute(rule.Route, routePath) && !matchRoute(rule.Route, requestPath) {
+			continue
+		}
+
+		score := 0
+		if rule.Tenant != "" {
+			if rule.Tenant != tenant {
+				continue
+			}
+			score += 2
+		}
+		if rule.Device != "" {
+			if !strings.EqualFold(rule.Device, device) {
+				continue
+			}
+			score++
+		}
+
+		if score > bestScore {
+			best, bestScore = rule, score
+		}
+	}
+
+	return best, bestScore >= 0
+}
+
+// requestVariant returns the variant of the request, reading the headers from the request or, when the
+// ForwardHeadersMiddleware stored them, from the forwarded headers.
+func requestVariant(c *fiber.Ctx, rules variants.Rules) string {
+	forwarded, _ := c.UserContext().Value("forwardedHeaders").(map[string]string)
+
+	return rules.Variant(func(header string) string {
+		if value := c.Get(header); value != "" {
+			return value
+		}
+		return forwarded[strings.ToLower(header)]
+	})
+}
+
+func matchRoute(pattern, value string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return value == prefix || strings.HasPrefix(value, prefix+"/")
+	}
+
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+func (r CacheRule) cacheControl() CacheControl {
+	return CacheControl{
+		Type:                 r.Type,
+		MaxAge:               r.MaxAge,
+		SMaxAge:              r.SMaxAge,
+		StaleWhileRevalidate: r.StaleWhileRevalidate,
+		StaleIfError:         r.StaleIfError,
+		Immutable:            r.Immutable,
+		SurrogateMaxAge:      r.SurrogateMaxAge,
+		SurrogateKeys:        r.SurrogateKeys,
+	}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(d.Seconds()))
+}
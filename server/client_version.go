@@ -0,0 +1,164 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/devluispereira/go-package/variants"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CodeUpgradeRequired is the error code of the requests rejected by ClientVersionMiddleware.
+var CodeUpgradeRequired = ErrorCode{Name: "upgrade_required", Status: http.StatusUpgradeRequired}
+
+// ClientVersionRule declares the minimum client version of each platform for the routes matching Route.
+type ClientVersionRule struct {
+	// Route is a path pattern: "*" matches one segment and a trailing "/**" matches any suffix, e.g. "/api/checkout/**".
+	Route string `json:"route"`
+	// MinVersions maps platforms to their minimum version, e.g. {"ios": "4.2", "android": "4.0"}. The "*" entry
+	// applies to the platforms not listed; platforms without an entry are not gated.
+	MinVersions map[string]string `json:"min_versions"`
+	// RedirectURL redirects outdated clients (302) instead of answering 426, e.g. to an upgrade page.
+	RedirectURL string `json:"redirect_url"`
+}
+
+// ClientVersionConfig holds the client version rules. It can be loaded with the config package from a JSON/YAML
+// file.
+type ClientVersionConfig struct {
+	Rules []ClientVersionRule `json:"rules"`
+	// PlatformHeader is the request header carrying the platform. Defaults to x-platform-id.
+	PlatformHeader string `json:"platform_header" env:"CLIENT_VERSION_PLATFORM_HEADER" default:"x-platform-id"`
+	// VersionHeader is the request header carrying the client version. Defaults to x-client-version.
+	VersionHeader string `json:"version_header" env:"CLIENT_VERSION_HEADER" default:"x-client-version"`
+	// UpgradeURLs maps platforms to the URL where the client is upgraded (e.g. the store page), sent in the
+	// upgrade-required response.
+	UpgradeURLs map[string]string `json:"upgrade_urls"`
+	// RejectUnknown rejects the requests of gated platforms without a valid version. By default they pass.
+	RejectUnknown bool `json:"reject_unknown" env:"CLIENT_VERSION_REJECT_UNKNOWN"`
+}
+
+// UpgradeRequiredBody is the problem+json body of the requests rejected by ClientVersionMiddleware.
+type UpgradeRequiredBody struct {
+	ErrorBody
+	Platform   string `json:"platform"`
+	Version    string `json:"version,omitempty"`
+	MinVersion string `json:"min_version"`
+	UpgradeURL string `json:"upgrade_url,omitempty"`
+}
+
+// ClientVersionMiddleware blocks or redirects outdated app versions per route, from a minimum version matrix
+// declared in configuration, so a breaking API change can force mobile clients to upgrade.
+//
+// Parameters:
+//
+//	cfg: Client version rules, usually loaded with config.Load.
+//
+// Behavior:
+//   - The platform and version are read from PlatformHeader and VersionHeader (see variants.ParseVersion for the
+//     accepted formats); platforms are matched case-insensitively.
+//   - The first rule matching the request path wins. Requests of platforms without a minimum version pass.
+//   - Outdated clients are redirected to the rule RedirectURL or rejected with 426 and an UpgradeRequiredBody
+//     carrying the platform, versions, the UpgradeURLs entry of the platform and the request ID.
+//   - Requests without a valid version pass, unless RejectUnknown is set. Invalid minimum versions are ignored.
+//
+// Usage:
+//
+//	var versions server.ClientVersionConfig
+//	_ = config.Load(&versions, config.WithFile("client-versions.yaml"))
+//	app.Use(server.ClientVersionMiddleware(versions))
+//
+//	# client-versions.yaml
+//	upgrade_urls:
+//	  ios: https://apps.apple.com/app/id000000000
+//	  android: https://play.google.com/store/apps/details?id=com.example
+//	rules:
+//	  - route: /api/checkout/**
+//	    min_versions: {ios: "4.2", android: "4.0"}
+//	  - route: /api/**
+//	    min_versions: {"*": "3.0"}
+func ClientVersionMiddleware(cfg ClientVersionConfig) fiber.Handler {
+	if cfg.PlatformHeader == "" {
+		cfg.PlatformHeader = "x-platform-id"
+	}
+	if cfg.VersionHeader == "" {
+		cfg.VersionHeader = "x-client-version"
+	}
+
+	rules := make([]clientVersionRule, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		rules[i] = clientVersionRule{ClientVersionRule: rule, min: make(map[string]variants.Version, len(rule.MinVersions))}
+		for platform, raw := range rule.MinVersions {
+			if version, err := variants.ParseVersion(raw); err == nil {
+				rules[i].min[strings.ToLower(platform)] = version
+			}
+		}
+	}
+
+	upgradeURLs := make(map[string]string, len(cfg.UpgradeURLs))
+	for platform, url := range cfg.UpgradeURLs {
+		upgradeURLs[strings.ToLower(platform)] = url
+	}
+
+	return func(c *fiber.Ctx) error {
+		rule, ok := matchClientVersionRule(rules, c.Path())
+		if !ok {
+			return c.Next()
+		}
+
+		platform := strings.ToLower(strings.TrimSpace(c.Get(cfg.PlatformHeader)))
+		minVersion, ok := rule.min[platform]
+		if !ok {
+			minVersion, ok = rule.min["*"]
+		}
+		if !ok {
+			return c.Next()
+		}
+
+		raw := c.Get(cfg.VersionHeader)
+		version, err := variants.ParseVersion(raw)
+		switch {
+		case err != nil && !cfg.RejectUnknown:
+			return c.Next()
+		case err == nil && version.Compare(minVersion) >= 0:
+			return c.Next()
+		}
+
+		if rule.RedirectURL != "" {
+			return c.Redirect(rule.RedirectURL, fiber.StatusFound)
+		}
+
+		requestID := RequestID(c)
+		c.Set("X-Request-Id", requestID)
+		c.Status(CodeUpgradeRequired.Status)
+		return c.JSON(UpgradeRequiredBody{
+			ErrorBody: ErrorBody{
+				Type:      "about:blank",
+				Title:     http.StatusText(CodeUpgradeRequired.Status),
+				Status:    CodeUpgradeRequired.Status,
+				Detail:    "client version " + minVersion.String() + " or later is required",
+				Code:      CodeUpgradeRequired.Name,
+				RequestID: requestID,
+			},
+			Platform:   platform,
+			Version:    raw,
+			MinVersion: minVersion.String(),
+			UpgradeURL: upgradeURLs[platform],
+		}, mimeProblemJSON)
+	}
+}
+
+// clientVersionRule is a ClientVersionRule with its minimum versions parsed and keyed by lowercase platform.
+type clientVersionRule struct {
+	ClientVersionRule
+	min map[string]variants.Version
+}
+
+func matchClientVersionRule(rules []clientVersionRule, requestPath string) (clientVersionRule, bool) {
+	for _, rule := range rules {
+		if matchRoute(rule.Route, requestPath) {
+			return rule, true
+		}
+	}
+
+	return clientVersionRule{}, false
+}
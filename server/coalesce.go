@@ -0,0 +1,169 @@
+package server
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/devluispereira/go-package/variants"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/sync/singleflight"
+)
+
+// CoalesceConfig holds the configuration for the request coalescing middleware.
+type CoalesceConfig struct {
+	// VaryHeaders are request headers that change the response, added to the coalescing key (e.g. x-tenant-id).
+	VaryHeaders []string
+	// Variants add the device variant of the request to the coalescing key, so requests of the same platform and
+	// client version range are coalesced together.
+	Variants variants.Rules
+	// KeyByCredentials coalesces requests carrying Authorization or Cookie headers, adding those headers to the
+	// key so only requests of the same credentials share a response. By default such requests are not coalesced.
+	KeyByCredentials bool
+}
+
+// credentialHeaders identify the user of a request; responses to them may be personal.
+var credentialHeaders = []string{fiber.HeaderAuthorization, fiber.HeaderCookie}
+
+// perRequestHeaders are response headers describing a single request, never copied from the leader to waiters.
+var perRequestHeaders = []string{fiber.HeaderSetCookie, fiber.HeaderXRequestID, fiber.HeaderDate, fiber.HeaderContentLength}
+
+type coalescedResponse struct {
+	status  int
+	headers [][2]string
+	body    []byte
+	// private responses (Set-Cookie, Cache-Control private or no-store) are not shared: waiters run the handler.
+	private bool
+}
+
+// CoalesceMiddleware collapses concurrent identical GET requests into a single handler execution,
+// sharing the response among the waiting requests (singleflight).
+//
+// Parameters:
+//
+//	cfg: Coalescing configuration.
+//
+// Behavior:
+//   - Requests are identical when method, path, sorted query, VaryHeaders and the Variants variant match.
+//   - Only GET and HEAD requests are coalesced; other methods pass through.
+//   - Requests with Authorization or Cookie headers pass through, unless KeyByCredentials adds them to the key.
+//   - Waiters receive the leader's status, body and the headers set by the handler (not those set by outer
+//     middlewares, which ran for each waiter), with the X-Coalesced: true header. Set-Cookie, X-Request-Id, Date
+//     and Content-Length are never copied, and responses with Set-Cookie or Cache-Control private/no-store are not
+//     shared: waiters run the handler themselves.
+//   - When the leader's handler returns an error, every waiter returns the same error.
+//
+// Usage:
+//
+//	app.Get("/home", CoalesceMiddleware(CoalesceConfig{VaryHeaders: []string{"x-tenant-id"}}), expensiveHandler)
+func CoalesceMiddleware(cfg CoalesceConfig) fiber.Handler {
+	var group singleflight.Group
+
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return c.Next()
+		}
+		if !cfg.KeyByCredentials && hasCredentials(c) {
+			return c.Next()
+		}
+
+		leader := false
+		result, err, shared := group.Do(coalesceKey(c, cfg), func() (any, error) {
+			leader = true
+			outer := make(map[[2]string]bool)
+			c.Response().Header.VisitAll(func(key, value []byte) {
+				outer[[2]string{string(key), string(value)}] = true
+			})
+
+			if err := c.Next(); err != nil {
+				return nil, err
+			}
+
+			resp := &coalescedResponse{
+				status: c.Response().StatusCode(),
+				body:   append([]byte(nil), c.Response().Body()...),
+			}
+			c.Response().Header.VisitAll(func(key, value []byte) {
+				header := [2]string{string(key), string(value)}
+				if !outer[header] && !isPerRequestHeader(header[0]) {
+					resp.headers = append(resp.headers, header)
+				}
+			})
+			resp.private = isPrivateResponse(c)
+
+			return resp, nil
+		})
+
+		if leader || err != nil {
+			return err
+		}
+
+		resp := result.(*coalescedResponse)
+		if resp.private {
+			return c.Next()
+		}
+		copied := make(map[string]bool, len(resp.headers))
+		for _, header := range resp.headers {
+			if copied[header[0]] {
+				c.Response().Header.Add(header[0], header[1])
+				continue
+			}
+			copied[header[0]] = true
+			c.Response().Header.Set(header[0], header[1])
+		}
+		if shared {
+			c.Set("X-Coalesced", "true")
+		}
+
+		return c.Status(resp.status).Send(resp.body)
+	}
+}
+
+func coalesceKey(c *fiber.Ctx, cfg CoalesceConfig) string {
+	var query []string
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		query = append(query, string(key)+"="+string(value))
+	})
+	sort.Strings(query)
+
+	parts := []string{c.Method(), c.Path(), strings.Join(query, "&")}
+	for _, header := range cfg.VaryHeaders {
+		parts = append(parts, header+":"+c.Get(header))
+	}
+	if len(cfg.Variants) > 0 {
+		parts = append(parts, requestVariant(c, cfg.Variants))
+	}
+	if cfg.KeyByCredentials {
+		for _, header := range credentialHeaders {
+			parts = append(parts, header+":"+c.Get(header))
+		}
+	}
+
+	return strings.Join(parts, "|")
+}
+
+func isPerRequestHeader(name string) bool {
+	for _, header := range perRequestHeaders {
+		if strings.EqualFold(name, header) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasCredentials(c *fiber.Ctx) bool {
+	for _, header := range credentialHeaders {
+		if c.Get(header) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrivateResponse reports whether the response is personal to the requester.
+func isPrivateResponse(c *fiber.Ctx) bool {
+	if len(c.Response().Header.Peek(fiber.HeaderSetCookie)) > 0 {
+		return true
+	}
+	cacheControl := strings.ToLower(string(c.Response().Header.Peek(fiber.HeaderCacheControl)))
+	return strings.Contains(cacheControl, "private") || strings.Contains(cacheControl, "no-store")
+}
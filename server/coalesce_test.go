@@ -0,0 +1,168 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// runConcurrently sends n copies of the request built by newReq at once, returning the responses.
+func runConcurrently(t *testing.T, app *fiber.App, n int, newReq func(i int) *http.Request) []*http.Response {
+	t.Helper()
+
+	responses := make([]*http.Response, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := app.Test(newReq(i), -1)
+			if err != nil {
+				t.Errorf("request %d failed: %v", i, err)
+				return
+			}
+			responses[i] = resp
+		}()
+	}
+	wg.Wait()
+	return responses
+}
+
+func TestCoalesceSharesAnonymousResponses(t *testing.T) {
+	var calls atomic.Int32
+	app := fiber.New()
+	app.Get("/home", CoalesceMiddleware(CoalesceConfig{}), func(c *fiber.Ctx) error {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return c.SendString("home")
+	})
+
+	responses := runConcurrently(t, app, 5, func(int) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/home", nil)
+	})
+	for _, resp := range responses {
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != "home" {
+			t.Errorf("body %q, want home", body)
+		}
+	}
+	if n := calls.Load(); n >= 5 {
+		t.Errorf("handler ran %d times, want the requests coalesced", n)
+	}
+}
+
+func TestCoalesceDoesNotShareCredentialedRequests(t *testing.T) {
+	var calls atomic.Int32
+	app := fiber.New()
+	app.Get("/me", CoalesceMiddleware(CoalesceConfig{}), func(c *fiber.Ctx) error {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return c.SendString(c.Get(fiber.HeaderAuthorization))
+	})
+
+	responses := runConcurrently(t, app, 4, func(i int) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer user-"+string(rune('a'+i)))
+		return req
+	})
+	for i, resp := range responses {
+		body, _ := io.ReadAll(resp.Body)
+		if want := "Bearer user-" + string(rune('a'+i)); string(body) != want {
+			t.Errorf("request %d got %q, want %q", i, body, want)
+		}
+	}
+	if n := calls.Load(); n != 4 {
+		t.Errorf("handler ran %d times, want 4", n)
+	}
+}
+
+func TestCoalesceKeyByCredentialsSeparatesUsers(t *testing.T) {
+	app := fiber.New()
+	app.Get("/me", CoalesceMiddleware(CoalesceConfig{KeyByCredentials: true}), func(c *fiber.Ctx) error {
+		time.Sleep(50 * time.Millisecond)
+		return c.SendString(c.Get(fiber.HeaderCookie))
+	})
+
+	responses := runConcurrently(t, app, 4, func(i int) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(fiber.HeaderCookie, "session="+string(rune('a'+i%2)))
+		return req
+	})
+	for i, resp := range responses {
+		body, _ := io.ReadAll(resp.Body)
+		if want := "session=" + string(rune('a'+i%2)); string(body) != want {
+			t.Errorf("request %d got %q, want %q", i, body, want)
+		}
+	}
+}
+
+func TestCoalesceNeverSharesSetCookie(t *testing.T) {
+	var calls atomic.Int32
+	app := fiber.New()
+	app.Get("/home", CoalesceMiddleware(CoalesceConfig{}), func(c *fiber.Ctx) error {
+		n := calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		c.Cookie(&fiber.Cookie{Name: "session", Value: string(rune('0' + n))})
+		return c.SendString("home")
+	})
+
+	responses := runConcurrently(t, app, 5, func(int) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/home", nil)
+	})
+
+	seen := map[string]bool{}
+	for _, resp := range responses {
+		for _, cookie := range resp.Header.Values(fiber.HeaderSetCookie) {
+			if seen[cookie] {
+				t.Errorf("Set-Cookie %q sent to more than one request", cookie)
+			}
+			seen[cookie] = true
+		}
+		if resp.Header.Get("X-Coalesced") != "" {
+			t.Errorf("private response shared with a waiter")
+		}
+	}
+}
+
+func TestCoalesceWaitersKeepTheirOwnHeaders(t *testing.T) {
+	var calls atomic.Int32
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Set("X-Origin-App", "my-app")
+		c.Set(fiber.HeaderXRequestID, c.Get(fiber.HeaderXRequestID))
+		return c.Next()
+	})
+	app.Get("/home", CoalesceMiddleware(CoalesceConfig{}), func(c *fiber.Ctx) error {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		c.Set("X-Handler", "home")
+		return c.SendString("home")
+	})
+
+	responses := runConcurrently(t, app, 5, func(i int) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/home", nil)
+		req.Header.Set(fiber.HeaderXRequestID, "req-"+string(rune('a'+i)))
+		return req
+	})
+	for i, resp := range responses {
+		if want := "req-" + string(rune('a'+i)); len(resp.Header.Values(fiber.HeaderXRequestID)) != 1 ||
+			resp.Header.Get(fiber.HeaderXRequestID) != want {
+			t.Errorf("request %d got X-Request-Id %v, want %s", i, resp.Header.Values(fiber.HeaderXRequestID), want)
+		}
+		if values := resp.Header.Values("X-Origin-App"); len(values) != 1 {
+			t.Errorf("request %d got X-Origin-App %v, want one value", i, values)
+		}
+		if resp.Header.Get("X-Handler") != "home" {
+			t.Errorf("request %d got no X-Handler header", i)
+		}
+	}
+	if n := calls.Load(); n >= 5 {
+		t.Errorf("handler ran %d times, want the requests coalesced", n)
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestMatcher reports whether a request matches a condition.
+type RequestMatcher func(c *fiber.Ctx) bool
+
+// PathPrefix matches requests whose path starts with any of the prefixes.
+func PathPrefix(prefixes ...string) RequestMatcher {
+	return func(c *fiber.Ctx) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(c.Path(), prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// PathIs matches requests whose path is exactly one of the paths.
+func PathIs(paths ...string) RequestMatcher {
+	return func(c *fiber.Ctx) bool {
+		for _, p := range paths {
+			if c.Path() == p {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// When runs handler only for requests matching match; other requests skip to the next handler.
+//
+// Usage:
+//
+//	app.Use(When(PathPrefix("/api"), TenantMiddleware(tenantCfg)))
+func When(match RequestMatcher, handler fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if match(c) {
+			return handler(c)
+		}
+		return c.Next()
+	}
+}
+
+// Unless runs handler for every request except the ones matching match.
+//
+// Usage:
+//
+//	app.Use(Unless(PathIs("/healthcheck", "/readyz", "/metrics"), MetricsMiddleware()))
+func Unless(match RequestMatcher, handler fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if match(c) {
+			return c.Next()
+		}
+		return handler(c)
+	}
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devluispereira/go-package/clock"
+	"github.com/devluispereira/go-package/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	// ErrCookieNotFound is returned by SecureCookies.Get when the request has no such cookie.
+	ErrCookieNotFound = errors.New("cookie not found")
+	// ErrInvalidCookie is returned by SecureCookies.Get when the cookie was tampered with or signed with an
+	// unknown key.
+	ErrInvalidCookie = errors.New("invalid cookie")
+	// ErrCookieExpired is returned by SecureCookies.Get when the expiry embedded in the cookie has passed.
+	ErrCookieExpired = errors.New("cookie expired")
+)
+
+// defaultSessionCookieMaxAge is the server-side lifetime of session cookies when
+// SecureCookieConfig.SessionMaxAge is not set.
+const defaultSessionCookieMaxAge = 24 * time.Hour
+
+// CookieKeyProvider supplies the keys of SecureCookies. The first key signs or encrypts new cookies; every key
+// is tried when verifying, so keys can be rotated without invalidating the cookies already issued. Implement it
+// on top of the secrets provider to rotate keys at runtime.
+type CookieKeyProvider interface {
+	CookieKeys() [][]byte
+}
+
+// StaticCookieKeys is a CookieKeyProvider with fixed keys, current key first.
+type StaticCookieKeys [][]byte
+
+func (k StaticCookieKeys) CookieKeys() [][]byte {
+	return k
+}
+
+// SecureCookieConfig holds the configuration for SecureCookies.
+type SecureCookieConfig struct {
+	Keys CookieKeyProvider
+	// Encrypt seals the values with AES-GCM instead of signing them with HMAC-SHA256, hiding them from the client.
+	Encrypt bool
+	// Path and Domain scope the cookies. Path defaults to "/".
+	Path   string
+	Domain string
+	// SameSite is "Lax" (default), "Strict" or "None".
+	SameSite string
+	// Insecure allows the cookies over plain HTTP (local development only).
+	Insecure bool
+	// ScriptAccess removes HttpOnly, exposing the cookies to JavaScript.
+	ScriptAccess bool
+	// SessionMaxAge is how long the server accepts session cookies (set with a maxAge of 0), which the browser
+	// keeps until it is closed. Defaults to 24h.
+	SessionMaxAge time.Duration
+	// Clock timestamps and checks the expiry embedded in the cookies. Defaults to clock.Real.
+	Clock clock.Clock
+}
+
+// SecureCookies sets and verifies signed or encrypted cookies.
+type SecureCookies struct {
+	cfg SecureCookieConfig
+}
+
+// NewSecureCookies creates signed (HMAC-SHA256) or encrypted (AES-GCM) cookie helpers.
+//
+// Parameters:
+//
+//	cfg: Cookie configuration.
+//	  - Keys: Key provider, current key first. Keys should have at least 32 bytes.
+//	  - Encrypt: Seal the values with AES-GCM instead of signing them.
+//
+// Behavior:
+//   - Cookies are Secure, HttpOnly and SameSite=Lax unless configured otherwise.
+//   - The cookie name is authenticated with the value, so a valid cookie can't be replayed under another name.
+//   - The expiry is authenticated with the value too, and checked by Get, so a captured cookie is rejected once
+//     its maxAge (or SessionMaxAge, for session cookies) has passed, even if the client kept it.
+//   - Values signed or encrypted with any key of the provider are accepted, allowing key rotation.
+//   - Tampered cookies are logged as a security event ("event": "security.cookie_invalid").
+//
+// Usage:
+//
+//	cookies := server.NewSecureCookies(server.SecureCookieConfig{Keys: server.StaticCookieKeys{current, previous}})
+//
+//	// In handlers:
+//	_ = cookies.Set(c, "prefs", `{"theme":"dark"}`, 30*24*time.Hour)
+//	prefs, err := cookies.Get(c, "prefs")
+func NewSecureCookies(cfg SecureCookieConfig) *SecureCookies {
+	if cfg.Path == "" {
+		cfg.Path = "/"
+	}
+	if cfg.SameSite == "" {
+		cfg.SameSite = fiber.CookieSameSiteLaxMode
+	}
+	if cfg.SessionMaxAge <= 0 {
+		cfg.SessionMaxAge = defaultSessionCookieMaxAge
+	}
+	cfg.Clock = clock.Or(cfg.Clock)
+
+	return &SecureCookies{cfg: cfg}
+}
+
+// Set signs or encrypts value with the current key and sets the cookie. A maxAge of 0 creates a session cookie.
+func (s *SecureCookies) Set(c *fiber.Ctx, name, value string, maxAge time.Duration) error {
+	keys := s.cfg.Keys.CookieKeys()
+	if len(keys) == 0 {
+		return errors.New("no cookie keys configured")
+	}
+
+	lifetime := maxAge
+	if lifetime <= 0 {
+		lifetime = s.cfg.SessionMaxAge
+	}
+	expires := s.cfg.Clock.Now().Add(lifetime).Unix()
+
+	encoded, err := s.encode(keys[0], name, strconv.FormatInt(expires, 10)+"|"+value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cookie %q: %w", name, err)
+	}
+
+	c.Cookie(s.cookie(name, encoded, int(maxAge.Seconds())))
+	return nil
+}
+
+// Get returns the verified value of the cookie. It returns ErrCookieNotFound when the cookie is missing,
+// ErrInvalidCookie when it fails verification with every key and ErrCookieExpired when its expiry has passed.
+func (s *SecureCookies) Get(c *fiber.Ctx, name string) (string, error) {
+	raw := c.Cookies(name)
+	if raw == "" {
+		return "", ErrCookieNotFound
+	}
+
+	for _, key := range s.cfg.Keys.CookieKeys() {
+		if decoded, err := s.decode(key, name, raw); err == nil {
+			return s.unwrapExpiry(decoded)
+		}
+	}
+
+	log := logging.FromContext(c.UserContext())
+	log.Warn().
+		Str("event", "security.cookie_invalid").
+		Str("cookie", name).
+		Str("ip", c.IP()).
+		Msg("rejected invalid cookie")
+
+	return "", ErrInvalidCookie
+}
+
+// Clear expires the cookie.
+func (s *SecureCookies) Clear(c *fiber.Ctx, name string) {
+	c.Cookie(s.cookie(name, "", -1))
+}
+
+// unwrapExpiry returns the value of a decoded cookie, "<expires unix>|<value>", checking its expiry.
+func (s *SecureCookies) unwrapExpiry(decoded string) (string, error) {
+	rawExpires, value, ok := strings.Cut(decoded, "|")
+	if !ok {
+		return "", ErrInvalidCookie
+	}
+
+	expires, err := strconv.ParseInt(rawExpires, 10, 64)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	if s.cfg.Clock.Now().Unix() >= expires {
+		return "", ErrCookieExpired
+	}
+	return value, nil
+}
+
+func (s *SecureCookies) cookie(name, value string, maxAge int) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     s.cfg.Path,
+		Domain:   s.cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   !s.cfg.Insecure,
+		HTTPOnly: !s.cfg.ScriptAccess,
+		SameSite: s.cfg.SameSite,
+	}
+}
+
+func (s *SecureCookies) encode(key []byte, name, value string) (string, error) {
+	if !s.cfg.Encrypt {
+		payload := base64.RawURLEncoding.EncodeToString([]byte(value))
+		return payload + "." + base64.RawURLEncoding.EncodeToString(cookieMAC(key, name, payload)), nil
+	}
+
+	aead, err := cookieAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(value), []byte(name))), nil
+}
+
+func (s *SecureCookies) decode(key []byte, name, raw string) (string, error) {
+	if !s.cfg.Encrypt {
+		payload, signature, ok := strings.Cut(raw, ".")
+		if !ok {
+			return "", ErrInvalidCookie
+		}
+
+		mac, err := base64.RawURLEncoding.DecodeString(signature)
+		if err != nil || !hmac.Equal(mac, cookieMAC(key, name, payload)) {
+			return "", ErrInvalidCookie
+		}
+
+		value, err := base64.RawURLEncoding.DecodeString(payload)
+		if err != nil {
+			return "", ErrInvalidCookie
+		}
+		return string(value), nil
+	}
+
+	aead, err := cookieAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrInvalidCookie
+	}
+
+	value, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name))
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	return string(value), nil
+}
+
+func cookieMAC(key []byte, name, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name + "|" + payload))
+	return mac.Sum(nil)
+}
+
+// cookieAEAD derives an AES-256-GCM cipher from key, so keys of any length can be used.
+func cookieAEAD(key []byte) (cipher.AEAD, error) {
+	derived := sha256.Sum256(key)
+	block, err := aes.NewCipher(derived[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
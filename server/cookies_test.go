@@ -0,0 +1,167 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devluispereira/go-package/clock/clocktest"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	currentCookieKey  = []byte("0123456789abcdef0123456789abcdef")
+	previousCookieKey = []byte("fedcba9876543210fedcba9876543210")
+)
+
+// newCookieApp serves GET /set/:name, setting the cookie to "value" for maxAge, and GET /get/:name, answering
+// with its value or the error.
+func newCookieApp(cookies *SecureCookies, maxAge time.Duration) *fiber.App {
+	app := fiber.New()
+	app.Get("/set/:name", func(c *fiber.Ctx) error {
+		return cookies.Set(c, c.Params("name"), "value", maxAge)
+	})
+	app.Get("/get/:name", func(c *fiber.Ctx) error {
+		value, err := cookies.Get(c, c.Params("name"))
+		if err != nil {
+			return c.SendString(err.Error())
+		}
+		return c.SendString(value)
+	})
+	return app
+}
+
+func setCookie(t *testing.T, app *fiber.App, name string) *http.Cookie {
+	t.Helper()
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/set/"+name, nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	t.Fatalf("cookie %q not set", name)
+	return nil
+}
+
+func getCookie(t *testing.T, app *fiber.App, name string, cookie *http.Cookie) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/get/"+name, nil)
+	req.AddCookie(cookie)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestSecureCookiesRoundTrip(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		cookies := NewSecureCookies(SecureCookieConfig{Keys: StaticCookieKeys{currentCookieKey}, Encrypt: encrypt})
+		app := newCookieApp(cookies, time.Hour)
+
+		cookie := setCookie(t, app, "prefs")
+		if !cookie.Secure || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+			t.Errorf("encrypt=%v: cookie %+v is not Secure, HttpOnly and SameSite=Lax", encrypt, cookie)
+		}
+		if got := getCookie(t, app, "prefs", cookie); got != "value" {
+			t.Errorf("encrypt=%v: got %q, want value", encrypt, got)
+		}
+	}
+}
+
+func TestSecureCookiesRejectExpiredValues(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		clk := clocktest.NewFake(time.Now())
+		cookies := NewSecureCookies(SecureCookieConfig{
+			Keys:    StaticCookieKeys{currentCookieKey},
+			Encrypt: encrypt,
+			Clock:   clk,
+		})
+		app := newCookieApp(cookies, time.Hour)
+
+		// A captured cookie replayed after its maxAge, although the client was told to drop it.
+		cookie := setCookie(t, app, "prefs")
+		clk.Advance(time.Hour + time.Second)
+		if got := getCookie(t, app, "prefs", cookie); got != ErrCookieExpired.Error() {
+			t.Errorf("encrypt=%v: got %q, want %v", encrypt, got, ErrCookieExpired)
+		}
+	}
+}
+
+func TestSecureCookiesBoundSessionCookies(t *testing.T) {
+	clk := clocktest.NewFake(time.Now())
+	cookies := NewSecureCookies(SecureCookieConfig{
+		Keys:          StaticCookieKeys{currentCookieKey},
+		SessionMaxAge: time.Hour,
+		Clock:         clk,
+	})
+	app := newCookieApp(cookies, 0)
+
+	cookie := setCookie(t, app, "session")
+	if got := getCookie(t, app, "session", cookie); got != "value" {
+		t.Fatalf("got %q, want value", got)
+	}
+	clk.Advance(2 * time.Hour)
+	if got := getCookie(t, app, "session", cookie); got != ErrCookieExpired.Error() {
+		t.Fatalf("got %q, want %v", got, ErrCookieExpired)
+	}
+}
+
+func TestSecureCookiesRejectTampering(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		cookies := NewSecureCookies(SecureCookieConfig{Keys: StaticCookieKeys{currentCookieKey}, Encrypt: encrypt})
+		app := newCookieApp(cookies, time.Hour)
+		cookie := setCookie(t, app, "prefs")
+
+		// Replayed under another name.
+		renamed := &http.Cookie{Name: "admin", Value: cookie.Value}
+		if got := getCookie(t, app, "admin", renamed); got != ErrInvalidCookie.Error() {
+			t.Errorf("encrypt=%v: renamed cookie got %q, want %v", encrypt, got, ErrInvalidCookie)
+		}
+
+		first := "A"
+		if strings.HasPrefix(cookie.Value, first) {
+			first = "B"
+		}
+		tampered := &http.Cookie{Name: "prefs", Value: first + cookie.Value[1:]}
+		if got := getCookie(t, app, "prefs", tampered); got != ErrInvalidCookie.Error() {
+			t.Errorf("encrypt=%v: tampered cookie got %q, want %v", encrypt, got, ErrInvalidCookie)
+		}
+	}
+}
+
+func TestSecureCookiesAcceptRotatedKeys(t *testing.T) {
+	old := newCookieApp(NewSecureCookies(SecureCookieConfig{Keys: StaticCookieKeys{previousCookieKey}}), time.Hour)
+	cookie := setCookie(t, old, "prefs")
+
+	rotated := newCookieApp(NewSecureCookies(SecureCookieConfig{
+		Keys: StaticCookieKeys{currentCookieKey, previousCookieKey},
+	}), time.Hour)
+	if got := getCookie(t, rotated, "prefs", cookie); got != "value" {
+		t.Fatalf("got %q, want value", got)
+	}
+
+	dropped := newCookieApp(NewSecureCookies(SecureCookieConfig{Keys: StaticCookieKeys{currentCookieKey}}), time.Hour)
+	if got := getCookie(t, dropped, "prefs", cookie); got != ErrInvalidCookie.Error() {
+		t.Fatalf("got %q, want %v", got, ErrInvalidCookie)
+	}
+}
+
+func TestUnwrapExpiryRejectsMalformedValues(t *testing.T) {
+	cookies := NewSecureCookies(SecureCookieConfig{Keys: StaticCookieKeys{currentCookieKey}})
+	for _, decoded := range []string{"value", "soon|value"} {
+		if _, err := cookies.unwrapExpiry(decoded); !errors.Is(err, ErrInvalidCookie) {
+			t.Errorf("%q: got %v, want ErrInvalidCookie", decoded, err)
+		}
+	}
+}
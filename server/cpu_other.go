@@ -0,0 +1,10 @@
+//go:build !unix
+
+package server
+
+import "time"
+
+// processCPUTime is not supported on this platform.
+func processCPUTime() time.Duration {
+	return 0
+}
@@ -1,48 +1,150 @@
 package server
 
 import (
+	"time"
+
+	"github.com/devluispereira/go-package/buildinfo"
 	"github.com/gofiber/fiber/v2"
 )
 
 type Server struct {
 	App *fiber.App
+	// HookTimeout is the maximum time each OnStart/OnStop hook may take. Defaults to 15 seconds.
+	HookTimeout time.Duration
+	// ShutdownTimeout is the maximum time Listen waits for in-flight requests on shutdown; the stop hooks that
+	// follow are bounded by HookTimeout each. Defaults to 30 seconds.
+	ShutdownTimeout time.Duration
+
+	startHooks []Hook
+	stopHooks  []stopHook
+	metrics    MetricsConfig
+}
+
+// ServerConfig holds the server configuration. It can be loaded with the config package.
+type ServerConfig struct {
+	Name           string   `json:"name" env:"APP_NAME"`
+	ForwardHeaders []string `json:"forward_headers" env:"FORWARD_HEADERS"`
+	// StripTraceHeaders stops forwarding traceparent, tracestate and baggage, for services exposed to clients
+	// outside the trust boundary, which could otherwise join or poison internal traces.
+	StripTraceHeaders bool          `json:"strip_trace_headers" env:"STRIP_TRACE_HEADERS"`
+	HookTimeout       time.Duration `json:"hook_timeout" env:"HOOK_TIMEOUT" default:"15s"`
+	// ShutdownTimeout bounds the wait for in-flight requests in the graceful shutdown of Listen. Defaults to 30s.
+	ShutdownTimeout time.Duration `json:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"30s"`
+	// Tenant, if set, resolves the tenant of every request (see TenantMiddleware) before RoutePolicies, so rate
+	// limits are counted per tenant. With Required set, every route without tenant is rejected, /healthcheck included.
+	Tenant *TenantConfig `json:"-"`
+	// RoutePolicies are applied to every route (see RoutePolicyMiddleware).
+	RoutePolicies RoutePolicyConfig `json:"route_policies"`
+	// Metrics configures the metrics middleware applied by EnableTelemetry.
+	Metrics MetricsConfig `json:"metrics"`
+	// StreamUploads streams request bodies above the Fiber BodyLimit instead of reading them into memory, and
+	// stops Fiber from parsing multipart bodies into temporary files, so an Uploader can stream them.
+	StreamUploads bool `json:"stream_uploads" env:"STREAM_UPLOADS"`
 }
 
 // NewServer creates and configures a Fiber server instance.
 //
 // Parameters:
 //
-//	name: The name of the origin application. Used for the X-Origin-App header.
+//	name: The name of the origin application. Used for the X-Origin-App header, as "<name>/<version>".
 //	forwardHeaders: List of headers to be forwarded. If empty, uses defaults.
 //
 // Behavior:
 //   - Removes default server identification headers.
-//   - Sets the X-Origin-App header in the request.
+//   - Sets the X-Origin-App header in the response, with the name and the version of the build (see buildinfo).
 //   - Applies ForwardHeadersMiddleware to collect and forward headers.
-//   - Adds a /healthcheck endpoint for health monitoring.
+//   - Adds a /healthcheck endpoint for health monitoring and a /version endpoint with the build information.
+//   - Answers errors with a problem+json body carrying an error code and the request ID (see ErrorHandler).
+//   - Recovers panics in handlers, reporting them to the errorreport Reporter (see RecoverMiddleware).
 //
 // Usage:
 //
 //	server := NewServer("my-app", []string{"x-request-id", "x-client-user-agent"})
-//	log.Fatal(server.App.Listen(":8080"))
+//	server.OnStop(func(ctx context.Context) error { return redis.Close() })
+//	log.Fatal(server.Listen(":8080"))
 func NewServer(name string, forwardHeaders []string) *Server {
-	app := fiber.New()
+	return NewServerWithConfig(ServerConfig{
+		Name:           name,
+		ForwardHeaders: forwardHeaders,
+	})
+}
+
+// NewServerWithConfig creates and configures a Fiber server instance from a ServerConfig.
+// It behaves like NewServer, additionally applying the configured StripTraceHeaders, HookTimeout, ShutdownTimeout,
+// Tenant, RoutePolicies, Metrics and StreamUploads.
+//
+// Usage:
+//
+//	var cfg server.ServerConfig
+//	if err := config.Load(&cfg); err != nil {
+//		log.Fatal(err)
+//	}
+//	srv := server.NewServerWithConfig(cfg)
+func NewServerWithConfig(cfg ServerConfig) *Server {
+	app := fiber.New(fiber.Config{
+		ErrorHandler:                 ErrorHandler,
+		StreamRequestBody:            cfg.StreamUploads,
+		DisablePreParseMultipartForm: cfg.StreamUploads,
+	})
+
+	app.Use(RecoverMiddleware())
+
+	build := buildinfo.Get()
+	origin := cfg.Name
+	if build.Version != "" {
+		origin += "/" + build.Version
+	}
 
 	app.Use(func(c *fiber.Ctx) error {
 		c.Response().Header.Del("Server")
 		c.Response().Header.Del("X-Powered-By")
-		c.Set("X-Origin-App", name)
+		c.Set("X-Origin-App", origin)
 
 		return c.Next()
 	})
 
-	app.Use(ForwardHeadersMiddleware(name, forwardHeaders))
+	forwardHeaders := cfg.ForwardHeaders
+	if cfg.StripTraceHeaders {
+		forwardHeaders = withoutTraceHeaders(forwardHeaders)
+	}
+	app.Use(ForwardHeadersMiddleware(cfg.Name, forwardHeaders))
+
+	if cfg.Tenant != nil {
+		app.Use(TenantMiddleware(*cfg.Tenant))
+	}
+
+	if len(cfg.RoutePolicies.Policies) > 0 {
+		app.Use(RoutePolicyMiddleware(cfg.RoutePolicies))
+	}
 
 	app.Get("/healthcheck", func(c *fiber.Ctx) error {
 		return c.Status(200).SendString("OK")
 	})
 
+	app.Get("/version", func(c *fiber.Ctx) error {
+		return c.JSON(versionBody{App: cfg.Name, Info: build})
+	})
+
+	hookTimeout := cfg.HookTimeout
+	if hookTimeout <= 0 {
+		hookTimeout = defaultHookTimeout
+	}
+
+	shutdownTimeout := cfg.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+
 	return &Server{
-		App: app,
+		App:             app,
+		HookTimeout:     hookTimeout,
+		ShutdownTimeout: shutdownTimeout,
+		metrics:         cfg.Metrics,
 	}
 }
+
+// versionBody is the body of the /version endpoint.
+type versionBody struct {
+	App string `json:"app"`
+	buildinfo.Info
+}
@@ -0,0 +1,420 @@
+// Package devserver serves stubbed routes declared in YAML or JSON fixtures, so frontend teams can run a fake of a
+// service without its real downstreams.
+package devserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/devluispereira/go-package/clients/httpclient/contract"
+	"github.com/devluispereira/go-package/logging"
+	"github.com/devluispereira/go-package/server"
+	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var logger = logging.New("devserver")
+
+// Config holds the devserver configuration. It can be loaded with the config package.
+type Config struct {
+	// Enabled serves the stubs instead of the real routes; see Mount.
+	Enabled bool `json:"enabled" env:"DEVSERVER_ENABLED"`
+	// Fixtures lists the fixture files, or glob patterns of them. Defaults to "fixtures/*.yaml".
+	Fixtures []string `json:"fixtures" env:"DEVSERVER_FIXTURES" default:"fixtures/*.yaml"`
+	// Latency is added to the responses of stubs without their own latency, to surface loading states.
+	Latency time.Duration `json:"latency" env:"DEVSERVER_LATENCY"`
+}
+
+// Fixture is the content of a fixture file.
+type Fixture struct {
+	Stubs []Stub `json:"stubs" yaml:"stubs"`
+}
+
+// Stub answers the requests matching Method, Path and Match with Response.
+type Stub struct {
+	// Name identifies the stub in logs.
+	Name string `json:"name" yaml:"name"`
+	// Method defaults to GET.
+	Method string `json:"method" yaml:"method"`
+	// Path is a Fiber route, e.g. "/products/:id".
+	Path     string   `json:"path" yaml:"path"`
+	Match    Match    `json:"match" yaml:"match"`
+	Response Response `json:"response" yaml:"response"`
+}
+
+// Match restricts a stub to some requests. Stubs of the same method and path are tried in declaration order, so
+// declare the specific ones first and a stub without Match last, as the fallback.
+type Match struct {
+	// Query parameters the request must have, with these values.
+	Query map[string]string `json:"query" yaml:"query"`
+	// Headers the request must have, with these values.
+	Headers map[string]string `json:"headers" yaml:"headers"`
+	// Body the JSON request body must match: objects match when every key listed matches, extra keys are allowed.
+	Body any `json:"body" yaml:"body"`
+}
+
+// Response is the response of a stub.
+type Response struct {
+	// Status defaults to 200.
+	Status  int               `json:"status" yaml:"status"`
+	Headers map[string]string `json:"headers" yaml:"headers"`
+	// Body is sent as JSON when it is an object or array, as text otherwise. Its strings are text/template
+	// templates over the request: {{.Params.id}}, {{.Query.page}}, {{.Headers.Authorization}}, {{.Body.name}} and
+	// {{.Now}}, with the json function to embed values as JSON. Use index for hyphenated headers:
+	// {{index .Headers "X-Tenant-Id"}}.
+	Body any `json:"body" yaml:"body"`
+	// BodyFile is a file sent as the body, relative to the fixture file. It is not templated.
+	BodyFile string `json:"body_file" yaml:"body_file"`
+	// Latency delays the response, plus a random Jitter, to exercise loading states and timeouts.
+	Latency time.Duration `json:"latency" yaml:"latency"`
+	Jitter  time.Duration `json:"jitter" yaml:"jitter"`
+
+	dir string
+}
+
+// Mount registers the stubs of the fixtures of cfg on app, when cfg is enabled.
+//
+// Parameters:
+//
+//	app: The app of the service, usually srv.App.
+//	cfg: Devserver configuration.
+//
+// Behavior:
+//   - Register the stubs instead of the real routes and downstream clients, so the service starts without them.
+//   - Requests to a stubbed path matching no stub get 404 with the not_found error code.
+//
+// Usage:
+//
+//	var devCfg devserver.Config
+//	_ = config.Load(&devCfg)
+//
+//	srv := server.NewServer("catalog-bff", nil)
+//	if devCfg.Enabled {
+//		if err := devserver.Mount(srv.App, devCfg); err != nil {
+//			log.Fatal(err)
+//		}
+//	} else {
+//		registerRoutes(srv.App)
+//	}
+//
+//	# fixtures/products.yaml
+//	stubs:
+//	  - path: /products/:id
+//	    match:
+//	      headers: {x-tenant-id: acme}
+//	    response:
+//	      body: {id: "{{.Params.id}}", name: "Acme product", price: 10}
+//	      latency: 300ms
+//	  - path: /products/:id
+//	    response:
+//	      status: 404
+//	      body: {code: not_found}
+//
+// Returns:
+//   - error: When fixtures can't be read or are invalid.
+func Mount(app *fiber.App, cfg Config) error {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	patterns := cfg.Fixtures
+	if len(patterns) == 0 {
+		patterns = []string{"fixtures/*.yaml"}
+	}
+
+	stubs, err := Load(patterns...)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Latency > 0 {
+		for i := range stubs {
+			if stubs[i].Response.Latency == 0 {
+				stubs[i].Response.Latency = cfg.Latency
+			}
+		}
+	}
+
+	Register(app, stubs)
+	logger.Warn().Int("stubs", len(stubs)).Strs("fixtures", patterns).Msg("devserver enabled: serving stubbed routes")
+	return nil
+}
+
+// Load reads the stubs of fixture files, given as paths or glob patterns. YAML (.yaml, .yml) and JSON (.json)
+// files are supported.
+func Load(patterns ...string) ([]Stub, error) {
+	var stubs []Stub
+
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fixture pattern %s: %w", pattern, err)
+		}
+
+		for _, file := range files {
+			fixture, err := loadFixture(file)
+			if err != nil {
+				return nil, err
+			}
+			stubs = append(stubs, fixture.Stubs...)
+		}
+	}
+
+	if len(stubs) == 0 {
+		return nil, fmt.Errorf("no stubs found in %s", strings.Join(patterns, ", "))
+	}
+	return stubs, nil
+}
+
+func loadFixture(file string) (*Fixture, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture %s: %w", file, err)
+	}
+
+	// JSON is valid YAML, so one decoder reads both.
+	var fixture Fixture
+	if err := yaml.Unmarshal(content, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", file, err)
+	}
+
+	for i := range fixture.Stubs {
+		stub := &fixture.Stubs[i]
+		if stub.Path == "" {
+			return nil, fmt.Errorf("stub %d of %s has no path", i, file)
+		}
+		if stub.Method == "" {
+			stub.Method = fiber.MethodGet
+		}
+		stub.Method = strings.ToUpper(stub.Method)
+		if stub.Name == "" {
+			stub.Name = stub.Method + " " + stub.Path
+		}
+		stub.Response.dir = filepath.Dir(file)
+
+		// Normalize the body matcher as decoded JSON, e.g. YAML integers to float64.
+		if stub.Match.Body != nil {
+			if stub.Match.Body, err = normalizeJSON(stub.Match.Body); err != nil {
+				return nil, fmt.Errorf("invalid body matcher of stub %s in %s: %w", stub.Name, file, err)
+			}
+		}
+		if stub.Response.Body != nil {
+			if stub.Response.Body, err = normalizeJSON(stub.Response.Body); err != nil {
+				return nil, fmt.Errorf("invalid body of stub %s in %s: %w", stub.Name, file, err)
+			}
+		}
+	}
+
+	return &fixture, nil
+}
+
+// Register registers stubs on router, one route per method and path.
+func Register(router fiber.Router, stubs []Stub) {
+	type route struct{ method, path string }
+
+	var order []route
+	byRoute := map[route][]Stub{}
+	for _, stub := range stubs {
+		r := route{stub.Method, stub.Path}
+		if _, ok := byRoute[r]; !ok {
+			order = append(order, r)
+		}
+		byRoute[r] = append(byRoute[r], stub)
+	}
+
+	for _, r := range order {
+		candidates := byRoute[r]
+		router.Add(r.method, r.path, func(c *fiber.Ctx) error {
+			for _, stub := range candidates {
+				if stub.Match.matches(c) {
+					return stub.Response.send(c, stub.Name)
+				}
+			}
+			return server.Errorf(server.CodeNotFound, "no stub of %s %s matches the request", r.method, r.path)
+		})
+	}
+}
+
+// matches reports whether the request satisfies every condition of m.
+func (m Match) matches(c *fiber.Ctx) bool {
+	for name, value := range m.Query {
+		if c.Query(name) != value {
+			return false
+		}
+	}
+	for name, value := range m.Headers {
+		if c.Get(name) != value {
+			return false
+		}
+	}
+	if m.Body != nil {
+		var body any
+		if err := json.Unmarshal(c.Body(), &body); err != nil {
+			return false
+		}
+		if contract.Match(m.Body, body) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// send writes the response, after its latency.
+func (r Response) send(c *fiber.Ctx, name string) error {
+	if delay := r.delay(); delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-c.UserContext().Done():
+			return c.UserContext().Err()
+		}
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = fiber.StatusOK
+	}
+	c.Status(status)
+	for name, value := range r.Headers {
+		c.Set(name, value)
+	}
+
+	logging.FromContext(c.UserContext()).Debug().Str("stub", name).Int("status", status).Msg("served stub")
+
+	if r.BodyFile != "" {
+		path := r.BodyFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.dir, path)
+		}
+		return c.SendFile(path)
+	}
+
+	if r.Body == nil {
+		return nil
+	}
+
+	data := templateData(c)
+	body, err := render(r.Body, data)
+	if err != nil {
+		return server.Errorf(server.CodeInternal, "failed to render stub %s: %w", name, err)
+	}
+
+	if text, ok := body.(string); ok {
+		if _, ok := r.Headers["Content-Type"]; !ok {
+			c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		}
+		return c.SendString(text)
+	}
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return server.Errorf(server.CodeInternal, "failed to encode stub %s: %w", name, err)
+	}
+	if _, ok := r.Headers["Content-Type"]; !ok {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	return c.Send(encoded)
+}
+
+// delay returns the latency of the response with its jitter.
+func (r Response) delay() time.Duration {
+	if r.Jitter <= 0 {
+		return r.Latency
+	}
+	return r.Latency + rand.N(r.Jitter)
+}
+
+// templateData returns the data of the response templates of a request.
+func templateData(c *fiber.Ctx) map[string]any {
+	headers := map[string]string{}
+	for name, values := range c.GetReqHeaders() {
+		if len(values) > 0 {
+			headers[name] = values[0]
+		}
+	}
+
+	var body any
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		body = string(c.Body())
+	}
+
+	return map[string]any{
+		"Params":  c.AllParams(),
+		"Query":   c.Queries(),
+		"Headers": headers,
+		"Body":    body,
+		"Now":     time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	},
+}
+
+// render executes the templates of the strings of value.
+func render(value any, data map[string]any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+		tmpl, err := template.New("stub").Funcs(templateFuncs).Option("missingkey=zero").Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, err
+		}
+		return b.String(), nil
+
+	case map[string]any:
+		rendered := make(map[string]any, len(v))
+		for key, item := range v {
+			r, err := render(item, data)
+			if err != nil {
+				return nil, err
+			}
+			rendered[key] = r
+		}
+		return rendered, nil
+
+	case []any:
+		rendered := make([]any, len(v))
+		for i, item := range v {
+			r, err := render(item, data)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = r
+		}
+		return rendered, nil
+
+	default:
+		return v, nil
+	}
+}
+
+// normalizeJSON returns value as decoded by encoding/json, rejecting values JSON can't represent.
+func normalizeJSON(value any) (any, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized any
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return nil, errors.New("body is not valid JSON")
+	}
+	return normalized, nil
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"net"
+	"strings"
+
+	"github.com/devluispereira/go-package/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Preload is a resource the browser can start loading before the response is ready, sent in a Link header.
+type Preload struct {
+	// URL of the resource, e.g. "/static/app.css" or "https://fonts.example.com".
+	URL string `json:"url"`
+	// Rel is the link relation. Defaults to "preload"; "preconnect" and "modulepreload" are also useful.
+	Rel string `json:"rel"`
+	// As is the destination of preloads: "style", "script", "font", "image" or "fetch".
+	As string `json:"as"`
+	// Type is the MIME type of the resource, e.g. "font/woff2", letting browsers skip unsupported formats.
+	Type string `json:"type"`
+	// CrossOrigin is "anonymous" or "use-credentials". Fonts and fetches must set it to be reused by the page.
+	CrossOrigin string `json:"crossorigin"`
+}
+
+// String returns the Link header value of the preload, e.g. `</static/app.css>; rel=preload; as=style`.
+func (p Preload) String() string {
+	rel := p.Rel
+	if rel == "" {
+		rel = "preload"
+	}
+
+	var b strings.Builder
+	b.WriteString("<" + p.URL + ">; rel=" + rel)
+	if p.As != "" {
+		b.WriteString("; as=" + p.As)
+	}
+	if p.Type != "" {
+		b.WriteString(`; type="` + p.Type + `"`)
+	}
+	if p.CrossOrigin != "" {
+		b.WriteString("; crossorigin=" + p.CrossOrigin)
+	}
+	return b.String()
+}
+
+// EarlyHintsRule declares the resources preloaded by the routes matching Route.
+type EarlyHintsRule struct {
+	// Route is a path pattern: "*" matches one segment and a trailing "/**" matches any suffix, e.g. "/products/**".
+	Route    string    `json:"route"`
+	Preloads []Preload `json:"preloads"`
+}
+
+// EarlyHintsConfig holds the early hints rules. It can be loaded with the config package from a JSON/YAML file.
+type EarlyHintsConfig struct {
+	Rules []EarlyHintsRule `json:"rules"`
+	// LinkOnly only sets the Link header of the final response, without the 103 response. Use it when a proxy in
+	// front of the service mishandles 1xx responses; CDNs such as Cloudflare turn the Link headers into 103s.
+	LinkOnly bool `json:"link_only" env:"EARLY_HINTS_LINK_ONLY"`
+}
+
+// EarlyHintsMiddleware sends the preloads of the matching routes as HTTP 103 Early Hints (RFC 8297), so the browser
+// fetches stylesheets, scripts and fonts while the handler is still rendering the page, and as Link headers of the
+// final response.
+//
+// Parameters:
+//
+//	cfg: Preloads by route, usually loaded with config.Load.
+//
+// Behavior:
+//   - Every rule matching the request path adds its preloads.
+//   - The 103 response is only sent to GET navigations over HTTP/1.1 that accept text/html, since browsers ignore
+//     early hints of other requests. Other requests only get the Link headers.
+//   - The 103 response is written to the connection before the handler runs, so it is not sent to the in-memory
+//     connections of app.Test, which would read it as the final response.
+//
+// Usage:
+//
+//	app.Use(server.EarlyHintsMiddleware(server.EarlyHintsConfig{
+//		Rules: []server.EarlyHintsRule{{
+//			Route: "/products/**",
+//			Preloads: []server.Preload{
+//				{URL: "/static/app.css", As: "style"},
+//				{URL: "/static/app.js", As: "script"},
+//				{URL: "/static/inter.woff2", As: "font", Type: "font/woff2", CrossOrigin: "anonymous"},
+//			},
+//		}},
+//	}))
+func EarlyHintsMiddleware(cfg EarlyHintsConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var preloads []Preload
+		for _, rule := range cfg.Rules {
+			if matchRoute(rule.Route, c.Path()) {
+				preloads = append(preloads, rule.Preloads...)
+			}
+		}
+		if len(preloads) == 0 {
+			return c.Next()
+		}
+
+		if cfg.LinkOnly {
+			setPreloadLinks(c, preloads)
+			return c.Next()
+		}
+
+		if err := EarlyHints(c, preloads...); err != nil {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// EarlyHints sends preloads in a 103 Early Hints response, when the request is a navigation able to use it, and
+// sets them as Link headers of the final response. Call it from a handler before slow work, for preloads known only
+// at request time, e.g. the hero image of a product page.
+//
+// Returns:
+//   - error: Only when writing the 103 response fails, which usually means the client went away.
+func EarlyHints(c *fiber.Ctx, preloads ...Preload) error {
+	if len(preloads) == 0 {
+		return nil
+	}
+	setPreloadLinks(c, preloads)
+
+	if !acceptsEarlyHints(c) {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 103 Early Hints\r\n")
+	for _, preload := range preloads {
+		b.WriteString("Link: " + sanitizeHeaderValue(preload.String()) + "\r\n")
+	}
+	b.WriteString("\r\n")
+
+	if _, err := c.Context().Conn().Write([]byte(b.String())); err != nil {
+		logging.FromContext(c.UserContext()).Debug().Err(err).Msg("failed to send early hints")
+		return err
+	}
+	return nil
+}
+
+// setPreloadLinks adds preloads to the Link header of the response.
+func setPreloadLinks(c *fiber.Ctx, preloads []Preload) {
+	for _, preload := range preloads {
+		c.Response().Header.Add(fiber.HeaderLink, sanitizeHeaderValue(preload.String()))
+	}
+}
+
+// acceptsEarlyHints reports whether a 103 response can be sent to the request: a GET navigation over a real
+// HTTP/1.1 connection.
+func acceptsEarlyHints(c *fiber.Ctx) bool {
+	if c.Method() != fiber.MethodGet || !c.Request().Header.IsHTTP11() {
+		return false
+	}
+	if !strings.Contains(c.Get(fiber.HeaderAccept), fiber.MIMETextHTML) {
+		return false
+	}
+
+	// The in-memory connections of app.Test have no remote address.
+	addr, ok := c.Context().Conn().RemoteAddr().(*net.TCPAddr)
+	return !ok || !addr.IP.IsUnspecified() || addr.Port != 0
+}
+
+// sanitizeHeaderValue strips line breaks, so configured values can't inject headers.
+func sanitizeHeaderValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
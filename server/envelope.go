@@ -0,0 +1,73 @@
+package server
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// ResponseEnvelope is the standard response body shape shared across services, also written by the Envelope
+// transform: the payload under data, response metadata (request ID, pagination) under meta and partial failures
+// under errors. Errors of the whole request are still answered by ErrorHandler with problem+json. The httpclient
+// package unwraps it with HTTPResponse.Decode.
+type ResponseEnvelope struct {
+	Data   any           `json:"data"`
+	Meta   *ResponseMeta `json:"meta,omitempty"`
+	Errors []ErrorBody   `json:"errors,omitempty"`
+}
+
+// ResponseMeta is the metadata of a ResponseEnvelope.
+type ResponseMeta struct {
+	RequestID  string      `json:"request_id,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination describes the page of a list response. Offset paginated lists set Page, PerPage and Total; cursor
+// paginated lists set NextCursor and PrevCursor. HasMore is set in both.
+type Pagination struct {
+	Page       int    `json:"page,omitempty"`
+	PerPage    int    `json:"per_page,omitempty"`
+	Total      int64  `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// OK answers 200 with data wrapped in a ResponseEnvelope.
+//
+// Parameters:
+//   - c: Fiber context.
+//   - data: The payload, written under data.
+//   - meta: Optional metadata; nil writes only the request ID.
+//
+// Behavior:
+//   - meta.RequestID defaults to the request ID (see RequestID), so clients can quote it in support requests.
+//
+// Usage:
+//
+//	return server.OK(c, products, &server.ResponseMeta{Pagination: &server.Pagination{Page: 2, PerPage: 20, Total: 135, HasMore: true}})
+func OK(c *fiber.Ctx, data any, meta *ResponseMeta) error {
+	return writeEnvelope(c, fiber.StatusOK, data, meta)
+}
+
+// Created answers 201 with data wrapped in a ResponseEnvelope, setting the Location header when location is not empty.
+//
+// Usage:
+//
+//	return server.Created(c, "/products/"+product.ID, product, nil)
+func Created(c *fiber.Ctx, location string, data any, meta *ResponseMeta) error {
+	if location != "" {
+		c.Location(location)
+	}
+	return writeEnvelope(c, fiber.StatusCreated, data, meta)
+}
+
+func writeEnvelope(c *fiber.Ctx, status int, data any, meta *ResponseMeta) error {
+	m := ResponseMeta{}
+	if meta != nil {
+		m = *meta
+	}
+	if m.RequestID == "" {
+		m.RequestID = RequestID(c)
+	}
+
+	return c.Status(status).JSON(ResponseEnvelope{Data: data, Meta: &m})
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/devluispereira/go-package/logging"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrorCode is a stable, machine-readable error code with its HTTP status.
+type ErrorCode struct {
+	Name   string
+	Status int
+}
+
+// Error codes used by ErrorHandler for errors without one. Declare application codes the same way, e.g.
+// server.ErrorCode{Name: "product_unavailable", Status: 409}.
+var (
+	CodeInvalidArgument  = ErrorCode{Name: "invalid_argument", Status: http.StatusBadRequest}
+	CodeUnauthenticated  = ErrorCode{Name: "unauthenticated", Status: http.StatusUnauthorized}
+	CodePermissionDenied = ErrorCode{Name: "permission_denied", Status: http.StatusForbidden}
+	CodeNotFound         = ErrorCode{Name: "not_found", Status: http.StatusNotFound}
+	CodeConflict         = ErrorCode{Name: "conflict", Status: http.StatusConflict}
+	CodeTooLarge         = ErrorCode{Name: "payload_too_large", Status: http.StatusRequestEntityTooLarge}
+	CodeRateLimited      = ErrorCode{Name: "rate_limited", Status: http.StatusTooManyRequests}
+	CodeInternal         = ErrorCode{Name: "internal", Status: http.StatusInternalServerError}
+	CodeUnavailable      = ErrorCode{Name: "unavailable", Status: http.StatusServiceUnavailable}
+	CodeTimeout          = ErrorCode{Name: "timeout", Status: http.StatusGatewayTimeout}
+)
+
+// Error is an error returned to clients with a stable code. Create it with Errorf.
+type Error struct {
+	Code    ErrorCode
+	Message string
+	// Err is the underlying cause. It is logged, but never sent to the client.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Code.Name + ": " + e.Message + ": " + e.Err.Error()
+	}
+	return e.Code.Name + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errorf creates an Error with code and a formatted message. Like fmt.Errorf, a %w verb wraps the cause, which is
+// logged but not sent to the client.
+//
+// Usage:
+//
+//	if errors.Is(err, repo.ErrNotFound) {
+//		return server.Errorf(server.CodeNotFound, "product %s not found", id)
+//	}
+func Errorf(code ErrorCode, format string, args ...any) *Error {
+	err := fmt.Errorf(format, args...)
+	message := err.Error()
+
+	var cause error
+	if wrapped := errors.Unwrap(err); wrapped != nil {
+		cause = wrapped
+		message = strings.TrimSuffix(strings.TrimSuffix(message, wrapped.Error()), ": ")
+	}
+
+	return &Error{Code: code, Message: message, Err: cause}
+}
+
+const mimeProblemJSON = "application/problem+json"
+
+// ErrorBody is the application/problem+json body (RFC 9457) written by ErrorHandler.
+type ErrorBody struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id"`
+}
+
+// ErrorHandler is the Fiber error handler installed by NewServer. It answers every error with a problem+json body
+// carrying a stable error code and the request ID, so a support investigation can start from a client screenshot.
+//
+// Behavior:
+//   - *Error keeps its code and message; *fiber.Error is mapped to the code of its status; context deadline errors
+//     become "timeout" (504); other errors become "internal" (500) without leaking their message.
+//   - The request ID is the x-request-id header when valid, the trace ID of the request span or a generated ID,
+//     in this order (see RequestID). It is also sent in the X-Request-Id response header.
+//   - 5xx errors are logged with the request ID and the underlying cause.
+//
+// Usage:
+//
+//	app := fiber.New(fiber.Config{ErrorHandler: server.ErrorHandler})
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	appErr := toError(err)
+	requestID := RequestID(c)
+
+	if appErr.Code.Status >= 500 {
+		log := logging.FromContext(c.UserContext())
+		log.Error().
+			Err(err).
+			Str("request_id", requestID).
+			Str("code", appErr.Code.Name).
+			Str("method", c.Method()).
+			Str("path", c.Path()).
+			Msg("request failed")
+	}
+
+	c.Set("X-Request-Id", requestID)
+	c.Status(appErr.Code.Status)
+	return c.JSON(ErrorBody{
+		Type:      "about:blank",
+		Title:     http.StatusText(appErr.Code.Status),
+		Status:    appErr.Code.Status,
+		Detail:    appErr.Message,
+		Code:      appErr.Code.Name,
+		RequestID: requestID,
+	}, mimeProblemJSON)
+}
+
+func toError(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return &Error{Code: codeForStatus(fiberErr.Code), Message: fiberErr.Message}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &Error{Code: CodeTimeout, Message: "request timed out", Err: err}
+	}
+
+	return &Error{Code: CodeInternal, Message: "internal server error", Err: err}
+}
+
+// codeForStatus returns the ErrorCode of an HTTP status, deriving the name from the status text for statuses
+// without a predefined code.
+func codeForStatus(status int) ErrorCode {
+	for _, code := range []ErrorCode{
+		CodeInvalidArgument, CodeUnauthenticated, CodePermissionDenied, CodeNotFound, CodeConflict,
+		CodeTooLarge, CodeRateLimited, CodeInternal, CodeUnavailable, CodeTimeout,
+	} {
+		if code.Status == status {
+			return code
+		}
+	}
+
+	name := strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+	if name == "" {
+		name = "unknown"
+	}
+	return ErrorCode{Name: name, Status: status}
+}
+
+// maxRequestIDLength bounds the x-request-id accepted from clients.
+const maxRequestIDLength = 128
+
+// RequestID returns the ID that identifies the request in logs and error bodies: the x-request-id header, the
+// trace ID of the request span or, when neither is available, an ID generated once per request.
+//
+// Behavior:
+//   - The header is echoed in responses and logs, so it is only used when it has at most 128 letters, digits,
+//     '-', '_', '.' or ':'. Otherwise it is ignored as if it were missing.
+func RequestID(c *fiber.Ctx) string {
+	if id := c.Get("x-request-id"); validRequestID(id) {
+		return id
+	}
+
+	if span := trace.SpanContextFromContext(c.UserContext()); span.HasTraceID() {
+		return span.TraceID().String()
+	}
+
+	if id, ok := c.Locals("requestID").(string); ok {
+		return id
+	}
+
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	id := hex.EncodeToString(b)
+	c.Locals("requestID", id)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		switch ch := id[i]; {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-', ch == '_', ch == '.', ch == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
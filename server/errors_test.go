@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func requestIDOf(t *testing.T, header string) (string, ErrorBody) {
+	t.Helper()
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/", func(c *fiber.Ctx) error { return fiber.ErrNotFound })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("x-request-id", header)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body ErrorBody
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp.Header.Get("X-Request-Id"), body
+}
+
+func TestRequestIDKeepsValidHeader(t *testing.T) {
+	for _, id := range []string{"abc-123", "req_1.2:3", strings.Repeat("a", maxRequestIDLength)} {
+		header, body := requestIDOf(t, id)
+		if header != id || body.RequestID != id {
+			t.Errorf("%q: got header %q and body %q", id, header, body.RequestID)
+		}
+	}
+}
+
+func TestRequestIDReplacesInvalidHeader(t *testing.T) {
+	for _, id := range []string{
+		strings.Repeat("a", maxRequestIDLength+1),
+		"abc\tinjected",
+		"<script>alert(1)</script>",
+		"id with spaces",
+		"ação",
+	} {
+		header, body := requestIDOf(t, id)
+		if header == id || len(header) != 32 || body.RequestID != header {
+			t.Errorf("%q: got header %q and body %q, want a generated ID", id, header, body.RequestID)
+		}
+	}
+}
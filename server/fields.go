@@ -0,0 +1,172 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FieldsConfig holds the configuration for the partial-response middleware.
+type FieldsConfig struct {
+	// Param is the query parameter listing the requested fields. Defaults to "fields".
+	Param string
+	// MaxDepth is the maximum number of segments of a field path, e.g. "a.b.c" has depth 3. Defaults to 5.
+	MaxDepth int
+	// MaxFields is the maximum number of requested fields. Defaults to 50.
+	MaxFields int
+	// Platforms restricts the projection to requests whose x-platform-id is listed. Empty applies to every request.
+	Platforms []string
+}
+
+// FieldsMiddleware filters JSON responses to the field paths requested in the fields query parameter.
+//
+// Parameters:
+//
+//	cfg: Partial-response configuration.
+//
+// Behavior:
+//   - Fields are comma-separated dot paths, e.g. ?fields=id,title,images.url.
+//   - Arrays are projected element by element, so "items.id" keeps only the id of each item.
+//   - Only successful application/json responses are projected; others pass through untouched.
+//   - Paths deeper than MaxDepth, or more than MaxFields fields, are rejected with 400.
+//
+// Usage:
+//
+//	app.Use(FieldsMiddleware(FieldsConfig{Platforms: []string{"android", "ios"}}))
+func FieldsMiddleware(cfg FieldsConfig) fiber.Handler {
+	if cfg.Param == "" {
+		cfg.Param = "fields"
+	}
+	if cfg.MaxDepth <= 0 {
+		cfg.MaxDepth = 5
+	}
+	if cfg.MaxFields <= 0 {
+		cfg.MaxFields = 50
+	}
+
+	platforms := make(map[string]bool, len(cfg.Platforms))
+	for _, platform := range cfg.Platforms {
+		platforms[strings.ToLower(platform)] = true
+	}
+
+	return func(c *fiber.Ctx) error {
+		raw := c.Query(cfg.Param)
+		if raw == "" || (len(platforms) > 0 && !platforms[strings.ToLower(c.Get("x-platform-id"))]) {
+			return c.Next()
+		}
+
+		tree, err := parseFields(raw, cfg.MaxDepth, cfg.MaxFields)
+		if err != nil {
+			return err
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status < 200 || status >= 300 || !strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMEApplicationJSON) {
+			return nil
+		}
+
+		body, err := unmarshalJSON(c.Response().Body())
+		if err != nil {
+			return nil
+		}
+
+		projected, err := json.Marshal(project(body, tree))
+		if err != nil {
+			return nil
+		}
+
+		c.Response().SetBodyRaw(projected)
+		return nil
+	}
+}
+
+// unmarshalJSON decodes a response body keeping numbers as json.Number, so integers above 2^53 are re-encoded
+// unchanged.
+func unmarshalJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var body any
+	if err := decoder.Decode(&body); err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return body, nil
+}
+
+// fieldTree is the set of requested paths; a nil subtree selects the whole value.
+type fieldTree map[string]fieldTree
+
+func parseFields(raw string, maxDepth, maxFields int) (fieldTree, error) {
+	paths := strings.Split(raw, ",")
+	if len(paths) > maxFields {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "too many fields")
+	}
+
+	tree := fieldTree{}
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		segments := strings.Split(path, ".")
+		if len(segments) > maxDepth {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "field path too deep: "+path)
+		}
+
+		node := tree
+		for i, segment := range segments {
+			child, exists := node[segment]
+			if exists && child == nil {
+				break
+			}
+			if i == len(segments)-1 {
+				node[segment] = nil
+				break
+			}
+			if !exists {
+				child = fieldTree{}
+				node[segment] = child
+			}
+			node = child
+		}
+	}
+
+	return tree, nil
+}
+
+func project(value any, tree fieldTree) any {
+	if tree == nil {
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(tree))
+		for key, subtree := range tree {
+			if child, ok := v[key]; ok {
+				out[key] = project(child, subtree)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = project(item, tree)
+		}
+		return out
+	default:
+		return value
+	}
+}
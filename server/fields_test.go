@@ -0,0 +1,28 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestFieldsKeepsLargeNumbers(t *testing.T) {
+	app := fiber.New()
+	app.Get("/items", FieldsMiddleware(FieldsConfig{}), func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.SendString(`{"id":9007199254740993,"price":0.1,"title":"x","items":[{"id":12345678901234567890,"name":"a"}]}`)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/items?fields=id,price,items.id", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+
+	if want := `{"id":9007199254740993,"items":[{"id":12345678901234567890}],"price":0.1}`; string(body) != want {
+		t.Fatalf("got %s, want %s", body, want)
+	}
+}
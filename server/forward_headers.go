@@ -2,13 +2,20 @@ package server
 
 import (
 	"context"
+	"slices"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // defaultForwardHeaders defines the default list of headers to be forwarded by the middleware.
 // These headers are commonly used for tracing, user identification, and platform information.
+// The W3C trace headers keep traces connected across services without the OTel middlewares; they are validated
+// (see ValidForwardedHeader), and ServerConfig.StripTraceHeaders drops them at a trust boundary.
 var defaultForwardHeaders = []string{
+	"traceparent",
+	"tracestate",
+	"baggage",
 	"x-request-id",
 	"x-canonical-uri",
 	"x-client-user-agent",
@@ -23,6 +30,15 @@ var defaultForwardHeaders = []string{
 	"x-glb-exp-id",
 }
 
+// traceHeaders are the W3C trace headers dropped by ServerConfig.StripTraceHeaders.
+var traceHeaders = []string{"traceparent", "tracestate", "baggage"}
+
+// DefaultForwardHeaders returns the headers forwarded when none are configured, for other transports (e.g.
+// grpcserver) that propagate the same headers.
+func DefaultForwardHeaders() []string {
+	return slices.Clone(defaultForwardHeaders)
+}
+
 type ForwardedHeadersKeyType struct{}
 
 // ForwardHeadersMiddleware collects specified headers from the incoming request and stores them in Fiber's Locals.
@@ -34,6 +50,8 @@ type ForwardedHeadersKeyType struct{}
 //
 // Behavior:
 //   - For each header in the list, if present in the request, adds it to a map.
+//   - traceparent, tracestate and baggage are only forwarded when valid (see ValidForwardedHeader); tracestate
+//     also requires a valid traceparent.
 //   - Adds "x-origin-app" with the value of appName to the map.
 //   - Stores the map in c.Locals("forwardedHeaders") for use in subsequent handlers.
 //
@@ -46,18 +64,7 @@ type ForwardedHeadersKeyType struct{}
 
 func ForwardHeadersMiddleware(appName string, forwardHeaders []string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		headersMap := make(map[string]string)
-
-		if len(forwardHeaders) == 0 {
-			forwardHeaders = append(forwardHeaders, defaultForwardHeaders...)
-		}
-
-		for _, h := range forwardHeaders {
-			val := c.Get(h)
-			if val != "" {
-				headersMap[h] = val
-			}
-		}
+		headersMap := CollectForwardedHeaders(forwardHeaders, func(name string) string { return c.Get(name) })
 
 		ctx := context.WithValue(c.UserContext(), "forwardedHeaders", headersMap)
 
@@ -65,3 +72,39 @@ func ForwardHeadersMiddleware(appName string, forwardHeaders []string) fiber.Han
 		return c.Next()
 	}
 }
+
+// CollectForwardedHeaders returns the headers of forwardHeaders (or the defaults when empty) found by get, as
+// ForwardHeadersMiddleware stores them. Consumers of other transports (e.g. Kafka records or queue messages) use it
+// so a producer can only set the headers a client could, and not arbitrary ones such as Authorization.
+//
+// Behavior:
+//   - Empty values are skipped; traceparent, tracestate and baggage must be valid (see ValidForwardedHeader), and
+//     tracestate also requires a valid traceparent.
+func CollectForwardedHeaders(forwardHeaders []string, get func(name string) string) map[string]string {
+	if len(forwardHeaders) == 0 {
+		forwardHeaders = defaultForwardHeaders
+	}
+
+	headers := make(map[string]string, len(forwardHeaders))
+	for _, h := range forwardHeaders {
+		if val := get(h); val != "" && ValidForwardedHeader(h, val) {
+			headers[h] = val
+		}
+	}
+
+	if _, ok := headers["traceparent"]; !ok {
+		delete(headers, "tracestate")
+	}
+	return headers
+}
+
+// withoutTraceHeaders returns forwardHeaders, or the defaults when empty, without the W3C trace headers.
+func withoutTraceHeaders(forwardHeaders []string) []string {
+	if len(forwardHeaders) == 0 {
+		forwardHeaders = defaultForwardHeaders
+	}
+
+	return slices.DeleteFunc(slices.Clone(forwardHeaders), func(h string) bool {
+		return slices.ContainsFunc(traceHeaders, func(trace string) bool { return strings.EqualFold(h, trace) })
+	})
+}
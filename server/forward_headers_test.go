@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const sampleTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func forwardedHeaders(t *testing.T, forward []string, headers map[string]string) map[string]string {
+	t.Helper()
+
+	var forwarded map[string]string
+	app := fiber.New()
+	app.Use(ForwardHeadersMiddleware("my-app", forward))
+	app.Get("/", func(c *fiber.Ctx) error {
+		forwarded, _ = c.UserContext().Value("forwardedHeaders").(map[string]string)
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	doStatus(t, app, req)
+	return forwarded
+}
+
+func TestForwardHeadersIncludesValidTraceHeadersByDefault(t *testing.T) {
+	forwarded := forwardedHeaders(t, nil, map[string]string{
+		"traceparent":  sampleTraceparent,
+		"tracestate":   "vendor=value",
+		"baggage":      "tier=gold",
+		"x-request-id": "abc",
+	})
+
+	if forwarded["traceparent"] != sampleTraceparent || forwarded["tracestate"] != "vendor=value" ||
+		forwarded["baggage"] != "tier=gold" || forwarded["x-request-id"] != "abc" {
+		t.Fatalf("got %v, want the trace headers forwarded by default", forwarded)
+	}
+
+	forwarded = forwardedHeaders(t, nil, map[string]string{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"tracestate":  "vendor=value",
+		"baggage":     strings.Repeat("k=v,", maxBaggageMembers) + "k=v",
+	})
+	if len(forwarded) != 0 {
+		t.Fatalf("got %v, want malformed and oversized trace headers dropped", forwarded)
+	}
+}
+
+func TestServerStripTraceHeaders(t *testing.T) {
+	var forwarded map[string]string
+	srv := NewServerWithConfig(ServerConfig{Name: "my-app", StripTraceHeaders: true})
+	srv.App.Get("/", func(c *fiber.Ctx) error {
+		forwarded, _ = c.UserContext().Value("forwardedHeaders").(map[string]string)
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", sampleTraceparent)
+	req.Header.Set("baggage", "tier=gold")
+	req.Header.Set("x-request-id", "abc")
+	doStatus(t, srv.App, req)
+
+	if forwarded["x-request-id"] != "abc" {
+		t.Fatalf("got %v, want x-request-id forwarded", forwarded)
+	}
+	if _, ok := forwarded["traceparent"]; ok {
+		t.Fatalf("got %v, want traceparent stripped", forwarded)
+	}
+	if _, ok := forwarded["baggage"]; ok {
+		t.Fatalf("got %v, want baggage stripped", forwarded)
+	}
+}
+
+func TestForwardHeadersValidatesListedTraceHeaders(t *testing.T) {
+	forward := []string{"traceparent", "tracestate", "baggage"}
+
+	forwarded := forwardedHeaders(t, forward, map[string]string{
+		"traceparent": sampleTraceparent,
+		"baggage":     "tier=gold",
+	})
+	if forwarded["traceparent"] != sampleTraceparent || forwarded["baggage"] != "tier=gold" {
+		t.Fatalf("got %v, want the listed trace headers", forwarded)
+	}
+
+	forwarded = forwardedHeaders(t, forward, map[string]string{
+		"traceparent": "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"tracestate":  "vendor=value",
+	})
+	if len(forwarded) != 0 {
+		t.Fatalf("got %v, want invalid traceparent and orphan tracestate dropped", forwarded)
+	}
+}
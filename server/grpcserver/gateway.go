@@ -0,0 +1,147 @@
+package grpcserver
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/devluispereira/go-package/server"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// gatewayCall carries the Fiber user context into the net/http handler and the error back to Fiber.
+type gatewayCall struct {
+	ctx context.Context
+	err error
+}
+
+type gatewayCallKeyType struct{}
+
+// HTTPHandler mounts a net/http handler (a grpc-gateway mux, a connect-go handler) in Fiber, running it with the
+// Fiber user context, so it sees the request span, forwarded headers, tenant and deadlines set by the server
+// middlewares.
+//
+// Usage:
+//
+//	path, handler := catalogv1connect.NewCatalogHandler(&catalogService{})
+//	srv.App.All(path+"*", grpcserver.HTTPHandler(handler))
+func HTTPHandler(h http.Handler) fiber.Handler {
+	wrapped := adaptor.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call, ok := r.Context().Value(gatewayCallKeyType{}).(*gatewayCall)
+		if !ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(call.ctx, gatewayCallKeyType{}, call)))
+	}))
+
+	return func(c *fiber.Ctx) error {
+		call := &gatewayCall{ctx: c.UserContext()}
+		c.Locals(gatewayCallKeyType{}, call)
+
+		if err := wrapped(c); err != nil {
+			return err
+		}
+		return call.err
+	}
+}
+
+// NewGatewayMux creates a grpc-gateway mux integrated with the Fiber server. Mount it with Gateway.
+//
+// Behavior:
+//   - The forwarded headers of the request (see server.ForwardHeadersMiddleware) and the trace context are sent
+//     to the gRPC service as metadata, where ForwardMetadata and Tracing pick them up.
+//   - gRPC errors are answered by server.ErrorHandler as problem+json, with the error code of the gRPC status
+//     (NotFound becomes "not_found", 404) and the request ID. Messages of Internal, Unknown and DataLoss errors
+//     are not sent to the client.
+//
+// Usage:
+//
+//	mux := grpcserver.NewGatewayMux()
+//	err := catalogpb.RegisterCatalogHandlerFromEndpoint(ctx, mux, "localhost:9090",
+//		[]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())})
+//	srv.App.All("/v1/*", grpcserver.Gateway(mux))
+func NewGatewayMux(opts ...runtime.ServeMuxOption) *runtime.ServeMux {
+	return runtime.NewServeMux(append([]runtime.ServeMuxOption{
+		runtime.WithMetadata(gatewayMetadata),
+		runtime.WithErrorHandler(gatewayError),
+	}, opts...)...)
+}
+
+// Gateway mounts a grpc-gateway mux created with NewGatewayMux in Fiber. See HTTPHandler.
+func Gateway(mux *runtime.ServeMux) fiber.Handler {
+	return HTTPHandler(mux)
+}
+
+// gatewayMetadata sends the forwarded headers and the trace context as metadata.
+func gatewayMetadata(ctx context.Context, _ *http.Request) metadata.MD {
+	md := metadata.MD{}
+
+	if headers, ok := ctx.Value("forwardedHeaders").(map[string]string); ok {
+		for k, v := range headers {
+			md.Set(k, v)
+		}
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	for k, v := range carrier {
+		md.Set(k, v)
+	}
+
+	return md
+}
+
+// gatewayError hands the error over to Fiber, falling back to the grpc-gateway handler outside HTTPHandler.
+func gatewayError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	call, ok := ctx.Value(gatewayCallKeyType{}).(*gatewayCall)
+	if !ok {
+		runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
+		return
+	}
+
+	call.err = fromStatus(err)
+}
+
+// grpcErrorCodes maps gRPC codes to the server error codes.
+var grpcErrorCodes = map[codes.Code]server.ErrorCode{
+	codes.InvalidArgument:    server.CodeInvalidArgument,
+	codes.OutOfRange:         server.CodeInvalidArgument,
+	codes.Unauthenticated:    server.CodeUnauthenticated,
+	codes.PermissionDenied:   server.CodePermissionDenied,
+	codes.NotFound:           server.CodeNotFound,
+	codes.AlreadyExists:      server.CodeConflict,
+	codes.Aborted:            server.CodeConflict,
+	codes.ResourceExhausted:  server.CodeRateLimited,
+	codes.Unavailable:        server.CodeUnavailable,
+	codes.DeadlineExceeded:   server.CodeTimeout,
+	codes.Internal:           server.CodeInternal,
+	codes.Unknown:            server.CodeInternal,
+	codes.DataLoss:           server.CodeInternal,
+	codes.Canceled:           {Name: "canceled", Status: 499},
+	codes.FailedPrecondition: {Name: "failed_precondition", Status: http.StatusBadRequest},
+	codes.Unimplemented:      {Name: "unimplemented", Status: http.StatusNotImplemented},
+}
+
+// fromStatus converts a gRPC error into a server error.
+func fromStatus(err error) *server.Error {
+	st := status.Convert(err)
+
+	code, ok := grpcErrorCodes[st.Code()]
+	if !ok {
+		code = server.CodeInternal
+	}
+
+	message := st.Message()
+	if code == server.CodeInternal {
+		message = "internal server error"
+	}
+
+	return &server.Error{Code: code, Message: message, Err: err}
+}
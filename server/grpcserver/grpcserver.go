@@ -0,0 +1,253 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/devluispereira/go-package/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Config holds the gRPC server configuration. It can be loaded with the config package.
+type Config struct {
+	Name string `json:"name" env:"APP_NAME"`
+	// ForwardHeaders are the metadata keys propagated like server.ForwardHeadersMiddleware does for HTTP headers.
+	// If empty, uses server.DefaultForwardHeaders.
+	ForwardHeaders []string `json:"forward_headers" env:"FORWARD_HEADERS"`
+	// Reflection registers the reflection service, for grpcurl and similar tools.
+	Reflection bool `json:"reflection" env:"GRPC_REFLECTION"`
+	// HookTimeout is the maximum time each OnStart/OnStop hook may take. Defaults to 15 seconds.
+	HookTimeout time.Duration `json:"hook_timeout" env:"HOOK_TIMEOUT" default:"15s"`
+	// ShutdownTimeout bounds the graceful shutdown of Listen. Defaults to 30 seconds.
+	ShutdownTimeout time.Duration `json:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"30s"`
+	// Interceptors run after the built-in ones, in order.
+	Interceptors []Interceptor `json:"-"`
+	// Options are passed to grpc.NewServer, e.g. credentials or message size limits.
+	Options []grpc.ServerOption `json:"-"`
+}
+
+// Server is a gRPC server with the toolkit cross-cutting stack and lifecycle hooks.
+type Server struct {
+	GRPC *grpc.Server
+	// Health is the standard health service, SERVING while the server runs. Set the status of each service with
+	// Health.SetServingStatus.
+	Health *health.Server
+	// HookTimeout is the maximum time each OnStart/OnStop hook may take.
+	HookTimeout time.Duration
+	// ShutdownTimeout is the maximum time Listen waits for in-flight calls and stop hooks on shutdown.
+	ShutdownTimeout time.Duration
+
+	startHooks []server.Hook
+	stopHooks  []stopHook
+}
+
+// stopHook is a stop hook with the number of start hooks registered before it.
+type stopHook struct {
+	hook   server.Hook
+	starts int
+}
+
+// New creates a gRPC server with the same cross-cutting stack as server.NewServer.
+//
+// Parameters:
+//
+//	cfg: Server name, forwarded metadata keys, reflection toggle and extra interceptors and options.
+//
+// Behavior:
+//   - Unary and stream calls go through Recovery, ForwardMetadata, Tracing, Metrics and Logging, in this order,
+//     and then through cfg.Interceptors.
+//   - Registers the standard health service (grpc.health.v1.Health) and, with cfg.Reflection, the reflection
+//     service.
+//   - Shutdown marks the health service NOT_SERVING, waits for in-flight calls and runs the stop hooks.
+//
+// Usage:
+//
+//	srv := grpcserver.New(grpcserver.Config{Name: "catalog", Reflection: true})
+//	catalogpb.RegisterCatalogServer(srv.GRPC, &catalogService{})
+//	srv.OnStop(func(ctx context.Context) error { return redis.Close() })
+//	log.Fatal(srv.Listen(":9090"))
+func New(cfg Config) *Server {
+	forwardHeaders := cfg.ForwardHeaders
+	if len(forwardHeaders) == 0 {
+		forwardHeaders = server.DefaultForwardHeaders()
+	}
+
+	interceptors := append([]Interceptor{
+		Recovery(),
+		ForwardMetadata(forwardHeaders),
+		Tracing(),
+		Metrics(),
+		Logging(),
+	}, cfg.Interceptors...)
+
+	unary := make([]grpc.UnaryServerInterceptor, len(interceptors))
+	stream := make([]grpc.StreamServerInterceptor, len(interceptors))
+	for i, interceptor := range interceptors {
+		unary[i] = interceptor.Unary()
+		stream[i] = interceptor.Stream()
+	}
+
+	options := append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}, cfg.Options...)
+
+	s := &Server{
+		GRPC:            grpc.NewServer(options...),
+		Health:          health.NewServer(),
+		HookTimeout:     cfg.HookTimeout,
+		ShutdownTimeout: cfg.ShutdownTimeout,
+	}
+	if s.HookTimeout <= 0 {
+		s.HookTimeout = 15 * time.Second
+	}
+	if s.ShutdownTimeout <= 0 {
+		s.ShutdownTimeout = 30 * time.Second
+	}
+
+	healthpb.RegisterHealthServer(s.GRPC, s.Health)
+	if cfg.Reflection {
+		reflection.Register(s.GRPC)
+	}
+
+	return s
+}
+
+// OnStart registers a hook executed before the server starts serving. If one fails, the stop hooks registered
+// before it run. See server.Server.OnStart.
+func (s *Server) OnStart(hook server.Hook) {
+	s.startHooks = append(s.startHooks, hook)
+}
+
+// OnStop registers a hook executed after the server stops serving, in reverse registration order. See
+// server.Server.OnStop.
+func (s *Server) OnStop(hook server.Hook) {
+	s.stopHooks = append(s.stopHooks, stopHook{hook: hook, starts: len(s.startHooks)})
+}
+
+// Start runs the start hooks in order, each one bounded by HookTimeout. If one fails, the stop hooks registered
+// before it run in reverse order, even when ctx is canceled.
+func (s *Server) Start(ctx context.Context) error {
+	for i, hook := range s.startHooks {
+		if err := s.runHook(ctx, hook); err != nil {
+			err = fmt.Errorf("start hook %d failed: %w", i, err)
+			return errors.Join(err, s.stop(context.WithoutCancel(ctx), i))
+		}
+	}
+
+	return nil
+}
+
+// Serve marks the health service SERVING and serves on lis until Shutdown.
+func (s *Server) Serve(lis net.Listener) error {
+	s.Health.Resume()
+	return s.GRPC.Serve(lis)
+}
+
+// Shutdown marks the health service NOT_SERVING, stops accepting calls and waits for in-flight ones until ctx is
+// done, when they are canceled. It then runs the stop hooks in reverse order.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	s.Health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GRPC.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.GRPC.Stop()
+		errs = append(errs, fmt.Errorf("grpc shutdown failed: %w", ctx.Err()))
+	}
+
+	return errors.Join(append(errs, s.stop(ctx, len(s.startHooks)))...)
+}
+
+// stop runs, in reverse order, the stop hooks registered before the start hook at index started, i.e. the stop
+// hooks of the components already started.
+func (s *Server) stop(ctx context.Context, started int) error {
+	var errs []error
+
+	for i := len(s.stopHooks) - 1; i >= 0; i-- {
+		if s.stopHooks[i].starts > started {
+			continue
+		}
+		if err := s.runHook(ctx, s.stopHooks[i].hook); err != nil {
+			errs = append(errs, fmt.Errorf("stop hook %d failed: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Listen runs the start hooks, serves on addr and blocks until SIGINT or SIGTERM is received, then shuts the
+// server down gracefully, waiting up to ShutdownTimeout.
+//
+// Usage:
+//
+//	log.Fatal(srv.Listen(":9090"))
+func (s *Server) Listen(addr string) error {
+	if err := s.Start(context.Background()); err != nil {
+		return err
+	}
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Join(err, s.shutdownWithTimeout())
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.Serve(lis)
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-serveErr:
+		if err != nil {
+			return errors.Join(err, s.shutdownWithTimeout())
+		}
+		return nil
+	case <-signals:
+		return s.shutdownWithTimeout()
+	}
+}
+
+func (s *Server) shutdownWithTimeout() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+	defer cancel()
+	return s.Shutdown(ctx)
+}
+
+func (s *Server) runHook(ctx context.Context, hook server.Hook) error {
+	ctx, cancel := context.WithTimeout(ctx, s.HookTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- hook(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,32 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestStartFailureStopsStartedComponents(t *testing.T) {
+	srv := New(Config{Name: "test"})
+	var calls []string
+	record := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	srv.OnStart(record("start cache", nil))
+	srv.OnStop(record("stop cache", nil))
+	srv.OnStart(record("start consumer", errors.New("broker unavailable")))
+	srv.OnStop(record("stop consumer", nil))
+
+	if err := srv.Start(context.Background()); err == nil {
+		t.Fatal("Start succeeded, want the start hook error")
+	}
+	want := []string{"start cache", "start consumer", "stop cache"}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+}
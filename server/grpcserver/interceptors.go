@@ -0,0 +1,241 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/devluispereira/go-package/errorreport"
+	"github.com/devluispereira/go-package/logging"
+	"github.com/devluispereira/go-package/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const instrumentationName = "github.com/devluispereira/go-package/server/grpcserver"
+
+// Interceptor wraps unary and stream calls alike. method is the full method name ("/catalog.v1.Catalog/Get")
+// and call runs the rest of the chain with the given context.
+type Interceptor func(ctx context.Context, method string, call func(ctx context.Context) error) error
+
+// Unary adapts the interceptor to unary calls.
+func (i Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var resp any
+		err := i(ctx, info.FullMethod, func(ctx context.Context) error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+// Stream adapts the interceptor to stream calls; the stream context is replaced by the one given to call.
+func (i Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return i(ss.Context(), info.FullMethod, func(ctx context.Context) error {
+			return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		})
+	}
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// Recovery turns panics in handlers into codes.Internal errors, logging them with the stack and reporting them
+// to the errorreport Reporter.
+func Recovery() Interceptor {
+	return func(ctx context.Context, method string, call func(ctx context.Context) error) (err error) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			stack := debug.Stack()
+
+			log := logging.FromContextFor(ctx, "grpc-server")
+			log.Error().
+				Str("method", method).
+				Interface("panic", recovered).
+				Bytes("stack", stack).
+				Msg("handler panicked")
+
+			cause, ok := recovered.(error)
+			if !ok {
+				cause = fmt.Errorf("%v", recovered)
+			}
+
+			errorreport.Capture(ctx, errorreport.Event{
+				Err:     cause,
+				Message: fmt.Sprintf("panic: %v", recovered),
+				Level:   errorreport.LevelFatal,
+				Panic:   true,
+				Stack:   stack,
+				Tags:    map[string]string{"component": "grpc-server", "method": method},
+			})
+
+			err = status.Error(codes.Internal, "internal error")
+		}()
+
+		return call(ctx)
+	}
+}
+
+// ForwardMetadata stores the incoming metadata keys listed in headers in the context under "forwardedHeaders",
+// like server.ForwardHeadersMiddleware, so logs and httpclient calls made by the handler carry them.
+func ForwardMetadata(headers []string) Interceptor {
+	return func(ctx context.Context, _ string, call func(ctx context.Context) error) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		forwarded := make(map[string]string)
+		for _, h := range headers {
+			if values := md.Get(h); len(values) > 0 && values[0] != "" && server.ValidForwardedHeader(h, values[0]) {
+				forwarded[strings.ToLower(h)] = values[0]
+			}
+		}
+
+		if _, ok := forwarded["traceparent"]; !ok {
+			delete(forwarded, "tracestate")
+		}
+
+		return call(context.WithValue(ctx, "forwardedHeaders", forwarded))
+	}
+}
+
+// Tracing starts a server span for each call, continuing the trace received in the traceparent/baggage metadata.
+func Tracing() Interceptor {
+	tracer := otel.Tracer(instrumentationName)
+
+	return func(ctx context.Context, method string, call func(ctx context.Context) error) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		service, name := splitMethod(method)
+
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+		ctx, span := tracer.Start(ctx, strings.TrimPrefix(method, "/"),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("rpc.system", "grpc"),
+				attribute.String("rpc.service", service),
+				attribute.String("rpc.method", name),
+			),
+		)
+		defer span.End()
+
+		err := call(ctx)
+
+		code := status.Code(err)
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+		if err != nil {
+			span.RecordError(err)
+		}
+		if isServerError(code) {
+			span.SetStatus(otelcodes.Error, code.String())
+		}
+
+		return err
+	}
+}
+
+// Metrics records the duration of each call in the "rpc.server.call.duration" histogram, labeled by service,
+// method and status code.
+func Metrics() Interceptor {
+	duration, _ := otel.Meter(instrumentationName).Float64Histogram(
+		"rpc.server.call.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of incoming gRPC calls."),
+	)
+
+	return func(ctx context.Context, method string, call func(ctx context.Context) error) error {
+		start := time.Now()
+		err := call(ctx)
+
+		service, name := splitMethod(method)
+		duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", name),
+			attribute.String("rpc.grpc.status_code", status.Code(err).String()),
+		))
+
+		return err
+	}
+}
+
+// Logging logs each call with its method, status code and duration; server errors are logged at ERROR level.
+func Logging() Interceptor {
+	return func(ctx context.Context, method string, call func(ctx context.Context) error) error {
+		start := time.Now()
+		err := call(ctx)
+
+		code := status.Code(err)
+		log := logging.FromContextFor(ctx, "grpc-server")
+
+		event := log.Info()
+		if isServerError(code) {
+			event = log.Error().Err(err)
+		}
+		event.
+			Str("method", method).
+			Str("code", code.String()).
+			Int64("duration_ms", time.Since(start).Milliseconds()).
+			Msg("grpc call")
+
+		return err
+	}
+}
+
+// isServerError reports whether code means the server failed, as opposed to a client error.
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// splitMethod splits "/package.Service/Method" into service and method.
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", fullMethod
+	}
+	return service, method
+}
+
+// metadataCarrier adapts incoming metadata to the OpenTelemetry propagators.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
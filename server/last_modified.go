@@ -0,0 +1,112 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LastModifiedFunc returns the last modification time of the resource of a request, e.g. the greatest updated_at
+// of a list. A zero time means unknown.
+type LastModifiedFunc func(c *fiber.Ctx) (time.Time, error)
+
+// LastModifiedMiddleware answers conditional GET and HEAD requests with 304 Not Modified, from the Last-Modified
+// and ETag of the response. It avoids hashing large list responses just to revalidate them.
+//
+// Parameters:
+//
+//	lastModified: Optional. Resolves the last modification time before the handler runs, so not modified requests
+//	skip the handler; its errors are returned. If nil, handlers declare it with SetLastModified.
+//
+// Behavior:
+//   - If-None-Match is compared (weakly) with the ETag of the response and, when present, takes precedence over
+//     If-Modified-Since, which is compared with Last-Modified at one second precision.
+//   - Only 200 responses become 304; their body is dropped and the validators and caching headers are kept.
+//   - Other methods and unconditional requests pass through.
+//
+// Usage:
+//
+//	app.Get("/products", server.LastModifiedMiddleware(func(c *fiber.Ctx) (time.Time, error) {
+//		return repo.LastUpdate(c.UserContext())
+//	}), listProducts)
+func LastModifiedMiddleware(lastModified LastModifiedFunc) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return c.Next()
+		}
+
+		if lastModified != nil {
+			t, err := lastModified(c)
+			if err != nil {
+				return err
+			}
+			if SetLastModified(c, t) {
+				return c.SendStatus(fiber.StatusNotModified)
+			}
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		if c.Response().StatusCode() == fiber.StatusOK && notModified(c) {
+			c.Response().ResetBody()
+			c.Status(fiber.StatusNotModified)
+		}
+
+		return nil
+	}
+}
+
+// SetLastModified sets the Last-Modified header of the response to t and reports whether the request is conditional
+// and the resource was not modified since, so the handler can skip building the body. A zero t is ignored.
+//
+// Usage:
+//
+//	if server.SetLastModified(c, catalog.UpdatedAt) {
+//		return c.SendStatus(fiber.StatusNotModified)
+//	}
+func SetLastModified(c *fiber.Ctx, t time.Time) bool {
+	if t.IsZero() {
+		return false
+	}
+
+	c.Set(fiber.HeaderLastModified, t.UTC().Format(http.TimeFormat))
+	return notModified(c)
+}
+
+// notModified evaluates the If-None-Match and If-Modified-Since headers of the request against the ETag and
+// Last-Modified of the response, as specified by RFC 9110.
+func notModified(c *fiber.Ctx) bool {
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return false
+	}
+
+	if noneMatch := c.Get(fiber.HeaderIfNoneMatch); noneMatch != "" {
+		etag := string(c.Response().Header.Peek(fiber.HeaderETag))
+		if etag == "" {
+			return false
+		}
+
+		for _, candidate := range strings.Split(noneMatch, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	modifiedSince, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	lastModified, err := http.ParseTime(string(c.Response().Header.Peek(fiber.HeaderLastModified)))
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.After(modifiedSince)
+}
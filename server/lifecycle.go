@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// defaultHookTimeout is the maximum time each lifecycle hook may take when Server.HookTimeout is not set.
+const defaultHookTimeout = 15 * time.Second
+
+// defaultShutdownTimeout is the maximum time Listen waits for the shutdown when Server.ShutdownTimeout is not set.
+const defaultShutdownTimeout = 30 * time.Second
+
+// Hook is a lifecycle function executed when the server starts or stops.
+type Hook func(ctx context.Context) error
+
+// stopHook is a stop hook with the number of start hooks registered before it.
+type stopHook struct {
+	hook   Hook
+	starts int
+}
+
+// OnStart registers a hook executed before the server starts listening.
+// Hooks run in registration order; if one fails, the server does not start and the stop hooks registered before
+// the failing hook run, stopping the components already started.
+//
+// Usage:
+//
+//	srv.OnStart(func(ctx context.Context) error { return warmer.Warm(ctx) })
+func (s *Server) OnStart(hook Hook) {
+	s.startHooks = append(s.startHooks, hook)
+}
+
+// OnStop registers a hook executed after the server stops accepting requests.
+// Hooks run in reverse registration order, so components are stopped before their dependencies.
+// All hooks run even if one fails. Register it after the OnStart hook of the same component, so it only runs
+// when that component has started.
+//
+// Usage:
+//
+//	srv.OnStop(func(ctx context.Context) error { return redis.Close() })
+func (s *Server) OnStop(hook Hook) {
+	s.stopHooks = append(s.stopHooks, stopHook{hook: hook, starts: len(s.startHooks)})
+}
+
+// Start runs the start hooks in order, each one bounded by HookTimeout. If one fails, the stop hooks registered
+// before it run in reverse order, even when ctx is canceled.
+func (s *Server) Start(ctx context.Context) error {
+	for i, hook := range s.startHooks {
+		if err := s.runHook(ctx, hook); err != nil {
+			err = fmt.Errorf("start hook %d failed: %w", i, err)
+			return errors.Join(err, s.stop(context.WithoutCancel(ctx), i))
+		}
+	}
+
+	return nil
+}
+
+// Shutdown gracefully stops the Fiber app, waiting for in-flight requests until ctx is done, and then runs the
+// stop hooks in reverse order, each one bounded by HookTimeout. The stop hooks don't share the deadline of ctx,
+// so they still close the components when draining the requests used it up.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	if err := s.App.ShutdownWithContext(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("app shutdown failed: %w", err))
+	}
+
+	return errors.Join(append(errs, s.stop(context.WithoutCancel(ctx), len(s.startHooks)))...)
+}
+
+// stop runs, in reverse order, the stop hooks registered before the start hook at index started, i.e. the stop
+// hooks of the components already started.
+func (s *Server) stop(ctx context.Context, started int) error {
+	var errs []error
+
+	for i := len(s.stopHooks) - 1; i >= 0; i-- {
+		if s.stopHooks[i].starts > started {
+			continue
+		}
+		if err := s.runHook(ctx, s.stopHooks[i].hook); err != nil {
+			errs = append(errs, fmt.Errorf("stop hook %d failed: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Listen runs the start hooks, starts listening on addr and blocks until SIGINT or SIGTERM is received or the
+// listener is closed, then shuts the server down gracefully, waiting up to ShutdownTimeout for in-flight requests
+// and running the stop hooks.
+//
+// Usage:
+//
+//	log.Fatal(srv.Listen(":8080"))
+func (s *Server) Listen(addr string) error {
+	if err := s.Start(context.Background()); err != nil {
+		return err
+	}
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- s.App.Listen(addr)
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-listenErr:
+		return errors.Join(err, s.shutdownWithTimeout())
+	case <-signals:
+		return s.shutdownWithTimeout()
+	}
+}
+
+func (s *Server) shutdownWithTimeout() error {
+	timeout := s.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return s.Shutdown(ctx)
+}
+
+func (s *Server) runHook(ctx context.Context, hook Hook) error {
+	timeout := s.HookTimeout
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- hook(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
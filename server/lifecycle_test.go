@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestStartFailureStopsStartedComponents(t *testing.T) {
+	srv := NewServer("test", nil)
+	var calls []string
+	record := func(name string, err error) Hook {
+		return func(context.Context) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	srv.OnStop(record("stop redis", nil))
+	srv.OnStart(record("start cache", nil))
+	srv.OnStop(record("stop cache", nil))
+	srv.OnStart(record("start consumer", errors.New("broker unavailable")))
+	srv.OnStop(record("stop consumer", nil))
+
+	if err := srv.Start(context.Background()); err == nil {
+		t.Fatal("Start succeeded, want the start hook error")
+	}
+	want := []string{"start cache", "start consumer", "stop cache", "stop redis"}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestShutdownRunsEveryStopHook(t *testing.T) {
+	srv := NewServer("test", nil)
+	var calls []string
+	srv.OnStart(func(context.Context) error { return nil })
+	srv.OnStop(func(context.Context) error { calls = append(calls, "a"); return nil })
+	srv.OnStart(func(context.Context) error { return nil })
+	srv.OnStop(func(context.Context) error { calls = append(calls, "b"); return nil })
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if !slices.Equal(calls, []string{"b", "a"}) {
+		t.Fatalf("calls = %v, want [b a]", calls)
+	}
+}
+
+func TestShutdownWithTimeoutBoundsStopHooks(t *testing.T) {
+	srv := NewServerWithConfig(ServerConfig{Name: "test", HookTimeout: 50 * time.Millisecond})
+	srv.OnStop(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	if err := srv.shutdownWithTimeout(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("shutdown took %v, want it bounded by HookTimeout", elapsed)
+	}
+}
+
+func TestShutdownRunsStopHooksAfterTheDeadline(t *testing.T) {
+	srv := NewServer("test", nil)
+	var closed []string
+	srv.OnStop(func(ctx context.Context) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		closed = append(closed, "redis")
+		return nil
+	})
+
+	// Draining the requests used up the whole ShutdownTimeout.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if !slices.Equal(closed, []string{"redis"}) {
+		t.Fatalf("closed = %v, want [redis]", closed)
+	}
+}
+
+func TestListenRunsStopHooksWhenTheListenerCloses(t *testing.T) {
+	srv := NewServer("test", nil)
+	stopped := make(chan struct{})
+	srv.OnStop(func(context.Context) error {
+		close(stopped)
+		return nil
+	})
+
+	listening := make(chan struct{})
+	srv.App.Hooks().OnListen(func(fiber.ListenData) error {
+		close(listening)
+		return nil
+	})
+
+	listenErr := make(chan error, 1)
+	go func() { listenErr <- srv.Listen("127.0.0.1:0") }()
+
+	<-listening
+	if err := srv.App.Shutdown(); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := <-listenErr; err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("stop hooks did not run")
+	}
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"context"
+	"math"
+	"net/netip"
+	"runtime"
+	"runtime/metrics"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// LoadShedConfig holds the configuration for the server load shedding middleware.
+type LoadShedConfig struct {
+	// MaxInFlight is the number of concurrent requests above which normal priority requests are shed.
+	// Defaults to 1000.
+	MaxInFlight int
+	// LowPriorityRatio is the fraction of MaxInFlight above which low priority requests are shed. Defaults to 0.5.
+	LowPriorityRatio float64
+	// CPUThreshold is the process CPU utilization (0 to 1, relative to GOMAXPROCS) above which low priority
+	// requests are shed. Defaults to 0.85.
+	CPUThreshold float64
+	// QueueLatencyThreshold is the p99 time runnable goroutines wait in the scheduler queue above which low
+	// priority requests are shed. Defaults to 100ms.
+	QueueLatencyThreshold time.Duration
+	// TrustedSources are the addresses or CIDRs (e.g. "10.0.0.0/8") of the internal callers whose X-Priority
+	// header is honored. The header of any other caller is ignored, so external clients can't mark their requests
+	// critical.
+	TrustedSources []string
+	// Routes assigns priorities to routes, for requests without a trusted X-Priority header. The first matching
+	// rule wins; other requests are PriorityNormal.
+	Routes []RoutePriority
+	// Priority resolves the priority of a request, replacing TrustedSources and Routes.
+	Priority func(c *fiber.Ctx) httpclient.Priority
+	// RetryAfter is sent in the Retry-After header of shed requests. Defaults to 1s.
+	RetryAfter time.Duration
+}
+
+// RoutePriority assigns a priority to the requests of a route.
+type RoutePriority struct {
+	// Method restricts the rule to an HTTP method. Empty matches every method.
+	Method string
+	// Route is a path pattern: path.Match syntax (e.g. "/api/products/*"), or a prefix ending with "/**".
+	Route    string
+	Priority httpclient.Priority
+}
+
+// LoadShedMiddleware sheds the less important requests when the server is overloaded, protecting core traffic.
+//
+// Parameters:
+//
+//	cfg: Load shedding configuration.
+//
+// Behavior:
+//   - The priority comes from the X-Priority header of TrustedSources, then from Routes; it is PriorityNormal
+//     otherwise.
+//   - Low priority requests are shed when in-flight requests exceed LowPriorityRatio * MaxInFlight, the
+//     process CPU utilization is above CPUThreshold or the scheduler queue latency is above QueueLatencyThreshold.
+//   - Normal priority requests are shed above MaxInFlight. Critical requests are never shed.
+//   - Shed requests get 503 with Retry-After and are counted in http.server.shed, by priority and reason.
+//   - The priority is stored in the user context (see httpclient.WithPriority), so downstream calls made by
+//     the handler carry it.
+//
+// Usage:
+//
+//	app.Use(server.LoadShedMiddleware(server.LoadShedConfig{
+//		MaxInFlight:    500,
+//		TrustedSources: []string{"10.0.0.0/8"},
+//		Routes:         []server.RoutePriority{{Route: "/api/recommendations/**", Priority: httpclient.PriorityLow}},
+//	}))
+func LoadShedMiddleware(cfg LoadShedConfig) fiber.Handler {
+	return loadShed(cfg, startLoadSampler())
+}
+
+func loadShed(cfg LoadShedConfig, load *loadSampler) fiber.Handler {
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = 1000
+	}
+	if cfg.LowPriorityRatio <= 0 || cfg.LowPriorityRatio > 1 {
+		cfg.LowPriorityRatio = 0.5
+	}
+	if cfg.CPUThreshold <= 0 {
+		cfg.CPUThreshold = 0.85
+	}
+	if cfg.QueueLatencyThreshold <= 0 {
+		cfg.QueueLatencyThreshold = 100 * time.Millisecond
+	}
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = time.Second
+	}
+	if cfg.Priority == nil {
+		cfg.Priority = requestPriority(parseTrustedProxies(cfg.TrustedSources), cfg.Routes)
+	}
+
+	lowLimit := int64(math.Max(1, float64(cfg.MaxInFlight)*cfg.LowPriorityRatio))
+
+	var inFlight atomic.Int64
+
+	shed, _ := otel.Meter(instrumentationName).Int64Counter("http.server.shed",
+		metric.WithDescription("Requests shed due to overload."))
+
+	return func(c *fiber.Ctx) error {
+		priority := cfg.Priority(c)
+
+		reason := ""
+		current := inFlight.Load()
+		switch priority {
+		case httpclient.PriorityLow:
+			if current >= lowLimit {
+				reason = "in_flight"
+			} else if load.utilization() > cfg.CPUThreshold {
+				reason = "cpu"
+			} else if load.queueLatency() > cfg.QueueLatencyThreshold {
+				reason = "queue"
+			}
+		case httpclient.PriorityNormal:
+			if current >= int64(cfg.MaxInFlight) {
+				reason = "in_flight"
+			}
+		}
+
+		if reason != "" {
+			shed.Add(c.UserContext(), 1, metric.WithAttributes(
+				attribute.String("priority", priority.String()),
+				attribute.String("reason", reason),
+			))
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(cfg.RetryAfter.Seconds()))))
+			return fiber.NewError(fiber.StatusServiceUnavailable, "server overloaded")
+		}
+
+		inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		c.SetUserContext(httpclient.WithPriority(c.UserContext(), priority))
+		return c.Next()
+	}
+}
+
+// requestPriority returns the X-Priority header of requests from trusted sources, then the priority of the first
+// matching route.
+func requestPriority(trusted []netip.Prefix, routes []RoutePriority) func(c *fiber.Ctx) httpclient.Priority {
+	return func(c *fiber.Ctx) httpclient.Priority {
+		if header := c.Get(httpclient.PriorityHeader); header != "" && isTrustedProxy(c.IP(), trusted) {
+			return httpclient.ParsePriority(header)
+		}
+
+		appCfg := c.App().Config()
+		requestPath := routingPath(appCfg, c.Path())
+		for _, route := range routes {
+			if route.Method != "" && !strings.EqualFold(route.Method, c.Method()) {
+				continue
+			}
+			if matchRoute(routePattern(appCfg, route.Route), requestPath) {
+				return route.Priority
+			}
+		}
+
+		return httpclient.PriorityNormal
+	}
+}
+
+// loadSampler estimates the CPU utilization of the process and the p99 scheduler queue latency once per second.
+// On platforms without processCPUTime support the utilization is always 0, disabling CPU based shedding.
+type loadSampler struct {
+	cpu   atomic.Uint64
+	queue atomic.Int64
+}
+
+var (
+	sharedLoadSampler     *loadSampler
+	sharedLoadSamplerOnce sync.Once
+)
+
+// startLoadSampler returns the process-wide sampler, starting it on first use.
+func startLoadSampler() *loadSampler {
+	sharedLoadSamplerOnce.Do(func() {
+		sharedLoadSampler = &loadSampler{}
+		go sharedLoadSampler.run(context.Background())
+	})
+	return sharedLoadSampler
+}
+
+func (s *loadSampler) utilization() float64 {
+	return math.Float64frombits(s.cpu.Load())
+}
+
+func (s *loadSampler) queueLatency() time.Duration {
+	return time.Duration(s.queue.Load())
+}
+
+func (s *loadSampler) run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	sample := []metrics.Sample{{Name: "/sched/latencies:seconds"}}
+	metrics.Read(sample)
+	lastCounts := histogramCounts(sample[0])
+
+	lastBusy, lastTime := processCPUTime(), time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			busy := processCPUTime()
+			capacity := now.Sub(lastTime).Seconds() * float64(runtime.GOMAXPROCS(0))
+			if capacity > 0 {
+				s.cpu.Store(math.Float64bits(math.Min(1, (busy-lastBusy).Seconds()/capacity)))
+			}
+			lastBusy, lastTime = busy, now
+
+			metrics.Read(sample)
+			if sample[0].Value.Kind() == metrics.KindFloat64Histogram {
+				histogram := sample[0].Value.Float64Histogram()
+				s.queue.Store(int64(histogramQuantile(histogram, lastCounts, 0.99)))
+				lastCounts = histogramCounts(sample[0])
+			}
+		}
+	}
+}
+
+func histogramCounts(sample metrics.Sample) []uint64 {
+	if sample.Value.Kind() != metrics.KindFloat64Histogram {
+		return nil
+	}
+	return slices.Clone(sample.Value.Float64Histogram().Counts)
+}
+
+// histogramQuantile returns the lower bound of the bucket holding quantile q of the observations made since the
+// last counts were taken.
+func histogramQuantile(histogram *metrics.Float64Histogram, last []uint64, q float64) time.Duration {
+	counts := make([]uint64, len(histogram.Counts))
+	var total uint64
+	for i, count := range histogram.Counts {
+		if i < len(last) {
+			count -= last[i]
+		}
+		counts[i] = count
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	target := uint64(math.Ceil(float64(total) * q))
+	var seen uint64
+	for i, count := range counts {
+		seen += count
+		if seen >= target {
+			lower := histogram.Buckets[i]
+			if math.IsInf(lower, -1) || lower < 0 {
+				return 0
+			}
+			return time.Duration(lower * float64(time.Second))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"runtime/metrics"
+	"testing"
+	"time"
+
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/gofiber/fiber/v2"
+)
+
+// newSaturatedApp returns an app whose single in-flight slot is taken by a blocked request, until release is closed.
+func newSaturatedApp(t *testing.T, cfg LoadShedConfig) *fiber.App {
+	t.Helper()
+
+	cfg.MaxInFlight = 1
+	entered, release := make(chan struct{}), make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	app := fiber.New()
+	app.Use(loadShed(cfg, &loadSampler{}))
+	app.Get("/block", func(c *fiber.Ctx) error {
+		close(entered)
+		<-release
+		return nil
+	})
+	app.Get("/*", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	go func() { _, _ = app.Test(httptest.NewRequest(http.MethodGet, "/block", nil), -1) }()
+	<-entered
+	return app
+}
+
+func withPriority(target, priority string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set(httpclient.PriorityHeader, priority)
+	return req
+}
+
+func TestLoadShedIgnoresUntrustedPriority(t *testing.T) {
+	app := newSaturatedApp(t, LoadShedConfig{})
+
+	if status := doStatus(t, app, withPriority("/home", "critical")); status != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503: an external caller escaped shedding with X-Priority", status)
+	}
+}
+
+func TestLoadShedHonorsTrustedPriority(t *testing.T) {
+	// app.Test requests come from 0.0.0.0.
+	app := newSaturatedApp(t, LoadShedConfig{TrustedSources: []string{"0.0.0.0/32"}})
+
+	if status := doStatus(t, app, withPriority("/home", "critical")); status != http.StatusOK {
+		t.Fatalf("status %d, want 200", status)
+	}
+}
+
+func TestLoadShedRoutePriority(t *testing.T) {
+	app := newSaturatedApp(t, LoadShedConfig{
+		Routes: []RoutePriority{{Route: "/api/playback/**", Priority: httpclient.PriorityCritical}},
+	})
+
+	for _, target := range []string{"/api/playback/1", "/Api/Playback/1/"} {
+		if status := doStatus(t, app, httptest.NewRequest(http.MethodGet, target, nil)); status != http.StatusOK {
+			t.Errorf("%s: status %d, want 200", target, status)
+		}
+	}
+	if status := doStatus(t, app, httptest.NewRequest(http.MethodGet, "/api/home", nil)); status != http.StatusServiceUnavailable {
+		t.Errorf("/api/home: status %d, want 503", status)
+	}
+}
+
+func TestLoadShedOnQueuePressure(t *testing.T) {
+	load := &loadSampler{}
+	app := fiber.New()
+	app.Use(loadShed(LoadShedConfig{
+		QueueLatencyThreshold: 50 * time.Millisecond,
+		Routes:                []RoutePriority{{Route: "/prefetch", Priority: httpclient.PriorityLow}},
+	}, load))
+	app.Get("/*", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	if status := doStatus(t, app, httptest.NewRequest(http.MethodGet, "/prefetch", nil)); status != http.StatusOK {
+		t.Fatalf("status %d, want 200 without pressure", status)
+	}
+
+	load.queue.Store(int64(80 * time.Millisecond))
+	if status := doStatus(t, app, httptest.NewRequest(http.MethodGet, "/prefetch", nil)); status != http.StatusServiceUnavailable {
+		t.Fatalf("low priority status %d, want 503 under queue pressure", status)
+	}
+	if status := doStatus(t, app, httptest.NewRequest(http.MethodGet, "/home", nil)); status != http.StatusOK {
+		t.Fatalf("normal priority status %d, want 200", status)
+	}
+}
+
+func TestHistogramQuantileSinceLastSample(t *testing.T) {
+	histogram := &metrics.Float64Histogram{
+		Counts:  []uint64{100, 10, 5},
+		Buckets: []float64{math.Inf(-1), 0.001, 0.1, math.Inf(1)},
+	}
+
+	// Since the last sample, 2 observations took 1-100ms and 5 took over 100ms.
+	if got := histogramQuantile(histogram, []uint64{100, 8, 0}, 0.99); got != 100*time.Millisecond {
+		t.Fatalf("p99 = %v, want 100ms", got)
+	}
+	if got := histogramQuantile(histogram, histogram.Counts, 0.99); got != 0 {
+		t.Fatalf("p99 without observations = %v, want 0", got)
+	}
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Locale represents the language and country resolved for the current request.
+type Locale struct {
+	// Tag is the resolved BCP 47 language tag, e.g. "pt-BR".
+	Tag      string `json:"tag"`
+	Language string `json:"language"`
+	Region   string `json:"region"`
+	// Country is the forwarded x-country-code, which may differ from the language region.
+	Country string `json:"country"`
+}
+
+type LocaleKeyType struct{}
+
+// LocaleConfig holds the configuration for the locale middleware.
+type LocaleConfig struct {
+	// Supported lists the tags the application can serve, e.g. []string{"pt-BR", "en-US", "es"}.
+	Supported []string
+	// Default is used when no candidate matches. If empty, uses the first supported tag.
+	Default string
+	// Fallbacks maps a tag to the tag to try next, e.g. {"pt-PT": "pt-BR"}.
+	Fallbacks map[string]string
+	// CountryHeader is the header carrying the country code. If empty, uses x-country-code.
+	CountryHeader string
+}
+
+// LocaleMiddleware resolves a typed Locale from Accept-Language and the forwarded country code.
+//
+// Parameters:
+//
+//	cfg: Locale configuration (supported tags, default and fallback chains).
+//
+// Behavior:
+//   - Candidates from Accept-Language are tried in quality order; tags with q=0 were refused by the client and
+//     are never selected, and qualities above 1 count as 1.
+//   - A candidate without region is combined with the country code first (e.g. "pt" + "BR" = "pt-BR").
+//   - Each candidate follows its fallback chain, then its base language, before trying the next one.
+//   - When nothing matches, uses Default.
+//   - The resolved tag is added to the forwarded headers as "accept-language" for downstream calls.
+//
+// Usage:
+//
+//	app.Use(LocaleMiddleware(LocaleConfig{Supported: []string{"pt-BR", "en-US"}, Default: "pt-BR"}))
+//
+//	// In a handler:
+//	locale, _ := LocaleFromContext(c.UserContext())
+func LocaleMiddleware(cfg LocaleConfig) fiber.Handler {
+	countryHeader := cfg.countryHeader()
+
+	defaultTag := cfg.Default
+	if defaultTag == "" && len(cfg.Supported) > 0 {
+		defaultTag = cfg.Supported[0]
+	}
+
+	supported := make(map[string]string, len(cfg.Supported))
+	for _, tag := range cfg.Supported {
+		supported[strings.ToLower(tag)] = tag
+	}
+
+	return func(c *fiber.Ctx) error {
+		country := strings.ToUpper(c.Get(countryHeader))
+		tag := resolveLocaleTag(parseAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage)), country, supported, cfg.Fallbacks)
+		if tag == "" {
+			tag = defaultTag
+		}
+
+		setLocale(c, newLocale(tag, country))
+		return c.Next()
+	}
+}
+
+// LocaleOverride forces the locale of a route or group, keeping the resolved country. Without a Locale from
+// LocaleMiddleware, the country is read from x-country-code; use LocaleOverrideWithConfig for another header.
+//
+// Usage:
+//
+//	app.Get("/br/home", LocaleOverride("pt-BR"), handler)
+func LocaleOverride(tag string) fiber.Handler {
+	return LocaleOverrideWithConfig(tag, LocaleConfig{})
+}
+
+// LocaleOverrideWithConfig behaves like LocaleOverride, reading the country from cfg.CountryHeader when no Locale
+// was resolved. Pass the LocaleConfig of LocaleMiddleware.
+//
+// Usage:
+//
+//	cfg := LocaleConfig{Supported: []string{"pt-BR", "en-US"}, CountryHeader: "cf-ipcountry"}
+//	app.Use(LocaleMiddleware(cfg))
+//	app.Get("/br/home", LocaleOverrideWithConfig("pt-BR", cfg), handler)
+func LocaleOverrideWithConfig(tag string, cfg LocaleConfig) fiber.Handler {
+	countryHeader := cfg.countryHeader()
+
+	return func(c *fiber.Ctx) error {
+		country := strings.ToUpper(c.Get(countryHeader))
+		if current, ok := LocaleFromContext(c.UserContext()); ok {
+			country = current.Country
+		}
+
+		setLocale(c, newLocale(tag, country))
+		return c.Next()
+	}
+}
+
+func (cfg LocaleConfig) countryHeader() string {
+	if cfg.CountryHeader == "" {
+		return "x-country-code"
+	}
+	return cfg.CountryHeader
+}
+
+// LocaleFromContext returns the locale stored by LocaleMiddleware, if any.
+func LocaleFromContext(ctx context.Context) (Locale, bool) {
+	locale, ok := ctx.Value(LocaleKeyType{}).(Locale)
+	return locale, ok
+}
+
+func setLocale(c *fiber.Ctx, locale Locale) {
+	ctx := context.WithValue(c.UserContext(), LocaleKeyType{}, locale)
+
+	if headers, ok := ctx.Value("forwardedHeaders").(map[string]string); ok && locale.Tag != "" {
+		headers["accept-language"] = locale.Tag
+	}
+
+	c.SetUserContext(ctx)
+	c.Locals("locale", locale)
+}
+
+func newLocale(tag, country string) Locale {
+	language, region, _ := strings.Cut(tag, "-")
+
+	locale := Locale{
+		Tag:      tag,
+		Language: strings.ToLower(language),
+		Region:   strings.ToUpper(region),
+		Country:  country,
+	}
+
+	if locale.Region == "" {
+		locale.Region = country
+	}
+
+	return locale
+}
+
+func resolveLocaleTag(candidates []string, country string, supported map[string]string, fallbacks map[string]string) string {
+	for _, candidate := range candidates {
+		language, region, _ := strings.Cut(candidate, "-")
+
+		chain := []string{}
+		if region == "" && country != "" {
+			chain = append(chain, language+"-"+country)
+		}
+		chain = append(chain, candidate)
+
+		for next, seen := fallbacks[candidate], 0; next != "" && seen < 10; next, seen = fallbacks[next], seen+1 {
+			chain = append(chain, next)
+		}
+
+		chain = append(chain, language)
+
+		for _, tag := range chain {
+			if match, ok := supported[strings.ToLower(tag)]; ok {
+				return match
+			}
+		}
+	}
+
+	return ""
+}
+
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var items []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil && !math.IsNaN(parsed) {
+				q = min(parsed, 1)
+			}
+		}
+		// q=0 means "not acceptable" (RFC 9110, section 12.4.2), so the tag is never a candidate.
+		if q <= 0 {
+			continue
+		}
+
+		items = append(items, weighted{tag: tag, q: q})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].q > items[j].q
+	})
+
+	tags := make([]string, 0, len(items))
+	for _, item := range items {
+		tags = append(tags, item.tag)
+	}
+
+	return tags
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func overrideLocale(t *testing.T, handlers ...fiber.Handler) Locale {
+	t.Helper()
+
+	var locale Locale
+	app := fiber.New()
+	handlers = append(handlers, func(c *fiber.Ctx) error {
+		locale, _ = LocaleFromContext(c.UserContext())
+		return nil
+	})
+	app.Get("/br/home", handlers...)
+
+	req := httptest.NewRequest(http.MethodGet, "/br/home", nil)
+	req.Header.Set("cf-ipcountry", "pt")
+	req.Header.Set("x-country-code", "us")
+	doStatus(t, app, req)
+	return locale
+}
+
+func TestLocaleOverrideUsesConfiguredCountryHeader(t *testing.T) {
+	cfg := LocaleConfig{Supported: []string{"pt-BR"}, CountryHeader: "cf-ipcountry"}
+
+	if locale := overrideLocale(t, LocaleOverrideWithConfig("pt-BR", cfg)); locale.Tag != "pt-BR" || locale.Country != "PT" {
+		t.Fatalf("got %+v, want pt-BR in PT", locale)
+	}
+	if locale := overrideLocale(t, LocaleMiddleware(cfg), LocaleOverride("pt-BR")); locale.Country != "PT" {
+		t.Fatalf("got %+v, want the country resolved by LocaleMiddleware", locale)
+	}
+	if locale := overrideLocale(t, LocaleOverride("pt-BR")); locale.Country != "US" {
+		t.Fatalf("got %+v, want the default x-country-code", locale)
+	}
+}
+
+func TestLocaleSkipsRefusedLanguages(t *testing.T) {
+	app := fiber.New()
+	app.Use(LocaleMiddleware(LocaleConfig{Supported: []string{"pt-BR", "en-US"}, Default: "pt-BR"}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		locale, _ := LocaleFromContext(c.UserContext())
+		return c.SendString(locale.Tag)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderAcceptLanguage, "en-US;q=0, fr")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "pt-BR" {
+		t.Fatalf("got %s, want the default instead of the refused en-US", body)
+	}
+}
+
+func TestParseAcceptLanguageQualities(t *testing.T) {
+	cases := map[string][]string{
+		"en-US;q=0, pt-BR;q=0.5":   {"pt-BR"},
+		"es;q=-1, fr;q=0.0":        {},
+		"en-US, pt-BR;q=5":         {"en-US", "pt-BR"},
+		"de;q=0.2, it, fr;q=0.8":   {"it", "fr", "de"},
+		"pt-BR;q=abc, en-US;q=0.9": {"pt-BR", "en-US"},
+	}
+	for header, want := range cases {
+		if got := parseAcceptLanguage(header); !slices.Equal(got, want) {
+			t.Errorf("%q: got %v, want %v", header, got, want)
+		}
+	}
+}
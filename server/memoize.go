@@ -0,0 +1,26 @@
+package server
+
+import (
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/gofiber/fiber/v2"
+)
+
+// MemoizeMiddleware scopes the memoization of downstream calls to the incoming request.
+//
+// Behavior:
+//   - Stores a memoization scope in the user context (see httpclient.WithMemoization), so clients with
+//     httpclient.NewMemoizationMiddleware make a single call per URL while the request is handled.
+//   - Clears the memoized responses when the request ends.
+//
+// Usage:
+//
+//	app.Use(server.MemoizeMiddleware())
+func MemoizeMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, clear := httpclient.WithMemoization(c.UserContext())
+		defer clear()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Migration converts payloads between a version and the one before it.
+type Migration struct {
+	// Version is the version introduced by the migration. Clients on earlier versions go through it.
+	Version string
+	// Routes restricts the migration to path patterns ("*" matches one segment, a trailing "/**" any suffix).
+	// Empty applies it to every route.
+	Routes []string
+	// Upgrade turns a request body of the previous version into this version's shape.
+	Upgrade Transform
+	// Downgrade turns a response body of this version into the previous version's shape.
+	Downgrade Transform
+}
+
+// MigrationConfig holds the API versions and the migrations between them.
+type MigrationConfig struct {
+	// Versions lists the API versions, oldest first, e.g. []string{"v1", "v2", "v3"}.
+	Versions []string
+	// Default is the version of requests without the version header. If empty, uses the latest version.
+	Default string
+	// Header carries the client version. If empty, uses X-API-Version.
+	Header     string
+	Migrations []Migration
+}
+
+type APIVersionKeyType struct{}
+
+// MigrationMiddleware transparently upgrades the JSON request bodies of old clients to the latest API version
+// before they reach the handlers, and downgrades the JSON responses on the way out, so handlers only know the
+// latest shape during long deprecation windows.
+//
+// Parameters:
+//
+//	cfg: Versions, oldest first, and the migrations between them.
+//
+// Behavior:
+//   - The client version comes from the version header; unknown versions are rejected with 400.
+//   - Request bodies go through the Upgrade of every newer migration, oldest first; successful JSON responses go
+//     through the Downgrade of the same migrations, newest first.
+//   - Bodies that are not JSON are passed through untouched.
+//   - The client version is available to handlers with APIVersionFromContext.
+//
+// Usage:
+//
+//	app.Use(server.MigrationMiddleware(server.MigrationConfig{
+//		Versions: []string{"v1", "v2"},
+//		Migrations: []server.Migration{{
+//			Version:   "v2",
+//			Routes:    []string{"/api/products/**"},
+//			Upgrade:   server.RenameFields(map[string]string{"titulo": "title"}),
+//			Downgrade: server.RenameFields(map[string]string{"title": "titulo"}),
+//		}},
+//	}))
+func MigrationMiddleware(cfg MigrationConfig) fiber.Handler {
+	if cfg.Header == "" {
+		cfg.Header = "X-API-Version"
+	}
+	if cfg.Default == "" && len(cfg.Versions) > 0 {
+		cfg.Default = cfg.Versions[len(cfg.Versions)-1]
+	}
+
+	migrations := slices.Clone(cfg.Migrations)
+	slices.SortStableFunc(migrations, func(a, b Migration) int {
+		return slices.Index(cfg.Versions, a.Version) - slices.Index(cfg.Versions, b.Version)
+	})
+
+	return func(c *fiber.Ctx) error {
+		version := c.Get(cfg.Header)
+		if version == "" {
+			version = cfg.Default
+		}
+
+		current := slices.Index(cfg.Versions, version)
+		if current < 0 {
+			return Errorf(CodeInvalidArgument, "unsupported API version %q", version)
+		}
+
+		c.SetUserContext(context.WithValue(c.UserContext(), APIVersionKeyType{}, version))
+		c.Locals("apiVersion", version)
+
+		var pending []Migration
+		for _, migration := range migrations {
+			if slices.Index(cfg.Versions, migration.Version) > current && migration.matches(c.Path()) {
+				pending = append(pending, migration)
+			}
+		}
+
+		if len(pending) == 0 {
+			return c.Next()
+		}
+
+		if body, ok := decodeJSON(c.Body(), string(c.Request().Header.ContentType())); ok {
+			for _, migration := range pending {
+				if migration.Upgrade != nil {
+					body = migration.Upgrade(c, body)
+				}
+			}
+			if upgraded, err := json.Marshal(body); err == nil {
+				c.Request().SetBody(upgraded)
+			}
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status < 200 || status >= 300 {
+			return nil
+		}
+
+		body, ok := decodeJSON(c.Response().Body(), string(c.Response().Header.ContentType()))
+		if !ok {
+			return nil
+		}
+
+		for i := len(pending) - 1; i >= 0; i-- {
+			if pending[i].Downgrade != nil {
+				body = pending[i].Downgrade(c, body)
+			}
+		}
+
+		if downgraded, err := json.Marshal(body); err == nil {
+			c.Response().SetBodyRaw(downgraded)
+		}
+		return nil
+	}
+}
+
+func (m Migration) matches(requestPath string) bool {
+	if len(m.Routes) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(m.Routes, func(route string) bool { return matchRoute(route, requestPath) })
+}
+
+func decodeJSON(data []byte, contentType string) (any, bool) {
+	if len(data) == 0 || !strings.HasPrefix(contentType, fiber.MIMEApplicationJSON) {
+		return nil, false
+	}
+
+	var body any
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+// APIVersionFromContext returns the API version of the client resolved by MigrationMiddleware, if any.
+func APIVersionFromContext(ctx context.Context) (string, bool) {
+	version, ok := ctx.Value(APIVersionKeyType{}).(string)
+	return version, ok
+}
@@ -0,0 +1,258 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// ErrInvalidCursor is returned by Paginator.DecodeCursor when the cursor was tampered with, signed with an unknown
+// key or expired.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PaginationConfig holds the configuration of a Paginator. It can be loaded with the config package; Keys are
+// usually set from the secrets provider.
+type PaginationConfig struct {
+	// Keys sign the cursors with HMAC-SHA256, current key first. Every key is tried when verifying, so keys can be
+	// rotated without invalidating the cursors already issued.
+	Keys [][]byte `json:"-"`
+	// DefaultLimit is the page size when the request has no limit. Defaults to 20.
+	DefaultLimit int `json:"default_limit" env:"PAGINATION_DEFAULT_LIMIT" default:"20"`
+	// MaxLimit is the largest page size a request may ask for. Defaults to 100.
+	MaxLimit int `json:"max_limit" env:"PAGINATION_MAX_LIMIT" default:"100"`
+	// TTL expires the cursors, so clients can't keep paging on stale snapshots forever. 0 never expires them.
+	TTL time.Duration `json:"ttl" env:"PAGINATION_TTL"`
+	// CursorParam and LimitParam are the query parameters of the cursor and the page size. Default to "cursor" and
+	// "limit".
+	CursorParam string `json:"cursor_param" env:"PAGINATION_CURSOR_PARAM" default:"cursor"`
+	LimitParam  string `json:"limit_param" env:"PAGINATION_LIMIT_PARAM" default:"limit"`
+}
+
+// Cursor is the position of a page in a list: the sort keys of the item the page starts after (or before, when
+// Backward is set). It is opaque to clients.
+type Cursor struct {
+	// Keys are the sort keys of the boundary item, e.g. {"created_at": "2024-05-01T10:00:00Z", "id": "42"}.
+	Keys map[string]string `json:"k"`
+	// Backward means the page ends before the boundary item (a "previous" cursor).
+	Backward bool `json:"b,omitempty"`
+	// IssuedAt is the Unix time the cursor was encoded, checked against TTL.
+	IssuedAt int64 `json:"t"`
+}
+
+// PageRequest is the page asked by a request.
+type PageRequest struct {
+	// Cursor is nil for the first page.
+	Cursor *Cursor
+	Limit  int
+}
+
+// Paginator encodes and decodes opaque cursors and builds the pagination metadata of cursor paginated lists.
+type Paginator struct {
+	cfg PaginationConfig
+}
+
+// NewPaginator creates a Paginator.
+//
+// Parameters:
+//
+//	cfg: Pagination configuration.
+//	  - Keys: HMAC keys, current key first. Keys should have at least 32 bytes.
+//	  - DefaultLimit and MaxLimit: Page size bounds.
+//
+// Usage:
+//
+//	pager := server.NewPaginator(server.PaginationConfig{Keys: [][]byte{current, previous}})
+//
+//	app.Get("/products", func(c *fiber.Ctx) error {
+//		page, err := pager.Page(c)
+//		if err != nil {
+//			return err
+//		}
+//		items, next := repo.List(c.UserContext(), page.Cursor, page.Limit) // next is nil on the last page
+//		pagination, err := pager.Pagination(c, next, nil)
+//		if err != nil {
+//			return err
+//		}
+//		return server.OK(c, items, &server.ResponseMeta{Pagination: pagination})
+//	})
+func NewPaginator(cfg PaginationConfig) *Paginator {
+	if cfg.DefaultLimit <= 0 {
+		cfg.DefaultLimit = 20
+	}
+	if cfg.MaxLimit <= 0 {
+		cfg.MaxLimit = 100
+	}
+	if cfg.DefaultLimit > cfg.MaxLimit {
+		cfg.DefaultLimit = cfg.MaxLimit
+	}
+	if cfg.CursorParam == "" {
+		cfg.CursorParam = "cursor"
+	}
+	if cfg.LimitParam == "" {
+		cfg.LimitParam = "limit"
+	}
+
+	return &Paginator{cfg: cfg}
+}
+
+// Page reads the cursor and the page size of the request.
+//
+// Returns:
+//   - PageRequest: The decoded cursor (nil on the first page) and the page size, DefaultLimit when not asked.
+//   - error: An *Error with CodeInvalidArgument (400) when the limit is not between 1 and MaxLimit or the cursor is
+//     invalid or expired.
+func (p *Paginator) Page(c *fiber.Ctx) (PageRequest, error) {
+	page := PageRequest{Limit: p.cfg.DefaultLimit}
+
+	if raw := c.Query(p.cfg.LimitParam); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > p.cfg.MaxLimit {
+			return PageRequest{}, Errorf(CodeInvalidArgument, "%s must be between 1 and %d", p.cfg.LimitParam, p.cfg.MaxLimit)
+		}
+		page.Limit = limit
+	}
+
+	if raw := c.Query(p.cfg.CursorParam); raw != "" {
+		cursor, err := p.DecodeCursor(raw)
+		if err != nil {
+			return PageRequest{}, Errorf(CodeInvalidArgument, "invalid %s: %w", p.cfg.CursorParam, err)
+		}
+		page.Cursor = &cursor
+	}
+
+	return page, nil
+}
+
+// EncodeCursor signs cursor with the current key into an opaque, URL-safe string.
+func (p *Paginator) EncodeCursor(cursor Cursor) (string, error) {
+	if len(p.cfg.Keys) == 0 {
+		return "", errors.New("no pagination keys configured")
+	}
+
+	cursor.IssuedAt = time.Now().Unix()
+	value, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(value)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(cursorMAC(p.cfg.Keys[0], payload)), nil
+}
+
+// DecodeCursor verifies and decodes a cursor encoded by EncodeCursor. It returns ErrInvalidCursor when the cursor
+// fails verification with every key or is older than TTL.
+func (p *Paginator) DecodeCursor(raw string) (Cursor, error) {
+	payload, signature, ok := strings.Cut(raw, ".")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	verified := false
+	for _, key := range p.cfg.Keys {
+		if hmac.Equal(mac, cursorMAC(key, payload)) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	value, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(value, &cursor); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	if p.cfg.TTL > 0 && time.Since(time.Unix(cursor.IssuedAt, 0)) > p.cfg.TTL {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// Pagination encodes the cursors of the next and previous pages, sets the Link header (RFC 8288) with their URLs
+// and returns the Pagination block of the response meta.
+//
+// Parameters:
+//   - c: Fiber context of the list request.
+//   - next: Cursor of the next page, nil on the last page.
+//   - prev: Cursor of the previous page, nil on the first page.
+//
+// Behavior:
+//   - The links keep the query of the request, replacing the cursor, so filters and the page size carry over.
+//   - HasMore is set when there is a next page.
+func (p *Paginator) Pagination(c *fiber.Ctx, next, prev *Cursor) (*Pagination, error) {
+	pagination := &Pagination{PerPage: p.limit(c), HasMore: next != nil}
+	var links []string
+
+	if next != nil {
+		encoded, err := p.EncodeCursor(*next)
+		if err != nil {
+			return nil, err
+		}
+		pagination.NextCursor = encoded
+		links = append(links, `<`+p.pageURL(c, encoded)+`>; rel="next"`)
+	}
+
+	if prev != nil {
+		backward := *prev
+		backward.Backward = true
+		encoded, err := p.EncodeCursor(backward)
+		if err != nil {
+			return nil, err
+		}
+		pagination.PrevCursor = encoded
+		links = append(links, `<`+p.pageURL(c, encoded)+`>; rel="prev"`)
+	}
+
+	if len(links) > 0 {
+		c.Set(fiber.HeaderLink, strings.Join(links, ", "))
+	}
+
+	return pagination, nil
+}
+
+// limit returns the page size of the request, DefaultLimit when it is missing or invalid.
+func (p *Paginator) limit(c *fiber.Ctx) int {
+	limit, err := strconv.Atoi(c.Query(p.cfg.LimitParam))
+	if err != nil || limit < 1 || limit > p.cfg.MaxLimit {
+		return p.cfg.DefaultLimit
+	}
+	return limit
+}
+
+// pageURL returns the URL of the request with the cursor query parameter replaced by cursor.
+func (p *Paginator) pageURL(c *fiber.Ctx, cursor string) string {
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+
+	c.Context().QueryArgs().CopyTo(args)
+	args.Set(p.cfg.CursorParam, cursor)
+
+	return c.BaseURL() + c.Path() + "?" + args.String()
+}
+
+func cursorMAC(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("cursor|" + payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errUnsatisfiableRange is returned by parseRange for ranges starting after the end of the content.
+var errUnsatisfiableRange = errors.New("range not satisfiable")
+
+// ContentInfo describes the content served by ServeContent.
+type ContentInfo struct {
+	// ContentType defaults to application/octet-stream.
+	ContentType string
+	// ETag is a validator of the content, e.g. `"v42"`. Weak ETags never match If-Range.
+	ETag string
+	// LastModified is the modification time of the content. Zero means unknown.
+	LastModified time.Time
+	// Size is the length of the content. When zero or negative, it is found by seeking to the end of the content.
+	Size int64
+}
+
+// ServeContent sends content, honouring single byte ranges (RFC 9110, formerly RFC 7233) so clients resume
+// downloads and players seek in large blobs without transferring them entirely.
+//
+// Parameters:
+//   - c: Fiber context.
+//   - content: The content, read from the start of the range until its end. When it is an io.Closer, it is closed
+//     once sent.
+//   - info: Content type, validators and size of the content.
+//
+// Behavior:
+//   - Sets Accept-Ranges, Content-Type and, when given, ETag and Last-Modified.
+//   - Conditional GET and HEAD requests are answered with 304 Not Modified (see LastModifiedMiddleware).
+//   - A satisfiable "Range: bytes=..." of GET requests gets 206 Partial Content with Content-Range, streaming only
+//     the range; a range starting after the end gets 416 with "Content-Range: bytes */<size>".
+//   - Multiple ranges, malformed ranges and ranges failing If-Range (compared with ETag, strongly, or
+//     Last-Modified) are ignored and the whole content is sent with 200.
+//
+// Usage:
+//
+//	app.Get("/videos/:id", func(c *fiber.Ctx) error {
+//		file, err := os.Open(videoPath(c.Params("id")))
+//		if err != nil {
+//			return fiber.ErrNotFound
+//		}
+//		stat, _ := file.Stat()
+//		return server.ServeContent(c, file, server.ContentInfo{
+//			ContentType:  "video/mp4",
+//			LastModified: stat.ModTime(),
+//			Size:         stat.Size(),
+//		})
+//	})
+//
+// Returns:
+//   - error: When the content can't be sought.
+func ServeContent(c *fiber.Ctx, content io.ReadSeeker, info ContentInfo) error {
+	size := info.Size
+	if size <= 0 {
+		end, err := content.Seek(0, io.SeekEnd)
+		if err != nil {
+			return fmt.Errorf("failed to find the content size: %w", err)
+		}
+		size = end
+	}
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	if info.ETag != "" {
+		c.Set(fiber.HeaderETag, info.ETag)
+	}
+	if !info.LastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, info.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c) {
+		closeContent(content)
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	start, length := int64(0), size
+	if r, ok := requestedRange(c); ok {
+		var err error
+		start, length, err = parseRange(r, size)
+		switch {
+		case errors.Is(err, errUnsatisfiableRange):
+			closeContent(content)
+			c.Set(fiber.HeaderContentRange, "bytes */"+strconv.FormatInt(size, 10))
+			return c.SendStatus(fiber.StatusRequestedRangeNotSatisfiable)
+		case err != nil:
+			start, length = 0, size
+		default:
+			c.Set(fiber.HeaderContentRange, contentRange(start, length, size))
+			c.Status(fiber.StatusPartialContent)
+		}
+	}
+
+	if _, err := content.Seek(start, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek the content: %w", err)
+	}
+
+	var body io.Reader = io.LimitReader(content, length)
+	if closer, ok := content.(io.Closer); ok {
+		body = readCloser{Reader: body, Closer: closer}
+	}
+	c.Context().SetBodyStream(body, int(length))
+	return nil
+}
+
+// RangeMiddleware honours single byte ranges for the in-memory bodies of GET responses, such as generated
+// reports, with the rules of ServeContent. Use ServeContent for content too large to hold in memory.
+//
+// Behavior:
+//   - Only 200 responses with an in-memory body are sliced; streamed bodies and other statuses pass through.
+//   - Accept-Ranges is set on every 200 GET response it handles; If-Range is compared with the ETag and
+//     Last-Modified set by the handler.
+//
+// Usage:
+//
+//	app.Get("/reports/:id.csv", server.RangeMiddleware(), downloadReport)
+func RangeMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet {
+			return c.Next()
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		resp := c.Response()
+		if resp.StatusCode() != fiber.StatusOK || resp.IsBodyStream() {
+			return nil
+		}
+		c.Set(fiber.HeaderAcceptRanges, "bytes")
+
+		r, ok := requestedRange(c)
+		if !ok {
+			return nil
+		}
+
+		body := resp.Body()
+		size := int64(len(body))
+		start, length, err := parseRange(r, size)
+		switch {
+		case errors.Is(err, errUnsatisfiableRange):
+			resp.ResetBody()
+			c.Set(fiber.HeaderContentRange, "bytes */"+strconv.FormatInt(size, 10))
+			c.Status(fiber.StatusRequestedRangeNotSatisfiable)
+		case err == nil:
+			part := append([]byte(nil), body[start:start+length]...)
+			resp.SetBodyRaw(part)
+			c.Set(fiber.HeaderContentRange, contentRange(start, length, size))
+			c.Status(fiber.StatusPartialContent)
+		}
+		return nil
+	}
+}
+
+// requestedRange returns the Range header of a GET request, unless If-Range does not match the response validators.
+func requestedRange(c *fiber.Ctx) (string, bool) {
+	r := c.Get(fiber.HeaderRange)
+	if r == "" || c.Method() != fiber.MethodGet {
+		return "", false
+	}
+
+	ifRange := c.Get(fiber.HeaderIfRange)
+	if ifRange == "" {
+		return r, true
+	}
+
+	if strings.HasPrefix(ifRange, `"`) {
+		etag := string(c.Response().Header.Peek(fiber.HeaderETag))
+		return r, etag != "" && !strings.HasPrefix(etag, "W/") && etag == ifRange
+	}
+
+	since, err := http.ParseTime(ifRange)
+	if err != nil {
+		return "", false
+	}
+	lastModified, err := http.ParseTime(string(c.Response().Header.Peek(fiber.HeaderLastModified)))
+	return r, err == nil && lastModified.Equal(since)
+}
+
+// parseRange parses a single "bytes=" range of content of size bytes, returning its start and length.
+// Multiple and malformed ranges are errors, as are ranges starting after the end (errUnsatisfiableRange).
+func parseRange(header string, size int64) (int64, int64, error) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, errors.New("unsupported range")
+	}
+
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, 0, errors.New("invalid range")
+	}
+
+	if first == "" {
+		// Suffix range: the last bytes of the content.
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, errors.New("invalid range")
+		}
+		if n == 0 || size == 0 {
+			return 0, 0, errUnsatisfiableRange
+		}
+		n = min(n, size)
+		return size - n, n, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, errors.New("invalid range")
+	}
+	if start >= size {
+		return 0, 0, errUnsatisfiableRange
+	}
+
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, errors.New("invalid range")
+		}
+		end = min(end, size-1)
+	}
+	return start, end - start + 1, nil
+}
+
+func contentRange(start, length, size int64) string {
+	return "bytes " + strconv.FormatInt(start, 10) + "-" + strconv.FormatInt(start+length-1, 10) + "/" + strconv.FormatInt(size, 10)
+}
+
+// readCloser closes the content once fasthttp has sent the limited body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func closeContent(content io.ReadSeeker) {
+	if closer, ok := content.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/devluispereira/go-package/errorreport"
+	"github.com/devluispereira/go-package/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+// reportedHeaders are the request headers attached to panic reports. Credentials and cookies are never sent.
+var reportedHeaders = []string{"User-Agent", "Referer", "Content-Type", "Accept", "X-Forwarded-For"}
+
+// RecoverMiddleware turns panics in handlers into 500 responses instead of crashing the process, logging them
+// and reporting them to the errorreport Reporter.
+//
+// Behavior:
+//   - The panic is logged with its stack and answered as CodeInternal by ErrorHandler, with the request ID.
+//   - The report carries the method, URL, a safe subset of the request headers, the route and the request ID; the
+//     forwarded headers, trace IDs and release are attached by errorreport.Capture.
+//   - NewServer installs it first, so it covers every other middleware.
+//
+// Usage:
+//
+//	app.Use(server.RecoverMiddleware())
+func RecoverMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			stack := debug.Stack()
+			requestID := RequestID(c)
+
+			log := logging.FromContext(c.UserContext())
+			log.Error().
+				Str("request_id", requestID).
+				Str("method", c.Method()).
+				Str("path", c.Path()).
+				Interface("panic", recovered).
+				Bytes("stack", stack).
+				Msg("handler panicked")
+
+			headers := make(map[string]string, len(reportedHeaders))
+			for _, name := range reportedHeaders {
+				if value := c.Get(name); value != "" {
+					headers[name] = value
+				}
+			}
+
+			cause, ok := recovered.(error)
+			if !ok {
+				cause = fmt.Errorf("%v", recovered)
+			}
+
+			errorreport.Capture(c.UserContext(), errorreport.Event{
+				Err:     cause,
+				Message: fmt.Sprintf("panic: %v", recovered),
+				Level:   errorreport.LevelFatal,
+				Panic:   true,
+				Stack:   stack,
+				Tags:    map[string]string{"component": "server", "route": c.Route().Path, "request_id": requestID},
+				Request: &errorreport.Request{Method: c.Method(), URL: c.BaseURL() + c.OriginalURL(), Headers: headers},
+			})
+
+			err = &Error{Code: CodeInternal, Message: "internal server error", Err: cause}
+		}()
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,299 @@
+package server
+
+import (
+	"context"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/clock"
+	"github.com/devluispereira/go-package/settings"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RoutePolicy declares the operational policy of the routes matching Route.
+type RoutePolicy struct {
+	// Route is a path pattern: "*" matches one segment and a trailing "/**" matches any suffix, e.g. "/api/reports/**".
+	Route string `json:"route"`
+	// Method restricts the policy to an HTTP method. Empty matches every method.
+	Method string `json:"method"`
+	// Timeout sets the deadline of the handler context.
+	Timeout time.Duration `json:"timeout"`
+	// MaxBody rejects request bodies larger than MaxBody bytes with 413.
+	MaxBody int `json:"max_body"`
+	// RateLimitTier names an entry of RoutePolicyConfig.RateLimitTiers.
+	RateLimitTier string `json:"rate_limit_tier"`
+	// CacheTTL sets "Cache-Control: public, max-age=<CacheTTL>" on successful responses without Cache-Control.
+	CacheTTL time.Duration `json:"cache_ttl"`
+	// Auth requires RoutePolicyConfig.Authenticate to accept the request.
+	Auth bool `json:"auth"`
+}
+
+// RateLimitTier allows Requests per Window for each client (see RoutePolicyConfig.RateLimitKey).
+type RateLimitTier struct {
+	Requests int           `json:"requests"`
+	Window   time.Duration `json:"window"`
+}
+
+// Authenticator validates the credentials of a request, returning an error (e.g. fiber.ErrUnauthorized) to reject it.
+type Authenticator func(c *fiber.Ctx) error
+
+// RoutePolicyConfig holds the route policies. It can be loaded with the config package from a JSON/YAML file.
+type RoutePolicyConfig struct {
+	Policies       []RoutePolicy            `json:"policies"`
+	RateLimitTiers map[string]RateLimitTier `json:"rate_limit_tiers"`
+	// Authenticate is called for the routes whose policy has Auth set.
+	Authenticate Authenticator `json:"-"`
+	// TrustedProxies are the addresses or CIDRs (e.g. "10.0.0.0/8") of the proxies and CDNs in front of the
+	// service. Requests from them are rate limited by the client address they append to X-Forwarded-For, instead
+	// of the proxy address.
+	TrustedProxies []string `json:"trusted_proxies" env:"ROUTE_POLICY_TRUSTED_PROXIES"`
+	// RateLimitKey identifies the client of a request for rate limiting. Defaults to the subject of the
+	// authenticated claims (see ClaimsFromContext), or the client address, within the tenant of the request (see
+	// TenantFromContext).
+	RateLimitKey func(c *fiber.Ctx) string `json:"-"`
+	// Clock times the rate limit windows. Defaults to clock.Real.
+	Clock clock.Clock `json:"-"`
+}
+
+// RoutePolicyMiddleware applies the route policies declared in configuration, keeping timeouts, body limits,
+// rate limits, cache TTLs and authentication requirements out of handler code. NewServerWithConfig installs it
+// when ServerConfig.RoutePolicies has policies.
+//
+// Parameters:
+//
+//	cfg: Route policies, usually loaded with config.Load.
+//
+// Behavior:
+//   - The first policy matching the request method and path wins. Paths are compared the way the app routes them:
+//     case-insensitively and ignoring a trailing slash, unless the app sets CaseSensitive or StrictRouting, so
+//     "/Api/Products/1/" gets the policy of "/api/products/*".
+//   - Rate limits count requests per client: the authenticated subject, or the client address, taken from
+//     X-Forwarded-For when the request comes from TrustedProxies. With TenantMiddleware before this middleware
+//     (see ServerConfig.Tenant), each tenant counts its clients apart, so tenants behind a shared address don't
+//     exhaust each other's limit.
+//   - Requests are rejected in order: 401 by Authenticate, 413 above MaxBody and 429 (with Retry-After) above the
+//     rate limit tier.
+//   - A policy with Auth set rejects every request with 401 when Authenticate is nil.
+//   - Unknown rate limit tiers are ignored.
+//   - The "rate_limit:<tier>" setting (see settings.RateLimitPrefix) overrides the Requests of a tier at runtime;
+//     removing it restores the configured value.
+//
+// Usage:
+//
+//	var policies server.RoutePolicyConfig
+//	_ = config.Load(&policies, config.WithFile("routes.yaml"))
+//	policies.Authenticate = authenticate
+//	app.Use(server.RoutePolicyMiddleware(policies))
+//
+//	# routes.yaml
+//	rate_limit_tiers:
+//	  strict: {requests: 10, window: 1m}
+//	policies:
+//	  - route: /api/reports/**
+//	    method: POST
+//	    timeout: 30s
+//	    max_body: 1048576
+//	    rate_limit_tier: strict
+//	    auth: true
+//	  - route: /api/products/*
+//	    timeout: 2s
+//	    cache_ttl: 1m
+func RoutePolicyMiddleware(cfg RoutePolicyConfig) fiber.Handler {
+	clk := clock.Or(cfg.Clock)
+	limiters := make(map[string]*fixedWindowLimiter, len(cfg.RateLimitTiers))
+	for name, tier := range cfg.RateLimitTiers {
+		if tier.Requests > 0 && tier.Window > 0 {
+			limiters[name] = newFixedWindowLimiter(name, tier, clk)
+		}
+	}
+
+	trusted := parseTrustedProxies(cfg.TrustedProxies)
+	rateLimitKey := cfg.RateLimitKey
+	if rateLimitKey == nil {
+		rateLimitKey = func(c *fiber.Ctx) string {
+			var namespace string
+			if tenant, ok := TenantFromContext(c.UserContext()); ok {
+				namespace = "tenant:" + tenant.ID + "|"
+			}
+			if claims, ok := ClaimsFromContext(c.UserContext()); ok && claims.Subject != "" {
+				return namespace + "sub:" + claims.Subject
+			}
+			return namespace + "ip:" + clientIP(c, trusted)
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		appCfg := c.App().Config()
+		policy, ok := matchRoutePolicy(cfg.Policies, c.Method(), routingPath(appCfg, c.Path()), appCfg)
+		if !ok {
+			return c.Next()
+		}
+
+		if policy.Auth {
+			if cfg.Authenticate == nil {
+				return fiber.ErrUnauthorized
+			}
+			if err := cfg.Authenticate(c); err != nil {
+				return err
+			}
+		}
+
+		if policy.MaxBody > 0 && (c.Request().Header.ContentLength() > policy.MaxBody || len(c.Body()) > policy.MaxBody) {
+			return fiber.ErrRequestEntityTooLarge
+		}
+
+		if limiter, ok := limiters[policy.RateLimitTier]; ok {
+			if retryAfter, allowed := limiter.allow(policy.Route + "|" + rateLimitKey(c)); !allowed {
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(retryAfter.Seconds())+1))
+				return fiber.ErrTooManyRequests
+			}
+		}
+
+		if policy.Timeout > 0 {
+			ctx, cancel := context.WithTimeout(c.UserContext(), policy.Timeout)
+			defer cancel()
+			c.SetUserContext(ctx)
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		if policy.CacheTTL > 0 && c.Response().StatusCode() < 400 && len(c.Response().Header.Peek(fiber.HeaderCacheControl)) == 0 {
+			c.Set(fiber.HeaderCacheControl, "public, max-age="+seconds(policy.CacheTTL))
+		}
+
+		return nil
+	}
+}
+
+// matchRoutePolicy returns the first policy matching the method and the routing path (see routingPath), with
+// the route patterns normalized the same way.
+func matchRoutePolicy(policies []RoutePolicy, method, requestPath string, appCfg fiber.Config) (RoutePolicy, bool) {
+	for _, policy := range policies {
+		if policy.Method != "" && !strings.EqualFold(policy.Method, method) {
+			continue
+		}
+
+		if matchRoute(routePattern(appCfg, policy.Route), requestPath) {
+			return policy, true
+		}
+	}
+
+	return RoutePolicy{}, false
+}
+
+// routePattern normalizes a route pattern with routingPath, keeping its "/**" suffix.
+func routePattern(cfg fiber.Config, pattern string) string {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return routingPath(cfg, prefix) + "/**"
+	}
+	return routingPath(cfg, pattern)
+}
+
+// routingPath normalizes a request path the way Fiber does before matching routes: lowercased unless
+// CaseSensitive, without the trailing slash unless StrictRouting. Policies must match the path the router matches,
+// otherwise a variant of the path would reach the handler without its policy.
+func routingPath(cfg fiber.Config, requestPath string) string {
+	if !cfg.CaseSensitive {
+		requestPath = strings.ToLower(requestPath)
+	}
+	if !cfg.StrictRouting && len(requestPath) > 1 {
+		requestPath = strings.TrimRight(requestPath, "/")
+		if requestPath == "" {
+			requestPath = "/"
+		}
+	}
+	return requestPath
+}
+
+// parseTrustedProxies parses addresses and CIDRs, ignoring invalid entries.
+func parseTrustedProxies(proxies []string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, proxy := range proxies {
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(proxy); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	return prefixes
+}
+
+// clientIP returns the address of the client: the connection address, or, when it is a trusted proxy, the last
+// X-Forwarded-For address not belonging to a trusted proxy. Addresses before it are set by the client and can't be
+// trusted.
+func clientIP(c *fiber.Ctx, trusted []netip.Prefix) string {
+	ip := c.IP()
+	if !isTrustedProxy(ip, trusted) {
+		return ip
+	}
+
+	hops := strings.Split(c.Get(fiber.HeaderXForwardedFor), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !isTrustedProxy(hop, trusted) {
+			return hop
+		}
+		ip = hop
+	}
+	return ip
+}
+
+func isTrustedProxy(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// fixedWindowLimiter counts requests per key in fixed windows.
+type fixedWindowLimiter struct {
+	name   string
+	tier   RateLimitTier
+	clock  clock.Clock
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]int
+}
+
+func newFixedWindowLimiter(name string, tier RateLimitTier, clk clock.Clock) *fixedWindowLimiter {
+	return &fixedWindowLimiter{name: name, tier: tier, clock: clk, counts: make(map[string]int)}
+}
+
+// allow records a request for key, returning the time left in the window when the limit is exceeded.
+func (l *fixedWindowLimiter) allow(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if now.Sub(l.start) >= l.tier.Window {
+		l.start = now
+		clear(l.counts)
+	}
+
+	requests := l.tier.Requests
+	if override, ok := settings.Default.Int(settings.RateLimitPrefix + l.name); ok && override >= 0 {
+		requests = override
+	}
+
+	if l.counts[key] >= requests {
+		return l.tier.Window - now.Sub(l.start), false
+	}
+
+	l.counts[key]++
+	return 0, true
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/devluispereira/go-package/clock/clocktest"
+	"github.com/devluispereira/go-package/settings"
+	"github.com/gofiber/fiber/v2"
+)
+
+func newRoutePolicyApp(t *testing.T, appCfg fiber.Config, cfg RoutePolicyConfig) *fiber.App {
+	t.Helper()
+
+	app := fiber.New(appCfg)
+	app.Use(RoutePolicyMiddleware(cfg))
+	app.Get("/api/products/:id", func(c *fiber.Ctx) error {
+		return c.SendString("product")
+	})
+	app.Get("/api/reports/*", func(c *fiber.Ctx) error {
+		return c.SendString("report")
+	})
+	return app
+}
+
+func doStatus(t *testing.T, app *fiber.App, req *http.Request) int {
+	t.Helper()
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestRoutePolicyAuthCoversPathVariants(t *testing.T) {
+	app := newRoutePolicyApp(t, fiber.Config{}, RoutePolicyConfig{
+		Policies: []RoutePolicy{{Route: "/api/products/*", Auth: true}, {Route: "/api/reports/**", Auth: true}},
+	})
+
+	for _, path := range []string{
+		"/api/products/1",
+		"/api/products/1/",
+		"/Api/Products/1",
+		"/API/PRODUCTS/1//",
+		"/api/reports/2024/q1",
+		"/API/Reports/2024/",
+	} {
+		if status := doStatus(t, app, httptest.NewRequest(http.MethodGet, path, nil)); status != http.StatusUnauthorized {
+			t.Errorf("GET %s: status %d, want 401", path, status)
+		}
+	}
+}
+
+func TestRoutePolicyRespectsStrictAndCaseSensitiveRouting(t *testing.T) {
+	app := newRoutePolicyApp(t, fiber.Config{StrictRouting: true, CaseSensitive: true}, RoutePolicyConfig{
+		Policies: []RoutePolicy{{Route: "/api/products/*", Auth: true}},
+	})
+
+	if status := doStatus(t, app, httptest.NewRequest(http.MethodGet, "/api/products/1", nil)); status != http.StatusUnauthorized {
+		t.Errorf("status %d, want 401", status)
+	}
+	// The router doesn't route these variants to the protected handler either.
+	for _, path := range []string{"/api/products/1/", "/Api/Products/1"} {
+		if status := doStatus(t, app, httptest.NewRequest(http.MethodGet, path, nil)); status != http.StatusNotFound {
+			t.Errorf("GET %s: status %d, want 404", path, status)
+		}
+	}
+}
+
+func TestRoutePolicyRateLimitUsesClockAndClientAddress(t *testing.T) {
+	clk := clocktest.NewFake(time.Time{})
+	app := newRoutePolicyApp(t, fiber.Config{}, RoutePolicyConfig{
+		Policies:       []RoutePolicy{{Route: "/api/products/*", RateLimitTier: "strict"}},
+		RateLimitTiers: map[string]RateLimitTier{"strict": {Requests: 1, Window: time.Minute}},
+		TrustedProxies: []string{"0.0.0.0/0"},
+		Clock:          clk,
+	})
+
+	request := func(forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/products/1", nil)
+		req.Header.Set(fiber.HeaderXForwardedFor, forwardedFor)
+		return doStatus(t, app, req)
+	}
+
+	if status := request("203.0.113.1"); status != http.StatusOK {
+		t.Fatalf("first request: status %d, want 200", status)
+	}
+	if status := request("203.0.113.1"); status != http.StatusTooManyRequests {
+		t.Fatalf("second request: status %d, want 429", status)
+	}
+	// Another client behind the same proxy has its own limit.
+	if status := request("203.0.113.2"); status != http.StatusOK {
+		t.Fatalf("other client: status %d, want 200", status)
+	}
+
+	clk.Advance(time.Minute)
+	if status := request("203.0.113.1"); status != http.StatusOK {
+		t.Fatalf("after the window: status %d, want 200", status)
+	}
+}
+
+func TestClientIPIgnoresUntrustedForwardedFor(t *testing.T) {
+	app := fiber.New()
+	trusted := parseTrustedProxies([]string{"10.0.0.0/8"})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(clientIP(c, trusted))
+	})
+
+	// app.Test connections come from 0.0.0.0, which is not trusted here.
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderXForwardedFor, "198.51.100.7")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body := make([]byte, 64)
+	n, _ := resp.Body.Read(body)
+	if got := string(body[:n]); got == "198.51.100.7" {
+		t.Fatalf("client IP taken from an untrusted X-Forwarded-For")
+	}
+}
+
+func TestRoutePolicyRateLimitSettingOverride(t *testing.T) {
+	app := newRoutePolicyApp(t, fiber.Config{}, RoutePolicyConfig{
+		Policies:       []RoutePolicy{{Route: "/api/products/*", RateLimitTier: "override-test"}},
+		RateLimitTiers: map[string]RateLimitTier{"override-test": {Requests: 1, Window: time.Minute}},
+		Clock:          clocktest.NewFake(time.Time{}),
+	})
+
+	if err := settings.Default.Set(settings.RateLimitPrefix+"override-test", "3", "test"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = settings.Default.Delete(settings.RateLimitPrefix+"override-test", "test") })
+
+	for i := range 3 {
+		if status := doStatus(t, app, httptest.NewRequest(http.MethodGet, "/api/products/1", nil)); status != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i, status)
+		}
+	}
+	if status := doStatus(t, app, httptest.NewRequest(http.MethodGet, "/api/products/1", nil)); status != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429", status)
+	}
+}
@@ -0,0 +1,96 @@
+package servertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/devluispereira/go-package/clients/httpclient/contract"
+)
+
+// VerifyContract replays every interaction of a consumer contract against the app, as a subtest per interaction,
+// checking status, recorded response headers and body (matched as a subset, see contract.Match).
+//
+// Parameters:
+//
+//	pact: The contract, usually loaded with contract.Load from the consumer's published pact file.
+//	states: Setup functions per provider state, run before the interactions declaring that state.
+//	  Interactions with an unknown state fail.
+//
+// Usage:
+//
+//	pact, err := contract.Load("pacts/catalog-bff-catalog.json")
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	tester := servertest.New(t, srv.App)
+//	tester.VerifyContract(pact, map[string]func(t testing.TB){
+//		"product 1 exists": func(t testing.TB) { repo.Save(product1) },
+//	})
+func (tt *Tester) VerifyContract(pact *contract.Pact, states map[string]func(t testing.TB)) {
+	tt.t.Helper()
+
+	run, ok := tt.t.(*testing.T)
+	if !ok {
+		tt.t.Fatalf("servertest: VerifyContract requires a *testing.T")
+	}
+
+	for _, interaction := range pact.Interactions {
+		run.Run(interaction.Description, func(t *testing.T) {
+			if interaction.ProviderState != "" {
+				setup, ok := states[interaction.ProviderState]
+				if !ok {
+					t.Fatalf("servertest: unknown provider state %q", interaction.ProviderState)
+				}
+				setup(t)
+			}
+
+			New(t, tt.app).verify(interaction)
+		})
+	}
+}
+
+func (tt *Tester) verify(interaction contract.Interaction) {
+	tt.t.Helper()
+
+	path := interaction.Request.Path
+	if interaction.Request.Query != "" {
+		path += "?" + interaction.Request.Query
+	}
+
+	req := tt.NewRequest(strings.ToUpper(interaction.Request.Method), path)
+	for name, value := range interaction.Request.Headers {
+		req.Header(name, value)
+	}
+
+	switch body := interaction.Request.Body.(type) {
+	case nil:
+	case string:
+		req.Body(strings.NewReader(body))
+	default:
+		req.JSON(body)
+	}
+
+	resp := req.Do().AssertStatus(interaction.Response.Status)
+	for name, value := range interaction.Response.Headers {
+		resp.AssertHeader(name, value)
+	}
+
+	switch expected := interaction.Response.Body.(type) {
+	case nil:
+	case string:
+		if !bytes.Equal(resp.Bytes(), []byte(expected)) {
+			tt.t.Errorf("servertest: expected body %q, got %q", expected, resp.Bytes())
+		}
+	default:
+		var actual any
+		if err := json.Unmarshal(resp.Bytes(), &actual); err != nil {
+			tt.t.Errorf("servertest: response body is not JSON: %s", resp.Bytes())
+			return
+		}
+		if err := contract.Match(expected, actual); err != nil {
+			tt.t.Errorf("servertest: body does not match the contract: %v", err)
+		}
+	}
+}
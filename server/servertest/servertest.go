@@ -0,0 +1,397 @@
+// Package servertest provides helpers to test Fiber handlers in-process, without spinning up listeners.
+package servertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/devluispereira/go-package/server"
+	"github.com/gofiber/fiber/v2"
+)
+
+// seedHeader carries the ID of the seed registered for a request, so Seed can find its locals and forwarded headers.
+const seedHeader = "X-Servertest-Seed"
+
+type seed struct {
+	locals    map[string]any
+	forwarded map[string]string
+}
+
+// Tester runs requests through a Fiber app and returns responses with fluent assertions.
+type Tester struct {
+	t   testing.TB
+	app *fiber.App
+
+	mu     sync.Mutex
+	seeds  map[string]seed
+	nextID atomic.Int64
+}
+
+// New creates a Tester for an existing app.
+//
+// Parameters:
+//
+//	t: The test (or benchmark) running the requests. Failures are reported through it.
+//	app: The app under test.
+//
+// Behavior:
+//   - Requests run in-process with app.Test, without a listener and without a timeout.
+//   - Locals and forwarded headers set with Request.Local and Request.Forwarded are only injected
+//     when tester.Seed() is registered before the handlers (see NewApp, which does it for you).
+//
+// Usage:
+//
+//	srv := server.NewServer("my-app", nil)
+//	tester := servertest.New(t, srv.App)
+//	tester.Get("/healthcheck").Do().AssertStatus(200)
+func New(t testing.TB, app *fiber.App) *Tester {
+	return &Tester{t: t, app: app, seeds: make(map[string]seed)}
+}
+
+// NewApp creates a fresh app with Seed registered first and the server.ErrorHandler, lets register add middlewares and routes,
+// and returns a Tester for it.
+//
+// Usage:
+//
+//	tester := servertest.NewApp(t, func(app *fiber.App) {
+//		app.Get("/products/:id", handler.GetProduct)
+//	})
+//	tester.Get("/products/1").Forwarded("x-tenant-id", "acme").Do().AssertStatus(200)
+func NewApp(t testing.TB, register func(app *fiber.App)) *Tester {
+	app := fiber.New(fiber.Config{ErrorHandler: server.ErrorHandler})
+	tester := New(t, app)
+
+	app.Use(tester.Seed())
+	register(app)
+
+	return tester
+}
+
+// Seed returns a middleware injecting the locals and forwarded headers prepopulated on each request.
+// Forwarded headers are stored in the user context under "forwardedHeaders", as ForwardHeadersMiddleware does.
+func (tt *Tester) Seed() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(seedHeader)
+		if id == "" {
+			return c.Next()
+		}
+		c.Request().Header.Del(seedHeader)
+
+		tt.mu.Lock()
+		s, ok := tt.seeds[id]
+		delete(tt.seeds, id)
+		tt.mu.Unlock()
+
+		if !ok {
+			return c.Next()
+		}
+
+		for key, value := range s.locals {
+			c.Locals(key, value)
+		}
+
+		if len(s.forwarded) > 0 {
+			headers, _ := c.UserContext().Value("forwardedHeaders").(map[string]string)
+			if headers == nil {
+				headers = make(map[string]string, len(s.forwarded))
+			}
+			for name, value := range s.forwarded {
+				headers[name] = value
+			}
+			c.SetUserContext(context.WithValue(c.UserContext(), "forwardedHeaders", headers))
+		}
+
+		return c.Next()
+	}
+}
+
+// Request is a request being built. Create it with the Tester verb methods and run it with Do.
+type Request struct {
+	tester    *Tester
+	method    string
+	path      string
+	headers   http.Header
+	body      io.Reader
+	locals    map[string]any
+	forwarded map[string]string
+}
+
+// NewRequest starts building a request with any method.
+func (tt *Tester) NewRequest(method, path string) *Request {
+	return &Request{
+		tester:    tt,
+		method:    method,
+		path:      path,
+		headers:   make(http.Header),
+		locals:    make(map[string]any),
+		forwarded: make(map[string]string),
+	}
+}
+
+// Get starts building a GET request.
+func (tt *Tester) Get(path string) *Request { return tt.NewRequest(http.MethodGet, path) }
+
+// Post starts building a POST request.
+func (tt *Tester) Post(path string) *Request { return tt.NewRequest(http.MethodPost, path) }
+
+// Put starts building a PUT request.
+func (tt *Tester) Put(path string) *Request { return tt.NewRequest(http.MethodPut, path) }
+
+// Patch starts building a PATCH request.
+func (tt *Tester) Patch(path string) *Request { return tt.NewRequest(http.MethodPatch, path) }
+
+// Delete starts building a DELETE request.
+func (tt *Tester) Delete(path string) *Request { return tt.NewRequest(http.MethodDelete, path) }
+
+// Header sets a request header.
+func (r *Request) Header(name, value string) *Request {
+	r.headers.Set(name, value)
+	return r
+}
+
+// Forwarded sets a header that is both sent on the request and prepopulated in the forwarded headers map,
+// so handlers and downstream httpclient calls see it even without ForwardHeadersMiddleware.
+func (r *Request) Forwarded(name, value string) *Request {
+	r.headers.Set(name, value)
+	r.forwarded[strings.ToLower(name)] = value
+	return r
+}
+
+// Local prepopulates c.Locals(key) for the handlers. Requires Seed (see NewApp).
+func (r *Request) Local(key string, value any) *Request {
+	r.locals[key] = value
+	return r
+}
+
+// Body sets a raw request body.
+func (r *Request) Body(body io.Reader) *Request {
+	r.body = body
+	return r
+}
+
+// JSON sets the request body to the JSON encoding of v and the Content-Type to application/json.
+func (r *Request) JSON(v any) *Request {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		r.tester.t.Helper()
+		r.tester.t.Fatalf("servertest: failed to marshal request body: %v", err)
+	}
+
+	r.body = bytes.NewReader(raw)
+	r.headers.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return r
+}
+
+// Do runs the request through the app. It fails the test immediately when the app cannot be reached.
+func (r *Request) Do() *Response {
+	tt := r.tester
+	tt.t.Helper()
+
+	req := httptest.NewRequest(r.method, r.path, r.body)
+	for name, values := range r.headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	if len(r.locals) > 0 || len(r.forwarded) > 0 {
+		id := strconv.FormatInt(tt.nextID.Add(1), 10)
+
+		tt.mu.Lock()
+		tt.seeds[id] = seed{locals: r.locals, forwarded: r.forwarded}
+		tt.mu.Unlock()
+
+		req.Header.Set(seedHeader, id)
+		defer func() {
+			tt.mu.Lock()
+			delete(tt.seeds, id)
+			tt.mu.Unlock()
+		}()
+	}
+
+	resp, err := tt.app.Test(req, -1)
+	if err != nil {
+		tt.t.Fatalf("servertest: %s %s failed: %v", r.method, r.path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		tt.t.Fatalf("servertest: failed to read response body: %v", err)
+	}
+
+	return &Response{t: tt.t, Response: resp, body: body}
+}
+
+// Response is the result of a request, with fluent assertions. Failed assertions are reported with t.Errorf,
+// so every assertion of a chain is checked.
+type Response struct {
+	*http.Response
+	t    testing.TB
+	body []byte
+}
+
+// Bytes returns the raw response body.
+func (r *Response) Bytes() []byte {
+	return r.body
+}
+
+// Decode unmarshals the JSON response body into v, failing the test when it is not valid JSON.
+func (r *Response) Decode(v any) *Response {
+	r.t.Helper()
+
+	if err := json.Unmarshal(r.body, v); err != nil {
+		r.t.Fatalf("servertest: failed to decode response body %q: %v", r.body, err)
+	}
+
+	return r
+}
+
+// AssertStatus checks the response status code.
+func (r *Response) AssertStatus(code int) *Response {
+	r.t.Helper()
+
+	if r.StatusCode != code {
+		r.t.Errorf("servertest: expected status %d, got %d (body: %s)", code, r.StatusCode, r.body)
+	}
+
+	return r
+}
+
+// AssertHeader checks a response header value.
+func (r *Response) AssertHeader(name, value string) *Response {
+	r.t.Helper()
+
+	if got := r.Header.Get(name); got != value {
+		r.t.Errorf("servertest: expected header %s %q, got %q", name, value, got)
+	}
+
+	return r
+}
+
+// AssertBodyContains checks that the raw response body contains substr.
+func (r *Response) AssertBodyContains(substr string) *Response {
+	r.t.Helper()
+
+	if !bytes.Contains(r.body, []byte(substr)) {
+		r.t.Errorf("servertest: expected body to contain %q, got %s", substr, r.body)
+	}
+
+	return r
+}
+
+// AssertJSON checks that the response body is JSON equal to expected. Expected may be any value
+// (struct, map, slice) or a JSON string; both sides are compared after normalization, so key order
+// and number types don't matter.
+func (r *Response) AssertJSON(expected any) *Response {
+	r.t.Helper()
+
+	want, err := normalize(expected)
+	if err != nil {
+		r.t.Fatalf("servertest: invalid expected JSON: %v", err)
+	}
+
+	var got any
+	if err := json.Unmarshal(r.body, &got); err != nil {
+		r.t.Errorf("servertest: response body is not JSON: %s", r.body)
+		return r
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		wantRaw, _ := json.Marshal(want)
+		r.t.Errorf("servertest: expected JSON body %s, got %s", wantRaw, r.body)
+	}
+
+	return r
+}
+
+// AssertJSONPath checks the value at a dot-separated path of the JSON body, e.g. "data.items.0.id".
+// Numeric segments index arrays.
+func (r *Response) AssertJSONPath(path string, expected any) *Response {
+	r.t.Helper()
+
+	want, err := normalize(expected)
+	if err != nil {
+		r.t.Fatalf("servertest: invalid expected value: %v", err)
+	}
+
+	var body any
+	if err := json.Unmarshal(r.body, &body); err != nil {
+		r.t.Errorf("servertest: response body is not JSON: %s", r.body)
+		return r
+	}
+
+	got, err := lookup(body, path)
+	if err != nil {
+		r.t.Errorf("servertest: %v (body: %s)", err, r.body)
+		return r
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		r.t.Errorf("servertest: expected %s to be %v, got %v", path, want, got)
+	}
+
+	return r
+}
+
+// normalize round-trips v through JSON, so it compares equal to a decoded response body.
+// Strings holding valid JSON documents (objects and arrays) are decoded as such.
+func normalize(v any) (any, error) {
+	raw, ok := v.(string)
+	if ok && (strings.HasPrefix(strings.TrimSpace(raw), "{") || strings.HasPrefix(strings.TrimSpace(raw), "[")) {
+		var out any
+		if err := json.Unmarshal([]byte(raw), &out); err == nil {
+			return out, nil
+		}
+	}
+
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out any
+	if err := json.Unmarshal(encoded, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func lookup(value any, path string) (any, error) {
+	current := value
+
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			next, ok := node[segment]
+			if !ok {
+				return nil, fmt.Errorf("path %s not found", path)
+			}
+			current = next
+
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("path %s: invalid index %q", path, segment)
+			}
+			current = node[index]
+
+		default:
+			return nil, fmt.Errorf("path %s not found", path)
+		}
+	}
+
+	return current, nil
+}
@@ -0,0 +1,305 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// ISessionRedisClient defines the Redis operations used by SessionMiddleware. It is satisfied by
+// redisclient.RedisClient.
+type ISessionRedisClient interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value any, expiration time.Duration) error
+	Del(ctx context.Context, keys ...string) error
+	Expire(ctx context.Context, key string, expiration time.Duration) error
+}
+
+// SessionConfig holds the configuration for the session middleware.
+type SessionConfig struct {
+	Client ISessionRedisClient
+	// CookieName is the cookie carrying the session ID. If empty, uses "session_id".
+	CookieName string
+	// KeyPrefix namespaces the session keys in Redis. If empty, uses "session:".
+	KeyPrefix string
+	// TTL is the idle timeout, renewed on every request (sliding expiration). If zero, uses 24h.
+	TTL time.Duration
+	// CookieDomain and CookiePath scope the cookie. CookiePath defaults to "/".
+	CookieDomain string
+	CookiePath   string
+	// Insecure allows the cookie over plain HTTP (local development only). By default it is sent over HTTPS only.
+	Insecure bool
+	// SameSite is "Lax" (default), "Strict" or "None".
+	SameSite string
+}
+
+type SessionKeyType struct{}
+
+// Session holds the values of the current session. Values are serialized as JSON.
+type Session struct {
+	mu        sync.Mutex
+	id        string
+	values    map[string]json.RawMessage
+	stored    bool
+	dirty     bool
+	destroyed bool
+	previous  string
+}
+
+// ID returns the session ID, empty for a new session that has not been saved yet.
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.id
+}
+
+// Set stores value under key. The session is saved when the request ends.
+func (s *Session) Set(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session value %q: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = raw
+	s.dirty = true
+	return nil
+}
+
+// Get decodes the value stored under key into dest. It returns false if the key is not set.
+func (s *Session) Get(key string, dest any) (bool, error) {
+	s.mu.Lock()
+	raw, ok := s.values[key]
+	s.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, dest); err != nil {
+		return true, fmt.Errorf("failed to unmarshal session value %q: %w", key, err)
+	}
+	return true, nil
+}
+
+// Delete removes key from the session.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.dirty = true
+	}
+}
+
+// Regenerate moves the session to a new ID, keeping its values. Call it after login to prevent session fixation.
+func (s *Session) Regenerate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stored && s.previous == "" {
+		s.previous = s.id
+	}
+	s.id = ""
+	s.stored = false
+	s.dirty = true
+}
+
+// Destroy removes the session from Redis and expires the cookie when the request ends.
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = make(map[string]json.RawMessage)
+	s.destroyed = true
+}
+
+// SessionMiddleware loads the session identified by the session cookie from Redis and saves it when the
+// request ends.
+//
+// Parameters:
+//
+//	cfg: Session configuration.
+//	  - Client: Redis client storing the sessions (e.g. redisclient.RedisClient).
+//	  - TTL: Idle timeout, renewed on every request.
+//
+// Behavior:
+//   - Stores the Session in the user context (see SessionFromContext, SessionGet and SessionSet) and in
+//     c.Locals("session").
+//   - Unknown or expired session IDs start a new, empty session.
+//   - New sessions get a random ID and a cookie only when a value is set, so anonymous traffic doesn't create keys.
+//   - Changed sessions are saved with the TTL; unchanged ones have their TTL and cookie renewed.
+//   - The cookie is Secure (unless Insecure) and HttpOnly; SameSite follows the configuration, like SecureCookies.
+//   - Redis errors fail the request with 500.
+//
+// Usage:
+//
+//	app.Use(server.SessionMiddleware(server.SessionConfig{Client: redis, TTL: 2 * time.Hour}))
+//
+//	// In a handler:
+//	_ = server.SessionSet(c.UserContext(), "cart_id", cartID)
+//	cartID, ok := server.SessionGet[string](c.UserContext(), "cart_id")
+func SessionMiddleware(cfg SessionConfig) fiber.Handler {
+	if cfg.CookieName == "" {
+		cfg.CookieName = "session_id"
+	}
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = "session:"
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.CookiePath == "" {
+		cfg.CookiePath = "/"
+	}
+	if cfg.SameSite == "" {
+		cfg.SameSite = fiber.CookieSameSiteLaxMode
+	}
+
+	return func(c *fiber.Ctx) error {
+		session, err := loadSession(c.UserContext(), cfg, c.Cookies(cfg.CookieName))
+		if err != nil {
+			return err
+		}
+
+		c.SetUserContext(context.WithValue(c.UserContext(), SessionKeyType{}, session))
+		c.Locals("session", session)
+
+		handlerErr := c.Next()
+
+		if err := saveSession(c, cfg, session); err != nil {
+			return err
+		}
+		return handlerErr
+	}
+}
+
+func loadSession(ctx context.Context, cfg SessionConfig, id string) (*Session, error) {
+	session := &Session{values: make(map[string]json.RawMessage)}
+	if id == "" {
+		return session, nil
+	}
+
+	value, err := cfg.Client.Get(ctx, cfg.KeyPrefix+id)
+	if errors.Is(err, redis.Nil) {
+		return session, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session from redis: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(value), &session.values); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+
+	session.id = id
+	session.stored = true
+	return session, nil
+}
+
+func saveSession(c *fiber.Ctx, cfg SessionConfig, session *Session) error {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	ctx := c.UserContext()
+
+	if session.previous != "" {
+		if err := cfg.Client.Del(ctx, cfg.KeyPrefix+session.previous); err != nil {
+			return fmt.Errorf("failed to delete session from redis: %w", err)
+		}
+	}
+
+	switch {
+	case session.destroyed:
+		if session.stored {
+			if err := cfg.Client.Del(ctx, cfg.KeyPrefix+session.id); err != nil {
+				return fmt.Errorf("failed to delete session from redis: %w", err)
+			}
+		}
+		if session.stored || session.previous != "" {
+			c.Cookie(sessionCookie(cfg, "", -1))
+		}
+		return nil
+
+	case session.dirty:
+		if session.id == "" {
+			session.id = newSessionID()
+		}
+
+		raw, err := json.Marshal(session.values)
+		if err != nil {
+			return fmt.Errorf("failed to marshal session: %w", err)
+		}
+		if err := cfg.Client.Set(ctx, cfg.KeyPrefix+session.id, raw, cfg.TTL); err != nil {
+			return fmt.Errorf("failed to save session to redis: %w", err)
+		}
+
+	case session.stored:
+		if err := cfg.Client.Expire(ctx, cfg.KeyPrefix+session.id, cfg.TTL); err != nil {
+			return fmt.Errorf("failed to renew session in redis: %w", err)
+		}
+
+	default:
+		return nil
+	}
+
+	session.stored = true
+	session.dirty = false
+	session.previous = ""
+	c.Cookie(sessionCookie(cfg, session.id, int(cfg.TTL.Seconds())))
+	return nil
+}
+
+func sessionCookie(cfg SessionConfig, value string, maxAge int) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     cfg.CookieName,
+		Value:    value,
+		Path:     cfg.CookiePath,
+		Domain:   cfg.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   !cfg.Insecure,
+		HTTPOnly: true,
+		SameSite: cfg.SameSite,
+	}
+}
+
+func newSessionID() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// SessionFromContext returns the session loaded by SessionMiddleware, if any.
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(SessionKeyType{}).(*Session)
+	return session, ok
+}
+
+// SessionGet returns the session value stored under key, decoded as T. It returns false when there is no
+// session, the key is not set or the value can't be decoded as T.
+func SessionGet[T any](ctx context.Context, key string) (T, bool) {
+	var value T
+
+	session, ok := SessionFromContext(ctx)
+	if !ok {
+		return value, false
+	}
+
+	found, err := session.Get(key, &value)
+	return value, found && err == nil
+}
+
+// SessionSet stores value under key in the current session. It fails if SessionMiddleware is not installed.
+func SessionSet(ctx context.Context, key string, value any) error {
+	session, ok := SessionFromContext(ctx)
+	if !ok {
+		return errors.New("no session in context")
+	}
+	return session.Set(key, value)
+}
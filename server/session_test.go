@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// memorySessionRedis is an in-memory ISessionRedisClient, ignoring expirations.
+type memorySessionRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (m *memorySessionRedis) Get(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.data[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (m *memorySessionRedis) Set(_ context.Context, key string, value any, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = string(value.([]byte))
+	return nil
+}
+
+func (m *memorySessionRedis) Del(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.data, key)
+	}
+	return nil
+}
+
+func (m *memorySessionRedis) Expire(context.Context, string, time.Duration) error {
+	return nil
+}
+
+// sessionSetCookie runs a request setting a session value and returns the Set-Cookie header.
+func sessionSetCookie(t *testing.T, cfg SessionConfig) string {
+	t.Helper()
+
+	cfg.Client = &memorySessionRedis{data: map[string]string{}}
+	app := fiber.New()
+	app.Use(SessionMiddleware(cfg))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return SessionSet(c.UserContext(), "cart_id", "42")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil), -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	cookie := resp.Header.Get(fiber.HeaderSetCookie)
+	if !strings.HasPrefix(cookie, "session_id=") {
+		t.Fatalf("Set-Cookie %q, want the session cookie", cookie)
+	}
+	return strings.ToLower(cookie)
+}
+
+func TestSessionCookieSecureByDefault(t *testing.T) {
+	cookie := sessionSetCookie(t, SessionConfig{})
+	if !strings.Contains(cookie, "; secure") {
+		t.Errorf("Set-Cookie %q, want Secure", cookie)
+	}
+	if !strings.Contains(cookie, "; httponly") {
+		t.Errorf("Set-Cookie %q, want HttpOnly", cookie)
+	}
+}
+
+func TestSessionCookieInsecure(t *testing.T) {
+	cookie := sessionSetCookie(t, SessionConfig{Insecure: true})
+	if strings.Contains(cookie, "; secure") {
+		t.Errorf("Set-Cookie %q, want no Secure with Insecure", cookie)
+	}
+}
@@ -2,7 +2,8 @@ package server
 
 import (
 	"fmt"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -18,6 +19,94 @@ const (
 	CacheNoCache CacheType = "no-cache"
 )
 
+// CacheControl declares the caching directives of a response: Cache-Control for browsers and shared caches, and
+// Surrogate-Control and Surrogate-Key for the CDN. It can be loaded with the config package.
+type CacheControl struct {
+	Type   CacheType     `json:"type"`
+	MaxAge time.Duration `json:"max_age"`
+	// SMaxAge is the shared caches (CDN) max-age.
+	SMaxAge time.Duration `json:"s_maxage"`
+	// StaleWhileRevalidate lets caches serve a stale response while revalidating it in the background.
+	StaleWhileRevalidate time.Duration `json:"stale_while_revalidate"`
+	// StaleIfError lets caches serve a stale response when the origin fails.
+	StaleIfError time.Duration `json:"stale_if_error"`
+	// Immutable tells browsers the response never changes while fresh, e.g. fingerprinted assets.
+	Immutable bool `json:"immutable"`
+	// SurrogateMaxAge sets the Surrogate-Control header, consumed and stripped by the CDN.
+	SurrogateMaxAge time.Duration `json:"surrogate_max_age"`
+	// SurrogateKeys are sent in the Surrogate-Key header, enabling purge by key at the CDN.
+	SurrogateKeys []string `json:"surrogate_keys"`
+}
+
+// Validate reports whether the directives are consistent: a valid type, no negative durations, no shared cache
+// directives on private responses and max-age with immutable.
+func (cc CacheControl) Validate() error {
+	if !isValidCacheType(cc.Type) {
+		return fmt.Errorf("invalid cache type: %s", cc.Type)
+	}
+
+	for name, d := range map[string]time.Duration{
+		"max_age": cc.MaxAge, "s_maxage": cc.SMaxAge, "stale_while_revalidate": cc.StaleWhileRevalidate,
+		"stale_if_error": cc.StaleIfError, "surrogate_max_age": cc.SurrogateMaxAge,
+	} {
+		if d < 0 {
+			return fmt.Errorf("invalid cache control: negative %s", name)
+		}
+	}
+
+	if cc.Type == CachePrivate && (cc.SMaxAge > 0 || cc.SurrogateMaxAge > 0 || len(cc.SurrogateKeys) > 0) {
+		return fmt.Errorf("invalid cache control: shared cache directives on a private response")
+	}
+	if cc.Immutable && cc.MaxAge <= 0 && cc.Type != CacheNoStore {
+		return fmt.Errorf("invalid cache control: immutable requires max_age")
+	}
+
+	return nil
+}
+
+// String returns the Cache-Control value, e.g. "public, max-age=60, s-maxage=300, stale-while-revalidate=30".
+// With no-store, the other directives are omitted.
+func (cc CacheControl) String() string {
+	directives := []string{string(cc.Type)}
+
+	if cc.Type == CacheNoStore {
+		return directives[0]
+	}
+
+	if cc.MaxAge > 0 {
+		directives = append(directives, "max-age="+seconds(cc.MaxAge))
+	}
+	if cc.SMaxAge > 0 {
+		directives = append(directives, "s-maxage="+seconds(cc.SMaxAge))
+	}
+	if cc.StaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+seconds(cc.StaleWhileRevalidate))
+	}
+	if cc.StaleIfError > 0 {
+		directives = append(directives, "stale-if-error="+seconds(cc.StaleIfError))
+	}
+	if cc.Immutable {
+		directives = append(directives, "immutable")
+	}
+
+	return strings.Join(directives, ", ")
+}
+
+// apply sets Cache-Control and the CDN headers on the response.
+func (cc CacheControl) apply(c *fiber.Ctx) {
+	c.Set(fiber.HeaderCacheControl, cc.String())
+	if cc.Type == CacheNoStore {
+		return
+	}
+
+	if cc.SurrogateMaxAge > 0 {
+		c.Set("Surrogate-Control", "max-age="+seconds(cc.SurrogateMaxAge))
+	}
+	if len(cc.SurrogateKeys) > 0 {
+		c.Set("Surrogate-Key", strings.Join(cc.SurrogateKeys, " "))
+	}
+}
+
 // SetCacheControlMiddleware sets the Cache-Control header for a route or group in Fiber.
 //
 // Parameters:
@@ -30,10 +119,39 @@ const (
 //	app.Get("/route", SetCacheControlMiddleware(CachePublic, 60), handler)
 //
 // If an invalid cacheType is provided, the middleware returns an error and does not set the header.
+// Use SetCacheControlMiddlewareWithConfig for the CDN directives.
 func SetCacheControlMiddleware(cacheType CacheType, ttl int) fiber.Handler {
+	return SetCacheControlMiddlewareWithConfig(CacheControl{Type: cacheType, MaxAge: time.Duration(max(ttl, 0)) * time.Second})
+}
+
+// SetCacheControlMiddlewareWithConfig sets Cache-Control with the full set of modern directives and, for CDNs,
+// Surrogate-Control and Surrogate-Key.
+//
+// Parameters:
+//
+//	cfg: The caching directives.
+//
+// Behavior:
+//   - cfg is validated once; when invalid, the middleware returns the validation error and sets no header.
+//   - The headers are set after the handler, on successful or failed responses alike, replacing the ones set by
+//     the handler.
+//
+// Usage:
+//
+//	app.Get("/home", server.SetCacheControlMiddlewareWithConfig(server.CacheControl{
+//		Type:                 server.CachePublic,
+//		MaxAge:               30 * time.Second,
+//		SMaxAge:              5 * time.Minute,
+//		StaleWhileRevalidate: time.Minute,
+//		StaleIfError:         time.Hour,
+//		SurrogateKeys:        []string{"home"},
+//	}), homeHandler)
+func SetCacheControlMiddlewareWithConfig(cfg CacheControl) fiber.Handler {
+	invalid := cfg.Validate()
+
 	return func(c *fiber.Ctx) error {
-		if !isValidCacheType(cacheType) {
-			return fmt.Errorf("invalid cache type: %s", cacheType)
+		if invalid != nil {
+			return invalid
 		}
 
 		err := c.Next()
@@ -42,11 +160,7 @@ func SetCacheControlMiddleware(cacheType CacheType, ttl int) fiber.Handler {
 			return err
 		}
 
-		value := string(cacheType)
-		if ttl > 0 {
-			value += ", max-age=" + strconv.Itoa(ttl)
-		}
-		c.Response().Header.Set("Cache-Control", value)
+		cfg.apply(c)
 		return nil
 	}
 }
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/devluispereira/go-package/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SlowRequestConfig configures the SlowRequestRecorder. It can be loaded with the config package.
+type SlowRequestConfig struct {
+	// Threshold is the latency above which a request is captured. Defaults to 2s.
+	Threshold time.Duration `json:"threshold" env:"SLOW_REQUEST_THRESHOLD" default:"2s"`
+	// Capacity is the number of snapshots kept in memory, the most recent ones. Defaults to 50.
+	Capacity int `json:"capacity" env:"SLOW_REQUEST_CAPACITY" default:"50"`
+	// Sink receives every snapshot in background, e.g. to store it in Redis. Snapshots are always logged.
+	Sink func(ctx context.Context, snapshot SlowRequestSnapshot) `json:"-"`
+}
+
+// SlowRequestSnapshot is the diagnostic snapshot of a slow request.
+type SlowRequestSnapshot struct {
+	Time      time.Time     `json:"time"`
+	RequestID string        `json:"request_id"`
+	Method    string        `json:"method"`
+	Route     string        `json:"route"`
+	Path      string        `json:"path"`
+	Status    int           `json:"status"`
+	Duration  time.Duration `json:"duration"`
+	// Calls are the downstream calls made by the httpclient package while serving the request.
+	Calls        []httpclient.CallTiming `json:"calls"`
+	DroppedCalls int                     `json:"dropped_calls,omitempty"`
+	Goroutines   int                     `json:"goroutines"`
+	GC           GCSnapshot              `json:"gc"`
+	// Breakers are the states of the circuit breakers when the request finished.
+	Breakers []httpclient.BreakerInfo `json:"breakers"`
+}
+
+// GCSnapshot holds the garbage collector and heap statistics of a SlowRequestSnapshot. LastGC is zero when no
+// collection ran yet.
+type GCSnapshot struct {
+	NumGC      uint32        `json:"num_gc"`
+	LastGC     time.Time     `json:"last_gc"`
+	LastPause  time.Duration `json:"last_pause"`
+	PauseTotal time.Duration `json:"pause_total"`
+	HeapAlloc  uint64        `json:"heap_alloc"`
+	HeapInuse  uint64        `json:"heap_inuse"`
+}
+
+// SlowRequestRecorder is a flight recorder for slow requests: requests above a latency threshold get a diagnostic
+// snapshot, logged, kept in memory and handed to a sink, so sporadic slow requests can be investigated after the
+// fact.
+type SlowRequestRecorder struct {
+	cfg SlowRequestConfig
+
+	mu        sync.Mutex
+	snapshots []SlowRequestSnapshot
+	next      int
+}
+
+// NewSlowRequestRecorder creates a SlowRequestRecorder.
+//
+// Usage:
+//
+//	slow := server.NewSlowRequestRecorder(server.SlowRequestConfig{Threshold: time.Second})
+//	app.Use(slow.Middleware())
+//	adminGroup.Get("/slow-requests", slow.Handler())
+func NewSlowRequestRecorder(cfg SlowRequestConfig) *SlowRequestRecorder {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 2 * time.Second
+	}
+	if cfg.Capacity <= 0 {
+		cfg.Capacity = 50
+	}
+
+	return &SlowRequestRecorder{cfg: cfg, snapshots: make([]SlowRequestSnapshot, 0, cfg.Capacity)}
+}
+
+// Middleware returns the middleware timing the requests.
+//
+// Behavior:
+//   - The downstream calls of the httpclient package made with the request context are recorded (see
+//     httpclient.WithCallRecorder); register the middleware before the handlers, and pass c.UserContext() to the
+//     clients.
+//   - Requests above Threshold get a snapshot with the calls, the goroutine count, the GC statistics and the
+//     circuit breaker states. It is logged at warn level, kept among the last Capacity snapshots and handed to Sink.
+//   - Fast requests only pay for the call recording.
+func (r *SlowRequestRecorder) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		ctx, calls := httpclient.WithCallRecorder(c.UserContext())
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		duration := time.Since(start)
+		if duration < r.cfg.Threshold {
+			return err
+		}
+
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+
+		snapshot := SlowRequestSnapshot{
+			Time:         start,
+			RequestID:    strings.Clone(RequestID(c)),
+			Method:       strings.Clone(c.Method()),
+			Route:        c.Route().Path,
+			Path:         strings.Clone(c.Path()),
+			Status:       responseStatus(c, err),
+			Duration:     duration,
+			Calls:        calls.Calls(),
+			DroppedCalls: calls.Dropped(),
+			Goroutines:   runtime.NumGoroutine(),
+			GC: GCSnapshot{
+				NumGC:      mem.NumGC,
+				LastPause:  time.Duration(mem.PauseNs[(mem.NumGC+255)%256]),
+				PauseTotal: time.Duration(mem.PauseTotalNs),
+				HeapAlloc:  mem.HeapAlloc,
+				HeapInuse:  mem.HeapInuse,
+			},
+			Breakers: httpclient.Breakers(),
+		}
+		if mem.LastGC > 0 {
+			snapshot.GC.LastGC = time.Unix(0, int64(mem.LastGC))
+		}
+
+		r.record(snapshot)
+
+		logging.FromContext(ctx).Warn().
+			Str("request_id", snapshot.RequestID).
+			Str("method", snapshot.Method).
+			Str("route", snapshot.Route).
+			Int("status", snapshot.Status).
+			Dur("duration", duration).
+			Interface("calls", snapshot.Calls).
+			Int("goroutines", snapshot.Goroutines).
+			Interface("gc", snapshot.GC).
+			Interface("breakers", snapshot.Breakers).
+			Msg("slow request")
+
+		if r.cfg.Sink != nil {
+			go r.cfg.Sink(context.WithoutCancel(ctx), snapshot)
+		}
+
+		return err
+	}
+}
+
+func (r *SlowRequestRecorder) record(snapshot SlowRequestSnapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.snapshots) < r.cfg.Capacity {
+		r.snapshots = append(r.snapshots, snapshot)
+		return
+	}
+
+	r.snapshots[r.next] = snapshot
+	r.next = (r.next + 1) % r.cfg.Capacity
+}
+
+// Snapshots returns the snapshots kept in memory, most recent first.
+func (r *SlowRequestRecorder) Snapshots() []SlowRequestSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshots := make([]SlowRequestSnapshot, 0, len(r.snapshots))
+	for i := range r.snapshots {
+		snapshots = append(snapshots, r.snapshots[(r.next-1-i+2*len(r.snapshots))%len(r.snapshots)])
+	}
+	return snapshots
+}
+
+// Handler returns a handler answering with the snapshots kept in memory, most recent first. Register it on an
+// internal or admin route.
+func (r *SlowRequestRecorder) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(r.Snapshots())
+	}
+}
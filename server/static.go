@@ -0,0 +1,193 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StaticOptions holds the options of Server.ServeStatic.
+type StaticOptions struct {
+	// Index is served for directories and as the SPA fallback. If empty, uses "index.html".
+	Index string
+	// SPA serves Index for unknown paths without a file extension, so client-side routes work on reload.
+	SPA bool
+	// MaxAge is the max-age of files without a content hash in the name. If zero, they are sent with
+	// "no-cache" and revalidated with their ETag.
+	MaxAge time.Duration
+	// ImmutableMaxAge is the max-age of files with a content hash in the name (e.g. "app.3f9c2b1e.js").
+	// If zero, uses 365 days.
+	ImmutableMaxAge time.Duration
+}
+
+// precompressed lists the pre-compressed variants looked up next to each file, in order of preference.
+var precompressed = []struct{ encoding, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// ServeStatic serves the assets of fsys under prefix, e.g. a frontend build embedded with embed.FS or a
+// directory opened with os.DirFS.
+//
+// Parameters:
+//
+//	prefix: Route prefix, e.g. "/" or "/assets".
+//	fsys: File system with the assets. Use fs.Sub to strip a build directory from an embed.FS.
+//	opts: Cache and SPA options.
+//
+// Behavior:
+//   - Files with a content hash in the name get "public, max-age=<ImmutableMaxAge>, immutable"; other files get
+//     MaxAge or "no-cache", and Index is always "no-cache".
+//   - Every file has a strong ETag computed from its content; If-None-Match is answered with 304.
+//   - When the client accepts it, a pre-compressed variant ("file.br" or "file.gz") is served instead of the file,
+//     with Content-Encoding and Vary: Accept-Encoding.
+//   - With SPA, unknown paths without a file extension are answered with Index; other unknown paths return 404.
+//   - Register it after the API routes, since it matches every path under prefix.
+//
+// Usage:
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	assets, _ := fs.Sub(dist, "dist")
+//	srv.ServeStatic("/", assets, server.StaticOptions{SPA: true})
+func (s *Server) ServeStatic(prefix string, fsys fs.FS, opts StaticOptions) {
+	if opts.Index == "" {
+		opts.Index = "index.html"
+	}
+	if opts.ImmutableMaxAge <= 0 {
+		opts.ImmutableMaxAge = 365 * 24 * time.Hour
+	}
+
+	static := &staticHandler{fsys: fsys, opts: opts}
+	s.App.Get(strings.TrimSuffix(prefix, "/")+"/*", static.serve)
+}
+
+type staticHandler struct {
+	fsys  fs.FS
+	opts  StaticOptions
+	etags sync.Map
+}
+
+func (h *staticHandler) serve(c *fiber.Ctx) error {
+	name := strings.TrimPrefix(path.Clean("/"+c.Params("*")), "/")
+	if name == "" {
+		name = h.opts.Index
+	}
+
+	if info, err := fs.Stat(h.fsys, name); err == nil && info.IsDir() {
+		name = path.Join(name, h.opts.Index)
+	}
+
+	if _, err := fs.Stat(h.fsys, name); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if !h.opts.SPA || path.Ext(name) != "" {
+			return fiber.ErrNotFound
+		}
+		name = h.opts.Index
+	}
+
+	return h.send(c, name)
+}
+
+func (h *staticHandler) send(c *fiber.Ctx, name string) error {
+	file, encoding := name, ""
+	accepted := c.Get(fiber.HeaderAcceptEncoding)
+	for _, variant := range precompressed {
+		if !strings.Contains(accepted, variant.encoding) {
+			continue
+		}
+		if _, err := fs.Stat(h.fsys, name+variant.ext); err == nil {
+			file, encoding = name+variant.ext, variant.encoding
+			break
+		}
+	}
+
+	data, err := fs.ReadFile(h.fsys, file)
+	if err != nil {
+		return err
+	}
+
+	c.Vary(fiber.HeaderAcceptEncoding)
+	c.Set(fiber.HeaderCacheControl, h.cacheControl(name))
+
+	etag := h.etag(file, data)
+	c.Set(fiber.HeaderETag, etag)
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		c.Set(fiber.HeaderContentType, contentType)
+	}
+	if encoding != "" {
+		c.Set(fiber.HeaderContentEncoding, encoding)
+	}
+
+	return c.Send(data)
+}
+
+func (h *staticHandler) cacheControl(name string) string {
+	switch {
+	case path.Base(name) == h.opts.Index:
+		return "no-cache"
+	case hasContentHash(path.Base(name)):
+		return "public, max-age=" + strconv.Itoa(int(h.opts.ImmutableMaxAge.Seconds())) + ", immutable"
+	case h.opts.MaxAge > 0:
+		return "public, max-age=" + strconv.Itoa(int(h.opts.MaxAge.Seconds()))
+	default:
+		return "no-cache"
+	}
+}
+
+// etag returns the strong ETag of file, computed once per file.
+func (h *staticHandler) etag(file string, data []byte) string {
+	if etag, ok := h.etags.Load(file); ok {
+		return etag.(string)
+	}
+
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	h.etags.Store(file, etag)
+	return etag
+}
+
+// hasContentHash reports whether a file name carries a content hash added by the bundler, as a segment of 8 or
+// more letters and digits, with at least one digit, before the extension: "app.3f9c2b1e.js", "index-B4x9kQ2a.css".
+func hasContentHash(name string) bool {
+	name = strings.TrimSuffix(name, path.Ext(name))
+
+	i := strings.LastIndexAny(name, ".-_")
+	if i < 0 {
+		return false
+	}
+
+	segment := name[i+1:]
+	if len(segment) < 8 {
+		return false
+	}
+
+	digits := 0
+	for _, r := range segment {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+		default:
+			return false
+		}
+	}
+
+	return digits > 0
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devluispereira/go-package/observability"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/devluispereira/go-package/server"
+
+// TracingMiddleware starts a server span for each request, continuing the trace received in the
+// traceparent/baggage headers, and stores the span in the user context so downstream httpclient calls join it.
+//
+// Usage:
+//
+//	app.Use(TracingMiddleware())
+func TracingMiddleware() fiber.Handler {
+	tracer := otel.Tracer(instrumentationName)
+
+	return func(c *fiber.Ctx) error {
+		headers := make(http.Header)
+		for k, v := range c.GetReqHeaders() {
+			headers[k] = v
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), propagation.HeaderCarrier(headers))
+		ctx, span := tracer.Start(ctx, c.Method()+" "+c.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Method()),
+				attribute.String("url.path", c.Path()),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		status := responseStatus(c, err)
+		span.SetName(c.Method() + " " + c.Route().Path)
+		span.SetAttributes(
+			attribute.String("http.route", c.Route().Path),
+			attribute.Int("http.response.status_code", status),
+		)
+
+		if err != nil {
+			span.RecordError(err)
+		}
+		if status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		return err
+	}
+}
+
+// Metric labels recorded by MetricsMiddleware.
+const (
+	LabelMethod = "http.request.method"
+	LabelRoute  = "http.route"
+	LabelStatus = "http.response.status_code"
+	// LabelTenant is the ID of the tenant resolved by TenantMiddleware. Requests without tenant don't carry it.
+	LabelTenant = "tenant"
+)
+
+// MetricsConfig holds the configuration of MetricsMiddlewareWithConfig. It can be loaded with the config package.
+type MetricsConfig struct {
+	// Labels is the allowlist of recorded labels, among LabelMethod, LabelRoute, LabelStatus and LabelTenant. If
+	// empty, records all of them.
+	Labels []string `json:"labels" env:"METRICS_LABELS"`
+	// CardinalityLimit is the maximum number of distinct values per label; new values beyond it are recorded as
+	// "other". If zero, uses 100.
+	CardinalityLimit int `json:"cardinality_limit" env:"METRICS_CARDINALITY_LIMIT" default:"100"`
+	// Histogram configures the buckets, or the exponential aggregation, of the duration histogram. If empty, uses
+	// observability.DefaultLatencyBuckets.
+	Histogram observability.HistogramConfig `json:"histogram" envPrefix:"METRICS_"`
+}
+
+// MetricsMiddleware records the duration of each request in the "http.server.request.duration" histogram,
+// labeled by method, route pattern and status code. See MetricsMiddlewareWithConfig.
+//
+// Usage:
+//
+//	app.Use(MetricsMiddleware())
+func MetricsMiddleware() fiber.Handler {
+	return MetricsMiddlewareWithConfig(MetricsConfig{})
+}
+
+// MetricsMiddlewareWithConfig records the duration of each request in the "http.server.request.duration"
+// histogram, keeping the number of series bounded.
+//
+// Parameters:
+//
+//	cfg: Allowlist of labels, cardinality limit and histogram buckets.
+//
+// Behavior:
+//   - The route label is the Fiber route pattern ("/products/:id"), never the raw path; requests matching no
+//     route are labeled "unmatched".
+//   - Non-standard methods are labeled "_OTHER" and label values are sanitized (see observability.SanitizeLabel).
+//   - Requests with a tenant (see TenantMiddleware, registered after this middleware) are labeled with its ID.
+//   - Each label keeps at most CardinalityLimit distinct values, aggregating the overflow into "other".
+//
+// Usage:
+//
+//	app.Use(MetricsMiddlewareWithConfig(MetricsConfig{Labels: []string{LabelRoute, LabelStatus}}))
+func MetricsMiddlewareWithConfig(cfg MetricsConfig) fiber.Handler {
+	duration, _ := observability.LatencyHistogram(instrumentationName, "http.server.request.duration",
+		"Duration of incoming HTTP requests.", cfg.Histogram)
+
+	labels := cfg.Labels
+	if len(labels) == 0 {
+		labels = []string{LabelMethod, LabelRoute, LabelStatus, LabelTenant}
+	}
+	limiter := observability.NewLabelLimiter(cfg.CardinalityLimit)
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		own := c.Route()
+		err := c.Next()
+
+		values := map[string]string{
+			LabelMethod: observability.MethodLabel(c.Method()),
+			LabelRoute:  routeLabel(c, own, err),
+			LabelStatus: strconv.Itoa(responseStatus(c, err)),
+		}
+		if tenant, ok := TenantFromContext(c.UserContext()); ok {
+			values[LabelTenant] = tenant.ID
+		}
+
+		attrs := make([]attribute.KeyValue, 0, len(labels))
+		for _, label := range labels {
+			if value, ok := values[label]; ok {
+				attrs = append(attrs, attribute.String(label, limiter.Value(label, value)))
+			}
+		}
+
+		duration.Record(c.UserContext(), time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+
+		return err
+	}
+}
+
+// routeLabel returns the pattern of the route that handled the request, or "unmatched" when no route matched:
+// the request never left the middleware chain (own is the route of the calling middleware) or got Fiber's
+// "Cannot <method> <path>" 404.
+func routeLabel(c *fiber.Ctx, own *fiber.Route, err error) string {
+	var fe *fiber.Error
+	if errors.As(err, &fe) && fe.Code == fiber.StatusNotFound && strings.HasPrefix(fe.Message, "Cannot ") {
+		return "unmatched"
+	}
+
+	route := c.Route()
+	if route == own {
+		return "unmatched"
+	}
+	return route.Path
+}
+
+// EnableTelemetry applies TracingMiddleware and MetricsMiddleware (configured by ServerConfig.Metrics) to the
+// routes registered afterwards and exposes the Prometheus metrics handler at metricsPath (e.g. "/metrics").
+// Call it after observability.Init and before registering the application routes.
+func (s *Server) EnableTelemetry(metricsPath string, metricsHandler http.Handler) {
+	s.App.Use(TracingMiddleware())
+	s.App.Use(MetricsMiddlewareWithConfig(s.metrics))
+
+	if metricsPath != "" && metricsHandler != nil {
+		s.App.Get(metricsPath, adaptor.HTTPHandler(metricsHandler))
+	}
+}
+
+// responseStatus returns the status code that will be sent, considering errors handled by Fiber's error handler.
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err != nil {
+		if fe, ok := err.(*fiber.Error); ok {
+			return fe.Code
+		}
+		return fiber.StatusInternalServerError
+	}
+
+	return c.Response().StatusCode()
+}
@@ -0,0 +1,414 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/devluispereira/go-package/clock"
+	"github.com/devluispereira/go-package/logging"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// Tenant represents the tenant resolved for the current request.
+type Tenant struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Tier     string            `json:"tier"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// CacheNamespace returns a prefix suitable for namespacing cache keys per tenant.
+func (t *Tenant) CacheNamespace() string {
+	return "tenant:" + t.ID + ":"
+}
+
+type TenantKeyType struct{}
+
+// ErrTenantNotFound is returned by a TenantProvider when the tenant ID is unknown.
+var ErrTenantNotFound = errors.New("tenant not found")
+
+// ErrNoTenantProvider is returned when TenantMiddleware or NewCachedTenantProvider is used without a provider.
+var ErrNoTenantProvider = errors.New("tenant provider not configured")
+
+// TenantProvider validates a tenant ID and returns the tenant data.
+type TenantProvider interface {
+	GetTenant(ctx context.Context, id string) (*Tenant, error)
+}
+
+// TenantResolver extracts a tenant ID from the incoming request. It returns an empty string when not found.
+type TenantResolver func(c *fiber.Ctx) string
+
+// TenantConfig holds the configuration for the tenant middleware.
+type TenantConfig struct {
+	Provider  TenantProvider
+	Resolvers []TenantResolver
+	Required  bool
+}
+
+// TenantMiddleware resolves the tenant of the request, validates it against the configured provider
+// and injects the typed Tenant into the request context.
+//
+// Parameters:
+//
+//	cfg: Tenant configuration.
+//	  - Provider: Source used to validate the tenant (StaticTenantProvider, RedisTenantProvider, HTTPTenantProvider).
+//	  - Resolvers: Ordered list of resolvers. The first non-empty value wins. If empty, uses x-tenant-id header.
+//	  - Required: If true, requests without tenant are rejected with 400.
+//
+// Behavior:
+//   - Unknown tenants are rejected with 403.
+//   - Without Provider, requests with a tenant fail with an error wrapping ErrNoTenantProvider (500).
+//   - Stores the tenant in the user context (see TenantFromContext) and in c.Locals("tenant").
+//   - Adds the "tenant" field to the request-scoped logger (see logging.FromContext).
+//   - Adds "x-tenant-id" to the forwarded headers, so downstream calls and cache keys are namespaced by tenant.
+//   - MetricsMiddleware labels the request with the tenant ID (see LabelTenant) and RoutePolicyMiddleware counts
+//     rate limits per tenant. The latter runs before middlewares added with app.Use, so configure the tenant in
+//     ServerConfig.Tenant, or add TenantMiddleware before RoutePolicyMiddleware.
+//
+// Usage:
+//
+//	app.Use(TenantMiddleware(TenantConfig{
+//		Provider:  StaticTenantProvider{"acme": {ID: "acme", Name: "Acme"}},
+//		Resolvers: []TenantResolver{TenantFromHeader("x-tenant-id"), TenantFromPathPrefix("/t")},
+//	}))
+func TenantMiddleware(cfg TenantConfig) fiber.Handler {
+	resolvers := cfg.Resolvers
+	if len(resolvers) == 0 {
+		resolvers = []TenantResolver{TenantFromHeader("x-tenant-id")}
+	}
+
+	return func(c *fiber.Ctx) error {
+		var id string
+		for _, resolve := range resolvers {
+			if id = resolve(c); id != "" {
+				break
+			}
+		}
+
+		if id == "" {
+			if cfg.Required {
+				return fiber.NewError(fiber.StatusBadRequest, "missing tenant")
+			}
+			return c.Next()
+		}
+
+		if cfg.Provider == nil {
+			return fmt.Errorf("error resolving tenant: %w", ErrNoTenantProvider)
+		}
+
+		tenant, err := cfg.Provider.GetTenant(c.UserContext(), id)
+		if errors.Is(err, ErrTenantNotFound) {
+			return fiber.NewError(fiber.StatusForbidden, "unknown tenant")
+		}
+		if err != nil {
+			return fmt.Errorf("error resolving tenant: %w", err)
+		}
+
+		ctx := context.WithValue(c.UserContext(), TenantKeyType{}, tenant)
+		ctx = logging.With(ctx, "tenant", tenant.ID)
+
+		if headers, ok := ctx.Value("forwardedHeaders").(map[string]string); ok {
+			headers["x-tenant-id"] = tenant.ID
+		}
+
+		c.SetUserContext(ctx)
+		c.Locals("tenant", tenant)
+		return c.Next()
+	}
+}
+
+// TenantFromContext returns the tenant stored by TenantMiddleware, if any.
+func TenantFromContext(ctx context.Context) (*Tenant, bool) {
+	tenant, ok := ctx.Value(TenantKeyType{}).(*Tenant)
+	return tenant, ok
+}
+
+// TenantFromHeader resolves the tenant ID from the given request header.
+func TenantFromHeader(header string) TenantResolver {
+	return func(c *fiber.Ctx) string {
+		return c.Get(header)
+	}
+}
+
+// TenantFromHost resolves the tenant ID from the subdomain of the request host.
+// For suffix ".example.com", the host "acme.example.com" resolves to "acme".
+func TenantFromHost(suffix string) TenantResolver {
+	return func(c *fiber.Ctx) string {
+		host := c.Hostname()
+		if i := strings.IndexByte(host, ':'); i >= 0 {
+			host = host[:i]
+		}
+
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+
+		return strings.TrimSuffix(host, suffix)
+	}
+}
+
+// TenantFromPathPrefix resolves the tenant ID from the path segment following prefix. For prefix "/t", the path
+// "/t/acme/products" resolves to "acme"; paths outside the prefix, such as "/healthcheck", resolve to no tenant.
+// The prefix is required: an empty prefix matches no path, so arbitrary first segments are never taken as tenants.
+func TenantFromPathPrefix(prefix string) TenantResolver {
+	prefix = "/" + strings.Trim(prefix, "/") + "/"
+
+	return func(c *fiber.Ctx) string {
+		path, ok := strings.CutPrefix(c.Path(), prefix)
+		if !ok {
+			return ""
+		}
+
+		segment, _, _ := strings.Cut(path, "/")
+		return segment
+	}
+}
+
+// StaticTenantProvider validates tenants against a fixed map of tenant ID to Tenant.
+type StaticTenantProvider map[string]Tenant
+
+func (p StaticTenantProvider) GetTenant(_ context.Context, id string) (*Tenant, error) {
+	tenant, ok := p[id]
+	if !ok {
+		return nil, ErrTenantNotFound
+	}
+
+	if tenant.ID == "" {
+		tenant.ID = id
+	}
+
+	return &tenant, nil
+}
+
+// ITenantRedisClient defines the Redis operations used by RedisTenantProvider.
+type ITenantRedisClient interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// RedisTenantProvider validates tenants stored in Redis as JSON under KeyPrefix + tenant ID.
+type RedisTenantProvider struct {
+	Client    ITenantRedisClient
+	KeyPrefix string
+}
+
+func (p *RedisTenantProvider) GetTenant(ctx context.Context, id string) (*Tenant, error) {
+	value, err := p.Client.Get(ctx, p.KeyPrefix+id)
+	if errors.Is(err, redis.Nil) || (err == nil && value == "") {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant from redis: %w", err)
+	}
+
+	var tenant Tenant
+	if err := json.Unmarshal([]byte(value), &tenant); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tenant: %w", err)
+	}
+
+	if tenant.ID == "" {
+		tenant.ID = id
+	}
+
+	return &tenant, nil
+}
+
+// HTTPTenantProvider validates tenants against an HTTP API.
+// PathPattern must contain a single %s placeholder for the tenant ID, e.g. "/tenants/%s".
+type HTTPTenantProvider struct {
+	Client      *httpclient.HTTPClient
+	PathPattern string
+}
+
+func (p *HTTPTenantProvider) GetTenant(ctx context.Context, id string) (*Tenant, error) {
+	tenant, resp, err := httpclient.GetAs[Tenant](ctx, p.Client, fmt.Sprintf(p.PathPattern, url.PathEscape(id)))
+	// The status is checked first: error pages, e.g. an HTML 404 of a gateway, don't decode.
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrTenantNotFound
+	}
+	if resp != nil && resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status from tenant api: %d", resp.StatusCode)
+	}
+	if errors.Is(err, httpclient.ErrDecodeResponse) {
+		return nil, fmt.Errorf("failed to unmarshal tenant: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant from api: %w", err)
+	}
+
+	if tenant.ID == "" {
+		tenant.ID = id
+	}
+
+	return &tenant, nil
+}
+
+// CachedTenantProviderConfig holds the configuration of NewCachedTenantProviderWithConfig.
+type CachedTenantProviderConfig struct {
+	// Provider is the wrapped TenantProvider.
+	Provider TenantProvider `json:"-"`
+	// TTL is how long a found tenant is cached. Defaults to 1m.
+	TTL time.Duration `json:"ttl" env:"TENANT_CACHE_TTL" default:"1m"`
+	// NegativeTTL is how long an unknown tenant ID is cached. Defaults to 10s, or TTL if shorter.
+	NegativeTTL time.Duration `json:"negative_ttl" env:"TENANT_CACHE_NEGATIVE_TTL" default:"10s"`
+	// MaxEntries bounds the tenants cached; the least recently used is evicted first. Defaults to 10000.
+	MaxEntries int `json:"max_entries" env:"TENANT_CACHE_MAX_ENTRIES" default:"10000"`
+	// MaxNegativeEntries bounds the unknown IDs cached, apart from the tenants, so requests with random IDs
+	// can't evict the real tenants. Defaults to 1000.
+	MaxNegativeEntries int `json:"max_negative_entries" env:"TENANT_CACHE_MAX_NEGATIVE_ENTRIES" default:"1000"`
+	// Clock expires the entries. Defaults to clock.Real.
+	Clock clock.Clock `json:"-"`
+}
+
+// NewCachedTenantProvider wraps a TenantProvider with an in-memory cache, avoiding a lookup on every request.
+// Unknown tenants are cached too, for at most 10s, so invalid IDs don't hit the underlying provider repeatedly.
+// See NewCachedTenantProviderWithConfig for the bounds of the cache.
+func NewCachedTenantProvider(provider TenantProvider, ttl time.Duration) TenantProvider {
+	return NewCachedTenantProviderWithConfig(CachedTenantProviderConfig{Provider: provider, TTL: ttl})
+}
+
+// NewCachedTenantProviderWithConfig wraps a TenantProvider with a bounded in-memory cache.
+//
+// Behavior:
+//   - Found tenants are cached for TTL, unknown IDs (ErrTenantNotFound) for NegativeTTL, in separate LRUs of
+//     MaxEntries and MaxNegativeEntries entries. Other errors are not cached.
+//   - A nil Provider fails every lookup with ErrNoTenantProvider.
+//
+// Usage:
+//
+//	provider := server.NewCachedTenantProviderWithConfig(server.CachedTenantProviderConfig{
+//		Provider:   &server.RedisTenantProvider{Client: redis, KeyPrefix: "tenants:"},
+//		TTL:        time.Minute,
+//		MaxEntries: 5000,
+//	})
+func NewCachedTenantProviderWithConfig(cfg CachedTenantProviderConfig) TenantProvider {
+	if cfg.TTL <= 0 {
+		cfg.TTL = time.Minute
+	}
+	if cfg.NegativeTTL <= 0 {
+		cfg.NegativeTTL = 10 * time.Second
+	}
+	cfg.NegativeTTL = min(cfg.NegativeTTL, cfg.TTL)
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 10000
+	}
+	if cfg.MaxNegativeEntries <= 0 {
+		cfg.MaxNegativeEntries = 1000
+	}
+
+	return &cachedTenantProvider{
+		cfg:      cfg,
+		clock:    clock.Or(cfg.Clock),
+		found:    newTenantLRU(cfg.MaxEntries),
+		notFound: newTenantLRU(cfg.MaxNegativeEntries),
+	}
+}
+
+type cachedTenant struct {
+	id        string
+	tenant    *Tenant
+	expiresAt time.Time
+}
+
+type cachedTenantProvider struct {
+	cfg      CachedTenantProviderConfig
+	clock    clock.Clock
+	mu       sync.Mutex
+	found    *tenantLRU
+	notFound *tenantLRU
+}
+
+func (p *cachedTenantProvider) GetTenant(ctx context.Context, id string) (*Tenant, error) {
+	if p.cfg.Provider == nil {
+		return nil, ErrNoTenantProvider
+	}
+
+	now := p.clock.Now()
+
+	p.mu.Lock()
+	if entry, ok := p.found.get(id, now); ok {
+		p.mu.Unlock()
+		return entry.tenant, nil
+	}
+	if _, ok := p.notFound.get(id, now); ok {
+		p.mu.Unlock()
+		return nil, ErrTenantNotFound
+	}
+	p.mu.Unlock()
+
+	tenant, err := p.cfg.Provider.GetTenant(ctx, id)
+	if err != nil && !errors.Is(err, ErrTenantNotFound) {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	if err != nil {
+		p.notFound.put(cachedTenant{id: id, expiresAt: now.Add(p.cfg.NegativeTTL)})
+	} else {
+		p.notFound.remove(id)
+		p.found.put(cachedTenant{id: id, tenant: tenant, expiresAt: now.Add(p.cfg.TTL)})
+	}
+	p.mu.Unlock()
+
+	return tenant, err
+}
+
+// tenantLRU is a least recently used cache of tenants. It is not safe for concurrent use.
+type tenantLRU struct {
+	max     int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newTenantLRU(max int) *tenantLRU {
+	return &tenantLRU{max: max, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// get returns the entry of id unless expired at now, marking it as recently used.
+func (l *tenantLRU) get(id string, now time.Time) (cachedTenant, bool) {
+	elem, ok := l.entries[id]
+	if !ok {
+		return cachedTenant{}, false
+	}
+
+	entry := elem.Value.(cachedTenant)
+	if !now.Before(entry.expiresAt) {
+		l.remove(id)
+		return cachedTenant{}, false
+	}
+
+	l.order.MoveToFront(elem)
+	return entry, true
+}
+
+// put stores entry, evicting the least recently used entry when full.
+func (l *tenantLRU) put(entry cachedTenant) {
+	if elem, ok := l.entries[entry.id]; ok {
+		elem.Value = entry
+		l.order.MoveToFront(elem)
+		return
+	}
+
+	l.entries[entry.id] = l.order.PushFront(entry)
+	if l.order.Len() > l.max {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(cachedTenant).id)
+	}
+}
+
+func (l *tenantLRU) remove(id string) {
+	if elem, ok := l.entries[id]; ok {
+		l.order.Remove(elem)
+		delete(l.entries, id)
+	}
+}
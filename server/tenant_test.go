@@ -0,0 +1,273 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devluispereira/go-package/clients/httpclient"
+	"github.com/devluispereira/go-package/clock/clocktest"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// countingTenantProvider counts the lookups of StaticTenantProvider.
+type countingTenantProvider struct {
+	StaticTenantProvider
+	calls atomic.Int32
+}
+
+func (p *countingTenantProvider) GetTenant(ctx context.Context, id string) (*Tenant, error) {
+	p.calls.Add(1)
+	return p.StaticTenantProvider.GetTenant(ctx, id)
+}
+
+func TestCachedTenantProviderEvictsLeastRecentlyUsed(t *testing.T) {
+	provider := &countingTenantProvider{StaticTenantProvider: StaticTenantProvider{"a": {}, "b": {}, "c": {}}}
+	cached := NewCachedTenantProviderWithConfig(CachedTenantProviderConfig{Provider: provider, MaxEntries: 2})
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b", "a", "c"} {
+		if _, err := cached.GetTenant(ctx, id); err != nil {
+			t.Fatalf("GetTenant(%s): %v", id, err)
+		}
+	}
+	// "b" was the least recently used when "c" was added.
+	_, _ = cached.GetTenant(ctx, "a")
+	if n := provider.calls.Load(); n != 3 {
+		t.Fatalf("provider called %d times, want 3", n)
+	}
+	_, _ = cached.GetTenant(ctx, "b")
+	if n := provider.calls.Load(); n != 4 {
+		t.Fatalf("provider called %d times, want 4", n)
+	}
+}
+
+func TestCachedTenantProviderExpiresEntries(t *testing.T) {
+	clk := clocktest.NewFake(time.Time{})
+	provider := &countingTenantProvider{StaticTenantProvider: StaticTenantProvider{"a": {}}}
+	cached := NewCachedTenantProviderWithConfig(CachedTenantProviderConfig{
+		Provider:    provider,
+		TTL:         time.Minute,
+		NegativeTTL: time.Second,
+		Clock:       clk,
+	})
+	ctx := context.Background()
+
+	_, _ = cached.GetTenant(ctx, "a")
+	if _, err := cached.GetTenant(ctx, "unknown"); !errors.Is(err, ErrTenantNotFound) {
+		t.Fatalf("got %v, want ErrTenantNotFound", err)
+	}
+	_, _ = cached.GetTenant(ctx, "a")
+	_, _ = cached.GetTenant(ctx, "unknown")
+	if n := provider.calls.Load(); n != 2 {
+		t.Fatalf("provider called %d times, want 2", n)
+	}
+
+	// Unknown IDs expire first.
+	clk.Advance(2 * time.Second)
+	_, _ = cached.GetTenant(ctx, "a")
+	_, _ = cached.GetTenant(ctx, "unknown")
+	if n := provider.calls.Load(); n != 3 {
+		t.Fatalf("provider called %d times, want 3", n)
+	}
+
+	clk.Advance(time.Minute)
+	_, _ = cached.GetTenant(ctx, "a")
+	if n := provider.calls.Load(); n != 4 {
+		t.Fatalf("provider called %d times, want 4", n)
+	}
+}
+
+func TestCachedTenantProviderBoundsUnknownIDsApart(t *testing.T) {
+	provider := &countingTenantProvider{StaticTenantProvider: StaticTenantProvider{"a": {}}}
+	cached := NewCachedTenantProviderWithConfig(CachedTenantProviderConfig{
+		Provider:           provider,
+		MaxEntries:         1,
+		MaxNegativeEntries: 1,
+	})
+	ctx := context.Background()
+
+	_, _ = cached.GetTenant(ctx, "a")
+	for _, id := range []string{"x", "y", "z"} {
+		_, _ = cached.GetTenant(ctx, id)
+	}
+	_, _ = cached.GetTenant(ctx, "a")
+	if n := provider.calls.Load(); n != 4 {
+		t.Fatalf("provider called %d times, want 4: unknown IDs evicted a tenant", n)
+	}
+}
+
+func TestCachedTenantProviderWithoutProvider(t *testing.T) {
+	cached := NewCachedTenantProvider(nil, time.Minute)
+	if _, err := cached.GetTenant(context.Background(), "a"); !errors.Is(err, ErrNoTenantProvider) {
+		t.Fatalf("got %v, want ErrNoTenantProvider", err)
+	}
+}
+
+func TestTenantMiddlewareWithoutProvider(t *testing.T) {
+	app := fiber.New()
+	app.Use(TenantMiddleware(TenantConfig{}))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("x-tenant-id", "acme")
+	if status := doStatus(t, app, req); status != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500", status)
+	}
+}
+
+func TestHTTPTenantProvider(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tenants/acme" {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("<html>Not Found</html>"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"Acme","tier":"gold"}`))
+	}))
+	defer api.Close()
+
+	provider := &HTTPTenantProvider{
+		Client:      httpclient.NewHTTPClient(api.URL, time.Second),
+		PathPattern: "/tenants/%s",
+	}
+
+	tenant, err := provider.GetTenant(context.Background(), "acme")
+	if err != nil {
+		t.Fatalf("GetTenant: %v", err)
+	}
+	if tenant.ID != "acme" || tenant.Name != "Acme" || tenant.Tier != "gold" {
+		t.Fatalf("got %+v", tenant)
+	}
+
+	if _, err := provider.GetTenant(context.Background(), "unknown"); !errors.Is(err, ErrTenantNotFound) {
+		t.Fatalf("got %v, want ErrTenantNotFound", err)
+	}
+}
+
+func TestTenantFromPathPrefixOnlyResolvesUnderThePrefix(t *testing.T) {
+	app := fiber.New()
+	app.Use(TenantMiddleware(TenantConfig{
+		Provider:  StaticTenantProvider{"acme": {}},
+		Resolvers: []TenantResolver{TenantFromPathPrefix("/t")},
+	}))
+	app.Get("/*", func(c *fiber.Ctx) error {
+		if tenant, ok := TenantFromContext(c.UserContext()); ok {
+			return c.SendString(tenant.ID)
+		}
+		return c.SendString("none")
+	})
+
+	cases := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/healthcheck", http.StatusOK, "none"},
+		{"/api/products", http.StatusOK, "none"},
+		{"/t/acme/products", http.StatusOK, "acme"},
+		{"/t/unknown/products", http.StatusForbidden, ""},
+	}
+	for _, tc := range cases {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, tc.path, nil), -1)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tc.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.status {
+			t.Fatalf("%s: status %d, want %d", tc.path, resp.StatusCode, tc.status)
+		}
+		if tc.status == http.StatusOK && string(body) != tc.body {
+			t.Fatalf("%s: tenant %q, want %q", tc.path, body, tc.body)
+		}
+	}
+
+	// An empty prefix doesn't fall back to the first segment.
+	app = fiber.New()
+	app.Use(TenantMiddleware(TenantConfig{
+		Provider:  StaticTenantProvider{"acme": {}},
+		Resolvers: []TenantResolver{TenantFromPathPrefix("")},
+		Required:  true,
+	}))
+	app.Get("/*", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	if status := doStatus(t, app, httptest.NewRequest(http.MethodGet, "/acme/products", nil)); status != http.StatusBadRequest {
+		t.Fatalf("empty prefix: status %d, want 400", status)
+	}
+}
+
+func TestServerTenantRateLimitsEachTenantApart(t *testing.T) {
+	srv := NewServerWithConfig(ServerConfig{
+		Tenant: &TenantConfig{Provider: StaticTenantProvider{"acme": {}, "globex": {}}},
+		RoutePolicies: RoutePolicyConfig{
+			Policies:       []RoutePolicy{{Route: "/api/**", RateLimitTier: "strict"}},
+			RateLimitTiers: map[string]RateLimitTier{"strict": {Requests: 1, Window: time.Minute}},
+			Clock:          clocktest.NewFake(time.Time{}),
+		},
+	})
+	srv.App.Get("/api/products", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	request := func(tenant string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/products", nil)
+		req.Header.Set("x-tenant-id", tenant)
+		return doStatus(t, srv.App, req)
+	}
+
+	if status := request("acme"); status != http.StatusOK {
+		t.Fatalf("acme: status %d, want 200", status)
+	}
+	if status := request("acme"); status != http.StatusTooManyRequests {
+		t.Fatalf("acme again: status %d, want 429", status)
+	}
+	// Same client address, another tenant: its own limit.
+	if status := request("globex"); status != http.StatusOK {
+		t.Fatalf("globex: status %d, want 200", status)
+	}
+}
+
+func TestMetricsMiddlewareLabelsTheTenant(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(previous)
+
+	app := fiber.New()
+	app.Use(MetricsMiddleware())
+	app.Use(TenantMiddleware(TenantConfig{Provider: StaticTenantProvider{"acme": {}}}))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("x-tenant-id", "acme")
+	doStatus(t, app, req)
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			histogram, ok := m.Data.(metricdata.Histogram[float64])
+			if m.Name != "http.server.request.duration" || !ok {
+				continue
+			}
+			for _, point := range histogram.DataPoints {
+				if value, ok := point.Attributes.Value(attribute.Key(LabelTenant)); ok && value.AsString() == "acme" {
+					return
+				}
+			}
+		}
+	}
+	t.Fatal("request duration not labeled with the tenant")
+}
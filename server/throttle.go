@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/devluispereira/go-package/clock"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ThrottleConfig holds the configuration for the concurrency throttle of heavy routes.
+type ThrottleConfig struct {
+	// Name identifies the throttle in metrics.
+	Name string
+	// MaxConcurrent is the number of requests executed at the same time. Defaults to 1.
+	MaxConcurrent int
+	// MaxQueue is the number of requests waiting for a slot. Requests beyond it are rejected immediately.
+	MaxQueue int
+	// QueueTimeout is the maximum time a request waits in the queue. Defaults to 10s.
+	QueueTimeout time.Duration
+	// RetryAfter is sent in the Retry-After header of rejected requests. Defaults to QueueTimeout.
+	RetryAfter time.Duration
+	// Clock times QueueTimeout. Defaults to clock.Real.
+	Clock clock.Clock
+}
+
+// ThrottleMiddleware limits the concurrency of expensive routes (e.g. report generation), queuing excess requests.
+//
+// Parameters:
+//
+//	cfg: Throttle configuration.
+//
+// Behavior:
+//   - Up to MaxConcurrent requests run at once; up to MaxQueue more wait for a free slot.
+//   - Requests are rejected with 503 and Retry-After when the queue is full or QueueTimeout expires.
+//   - Exposes the http.server.throttle.active and http.server.throttle.queued gauges and the
+//     http.server.throttle.rejected counter, labelled by throttle name.
+//
+// Usage:
+//
+//	app.Post("/reports", ThrottleMiddleware(ThrottleConfig{Name: "reports", MaxConcurrent: 4, MaxQueue: 20}), handler)
+func ThrottleMiddleware(cfg ThrottleConfig) fiber.Handler {
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 1
+	}
+	if cfg.QueueTimeout <= 0 {
+		cfg.QueueTimeout = 10 * time.Second
+	}
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = cfg.QueueTimeout
+	}
+	cfg.Clock = clock.Or(cfg.Clock)
+
+	slots := make(chan struct{}, cfg.MaxConcurrent)
+	var queued atomic.Int64
+
+	nameAttr := metric.WithAttributes(attribute.String("throttle", cfg.Name))
+	meter := otel.Meter(instrumentationName)
+	rejected, _ := meter.Int64Counter("http.server.throttle.rejected",
+		metric.WithDescription("Requests rejected by the throttle."))
+	active, _ := meter.Int64ObservableGauge("http.server.throttle.active",
+		metric.WithDescription("Requests running under the throttle."))
+	depth, _ := meter.Int64ObservableGauge("http.server.throttle.queued",
+		metric.WithDescription("Requests waiting in the throttle queue."))
+	_, _ = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(active, int64(len(slots)), nameAttr)
+		o.ObserveInt64(depth, queued.Load(), nameAttr)
+		return nil
+	}, active, depth)
+
+	reject := func(c *fiber.Ctx, reason string) error {
+		rejected.Add(c.UserContext(), 1, metric.WithAttributes(
+			attribute.String("throttle", cfg.Name),
+			attribute.String("reason", reason),
+		))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(cfg.RetryAfter.Seconds())))
+		return fiber.NewError(fiber.StatusServiceUnavailable, "server busy")
+	}
+
+	return func(c *fiber.Ctx) error {
+		select {
+		case slots <- struct{}{}:
+		default:
+			if queued.Add(1) > int64(cfg.MaxQueue) {
+				queued.Add(-1)
+				return reject(c, "queue_full")
+			}
+
+			timer := cfg.Clock.NewTimer(cfg.QueueTimeout)
+			select {
+			case slots <- struct{}{}:
+				timer.Stop()
+				queued.Add(-1)
+			case <-timer.C():
+				queued.Add(-1)
+				return reject(c, "queue_timeout")
+			case <-c.UserContext().Done():
+				timer.Stop()
+				queued.Add(-1)
+				return reject(c, "canceled")
+			}
+		}
+
+		defer func() { <-slots }()
+		return c.Next()
+	}
+}
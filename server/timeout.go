@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestTimeoutConfig holds the configuration for the request timeout middleware.
+type RequestTimeoutConfig struct {
+	// Header carries the caller's remaining budget. Defaults to X-Request-Timeout; grpc-timeout is also accepted.
+	Header string
+	// Default is applied when the request carries no timeout. Zero leaves such requests without deadline.
+	Default time.Duration
+	// Max caps the timeout requested by callers. Zero disables the cap.
+	Max time.Duration
+}
+
+// RequestTimeoutMiddleware sets a context deadline for the handler from the timeout sent by the caller,
+// so downstream httpclient calls stop when the caller has already given up.
+//
+// Parameters:
+//
+//	cfg: Timeout configuration.
+//
+// Behavior:
+//   - Accepts Go durations ("1.5s", "800ms"), plain milliseconds ("800") and grpc-timeout values ("800m", "2S").
+//   - Invalid or non-positive values are ignored and Default is used.
+//   - The deadline is set on the user context; httpclient forwards the remaining budget in X-Request-Timeout.
+//
+// Usage:
+//
+//	app.Use(RequestTimeoutMiddleware(RequestTimeoutConfig{Default: 5 * time.Second, Max: 30 * time.Second}))
+func RequestTimeoutMiddleware(cfg RequestTimeoutConfig) fiber.Handler {
+	if cfg.Header == "" {
+		cfg.Header = "X-Request-Timeout"
+	}
+
+	return func(c *fiber.Ctx) error {
+		timeout, ok := parseRequestTimeout(c.Get(cfg.Header))
+		if !ok {
+			timeout, ok = parseGRPCTimeout(c.Get("grpc-timeout"))
+		}
+		if !ok {
+			timeout = cfg.Default
+		}
+
+		if cfg.Max > 0 && timeout > cfg.Max {
+			timeout = cfg.Max
+		}
+
+		if timeout <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+func parseRequestTimeout(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, ms > 0
+	}
+
+	if timeout, err := time.ParseDuration(value); err == nil {
+		return timeout, timeout > 0
+	}
+
+	return parseGRPCTimeout(value)
+}
+
+// parseGRPCTimeout parses the grpc-timeout format: an integer followed by H, M, S, m, u or n.
+func parseGRPCTimeout(value string) (time.Duration, bool) {
+	if len(value) < 2 {
+		return 0, false
+	}
+
+	units := map[byte]time.Duration{
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+		'm': time.Millisecond,
+		'u': time.Microsecond,
+		'n': time.Nanosecond,
+	}
+
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return 0, false
+	}
+
+	amount, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if err != nil || amount <= 0 {
+		return 0, false
+	}
+
+	return time.Duration(amount) * unit, true
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Limits of the W3C Trace Context and Baggage specifications.
+const (
+	maxTraceStateMembers = 32
+	maxTraceStateLength  = 512
+	maxBaggageMembers    = 180
+	maxBaggageLength     = 8192
+)
+
+var (
+	traceparentPattern = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}(-.*)?$`)
+	tracestatePattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9_\-*/@]{0,255}=[\x20-\x2b\x2d-\x3c\x3e-\x7e]{0,255}[\x21-\x2b\x2d-\x3c\x3e-\x7e]$`)
+	baggageKeyPattern  = regexp.MustCompile("^[!#$%&'*+\\-.^_`|~0-9A-Za-z]+$")
+)
+
+// ValidForwardedHeader reports whether value is valid for the forwarded header name. The W3C trace headers
+// (traceparent, tracestate and baggage) are validated against their specifications, so a malformed or oversized
+// value sent by a client is not propagated downstream; other headers are always valid.
+func ValidForwardedHeader(name, value string) bool {
+	switch strings.ToLower(name) {
+	case "traceparent":
+		return validTraceparent(value)
+	case "tracestate":
+		return validTracestate(value)
+	case "baggage":
+		return validBaggage(value)
+	default:
+		return true
+	}
+}
+
+// validTraceparent checks a traceparent header: version "ff" and all-zero trace or parent IDs are invalid, and
+// only version 00 must have exactly four fields.
+func validTraceparent(value string) bool {
+	m := traceparentPattern.FindStringSubmatch(value)
+	if m == nil {
+		return false
+	}
+
+	version, traceID, parentID, extra := m[1], m[2], m[3], m[4]
+	if version == "ff" || (version == "00" && extra != "") {
+		return false
+	}
+
+	return strings.Trim(traceID, "0") != "" && strings.Trim(parentID, "0") != ""
+}
+
+func validTracestate(value string) bool {
+	if len(value) > maxTraceStateLength {
+		return false
+	}
+
+	members := strings.Split(value, ",")
+	if len(members) > maxTraceStateMembers {
+		return false
+	}
+
+	for _, member := range members {
+		if member = strings.TrimSpace(member); member != "" && !tracestatePattern.MatchString(member) {
+			return false
+		}
+	}
+	return true
+}
+
+func validBaggage(value string) bool {
+	if len(value) > maxBaggageLength {
+		return false
+	}
+
+	members := strings.Split(value, ",")
+	if len(members) > maxBaggageMembers {
+		return false
+	}
+
+	for _, member := range members {
+		pair, _, _ := strings.Cut(member, ";")
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok || !baggageKeyPattern.MatchString(strings.TrimSpace(key)) || strings.ContainsAny(strings.TrimSpace(val), " \",;\\") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Transform changes a decoded JSON response body. It returns the new body.
+// Numbers are decoded as json.Number, so large integers are re-encoded unchanged.
+type Transform func(c *fiber.Ctx, body any) any
+
+// TransformMiddleware applies transforms, in order, to the JSON responses of a route or group.
+//
+// Parameters:
+//
+//	transforms: Transforms to apply (RenameFields, StripFields, Envelope or custom ones).
+//
+// Behavior:
+//   - Only successful application/json responses are transformed; others pass through untouched.
+//   - Register FieldsMiddleware first, so it is the outer handler and projects the transformed body, with the
+//     final field names (under "data." when Envelope is used).
+//
+// Usage:
+//
+//	api := app.Group("/api", TransformMiddleware(
+//		StripFields("internal_id", "_debug"),
+//		RenameFields(map[string]string{"titulo": "title"}),
+//		Envelope(),
+//	))
+func TransformMiddleware(transforms ...Transform) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals("transformStart", time.Now())
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status < 200 || status >= 300 || !strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMEApplicationJSON) {
+			return nil
+		}
+
+		body, err := unmarshalJSON(c.Response().Body())
+		if err != nil {
+			return nil
+		}
+
+		for _, transform := range transforms {
+			body = transform(c, body)
+		}
+
+		transformed, err := json.Marshal(body)
+		if err != nil {
+			return nil
+		}
+
+		c.Response().SetBodyRaw(transformed)
+		return nil
+	}
+}
+
+// RenameFields renames object keys at any depth, e.g. {"titulo": "title"}.
+func RenameFields(mapping map[string]string) Transform {
+	return func(_ *fiber.Ctx, body any) any {
+		return walkObjects(body, func(obj map[string]any) {
+			for from, to := range mapping {
+				if value, ok := obj[from]; ok {
+					delete(obj, from)
+					obj[to] = value
+				}
+			}
+		})
+	}
+}
+
+// StripFields removes the given object keys at any depth, e.g. internal IDs or debug data.
+func StripFields(fields ...string) Transform {
+	return func(_ *fiber.Ctx, body any) any {
+		return walkObjects(body, func(obj map[string]any) {
+			for _, field := range fields {
+				delete(obj, field)
+			}
+		})
+	}
+}
+
+// Envelope wraps the body as {"data": body, "meta": {"request_id": ..., "duration_ms": ...}}.
+// The request ID is the one of RequestID.
+func Envelope() Transform {
+	return func(c *fiber.Ctx, body any) any {
+		meta := map[string]any{}
+
+		meta["request_id"] = RequestID(c)
+		if start, ok := c.Locals("transformStart").(time.Time); ok {
+			meta["duration_ms"] = time.Since(start).Milliseconds()
+		}
+
+		return map[string]any{"data": body, "meta": meta}
+	}
+}
+
+func walkObjects(value any, fn func(obj map[string]any)) any {
+	switch v := value.(type) {
+	case map[string]any:
+		fn(v)
+		for _, child := range v {
+			walkObjects(child, fn)
+		}
+	case []any:
+		for _, item := range v {
+			walkObjects(item, fn)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestTransformKeepsLargeNumbers(t *testing.T) {
+	app := fiber.New()
+	app.Get("/items", TransformMiddleware(
+		StripFields("_debug"),
+		RenameFields(map[string]string{"titulo": "title"}),
+	), func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.SendString(`{"id":9007199254740993,"titulo":"x","_debug":true,"ratio":1e-7}`)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/items", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+
+	if want := `{"id":9007199254740993,"ratio":1e-7,"title":"x"}`; string(body) != want {
+		t.Fatalf("got %s, want %s", body, want)
+	}
+}
+
+func TestFieldsProjectsTheTransformedBody(t *testing.T) {
+	app := fiber.New()
+	// FieldsMiddleware is the outer handler, so it sees the renamed fields.
+	app.Use(FieldsMiddleware(FieldsConfig{}))
+	app.Get("/items", TransformMiddleware(
+		StripFields("_debug"),
+		RenameFields(map[string]string{"titulo": "title"}),
+	), func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.SendString(`{"id":1,"titulo":"x","price":10,"_debug":true}`)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/items?fields=id,title", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+
+	if want := `{"id":1,"title":"x"}`; string(body) != want {
+		t.Fatalf("got %s, want %s", body, want)
+	}
+}
@@ -0,0 +1,360 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/devluispereira/go-package/logging"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// maxUploadFieldSize bounds the non-file fields of an upload, which are kept in memory.
+const maxUploadFieldSize = 64 << 10
+
+// errUploadTooLarge is returned by the reader of an uploaded file once it exceeds MaxFileSize.
+var errUploadTooLarge = errors.New("file exceeds the maximum size")
+
+// ObjectStore is the storage uploaded files are streamed to. The objectstorage client implements it for
+// S3-compatible APIs; implement it to use GCS or an in-memory store in tests.
+type ObjectStore interface {
+	// Put stores body under key. size is -1 when unknown, as for streamed uploads.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Delete removes the object under key.
+	Delete(ctx context.Context, key string) error
+}
+
+// UploadConfig holds the configuration of an Uploader.
+type UploadConfig struct {
+	// Name identifies the uploader in metrics, e.g. "avatars".
+	Name string
+	// Store receives the files.
+	Store ObjectStore
+	// MaxFileSize is the maximum size of each file, in bytes. Defaults to 10 MiB.
+	MaxFileSize int64
+	// MaxFiles is the maximum number of files of a request. Defaults to 10.
+	MaxFiles int
+	// MaxFields is the maximum number of non-file fields of a request, which are kept in memory. Defaults to 50.
+	MaxFields int
+	// MaxFieldsSize is the maximum total size of the non-file fields of a request, in bytes. Defaults to 1 MiB.
+	MaxFieldsSize int64
+	// AllowedTypes lists the accepted content types, sniffed from the first bytes of each file, e.g.
+	// []string{"image/*", "application/pdf"}. If empty, accepts any type.
+	AllowedTypes []string
+	// Key returns the object key of a file. Defaults to "<Name>/<random hex><extension>", or
+	// "uploads/<random hex><extension>" without a Name.
+	Key func(c *fiber.Ctx, file UploadedFile) string
+	// OnProgress is called as the bytes of a file are stored, with the total written so far.
+	OnProgress func(file UploadedFile, written int64)
+}
+
+// UploadedFile describes a file of an upload.
+type UploadedFile struct {
+	// Field is the form field of the file.
+	Field    string `json:"field"`
+	Filename string `json:"filename"`
+	// ContentType is sniffed from the content, never taken from the client.
+	ContentType string `json:"content_type"`
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+}
+
+// UploadResult is the outcome of Uploader.Upload.
+type UploadResult struct {
+	Files []UploadedFile `json:"files"`
+	// Fields are the non-file form fields.
+	Fields map[string]string `json:"fields"`
+}
+
+// Uploader streams multipart/form-data uploads to an ObjectStore, part by part, without buffering whole files in
+// memory or on disk.
+type Uploader struct {
+	cfg   UploadConfig
+	bytes metric.Int64Counter
+	files metric.Int64Counter
+	name  attribute.KeyValue
+}
+
+// NewUploader creates an Uploader.
+//
+// Parameters:
+//
+//	cfg: Upload configuration.
+//	  - Store: Where the files are streamed to.
+//	  - MaxFileSize, MaxFiles and AllowedTypes: Validation of the files.
+//	  - MaxFields and MaxFieldsSize: Bounds of the non-file fields, each also limited to 64 KiB.
+//
+// Behavior:
+//   - Fiber reads request bodies into memory by default: create the server with ServerConfig.StreamUploads (or
+//     fiber.Config StreamRequestBody and DisablePreParseMultipartForm) so bodies above the BodyLimit are streamed.
+//   - Exposes the http.server.upload.bytes counter, incremented as bytes are stored, and the
+//     http.server.upload.files counter by outcome (stored, too_large, type_rejected, failed), labelled by uploader
+//     name.
+//
+// Usage:
+//
+//	avatars := server.NewUploader(server.UploadConfig{
+//		Name:         "avatars",
+//		Store:        storage,
+//		MaxFileSize:  5 << 20,
+//		AllowedTypes: []string{"image/png", "image/jpeg"},
+//	})
+//
+//	app.Post("/avatars", func(c *fiber.Ctx) error {
+//		result, err := avatars.Upload(c)
+//		if err != nil {
+//			return err
+//		}
+//		return server.Created(c, "", result.Files, nil)
+//	})
+func NewUploader(cfg UploadConfig) *Uploader {
+	if cfg.MaxFileSize <= 0 {
+		cfg.MaxFileSize = 10 << 20
+	}
+	if cfg.MaxFiles <= 0 {
+		cfg.MaxFiles = 10
+	}
+	if cfg.MaxFields <= 0 {
+		cfg.MaxFields = 50
+	}
+	if cfg.MaxFieldsSize <= 0 {
+		cfg.MaxFieldsSize = 1 << 20
+	}
+	if cfg.Key == nil {
+		prefix := cfg.Name
+		if prefix == "" {
+			prefix = "uploads"
+		}
+		cfg.Key = func(_ *fiber.Ctx, file UploadedFile) string {
+			return prefix + "/" + randomKey() + uploadExtension(file.Filename)
+		}
+	}
+
+	meter := otel.Meter(instrumentationName)
+	bytesCounter, _ := meter.Int64Counter("http.server.upload.bytes",
+		metric.WithDescription("Bytes of uploaded files stored."), metric.WithUnit("By"))
+	filesCounter, _ := meter.Int64Counter("http.server.upload.files",
+		metric.WithDescription("Uploaded files by outcome."))
+
+	return &Uploader{
+		cfg:   cfg,
+		bytes: bytesCounter,
+		files: filesCounter,
+		name:  attribute.String("upload", cfg.Name),
+	}
+}
+
+// Upload streams the files of a multipart/form-data request to the store.
+//
+// Behavior:
+//   - Each file is validated while it streams: its content type is sniffed from the first 512 bytes and checked
+//     against AllowedTypes, and the upload stops as soon as it exceeds MaxFileSize.
+//   - When a file fails, the files already stored by the request are deleted, so a rejected request leaves nothing
+//     behind.
+//
+// Returns:
+//   - *UploadResult: The stored files and the other form fields.
+//   - error: An *Error with CodeInvalidArgument (400) for non-multipart requests, too many files or rejected
+//     content types, CodeTooLarge (413) for files above MaxFileSize or fields above MaxFields or MaxFieldsSize,
+//     and CodeUnavailable (503) when the store fails.
+func (u *Uploader) Upload(c *fiber.Ctx) (*UploadResult, error) {
+	boundary := string(c.Request().Header.MultipartFormBoundary())
+	if boundary == "" {
+		return nil, Errorf(CodeInvalidArgument, "expected a multipart/form-data body")
+	}
+
+	var body io.Reader = c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	ctx := c.UserContext()
+	result := &UploadResult{Fields: map[string]string{}}
+
+	err := u.upload(c, multipart.NewReader(body, boundary), result)
+	if err != nil {
+		u.cleanup(ctx, result.Files)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (u *Uploader) upload(c *fiber.Ctx, reader *multipart.Reader, result *UploadResult) error {
+	var fields int
+	var fieldsSize int64
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return Errorf(CodeInvalidArgument, "invalid multipart body: %w", err)
+		}
+
+		if part.FileName() == "" {
+			if fields++; fields > u.cfg.MaxFields {
+				return Errorf(CodeTooLarge, "too many fields, the maximum is %d", u.cfg.MaxFields)
+			}
+
+			value, err := io.ReadAll(io.LimitReader(part, maxUploadFieldSize+1))
+			if err != nil {
+				return Errorf(CodeInvalidArgument, "invalid multipart body: %w", err)
+			}
+			if len(value) > maxUploadFieldSize {
+				return Errorf(CodeTooLarge, "field %s exceeds %d bytes", part.FormName(), maxUploadFieldSize)
+			}
+			if fieldsSize += int64(len(value)); fieldsSize > u.cfg.MaxFieldsSize {
+				return Errorf(CodeTooLarge, "fields exceed %d bytes", u.cfg.MaxFieldsSize)
+			}
+			result.Fields[part.FormName()] = string(value)
+			continue
+		}
+
+		if len(result.Files) >= u.cfg.MaxFiles {
+			return Errorf(CodeInvalidArgument, "too many files, the maximum is %d", u.cfg.MaxFiles)
+		}
+
+		file, err := u.store(c, part)
+		if err != nil {
+			return err
+		}
+		result.Files = append(result.Files, file)
+	}
+}
+
+// store streams a file part to the store, validating its content type and size.
+func (u *Uploader) store(c *fiber.Ctx, part *multipart.Part) (UploadedFile, error) {
+	ctx := c.UserContext()
+	file := UploadedFile{Field: part.FormName(), Filename: filepath.Base(part.FileName())}
+
+	buffered := bufio.NewReaderSize(part, 512)
+	head, _ := buffered.Peek(512)
+	file.ContentType = http.DetectContentType(head)
+
+	if !u.allowed(file.ContentType) {
+		u.count(ctx, "type_rejected")
+		return file, Errorf(CodeInvalidArgument, "file %s has content type %s, which is not allowed", file.Filename, file.ContentType)
+	}
+
+	file.Key = u.cfg.Key(c, file)
+	counter := &uploadReader{reader: buffered, uploader: u, ctx: ctx, file: &file}
+
+	start := time.Now()
+	err := u.cfg.Store.Put(ctx, file.Key, counter, -1, file.ContentType)
+	if errors.Is(err, errUploadTooLarge) || counter.tooLarge {
+		u.count(ctx, "too_large")
+		_ = u.cfg.Store.Delete(context.WithoutCancel(ctx), file.Key)
+		return file, Errorf(CodeTooLarge, "file %s exceeds %d bytes", file.Filename, u.cfg.MaxFileSize)
+	}
+	if err != nil {
+		u.count(ctx, "failed")
+		return file, Errorf(CodeUnavailable, "failed to store file %s: %w", file.Filename, err)
+	}
+
+	u.count(ctx, "stored")
+	logging.FromContext(ctx).Debug().
+		Str("upload", u.cfg.Name).
+		Str("key", file.Key).
+		Int64("size", file.Size).
+		Dur("duration", time.Since(start)).
+		Msg("stored uploaded file")
+
+	return file, nil
+}
+
+// allowed reports whether contentType matches AllowedTypes, ignoring its parameters.
+func (u *Uploader) allowed(contentType string) bool {
+	if len(u.cfg.AllowedTypes) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range u.cfg.AllowedTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+		if strings.EqualFold(allowed, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanup deletes the files stored by a failed upload.
+func (u *Uploader) cleanup(ctx context.Context, files []UploadedFile) {
+	ctx = context.WithoutCancel(ctx)
+	for _, file := range files {
+		if err := u.cfg.Store.Delete(ctx, file.Key); err != nil {
+			logging.FromContext(ctx).Warn().Err(err).Str("key", file.Key).Msg("failed to delete file of a rejected upload")
+		}
+	}
+}
+
+func (u *Uploader) count(ctx context.Context, outcome string) {
+	u.files.Add(ctx, 1, metric.WithAttributes(u.name, attribute.String("outcome", outcome)))
+}
+
+// uploadReader counts the bytes of a file as the store reads them, failing once they exceed MaxFileSize.
+type uploadReader struct {
+	reader   io.Reader
+	uploader *Uploader
+	ctx      context.Context
+	file     *UploadedFile
+	tooLarge bool
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.file.Size += int64(n)
+		if r.file.Size > r.uploader.cfg.MaxFileSize {
+			r.tooLarge = true
+			return 0, errUploadTooLarge
+		}
+
+		r.uploader.bytes.Add(r.ctx, int64(n), metric.WithAttributes(r.uploader.name))
+		if r.uploader.cfg.OnProgress != nil {
+			r.uploader.cfg.OnProgress(*r.file, r.file.Size)
+		}
+	}
+	return n, err
+}
+
+// uploadExtension returns the lowercase extension of filename, or an empty string when it is unusual.
+func uploadExtension(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if len(ext) < 2 || len(ext) > 10 {
+		return ""
+	}
+	for _, r := range ext[1:] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return ext
+}
+
+func randomKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// memoryObjectStore is an in-memory ObjectStore.
+type memoryObjectStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *memoryObjectStore) Put(_ context.Context, key string, body io.Reader, _ int64, _ string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memoryObjectStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+// uploadStatus posts a multipart body with fields and a text file, returning the status and the stored objects.
+func uploadStatus(t *testing.T, cfg UploadConfig, fields map[string]string) (int, *memoryObjectStore) {
+	t.Helper()
+
+	store := &memoryObjectStore{objects: map[string][]byte{}}
+	cfg.Store = store
+	uploader := NewUploader(cfg)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/upload", func(c *fiber.Ctx) error {
+		result, err := uploader.Upload(c)
+		if err != nil {
+			return err
+		}
+		return c.JSON(result)
+	})
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	file, _ := writer.CreateFormFile("file", "notes.txt")
+	_, _ = file.Write([]byte("hello"))
+	for name, value := range fields {
+		_ = writer.WriteField(name, value)
+	}
+	_ = writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set(fiber.HeaderContentType, writer.FormDataContentType())
+	return doStatus(t, app, req), store
+}
+
+func TestUploaderStoresFilesAndFields(t *testing.T) {
+	status, store := uploadStatus(t, UploadConfig{Name: "docs"}, map[string]string{"title": "notes"})
+	if status != http.StatusOK {
+		t.Fatalf("status %d, want 200", status)
+	}
+	if len(store.objects) != 1 {
+		t.Fatalf("stored %d objects, want 1", len(store.objects))
+	}
+}
+
+func TestUploaderLimitsFields(t *testing.T) {
+	many := map[string]string{}
+	for i := range 4 {
+		many["field"+strconv.Itoa(i)] = "value"
+	}
+	status, store := uploadStatus(t, UploadConfig{MaxFields: 3}, many)
+	if status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status %d with too many fields, want 413", status)
+	}
+	if len(store.objects) != 0 {
+		t.Fatalf("stored %d objects after a rejected upload, want 0", len(store.objects))
+	}
+
+	large := map[string]string{"a": strings.Repeat("a", 600), "b": strings.Repeat("b", 600)}
+	if status, _ := uploadStatus(t, UploadConfig{MaxFieldsSize: 1000}, large); status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status %d with fields above MaxFieldsSize, want 413", status)
+	}
+}
@@ -0,0 +1,123 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IPubSubClient defines the Redis operations used to synchronize settings between replicas.
+type IPubSubClient interface {
+	Publish(ctx context.Context, channel string, message any) error
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HSet(ctx context.Context, key string, values ...any) error
+	HDel(ctx context.Context, key string, fields ...string) error
+}
+
+type syncMessage struct {
+	Origin string `json:"origin"`
+	Change Change `json:"change"`
+}
+
+// SyncRedis publishes local changes to channel and applies changes published by other replicas,
+// until ctx is done.
+//
+// Behavior:
+//   - The current settings are kept in the Redis hash "<channel>:snapshot", loaded on start, so a replica
+//     started after a change applies it too.
+//   - Local changes update the snapshot and are published; changes of other replicas are applied with source
+//     "redis".
+//   - Once ctx is done, local changes are no longer published.
+//
+// Usage:
+//
+//	go settings.Default.SyncRedis(ctx, redis, "my-app:settings")
+func (s *Store) SyncRedis(ctx context.Context, client IPubSubClient, channel string) error {
+	hostname, _ := os.Hostname()
+	origin := hostname + ":" + strconv.Itoa(os.Getpid())
+	snapshotKey := channel + ":snapshot"
+
+	// Subscribing before loading the snapshot, no change published in between is missed.
+	pubsub := client.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to settings channel: %w", err)
+	}
+
+	if err := s.loadSnapshot(ctx, client, snapshotKey); err != nil {
+		return err
+	}
+
+	unsubscribe := s.Subscribe(s.publishLocal(ctx, client, channel, snapshotKey, origin))
+	defer unsubscribe()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+
+			var m syncMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil || m.Origin == origin {
+				continue
+			}
+
+			if err := s.apply(m.Change.Key, m.Change.New, m.Change.Actor, "redis"); err != nil {
+				s.logger.Error().Err(err).Str("key", m.Change.Key).Msg("settings:remote change rejected")
+			}
+		}
+	}
+}
+
+// loadSnapshot applies the settings stored in the snapshot hash.
+func (s *Store) loadSnapshot(ctx context.Context, client IPubSubClient, snapshotKey string) error {
+	snapshot, err := client.HGetAll(ctx, snapshotKey)
+	if err != nil {
+		return fmt.Errorf("failed to load settings snapshot: %w", err)
+	}
+
+	for key, value := range snapshot {
+		if err := s.apply(key, value, "", "redis"); err != nil {
+			s.logger.Error().Err(err).Str("key", key).Msg("settings:snapshot value rejected")
+		}
+	}
+	return nil
+}
+
+// publishLocal returns the listener saving local changes to the snapshot hash and publishing them to channel.
+func (s *Store) publishLocal(ctx context.Context, client IPubSubClient, channel, snapshotKey, origin string) func(Change) {
+	return func(change Change) {
+		if change.Source != "local" {
+			return
+		}
+
+		payload, err := json.Marshal(syncMessage{Origin: origin, Change: change})
+		if err != nil {
+			return
+		}
+
+		ctx := context.WithoutCancel(ctx)
+		if change.New == "" {
+			err = client.HDel(ctx, snapshotKey, change.Key)
+		} else {
+			err = client.HSet(ctx, snapshotKey, change.Key, change.New)
+		}
+		if err != nil {
+			s.logger.Error().Err(err).Str("key", change.Key).Msg("settings:snapshot update failed")
+		}
+
+		if err := client.Publish(ctx, channel, payload); err != nil {
+			s.logger.Error().Err(err).Str("key", change.Key).Msg("settings:publish failed")
+		}
+	}
+}
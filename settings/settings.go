@@ -0,0 +1,288 @@
+package settings
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/logging"
+	"github.com/rs/zerolog"
+)
+
+// Well-known setting keys consumed by the toolkit.
+const (
+	// LogLevel changes the global log level (trace, debug, info, warn, error).
+	LogLevel = "log_level"
+	// LogLevelPrefix followed by a logging component (e.g. "http-client") changes the level of that component.
+	LogLevelPrefix = "log_level:"
+	// CacheTTLOverride overrides the TTL of every entry stored by the httpclient cache middleware (e.g. "30s").
+	CacheTTLOverride = "cache_ttl_override"
+	// BreakerForcePrefix followed by the breaker name forces a circuit breaker "open" or "closed".
+	BreakerForcePrefix = "breaker_force:"
+	// RateLimitPrefix followed by a rate limit tier name (see server.RoutePolicyConfig.RateLimitTiers) overrides the
+	// requests it allows per window.
+	RateLimitPrefix = "rate_limit:"
+	// DisablePrefix followed by an httpclient middleware ("cache", "retry", "breaker" or "logging") disables it in
+	// every client when "true", or only in one client when followed by ":<client name>" (e.g. "disable:cache:catalog").
+	DisablePrefix = "disable:"
+)
+
+// Change describes a setting update, used for notifications and audit logs.
+type Change struct {
+	Key    string    `json:"key"`
+	Old    string    `json:"old"`
+	New    string    `json:"new"`
+	Actor  string    `json:"actor"`
+	Source string    `json:"source"`
+	At     time.Time `json:"at"`
+}
+
+// listener is a function registered with Subscribe, held by pointer so it can be unsubscribed.
+type listener struct {
+	fn func(Change)
+}
+
+// Validator validates a setting value before it is applied.
+type Validator func(value string) error
+
+// Store holds runtime settings that can be changed without a redeploy.
+type Store struct {
+	mu         sync.RWMutex
+	values     map[string]string
+	validators map[string]Validator
+	listeners  []*listener
+	logger     zerolog.Logger
+}
+
+// Default is the process-wide settings store read by the toolkit components.
+var Default = NewStore()
+
+// NewStore creates a settings Store with validators for the well-known keys.
+// Changes to LogLevel and LogLevelPrefix keys are applied to the logging package levels; removing them restores
+// the levels the process was configured with (see logging.ResetLevel).
+func NewStore() *Store {
+	s := &Store{
+		values:     make(map[string]string),
+		validators: make(map[string]Validator),
+		logger:     logging.New("settings"),
+	}
+
+	s.RegisterValidator(LogLevel, func(value string) error {
+		_, err := zerolog.ParseLevel(value)
+		return err
+	})
+	s.RegisterValidator(LogLevelPrefix, func(value string) error {
+		_, err := zerolog.ParseLevel(value)
+		return err
+	})
+	s.RegisterValidator(CacheTTLOverride, func(value string) error {
+		_, err := time.ParseDuration(value)
+		return err
+	})
+	s.RegisterValidator(BreakerForcePrefix, func(value string) error {
+		if value != "open" && value != "closed" {
+			return fmt.Errorf("must be open or closed")
+		}
+		return nil
+	})
+	s.RegisterValidator(RateLimitPrefix, func(value string) error {
+		_, err := strconv.Atoi(value)
+		return err
+	})
+	s.RegisterValidator(DisablePrefix, func(value string) error {
+		_, err := strconv.ParseBool(value)
+		return err
+	})
+
+	s.Subscribe(func(change Change) {
+		if component, ok := strings.CutPrefix(change.Key, LogLevelPrefix); ok {
+			if change.New == "" {
+				logging.ResetComponentLevel(component)
+				return
+			}
+			_ = logging.SetComponentLevel(component, change.New)
+			return
+		}
+		if change.Key != LogLevel {
+			return
+		}
+
+		if change.New == "" {
+			logging.ResetLevel()
+			return
+		}
+		_ = logging.SetLevel(change.New)
+	})
+
+	return s
+}
+
+// RegisterValidator registers a validator for a key, or for every key starting with it when it ends with ":".
+func (s *Store) RegisterValidator(key string, validator Validator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.validators[key] = validator
+}
+
+// Subscribe registers a function called after every change. The returned function unsubscribes it.
+func (s *Store) Subscribe(fn func(Change)) (unsubscribe func()) {
+	l := &listener{fn: fn}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, l)
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.listeners = slices.DeleteFunc(s.listeners, func(other *listener) bool { return other == l })
+	}
+}
+
+// Set validates and applies a setting, recording who changed it in the audit log.
+func (s *Store) Set(key, value, actor string) error {
+	return s.apply(key, value, actor, "local")
+}
+
+// Delete removes a setting, restoring the component default.
+func (s *Store) Delete(key, actor string) error {
+	return s.apply(key, "", actor, "local")
+}
+
+func (s *Store) apply(key, value, actor, source string) error {
+	if value != "" {
+		if validator := s.validator(key); validator != nil {
+			if err := validator(value); err != nil {
+				return fmt.Errorf("invalid value for %s: %w", key, err)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	old := s.values[key]
+	if old == value {
+		s.mu.Unlock()
+		return nil
+	}
+
+	if value == "" {
+		delete(s.values, key)
+	} else {
+		s.values[key] = value
+	}
+	listeners := slices.Clone(s.listeners)
+	s.mu.Unlock()
+
+	change := Change{Key: key, Old: old, New: value, Actor: actor, Source: source, At: time.Now()}
+
+	s.logger.Info().
+		Str("key", key).
+		Str("old", old).
+		Str("new", value).
+		Str("actor", actor).
+		Str("source", source).
+		Msg("settings:changed")
+
+	for _, l := range listeners {
+		l.fn(change)
+	}
+
+	return nil
+}
+
+func (s *Store) validator(key string) Validator {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if validator, ok := s.validators[key]; ok {
+		return validator
+	}
+
+	for prefix, validator := range s.validators {
+		if strings.HasSuffix(prefix, ":") && strings.HasPrefix(key, prefix) {
+			return validator
+		}
+	}
+
+	return nil
+}
+
+// Get returns the raw value of a setting.
+func (s *Store) Get(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Duration returns a setting parsed as time.Duration.
+func (s *Store) Duration(key string) (time.Duration, bool) {
+	value, ok := s.Get(key)
+	if !ok {
+		return 0, false
+	}
+
+	d, err := time.ParseDuration(value)
+	return d, err == nil
+}
+
+// Int returns a setting parsed as int.
+func (s *Store) Int(key string) (int, bool) {
+	value, ok := s.Get(key)
+	if !ok {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(value)
+	return n, err == nil
+}
+
+// Bool returns a setting parsed as bool.
+func (s *Store) Bool(key string) (bool, bool) {
+	value, ok := s.Get(key)
+	if !ok {
+		return false, false
+	}
+
+	b, err := strconv.ParseBool(value)
+	return b, err == nil
+}
+
+// Disabled reports whether middleware was disabled for the client name through the DisablePrefix settings. The
+// client setting ("disable:cache:catalog") takes precedence over the global one ("disable:cache"), so a middleware
+// disabled everywhere can be kept in one client with "false".
+func (s *Store) Disabled(middleware, name string) bool {
+	if disabled, ok := s.Bool(DisablePrefix + middleware + ":" + name); ok {
+		return disabled
+	}
+
+	disabled, _ := s.Bool(DisablePrefix + middleware)
+	return disabled
+}
+
+// All returns a copy of every setting currently defined.
+func (s *Store) All() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all := make(map[string]string, len(s.values))
+	for k, v := range s.values {
+		all[k] = v
+	}
+	return all
+}
+
+// Keys returns the defined setting keys, sorted.
+func (s *Store) Keys() []string {
+	all := s.All()
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
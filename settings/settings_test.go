@@ -0,0 +1,128 @@
+package settings
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/devluispereira/go-package/logging"
+	"github.com/redis/go-redis/v9"
+)
+
+// memoryPubSub records the snapshot and the published messages of SyncRedis.
+type memoryPubSub struct {
+	mu        sync.Mutex
+	hash      map[string]string
+	published int
+}
+
+func (m *memoryPubSub) Publish(context.Context, string, any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published++
+	return nil
+}
+
+func (m *memoryPubSub) Subscribe(context.Context, ...string) *redis.PubSub {
+	return nil
+}
+
+func (m *memoryPubSub) HGetAll(context.Context, string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all := make(map[string]string, len(m.hash))
+	for k, v := range m.hash {
+		all[k] = v
+	}
+	return all, nil
+}
+
+func (m *memoryPubSub) HSet(_ context.Context, _ string, values ...any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := 0; i+1 < len(values); i += 2 {
+		m.hash[values[i].(string)] = values[i+1].(string)
+	}
+	return nil
+}
+
+func (m *memoryPubSub) HDel(_ context.Context, _ string, fields ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, field := range fields {
+		delete(m.hash, field)
+	}
+	return nil
+}
+
+func TestSubscribeReturnsUnsubscribe(t *testing.T) {
+	store := NewStore()
+
+	var calls int
+	unsubscribe := store.Subscribe(func(Change) { calls++ })
+	_ = store.Set("rate_limit:strict", "10", "maria")
+	unsubscribe()
+	_ = store.Set("rate_limit:strict", "20", "maria")
+
+	if calls != 1 {
+		t.Fatalf("listener called %d times, want 1", calls)
+	}
+}
+
+func TestSyncSnapshotIsSavedAndLoaded(t *testing.T) {
+	client := &memoryPubSub{hash: map[string]string{}}
+	ctx := context.Background()
+
+	first := NewStore()
+	unsubscribe := first.Subscribe(first.publishLocal(ctx, client, "app:settings", "app:settings:snapshot", "first"))
+	defer unsubscribe()
+
+	_ = first.Set("rate_limit:strict", "10", "maria")
+	_ = first.Set("disable:cache", "true", "maria")
+	_ = first.Delete("disable:cache", "maria")
+
+	if client.published != 3 {
+		t.Fatalf("published %d changes, want 3", client.published)
+	}
+
+	// A replica started after the changes loads them.
+	second := NewStore()
+	if err := second.loadSnapshot(ctx, client, "app:settings:snapshot"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := second.Int("rate_limit:strict"); n != 10 {
+		t.Fatalf("rate_limit:strict = %d, want 10", n)
+	}
+	if _, ok := second.Get("disable:cache"); ok {
+		t.Fatal("deleted setting loaded from the snapshot")
+	}
+
+	// Changes applied from Redis are not published again.
+	_ = first.apply(CacheTTLOverride, "30s", "", "redis")
+	if client.published != 3 {
+		t.Fatalf("remote change published again")
+	}
+}
+
+func TestDeletingLogLevelRestoresTheBootLevel(t *testing.T) {
+	store := NewStore()
+	// Without LOG_LEVEL, the process boots at debug.
+	boot := logging.Levels()["global"]
+
+	_ = store.Set(LogLevel, "error", "maria")
+	_ = store.Set(LogLevelPrefix+"http-client", "warn", "maria")
+	if got := logging.Levels()["global"]; got != "error" {
+		t.Fatalf("global level %q, want error", got)
+	}
+
+	_ = store.Delete(LogLevel, "maria")
+	_ = store.Delete(LogLevelPrefix+"http-client", "maria")
+
+	levels := logging.Levels()
+	if levels["global"] != boot {
+		t.Fatalf("global level %q after delete, want the boot level %q", levels["global"], boot)
+	}
+	if level, ok := levels["http-client"]; ok {
+		t.Fatalf("http-client level %q after delete, want no override", level)
+	}
+}
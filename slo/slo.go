@@ -0,0 +1,243 @@
+package slo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "github.com/devluispereira/go-package/slo"
+
+// bucketsPerWindow is the number of time buckets of the sliding window.
+const bucketsPerWindow = 60
+
+// MinWindow is the shortest Objective.Window, giving one-second buckets.
+const MinWindow = bucketsPerWindow * time.Second
+
+// ErrWindowTooShort is returned by SetObjective for a Window shorter than MinWindow.
+var ErrWindowTooShort = errors.New("slo window too short")
+
+// latencyBounds are the upper bounds, in milliseconds, of the latency histogram used for percentiles.
+var latencyBounds = []float64{5, 10, 25, 50, 75, 100, 150, 200, 300, 500, 750, 1000, 1500, 2000, 3000, 5000, 10000, math.Inf(1)}
+
+// Objective is the SLO of a downstream. It can be loaded with the config package.
+type Objective struct {
+	Name string `json:"name"`
+	// Availability is the target ratio of successful requests, e.g. 0.999.
+	Availability float64 `json:"availability" default:"0.999"`
+	// LatencyThreshold and LatencyTarget define the latency objective: LatencyTarget of the requests
+	// (e.g. 0.95) must complete within LatencyThreshold (e.g. 300ms).
+	LatencyThreshold time.Duration `json:"latency_threshold" default:"300ms"`
+	LatencyTarget    float64       `json:"latency_target" default:"0.95"`
+	// Window is the sliding window evaluated, at least MinWindow. Defaults to 1h.
+	Window time.Duration `json:"window" default:"1h"`
+}
+
+// Summary reports the compliance of a downstream over its window.
+type Summary struct {
+	Objective    Objective `json:"objective"`
+	Requests     int64     `json:"requests"`
+	Errors       int64     `json:"errors"`
+	Availability float64   `json:"availability"`
+	// LatencyCompliance is the ratio of requests within the latency threshold.
+	LatencyCompliance float64 `json:"latency_compliance"`
+	P50Ms             float64 `json:"p50_ms"`
+	P95Ms             float64 `json:"p95_ms"`
+	P99Ms             float64 `json:"p99_ms"`
+	// BurnRate is how fast the error budget is consumed; 1 consumes exactly the budget over the window.
+	BurnRate float64 `json:"burn_rate"`
+	// ErrorBudgetRemaining is the fraction of the error budget left in the window (negative when exhausted).
+	ErrorBudgetRemaining float64 `json:"error_budget_remaining"`
+}
+
+type bucket struct {
+	start     time.Time
+	requests  int64
+	errors    int64
+	slow      int64
+	latencies [18]int64
+}
+
+type series struct {
+	objective Objective
+	width     time.Duration
+	buckets   [bucketsPerWindow]bucket
+}
+
+// Tracker tracks availability and latency per named downstream against its objective.
+type Tracker struct {
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// defaultTracker is unexported so it can't be replaced while middlewares hold it.
+var defaultTracker = NewTracker()
+
+// Default returns the process-wide tracker, fed by the httpclient metrics middleware unless
+// httpclient.MetricsConfig.SLO sets another one.
+func Default() *Tracker {
+	return defaultTracker
+}
+
+// NewTracker creates a tracker and registers its metrics: slo.availability, slo.burn_rate and
+// slo.error_budget.remaining, labelled by downstream.
+func NewTracker() *Tracker {
+	t := &Tracker{series: make(map[string]*series)}
+
+	meter := otel.Meter(instrumentationName)
+	availability, _ := meter.Float64ObservableGauge("slo.availability",
+		metric.WithDescription("Ratio of successful requests over the SLO window."))
+	burnRate, _ := meter.Float64ObservableGauge("slo.burn_rate",
+		metric.WithDescription("Error budget burn rate over the SLO window."))
+	budget, _ := meter.Float64ObservableGauge("slo.error_budget.remaining",
+		metric.WithDescription("Fraction of the error budget left over the SLO window."))
+
+	_, _ = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for _, summary := range t.Summaries() {
+			attrs := metric.WithAttributes(attribute.String("downstream", summary.Objective.Name))
+			o.ObserveFloat64(availability, summary.Availability, attrs)
+			o.ObserveFloat64(burnRate, summary.BurnRate, attrs)
+			o.ObserveFloat64(budget, summary.ErrorBudgetRemaining, attrs)
+		}
+		return nil
+	}, availability, burnRate, budget)
+
+	return t
+}
+
+// SetObjective starts tracking a downstream against obj. Only downstreams with an objective are tracked. It returns
+// ErrWindowTooShort for a Window shorter than MinWindow; invalid targets fall back to their defaults.
+func (t *Tracker) SetObjective(obj Objective) error {
+	if obj.Availability <= 0 || obj.Availability >= 1 {
+		obj.Availability = 0.999
+	}
+	if obj.LatencyThreshold <= 0 {
+		obj.LatencyThreshold = 300 * time.Millisecond
+	}
+	if obj.LatencyTarget <= 0 || obj.LatencyTarget > 1 {
+		obj.LatencyTarget = 0.95
+	}
+	if obj.Window == 0 {
+		obj.Window = time.Hour
+	}
+	if obj.Window < MinWindow {
+		return fmt.Errorf("%w: %s is %v, want at least %v", ErrWindowTooShort, obj.Name, obj.Window, MinWindow)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.series[obj.Name] = &series{objective: obj, width: obj.Window / bucketsPerWindow}
+	return nil
+}
+
+// Observe records a request to the named downstream. It is a no-op when the downstream has no objective.
+func (t *Tracker) Observe(name string, duration time.Duration, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.series[name]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	start := now.Truncate(s.width)
+	b := &s.buckets[int(start.UnixNano()/int64(s.width))%bucketsPerWindow]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+
+	b.requests++
+	if failed {
+		b.errors++
+	}
+	if duration > s.objective.LatencyThreshold {
+		b.slow++
+	}
+
+	ms := float64(duration) / float64(time.Millisecond)
+	for i, bound := range latencyBounds {
+		if ms <= bound {
+			b.latencies[i]++
+			break
+		}
+	}
+}
+
+// Summaries returns the compliance of every tracked downstream, sorted by name.
+func (t *Tracker) Summaries() []Summary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	summaries := make([]Summary, 0, len(t.series))
+	for _, s := range t.series {
+		summaries = append(summaries, s.summary(time.Now()))
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Objective.Name < summaries[j].Objective.Name })
+	return summaries
+}
+
+func (s *series) summary(now time.Time) Summary {
+	summary := Summary{Objective: s.objective, Availability: 1, LatencyCompliance: 1, ErrorBudgetRemaining: 1}
+
+	var slow int64
+	var latencies [18]int64
+	oldest := now.Add(-s.objective.Window)
+
+	for _, b := range s.buckets {
+		if b.start.Before(oldest) {
+			continue
+		}
+
+		summary.Requests += b.requests
+		summary.Errors += b.errors
+		slow += b.slow
+		for i, count := range b.latencies {
+			latencies[i] += count
+		}
+	}
+
+	if summary.Requests == 0 {
+		return summary
+	}
+
+	errorRate := float64(summary.Errors) / float64(summary.Requests)
+	allowed := 1 - s.objective.Availability
+
+	summary.Availability = 1 - errorRate
+	summary.LatencyCompliance = 1 - float64(slow)/float64(summary.Requests)
+	summary.BurnRate = errorRate / allowed
+	summary.ErrorBudgetRemaining = 1 - summary.BurnRate
+	summary.P50Ms = percentile(latencies, summary.Requests, 0.50)
+	summary.P95Ms = percentile(latencies, summary.Requests, 0.95)
+	summary.P99Ms = percentile(latencies, summary.Requests, 0.99)
+
+	return summary
+}
+
+// percentile returns the upper bound of the histogram bucket containing the p-th request.
+func percentile(latencies [18]int64, total int64, p float64) float64 {
+	rank := int64(math.Ceil(p * float64(total)))
+
+	var seen int64
+	for i, count := range latencies {
+		seen += count
+		if seen >= rank {
+			if math.IsInf(latencyBounds[i], 1) {
+				return latencyBounds[i-1]
+			}
+			return latencyBounds[i]
+		}
+	}
+
+	return 0
+}
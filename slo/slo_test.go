@@ -0,0 +1,53 @@
+package slo
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetObjectiveRejectsShortWindow(t *testing.T) {
+	tracker := NewTracker()
+
+	for _, window := range []time.Duration{time.Nanosecond, 59 * time.Nanosecond, time.Second, -time.Minute} {
+		if err := tracker.SetObjective(Objective{Name: "catalog", Window: window}); !errors.Is(err, ErrWindowTooShort) {
+			t.Errorf("window %v: got %v, want ErrWindowTooShort", window, err)
+		}
+	}
+
+	// Rejected objectives are not tracked, so observing them must not divide by a zero bucket width.
+	tracker.Observe("catalog", time.Millisecond, false)
+	if summaries := tracker.Summaries(); len(summaries) != 0 {
+		t.Fatalf("got %+v, want no tracked downstream", summaries)
+	}
+}
+
+func TestTrackerSummaries(t *testing.T) {
+	tracker := NewTracker()
+	if err := tracker.SetObjective(Objective{Name: "catalog", Availability: 0.9, Window: MinWindow}); err != nil {
+		t.Fatalf("SetObjective: %v", err)
+	}
+
+	tracker.Observe("catalog", 10*time.Millisecond, false)
+	tracker.Observe("catalog", 10*time.Millisecond, true)
+	tracker.Observe("untracked", 10*time.Millisecond, true)
+
+	summaries := tracker.Summaries()
+	if len(summaries) != 1 {
+		t.Fatalf("got %d summaries, want 1", len(summaries))
+	}
+	if s := summaries[0]; s.Requests != 2 || s.Errors != 1 || s.Availability != 0.5 {
+		t.Fatalf("got %+v", s)
+	}
+}
+
+func TestDefaultTrackerDefaultsWindow(t *testing.T) {
+	if err := Default().SetObjective(Objective{Name: t.Name()}); err != nil {
+		t.Fatalf("SetObjective: %v", err)
+	}
+	for _, s := range Default().Summaries() {
+		if s.Objective.Name == t.Name() && s.Objective.Window != time.Hour {
+			t.Fatalf("window %v, want 1h", s.Objective.Window)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package variants
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Variant values for requests without the header and for values outside the rule.
+const (
+	None  = "none"
+	Other = "other"
+)
+
+// Rule maps a request header, usually a forwarded device header, into a bounded set of variants. It can be loaded
+// with the config package.
+type Rule struct {
+	// Header is the request header, e.g. "x-platform-id" or "x-client-version".
+	Header string `json:"header"`
+	// Values are the known values, matched case-insensitively, e.g. ["web", "ios", "android"]. Other values become
+	// "other".
+	Values []string `json:"values"`
+	// Versions are version boundaries, e.g. ["3.0", "4.2"]. A version becomes ">=<boundary>" for the greatest
+	// boundary not above it, or "<<first boundary>" below all of them; invalid versions become "other".
+	Versions []string `json:"versions"`
+}
+
+// Rules derive the variant of a request from its headers. Each header contributes one of a few values, so the
+// number of variants is bounded whatever the clients send.
+type Rules []Rule
+
+// Variant returns the variant of the request whose headers are read with get, e.g.
+// "x-platform-id=ios;x-client-version=>=4.2". Missing headers contribute "none"; a rule without Values or Versions
+// only distinguishes present ("other") from missing headers.
+//
+// Usage:
+//
+//	variant := rules.Variant(req.Header.Get)
+//	variant := rules.Variant(func(h string) string { return c.Get(h) })
+func (r Rules) Variant(get func(header string) string) string {
+	parts := make([]string, len(r))
+	for i, rule := range r {
+		parts[i] = strings.ToLower(rule.Header) + "=" + rule.value(strings.TrimSpace(get(rule.Header)))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Headers returns the headers read by the rules.
+func (r Rules) Headers() []string {
+	headers := make([]string, len(r))
+	for i, rule := range r {
+		headers[i] = rule.Header
+	}
+	return headers
+}
+
+func (r Rule) value(raw string) string {
+	if raw == "" {
+		return None
+	}
+
+	for _, v := range r.Values {
+		if strings.EqualFold(v, raw) {
+			return strings.ToLower(v)
+		}
+	}
+
+	if len(r.Versions) > 0 {
+		version, err := ParseVersion(raw)
+		if err != nil {
+			return Other
+		}
+
+		bucket := "<" + r.Versions[0]
+		for _, boundary := range r.Versions {
+			if b, err := ParseVersion(boundary); err == nil && version.Compare(b) >= 0 {
+				bucket = ">=" + boundary
+			}
+		}
+		return bucket
+	}
+
+	return Other
+}
+
+// Version is a dotted numeric version, e.g. 4.2.1.
+type Version []int
+
+// ParseVersion parses a version such as "4.2.1", "v4.2" or "4.2.1-beta+123"; the pre-release and build suffixes
+// are ignored.
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+ "); i >= 0 {
+		s = s[:i]
+	}
+	if s == "" {
+		return nil, fmt.Errorf("invalid version %q", s)
+	}
+
+	parts := strings.Split(s, ".")
+	version := make(Version, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		version[i] = n
+	}
+
+	return version, nil
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or greater than other. Missing components are zero,
+// so "4.2" equals "4.2.0".
+func (v Version) Compare(other Version) int {
+	for i := 0; i < max(len(v), len(other)); i++ {
+		a, b := 0, 0
+		if i < len(v) {
+			a = v[i]
+		}
+		if i < len(other) {
+			b = other[i]
+		}
+
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	}
+	return 0
+}
+
+// String formats the version, e.g. "4.2.1".
+func (v Version) String() string {
+	parts := make([]string, len(v))
+	for i, n := range v {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
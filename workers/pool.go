@@ -0,0 +1,212 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/devluispereira/go-package/errorreport"
+	"github.com/devluispereira/go-package/logging"
+)
+
+var logger = logging.New("workers")
+
+var (
+	// ErrPoolClosed is returned when submitting to a stopped pool.
+	ErrPoolClosed = errors.New("worker pool closed")
+	// ErrQueueFull is returned by TrySubmit when the queue has no room.
+	ErrQueueFull = errors.New("worker pool queue full")
+)
+
+// Task is a unit of work executed by the pool.
+type Task func(ctx context.Context) error
+
+// Config holds the configuration of a Pool. It can be loaded with the config package.
+type Config struct {
+	// Name identifies the pool in logs.
+	Name string `json:"name"`
+	// Size is the number of worker goroutines. Defaults to 10.
+	Size int `json:"size" env:"WORKERS_SIZE" default:"10"`
+	// QueueSize is the number of tasks waiting for a worker before Submit blocks. Defaults to 100.
+	QueueSize int `json:"queue_size" env:"WORKERS_QUEUE_SIZE" default:"100"`
+	// TaskTimeout bounds each task. Zero means no timeout.
+	TaskTimeout time.Duration `json:"task_timeout" env:"WORKERS_TASK_TIMEOUT"`
+	// OnError is called when a task fails or panics. If nil, errors are logged. Panics are also reported to the
+	// errorreport Reporter.
+	OnError func(err error) `json:"-"`
+}
+
+// Pool is a bounded goroutine pool with backpressure and graceful drain.
+type Pool struct {
+	cfg    Config
+	tasks  chan Task
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	// done is closed by Stop, waking the submitters blocked on a full queue.
+	done chan struct{}
+	// submitters counts the Submit and TrySubmit calls in progress; tasks is closed once they returned.
+	submitters sync.WaitGroup
+	mu         sync.RWMutex
+	closed     bool
+	stopOnce   sync.Once
+}
+
+// NewPool creates a Pool and starts its workers.
+//
+// Usage:
+//
+//	pool := workers.NewPool(workers.Config{Name: "cache-writes", Size: 4, QueueSize: 1000})
+//	srv.OnStop(pool.Stop)
+//
+//	err := pool.TrySubmit(func(ctx context.Context) error { return redis.Set(ctx, key, value, ttl) })
+func NewPool(cfg Config) *Pool {
+	if cfg.Size <= 0 {
+		cfg.Size = 10
+	}
+	if cfg.QueueSize < 0 {
+		cfg.QueueSize = 0
+	} else if cfg.QueueSize == 0 {
+		cfg.QueueSize = 100
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		cfg:    cfg,
+		tasks:  make(chan Task, cfg.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	for i := 0; i < cfg.Size; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+
+	return p
+}
+
+// Submit enqueues a task, blocking while the queue is full until ctx is done (backpressure). It returns
+// ErrPoolClosed when the pool is stopped, including while it waits.
+func (p *Pool) Submit(ctx context.Context, task Task) error {
+	if !p.enter() {
+		return ErrPoolClosed
+	}
+	defer p.submitters.Done()
+
+	select {
+	case p.tasks <- task:
+		return nil
+	case <-p.done:
+		return ErrPoolClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrySubmit enqueues a task without blocking, returning ErrQueueFull when there is no room.
+func (p *Pool) TrySubmit(task Task) error {
+	if !p.enter() {
+		return ErrPoolClosed
+	}
+	defer p.submitters.Done()
+
+	select {
+	case p.tasks <- task:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// enter registers a submitter, reporting false when the pool is stopped. The lock is only held for the check, not
+// while the submitter waits for room in the queue.
+func (p *Pool) enter() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return false
+	}
+	p.submitters.Add(1)
+	return true
+}
+
+// Stop stops accepting tasks and waits for queued and in-flight tasks to finish.
+// If ctx is done first, running tasks have their context canceled and ctx.Err() is returned.
+// Its signature matches server.Hook, so it can be registered with srv.OnStop.
+func (p *Pool) Stop(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		p.mu.Lock()
+		p.closed = true
+		p.mu.Unlock()
+
+		close(p.done)
+		go func() {
+			// No task is sent once the submitters returned, so the workers can drain the queue and exit.
+			p.submitters.Wait()
+			close(p.tasks)
+		}()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+// Pending returns the number of queued tasks.
+func (p *Pool) Pending() int {
+	return len(p.tasks)
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for task := range p.tasks {
+		if err := p.run(task); err != nil {
+			p.reportError(err)
+		}
+	}
+}
+
+func (p *Pool) run(task Task) (err error) {
+	ctx := p.ctx
+	if p.cfg.TaskTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.cfg.TaskTimeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v\n%s", r, debug.Stack())
+			errorreport.CapturePanic(ctx, r, map[string]string{"component": "workers", "pool": p.cfg.Name})
+		}
+	}()
+
+	return task(ctx)
+}
+
+func (p *Pool) reportError(err error) {
+	if p.cfg.OnError != nil {
+		p.cfg.OnError(err)
+		return
+	}
+
+	logger.Error().Err(err).Str("pool", p.cfg.Name).Msg("workers:task failed")
+}
@@ -0,0 +1,99 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolStopReleasesBlockedSubmit(t *testing.T) {
+	pool := NewPool(Config{Size: 1, QueueSize: 1})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	_ = pool.Submit(context.Background(), func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	// Fills the queue, so the next Submit blocks.
+	_ = pool.Submit(context.Background(), func(context.Context) error { return nil })
+
+	submitted := make(chan error, 1)
+	go func() {
+		submitted <- pool.Submit(context.Background(), func(context.Context) error { return nil })
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- pool.Stop(context.Background())
+	}()
+
+	select {
+	case err := <-submitted:
+		if !errors.Is(err, ErrPoolClosed) {
+			t.Fatalf("Submit returned %v, want ErrPoolClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Submit still blocked after Stop")
+	}
+
+	close(release)
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatalf("Stop: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Stop hung")
+	}
+}
+
+func TestPoolStopDrainsQueuedTasks(t *testing.T) {
+	pool := NewPool(Config{Size: 2, QueueSize: 10})
+
+	var ran atomic.Int32
+	for range 10 {
+		if err := pool.Submit(context.Background(), func(context.Context) error {
+			time.Sleep(time.Millisecond)
+			ran.Add(1)
+			return nil
+		}); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+
+	if err := pool.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if n := ran.Load(); n != 10 {
+		t.Fatalf("ran %d tasks, want 10", n)
+	}
+	if err := pool.TrySubmit(func(context.Context) error { return nil }); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("TrySubmit after Stop returned %v, want ErrPoolClosed", err)
+	}
+	// Stop is idempotent.
+	if err := pool.Stop(context.Background()); err != nil {
+		t.Fatalf("second Stop: %v", err)
+	}
+}
+
+func TestPoolConcurrentSubmitAndStop(t *testing.T) {
+	for range 20 {
+		pool := NewPool(Config{Size: 2, QueueSize: 1})
+		for range 8 {
+			go func() {
+				_ = pool.Submit(context.Background(), func(context.Context) error { return nil })
+			}()
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		if err := pool.Stop(ctx); err != nil {
+			t.Fatalf("Stop: %v", err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,186 @@
+package workers
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "github.com/devluispereira/go-package/workers"
+
+// WriterConfig holds the configuration of a Writer. It can be loaded with the config package.
+type WriterConfig struct {
+	// Name identifies the writer in logs and metrics.
+	Name string `json:"name" default:"background-writer"`
+	// Size is the number of writer goroutines. Defaults to 4.
+	Size int `json:"size" env:"WRITER_SIZE" default:"4"`
+	// QueueSize is the number of writes waiting for a worker. Writes beyond it are dropped. Defaults to 1000.
+	QueueSize int `json:"queue_size" env:"WRITER_QUEUE_SIZE" default:"1000"`
+	// MaxAttempts is the number of attempts per write, including the first one. Defaults to 3.
+	MaxAttempts int `json:"max_attempts" env:"WRITER_MAX_ATTEMPTS" default:"3"`
+	// InitialBackoff and MaxBackoff bound the jittered exponential backoff between attempts.
+	InitialBackoff time.Duration `json:"initial_backoff" env:"WRITER_INITIAL_BACKOFF" default:"50ms"`
+	MaxBackoff     time.Duration `json:"max_backoff" env:"WRITER_MAX_BACKOFF" default:"1s"`
+	// AttemptTimeout bounds each attempt. Defaults to 2s.
+	AttemptTimeout time.Duration `json:"attempt_timeout" env:"WRITER_ATTEMPT_TIMEOUT" default:"2s"`
+}
+
+// Writer runs fire-and-forget writes (cache entries, audit records) in the background, detached from the
+// request that produced them.
+//
+// Writes run with the writer's own base context, so they are not canceled when the handler returns.
+// Failed writes are retried with backoff; writes that don't fit in the queue or exhaust their attempts are
+// dropped and counted in the "workers.writer.dropped" metric, labelled by writer and reason.
+type Writer struct {
+	cfg  WriterConfig
+	pool *Pool
+
+	dropped metric.Int64Counter
+	retries metric.Int64Counter
+}
+
+// NewWriter creates a Writer and starts its workers. Register Stop as a server stop hook to flush
+// pending writes on shutdown.
+//
+// Usage:
+//
+//	writer := workers.NewWriter(workers.WriterConfig{Name: "cache", QueueSize: 5000})
+//	srv.OnStop(writer.Stop)
+//
+//	cacheCfg := &httpclient.CacheConfig{RedisClient: redis, Writer: writer}
+func NewWriter(cfg WriterConfig) *Writer {
+	if cfg.Name == "" {
+		cfg.Name = "background-writer"
+	}
+	if cfg.Size <= 0 {
+		cfg.Size = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = 50 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Second
+	}
+	if cfg.AttemptTimeout <= 0 {
+		cfg.AttemptTimeout = 2 * time.Second
+	}
+
+	meter := otel.Meter(instrumentationName)
+	dropped, _ := meter.Int64Counter("workers.writer.dropped",
+		metric.WithDescription("Background writes dropped, by reason (queue_full, closed, exhausted)."))
+	retries, _ := meter.Int64Counter("workers.writer.retries",
+		metric.WithDescription("Background write attempts retried after a failure."))
+
+	w := &Writer{cfg: cfg, dropped: dropped, retries: retries}
+	w.pool = NewPool(Config{
+		Name:      cfg.Name,
+		Size:      cfg.Size,
+		QueueSize: cfg.QueueSize,
+		OnError:   w.exhausted,
+	})
+
+	queued, _ := meter.Int64ObservableGauge("workers.writer.queued",
+		metric.WithDescription("Background writes waiting for a worker."))
+	_, _ = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(queued, int64(w.pool.Pending()), metric.WithAttributes(attribute.String("writer", cfg.Name)))
+		return nil
+	}, queued)
+
+	return w
+}
+
+var (
+	defaultWriter     *Writer
+	defaultWriterOnce sync.Once
+)
+
+// DefaultWriter returns a process-wide Writer with the default configuration, created on first use.
+// It is used by the httpclient cache when no Writer is configured.
+func DefaultWriter() *Writer {
+	defaultWriterOnce.Do(func() {
+		defaultWriter = NewWriter(WriterConfig{Name: "default"})
+	})
+	return defaultWriter
+}
+
+// Write enqueues write without blocking. It reports false when the write was dropped because the queue
+// is full or the writer is stopped.
+func (w *Writer) Write(write Task) bool {
+	err := w.pool.TrySubmit(func(ctx context.Context) error {
+		return w.attempt(ctx, write)
+	})
+
+	switch err {
+	case nil:
+		return true
+	case ErrPoolClosed:
+		w.drop("closed")
+	default:
+		w.drop("queue_full")
+	}
+
+	return false
+}
+
+// Stop stops accepting writes and waits for the pending ones, like Pool.Stop.
+// Its signature matches server.Hook, so it can be registered with srv.OnStop.
+func (w *Writer) Stop(ctx context.Context) error {
+	return w.pool.Stop(ctx)
+}
+
+func (w *Writer) attempt(ctx context.Context, write Task) error {
+	var err error
+
+	for attempt := 1; attempt <= w.cfg.MaxAttempts; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, w.cfg.AttemptTimeout)
+		err = write(attemptCtx)
+		cancel()
+
+		if err == nil || attempt == w.cfg.MaxAttempts {
+			break
+		}
+
+		w.retries.Add(ctx, 1, metric.WithAttributes(attribute.String("writer", w.cfg.Name)))
+
+		select {
+		case <-time.After(w.backoff(attempt)):
+		case <-ctx.Done():
+			return err
+		}
+	}
+
+	return err
+}
+
+// backoff returns a jittered exponential delay for the given attempt.
+func (w *Writer) backoff(attempt int) time.Duration {
+	delay := w.cfg.InitialBackoff << (attempt - 1)
+	if delay <= 0 || delay > w.cfg.MaxBackoff {
+		delay = w.cfg.MaxBackoff
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func (w *Writer) exhausted(err error) {
+	w.drop("exhausted")
+	logger.Error().Err(err).Str("writer", w.cfg.Name).Msg("workers:background write dropped")
+}
+
+func (w *Writer) drop(reason string) {
+	w.dropped.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("writer", w.cfg.Name),
+		attribute.String("reason", reason),
+	))
+}